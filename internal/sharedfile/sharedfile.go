@@ -0,0 +1,123 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sharedfile updates files that are shared by every provider process on the machine, e.g. below the Pulumi
+// home directory or the temp directory when several stacks deploy at once. Writers take a lock and rename the new
+// contents into place, so that concurrent writers do not lose each other's updates and a crash cannot leave a file
+// half-written.
+package sharedfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// lockTimeout bounds the time spent waiting for another process to release a shared file.
+	lockTimeout = 30 * time.Second
+	// lockPoll is the time between attempts to take the lock on a shared file.
+	lockPoll = 50 * time.Millisecond
+	// stalePartialFileAge is the age after which partially written files are assumed to have been left behind by a
+	// process that crashed. Writes to shared files take milliseconds, so this is generous.
+	stalePartialFileAge = 10 * time.Second
+)
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// Lock takes an exclusive lock on the shared file at the given path, waiting for other processes to release it, and
+// returns a function that releases the lock. The lock is an OS file lock on a lock file beside the shared file, so the
+// OS releases it if its owner crashes. The lock file itself is never removed, as removing it would let two processes
+// lock different files of the same name.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	lockPath := path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = tryLock(lock)
+		if err == nil {
+			break
+		}
+		if err != errLocked || time.Now().After(deadline) {
+			_ = lock.Close()
+			if err == errLocked {
+				return nil, errors.Errorf("timed out waiting for the lock on %s", path)
+			}
+			return nil, errors.Wrapf(err, "locking %s", path)
+		}
+		time.Sleep(lockPoll)
+	}
+
+	return func() {
+		if err := unlock(lock); err != nil {
+			glog.V(5).Infof("failed to release lock %s: %v", lockPath, err)
+		}
+		if err := lock.Close(); err != nil {
+			glog.V(5).Infof("failed to close lock %s: %v", lockPath, err)
+		}
+	}, nil
+}
+
+// Write writes the given contents beside the shared file at the given path, then renames them into place, so that
+// readers never see a partially written file. Partially written files left behind by crashed writers are cleaned up
+// first.
+func Write(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	removeStalePartialFiles(path)
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.partial")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(contents); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// removeStalePartialFiles removes the partially written files that writers of the shared file at the given path left
+// behind when they crashed.
+func removeStalePartialFiles(path string) {
+	partials, err := filepath.Glob(path + ".*.partial")
+	if err != nil {
+		return
+	}
+	for _, partial := range partials {
+		stat, err := os.Stat(partial)
+		if err != nil || time.Since(stat.ModTime()) <= stalePartialFileAge {
+			continue
+		}
+		glog.V(5).Infof("removing partially written file %s", partial)
+		_ = os.Remove(partial)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharedfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared", "count")
+
+	// Concurrent read-modify-write cycles do not lose each other's updates.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer unlock()
+
+			count := 0
+			if contents, err := ioutil.ReadFile(path); err == nil {
+				count, _ = strconv.Atoi(string(contents))
+			}
+			assert.NoError(t, Write(path, []byte(strconv.Itoa(count+1))))
+		}()
+	}
+	wg.Wait()
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", string(contents))
+
+	// A held lock is not taken until it is released.
+	unlock, err := Lock(path)
+	assert.NoError(t, err)
+	locked := make(chan func())
+	go func() {
+		unlockNext, err := Lock(path)
+		assert.NoError(t, err)
+		locked <- unlockNext
+	}()
+	select {
+	case <-locked:
+		t.Fatal("took a held lock")
+	case <-time.After(4 * lockPoll):
+	}
+	unlock()
+	(<-locked)()
+
+	// Lock files and partially written files left behind by crashed processes do not get in the way.
+	stale := time.Now().Add(-2 * stalePartialFileAge)
+	partial := path + ".123.partial"
+	assert.NoError(t, ioutil.WriteFile(partial, nil, 0600))
+	assert.NoError(t, os.Chtimes(partial, stale, stale))
+	assert.FileExists(t, path+".lock")
+	unlock, err = Lock(path)
+	assert.NoError(t, err)
+	assert.NoError(t, Write(path, []byte("11")))
+	unlock()
+	assert.NoFileExists(t, partial)
+}
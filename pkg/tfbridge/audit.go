@@ -0,0 +1,170 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// auditLogConfigKey is the bridge-level configuration key that holds the path of the operation audit log. The key is
+// only interpreted by the bridge if the upstream provider does not define configuration with the same name.
+const auditLogConfigKey = "auditLogPath"
+
+// auditEntry is a single line in the operation audit log.
+type auditEntry struct {
+	Time       string `json:"time"`
+	Operation  string `json:"operation"`
+	URN        string `json:"urn"`
+	Token      string `json:"token"`
+	DurationMs int64  `json:"durationMs"`
+	Result     string `json:"result"`
+	Error      string `json:"error,omitempty"`
+	InputHash  string `json:"inputHash,omitempty"`
+}
+
+// auditLog appends a JSON line to its writer for every CRUD operation performed by the provider.
+type auditLog struct {
+	m sync.Mutex
+	w io.WriteCloser
+}
+
+// openAuditLog opens the audit log at the given path for appending, creating it if necessary.
+func openAuditLog(path string) (*auditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{w: f}, nil
+}
+
+func (l *auditLog) write(entry auditEntry) error {
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	bytes = append(bytes, '\n')
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	_, err = l.w.Write(bytes)
+	return err
+}
+
+func (l *auditLog) close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return l.w.Close()
+}
+
+// configureAuditLog removes the audit log path from vars and, if one was set, opens the audit log it names. Providers
+// that do not set ProviderInfo.AuditLog leave the key to the upstream provider.
+func (p *Provider) configureAuditLog(vars resource.PropertyMap) error {
+	if !p.info.AuditLog {
+		return nil
+	}
+	path, ok := p.takeBridgeConfig(vars, auditLogConfigKey)
+	if !ok || path == "" {
+		return nil
+	}
+
+	log, err := openAuditLog(path)
+	if err != nil {
+		return errors.Wrapf(err, "opening audit log for '%v'", auditLogConfigKey)
+	}
+	if p.auditLog != nil {
+		if err = p.auditLog.close(); err != nil {
+			glog.V(5).Infof("failed to close previous audit log: %v", err)
+		}
+	}
+	p.auditLog = log
+	return nil
+}
+
+// auditOperation records the outcome of a CRUD operation in the audit log, if one is configured. It must be called
+// via defer, with err pointing at the operation's named error result.
+func (p *Provider) auditOperation(operation string, urn resource.URN, inputs *pbstruct.Struct, start time.Time,
+	err *error) {
+
+	if p.auditLog == nil {
+		return
+	}
+
+	entry := auditEntry{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		Operation:  operation,
+		URN:        string(urn),
+		Token:      string(urn.Type()),
+		DurationMs: time.Since(start).Milliseconds(),
+		Result:     "ok",
+		InputHash:  hashAuditInputs(inputs),
+	}
+	if *err != nil {
+		entry.Result, entry.Error = "error", (*err).Error()
+	}
+
+	if werr := p.auditLog.write(entry); werr != nil {
+		glog.V(5).Infof("failed to write audit log entry: %v", werr)
+	}
+}
+
+// hashAuditInputs returns a stable hash of the given inputs with the values of any secrets elided, so that the
+// audit log can be used to correlate operations without leaking their contents.
+func hashAuditInputs(inputs *pbstruct.Struct) string {
+	if inputs == nil {
+		return ""
+	}
+	props, err := plugin.UnmarshalProperties(inputs, plugin.MarshalOptions{
+		KeepUnknowns: true,
+		KeepSecrets:  true,
+		SkipNulls:    true,
+	})
+	if err != nil {
+		return ""
+	}
+
+	sanitized := props.MapRepl(nil, func(v resource.PropertyValue) (interface{}, bool) {
+		switch {
+		case v.IsSecret():
+			return "[secret]", true
+		case v.IsComputed() || v.IsOutput():
+			return "[unknown]", true
+		}
+		return nil, false
+	})
+
+	// encoding/json sorts map keys, so the result is stable across runs.
+	bytes, err := json.Marshal(sanitized)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:])
+}
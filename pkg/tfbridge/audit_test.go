@@ -0,0 +1,95 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logs", "audit.jsonl")
+
+	vars := resource.PropertyMap{
+		"auditLogPath": resource.NewStringProperty(path),
+		"region":       resource.NewStringProperty("us-west-2"),
+	}
+
+	// Providers that do not opt in leave the key alone.
+	p := &Provider{config: schemaMap(nil)}
+	assert.NoError(t, p.configureAuditLog(vars))
+	assert.Nil(t, p.auditLog)
+	assert.Contains(t, vars, resource.PropertyKey("auditLogPath"))
+
+	p.info.AuditLog = true
+	assert.NoError(t, p.configureAuditLog(vars))
+	assert.Equal(t, resource.PropertyMap{"region": resource.NewStringProperty("us-west-2")}, vars)
+
+	props := func(password string) resource.PropertyMap {
+		return resource.PropertyMap{
+			"name":     resource.NewStringProperty("widget"),
+			"password": resource.MakeSecret(resource.NewStringProperty(password)),
+		}
+	}
+	inputs1, err := plugin.MarshalProperties(props("hunter2"), plugin.MarshalOptions{KeepSecrets: true})
+	assert.NoError(t, err)
+	inputs2, err := plugin.MarshalProperties(props("correct horse"), plugin.MarshalOptions{KeepSecrets: true})
+	assert.NoError(t, err)
+
+	urn := resource.NewURN("stack", "project", "", "test:index/widget:Widget", "w")
+	var ok error
+	p.auditOperation("Create", urn, inputs1, time.Now(), &ok)
+	failed := errors.New("boom")
+	p.auditOperation("Update", urn, inputs2, time.Now(), &failed)
+	assert.NoError(t, p.auditLog.close())
+
+	bytes, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(bytes)), "\n")
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+	var create, update auditEntry
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &create))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &update))
+
+	assert.Equal(t, "Create", create.Operation)
+	assert.Equal(t, string(urn), create.URN)
+	assert.Equal(t, "test:index/widget:Widget", create.Token)
+	assert.Equal(t, "ok", create.Result)
+	assert.Empty(t, create.Error)
+
+	assert.Equal(t, "Update", update.Operation)
+	assert.Equal(t, "error", update.Result)
+	assert.Equal(t, "boom", update.Error)
+
+	// Secret values do not contribute to the input hash, and never appear in the log.
+	assert.NotEmpty(t, create.InputHash)
+	assert.Equal(t, create.InputHash, update.InputHash)
+	assert.NotContains(t, string(bytes), "hunter2")
+}
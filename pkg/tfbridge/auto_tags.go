@@ -0,0 +1,151 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// The bridge-level configuration keys that add a set of tags to every resource that has a tags-like property, so that
+// programs do not need a transformation to tag each resource. Both take JSON values. These keys are only interpreted
+// by the bridge if the upstream provider does not define configuration with the same name.
+const (
+	autoTagsConfigKey          = "autoTags"          // an object of the tags to add, e.g. {"team": "infra"}.
+	autoTagPropertiesConfigKey = "autoTagProperties" // an array of patterns that match tags-like properties.
+)
+
+// defaultAutoTagProperties match the Pulumi names of the properties that most providers use for tags.
+var defaultAutoTagProperties = []string{"tags", "labels"}
+
+// autoTags are the tags that are added to the tags-like properties of every resource.
+type autoTags struct {
+	tags     map[string]string
+	patterns []string // path.Match patterns that match the Pulumi names of tags-like properties.
+}
+
+// configureAutoTags removes the automatic tagging settings from vars and, if any tags were set, adds them to every
+// resource that has a tags-like property from now on. Providers that do not set ProviderInfo.AutoTags leave the keys to
+// the upstream provider.
+func (p *Provider) configureAutoTags(vars resource.PropertyMap) error {
+	if !p.info.AutoTags {
+		return nil
+	}
+	tagsValue, hasTags := p.takeBridgeConfig(vars, autoTagsConfigKey)
+	patternsValue, hasPatterns := p.takeBridgeConfig(vars, autoTagPropertiesConfigKey)
+	if !hasTags || tagsValue == "" {
+		return nil
+	}
+
+	auto := &autoTags{patterns: defaultAutoTagProperties}
+	if err := json.Unmarshal([]byte(tagsValue), &auto.tags); err != nil {
+		return errors.Errorf("malformed configuration value for '%v': must be a JSON object of strings",
+			autoTagsConfigKey)
+	}
+	if hasPatterns && patternsValue != "" {
+		// Unmarshal into a fresh slice, as decoding into auto.patterns would overwrite the defaults in place.
+		var patterns []string
+		if err := json.Unmarshal([]byte(patternsValue), &patterns); err != nil {
+			return errors.Errorf("malformed configuration value for '%v': must be a JSON array of strings",
+				autoTagPropertiesConfigKey)
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return errors.Errorf("malformed pattern %q in '%v': %v", pattern, autoTagPropertiesConfigKey, err)
+			}
+		}
+		auto.patterns = patterns
+	}
+
+	if len(auto.tags) == 0 {
+		auto = nil
+	}
+	p.autoTags = auto
+	return nil
+}
+
+// isTagsProperty returns true if the given top-level property can hold tags, i.e. it is an input map of strings.
+func isTagsProperty(sch shim.Schema) bool {
+	if sch == nil || sch.Type() != shim.TypeMap || !(sch.Optional() || sch.Required()) {
+		return false
+	}
+	switch elem := sch.Elem().(type) {
+	case nil:
+		return true
+	case shim.Schema:
+		return elem.Type() == shim.TypeString
+	default:
+		return false
+	}
+}
+
+// applyAutoTags adds the automatic tags to each tags-like property of the given resource inputs. Tags that the inputs
+// set explicitly take precedence, and properties whose values are not yet known are left alone.
+func (p *Provider) applyAutoTags(res Resource, news resource.PropertyMap) resource.PropertyMap {
+	if p.autoTags == nil || res.TF == nil {
+		return news
+	}
+	var fields map[string]*SchemaInfo
+	if res.Schema != nil {
+		fields = res.Schema.Fields
+	}
+
+	result := news.Copy()
+	res.TF.Schema().Range(func(tfName string, sch shim.Schema) bool {
+		key, _, info := getInfoFromTerraformName(tfName, res.TF.Schema(), fields, false)
+		if !isTagsProperty(sch) || (info != nil && info.Omit) || !p.autoTags.matches(string(key)) {
+			return true
+		}
+
+		v, has := result[key]
+		switch {
+		case !has || v.IsNull():
+			result[key] = resource.NewObjectProperty(p.autoTags.merge(resource.PropertyMap{}))
+		case v.IsObject():
+			result[key] = resource.NewObjectProperty(p.autoTags.merge(v.ObjectValue()))
+		case v.IsSecret() && v.SecretValue().Element.IsObject():
+			merged := p.autoTags.merge(v.SecretValue().Element.ObjectValue())
+			result[key] = resource.MakeSecret(resource.NewObjectProperty(merged))
+		}
+		return true
+	})
+	return result
+}
+
+// matches returns true if the property with the given Pulumi name is tags-like.
+func (a *autoTags) matches(name string) bool {
+	for _, pattern := range a.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns a copy of the given tags with the automatic tags that they do not set added.
+func (a *autoTags) merge(tags resource.PropertyMap) resource.PropertyMap {
+	result := tags.Copy()
+	for k, v := range a.tags {
+		if _, has := result[resource.PropertyKey(k)]; !has {
+			result[resource.PropertyKey(k)] = resource.NewStringProperty(v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestConfigureAutoTags(t *testing.T) {
+	optedIn := ProviderInfo{AutoTags: true}
+	p := &Provider{info: optedIn}
+	vars := resource.PropertyMap{
+		"autoTags": resource.NewStringProperty(`{"team": "infra", "env": "prod"}`),
+		"region":   resource.NewStringProperty("us-west-2"),
+	}
+	assert.NoError(t, p.configureAutoTags(vars))
+	if assert.NotNil(t, p.autoTags) {
+		assert.Equal(t, map[string]string{"team": "infra", "env": "prod"}, p.autoTags.tags)
+		assert.Equal(t, []string{"tags", "labels"}, p.autoTags.patterns)
+	}
+	assert.Equal(t, resource.PropertyMap{"region": resource.NewStringProperty("us-west-2")}, vars)
+
+	p = &Provider{info: optedIn}
+	assert.NoError(t, p.configureAutoTags(resource.PropertyMap{
+		"autoTags":          resource.NewStringProperty(`{"team": "infra"}`),
+		"autoTagProperties": resource.NewStringProperty(`["*Tags"]`),
+	}))
+	if assert.NotNil(t, p.autoTags) {
+		assert.True(t, p.autoTags.matches("resourceTags"))
+		assert.False(t, p.autoTags.matches("tags"))
+	}
+
+	p = &Provider{info: optedIn}
+	assert.NoError(t, p.configureAutoTags(resource.PropertyMap{"autoTags": resource.NewStringProperty(`{}`)}))
+	assert.Nil(t, p.autoTags)
+
+	for _, vars := range []resource.PropertyMap{
+		{"autoTags": resource.NewStringProperty(`["team"]`)},
+		{"autoTags": resource.NewStringProperty(`{"team": "infra"}`),
+			"autoTagProperties": resource.NewStringProperty(`"tags"`)},
+		{"autoTags": resource.NewStringProperty(`{"team": "infra"}`),
+			"autoTagProperties": resource.NewStringProperty(`["[tags"]`)},
+	} {
+		assert.Error(t, (&Provider{info: optedIn}).configureAutoTags(vars))
+	}
+
+	// Providers that do not opt in leave the keys alone.
+	p = &Provider{}
+	vars = resource.PropertyMap{"autoTags": resource.NewStringProperty(`{"team": "infra"}`)}
+	assert.NoError(t, p.configureAutoTags(vars))
+	assert.Nil(t, p.autoTags)
+	assert.Contains(t, vars, resource.PropertyKey("autoTags"))
+}
+
+func TestApplyAutoTags(t *testing.T) {
+	res := Resource{
+		TF: (&schema.Resource{Schema: schemaMap(map[string]*schema.Schema{
+			"name":        {Type: shim.TypeString, Optional: true},
+			"tags":        {Type: shim.TypeMap, Optional: true, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()},
+			"tags_all":    {Type: shim.TypeMap, Computed: true, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()},
+			"labels":      {Type: shim.TypeMap, Optional: true, Elem: (&schema.Schema{Type: shim.TypeInt}).Shim()},
+			"annotations": {Type: shim.TypeMap, Optional: true},
+		})}).Shim(),
+		Schema: &ResourceInfo{Fields: map[string]*SchemaInfo{"annotations": {Name: "labels"}}},
+	}
+	p := &Provider{autoTags: &autoTags{
+		tags:     map[string]string{"team": "infra", "env": "prod"},
+		patterns: defaultAutoTagProperties,
+	}}
+
+	// Explicit tags take precedence over the automatic ones, and properties that cannot hold tags are left alone.
+	news := resource.PropertyMap{
+		"name": resource.NewStringProperty("web"),
+		"tags": resource.NewObjectProperty(resource.PropertyMap{"env": resource.NewStringProperty("dev")}),
+	}
+	result := p.applyAutoTags(res, news)
+	assert.Equal(t, resource.PropertyMap{
+		"name": resource.NewStringProperty("web"),
+		"tags": resource.NewObjectProperty(resource.PropertyMap{
+			"env":  resource.NewStringProperty("dev"),
+			"team": resource.NewStringProperty("infra"),
+		}),
+		"labels": resource.NewObjectProperty(resource.PropertyMap{
+			"env":  resource.NewStringProperty("prod"),
+			"team": resource.NewStringProperty("infra"),
+		}),
+	}, result)
+	assert.Len(t, news["tags"].ObjectValue(), 1)
+
+	// Secret tags stay secret, and unknown tags are left for the engine to resolve.
+	result = p.applyAutoTags(res, resource.PropertyMap{
+		"tags":   resource.MakeSecret(resource.NewObjectProperty(resource.PropertyMap{})),
+		"labels": resource.MakeComputed(resource.NewStringProperty("")),
+	})
+	assert.True(t, result["tags"].IsSecret())
+	assert.Len(t, result["tags"].SecretValue().Element.ObjectValue(), 2)
+	assert.True(t, result["labels"].IsComputed())
+
+	p.autoTags = nil
+	assert.Equal(t, news, p.applyAutoTags(res, news))
+}
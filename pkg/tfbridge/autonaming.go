@@ -0,0 +1,269 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// autonamingConfigKey is the bridge-level configuration key that controls how the provider populates autonamed
+// properties, e.g. `verbatim`. The key is only interpreted by the bridge if the upstream provider does not define
+// configuration with the same name.
+const autonamingConfigKey = "autonaming"
+
+const (
+	defaultAutoNamingField        = "name"
+	defaultAutoNamingSeparator    = "-"
+	defaultAutoNamingRandomLength = 7
+)
+
+// AutonamingMode is how a program's configuration asks the provider to populate autonamed properties.
+type AutonamingMode string
+
+const (
+	// AutonamingDefault appends random characters to the resource's name, so that replacements can be created before
+	// the resources they replace are deleted.
+	AutonamingDefault AutonamingMode = ""
+	// AutonamingVerbatim uses the resource's name as is, without random characters.
+	AutonamingVerbatim AutonamingMode = "verbatim"
+	// AutonamingDisabled leaves autonamed properties unset, so that programs must set them.
+	AutonamingDisabled AutonamingMode = "disabled"
+)
+
+// AutoNameCasing is the casing that an autonamed property's value is converted to.
+type AutoNameCasing string
+
+const (
+	// AutoNameCasingAsIs leaves the casing of the resource's name as it is.
+	AutoNameCasingAsIs AutoNameCasing = ""
+	// AutoNameCasingLower converts autonamed values to lower case.
+	AutoNameCasingLower AutoNameCasing = "lower"
+	// AutoNameCasingUpper converts autonamed values to upper case.
+	AutoNameCasingUpper AutoNameCasing = "upper"
+)
+
+// AutoNamingInfo describes how a resource's name property is populated from the resource's Pulumi name when a program
+// does not set it. It replaces hand-written SchemaInfo defaults built with AutoName and its variants, so that every
+// autonamed resource follows the same rules and has them described in its docs.
+type AutoNamingInfo struct {
+	// the Terraform attribute that is autonamed (default "name").
+	Field string
+	// the separator between the resource's name and the random characters (default "-").
+	Separator string
+	// the maximum length of the generated value, or 0 for no limit.
+	MaxLength int
+	// the number of random characters appended to the resource's name (default 7), or a negative number for none.
+	RandomLength int
+	// the casing that the generated value is converted to.
+	Casing AutoNameCasing
+}
+
+// GetField returns the Terraform attribute that is autonamed.
+func (info *AutoNamingInfo) GetField() string {
+	if info.Field == "" {
+		return defaultAutoNamingField
+	}
+	return info.Field
+}
+
+// GetSeparator returns the separator between the resource's name and the random characters.
+func (info *AutoNamingInfo) GetSeparator() string {
+	if info.Separator == "" {
+		return defaultAutoNamingSeparator
+	}
+	return info.Separator
+}
+
+// GetRandomLength returns the number of random characters appended to the resource's name.
+func (info *AutoNamingInfo) GetRandomLength() int {
+	switch {
+	case info.RandomLength == 0:
+		return defaultAutoNamingRandomLength
+	case info.RandomLength < 0:
+		return 0
+	}
+	return info.RandomLength
+}
+
+// Validate returns an error if the info is malformed.
+func (info *AutoNamingInfo) Validate() error {
+	switch info.Casing {
+	case AutoNameCasingAsIs, AutoNameCasingLower, AutoNameCasingUpper:
+	default:
+		return errors.Errorf("unknown casing %q", info.Casing)
+	}
+	if info.MaxLength < 0 {
+		return errors.New("MaxLength must not be negative")
+	}
+	if info.MaxLength > 0 && info.MaxLength <= len(info.GetSeparator())+info.GetRandomLength() {
+		return errors.Errorf("MaxLength %d leaves no room for the resource's name", info.MaxLength)
+	}
+	return nil
+}
+
+// schemaInfo returns the info of the autonamed property, based on the given existing info, if any.
+func (info *AutoNamingInfo) schemaInfo(existing *SchemaInfo) *SchemaInfo {
+	field := SchemaInfo{}
+	if existing != nil {
+		field = *existing
+	}
+	field.Default = &DefaultInfo{
+		AutoNamed: true,
+		From: FromName(AutoNameOptions{
+			Separator: info.GetSeparator(),
+			Maxlen:    info.MaxLength,
+			Randlen:   info.GetRandomLength(),
+			PostTransform: func(_ *PulumiResource, name string) (string, error) {
+				switch info.Casing {
+				case AutoNameCasingLower:
+					return strings.ToLower(name), nil
+				case AutoNameCasingUpper:
+					return strings.ToUpper(name), nil
+				}
+				return name, nil
+			},
+		}),
+	}
+	return &field
+}
+
+// Describe returns a sentence that documents how the autonamed property is populated.
+func (info *AutoNamingInfo) Describe() string {
+	var b strings.Builder
+	b.WriteString("If not set, this is generated from the resource's name")
+	if n := info.GetRandomLength(); n > 0 {
+		fmt.Fprintf(&b, ", followed by `%s` and %d random characters", info.GetSeparator(), n)
+	}
+	switch info.Casing {
+	case AutoNameCasingLower:
+		b.WriteString(", in lower case")
+	case AutoNameCasingUpper:
+		b.WriteString(", in upper case")
+	}
+	if info.MaxLength > 0 {
+		fmt.Fprintf(&b, ", and is at most %d characters long", info.MaxLength)
+	}
+	b.WriteString(". The provider's `autonaming` configuration can use the name verbatim or disable generation.")
+	return b.String()
+}
+
+// ApplyAutoNaming returns the info for the given resource with its autonamed property configured by the resource's
+// AutoNaming, or by the provider-wide defaults if the resource has none. The property is only autonamed if it is an
+// input and its info does not already set a default; resources whose property is not autonamed are returned as is.
+// The given info is copied rather than modified, and the copy's AutoNaming is set to the rules that were applied.
+//
+// Both tfgen and the provider apply this rule, so the schema and the provider always agree on the autonamed
+// properties.
+func ApplyAutoNaming(res shim.Resource, info *ResourceInfo, defaults *AutoNamingInfo) *ResourceInfo {
+	autoNaming := defaults
+	if info != nil && info.AutoNaming != nil {
+		autoNaming = info.AutoNaming
+	}
+	if res == nil || autoNaming == nil {
+		return info
+	}
+	field := autoNaming.GetField()
+	sch, ok := res.Schema().GetOk(field)
+	if !ok || sch.Removed() != "" || !(sch.Optional() || sch.Required()) {
+		return info
+	}
+	var fields map[string]*SchemaInfo
+	if info != nil {
+		fields = info.Fields
+	}
+	if existing := fields[field]; existing != nil && (existing.HasDefault() || existing.Omit) {
+		return info
+	}
+
+	named := ResourceInfo{}
+	if info != nil {
+		named = *info
+	}
+	named.AutoNaming = autoNaming
+	named.Fields = make(map[string]*SchemaInfo, len(fields)+1)
+	for k, v := range fields {
+		named.Fields[k] = v
+	}
+	named.Fields[field] = autoNaming.schemaInfo(fields[field])
+	return &named
+}
+
+// autonames returns true if any of the provider's resources has an autonamed property, either through AutoNaming or
+// through a SchemaInfo default such as AutoName.
+func (info *ProviderInfo) autonames() bool {
+	if info.AutoNaming != nil {
+		return true
+	}
+	for _, res := range info.Resources {
+		if res == nil {
+			continue
+		}
+		if res.AutoNaming != nil {
+			return true
+		}
+		for _, field := range res.Fields {
+			if field != nil && field.HasDefault() && field.Default.AutoNamed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// configureAutonaming removes the bridge-level autonaming configuration from vars and records its mode. Providers
+// without autonamed properties leave the key to the upstream provider.
+func (p *Provider) configureAutonaming(vars resource.PropertyMap) error {
+	if !p.info.autonames() {
+		return nil
+	}
+	value, ok := p.takeBridgeConfig(vars, autonamingConfigKey)
+	if !ok {
+		return nil
+	}
+	switch mode := AutonamingMode(value); mode {
+	case AutonamingDefault, "default":
+		p.autonaming = AutonamingDefault
+	case AutonamingVerbatim, AutonamingDisabled:
+		p.autonaming = mode
+	default:
+		return errors.Errorf("malformed configuration value for '%v': must be one of 'default', 'verbatim' or "+
+			"'disabled'", autonamingConfigKey)
+	}
+	return nil
+}
+
+// verbatimNameRequiresDeleteBeforeReplace returns true if the provider populates autonamed properties verbatim and the
+// given inputs include an autonamed property. The replacement of such a resource is given the same name as the
+// resource it replaces, so it can only be created once the original has been deleted.
+func (p *Provider) verbatimNameRequiresDeleteBeforeReplace(inputs resource.PropertyMap,
+	tfs shim.SchemaMap, ps map[string]*SchemaInfo) bool {
+
+	if p.autonaming != AutonamingVerbatim {
+		return false
+	}
+	for key := range inputs {
+		_, _, psi := getInfoFromPulumiName(key, tfs, ps, false)
+		if psi != nil && psi.HasDefault() && psi.Default.AutoNamed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestApplyAutoNaming(t *testing.T) {
+	res := (&schema.Resource{Schema: schemaMap(map[string]*schema.Schema{
+		"name":  {Type: shim.TypeString, Optional: true},
+		"title": {Type: shim.TypeString, Optional: true},
+		"arn":   {Type: shim.TypeString, Computed: true},
+	})}).Shim()
+	defaults := &AutoNamingInfo{MaxLength: 20, Casing: AutoNameCasingLower}
+
+	// The provider-wide defaults apply to the name property, without modifying the original info.
+	info := &ResourceInfo{Tok: "test:index/widget:Widget"}
+	named := ApplyAutoNaming(res, info, defaults)
+	assert.Equal(t, defaults, named.AutoNaming)
+	assert.True(t, named.Fields["name"].Default.AutoNamed)
+	assert.Nil(t, info.Fields)
+
+	widget := &PulumiResource{URN: "urn:pulumi:s::p::test:index/widget:Widget::MyWidget"}
+	v, err := named.Fields["name"].Default.From(widget)
+	assert.NoError(t, err)
+	assert.Regexp(t, "^mywidget-[0-9a-f]{7}$", v)
+
+	// Resources can autoname another property, and keep the rest of the property's info.
+	info = &ResourceInfo{
+		AutoNaming: &AutoNamingInfo{Field: "title", RandomLength: -1},
+		Fields:     map[string]*SchemaInfo{"title": {Name: "displayName"}},
+	}
+	named = ApplyAutoNaming(res, info, defaults)
+	assert.Equal(t, "displayName", named.Fields["title"].Name)
+	assert.Nil(t, named.Fields["name"])
+	v, err = named.Fields["title"].Default.From(widget)
+	assert.NoError(t, err)
+	assert.Equal(t, "MyWidget", v)
+
+	// Properties with their own defaults, and properties that are not inputs, are left alone.
+	info = &ResourceInfo{Fields: map[string]*SchemaInfo{"name": {Default: &DefaultInfo{Value: "fixed"}}}}
+	assert.Equal(t, info, ApplyAutoNaming(res, info, defaults))
+	info = &ResourceInfo{AutoNaming: &AutoNamingInfo{Field: "arn"}}
+	assert.Equal(t, info, ApplyAutoNaming(res, info, defaults))
+
+	assert.Equal(t, "If not set, this is generated from the resource's name, followed by `-` and 7 random "+
+		"characters, in lower case, and is at most 20 characters long. The provider's `autonaming` configuration "+
+		"can use the name verbatim or disable generation.", defaults.Describe())
+	assert.Error(t, (&AutoNamingInfo{MaxLength: 8}).Validate())
+	assert.Error(t, (&AutoNamingInfo{Casing: "title"}).Validate())
+}
+
+func TestAutonamingConfig(t *testing.T) {
+	const urn = "urn:pulumi:stack::project::test:index:Widget::MyWidget"
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {Schema: map[string]*schemav2.Schema{
+				"name": {Type: schemav2.TypeString, Optional: true},
+			}},
+		},
+	})
+	info := ProviderInfo{
+		Name:       "test",
+		Resources:  map[string]*ResourceInfo{"test_widget": {Tok: "test:index:Widget"}},
+		AutoNaming: &AutoNamingInfo{Casing: AutoNameCasingUpper},
+	}
+
+	check := func(mode string) (resource.PropertyMap, error) {
+		p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+		vars := map[string]string{}
+		if mode != "" {
+			vars["test:config:autonaming"] = mode
+		}
+		if _, err := p.Configure(context.Background(), &pulumirpc.ConfigureRequest{Variables: vars}); err != nil {
+			return nil, err
+		}
+		news, err := plugin.MarshalProperties(resource.PropertyMap{}, plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		checked, err := p.Check(context.Background(), &pulumirpc.CheckRequest{Urn: urn, News: news})
+		assert.NoError(t, err)
+		return plugin.UnmarshalProperties(checked.GetInputs(), plugin.MarshalOptions{})
+	}
+
+	inputs, err := check("")
+	assert.NoError(t, err)
+	assert.Regexp(t, "^MYWIDGET-[0-9A-F]{7}$", inputs["name"].StringValue())
+
+	inputs, err = check("verbatim")
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("MYWIDGET"), inputs["name"])
+
+	inputs, err = check("disabled")
+	assert.NoError(t, err)
+	assert.NotContains(t, inputs, resource.PropertyKey("name"))
+
+	_, err = check("random")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed configuration value for 'autonaming'")
+}
+
+func TestAutonamingVerbatimReplace(t *testing.T) {
+	const urn = "urn:pulumi:stack::project::test:index:Widget::MyWidget"
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {Schema: map[string]*schemav2.Schema{
+				"name": {Type: schemav2.TypeString, Optional: true, ForceNew: true},
+				"size": {Type: schemav2.TypeInt, Optional: true, ForceNew: true},
+			}},
+		},
+	})
+	info := ProviderInfo{
+		Name:       "test",
+		Resources:  map[string]*ResourceInfo{"test_widget": {Tok: "test:index:Widget"}},
+		AutoNaming: &AutoNamingInfo{},
+	}
+
+	// Replacing the widget by changing its size keeps its autonamed name.
+	replace := func(mode string) *pulumirpc.DiffResponse {
+		p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+		_, err := p.Configure(context.Background(), &pulumirpc.ConfigureRequest{
+			Variables: map[string]string{"test:config:autonaming": mode}})
+		assert.NoError(t, err)
+
+		check := func(olds *pbstruct.Struct, size float64) *pbstruct.Struct {
+			news, err := plugin.MarshalProperties(resource.PropertyMap{"size": resource.NewNumberProperty(size)},
+				plugin.MarshalOptions{})
+			assert.NoError(t, err)
+			checked, err := p.Check(context.Background(), &pulumirpc.CheckRequest{Urn: urn, Olds: olds, News: news})
+			assert.NoError(t, err)
+			return checked.GetInputs()
+		}
+		olds := check(nil, 1)
+		news := check(olds, 2)
+		diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+			Urn: urn, Id: "MyWidget", Olds: olds, News: news})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"size"}, diff.GetReplaces())
+		return diff
+	}
+
+	// Names with random characters do not collide, so the replacement is created first.
+	assert.False(t, replace("default").GetDeleteBeforeReplace())
+	// Verbatim names would collide, so the original is deleted first.
+	assert.True(t, replace("verbatim").GetDeleteBeforeReplace())
+}
@@ -0,0 +1,138 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+const (
+	defaultAwaitOutputTimeout  = 10 * time.Minute
+	defaultAwaitOutputInterval = 2 * time.Second
+	maxAwaitOutputInterval     = 30 * time.Second
+)
+
+// outputReady returns true if the given output satisfies the declaration's condition.
+func (await AwaitOutput) outputReady(props resource.PropertyMap) bool {
+	v, ok := props[resource.PropertyKey(await.Property)]
+	if await.Condition != nil {
+		if !ok {
+			v = resource.NewNullProperty()
+		}
+		return await.Condition(v)
+	}
+	switch {
+	case !ok || v.IsNull() || v.IsComputed():
+		return false
+	case v.IsString():
+		return v.StringValue() != ""
+	case v.IsArray():
+		return len(v.ArrayValue()) > 0
+	case v.IsObject():
+		return len(v.ObjectValue()) > 0
+	}
+	return true
+}
+
+// awaitOutputs reads the newly created resource with the given state until the outputs that it awaits are ready. It
+// returns the latest state of the resource, along with an error if the outputs were not ready in time.
+func (p *Provider) awaitOutputs(ctx context.Context, urn resource.URN, res Resource,
+	state shim.InstanceState) (shim.InstanceState, error) {
+
+	if res.Schema == nil || len(res.Schema.AwaitOutputs) == 0 {
+		return state, nil
+	}
+
+	var wait, interval time.Duration
+	for _, await := range res.Schema.AwaitOutputs {
+		timeout := await.Timeout
+		if timeout == 0 {
+			timeout = defaultAwaitOutputTimeout
+		}
+		if timeout > wait {
+			wait = timeout
+		}
+		if await.Interval != 0 && (interval == 0 || await.Interval < interval) {
+			interval = await.Interval
+		}
+	}
+	if interval == 0 {
+		interval = defaultAwaitOutputInterval
+	}
+
+	// pending returns the outputs of the given state that are not ready yet.
+	start := time.Now()
+	pending := func(state shim.InstanceState) ([]string, error) {
+		props, err := MakeTerraformResult(p.tf, state, res.TF.Schema(), res.Schema.Fields, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, await := range res.Schema.AwaitOutputs {
+			timeout := await.Timeout
+			if timeout == 0 {
+				timeout = defaultAwaitOutputTimeout
+			}
+			if !await.outputReady(props) {
+				if time.Since(start) > timeout {
+					return nil, errors.Errorf("output %s of %s was not ready %v after it was created",
+						await.Property, urn, timeout)
+				}
+				names = append(names, await.Property)
+			}
+		}
+		return names, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	for {
+		names, err := pending(state)
+		if err != nil || len(names) == 0 {
+			return state, err
+		}
+		glog.V(9).Infof("%s: waiting %v for outputs %s", urn, interval, strings.Join(names, ", "))
+
+		select {
+		case <-ctx.Done():
+			return state, errors.Errorf("outputs %s of %s were not ready %v after it was created",
+				strings.Join(names, ", "), urn, wait)
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxAwaitOutputInterval {
+			interval = maxAwaitOutputInterval
+		}
+
+		if err = p.waitForRateLimit(ctx); err != nil {
+			return state, err
+		}
+		latest, err := p.readResource(ctx, res, state)
+		switch {
+		case err != nil:
+			return state, errors.Wrapf(err, "reading %s while waiting for its outputs", urn)
+		case latest == nil || latest.ID() == "":
+			return state, errors.Errorf("%s disappeared while waiting for its outputs", urn)
+		}
+		state = latest
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestAwaitOutputs(t *testing.T) {
+	const urn = "urn:pulumi:stack::project::test:index:Cluster::c"
+
+	// The cluster is provisioning when it is created, and is assigned an endpoint on the second read after that.
+	reads := 0
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_cluster": {
+				Schema: map[string]*schemav2.Schema{
+					"name":     {Type: schemav2.TypeString, Required: true, ForceNew: true},
+					"status":   {Type: schemav2.TypeString, Computed: true},
+					"endpoint": {Type: schemav2.TypeString, Computed: true},
+				},
+				Create: func(d *schemav2.ResourceData, _ interface{}) error {
+					reads = 0
+					d.SetId(d.Get("name").(string))
+					return d.Set("status", "PROVISIONING")
+				},
+				Read: func(d *schemav2.ResourceData, _ interface{}) error {
+					if reads++; reads >= 2 {
+						if err := d.Set("endpoint", "https://c.example.com"); err != nil {
+							return err
+						}
+						return d.Set("status", "READY")
+					}
+					return nil
+				},
+				Delete: func(d *schemav2.ResourceData, _ interface{}) error { return nil },
+			},
+		},
+	})
+	await := []AwaitOutput{
+		{Property: "endpoint", Interval: time.Millisecond},
+		{Property: "status", Condition: AwaitValues("READY"), Interval: time.Millisecond},
+	}
+	info := ProviderInfo{
+		Name:      "test",
+		Resources: map[string]*ResourceInfo{"test_cluster": {Tok: "test:index:Cluster", AwaitOutputs: await}},
+	}
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+	props, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name": resource.NewStringProperty("c"),
+	}, plugin.MarshalOptions{})
+	assert.NoError(t, err)
+
+	// Create reads the resource until its outputs are ready.
+	created, err := p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: props})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reads)
+	outs, err := plugin.UnmarshalProperties(created.GetProperties(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("https://c.example.com"), outs["endpoint"])
+	assert.Equal(t, resource.NewStringProperty("READY"), outs["status"])
+
+	// Previews do not wait.
+	reads = 0
+	_, err = p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: props, Preview: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reads)
+
+	// Outputs that are not ready in time fail the create, but the resource is still recorded.
+	info.Resources["test_cluster"].AwaitOutputs = []AwaitOutput{{
+		Property:  "status",
+		Condition: AwaitValues("ACTIVE"),
+		Timeout:   20 * time.Millisecond,
+		Interval:  time.Millisecond,
+	}}
+	p = NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+	_, err = p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: props})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not ready")
+	assert.Greater(t, reads, 2)
+}
+
+func TestAwaitOutputReady(t *testing.T) {
+	set := AwaitOutput{Property: "value"}
+	assert.False(t, set.outputReady(resource.PropertyMap{}))
+	assert.False(t, set.outputReady(resource.PropertyMap{"value": resource.NewStringProperty("")}))
+	assert.False(t, set.outputReady(resource.PropertyMap{"value": resource.NewArrayProperty(nil)}))
+	assert.True(t, set.outputReady(resource.PropertyMap{"value": resource.NewStringProperty("x")}))
+	assert.True(t, set.outputReady(resource.PropertyMap{"value": resource.NewBoolProperty(false)}))
+
+	status := AwaitOutput{Property: "value", Condition: AwaitValues("READY", "ACTIVE")}
+	assert.False(t, status.outputReady(resource.PropertyMap{}))
+	assert.False(t, status.outputReady(resource.PropertyMap{"value": resource.NewStringProperty("PENDING")}))
+	assert.True(t, status.outputReady(resource.PropertyMap{"value": resource.NewStringProperty("ACTIVE")}))
+}
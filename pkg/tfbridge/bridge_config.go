@@ -0,0 +1,113 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+// bridgeConfigKey is a configuration key that the bridge interprets itself, rather than passing it to the upstream
+// provider.
+type bridgeConfigKey struct {
+	name      string
+	schema    *schema.Schema
+	supported func(info *ProviderInfo) bool // whether the provider opted in to the feature that interprets the key.
+}
+
+// bridgeConfigKeys are the bridge-level configuration keys, in the order that they are documented in.
+var bridgeConfigKeys = []bridgeConfigKey{
+	{name: httpProxyConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeString,
+		Description: "The URL of the proxy to send the provider's HTTP requests through.",
+	}, supported: func(info *ProviderInfo) bool { return info.TransportCallback != nil }},
+	{name: caBundleConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeString,
+		Description: "The path to a PEM file of additional CA certificates to trust for the provider's HTTP requests.",
+	}, supported: func(info *ProviderInfo) bool { return info.TransportCallback != nil }},
+	{name: insecureSkipTLSVerifyConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeBool,
+		Description: "Disables TLS certificate verification for the provider's HTTP requests. This is insecure.",
+	}, supported: func(info *ProviderInfo) bool { return info.TransportCallback != nil }},
+	{name: auditLogConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeString,
+		Description: "The path of a JSON lines file to record every create, update and delete operation in.",
+	}, supported: func(info *ProviderInfo) bool { return info.AuditLog }},
+	{name: rateLimitConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeFloat,
+		Description: "The maximum sustained number of operations per second to send to the provider's API.",
+	}, supported: func(info *ProviderInfo) bool { return info.RateLimit }},
+	{name: rateLimitBurstConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeInt,
+		Description: "The number of operations that may be sent at once under `rateLimit` (default one second's worth).",
+	}, supported: func(info *ProviderInfo) bool { return info.RateLimit }},
+	{name: autoTagsConfigKey, schema: &schema.Schema{
+		Type:        shim.TypeMap,
+		Elem:        (&schema.Schema{Type: shim.TypeString}).Shim(),
+		Description: "Tags to add to the tags-like properties of every resource.",
+	}, supported: func(info *ProviderInfo) bool { return info.AutoTags }},
+	{name: autoTagPropertiesConfigKey, schema: &schema.Schema{
+		Type: shim.TypeList,
+		Elem: (&schema.Schema{Type: shim.TypeString}).Shim(),
+		Description: "Patterns that match the names of the tags-like properties that `autoTags` are added to " +
+			"(default `tags` and `labels`).",
+	}, supported: func(info *ProviderInfo) bool { return info.AutoTags }},
+	{name: defaultResourceOptionsConfigKey, schema: &schema.Schema{
+		Type: shim.TypeMap,
+		Elem: (&schema.Schema{Type: shim.TypeList, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()}).Shim(),
+		Description: "Resource types to protect (`protect`) or to leave in the cloud when deleted " +
+			"(`retainOnDelete`), as lists of type patterns in which `*` matches any run of characters.",
+	}, supported: func(info *ProviderInfo) bool { return info.DefaultOptions }},
+	{name: autonamingConfigKey, schema: &schema.Schema{
+		Type: shim.TypeString,
+		Description: "How autonamed properties are populated: `default` adds a random suffix, `verbatim` uses " +
+			"resource names as they are, and `disabled` requires names to be set explicitly.",
+	}, supported: (*ProviderInfo).autonames},
+	{name: configProfilesConfigKey, schema: &schema.Schema{
+		Type: shim.TypeMap,
+		Elem: (&schema.Schema{Type: shim.TypeMap, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()}).Shim(),
+		Description: "Named sets of provider configuration that resources may select, each overriding some of the " +
+			"provider's configuration.",
+	}, supported: func(info *ProviderInfo) bool {
+		return info.ConfigProfiles != nil && info.ConfigProfiles.NewProvider != nil
+	}},
+}
+
+// BridgeConfig returns the configuration keys that the bridge interprets for the provider, keyed by name, so that
+// they are declared in the provider's schema alongside its own configuration. Keys that the upstream provider or
+// ExtraConfig define are left out, as the bridge leaves them to the provider.
+func (info *ProviderInfo) BridgeConfig() map[string]*ConfigInfo {
+	config := map[string]*ConfigInfo{}
+	for _, key := range bridgeConfigKeys {
+		if !key.supported(info) {
+			continue
+		}
+		if _, has := info.ExtraConfig[key.name]; has {
+			continue
+		}
+		if info.P != nil {
+			_, sch, _ := getInfoFromPulumiName(resource.PropertyKey(key.name), info.P.Schema(), info.Config, false)
+			if sch != nil {
+				continue
+			}
+		}
+		sch := *key.schema
+		sch.Optional = true
+		config[key.name] = &ConfigInfo{Schema: sch.Shim(), Info: &SchemaInfo{Name: key.name}}
+	}
+	return config
+}
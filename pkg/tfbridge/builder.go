@@ -0,0 +1,318 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// ProviderInfoBuilder assembles a ProviderInfo programmatically. Mistakes in the mappings, such as malformed tokens,
+// tokens in undeclared modules, or mappings for resources that the upstream provider does not have, are collected as
+// the mappings are added and reported together by Build, rather than surfacing later as tfgen failures.
+type ProviderInfoBuilder struct {
+	info    ProviderInfo
+	pkg     string          // the Pulumi package that tokens must use, or empty to infer it from the tokens.
+	modules map[string]bool // the modules that tokens may use, or nil if any module may be used.
+	errs    []error
+}
+
+// NewProviderInfoBuilder returns a builder for the ProviderInfo of the given upstream provider.
+func NewProviderInfoBuilder(p shim.Provider, name string) *ProviderInfoBuilder {
+	return &ProviderInfoBuilder{info: ProviderInfo{
+		P:           p,
+		Name:        name,
+		Resources:   map[string]*ResourceInfo{},
+		DataSources: map[string]*DataSourceInfo{},
+	}}
+}
+
+// Package declares the Pulumi package that resource and data source tokens must use, e.g. "azure" for the "azurerm"
+// provider. If no package is declared, it is inferred from the tokens.
+func (b *ProviderInfoBuilder) Package(pkg string) *ProviderInfoBuilder {
+	b.pkg = pkg
+	return b
+}
+
+// Modules declares the modules that resource and data source tokens may use. The "index" module is always allowed.
+// If no modules are declared, tokens may use any module.
+func (b *ProviderInfoBuilder) Modules(modules ...string) *ProviderInfoBuilder {
+	if b.modules == nil {
+		b.modules = map[string]bool{"index": true}
+	}
+	for _, module := range modules {
+		b.modules[module] = true
+	}
+	return b
+}
+
+// With applies f to the ProviderInfo under construction, for setting the fields that have no dedicated builder method.
+func (b *ProviderInfoBuilder) With(f func(info *ProviderInfo)) *ProviderInfoBuilder {
+	f(&b.info)
+	return b
+}
+
+// Resource maps the given Terraform resource to the given token. The info may be nil; its Tok is overwritten.
+func (b *ProviderInfoBuilder) Resource(tfName string, tok tokens.Type, info *ResourceInfo) *ProviderInfoBuilder {
+	if _, has := b.info.Resources[tfName]; has {
+		b.errs = append(b.errs, errors.Errorf("resource %s is mapped more than once", tfName))
+		return b
+	}
+	if info == nil {
+		info = &ResourceInfo{}
+	}
+	info.Tok = tok
+	b.info.Resources[tfName] = info
+	return b
+}
+
+// DataSource maps the given Terraform data source to the given token. The info may be nil; its Tok is overwritten.
+func (b *ProviderInfoBuilder) DataSource(tfName string, tok tokens.ModuleMember,
+	info *DataSourceInfo) *ProviderInfoBuilder {
+
+	if _, has := b.info.DataSources[tfName]; has {
+		b.errs = append(b.errs, errors.Errorf("data source %s is mapped more than once", tfName))
+		return b
+	}
+	if info == nil {
+		info = &DataSourceInfo{}
+	}
+	info.Tok = tok
+	b.info.DataSources[tfName] = info
+	return b
+}
+
+// Build validates the ProviderInfo and returns it, along with every problem found while building it.
+func (b *ProviderInfoBuilder) Build() (ProviderInfo, error) {
+	errs := append([]error{}, b.errs...)
+	errs = append(errs, b.info.validate(b.pkg, b.modules)...)
+	if len(errs) > 0 {
+		return b.info, errors.Wrap(multierror.Append(nil, errs...), "invalid provider info")
+	}
+	return b.info, nil
+}
+
+// Validate checks that the required fields of the ProviderInfo are set, that every resource and data source is mapped
+// to a well-formed token in the provider's package, and that every mapped resource and data source exists upstream.
+// The package is the one that most of the tokens use, since it may differ from the Terraform provider's name. All of
+// the problems found are reported together.
+func (info ProviderInfo) Validate() error {
+	if errs := info.validate("", nil); len(errs) > 0 {
+		return errors.Wrap(multierror.Append(nil, errs...), "invalid provider info")
+	}
+	return nil
+}
+
+// validate returns the problems with the ProviderInfo. Tokens must use the given package, or the package that most of
+// them use if pkg is empty. If modules is non-nil, tokens must use one of the modules.
+func (info ProviderInfo) validate(pkg string, modules map[string]bool) []error {
+	var errs []error
+	if info.P == nil {
+		errs = append(errs, errors.New("P must be set to the upstream provider"))
+	}
+	if info.Name == "" {
+		errs = append(errs, errors.New("Name must be set"))
+	}
+
+	if info.AutoNaming != nil {
+		if err := info.AutoNaming.Validate(); err != nil {
+			errs = append(errs, errors.Wrap(err, "AutoNaming"))
+		}
+	}
+
+	if pkg == "" {
+		pkg = info.tokenPackage()
+	}
+
+	toks := map[string]string{}
+	checkTok := func(kind, tfName, tok string, upper bool) {
+		if tok == "" {
+			errs = append(errs, errors.Errorf("%s %s: Tok must be set", kind, tfName))
+			return
+		}
+		if err := validateToken(pkg, tok, upper, modules); err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s %s", kind, tfName))
+		}
+		if other, has := toks[tok]; has {
+			errs = append(errs, errors.Errorf("%s %s: token %s is already used by %s", kind, tfName, tok, other))
+		} else {
+			toks[tok] = fmt.Sprintf("%s %s", kind, tfName)
+		}
+	}
+
+	for _, name := range sortedResourceInfoNames(info.Resources) {
+		res := info.Resources[name]
+		if res == nil {
+			errs = append(errs, errors.Errorf("resource %s: info must not be nil", name))
+			continue
+		}
+		if info.P != nil {
+			if _, ok := info.P.ResourcesMap().GetOk(name); !ok {
+				errs = append(errs, errors.Errorf("resource %s does not exist in the upstream provider", name))
+			}
+		}
+		checkTok("resource", name, string(res.Tok), true)
+		for _, await := range res.AwaitOutputs {
+			if await.Property == "" {
+				errs = append(errs, errors.Errorf("resource %s: AwaitOutputs must name a Property", name))
+			}
+		}
+		if res.AutoNaming != nil {
+			if err := res.AutoNaming.Validate(); err != nil {
+				errs = append(errs, errors.Wrapf(err, "resource %s: AutoNaming", name))
+			}
+		}
+	}
+
+	for _, name := range sortedDataSourceInfoNames(info.DataSources) {
+		ds := info.DataSources[name]
+		if ds == nil {
+			errs = append(errs, errors.Errorf("data source %s: info must not be nil", name))
+			continue
+		}
+		if info.P != nil {
+			if _, ok := info.P.DataSourcesMap().GetOk(name); !ok {
+				errs = append(errs, errors.Errorf("data source %s does not exist in the upstream provider", name))
+			}
+		}
+		checkTok("data source", name, string(ds.Tok), false)
+		if ds.ResourceTok != "" {
+			checkTok("data source resource", name, string(ds.ResourceTok), true)
+		}
+	}
+
+	ephemeralNames := make([]string, 0, len(info.EphemeralResources))
+	for name := range info.EphemeralResources {
+		ephemeralNames = append(ephemeralNames, name)
+	}
+	sort.Strings(ephemeralNames)
+	for _, name := range ephemeralNames {
+		er := info.EphemeralResources[name]
+		if er == nil {
+			errs = append(errs, errors.Errorf("ephemeral resource %s: info must not be nil", name))
+			continue
+		}
+		if info.P != nil {
+			if ep, ok := info.P.(shim.EphemeralResourceProvider); !ok {
+				errs = append(errs, errors.Errorf("ephemeral resource %s: the upstream provider does not support "+
+					"ephemeral resources", name))
+			} else if _, ok := ep.EphemeralResourcesMap().GetOk(name); !ok {
+				errs = append(errs, errors.Errorf("ephemeral resource %s does not exist in the upstream provider", name))
+			}
+		}
+		checkTok("ephemeral resource", name, string(er.Tok), false)
+	}
+
+	for _, tool := range info.ExternalTools {
+		if err := tool.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if info.ConfigProfiles != nil && info.ConfigProfiles.NewProvider == nil {
+		errs = append(errs, errors.New("ConfigProfiles must set NewProvider"))
+	}
+
+	return append(errs, info.validateLegacyBehaviors()...)
+}
+
+// tokenPackage returns the package that most of the resource, data source and ephemeral resource tokens use,
+// preferring the first in lexical order if there is a tie, or the provider's name if there are no well-formed tokens.
+func (info ProviderInfo) tokenPackage() string {
+	counts := map[string]int{}
+	count := func(tok string) {
+		if parts := strings.Split(tok, ":"); len(parts) == 3 && parts[0] != "" {
+			counts[parts[0]]++
+		}
+	}
+	for _, res := range info.Resources {
+		if res != nil {
+			count(string(res.Tok))
+		}
+	}
+	for _, ds := range info.DataSources {
+		if ds != nil {
+			count(string(ds.Tok))
+		}
+	}
+	for _, er := range info.EphemeralResources {
+		if er != nil {
+			count(string(er.Tok))
+		}
+	}
+	if len(counts) == 0 {
+		return info.Name
+	}
+
+	pkg := ""
+	for candidate, n := range counts {
+		if n > counts[pkg] || n == counts[pkg] && candidate < pkg {
+			pkg = candidate
+		}
+	}
+	return pkg
+}
+
+// validateToken checks that tok has the form <pkg>:<module>:<Name>, that its name starts with an upper case letter if
+// upper is true and a lower case letter otherwise, and that its module is one of the given modules, if any.
+func validateToken(pkg, tok string, upper bool, modules map[string]bool) error {
+	parts := strings.Split(tok, ":")
+	if len(parts) != 3 || parts[1] == "" || !tokens.IsName(parts[2]) {
+		return errors.Errorf("token %s must have the form %s:<module>:<name>", tok, pkg)
+	}
+	if parts[0] != pkg {
+		return errors.Errorf("token %s must be in package %s", tok, pkg)
+	}
+
+	first := []rune(parts[2])[0]
+	if upper && !unicode.IsUpper(first) {
+		return errors.Errorf("token %s: the name of a resource must start with an upper case letter", tok)
+	}
+	if !upper && !unicode.IsLower(first) {
+		return errors.Errorf("token %s: the name of a function must start with a lower case letter", tok)
+	}
+
+	// Only the first part of a module, e.g. "s3" in "s3/bucket", names the module that the token is generated into.
+	module := strings.SplitN(parts[1], "/", 2)[0]
+	if modules != nil && !modules[module] {
+		return errors.Errorf("token %s: module %s is not declared", tok, module)
+	}
+	return nil
+}
+
+func sortedResourceInfoNames(m map[string]*ResourceInfo) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedDataSourceInfoNames(m map[string]*DataSourceInfo) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
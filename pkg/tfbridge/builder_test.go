@@ -0,0 +1,127 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestProviderInfoBuilder(t *testing.T) {
+	p := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {},
+			"test_gadget": {},
+		},
+		DataSourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {},
+		},
+	})
+
+	info, err := NewProviderInfoBuilder(p, "test").
+		Modules("widgets").
+		With(func(info *ProviderInfo) { info.Keywords = []string{"test"} }).
+		Resource("test_widget", "test:widgets/widget:Widget", &ResourceInfo{DeleteBeforeReplace: true}).
+		Resource("test_gadget", "test:index/gadget:Gadget", nil).
+		DataSource("test_widget", "test:widgets/getWidget:getWidget", nil).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test"}, info.Keywords)
+	assert.Equal(t, "test:widgets/widget:Widget", string(info.Resources["test_widget"].Tok))
+	assert.True(t, info.Resources["test_widget"].DeleteBeforeReplace)
+	assert.Equal(t, "test:widgets/getWidget:getWidget", string(info.DataSources["test_widget"].Tok))
+	assert.NoError(t, info.Validate())
+
+	// Every problem is reported at once.
+	_, err = NewProviderInfoBuilder(p, "test").
+		Modules("widgets").
+		Resource("test_widget", "test:widgets/widget:Widget", nil).
+		Resource("test_widget", "test:widgets/widget:Widget", nil).
+		Resource("test_gadget", "test:gadgets/gadget:Gadget", nil).
+		Resource("test_missing", "other:index/missing:missing", nil).
+		DataSource("test_widget", "test:widgets/widget:Widget", nil).
+		Build()
+	assert.Error(t, err)
+	for _, problem := range []string{
+		"resource test_widget is mapped more than once",
+		"resource test_gadget: token test:gadgets/gadget:Gadget: module gadgets is not declared",
+		"resource test_missing does not exist in the upstream provider",
+		"resource test_missing: token other:index/missing:missing must be in package test",
+		"data source test_widget: token test:widgets/widget:Widget: the name of a function must start with a " +
+			"lower case letter",
+		"data source test_widget: token test:widgets/widget:Widget is already used by resource test_widget",
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+func TestValidateProviderInfo(t *testing.T) {
+	err := ProviderInfo{
+		Resources: map[string]*ResourceInfo{
+			"test_widget": {Tok: "test:widget"},
+			"test_gadget": {},
+			"test_nil":    nil,
+		},
+	}.Validate()
+	assert.Error(t, err)
+	for _, problem := range []string{
+		"P must be set to the upstream provider",
+		"Name must be set",
+		"resource test_widget: token test:widget must have the form :<module>:<name>",
+		"resource test_gadget: Tok must be set",
+		"resource test_nil: info must not be nil",
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+func TestProviderInfoPackage(t *testing.T) {
+	p := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"azurerm_widget": {},
+			"azurerm_gadget": {},
+		},
+		DataSourcesMap: map[string]*schemav2.Resource{
+			"azurerm_widget": {},
+		},
+	})
+
+	// Tokens are validated against the package that they use, rather than the Terraform provider's name.
+	info, err := NewProviderInfoBuilder(p, "azurerm").
+		Resource("azurerm_widget", "azure:index/widget:Widget", nil).
+		Resource("azurerm_gadget", "azure:index/gadget:Gadget", nil).
+		DataSource("azurerm_widget", "azure:index/getWidget:getWidget", nil).
+		Build()
+	assert.NoError(t, err)
+	assert.NoError(t, info.Validate())
+
+	// Tokens outside of the package that most tokens use are reported.
+	info.Resources["azurerm_gadget"].Tok = "azurerm:index/gadget:Gadget"
+	err = info.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resource azurerm_gadget: token azurerm:index/gadget:Gadget must be in package azure")
+
+	// The package may also be declared.
+	_, err = NewProviderInfoBuilder(p, "azurerm").
+		Package("azure").
+		Resource("azurerm_widget", "azurerm:index/widget:Widget", nil).
+		Build()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resource azurerm_widget: token azurerm:index/widget:Widget must be in package azure")
+}
@@ -0,0 +1,150 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/golang/glog"
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// checkpointKey is the key in a TF bridge result that records the ID of a resource whose create was interrupted. It
+// is part of the provider-private state of the resource, so it travels with the stack.
+const checkpointKey = "__checkpoint"
+
+// checkpointID returns the ID that the upstream provider will assign to the resource with the given inputs, or "" if
+// the resource does not enable checkpointing.
+func checkpointID(urn resource.URN, res Resource, props *pbstruct.Struct) (string, error) {
+	if res.Schema == nil || res.Schema.Checkpoint == nil || res.Schema.Checkpoint.ID == nil {
+		return "", nil
+	}
+
+	inputs, err := plugin.UnmarshalProperties(props, plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.checkpoint", urn), SkipNulls: true})
+	if err != nil {
+		return "", err
+	}
+	id, err := res.Schema.Checkpoint.ID(inputs)
+	if err != nil {
+		return "", errors.Wrapf(err, "computing checkpoint ID for %s", urn)
+	}
+	return id, nil
+}
+
+// createInterrupted returns true if the create that failed with the given error may have been cut short after the
+// upstream provider started it, i.e. because the request was cancelled or the connection to the upstream provider or
+// its API failed. Any other error is a definite answer from upstream that the resource was not created.
+func createInterrupted(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
+// checkpointedApply runs apply, which creates the given resource. If the resource enables checkpointing and the create
+// is interrupted, the returned error records the ID that the resource was to be assigned along with its inputs, so
+// that the engine saves the resource in the stack. The next update of the resource resumes the create.
+func (p *Provider) checkpointedApply(ctx context.Context, urn resource.URN, res Resource, props *pbstruct.Struct,
+	apply func() (shim.InstanceState, error)) (shim.InstanceState, error) {
+
+	id, err := checkpointID(urn, res, props)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := apply()
+	if state != nil || err == nil || id == "" || !createInterrupted(ctx, err) {
+		return state, err
+	}
+
+	glog.V(9).Infof("%s: checkpointing create interrupted with ID %s: %v", urn, id, err)
+	fields := map[string]*pbstruct.Value{}
+	for k, v := range props.GetFields() {
+		fields[k] = v
+	}
+	fields[checkpointKey] = &pbstruct.Value{Kind: &pbstruct.Value_StringValue{StringValue: id}}
+	return nil, initializationError(id, &pbstruct.Struct{Fields: fields}, []string{
+		errors.Wrapf(err, "creating %s was interrupted; the next update will resume it", urn).Error(),
+	})
+}
+
+// resumeCreate completes the create of a resource whose previous create was interrupted. If the resource exists under
+// its checkpointed ID, it is adopted as it is; otherwise it is created.
+func (p *Provider) resumeCreate(ctx context.Context, urn resource.URN, res Resource, req *pulumirpc.UpdateRequest,
+	state shim.InstanceState) (*pulumirpc.UpdateResponse, error) {
+
+	label := fmt.Sprintf("%s.Resume(%s/%s)", p.label(), urn, res.TFName)
+	existing, err := p.readResource(ctx, res, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s's checkpointed state", urn)
+	}
+	if existing != nil {
+		glog.V(9).Infof("%s: adopting the resource created by an interrupted create", label)
+		props, err := MakeTerraformResult(p.tf, existing, res.TF.Schema(), res.Schema.Fields, nil, p.supportsSecrets)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting result for %s", urn)
+		}
+		mprops, err := plugin.MarshalProperties(props, plugin.MarshalOptions{
+			Label:       fmt.Sprintf("%s.outs", label),
+			KeepSecrets: p.supportsSecrets,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &pulumirpc.UpdateResponse{Properties: mprops}, nil
+	}
+
+	// The engine keeps the checkpointed ID, so the resource may only be created if it is assigned that ID again.
+	id, err := checkpointID(urn, res, req.GetNews())
+	if err != nil {
+		return nil, err
+	}
+	if id != req.GetId() {
+		return nil, errors.Errorf("the create of %s was interrupted before it could complete, and its inputs no "+
+			"longer produce the checkpointed ID %s; run `pulumi refresh` to remove it from the stack", urn, req.GetId())
+	}
+
+	glog.V(9).Infof("%s: creating the resource that an interrupted create did not", label)
+	created, err := p.Create(ctx, &pulumirpc.CreateRequest{
+		Urn:        req.GetUrn(),
+		Properties: req.GetNews(),
+		Timeout:    req.GetTimeout(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.UpdateResponse{Properties: created.GetProperties()}, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestCheckpointedCreate(t *testing.T) {
+	// The upstream resource is named by its inputs, so its ID is known before it is created.
+	existing, creates := map[string]bool{}, 0
+	var createErr error
+	var interrupt func()
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Required: true, ForceNew: true},
+		},
+		CreateContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			creates++
+			if interrupt != nil {
+				interrupt()
+			}
+			if createErr != nil {
+				return diag.FromErr(createErr)
+			}
+			d.SetId("widget-" + d.Get("name").(string))
+			existing[d.Id()] = true
+			return nil
+		},
+		ReadContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			if !existing[d.Id()] {
+				d.SetId("")
+			}
+			return nil
+		},
+		DeleteContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return nil
+		},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_widget": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/widget:Widget": {
+				TF:     shimv2.NewResource(tfRes),
+				TFName: "example_widget",
+				Schema: &ResourceInfo{
+					Tok: "example:index/widget:Widget",
+					Checkpoint: &CheckpointInfo{
+						ID: func(inputs resource.PropertyMap) (string, error) {
+							return "widget-" + inputs["name"].StringValue(), nil
+						},
+					},
+				},
+			},
+		},
+	}
+
+	urn := resource.NewURN("stack", "project", "", "example:index/widget:Widget", "w")
+	props, err := plugin.MarshalProperties(resource.PropertyMap{"name": resource.NewStringProperty("a")},
+		plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	create := func(ctx context.Context) (*pulumirpc.CreateResponse, error) {
+		return p.Create(ctx, &pulumirpc.CreateRequest{Urn: string(urn), Properties: props})
+	}
+	checkpointOf := func(err error) *pulumirpc.ErrorResourceInitFailed {
+		rpcErr, ok := rpcerror.FromError(err)
+		if !ok {
+			return nil
+		}
+		for _, detail := range rpcErr.Details() {
+			if initErr, ok := detail.(*pulumirpc.ErrorResourceInitFailed); ok {
+				return initErr
+			}
+		}
+		return nil
+	}
+	resume := func(olds *pbstruct.Struct) (*pulumirpc.UpdateResponse, error) {
+		return p.Update(context.Background(), &pulumirpc.UpdateRequest{
+			Urn: string(urn), Id: "widget-a", Olds: olds, News: props})
+	}
+
+	// A create that upstream rejects did not create anything, so it is not checkpointed.
+	createErr = errors.New("widget-a already exists")
+	_, err = create(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, checkpointOf(err))
+
+	// A create that is interrupted records the resource's ID and inputs in provider-private state.
+	ctx, cancel := context.WithCancel(context.Background())
+	createErr, interrupt = context.Canceled, cancel
+	_, err = create(ctx)
+	interrupt = nil
+	checkpoint := checkpointOf(err)
+	if assert.NotNil(t, checkpoint) {
+		assert.Equal(t, "widget-a", checkpoint.GetId())
+		assert.Equal(t, "widget-a", checkpoint.GetProperties().GetFields()[checkpointKey].GetStringValue())
+		assert.Equal(t, "a", checkpoint.GetProperties().GetFields()["name"].GetStringValue())
+	}
+
+	// The checkpoint is not an input, so it does not cause a diff.
+	diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Urn: string(urn), Id: "widget-a", Olds: checkpoint.GetProperties(), News: props})
+	assert.NoError(t, err)
+	assert.Empty(t, diff.GetReplaces())
+
+	// The resource does not exist, so the next update creates it.
+	createErr = nil
+	resp, err := resume(checkpoint.GetProperties())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, creates)
+	assert.Equal(t, "a", resp.GetProperties().GetFields()["name"].GetStringValue())
+	assert.NotContains(t, resp.GetProperties().GetFields(), checkpointKey)
+
+	// If the upstream create went ahead before it was interrupted, the resource is adopted rather than duplicated.
+	resp, err = resume(checkpoint.GetProperties())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, creates)
+	assert.Equal(t, "a", resp.GetProperties().GetFields()["name"].GetStringValue())
+	assert.NotContains(t, resp.GetProperties().GetFields(), checkpointKey)
+
+	// A resumed create is only attempted if it would produce the checkpointed ID.
+	delete(existing, "widget-a")
+	_, err = p.Update(context.Background(), &pulumirpc.UpdateRequest{
+		Urn: string(urn), Id: "widget-b", Olds: checkpoint.GetProperties(), News: props})
+	assert.Error(t, err)
+	assert.Equal(t, 3, creates)
+}
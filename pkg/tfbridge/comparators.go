@@ -0,0 +1,154 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// ValueComparator reports whether a value read from the provider is semantically equal to the prior value.
+type ValueComparator func(prior, remote resource.PropertyValue) bool
+
+// CaseInsensitiveComparator treats two strings that differ only in case as equal.
+func CaseInsensitiveComparator(prior, remote resource.PropertyValue) bool {
+	if !prior.IsString() || !remote.IsString() {
+		return false
+	}
+	return strings.EqualFold(prior.StringValue(), remote.StringValue())
+}
+
+// JSONEqualComparator treats two strings that contain equivalent JSON documents as equal, regardless of whitespace
+// or key order.
+func JSONEqualComparator(prior, remote resource.PropertyValue) bool {
+	if !prior.IsString() || !remote.IsString() {
+		return false
+	}
+	var p, r interface{}
+	if err := json.Unmarshal([]byte(prior.StringValue()), &p); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(remote.StringValue()), &r); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(p, r)
+}
+
+// SetEqualComparator treats two arrays that contain the same elements, regardless of order, as equal.
+func SetEqualComparator(prior, remote resource.PropertyValue) bool {
+	if !prior.IsArray() || !remote.IsArray() {
+		return false
+	}
+	p, r := prior.ArrayValue(), remote.ArrayValue()
+	if len(p) != len(r) {
+		return false
+	}
+	matched := make([]bool, len(r))
+	for _, pe := range p {
+		found := false
+		for i, re := range r {
+			if !matched[i] && pe.DeepEquals(re) {
+				matched[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileReadResult replaces values in the result of a refresh with their prior values wherever a property's
+// ReadComparator reports that the two are semantically equal, and for properties that are marked SkipRefresh. The
+// values of SkipRefresh properties have already been read by then; they are only discarded here.
+func reconcileReadResult(prior, remote resource.PropertyMap, tfs shim.SchemaMap,
+	ps map[string]*SchemaInfo) resource.PropertyMap {
+
+	if len(prior) == 0 {
+		return remote
+	}
+
+	result := make(resource.PropertyMap, len(remote))
+	for key, value := range remote {
+		_, etfs, eps := getInfoFromPulumiName(key, tfs, ps, false)
+		if old, ok := prior[key]; ok {
+			value = reconcileReadValue(old, value, etfs, eps)
+		}
+		result[key] = value
+	}
+
+	// Properties that are not refreshed keep their prior values even if the provider did not report them.
+	for key, old := range prior {
+		if _, ok := remote[key]; ok {
+			continue
+		}
+		if _, _, eps := getInfoFromPulumiName(key, tfs, ps, false); eps != nil && eps.SkipRefresh {
+			result[key] = old
+		}
+	}
+	return result
+}
+
+func reconcileReadValue(prior, remote resource.PropertyValue, tfs shim.Schema,
+	ps *SchemaInfo) resource.PropertyValue {
+
+	// Compare the underlying values, but preserve the secretness of the remote value.
+	secret := remote.IsSecret()
+	if secret {
+		remote = remote.SecretValue().Element
+	}
+	if prior.IsSecret() {
+		prior = prior.SecretValue().Element
+	}
+
+	result := remote
+	switch {
+	case ps != nil && ps.SkipRefresh:
+		result = prior
+	case ps != nil && ps.ReadComparator != nil && ps.ReadComparator(prior, remote):
+		result = prior
+	case prior.IsObject() && remote.IsObject():
+		var tfflds shim.SchemaMap
+		if tfs != nil {
+			if res, isres := tfs.Elem().(shim.Resource); isres {
+				tfflds = res.Schema()
+			}
+		}
+		var psflds map[string]*SchemaInfo
+		if ps != nil {
+			psflds = ps.Fields
+		}
+		result = resource.NewObjectProperty(
+			reconcileReadResult(prior.ObjectValue(), remote.ObjectValue(), tfflds, psflds))
+	case prior.IsArray() && remote.IsArray() && len(prior.ArrayValue()) == len(remote.ArrayValue()):
+		etfs, eps := elemSchemas(tfs, ps)
+		arr := make([]resource.PropertyValue, len(remote.ArrayValue()))
+		for i, e := range remote.ArrayValue() {
+			arr[i] = reconcileReadValue(prior.ArrayValue()[i], e, etfs, eps)
+		}
+		result = resource.NewArrayProperty(arr)
+	}
+
+	if secret {
+		return resource.MakeSecret(result)
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestComparators(t *testing.T) {
+	str := resource.NewStringProperty
+	arr := func(vs ...interface{}) resource.PropertyValue { return resource.NewPropertyValue(vs) }
+
+	assert.True(t, CaseInsensitiveComparator(str("Foo"), str("fOO")))
+	assert.False(t, CaseInsensitiveComparator(str("Foo"), str("bar")))
+
+	assert.True(t, JSONEqualComparator(str(`{"a": 1, "b": [1, 2]}`), str(`{"b":[1,2],"a":1}`)))
+	assert.False(t, JSONEqualComparator(str(`{"a": 1}`), str(`{"a": 2}`)))
+	assert.False(t, JSONEqualComparator(str(`not json`), str(`not json`)))
+
+	assert.True(t, SetEqualComparator(arr("a", "b", "b"), arr("b", "a", "b")))
+	assert.False(t, SetEqualComparator(arr("a", "b", "b"), arr("a", "a", "b")))
+	assert.False(t, SetEqualComparator(arr("a"), arr("a", "b")))
+}
+
+func TestReconcileReadResult(t *testing.T) {
+	tfs := schemaMap(map[string]*schema.Schema{
+		"policy": {Type: shim.TypeString},
+		"name":   {Type: shim.TypeString},
+		"settings": {
+			Type:     shim.TypeList,
+			MaxItems: 1,
+			Elem: (&schema.Resource{
+				Schema: schemaMap(map[string]*schema.Schema{
+					"mode": {Type: shim.TypeString},
+				}),
+			}).Shim(),
+		},
+	})
+	ps := map[string]*SchemaInfo{
+		"policy": {ReadComparator: JSONEqualComparator},
+		"settings": {
+			Fields: map[string]*SchemaInfo{
+				"mode": {ReadComparator: CaseInsensitiveComparator},
+			},
+		},
+	}
+
+	prior := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"policy":   `{"a": 1}`,
+		"name":     "foo",
+		"settings": map[string]interface{}{"mode": "Fast"},
+	})
+	remote := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"policy":   `{"a":1}`,
+		"name":     "FOO",
+		"settings": map[string]interface{}{"mode": "FAST"},
+	})
+
+	actual := reconcileReadResult(prior, remote, tfs, ps)
+	assert.Equal(t, resource.NewStringProperty(`{"a": 1}`), actual["policy"])
+	assert.Equal(t, resource.NewStringProperty("FOO"), actual["name"])
+	assert.True(t, resource.NewPropertyValue(map[string]interface{}{"mode": "Fast"}).DeepEquals(actual["settings"]))
+}
+
+func TestReconcileReadResultSkipRefresh(t *testing.T) {
+	tfs := schemaMap(map[string]*schema.Schema{
+		"name": {Type: shim.TypeString},
+		"tags": {Type: shim.TypeMap, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()},
+		"acl":  {Type: shim.TypeString},
+	})
+	ps := map[string]*SchemaInfo{
+		"tags": {SkipRefresh: true},
+		"acl":  {SkipRefresh: true},
+	}
+
+	prior := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name": "foo",
+		"tags": map[string]interface{}{"env": "prod"},
+		"acl":  "private",
+	})
+	remote := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name": "bar",
+		"tags": map[string]interface{}{"env": "dev"},
+	})
+
+	// Properties that are not refreshed keep their prior values, even if the provider did not report them.
+	actual := reconcileReadResult(prior, remote, tfs, ps)
+	assert.Equal(t, resource.NewStringProperty("bar"), actual["name"])
+	assert.True(t, prior["tags"].DeepEquals(actual["tags"]))
+	assert.Equal(t, resource.NewStringProperty("private"), actual["acl"])
+}
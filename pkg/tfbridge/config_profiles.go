@@ -0,0 +1,382 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// configProfilesConfigKey is the bridge-level configuration key that defines named configuration profiles, e.g.
+// {"dc2": {"endpoint": "https://dc2.example.com"}}. Each profile overrides some of the provider's configuration, and
+// resources select a profile with the property named by ConfigProfilesInfo.Property. The key is only interpreted by
+// the bridge if the upstream provider does not define configuration with the same name.
+const configProfilesConfigKey = "configProfiles"
+
+// DefaultConfigProfileProperty is the name of the input property that selects a resource's configuration profile,
+// unless ConfigProfilesInfo.Property names another.
+const DefaultConfigProfileProperty = "configProfile"
+
+// ConfigProfilesInfo lets the resources of a program talk to different endpoints through a single provider, for
+// providers whose upstream users configure one provider alias per endpoint, e.g. appliances that each have their own
+// address and credentials. Profiles are defined in the bridge-level `configProfiles` configuration, and each resource
+// selects one with an input property; resources that do not select one use the provider's own configuration. The
+// property may be secret, and may be unknown during previews. Resources whose profile is removed from the
+// configuration cannot be deleted until it is restored.
+type ConfigProfilesInfo struct {
+	// the name of the input property that selects a resource's profile (default "configProfile").
+	Property string
+	// returns a new, unconfigured instance of the upstream provider, which is configured with a profile's settings.
+	NewProvider func() shim.Provider
+}
+
+// GetProperty returns the name of the input property that selects a resource's configuration profile.
+func (info *ConfigProfilesInfo) GetProperty() string {
+	if info.Property == "" {
+		return DefaultConfigProfileProperty
+	}
+	return info.Property
+}
+
+// takeConfigProfiles removes the configuration profiles from vars and returns them, keyed by name.
+func (p *Provider) takeConfigProfiles(vars resource.PropertyMap) (map[string]map[string]interface{}, error) {
+	value, ok := p.takeBridgeConfig(vars, configProfilesConfigKey)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	if p.info.ConfigProfiles == nil || p.info.ConfigProfiles.NewProvider == nil {
+		return nil, errors.Errorf("the %s provider does not support '%v'", p.module, configProfilesConfigKey)
+	}
+
+	var profiles map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &profiles); err != nil {
+		return nil, errors.Errorf("malformed configuration value for '%v': must be a JSON object that maps each "+
+			"profile's name to an object of the configuration it overrides", configProfilesConfigKey)
+	}
+	for name := range profiles {
+		if name == "" {
+			return nil, errors.Errorf("malformed configuration value for '%v': profiles must have names",
+				configProfilesConfigKey)
+		}
+	}
+	return profiles, nil
+}
+
+// configureProfiles configures a provider for each of the given profiles, with the variables of the given request
+// overridden by the profile's configuration.
+func (p *Provider) configureProfiles(ctx context.Context, req *pulumirpc.ConfigureRequest,
+	profiles map[string]map[string]interface{}) (map[string]*Provider, error) {
+
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configured := make(map[string]*Provider, len(profiles))
+	for _, name := range names {
+		vars := map[string]string{}
+		for k, v := range req.GetVariables() {
+			if mm, err := tokens.ParseModuleMember(k); err == nil && string(mm.Name()) == configProfilesConfigKey {
+				continue
+			}
+			vars[k] = v
+		}
+		for key, value := range profiles[name] {
+			for _, mod := range []tokens.Module{p.baseConfigMod(), p.configMod()} {
+				delete(vars, string(tokens.NewModuleMemberToken(mod, tokens.ModuleMemberName(key))))
+			}
+			s, ok := value.(string)
+			if !ok {
+				bytes, err := json.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+				s = string(bytes)
+			}
+			vars[string(tokens.NewModuleMemberToken(p.baseConfigMod(), tokens.ModuleMemberName(key)))] = s
+		}
+
+		profile := &Provider{
+			host:         p.host,
+			module:       p.module,
+			version:      p.version,
+			tf:           p.info.ConfigProfiles.NewProvider(),
+			info:         p.info,
+			config:       p.config,
+			resources:    p.resources,
+			dataSources:  p.dataSources,
+			dsResources:  p.dsResources,
+			legacyTokens: p.legacyTokens,
+			pulumiSchema: p.pulumiSchema,
+			usageMetrics: p.usageMetrics,
+		}
+		if _, err := profile.Configure(ctx, &pulumirpc.ConfigureRequest{
+			Variables:     vars,
+			AcceptSecrets: req.GetAcceptSecrets(),
+		}); err != nil {
+			return nil, errors.Wrapf(err, "configuring profile %q", name)
+		}
+		configured[name] = profile
+	}
+	return configured, nil
+}
+
+// profileKey returns the name of the property that selects a resource's configuration profile, or "" if the provider
+// has no configuration profiles.
+func (p *Provider) profileKey() string {
+	if p.info.ConfigProfiles == nil {
+		return ""
+	}
+	return p.info.ConfigProfiles.GetProperty()
+}
+
+// takeProfile returns a copy of the given properties without the property that selects the resource's configuration
+// profile, along with the property's value. The value is nil if the properties do not select a profile.
+func (p *Provider) takeProfile(props *pbstruct.Struct) (*pbstruct.Struct, *pbstruct.Value) {
+	key := p.profileKey()
+	value, has := props.GetFields()[key]
+	if key == "" || !has {
+		return props, nil
+	}
+	fields := make(map[string]*pbstruct.Value, len(props.GetFields()))
+	for k, v := range props.GetFields() {
+		if k != key {
+			fields[k] = v
+		}
+	}
+	return &pbstruct.Struct{Fields: fields}, value
+}
+
+// profileName returns the name of the profile that the given property value selects, looking through secrets. known is
+// false if the value is unknown, e.g. because it is the output of a resource that has not been created yet.
+func profileName(value *pbstruct.Value) (name string, known bool) {
+	if fields := value.GetStructValue().GetFields(); fields[resource.SigKey].GetStringValue() == resource.SecretSig {
+		value = fields["value"]
+	}
+	name = value.GetStringValue()
+	return name, name != plugin.UnknownStringValue
+}
+
+// profileProvider returns the provider that serves resources with the given profile. Unknown profiles are only
+// accepted by previews, which are served by the provider's own configuration.
+func (p *Provider) profileProvider(urn string, value *pbstruct.Value, preview bool) (*Provider, error) {
+	name, known := profileName(value)
+	switch {
+	case !known && preview:
+		return p, nil
+	case !known:
+		return nil, errors.Errorf("%s: the %s property must be known", urn, p.profileKey())
+	case name == "":
+		return p, nil
+	}
+	if profile, has := p.profiles[name]; has {
+		return profile, nil
+	}
+	defined := make([]string, 0, len(p.profiles))
+	for k := range p.profiles {
+		defined = append(defined, fmt.Sprintf("%q", k))
+	}
+	sort.Strings(defined)
+	return nil, errors.Errorf("%s: unknown configuration profile %q; set '%s:%s' to define it (defined profiles: %s)",
+		urn, name, p.module, configProfilesConfigKey, strings.Join(defined, ", "))
+}
+
+// withProfile returns a copy of the given properties with the profile property set to value, if value is non-nil.
+func withProfile(props *pbstruct.Struct, key string, value *pbstruct.Value) *pbstruct.Struct {
+	if value == nil || props == nil {
+		return props
+	}
+	fields := make(map[string]*pbstruct.Value, len(props.GetFields())+1)
+	for k, v := range props.GetFields() {
+		fields[k] = v
+	}
+	fields[key] = value
+	return &pbstruct.Struct{Fields: fields}
+}
+
+// The following methods serve requests for resources that select a configuration profile: the profile property is
+// removed from the request, the request is served by the profile's provider, and the property is added back to the
+// resource's inputs and state. They return false if the request does not select a profile.
+
+func (p *Provider) checkWithProfile(ctx context.Context,
+	req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, bool, error) {
+
+	news, value := p.takeProfile(req.GetNews())
+	if value == nil {
+		return nil, false, nil
+	}
+	// Inputs are checked the same way whichever profile serves the resource, so the profile may still be unknown.
+	target, err := p.profileProvider(req.GetUrn(), value, true)
+	if err != nil {
+		return nil, true, err
+	}
+	olds, _ := p.takeProfile(req.GetOlds())
+	resp, err := target.Check(ctx, &pulumirpc.CheckRequest{
+		Urn: req.GetUrn(), Olds: olds, News: news})
+	if err != nil {
+		return nil, true, err
+	}
+	resp.Inputs = withProfile(resp.GetInputs(), p.profileKey(), value)
+	return resp, true, nil
+}
+
+func (p *Provider) diffWithProfile(ctx context.Context,
+	req *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, bool, error) {
+
+	olds, oldValue := p.takeProfile(req.GetOlds())
+	news, newValue := p.takeProfile(req.GetNews())
+	if oldValue == nil && newValue == nil {
+		return nil, false, nil
+	}
+	// If the new profile is not known yet, the resource is diffed by the profile that created it.
+	oldName, _ := profileName(oldValue)
+	newName, known := profileName(newValue)
+	selected := newValue
+	if !known {
+		selected = oldValue
+	}
+	target, err := p.profileProvider(req.GetUrn(), selected, true)
+	if err != nil {
+		return nil, true, err
+	}
+	resp, err := target.Diff(ctx, &pulumirpc.DiffRequest{
+		Id: req.GetId(), Urn: req.GetUrn(), Olds: olds, News: news, IgnoreChanges: req.GetIgnoreChanges()})
+	if err != nil {
+		return nil, true, err
+	}
+
+	// A resource that moves, or may move, to another profile talks to another endpoint, so it has to be replaced.
+	if oldName != newName {
+		key := p.profileKey()
+		resp.Changes = pulumirpc.DiffResponse_DIFF_SOME
+		resp.Replaces = append(resp.Replaces, key)
+		resp.Diffs = append(resp.Diffs, key)
+		if resp.DetailedDiff == nil {
+			resp.DetailedDiff = map[string]*pulumirpc.PropertyDiff{}
+		}
+		resp.DetailedDiff[key] = &pulumirpc.PropertyDiff{Kind: pulumirpc.PropertyDiff_UPDATE_REPLACE}
+		resp.HasDetailedDiff = true
+	}
+	return resp, true, nil
+}
+
+func (p *Provider) createWithProfile(ctx context.Context,
+	req *pulumirpc.CreateRequest) (*pulumirpc.CreateResponse, bool, error) {
+
+	props, value := p.takeProfile(req.GetProperties())
+	if value == nil {
+		return nil, false, nil
+	}
+	target, err := p.profileProvider(req.GetUrn(), value, req.GetPreview())
+	if err != nil {
+		return nil, true, err
+	}
+	resp, err := target.Create(ctx, &pulumirpc.CreateRequest{
+		Urn: req.GetUrn(), Properties: props, Timeout: req.GetTimeout(), Preview: req.GetPreview()})
+	if err != nil {
+		return nil, true, err
+	}
+	resp.Properties = withProfile(resp.GetProperties(), p.profileKey(), value)
+	return resp, true, nil
+}
+
+func (p *Provider) readWithProfile(ctx context.Context,
+	req *pulumirpc.ReadRequest) (*pulumirpc.ReadResponse, bool, error) {
+
+	props, value := p.takeProfile(req.GetProperties())
+	inputs, inputValue := p.takeProfile(req.GetInputs())
+	if value == nil {
+		value = inputValue
+	}
+	if value == nil {
+		return nil, false, nil
+	}
+	target, err := p.profileProvider(req.GetUrn(), value, false)
+	if err != nil {
+		return nil, true, err
+	}
+	resp, err := target.Read(ctx, &pulumirpc.ReadRequest{
+		Id: req.GetId(), Urn: req.GetUrn(), Properties: props, Inputs: inputs})
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.GetId() != "" {
+		resp.Properties = withProfile(resp.GetProperties(), p.profileKey(), value)
+		resp.Inputs = withProfile(resp.GetInputs(), p.profileKey(), value)
+	}
+	return resp, true, nil
+}
+
+func (p *Provider) updateWithProfile(ctx context.Context,
+	req *pulumirpc.UpdateRequest) (*pulumirpc.UpdateResponse, bool, error) {
+
+	olds, _ := p.takeProfile(req.GetOlds())
+	news, value := p.takeProfile(req.GetNews())
+	if value == nil {
+		return nil, false, nil
+	}
+	target, err := p.profileProvider(req.GetUrn(), value, req.GetPreview())
+	if err != nil {
+		return nil, true, err
+	}
+	resp, err := target.Update(ctx, &pulumirpc.UpdateRequest{
+		Id: req.GetId(), Urn: req.GetUrn(), Olds: olds, News: news, Timeout: req.GetTimeout(),
+		IgnoreChanges: req.GetIgnoreChanges(), Preview: req.GetPreview()})
+	if err != nil {
+		return nil, true, err
+	}
+	resp.Properties = withProfile(resp.GetProperties(), p.profileKey(), value)
+	return resp, true, nil
+}
+
+func (p *Provider) deleteWithProfile(ctx context.Context, req *pulumirpc.DeleteRequest) (*pbempty.Empty, bool, error) {
+	props, value := p.takeProfile(req.GetProperties())
+	if value == nil {
+		return nil, false, nil
+	}
+	// Deleting the resource through another profile could delete another resource with the same ID, so a resource
+	// whose profile is no longer defined cannot be deleted until the profile is restored.
+	if name, _ := profileName(value); name != "" {
+		if _, has := p.profiles[name]; !has {
+			return nil, true, errors.Errorf("%s: cannot delete the resource, because its configuration profile %q is "+
+				"no longer defined; restore the profile in '%s:%s' to delete it, or run `pulumi state delete` to "+
+				"remove it from the stack", req.GetUrn(), name, p.module, configProfilesConfigKey)
+		}
+	}
+	target, err := p.profileProvider(req.GetUrn(), value, false)
+	if err != nil {
+		return nil, true, err
+	}
+	resp, err := target.Delete(ctx, &pulumirpc.DeleteRequest{
+		Id: req.GetId(), Urn: req.GetUrn(), Properties: props, Timeout: req.GetTimeout()})
+	return resp, true, err
+}
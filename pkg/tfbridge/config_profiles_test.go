@@ -0,0 +1,201 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestConfigProfiles(t *testing.T) {
+	const urn = "urn:pulumi:stack::project::test:index:Widget::w"
+	marshal := func(m map[string]interface{}) *pbstruct.Struct {
+		s, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(m), plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		return s
+	}
+	unmarshal := func(s *pbstruct.Struct) resource.PropertyMap {
+		m, err := plugin.UnmarshalProperties(s, plugin.MarshalOptions{KeepUnknowns: true})
+		assert.NoError(t, err)
+		return m
+	}
+
+	// Each widget records the endpoint of the provider that created it.
+	newProvider := func() shim.Provider {
+		return shimv2.NewProvider(&schemav2.Provider{
+			Schema: map[string]*schemav2.Schema{
+				"endpoint": {Type: schemav2.TypeString, Optional: true},
+			},
+			ConfigureContextFunc: func(_ context.Context, d *schemav2.ResourceData) (interface{}, diag.Diagnostics) {
+				return d.Get("endpoint").(string), nil
+			},
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {
+					Schema: map[string]*schemav2.Schema{
+						"name":     {Type: schemav2.TypeString, Required: true, ForceNew: true},
+						"endpoint": {Type: schemav2.TypeString, Computed: true},
+					},
+					Create: func(d *schemav2.ResourceData, meta interface{}) error {
+						d.SetId(d.Get("name").(string))
+						return d.Set("endpoint", meta.(string))
+					},
+					Read:   func(d *schemav2.ResourceData, _ interface{}) error { return nil },
+					Delete: func(d *schemav2.ResourceData, _ interface{}) error { return nil },
+				},
+			},
+		})
+	}
+	info := ProviderInfo{
+		Name:           "test",
+		Resources:      map[string]*ResourceInfo{"test_widget": {Tok: "test:index:Widget"}},
+		ConfigProfiles: &ConfigProfilesInfo{NewProvider: newProvider},
+	}
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", newProvider(), info, nil)
+
+	_, err := p.Configure(context.Background(), &pulumirpc.ConfigureRequest{
+		Variables: map[string]string{
+			"test:config:endpoint":       "https://dc1.example.com",
+			"test:config:configProfiles": `{"dc2": {"endpoint": "https://dc2.example.com"}}`,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, p.profiles, 1)
+
+	create := func(props map[string]interface{}) (*pulumirpc.CreateResponse, error) {
+		return p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: marshal(props)})
+	}
+
+	// Resources that do not select a profile use the provider's own configuration.
+	created, err := create(map[string]interface{}{"name": "w1"})
+	assert.NoError(t, err)
+	outs := unmarshal(created.GetProperties())
+	assert.Equal(t, resource.NewStringProperty("https://dc1.example.com"), outs["endpoint"])
+	assert.NotContains(t, outs, resource.PropertyKey("configProfile"))
+
+	// Resources that select a profile use the profile's configuration, and keep the property in their state.
+	created, err = create(map[string]interface{}{"name": "w2", "configProfile": "dc2"})
+	assert.NoError(t, err)
+	outs = unmarshal(created.GetProperties())
+	assert.Equal(t, resource.NewStringProperty("https://dc2.example.com"), outs["endpoint"])
+	assert.Equal(t, resource.NewStringProperty("dc2"), outs["configProfile"])
+
+	// Unknown profiles are errors.
+	_, err = create(map[string]interface{}{"name": "w3", "configProfile": "dc3"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown configuration profile "dc3"`)
+
+	// Check passes the property through.
+	checked, err := p.Check(context.Background(), &pulumirpc.CheckRequest{
+		Urn:  urn,
+		News: marshal(map[string]interface{}{"name": "w2", "configProfile": "dc2"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("dc2"), unmarshal(checked.GetInputs())["configProfile"])
+
+	// Moving a resource to another profile replaces it.
+	diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Id:   "w2",
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"name": "w2", "configProfile": "dc2"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_NONE, diff.GetChanges())
+	diff, err = p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Id:   "w2",
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"name": "w2"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_SOME, diff.GetChanges())
+	assert.Equal(t, []string{"configProfile"}, diff.GetReplaces())
+	assert.Equal(t, pulumirpc.PropertyDiff_UPDATE_REPLACE, diff.GetDetailedDiff()["configProfile"].GetKind())
+
+	// Reads keep the property.
+	read, err := p.Read(context.Background(), &pulumirpc.ReadRequest{
+		Id:         "w2",
+		Urn:        urn,
+		Properties: created.GetProperties(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("dc2"), unmarshal(read.GetProperties())["configProfile"])
+
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{
+		Id:         "w2",
+		Urn:        urn,
+		Properties: created.GetProperties(),
+	})
+	assert.NoError(t, err)
+
+	// Secret profiles select the profile they hold.
+	secret, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name":          resource.NewStringProperty("w4"),
+		"configProfile": resource.MakeSecret(resource.NewStringProperty("dc2")),
+	}, plugin.MarshalOptions{KeepSecrets: true})
+	assert.NoError(t, err)
+	created, err = p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: secret})
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewStringProperty("https://dc2.example.com"), unmarshal(created.GetProperties())["endpoint"])
+
+	// Profiles that are not known yet pass Check and previews, and replace the resource, but cannot be created.
+	computed, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name":          resource.NewStringProperty("w4"),
+		"configProfile": resource.MakeComputed(resource.NewStringProperty("")),
+	}, plugin.MarshalOptions{KeepUnknowns: true})
+	assert.NoError(t, err)
+	_, err = p.Check(context.Background(), &pulumirpc.CheckRequest{Urn: urn, News: computed})
+	assert.NoError(t, err)
+	diff, err = p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Id: "w4", Urn: urn, Olds: created.GetProperties(), News: computed})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"configProfile"}, diff.GetReplaces())
+	_, err = p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: computed, Preview: true})
+	assert.NoError(t, err)
+	_, err = p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn, Properties: computed})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the configProfile property must be known")
+
+	// Resources whose profile has been removed from the configuration cannot be deleted through another profile.
+	delete(p.profiles, "dc2")
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{
+		Id:         "w4",
+		Urn:        urn,
+		Properties: created.GetProperties(),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `configuration profile "dc2" is no longer defined`)
+}
+
+func TestConfigProfilesUnsupported(t *testing.T) {
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", shimv2.NewProvider(&schemav2.Provider{}),
+		ProviderInfo{Name: "test"}, nil)
+	_, err := p.Configure(context.Background(), &pulumirpc.ConfigureRequest{
+		Variables: map[string]string{"test:config:configProfiles": `{"dc2": {}}`},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support 'configProfiles'")
+}
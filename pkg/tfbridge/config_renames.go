@@ -0,0 +1,52 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// renamedConfigKey returns the name that replaces the given configuration key and true if the key has been renamed,
+// warning the user that the key is deprecated. Otherwise it returns the key unchanged and false.
+func (p *Provider) renamedConfigKey(ctx context.Context, key resource.PropertyKey) (resource.PropertyKey, bool) {
+	newName, ok := p.info.RenamedConfig[string(key)]
+	if !ok || newName == "" {
+		return key, false
+	}
+
+	msg := fmt.Sprintf("configuration key %s is deprecated; use %s instead", key, newName)
+	glog.V(9).Infof("%s", msg)
+	if p.host != nil {
+		if err := p.host.Log(ctx, diag.Warning, "", msg); err != nil {
+			glog.V(9).Infof("failed to log deprecated configuration key: %v", err)
+		}
+	}
+	return resource.PropertyKey(newName), true
+}
+
+// mergeRenamedConfig adds the values set under deprecated configuration keys to vars under the names that replace
+// them. A value set under the new name takes precedence, so that stacks can move to it before dropping the old key.
+func mergeRenamedConfig(vars, renamed resource.PropertyMap) {
+	for key, v := range renamed {
+		if _, has := vars[key]; !has {
+			vars[key] = v
+		}
+	}
+}
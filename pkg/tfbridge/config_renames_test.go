@@ -0,0 +1,69 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestConfigureRenamedConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		expected  string
+	}{
+		{
+			name:      "old key",
+			variables: map[string]string{"test:config:legacyValue": "old"},
+			expected:  "old",
+		},
+		{
+			name:      "new key",
+			variables: map[string]string{"test:config:configValue": "new"},
+			expected:  "new",
+		},
+		{
+			name: "both keys",
+			variables: map[string]string{
+				"test:config:legacyValue": "old",
+				"test:config:configValue": "new",
+			},
+			expected: "new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{
+				module: "test",
+				tf:     shimv2.NewProvider(testTFProviderV2),
+				config: shimv2.NewSchemaMap(testTFProviderV2.Schema),
+				info: ProviderInfo{
+					RenamedConfig: map[string]string{"legacyValue": "configValue"},
+				},
+			}
+			_, err := p.Configure(context.Background(), &pulumirpc.ConfigureRequest{Variables: tt.variables})
+			assert.NoError(t, err)
+			assert.Equal(t, resource.NewStringProperty(tt.expected), p.configValues["configValue"])
+			assert.NotContains(t, p.configValues, resource.PropertyKey("legacyValue"))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// The Pulumi SDK that the bridge links against does not yet define the converter plugin RPC service, so the bridge
+// exposes state conversion through an invoke and through the convert-state command of tfgen instead. Both accept the
+// contents of a Terraform state file and return the resources it describes in the form accepted by
+// `pulumi import --file`, using the provider's own token mappings rather than mapping data embedded in the CLI.
+// `pulumi convert` (ConvertProgram and ConvertState) is not supported until the converter service is available.
+
+// convertStateToken returns the token of the invoke that converts Terraform state into Pulumi import specs.
+func (p *Provider) convertStateToken() tokens.ModuleMember {
+	return tokens.ModuleMember(p.pkg() + ":tfbridge:convertState")
+}
+
+// tfState is the subset of a Terraform state file (format version 4) that is needed to import its resources.
+type tfState struct {
+	Version   int               `json:"version"`
+	Resources []tfStateResource `json:"resources"`
+}
+
+type tfStateResource struct {
+	Module    string            `json:"module,omitempty"`
+	Mode      string            `json:"mode"`
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Instances []tfStateInstance `json:"instances"`
+}
+
+type tfStateInstance struct {
+	IndexKey   interface{}            `json:"index_key,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// importSpec describes a single resource to import, in the format of a `pulumi import --file` entry.
+type importSpec struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// convertedState is the result of converting a Terraform state file.
+type convertedState struct {
+	Resources []importSpec `json:"resources"`
+	Unmapped  []string     `json:"unmapped,omitempty"` // addresses of managed resources this provider cannot import
+}
+
+// convertState converts the managed resources in a Terraform state file into import specs for this provider.
+func (p *Provider) convertState(state []byte) (*convertedState, error) {
+	var tfs tfState
+	if err := json.Unmarshal(state, &tfs); err != nil {
+		return nil, errors.Wrap(err, "parsing Terraform state")
+	}
+	if tfs.Version != 4 {
+		return nil, errors.Errorf("unsupported Terraform state version %d; only version 4 is supported", tfs.Version)
+	}
+
+	// Visit the tokens in order so that a Terraform resource that is mapped to more than one token is always
+	// imported as the same one.
+	toks := make([]string, 0, len(p.resources))
+	for tok := range p.resources {
+		if !p.legacyTokens[string(tok)] {
+			toks = append(toks, string(tok))
+		}
+	}
+	sort.Strings(toks)
+	tokensByTFName := map[string]tokens.Type{}
+	for _, tok := range toks {
+		tfName := p.resources[tokens.Type(tok)].TFName
+		if _, has := tokensByTFName[tfName]; !has {
+			tokensByTFName[tfName] = tokens.Type(tok)
+		}
+	}
+
+	result := &convertedState{Resources: []importSpec{}}
+	for _, res := range tfs.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+
+		address := res.Type + "." + res.Name
+		if res.Module != "" {
+			address = res.Module + "." + address
+		}
+		tok, ok := tokensByTFName[res.Type]
+		if !ok {
+			result.Unmapped = append(result.Unmapped, address)
+			continue
+		}
+
+		for _, inst := range res.Instances {
+			id, _ := inst.Attributes["id"].(string)
+			if id == "" {
+				result.Unmapped = append(result.Unmapped, address)
+				continue
+			}
+			result.Resources = append(result.Resources, importSpec{
+				Type: string(tok),
+				Name: importName(res.Module, res.Name, inst.IndexKey),
+				ID:   id,
+			})
+		}
+	}
+
+	sort.Strings(result.Unmapped)
+	return result, nil
+}
+
+// ConvertTerraformState converts the managed resources in the given Terraform state file into the contents of a file
+// for `pulumi import --file`, using the token mappings of the provider with the given info. It also returns the
+// addresses of the managed resources that the provider cannot import.
+func ConvertTerraformState(info ProviderInfo, state []byte) ([]byte, []string, error) {
+	p := NewProvider(context.Background(), nil, info.Name, info.Version, info.P, info, nil)
+	converted, err := p.convertState(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	imports, err := json.MarshalIndent(struct {
+		Resources []importSpec `json:"resources"`
+	}{converted.Resources}, "", "    ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return imports, converted.Unmapped, nil
+}
+
+// importName derives a Pulumi resource name from a Terraform resource's module path, name and instance key, e.g.
+// module.network.aws_subnet.private["a"] becomes network_private_a.
+func importName(module, name string, indexKey interface{}) string {
+	var parts []string
+	for _, part := range strings.Split(module, ".") {
+		if part != "" && part != "module" {
+			parts = append(parts, part)
+		}
+	}
+	parts = append(parts, name)
+	if indexKey != nil {
+		parts = append(parts, fmt.Sprintf("%v", indexKey))
+	}
+	return strings.Join(parts, "_")
+}
+
+// invokeConvertState implements the convertState invoke, which takes the contents of a Terraform state file in its
+// `state` argument.
+func (p *Provider) invokeConvertState(req *pulumirpc.InvokeRequest) (*pulumirpc.InvokeResponse, error) {
+	args, err := plugin.UnmarshalProperties(req.GetArgs(), plugin.MarshalOptions{Label: "convertState.args"})
+	if err != nil {
+		return nil, err
+	}
+	state, ok := args["state"]
+	if !ok || !state.IsString() {
+		return &pulumirpc.InvokeResponse{Failures: []*pulumirpc.CheckFailure{{
+			Property: "state",
+			Reason:   "missing required string argument 'state'",
+		}}}, nil
+	}
+
+	converted, err := p.convertState([]byte(state.StringValue()))
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through JSON to build the property map, which keeps the result's shape in one place.
+	bytes, err := json.Marshal(converted)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(bytes, &m); err != nil {
+		return nil, err
+	}
+	ret, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(m),
+		plugin.MarshalOptions{Label: "convertState.ret"})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.InvokeResponse{Return: ret}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+const testTFState = `{
+  "version": 4,
+  "resources": [
+    {"mode": "managed", "type": "example_resource", "name": "web",
+     "instances": [{"attributes": {"id": "i-123"}}]},
+    {"module": "module.net", "mode": "managed", "type": "example_resource", "name": "subnet",
+     "instances": [{"index_key": "a", "attributes": {"id": "s-1"}}, {"index_key": 1, "attributes": {"id": "s-2"}}]},
+    {"mode": "data", "type": "example_data", "name": "lookup", "instances": [{"attributes": {"id": "d"}}]},
+    {"mode": "managed", "type": "other_resource", "name": "x", "instances": [{"attributes": {"id": "o"}}]}
+  ]
+}`
+
+func TestConvertState(t *testing.T) {
+	p := &Provider{
+		module: "test",
+		resources: map[tokens.Type]Resource{
+			"test:index:Example": {TFName: "example_resource"},
+		},
+	}
+
+	converted, err := p.convertState([]byte(testTFState))
+	assert.NoError(t, err)
+	assert.Equal(t, []importSpec{
+		{Type: "test:index:Example", Name: "web", ID: "i-123"},
+		{Type: "test:index:Example", Name: "net_subnet_a", ID: "s-1"},
+		{Type: "test:index:Example", Name: "net_subnet_1", ID: "s-2"},
+	}, converted.Resources)
+	assert.Equal(t, []string{"other_resource.x"}, converted.Unmapped)
+
+	// A Terraform resource with several tokens is always imported as the first of them.
+	p.resources["test:index:AnotherExample"] = Resource{TFName: "example_resource"}
+	for i := 0; i < 10; i++ {
+		converted, err = p.convertState([]byte(testTFState))
+		assert.NoError(t, err)
+		assert.Equal(t, "test:index:AnotherExample", converted.Resources[0].Type)
+	}
+	delete(p.resources, "test:index:AnotherExample")
+
+	_, err = p.convertState([]byte(`{"version": 3}`))
+	assert.Error(t, err)
+
+	args, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(map[string]interface{}{
+		"state": testTFState,
+	}), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	resp, err := p.Invoke(context.Background(), &pulumirpc.InvokeRequest{Tok: "test:tfbridge:convertState", Args: args})
+	assert.NoError(t, err)
+	ret, err := plugin.UnmarshalProperties(resp.GetReturn(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, ret["resources"].ArrayValue(), 3)
+	assert.Equal(t, "net_subnet_a", ret["resources"].ArrayValue()[1].ObjectValue()["name"].StringValue())
+}
+
+func TestConvertTerraformState(t *testing.T) {
+	info := ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{"example_resource": {}},
+		}),
+		Resources: map[string]*ResourceInfo{"example_resource": {Tok: "test:index:Example"}},
+	}
+
+	imports, unmapped, err := ConvertTerraformState(info, []byte(testTFState))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"resources": [
+		{"type": "test:index:Example", "name": "web", "id": "i-123"},
+		{"type": "test:index:Example", "name": "net_subnet_a", "id": "s-1"},
+		{"type": "test:index:Example", "name": "net_subnet_1", "id": "s-2"}
+	]}`, string(imports))
+	assert.Equal(t, []string{"other_resource.x"}, unmapped)
+}
@@ -0,0 +1,74 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// crashReportDirEnvVar may be set to override the directory that crash reports are written to. If unset, crash
+// reports are written to the system temporary directory.
+const crashReportDirEnvVar = "PULUMI_TFBRIDGE_CRASH_REPORT_DIR"
+
+// recoverProviderPanic converts a panic raised by upstream provider code into an error scoped to the operation
+// described by label. The panic value and stack trace are written to a crash report file whose path is included in
+// the resulting error. It must be called directly via defer.
+func (p *Provider) recoverProviderPanic(label string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	glog.Errorf("%s panicked: %v\n%s", label, r, stack)
+
+	report, werr := writeCrashReport(p.module, label, r, stack)
+	if werr != nil {
+		*err = errors.Errorf("%s: provider panicked: %v (failed to write crash report: %v)", label, r, werr)
+		return
+	}
+	*err = errors.Errorf("%s: provider panicked: %v; a crash report was written to %s", label, r, report)
+}
+
+// writeCrashReport writes a crash report for a recovered panic and returns the path of the report.
+func writeCrashReport(module, label string, value interface{}, stack []byte) (string, error) {
+	dir := os.Getenv(crashReportDirEnvVar)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := ioutil.TempFile(dir, fmt.Sprintf("pulumi-%s-crash-*.log", module))
+	if err != nil {
+		return "", err
+	}
+	defer contract.IgnoreClose(f)
+
+	_, err = fmt.Fprintf(f, "Time: %s\nOperation: %s\nPanic: %v\n\n%s", time.Now().UTC().Format(time.RFC3339),
+		label, value, stack)
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
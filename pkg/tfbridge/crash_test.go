@@ -0,0 +1,56 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverProviderPanic(t *testing.T) {
+	dir := t.TempDir()
+	os.Setenv(crashReportDirEnvVar, dir)
+	defer os.Unsetenv(crashReportDirEnvVar)
+
+	p := &Provider{module: "test"}
+	err := func() (err error) {
+		defer p.recoverProviderPanic("test.Create(urn/test_resource)", &err)
+		panic("boom")
+	}()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "test.Create(urn/test_resource): provider panicked: boom")
+
+	reports, globErr := filepath.Glob(filepath.Join(dir, "pulumi-test-crash-*.log"))
+	assert.NoError(t, globErr)
+	if assert.Len(t, reports, 1) {
+		assert.Contains(t, err.Error(), reports[0])
+
+		contents, readErr := ioutil.ReadFile(reports[0])
+		assert.NoError(t, readErr)
+		assert.Contains(t, string(contents), "Panic: boom")
+		assert.Contains(t, string(contents), "TestRecoverProviderPanic")
+	}
+
+	// No panic means no error.
+	err = func() (err error) {
+		defer p.recoverProviderPanic("test.Read", &err)
+		return nil
+	}()
+	assert.NoError(t, err)
+}
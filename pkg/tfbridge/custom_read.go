@@ -0,0 +1,78 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// CustomReadFunc reads the live state of the resource with the given ID in place of the upstream provider's Read. The
+// state holds the resource's last known outputs, keyed by Pulumi name, and config holds the provider's configuration,
+// e.g. for building a client of the cloud's SDK. It returns the resource's ID and outputs, again keyed by Pulumi name,
+// or an empty ID if the resource no longer exists.
+//
+// The outputs are checked against the resource's schema, and are marshaled and marked secret just like the outputs
+// of the upstream provider.
+type CustomReadFunc func(ctx context.Context, id string, state,
+	config resource.PropertyMap) (string, resource.PropertyMap, error)
+
+// readResource reads the resource with the given state, using the resource's CustomRead if it has one and the upstream
+// provider otherwise. It returns nil if the resource no longer exists.
+func (p *Provider) readResource(ctx context.Context, res Resource,
+	state shim.InstanceState) (shim.InstanceState, error) {
+
+	if res.Schema == nil || res.Schema.CustomRead == nil {
+		return p.tf.Refresh(res.TFName, state)
+	}
+	glog.V(9).Infof("%s: reading with CustomRead", res.TFName)
+
+	props, err := MakeTerraformResult(p.tf, state, res.TF.Schema(), res.Schema.Fields, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	meta, hasMeta := props[metaKey]
+	delete(props, metaKey)
+
+	id, outs, err := res.Schema.CustomRead(ctx, state.ID(), props, p.configValues)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, nil
+	}
+
+	tfs := res.TF.Schema()
+	for key := range outs {
+		if key == metaKey {
+			continue
+		}
+		if _, sch, _ := getInfoFromPulumiName(key, tfs, res.Schema.Fields, false); sch == nil {
+			return nil, errors.Errorf("CustomRead returned unknown property %q", key)
+		}
+	}
+
+	// Keep the Terraform metadata of the prior state, such as its schema version, unless the read replaced it.
+	if _, has := outs[metaKey]; hasMeta && !has {
+		outs = outs.Copy()
+		outs[metaKey] = meta
+	}
+	return MakeTerraformState(res, id, outs)
+}
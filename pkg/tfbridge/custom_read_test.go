@@ -0,0 +1,111 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestCustomRead(t *testing.T) {
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name":     {Type: schemav2.TypeString, Optional: true},
+			"password": {Type: schemav2.TypeString, Optional: true, Sensitive: true},
+		},
+		ReadContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return diag.Errorf("upstream read is broken")
+		},
+	}
+
+	var read resource.PropertyMap
+	exists, unknown := true, false
+	info := &ResourceInfo{
+		Tok: "example:index/user:User",
+		CustomRead: func(ctx context.Context, id string, state,
+			config resource.PropertyMap) (string, resource.PropertyMap, error) {
+
+			read = state
+			switch {
+			case !exists:
+				return "", nil, nil
+			case unknown:
+				return id, resource.PropertyMap{"color": resource.NewStringProperty("red")}, nil
+			}
+			return id, resource.PropertyMap{
+				"name":     resource.NewStringProperty("alice"),
+				"password": resource.NewStringProperty("hunter2"),
+			}, nil
+		},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_user": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/user:User": {TF: shimv2.NewResource(tfRes), TFName: "example_user", Schema: info},
+		},
+		supportsSecrets: true,
+	}
+	urn := resource.NewURN("stack", "project", "", "example:index/user:User", "user")
+
+	props, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name":     resource.NewStringProperty("bob"),
+		"password": resource.NewStringProperty("old"),
+	}, plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	refresh := func() (*pulumirpc.ReadResponse, error) {
+		return p.Read(context.Background(), &pulumirpc.ReadRequest{
+			Id: "user", Urn: string(urn), Properties: props, Inputs: props})
+	}
+
+	// The custom read replaces the broken upstream read, and its secrets are marked as such.
+	resp, err := refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, "user", resp.GetId())
+	assert.Equal(t, "bob", read["name"].StringValue())
+	outs, err := plugin.UnmarshalProperties(resp.GetProperties(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", outs["name"].StringValue())
+	inputs, err := plugin.UnmarshalProperties(resp.GetInputs(), plugin.MarshalOptions{KeepSecrets: true})
+	assert.NoError(t, err)
+	assert.True(t, inputs["password"].IsSecret())
+
+	// Properties that are not in the resource's schema are rejected.
+	unknown = true
+	_, err = refresh()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown property "color"`)
+
+	// An empty ID means that the resource is gone.
+	exists, unknown = false, false
+	resp, err = refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, "", resp.GetId())
+
+	// Without a custom read, the upstream read is used.
+	info.CustomRead = nil
+	_, err = refresh()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "upstream read is broken")
+}
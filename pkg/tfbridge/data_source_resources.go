@@ -0,0 +1,306 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// DataSourceResourceInfo returns the info of the read-only resource that the given data source is exposed as, or nil
+// if the data source is not exposed as a resource. The info is resolved by ResolveResourceInfo as it is for any other
+// resource, without autonaming, since the resource does not create anything.
+//
+// Both tfgen and the provider use this info, so the schema and the provider always agree on the resource's shape.
+func DataSourceResourceInfo(ds shim.Resource, info *DataSourceInfo) *ResourceInfo {
+	if info == nil || info.ResourceTok == "" {
+		return nil
+	}
+	res, _ := ResolveResourceInfo(ds, info.ResourceTok, &ResourceInfo{
+		Tok:                info.ResourceTok,
+		Fields:             info.Fields,
+		Docs:               info.Docs,
+		DeprecationMessage: info.DeprecationMessage,
+		RenameID:           info.RenameID,
+	}, nil)
+	return res
+}
+
+// readDataSource reads the data source with the given name and config. Errors are reported against label.
+func (p *Provider) readDataSource(ctx context.Context, tfname, label string,
+	rescfg shim.ResourceConfig) (shim.InstanceState, error) {
+
+	diff, err := p.tf.ReadDataDiff(tfname, rescfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading data source diff for %s", label)
+	}
+
+	if err = p.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	state, err := p.tf.ReadDataApply(tfname, diff)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invoking %s", label)
+	}
+	return state, nil
+}
+
+// readDataSourceResource reads the data source behind the given resource with the given inputs, and returns the ID
+// and the outputs of the resource. During previews, or if any of the inputs are unknown, the data source is not read,
+// and the outputs that are not inputs are unknown.
+func (p *Provider) readDataSourceResource(ctx context.Context, urn resource.URN, res Resource,
+	news resource.PropertyMap, preview bool) (string, resource.PropertyMap, error) {
+
+	if preview || news.ContainsUnknowns() {
+		outs := news.Copy()
+		res.TF.Schema().Range(func(key string, sch shim.Schema) bool {
+			name, _, info := getInfoFromTerraformName(key, res.TF.Schema(), res.Schema.Fields, false)
+			if _, has := outs[name]; !has && sch.Computed() && (info == nil || !info.Omit) {
+				outs[name] = resource.MakeComputed(resource.NewStringProperty(""))
+			}
+			return true
+		})
+		return "", outs, nil
+	}
+
+	inputs, assets, err := MakeTerraformInputs(&PulumiResource{URN: urn, Properties: news}, p.configValues, nil, news,
+		res.TF.Schema(), res.Schema.Fields)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "preparing %s's inputs", urn)
+	}
+	state, err := p.readDataSource(ctx, res.TFName, string(urn), MakeTerraformConfigFromInputs(p.tf, inputs))
+	if err != nil {
+		return "", nil, err
+	}
+	if state == nil || state.ID() == "" {
+		return "", nil, errors.Errorf("data source %s returned no result for %s", res.TFName, urn)
+	}
+	props, err := MakeTerraformResult(p.tf, state, res.TF.Schema(), res.Schema.Fields, assets, p.supportsSecrets)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "converting result for %s", urn)
+	}
+	return state.ID(), props, nil
+}
+
+// marshalDataSourceResourceOutputs marshals the outputs of a data source resource.
+func (p *Provider) marshalDataSourceResourceOutputs(ctx context.Context, urn resource.URN, label string,
+	props resource.PropertyMap, preview bool) (*pbstruct.Struct, error) {
+
+	p.auditSecrets(ctx, urn, label, props)
+	mprops, err := plugin.MarshalProperties(props, plugin.MarshalOptions{
+		Label:        fmt.Sprintf("%s.outs", label),
+		KeepUnknowns: preview,
+		KeepSecrets:  p.supportsSecrets,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mprops, p.checkStateSize(string(urn), mprops)
+}
+
+func (p *Provider) checkDataSourceResource(ctx context.Context, res Resource,
+	req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, error) {
+
+	urn := resource.URN(req.GetUrn())
+	label := fmt.Sprintf("%s.Check(%s/%s)", p.label(), urn, res.TFName)
+	glog.V(9).Infof("%s executing", label)
+
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	inputs, assets, err := MakeTerraformInputs(&PulumiResource{URN: urn, Properties: news}, p.configValues, nil, news,
+		res.TF.Schema(), res.Schema.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	warns, errs := p.tf.ValidateDataSource(res.TFName, MakeTerraformConfigFromInputs(p.tf, inputs))
+	for _, warn := range warns {
+		if err = p.host.Log(ctx, diag.Warning, urn, fmt.Sprintf("%v verification warning: %v", urn, warn)); err != nil {
+			return nil, err
+		}
+	}
+	var failures []*pulumirpc.CheckFailure
+	for _, err := range errs {
+		failures = append(failures, &pulumirpc.CheckFailure{Reason: err.Error()})
+	}
+
+	pinputs := MakeTerraformOutputs(p.tf, inputs, res.TF.Schema(), res.Schema.Fields, assets, false, p.supportsSecrets)
+	minputs, err := plugin.MarshalProperties(pinputs, plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.inputs", label), KeepUnknowns: true})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.CheckResponse{Inputs: minputs, Failures: failures}, nil
+}
+
+// diffDataSourceResource reports changes to the arguments of a data source resource as updates, which read the data
+// source again. Arguments that are left unset but computed by the data source are not changes.
+func (p *Provider) diffDataSourceResource(res Resource, req *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+	label := fmt.Sprintf("%s.Diff(%s/%s)", p.label(), req.GetUrn(), res.TFName)
+	olds, err := plugin.UnmarshalProperties(req.GetOlds(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.olds", label), SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	res.TF.Schema().Range(func(key string, _ shim.Schema) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+
+	detailedDiff := map[string]*pulumirpc.PropertyDiff{}
+	var properties []string
+	for _, key := range keys {
+		sch := res.TF.Schema().Get(key)
+		name, _, info := getInfoFromTerraformName(key, res.TF.Schema(), res.Schema.Fields, false)
+		if !(sch.Optional() || sch.Required()) || (info != nil && info.Omit) {
+			continue
+		}
+		oldValue, hasOld := olds[name]
+		newValue, hasNew := news[name]
+		var kind pulumirpc.PropertyDiff_Kind
+		switch {
+		case hasOld && hasNew:
+			if oldValue.DeepEquals(newValue) {
+				continue
+			}
+			kind = pulumirpc.PropertyDiff_UPDATE
+		case hasNew:
+			kind = pulumirpc.PropertyDiff_ADD
+		case hasOld && !sch.Computed():
+			kind = pulumirpc.PropertyDiff_DELETE
+		default:
+			continue
+		}
+		detailedDiff[string(name)] = &pulumirpc.PropertyDiff{Kind: kind}
+		properties = append(properties, string(name))
+	}
+
+	changes := pulumirpc.DiffResponse_DIFF_NONE
+	if len(properties) > 0 {
+		changes = pulumirpc.DiffResponse_DIFF_SOME
+	}
+	return &pulumirpc.DiffResponse{
+		Changes:         changes,
+		Diffs:           properties,
+		DetailedDiff:    detailedDiff,
+		HasDetailedDiff: true,
+	}, nil
+}
+
+func (p *Provider) createDataSourceResource(ctx context.Context, res Resource,
+	req *pulumirpc.CreateRequest) (*pulumirpc.CreateResponse, error) {
+
+	urn := resource.URN(req.GetUrn())
+	label := fmt.Sprintf("%s.Create(%s/%s)", p.label(), urn, res.TFName)
+	glog.V(9).Infof("%s executing", label)
+
+	news, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	id, props, err := p.readDataSourceResource(ctx, urn, res, news, req.GetPreview())
+	if err != nil {
+		return nil, err
+	}
+	mprops, err := p.marshalDataSourceResourceOutputs(ctx, urn, label, props, req.GetPreview())
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.CreateResponse{Id: id, Properties: mprops}, nil
+}
+
+// refreshDataSourceResource refreshes a data source resource by reading the data source again with the resource's
+// arguments. Data source resources have no ID that they could be looked up by, so they cannot be imported.
+func (p *Provider) refreshDataSourceResource(ctx context.Context, res Resource,
+	req *pulumirpc.ReadRequest) (*pulumirpc.ReadResponse, error) {
+
+	urn := resource.URN(req.GetUrn())
+	label := fmt.Sprintf("%s.Read(%s, %s/%s)", p.label(), req.GetId(), urn, res.TFName)
+	glog.V(9).Infof("%s executing", label)
+
+	if len(req.GetProperties().GetFields()) == 0 {
+		return nil, errors.Errorf("%s reads the %s data source and cannot be imported; declare it with its "+
+			"arguments instead", urn, res.TFName)
+	}
+	inputs, err := plugin.UnmarshalProperties(req.GetInputs(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.inputs", label), SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	id, props, err := p.readDataSourceResource(ctx, urn, res, inputs, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "refreshing %s", urn)
+	}
+	mprops, err := p.marshalDataSourceResourceOutputs(ctx, urn, label, props, false)
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.ReadResponse{Id: id, Properties: mprops, Inputs: req.GetInputs()}, nil
+}
+
+func (p *Provider) updateDataSourceResource(ctx context.Context, res Resource,
+	req *pulumirpc.UpdateRequest) (*pulumirpc.UpdateResponse, error) {
+
+	urn := resource.URN(req.GetUrn())
+	label := fmt.Sprintf("%s.Update(%s/%s)", p.label(), urn, res.TFName)
+	glog.V(9).Infof("%s executing", label)
+
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	_, props, err := p.readDataSourceResource(ctx, urn, res, news, req.GetPreview())
+	if err != nil {
+		return nil, err
+	}
+	mprops, err := p.marshalDataSourceResourceOutputs(ctx, urn, label, props, req.GetPreview())
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.UpdateResponse{Properties: mprops}, nil
+}
+
+// deleteDataSourceResource removes a data source resource from state. There is nothing to delete upstream.
+func (p *Provider) deleteDataSourceResource(req *pulumirpc.DeleteRequest) (*pbempty.Empty, error) {
+	if err := p.checkProtectedDelete(resource.URN(req.GetUrn())); err != nil {
+		return nil, err
+	}
+	return &pbempty.Empty{}, nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestDataSourceResource(t *testing.T) {
+	const urn = "urn:pulumi:stack::project::test:index:WidgetReader::w"
+	marshal := func(m map[string]interface{}) *pbstruct.Struct {
+		s, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(m), plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		return s
+	}
+	unmarshal := func(s *pbstruct.Struct) resource.PropertyMap {
+		m, err := plugin.UnmarshalProperties(s, plugin.MarshalOptions{KeepUnknowns: true})
+		assert.NoError(t, err)
+		return m
+	}
+
+	reads := 0
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		DataSourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {
+				Schema: map[string]*schemav2.Schema{
+					"name":   {Type: schemav2.TypeString, Required: true},
+					"region": {Type: schemav2.TypeString, Optional: true, Computed: true},
+					"arn":    {Type: schemav2.TypeString, Computed: true},
+				},
+				Read: func(d *schemav2.ResourceData, _ interface{}) error {
+					reads++
+					d.SetId(d.Get("name").(string))
+					if _, ok := d.GetOk("region"); !ok {
+						assert.NoError(t, d.Set("region", "us-west-2"))
+					}
+					return d.Set("arn", "arn:"+d.Get("region").(string)+":"+d.Get("name").(string))
+				},
+			},
+		},
+	})
+	info := ProviderInfo{
+		Name: "test",
+		DataSources: map[string]*DataSourceInfo{
+			"test_widget": {Tok: "test:index:getWidget", ResourceTok: "test:index:WidgetReader"},
+		},
+	}
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+	assert.Equal(t, "test_widget", p.dsResources["test:index:WidgetReader"].TFName)
+	assert.Equal(t, "test_widget", p.dataSources["test:index:getWidget"].TFName)
+
+	// Previews do not read the data source, and leave its results unknown.
+	created, err := p.Create(context.Background(), &pulumirpc.CreateRequest{
+		Urn:        urn,
+		Properties: marshal(map[string]interface{}{"name": "w1"}),
+		Preview:    true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reads)
+	outs := unmarshal(created.GetProperties())
+	assert.Equal(t, resource.NewStringProperty("w1"), outs["name"])
+	assert.True(t, outs["arn"].IsComputed())
+
+	// Creating the resource reads the data source.
+	created, err = p.Create(context.Background(), &pulumirpc.CreateRequest{
+		Urn:        urn,
+		Properties: marshal(map[string]interface{}{"name": "w1"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reads)
+	assert.Equal(t, "w1", created.GetId())
+	outs = unmarshal(created.GetProperties())
+	assert.Equal(t, resource.NewStringProperty("arn:us-west-2:w1"), outs["arn"])
+
+	// Computed arguments that are left unset are not changes, and changed arguments are updates, not replacements.
+	diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"name": "w1"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_NONE, diff.GetChanges())
+	diff, err = p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"name": "w2", "region": "us-east-1"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_SOME, diff.GetChanges())
+	assert.Equal(t, []string{"name", "region"}, diff.GetDiffs())
+	assert.Empty(t, diff.GetReplaces())
+	assert.Equal(t, pulumirpc.PropertyDiff_UPDATE, diff.GetDetailedDiff()["region"].GetKind())
+
+	// Updating the resource reads the data source again.
+	updated, err := p.Update(context.Background(), &pulumirpc.UpdateRequest{
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"name": "w2", "region": "us-east-1"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reads)
+	assert.Equal(t, resource.NewStringProperty("arn:us-east-1:w2"),
+		unmarshal(updated.GetProperties())["arn"])
+
+	// Refreshing the resource reads the data source with the resource's inputs.
+	inputs := marshal(map[string]interface{}{"name": "w1"})
+	read, err := p.Read(context.Background(), &pulumirpc.ReadRequest{
+		Id:         "w1",
+		Urn:        urn,
+		Properties: created.GetProperties(),
+		Inputs:     inputs,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reads)
+	assert.Equal(t, "w1", read.GetId())
+	assert.Equal(t, inputs, read.GetInputs())
+
+	// Data source resources cannot be imported.
+	_, err = p.Read(context.Background(), &pulumirpc.ReadRequest{Id: "w1", Urn: urn})
+	assert.Error(t, err)
+
+	// Deleting the resource does not read the data source.
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{
+		Id:         "w1",
+		Urn:        urn,
+		Properties: created.GetProperties(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reads)
+}
+
+func TestDataSourceResourceInfo(t *testing.T) {
+	ds := shimv2.NewResource(&schemav2.Resource{Schema: map[string]*schemav2.Schema{
+		"id": {Type: schemav2.TypeString, Optional: true},
+	}})
+	assert.Nil(t, DataSourceResourceInfo(ds, nil))
+	assert.Nil(t, DataSourceResourceInfo(ds, &DataSourceInfo{Tok: "test:index:getWidget"}))
+
+	info := DataSourceResourceInfo(ds, &DataSourceInfo{
+		Tok:                "test:index:getWidget",
+		ResourceTok:        "test:index:WidgetReader",
+		DeprecationMessage: "use something else",
+	})
+	assert.Equal(t, "test:index:WidgetReader", string(info.Tok))
+	assert.Equal(t, "use something else", info.DeprecationMessage)
+	assert.Nil(t, info.Fields["id"])
+
+	// The id argument is only renamed if the data source opts in.
+	info = DataSourceResourceInfo(ds, &DataSourceInfo{
+		Tok:         "test:index:getWidget",
+		ResourceTok: "test:index:WidgetReader",
+		RenameID:    true,
+	})
+	assert.Equal(t, RenamedIDPropertyName, info.Fields["id"].Name)
+}
@@ -0,0 +1,76 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"os"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// debugInvokesEnvVar enables the bridge's debug invokes, which expose internal details of the running provider.
+const debugInvokesEnvVar = "PULUMI_TFBRIDGE_DEBUG_INVOKES"
+
+func debugInvokesEnabled() bool {
+	return cmdutil.IsTruthy(os.Getenv(debugInvokesEnvVar))
+}
+
+// runtimeStatsToken returns the token of the debug invoke that reports runtime statistics for the provider process.
+func (p *Provider) runtimeStatsToken() tokens.ModuleMember {
+	return tokens.ModuleMember(p.pkg() + ":tfbridge:runtimeStats")
+}
+
+// trackOperation records the start of an operation on the given token and returns a function that records its end.
+// Usage metrics, if enabled, are flushed whenever the provider becomes idle.
+func (p *Provider) trackOperation(operation, token string) func() {
+	atomic.AddInt64(&p.operations, 1)
+	p.usageMetrics.count(operation, token)
+	return func() {
+		if atomic.AddInt64(&p.operations, -1) == 0 {
+			p.usageMetrics.flush()
+		}
+	}
+}
+
+// invokeRuntimeStats returns memory, goroutine, and operation statistics for the running provider process.
+func (p *Provider) invokeRuntimeStats() (*pulumirpc.InvokeResponse, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"heapAlloc":        float64(mem.HeapAlloc),
+		"heapInuse":        float64(mem.HeapInuse),
+		"heapObjects":      float64(mem.HeapObjects),
+		"sys":              float64(mem.Sys),
+		"numGC":            float64(mem.NumGC),
+		"goroutines":       runtime.NumGoroutine(),
+		"activeOperations": float64(atomic.LoadInt64(&p.operations)),
+		"resources":        len(p.resources),
+		"dataSources":      len(p.dataSources),
+		"configured":       p.configValues != nil,
+	})
+
+	ret, err := plugin.MarshalProperties(stats, plugin.MarshalOptions{Label: "runtimeStats.ret"})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.InvokeResponse{Return: ret}, nil
+}
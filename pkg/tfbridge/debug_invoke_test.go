@@ -0,0 +1,49 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvokeRuntimeStats(t *testing.T) {
+	p := &Provider{module: "test"}
+	req := &pulumirpc.InvokeRequest{Tok: "test:tfbridge:runtimeStats"}
+
+	// Without the flag, the debug invoke is not available.
+	_, err := p.Invoke(context.Background(), req)
+	assert.Error(t, err)
+
+	os.Setenv(debugInvokesEnvVar, "true")
+	defer os.Unsetenv(debugInvokesEnvVar)
+
+	done := p.trackOperation("Create", "test:index:Resource")
+	resp, err := p.Invoke(context.Background(), req)
+	done()
+	assert.NoError(t, err)
+
+	ret, err := plugin.UnmarshalProperties(resp.GetReturn(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), ret["activeOperations"].NumberValue())
+	assert.True(t, ret["goroutines"].NumberValue() > 0)
+	assert.True(t, ret["heapAlloc"].NumberValue() > 0)
+	assert.False(t, ret["configured"].BoolValue())
+}
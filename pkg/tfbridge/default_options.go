@@ -0,0 +1,134 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// defaultResourceOptionsConfigKey is the bridge-level configuration key that sets resource options for every resource
+// whose type matches a pattern, e.g. {"retainOnDelete": ["aws:s3/*"], "protect": ["aws:rds/instance:Instance"]}. The
+// provider enforces the options itself, so that they apply to every program that uses the provider configuration. In
+// patterns, `*` matches any run of characters, including `/` and `:`. The key is only interpreted by the bridge if the
+// upstream provider does not define configuration with the same name.
+const defaultResourceOptionsConfigKey = "defaultResourceOptions"
+
+// defaultResourceOptions are the resource options that the provider enforces for resources of matching types.
+type defaultResourceOptions struct {
+	// resources that must not be deleted, neither directly nor by a replacement.
+	Protect []string `json:"protect,omitempty"`
+	// resources that are left in the cloud when deleted, and only removed from the stack.
+	RetainOnDelete []string `json:"retainOnDelete,omitempty"`
+
+	protect, retainOnDelete []*regexp.Regexp
+}
+
+// configureDefaultResourceOptions removes the default resource options from vars and enforces them from now on.
+// Providers that do not set ProviderInfo.DefaultOptions leave the key to the upstream provider.
+func (p *Provider) configureDefaultResourceOptions(vars resource.PropertyMap) error {
+	if !p.info.DefaultOptions {
+		return nil
+	}
+	value, ok := p.takeBridgeConfig(vars, defaultResourceOptionsConfigKey)
+	if !ok || value == "" {
+		return nil
+	}
+
+	var opts defaultResourceOptions
+	if err := json.Unmarshal([]byte(value), &opts); err != nil {
+		return errors.Errorf("malformed configuration value for '%v': must be a JSON object with optional "+
+			"\"protect\" and \"retainOnDelete\" arrays of type patterns", defaultResourceOptionsConfigKey)
+	}
+	opts.protect, opts.retainOnDelete = typePatterns(opts.Protect), typePatterns(opts.RetainOnDelete)
+	if len(opts.protect) == 0 && len(opts.retainOnDelete) == 0 {
+		return nil
+	}
+	p.defaultOptions = &opts
+	return nil
+}
+
+// typePatterns compiles patterns in which `*` matches any run of characters into regular expressions.
+func typePatterns(patterns []string) []*regexp.Regexp {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+		result = append(result, regexp.MustCompile("^"+quoted+"$"))
+	}
+	return result
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// protects returns true if resources of the given type must not be deleted.
+func (o *defaultResourceOptions) protects(urn resource.URN) bool {
+	return o != nil && matchesAny(o.protect, string(urn.Type()))
+}
+
+// retains returns true if resources of the given type are left in the cloud when deleted.
+func (o *defaultResourceOptions) retains(urn resource.URN) bool {
+	return o != nil && matchesAny(o.retainOnDelete, string(urn.Type()))
+}
+
+// checkProtectedReplace fails a diff that replaces a resource that the default resource options protect, as the
+// replacement would delete it.
+func (p *Provider) checkProtectedReplace(urn resource.URN, replaces []string) error {
+	if len(replaces) == 0 || !p.defaultOptions.protects(urn) {
+		return nil
+	}
+	return errors.Errorf("%s is protected by the provider's '%v' configuration, but changes to %s require "+
+		"replacing it", urn, defaultResourceOptionsConfigKey, strings.Join(replaces, ", "))
+}
+
+// checkProtectedDelete fails the deletion of a resource that the default resource options protect.
+func (p *Provider) checkProtectedDelete(urn resource.URN) error {
+	if !p.defaultOptions.protects(urn) {
+		return nil
+	}
+	return errors.Errorf("%s is protected by the provider's '%v' configuration and cannot be deleted", urn,
+		defaultResourceOptionsConfigKey)
+}
+
+// retainOnDelete returns true, warning the user, if the given resource must be left in the cloud rather than
+// deleted.
+func (p *Provider) retainOnDelete(ctx context.Context, urn resource.URN, id string) bool {
+	if !p.defaultOptions.retains(urn) {
+		return false
+	}
+	msg := fmt.Sprintf("%s (%s) was removed from the stack but not deleted, as the provider's '%v' configuration "+
+		"retains it", urn, id, defaultResourceOptionsConfigKey)
+	glog.V(9).Infof("%s", msg)
+	if p.host != nil {
+		if err := p.host.Log(ctx, diag.Warning, urn, msg); err != nil {
+			glog.V(9).Infof("failed to log retained resource: %v", err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureDefaultResourceOptions(t *testing.T) {
+	optedIn := ProviderInfo{DefaultOptions: true}
+	p := &Provider{info: optedIn}
+	vars := resource.PropertyMap{
+		"defaultResourceOptions": resource.NewStringProperty(
+			`{"protect": ["test:db/*"], "retainOnDelete": ["test:*:Bucket"]}`),
+		"region": resource.NewStringProperty("us-west-2"),
+	}
+	assert.NoError(t, p.configureDefaultResourceOptions(vars))
+	assert.Equal(t, resource.PropertyMap{"region": resource.NewStringProperty("us-west-2")}, vars)
+
+	urn := func(t tokens.Type) resource.URN { return resource.NewURN("stack", "project", "", t, "name") }
+	assert.True(t, p.defaultOptions.protects(urn("test:db/instance:Instance")))
+	assert.False(t, p.defaultOptions.protects(urn("test:index/db:Db")))
+	assert.True(t, p.defaultOptions.retains(urn("test:s3/bucket:Bucket")))
+	assert.False(t, p.defaultOptions.retains(urn("test:s3/bucketPolicy:BucketPolicy")))
+
+	// Patterns are matched literally, but for `*`.
+	p = &Provider{info: optedIn}
+	assert.NoError(t, p.configureDefaultResourceOptions(resource.PropertyMap{
+		"defaultResourceOptions": resource.NewStringProperty(`{"protect": ["test:index/a.b:A"]}`),
+	}))
+	assert.False(t, p.defaultOptions.protects(urn("test:index/axb:A")))
+
+	p = &Provider{info: optedIn}
+	assert.NoError(t, p.configureDefaultResourceOptions(resource.PropertyMap{
+		"defaultResourceOptions": resource.NewStringProperty(`{}`),
+	}))
+	assert.Nil(t, p.defaultOptions)
+	assert.Error(t, p.configureDefaultResourceOptions(resource.PropertyMap{
+		"defaultResourceOptions": resource.NewStringProperty(`["test:*"]`),
+	}))
+
+	// Providers that do not opt in leave the key alone.
+	p = &Provider{}
+	vars = resource.PropertyMap{"defaultResourceOptions": resource.NewStringProperty(`{"protect": ["test:*"]}`)}
+	assert.NoError(t, p.configureDefaultResourceOptions(vars))
+	assert.Nil(t, p.defaultOptions)
+	assert.Contains(t, vars, resource.PropertyKey("defaultResourceOptions"))
+}
+
+func TestDefaultResourceOptionsEnforcement(t *testing.T) {
+	p := &Provider{
+		info: ProviderInfo{DefaultOptions: true},
+		resources: map[tokens.Type]Resource{
+			"test:db/instance:Instance": {TFName: "test_db_instance", Schema: &ResourceInfo{}},
+			"test:s3/bucket:Bucket":     {TFName: "test_s3_bucket", Schema: &ResourceInfo{}},
+		},
+	}
+	assert.NoError(t, p.configureDefaultResourceOptions(resource.PropertyMap{
+		"defaultResourceOptions": resource.NewStringProperty(
+			`{"protect": ["test:db/*"], "retainOnDelete": ["test:s3/*"]}`),
+	}))
+	instance := resource.NewURN("stack", "project", "", "test:db/instance:Instance", "db")
+	bucket := resource.NewURN("stack", "project", "", "test:s3/bucket:Bucket", "bucket")
+
+	// Protected resources can neither be deleted nor replaced.
+	_, err := p.Delete(context.Background(), &pulumirpc.DeleteRequest{Urn: string(instance), Id: "db-1"})
+	assert.EqualError(t, err, string(instance)+" is protected by the provider's 'defaultResourceOptions' "+
+		"configuration and cannot be deleted")
+	assert.Error(t, p.checkProtectedReplace(instance, []string{"engine"}))
+	assert.NoError(t, p.checkProtectedReplace(instance, nil))
+	assert.NoError(t, p.checkProtectedReplace(bucket, []string{"name"}))
+
+	// Retained resources are removed from the stack without being deleted upstream.
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{Urn: string(bucket), Id: "bucket-1"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,108 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+const (
+	defaultDeleteVerificationTimeout  = 5 * time.Minute
+	defaultDeleteVerificationInterval = 5 * time.Second
+)
+
+// dependenciesRequireDeleteBeforeReplace returns true if a replacement of the resource keeps any of the inputs that it
+// declares must be unique in the cloud unchanged.
+func dependenciesRequireDeleteBeforeReplace(info *ResourceInfo, olds, news resource.PropertyMap) bool {
+	if info == nil {
+		return false
+	}
+	for _, name := range info.DeleteBeforeReplaceDependencies {
+		key := resource.PropertyKey(name)
+		v, ok := news[key]
+		if !ok || v.IsNull() || v.ContainsUnknowns() {
+			continue
+		}
+		if old, ok := olds[key]; ok && old.DeepEquals(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyDeleted polls until the deleted resource with the given ID and state is gone, if the resource requests it.
+// The timeout is the delete timeout of the request in seconds, or 0 if it has none.
+func (p *Provider) verifyDeleted(ctx context.Context, urn resource.URN, res Resource, id string,
+	state resource.PropertyMap, timeout float64) error {
+
+	if res.Schema == nil || res.Schema.DeleteVerification == nil {
+		return nil
+	}
+	verification := res.Schema.DeleteVerification
+
+	exists := verification.Exists
+	if exists == nil {
+		exists = func(ctx context.Context, id string, state resource.PropertyMap) (bool, error) {
+			instance, err := MakeTerraformState(res, id, state)
+			if err != nil {
+				return false, err
+			}
+			if err = p.waitForRateLimit(ctx); err != nil {
+				return false, err
+			}
+			instance, err = p.readResource(ctx, res, instance)
+			if err != nil {
+				return false, err
+			}
+			return instance != nil && instance.ID() != "", nil
+		}
+	}
+
+	wait := verification.Timeout
+	switch {
+	case timeout != 0:
+		wait = time.Duration(timeout * float64(time.Second))
+	case wait == 0:
+		wait = defaultDeleteVerificationTimeout
+	}
+	interval := verification.Interval
+	if interval == 0 {
+		interval = defaultDeleteVerificationInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	for {
+		found, err := exists(ctx, id, state)
+		if err != nil {
+			return errors.Wrapf(err, "verifying that %s was deleted", urn)
+		}
+		if !found {
+			return nil
+		}
+		glog.V(9).Infof("%s: still exists after it was deleted; checking again in %v", urn, interval)
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("%s still exists %v after it was deleted", urn, wait)
+		case <-time.After(interval):
+		}
+	}
+}
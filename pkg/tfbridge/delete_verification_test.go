@@ -0,0 +1,133 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestDeleteBeforeReplaceDependencies(t *testing.T) {
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Required: true, ForceNew: true},
+			"size": {Type: schemav2.TypeInt, Optional: true, ForceNew: true},
+		},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_disk": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/disk:Disk": {
+				TF:     shimv2.NewResource(tfRes),
+				TFName: "example_disk",
+				Schema: &ResourceInfo{
+					Tok:                             "example:index/disk:Disk",
+					DeleteBeforeReplaceDependencies: []string{"name"},
+				},
+			},
+		},
+	}
+	urn := resource.NewURN("stack", "project", "", "example:index/disk:Disk", "disk")
+
+	diff := func(name string) *pulumirpc.DiffResponse {
+		olds, err := plugin.MarshalProperties(resource.PropertyMap{
+			"name": resource.NewStringProperty("disk"),
+			"size": resource.NewNumberProperty(1),
+		}, plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		news, err := plugin.MarshalProperties(resource.PropertyMap{
+			"name": resource.NewStringProperty(name),
+			"size": resource.NewNumberProperty(2),
+		}, plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		resp, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+			Id: "disk", Urn: string(urn), Olds: olds, News: news})
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// A replacement that keeps the name would conflict with the old disk, so the old disk is deleted first.
+	resp := diff("disk")
+	assert.Equal(t, []string{"size"}, resp.GetReplaces())
+	assert.True(t, resp.GetDeleteBeforeReplace())
+
+	resp = diff("other")
+	assert.ElementsMatch(t, []string{"name", "size"}, resp.GetReplaces())
+	assert.False(t, resp.GetDeleteBeforeReplace())
+}
+
+func TestDeleteVerification(t *testing.T) {
+	reads := 0
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Required: true, ForceNew: true},
+		},
+		// The disk is still visible for two reads after the delete returns.
+		ReadContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			if reads++; reads > 2 {
+				d.SetId("")
+			}
+			return nil
+		},
+		DeleteContext: func(context.Context, *schemav2.ResourceData, interface{}) diag.Diagnostics {
+			return nil
+		},
+	}
+	info := &ResourceInfo{
+		Tok:                "example:index/disk:Disk",
+		DeleteVerification: &DeleteVerificationInfo{Interval: time.Millisecond},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_disk": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/disk:Disk": {TF: shimv2.NewResource(tfRes), TFName: "example_disk", Schema: info},
+		},
+	}
+	urn := resource.NewURN("stack", "project", "", "example:index/disk:Disk", "disk")
+	props, err := plugin.MarshalProperties(resource.PropertyMap{"name": resource.NewStringProperty("disk")},
+		plugin.MarshalOptions{})
+	assert.NoError(t, err)
+
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{Id: "disk", Urn: string(urn), Properties: props})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reads)
+
+	// Deletes fail if the resource does not disappear in time.
+	var ids []string
+	info.DeleteVerification = &DeleteVerificationInfo{
+		Exists: func(_ context.Context, id string, state resource.PropertyMap) (bool, error) {
+			ids = append(ids, id)
+			assert.Equal(t, "disk", state["name"].StringValue())
+			return true, nil
+		},
+		Timeout:  10 * time.Millisecond,
+		Interval: time.Millisecond,
+	}
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{Id: "disk", Urn: string(urn), Properties: props})
+	assert.EqualError(t, err, string(urn)+" still exists 10ms after it was deleted")
+	assert.NotEmpty(t, ids)
+	assert.Equal(t, "disk", ids[0])
+}
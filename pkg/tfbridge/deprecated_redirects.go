@@ -0,0 +1,72 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// ResourceRedirectMessage returns the deprecation message of the given resource if upstream deprecated it in favor of
+// another mapped resource, as given by ResourceInfo.DeprecatedInFavorOf, or "" otherwise.
+func (info ProviderInfo) ResourceRedirectMessage(res *ResourceInfo) string {
+	if res == nil || res.DeprecatedInFavorOf == "" {
+		return ""
+	}
+	replacement := info.Resources[res.DeprecatedInFavorOf]
+	if replacement == nil || replacement.Tok == "" {
+		return ""
+	}
+	return deprecatedInFavorOfMessage(tokens.ModuleMember(res.Tok), tokens.ModuleMember(replacement.Tok))
+}
+
+// DataSourceRedirectMessage returns the deprecation message of the given data source if upstream deprecated it in favor
+// of another mapped data source, as given by DataSourceInfo.DeprecatedInFavorOf, or "" otherwise.
+func (info ProviderInfo) DataSourceRedirectMessage(ds *DataSourceInfo) string {
+	if ds == nil || ds.DeprecatedInFavorOf == "" {
+		return ""
+	}
+	replacement := info.DataSources[ds.DeprecatedInFavorOf]
+	if replacement == nil || replacement.Tok == "" {
+		return ""
+	}
+	return deprecatedInFavorOfMessage(ds.Tok, replacement.Tok)
+}
+
+// deprecatedInFavorOfMessage returns a message in the same form as the ones of resources renamed by
+// ProviderInfo.RenameResourceWithAlias, e.g. `aws.s3.Foo has been deprecated in favor of aws.s3.FooV2`.
+func deprecatedInFavorOfMessage(tok, replacement tokens.ModuleMember) string {
+	displayName := func(tok tokens.ModuleMember) string {
+		module := string(tok.Module().Name())
+		if i := strings.Index(module, "/"); i != -1 {
+			module = module[:i]
+		}
+		return generateResourceName(tok.Package(), strings.ToLower(module), tok.Name().String())
+	}
+	return fmt.Sprintf("%s has been deprecated in favor of %s", displayName(tok), displayName(replacement))
+}
+
+// warnDeprecatedRedirect warns the user that the resource or data source they use was deprecated upstream in favor of
+// another one, if the given message is not empty. Each resource is warned about once per provider process, and each
+// data source once in all.
+func (p *Provider) warnDeprecatedRedirect(ctx context.Context, urn resource.URN, msg string) {
+	if msg != "" {
+		p.warnOnce(ctx, urn, msg)
+	}
+}
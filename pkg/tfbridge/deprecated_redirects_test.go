@@ -0,0 +1,45 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecatedRedirectMessages(t *testing.T) {
+	info := ProviderInfo{
+		Resources: map[string]*ResourceInfo{
+			"aws_foo":    {Tok: "aws:s3/foo:Foo", DeprecatedInFavorOf: "aws_foo_v2"},
+			"aws_foo_v2": {Tok: "aws:s3/fooV2:FooV2"},
+			"aws_bar":    {Tok: "aws:index/bar:Bar", DeprecatedInFavorOf: "aws_unmapped"},
+		},
+		DataSources: map[string]*DataSourceInfo{
+			"aws_foo":    {Tok: "aws:s3/getFoo:getFoo", DeprecatedInFavorOf: "aws_foo_v2"},
+			"aws_foo_v2": {Tok: "aws:s3/getFooV2:getFooV2"},
+		},
+	}
+
+	assert.Equal(t, "aws.s3.Foo has been deprecated in favor of aws.s3.FooV2",
+		info.ResourceRedirectMessage(info.Resources["aws_foo"]))
+	assert.Equal(t, "aws.s3.getFoo has been deprecated in favor of aws.s3.getFooV2",
+		info.DataSourceRedirectMessage(info.DataSources["aws_foo"]))
+
+	// Replacements, and redirects to members that are not mapped, get no message.
+	assert.Equal(t, "", info.ResourceRedirectMessage(info.Resources["aws_foo_v2"]))
+	assert.Equal(t, "", info.ResourceRedirectMessage(info.Resources["aws_bar"]))
+	assert.Equal(t, "", info.DataSourceRedirectMessage(nil))
+}
@@ -15,11 +15,14 @@
 package tfbridge
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 
 	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
@@ -294,3 +297,120 @@ func makeDetailedDiff(tfs shim.SchemaMap, ps map[string]*SchemaInfo, olds, news
 	}
 	return diff
 }
+
+// debugDiffEnvVar enables logging of the raw upstream diff for each resource that is diffed. This is intended to help
+// maintainers understand why a property is reported as changed or as requiring replacement.
+const debugDiffEnvVar = "PULUMI_TFBRIDGE_DEBUG_DIFF"
+
+// debugDiffEnabled returns true if upstream diffs should be logged, either because the bridge-specific flag is set or
+// because gRPC debugging has been requested.
+func debugDiffEnabled() bool {
+	return cmdutil.IsTruthy(os.Getenv(debugDiffEnvVar)) || os.Getenv("PULUMI_DEBUG_GRPC") != ""
+}
+
+// sensitiveDiffValue replaces the values of sensitive attributes in debug diff output.
+const sensitiveDiffValue = "<sensitive>"
+
+// debugAttrDiff is the JSON representation of a single attribute's upstream diff.
+type debugAttrDiff struct {
+	Old         string `json:"old,omitempty"`
+	New         string `json:"new,omitempty"`
+	NewComputed bool   `json:"newComputed,omitempty"`
+	NewRemoved  bool   `json:"newRemoved,omitempty"`
+	RequiresNew bool   `json:"requiresNew,omitempty"`
+}
+
+// debugDiff is the JSON representation of an upstream instance diff.
+type debugDiff struct {
+	Resource    string                   `json:"resource"`
+	Destroy     bool                     `json:"destroy,omitempty"`
+	RequiresNew bool                     `json:"requiresNew,omitempty"`
+	Attributes  map[string]debugAttrDiff `json:"attributes"`
+}
+
+// makeDebugDiff renders the upstream diff as JSON, with the values of sensitive attributes elided. An attribute is
+// sensitive if it is marked Sensitive upstream or Secret in its SchemaInfo, or if it holds a secret in the old or new
+// Pulumi properties.
+func makeDebugDiff(tfName string, tfs shim.SchemaMap, ps map[string]*SchemaInfo, olds, news resource.PropertyMap,
+	diff shim.InstanceDiff) ([]byte, error) {
+
+	dd := debugDiff{Resource: tfName, Attributes: map[string]debugAttrDiff{}}
+	if diff != nil {
+		dd.Destroy, dd.RequiresNew = diff.Destroy(), diff.RequiresNew()
+		for k, attr := range diff.Attributes() {
+			ad := debugAttrDiff{
+				Old:         attr.Old,
+				New:         attr.New,
+				NewComputed: attr.NewComputed,
+				NewRemoved:  attr.NewRemoved,
+				RequiresNew: attr.RequiresNew,
+			}
+			if attr.Sensitive || isSensitiveAttribute(k, tfs, ps, olds, news) {
+				if ad.Old != "" {
+					ad.Old = sensitiveDiffValue
+				}
+				if ad.New != "" {
+					ad.New = sensitiveDiffValue
+				}
+			}
+			dd.Attributes[k] = ad
+		}
+	}
+	return json.Marshal(dd)
+}
+
+// isSensitiveAttribute returns true if the flattened Terraform attribute key (e.g. `nested.0.secret`) refers to a
+// sensitive or secret value, or to a value nested inside of one. The given property maps are followed along the key so
+// that values that are secret in either of them are found.
+func isSensitiveAttribute(key string, tfs shim.SchemaMap, ps map[string]*SchemaInfo,
+	olds, news resource.PropertyMap) bool {
+
+	values := []resource.PropertyValue{resource.NewObjectProperty(olds), resource.NewObjectProperty(news)}
+	for _, part := range strings.Split(key, ".") {
+		var sch shim.Schema
+		var ok bool
+		if tfs != nil {
+			sch, ok = tfs.GetOk(part)
+		}
+		if !ok {
+			// Follow list indices into the values; set hashes and count markers lead nowhere.
+			for i, v := range values {
+				index, err := strconv.Atoi(part)
+				switch {
+				case err == nil && v.IsArray() && index < len(v.ArrayValue()):
+					values[i] = v.ArrayValue()[index]
+				case err == nil && v.IsObject():
+					// A MaxItemsOne block is a single object in Pulumi.
+				default:
+					values[i] = resource.NewNullProperty()
+				}
+			}
+		} else {
+			name, _, info := getInfoFromTerraformName(part, tfs, ps, false)
+			if sch.Sensitive() || info != nil && info.Secret != nil && *info.Secret {
+				return true
+			}
+			for i, v := range values {
+				if v.IsObject() {
+					values[i] = v.ObjectValue()[name]
+				} else {
+					values[i] = resource.NewNullProperty()
+				}
+			}
+
+			tfs, ps = nil, nil
+			if res, isres := sch.Elem().(shim.Resource); isres {
+				tfs = res.Schema()
+			}
+			if info != nil && info.Elem != nil {
+				ps = info.Elem.Fields
+			}
+		}
+		for _, v := range values {
+			if v.IsSecret() {
+				return true
+			}
+		}
+	}
+	return false
+}
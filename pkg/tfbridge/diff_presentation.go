@@ -0,0 +1,179 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// DiffPresentation controls how changes to a property are presented in previews.
+type DiffPresentation int
+
+const (
+	// DiffPresentationDefault presents the old and new values of the property in full.
+	DiffPresentationDefault DiffPresentation = iota
+	// DiffPresentationJSONSummary presents changes to a string property that holds a JSON document, such as a policy,
+	// as the JSON paths that were added, removed or changed instead of the old and new values, which is far easier to
+	// review than two large blobs. Changes that replace the resource are still presented in full as well.
+	DiffPresentationJSONSummary
+)
+
+// maxJSONDiffSummaryPaths bounds the number of paths listed in a JSON diff summary.
+const maxJSONDiffSummaryPaths = 20
+
+// summarizeJSONDiff returns the paths that differ between two JSON documents, each prefixed with "+" if it was added,
+// "-" if it was removed or "~" if it was changed, and true; or false if either value is not JSON.
+func summarizeJSONDiff(olds, news string) ([]string, bool) {
+	var oldValue, newValue interface{}
+	if err := json.Unmarshal([]byte(olds), &oldValue); err != nil {
+		return nil, false
+	}
+	if err := json.Unmarshal([]byte(news), &newValue); err != nil {
+		return nil, false
+	}
+	var paths []string
+	diffJSONValues("", oldValue, newValue, &paths)
+	return paths, true
+}
+
+func diffJSONValues(path string, olds, news interface{}, paths *[]string) {
+	switch olds := olds.(type) {
+	case map[string]interface{}:
+		if news, ok := news.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(olds)+len(news))
+			for k := range olds {
+				keys = append(keys, k)
+			}
+			for k := range news {
+				if _, has := olds[k]; !has {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				o, hasOld := olds[k]
+				n, hasNew := news[k]
+				switch {
+				case !hasOld:
+					*paths = append(*paths, "+ "+jsonObjectPath(path, k))
+				case !hasNew:
+					*paths = append(*paths, "- "+jsonObjectPath(path, k))
+				default:
+					diffJSONValues(jsonObjectPath(path, k), o, n, paths)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if news, ok := news.([]interface{}); ok {
+			for i := 0; i < len(olds) || i < len(news); i++ {
+				elem := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(olds):
+					*paths = append(*paths, "+ "+elem)
+				case i >= len(news):
+					*paths = append(*paths, "- "+elem)
+				default:
+					diffJSONValues(elem, olds[i], news[i], paths)
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(olds, news) {
+		if path == "" {
+			path = "(document)"
+		}
+		*paths = append(*paths, "~ "+path)
+	}
+}
+
+// jsonObjectPath returns the path of the given key of the object at the given path.
+func jsonObjectPath(path, key string) string {
+	if key == "" || strings.IndexFunc(key, func(r rune) bool {
+		return !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}) != -1 {
+		return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// presentJSONDiffSummaries logs a summary of the changes to each changed top-level property whose diff is presented as
+// a JSON summary, and removes the property's update from the detailed diff so that the engine does not also present
+// the old and new values. Updates that replace the resource are kept so that the reason for the replacement is shown.
+func (p *Provider) presentJSONDiffSummaries(ctx context.Context, urn resource.URN, label string, tfs shim.SchemaMap,
+	ps map[string]*SchemaInfo, olds, news resource.PropertyMap, detailedDiff map[string]*pulumirpc.PropertyDiff) {
+
+	for _, k := range sortedDiffPaths(detailedDiff) {
+		key := resource.PropertyKey(k)
+		_, _, info := getInfoFromPulumiName(key, tfs, ps, false)
+		if info == nil || info.DiffPresentation != DiffPresentationJSONSummary {
+			continue
+		}
+		o, n := olds[key], news[key]
+		if !o.IsString() || !n.IsString() {
+			continue
+		}
+		paths, ok := summarizeJSONDiff(o.StringValue(), n.StringValue())
+		if !ok || len(paths) == 0 {
+			continue
+		}
+
+		total, more := len(paths), ""
+		if total > maxJSONDiffSummaryPaths {
+			more = fmt.Sprintf("\n    ... and %d more", total-maxJSONDiffSummaryPaths)
+			paths = paths[:maxJSONDiffSummaryPaths]
+		}
+		msg := fmt.Sprintf("%s changed at %d JSON path(s):\n    %s%s", key, total, strings.Join(paths, "\n    "), more)
+		glog.V(9).Infof("%s %s", label, msg)
+		if p.host != nil {
+			if err := p.host.Log(ctx, diag.Info, urn, msg); err != nil {
+				glog.V(9).Infof("%s failed to log JSON diff summary: %v", label, err)
+				continue
+			}
+		}
+		if detailedDiff[k].Kind == pulumirpc.PropertyDiff_UPDATE {
+			delete(detailedDiff, k)
+		}
+	}
+}
+
+// sortedDiffPaths returns the paths in the given detailed diff that name top-level properties, in sorted order.
+func sortedDiffPaths(detailedDiff map[string]*pulumirpc.PropertyDiff) []string {
+	var paths []string
+	for k := range detailedDiff {
+		if !strings.ContainsAny(k, ".[") {
+			paths = append(paths, k)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,161 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestSummarizeJSONDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		olds     string
+		news     string
+		expected []string
+		ok       bool
+	}{
+		{
+			name:     "unchanged",
+			olds:     `{"Version": "2012-10-17", "Statement": []}`,
+			news:     `{"Statement":[],"Version":"2012-10-17"}`,
+			expected: nil,
+			ok:       true,
+		},
+		{
+			name: "nested changes",
+			olds: `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*"}], "Id": "x"}`,
+			news: `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": "s3:*"}, {}], ` +
+				`"aws:tag": true}`,
+			expected: []string{
+				"- Id",
+				"~ Statement[0].Effect",
+				"+ Statement[1]",
+				`+ ["aws:tag"]`,
+			},
+			ok: true,
+		},
+		{
+			name:     "changed type",
+			olds:     `{"a": [1]}`,
+			news:     `{"a": {"b": 1}}`,
+			expected: []string{"~ a"},
+			ok:       true,
+		},
+		{
+			name:     "scalar document",
+			olds:     `1`,
+			news:     `2`,
+			expected: []string{"~ (document)"},
+			ok:       true,
+		},
+		{
+			name: "not JSON",
+			olds: `{}`,
+			news: `not json`,
+			ok:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, ok := summarizeJSONDiff(tt.olds, tt.news)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+func TestDiffPresentationJSONSummary(t *testing.T) {
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"policy":       {Type: schemav2.TypeString, Optional: true},
+			"trust_policy": {Type: schemav2.TypeString, Optional: true, ForceNew: true},
+			"description":  {Type: schemav2.TypeString, Optional: true},
+		},
+	}
+	summary := &SchemaInfo{DiffPresentation: DiffPresentationJSONSummary}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_role": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/role:Role": {
+				TF:     shimv2.NewResource(tfRes),
+				TFName: "example_role",
+				Schema: &ResourceInfo{
+					Tok:    "example:index/role:Role",
+					Fields: map[string]*SchemaInfo{"policy": summary, "trust_policy": summary},
+				},
+			},
+		},
+	}
+
+	diff := func(olds, news resource.PropertyMap) *pulumirpc.DiffResponse {
+		molds, err := plugin.MarshalProperties(olds, plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		mnews, err := plugin.MarshalProperties(news, plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		resp, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+			Id:   "r",
+			Urn:  string(resource.NewURN("stack", "project", "", "example:index/role:Role", "r")),
+			Olds: molds,
+			News: mnews,
+		})
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// The summarized property is still changed, but its old and new values are no longer presented.
+	resp := diff(resource.PropertyMap{
+		"id":          resource.NewStringProperty("r"),
+		"policy":      resource.NewStringProperty(`{"Effect": "Allow"}`),
+		"description": resource.NewStringProperty("a"),
+	}, resource.PropertyMap{
+		"policy":      resource.NewStringProperty(`{"Effect": "Deny"}`),
+		"description": resource.NewStringProperty("b"),
+	})
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_SOME, resp.GetChanges())
+	assert.ElementsMatch(t, []string{"policy", "description"}, resp.GetDiffs())
+	assert.NotContains(t, resp.GetDetailedDiff(), "policy")
+	assert.Contains(t, resp.GetDetailedDiff(), "description")
+
+	// A change that is only presented as a summary is still a change.
+	resp = diff(resource.PropertyMap{
+		"id":     resource.NewStringProperty("r"),
+		"policy": resource.NewStringProperty(`{"Effect": "Allow"}`),
+	}, resource.PropertyMap{
+		"policy": resource.NewStringProperty(`{"Effect": "Deny"}`),
+	})
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_SOME, resp.GetChanges())
+	assert.Equal(t, []string{"policy"}, resp.GetDiffs())
+	assert.Empty(t, resp.GetDetailedDiff())
+
+	// Changes that replace the resource are still presented in full.
+	resp = diff(resource.PropertyMap{
+		"id":          resource.NewStringProperty("r"),
+		"trustPolicy": resource.NewStringProperty(`{"Effect": "Allow"}`),
+	}, resource.PropertyMap{
+		"trustPolicy": resource.NewStringProperty(`{"Effect": "Deny"}`),
+	})
+	assert.Equal(t, []string{"trustPolicy"}, resp.GetReplaces())
+	assert.Equal(t, pulumirpc.PropertyDiff_UPDATE_REPLACE, resp.GetDetailedDiff()["trustPolicy"].GetKind())
+}
@@ -1657,3 +1657,69 @@ func TestListNestedAddMaxItemsOne(t *testing.T) {
 			"prop.nest": AR,
 		})
 }
+
+func TestMakeDebugDiff(t *testing.T) {
+	tfs := map[string]*schema.Schema{
+		"name":     {Type: schema.TypeString, Optional: true, ForceNew: true},
+		"password": {Type: schema.TypeString, Optional: true, Sensitive: true},
+		"token":    {Type: schema.TypeString, Optional: true},
+		"key":      {Type: schema.TypeString, Optional: true},
+		"nested": {Type: schema.TypeList, Optional: true, Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"value": {Type: schema.TypeString, Optional: true},
+			},
+		}},
+	}
+	res := &schema.Resource{Schema: tfs}
+	provider := shimv1.NewProvider(&schema.Provider{
+		ResourcesMap: map[string]*schema.Resource{"resource": res},
+	})
+	secret := true
+	r := Resource{TF: shimv1.NewResource(res), Schema: &ResourceInfo{
+		Fields: map[string]*SchemaInfo{"key": {Secret: &secret}},
+	}}
+
+	olds := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name":     "foo",
+		"password": "hunter2",
+		"token":    "old-token",
+		"key":      "old-key",
+		"nesteds":  []interface{}{map[string]interface{}{"value": "old-value"}},
+	})
+	news := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name":     "bar",
+		"password": "correct-horse",
+		"token":    "new-token",
+		"key":      "new-key",
+		"nesteds":  []interface{}{map[string]interface{}{"value": "new-value"}},
+	})
+	tfState, err := MakeTerraformState(r, "id", olds)
+	assert.NoError(t, err)
+	config, _, err := MakeTerraformConfig(&Provider{tf: provider}, news, r.TF.Schema(), r.Schema.Fields)
+	assert.NoError(t, err)
+
+	tfDiff, err := provider.Diff("resource", tfState, config)
+	assert.NoError(t, err)
+
+	// The token is a secret in the old properties, and the nested value in the new ones.
+	olds["token"] = resource.MakeSecret(olds["token"])
+	news["nesteds"] = resource.NewArrayProperty([]resource.PropertyValue{
+		resource.NewObjectProperty(resource.PropertyMap{
+			"value": resource.MakeSecret(resource.NewStringProperty("new-value")),
+		}),
+	})
+
+	bytes, err := makeDebugDiff("resource", r.TF.Schema(), r.Schema.Fields, olds, news, tfDiff)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"resource": "resource",
+		"requiresNew": true,
+		"attributes": {
+			"name": {"old": "foo", "new": "bar", "requiresNew": true},
+			"password": {"old": "<sensitive>", "new": "<sensitive>"},
+			"token": {"old": "<sensitive>", "new": "<sensitive>"},
+			"key": {"old": "<sensitive>", "new": "<sensitive>"},
+			"nested.0.value": {"old": "<sensitive>", "new": "<sensitive>"}
+		}
+	}`, string(bytes))
+}
@@ -0,0 +1,133 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// EphemeralResourceInfo exposes an upstream ephemeral resource, e.g. a short-lived authorization token, as a Pulumi
+// function. Ephemeral resources do not fit the lifecycle of Pulumi resources: they are opened every time they are
+// used and Terraform never stores their values. Each invocation of the function opens the ephemeral resource again.
+//
+// Unlike data sources, ephemeral resources are only exposed if they are mapped, and tfgen only includes them in the
+// schema when it is run with --include-ephemeral-resources.
+//
+// Experimental: ephemeral resources are only available if the shim of the upstream provider implements
+// shim.EphemeralResourceProvider, which none of the shims in this repository do yet.
+type EphemeralResourceInfo struct {
+	Tok    tokens.ModuleMember
+	Fields map[string]*SchemaInfo
+	Docs   *DocInfo // overrides for finding and mapping TF docs.
+	// how long the values of the ephemeral resource stay valid once opened, e.g. 12 hours for a registry token, if
+	// known. The TTL is documented on the function.
+	TTL time.Duration
+}
+
+func (info *EphemeralResourceInfo) GetTok() tokens.Token              { return tokens.Token(info.Tok) }
+func (info *EphemeralResourceInfo) GetFields() map[string]*SchemaInfo { return info.Fields }
+func (info *EphemeralResourceInfo) GetDocs() *DocInfo                 { return info.Docs }
+
+// initEphemeralResources maps the function token of each mapped ephemeral resource to the ephemeral resource.
+func (p *Provider) initEphemeralResources() {
+	p.ephemerals = make(map[tokens.ModuleMember]DataSource)
+	ep, ok := p.tf.(shim.EphemeralResourceProvider)
+	if !ok {
+		return
+	}
+	ep.EphemeralResourcesMap().Range(func(name string, er shim.Resource) bool {
+		if info := p.info.EphemeralResources[name]; info != nil && info.Tok != "" {
+			p.ephemerals[info.Tok] = DataSource{
+				TF:     er,
+				TFName: name,
+				Schema: &DataSourceInfo{Tok: info.Tok, Fields: info.Fields, Docs: info.Docs},
+			}
+		}
+		return true
+	})
+}
+
+// invokeEphemeralResource opens the given ephemeral resource with the arguments of the given request, and returns
+// its values.
+func (p *Provider) invokeEphemeralResource(ctx context.Context, er DataSource,
+	req *pulumirpc.InvokeRequest) (resp *pulumirpc.InvokeResponse, err error) {
+
+	tok := req.GetTok()
+	label := fmt.Sprintf("%s.Invoke(%s)", p.label(), tok)
+	glog.V(9).Infof("%s executing", label)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Invoke", tok)()
+
+	args, err := plugin.UnmarshalProperties(req.GetArgs(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.args", label), KeepUnknowns: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	inputs, _, err := MakeTerraformInputs(
+		&PulumiResource{Properties: args}, p.configValues, nil, args, er.TF.Schema(), er.Schema.Fields)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't prepare ephemeral resource %v input state", er.TFName)
+	}
+
+	// Ensure the inputs are valid before opening the ephemeral resource, as for data sources.
+	ep := p.tf.(shim.EphemeralResourceProvider)
+	rescfg := MakeTerraformConfigFromInputs(p.tf, inputs)
+	warns, errs := ep.ValidateEphemeralResource(er.TFName, rescfg)
+	for _, warn := range warns {
+		if err = p.host.Log(ctx, diag.Warning, "", fmt.Sprintf("%v verification warning: %v", tok, warn)); err != nil {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 {
+		var failures []*pulumirpc.CheckFailure
+		for _, err := range errs {
+			failures = append(failures, &pulumirpc.CheckFailure{Reason: err.Error()})
+		}
+		return &pulumirpc.InvokeResponse{Failures: failures}, nil
+	}
+
+	if err = p.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	state, err := ep.OpenEphemeralResource(er.TFName, rescfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", tok)
+	}
+
+	// The values of ephemeral resources are usually credentials, so sensitive attributes are returned as secrets.
+	props, err := MakeTerraformResult(p.tf, state, er.TF.Schema(), er.Schema.Fields, nil, p.supportsSecrets)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := plugin.MarshalProperties(props, plugin.MarshalOptions{
+		Label:       fmt.Sprintf("%s.returns", label),
+		KeepSecrets: p.supportsSecrets,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.InvokeResponse{Return: ret}, nil
+}
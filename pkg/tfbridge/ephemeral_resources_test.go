@@ -0,0 +1,100 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+// ephemeralProvider exposes the data sources of the provider it wraps as ephemeral resources.
+type ephemeralProvider struct {
+	shim.Provider
+	opened int
+}
+
+func (p *ephemeralProvider) EphemeralResourcesMap() shim.ResourceMap { return p.DataSourcesMap() }
+
+func (p *ephemeralProvider) ValidateEphemeralResource(t string, c shim.ResourceConfig) ([]string, []error) {
+	return p.ValidateDataSource(t, c)
+}
+
+func (p *ephemeralProvider) OpenEphemeralResource(t string, c shim.ResourceConfig) (shim.InstanceState, error) {
+	p.opened++
+	diff, err := p.ReadDataDiff(t, c)
+	if err != nil {
+		return nil, err
+	}
+	return p.ReadDataApply(t, diff)
+}
+
+func TestEphemeralResources(t *testing.T) {
+	tf := &ephemeralProvider{Provider: shimv2.NewProvider(&schemav2.Provider{
+		DataSourcesMap: map[string]*schemav2.Resource{
+			"test_token": {
+				Schema: map[string]*schemav2.Schema{
+					"registry": {Type: schemav2.TypeString, Required: true},
+					"password": {Type: schemav2.TypeString, Computed: true, Sensitive: true},
+				},
+				Read: func(d *schemav2.ResourceData, _ interface{}) error {
+					d.SetId(d.Get("registry").(string))
+					return d.Set("password", "secret-for-"+d.Get("registry").(string))
+				},
+			},
+		},
+	})}
+	info := ProviderInfo{
+		Name:               "test",
+		P:                  tf,
+		EphemeralResources: map[string]*EphemeralResourceInfo{"test_token": {Tok: "test:index:getToken"}},
+	}
+	assert.NoError(t, info.Validate())
+
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+	p.supportsSecrets = true
+	args, err := plugin.MarshalProperties(resource.PropertyMap{
+		"registry": resource.NewStringProperty("example.com"),
+	}, plugin.MarshalOptions{})
+	assert.NoError(t, err)
+
+	// Every invocation opens the ephemeral resource again.
+	for i := 1; i <= 2; i++ {
+		resp, err := p.Invoke(context.Background(), &pulumirpc.InvokeRequest{Tok: "test:index:getToken", Args: args})
+		assert.NoError(t, err)
+		assert.Equal(t, i, tf.opened)
+		ret, err := plugin.UnmarshalProperties(resp.GetReturn(), plugin.MarshalOptions{KeepSecrets: true})
+		assert.NoError(t, err)
+		assert.Equal(t, resource.MakeSecret(resource.NewStringProperty("secret-for-example.com")), ret["password"])
+	}
+
+	// Invalid arguments fail validation without opening the ephemeral resource.
+	resp, err := p.Invoke(context.Background(), &pulumirpc.InvokeRequest{Tok: "test:index:getToken"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.GetFailures())
+	assert.Equal(t, 2, tf.opened)
+
+	// Providers without ephemeral resources cannot map them.
+	info.P = tf.Provider
+	assert.Error(t, info.Validate())
+}
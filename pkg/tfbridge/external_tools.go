@@ -0,0 +1,132 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+)
+
+// externalToolVersionTimeout is how long an external program may take to print its version.
+const externalToolVersionTimeout = 10 * time.Second
+
+// externalToolVersionRegexp matches the first version number in the output of an external program, e.g.
+// "Client Version: v1.21.3" or "azure-cli 2.30.0".
+var externalToolVersionRegexp = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?`)
+
+// ExternalTool is an external program that the upstream provider runs, e.g. kubectl or az, and that therefore has to
+// be installed wherever the provider runs. Missing or outdated programs are reported when the provider is configured,
+// with instructions for installing them, rather than in the middle of an update.
+type ExternalTool struct {
+	Name        string   // the name of the program, which is looked up on the PATH.
+	MinVersion  string   // the minimum version of the program, if any, e.g. "1.20.0".
+	VersionArgs []string // the arguments that make the program print its version (default "--version").
+	InstallURL  string   // a URL with instructions for installing the program, if any.
+	Optional    bool     // true to only warn about the program, e.g. if few resources need it.
+}
+
+func (tool ExternalTool) validate() error {
+	if tool.Name == "" {
+		return errors.New("external tools must have a Name")
+	}
+	if tool.MinVersion != "" {
+		if _, err := semver.ParseTolerant(tool.MinVersion); err != nil {
+			return errors.Wrapf(err, "external tool %s: invalid MinVersion %q", tool.Name, tool.MinVersion)
+		}
+	}
+	return nil
+}
+
+// check returns an error that explains how to fix the problem if the program is not installed or is too old.
+func (tool ExternalTool) check(ctx context.Context) error {
+	path, err := exec.LookPath(tool.Name)
+	if err != nil {
+		return tool.errorf("%s was not found on the PATH", tool.Name)
+	}
+	if tool.MinVersion == "" {
+		return nil
+	}
+	required, err := semver.ParseTolerant(tool.MinVersion)
+	if err != nil {
+		return err
+	}
+
+	args := tool.VersionArgs
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, externalToolVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if err != nil {
+		return tool.errorf("could not get the version of %s (%s %s): %v", tool.Name, path, strings.Join(args, " "),
+			err)
+	}
+	found := externalToolVersionRegexp.FindString(string(out))
+	version, err := semver.ParseTolerant(found)
+	if err != nil {
+		return tool.errorf("could not find the version of %s in the output of %s %s", tool.Name, path,
+			strings.Join(args, " "))
+	}
+	if version.LT(required) {
+		return tool.errorf("%s %s is installed at %s, but version %s or later is required", tool.Name, found, path,
+			tool.MinVersion)
+	}
+	return nil
+}
+
+func (tool ExternalTool) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if tool.InstallURL != "" {
+		return errors.Errorf("%s; see %s for how to install it", msg, tool.InstallURL)
+	}
+	return errors.Errorf("%s; install it and make sure that it is on the PATH", msg)
+}
+
+// checkExternalTools checks that the external programs that the upstream provider runs are installed. Problems with
+// optional programs are reported as warnings; problems with the others fail the configuration.
+func (p *Provider) checkExternalTools(ctx context.Context) error {
+	var problems []string
+	for _, tool := range p.info.ExternalTools {
+		err := tool.check(ctx)
+		switch {
+		case err == nil:
+			continue
+		case tool.Optional:
+			glog.V(5).Infof("%s: %v", p.label(), err)
+			if p.host != nil {
+				if lerr := p.host.Log(ctx, diag.Warning, "", err.Error()); lerr != nil {
+					glog.V(5).Infof("failed to log external tool check: %v", lerr)
+				}
+			}
+		default:
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("the %s provider requires programs that are missing or outdated:\n  %s", p.module,
+			strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckExternalTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as external tools")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"Client Version: v1.21.3\"\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0700))
+	defer setEnv(t, "PATH", dir)()
+	ctx := context.Background()
+
+	assert.NoError(t, ExternalTool{Name: "kubectl"}.check(ctx))
+	assert.NoError(t, ExternalTool{Name: "kubectl", MinVersion: "1.20"}.check(ctx))
+
+	err := ExternalTool{Name: "kubectl", MinVersion: "1.22.0", InstallURL: "https://kubernetes.io"}.check(ctx)
+	assert.EqualError(t, err, "kubectl 1.21.3 is installed at "+filepath.Join(dir, "kubectl")+
+		", but version 1.22.0 or later is required; see https://kubernetes.io for how to install it")
+
+	err = ExternalTool{Name: "az"}.check(ctx)
+	assert.EqualError(t, err, "az was not found on the PATH; install it and make sure that it is on the PATH")
+
+	// Only required programs fail the configuration.
+	p := &Provider{module: "test", info: ProviderInfo{ExternalTools: []ExternalTool{
+		{Name: "kubectl", MinVersion: "1.20.0"},
+		{Name: "helm", Optional: true},
+	}}}
+	assert.NoError(t, p.checkExternalTools(ctx))
+	p.info.ExternalTools = append(p.info.ExternalTools, ExternalTool{Name: "az"})
+	assert.EqualError(t, p.checkExternalTools(ctx), "the test provider requires programs that are missing or "+
+		"outdated:\n  az was not found on the PATH; install it and make sure that it is on the PATH")
+}
+
+func TestValidateExternalTools(t *testing.T) {
+	assert.NoError(t, ExternalTool{Name: "kubectl", MinVersion: "v1.20"}.validate())
+	assert.Error(t, ExternalTool{MinVersion: "1.20.0"}.validate())
+	assert.Error(t, ExternalTool{Name: "kubectl", MinVersion: "latest"}.validate())
+}
@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// RenamedIDPropertyName is the name that a resource's user-settable `id` argument is given, as Pulumi reserves `id`
+// for the ID of every resource.
+const RenamedIDPropertyName = "resourceId"
+
+// RenameIDProperty returns the info for the given resource with its `id` property renamed if the resource opts in with
+// RenameID and the property is an input. The property is renamed to `resourceId`, or to `<resource>Id`, e.g. `widgetId`
+// for `pkg:index/widget:Widget`, if the resource already has a `resourceId` property. The given info is copied rather
+// than modified, and is returned as is if it already names the property. The new name is returned as well, or "" if
+// the property is not renamed.
+func RenameIDProperty(res shim.Resource, tok tokens.Type, info *ResourceInfo) (*ResourceInfo, string) {
+	if res == nil || info == nil || !info.RenameID {
+		return info, ""
+	}
+	tfs := res.Schema()
+	sch, ok := tfs.GetOk("id")
+	if !ok || sch.Removed() != "" || !(sch.Optional() || sch.Required()) {
+		return info, ""
+	}
+	fields := info.Fields
+	if idInfo := fields["id"]; idInfo != nil && (idInfo.Name != "" || idInfo.Omit) {
+		return info, ""
+	}
+
+	taken := map[string]bool{}
+	tfs.Range(func(key string, _ shim.Schema) bool {
+		if key != "id" {
+			name, _, _ := getInfoFromTerraformName(key, tfs, fields, false)
+			taken[string(name)] = true
+		}
+		return true
+	})
+	candidates := []string{RenamedIDPropertyName}
+	if typeName := string(tok.Name()); tok != "" && typeName != "" {
+		r, size := utf8.DecodeRuneInString(typeName)
+		candidates = append(candidates, string(unicode.ToLower(r))+typeName[size:]+"Id")
+	}
+	var name string
+	for _, candidate := range candidates {
+		if !taken[candidate] {
+			name = candidate
+			break
+		}
+	}
+	if name == "" {
+		return info, ""
+	}
+
+	renamed := *info
+	renamed.Fields = make(map[string]*SchemaInfo, len(fields)+1)
+	for k, v := range fields {
+		renamed.Fields[k] = v
+	}
+	idInfo := SchemaInfo{}
+	if fields["id"] != nil {
+		idInfo = *fields["id"]
+	}
+	idInfo.Name = name
+	renamed.Fields["id"] = &idInfo
+	return &renamed, name
+}
+
+// ResolveResourceInfo returns the info that both tfgen and the provider use for the given resource: the given info
+// with its `id` property renamed by RenameIDProperty and its autonamed property configured by ApplyAutoNaming, so that
+// the schema and the provider always agree on the resource's properties. The new name of the `id` property is returned
+// as well, or "" if the property is not renamed.
+func ResolveResourceInfo(res shim.Resource, tok tokens.Type, info *ResourceInfo,
+	autoNaming *AutoNamingInfo) (*ResourceInfo, string) {
+
+	info, renamedID := RenameIDProperty(res, tok, info)
+	return ApplyAutoNaming(res, info, autoNaming), renamedID
+}
@@ -0,0 +1,127 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestRenameIDProperty(t *testing.T) {
+	resourceWith := func(id *schema.Schema, others ...string) shim.Resource {
+		fields := map[string]*schema.Schema{"id": id}
+		for _, other := range others {
+			fields[other] = &schema.Schema{Type: shim.TypeString, Optional: true}
+		}
+		return (&schema.Resource{Schema: schemaMap(fields)}).Shim()
+	}
+	settable := &schema.Schema{Type: shim.TypeString, Optional: true, Computed: true}
+
+	// IDs are left alone unless the resource opts in.
+	info := &ResourceInfo{Tok: "test:index/widget:Widget"}
+	renamed, name := RenameIDProperty(resourceWith(settable), info.Tok, info)
+	assert.Equal(t, "", name)
+	assert.Equal(t, info, renamed)
+	renamed, name = RenameIDProperty(resourceWith(settable), info.Tok, nil)
+	assert.Equal(t, "", name)
+	assert.Nil(t, renamed)
+
+	// Computed-only IDs are left alone.
+	info = &ResourceInfo{Tok: "test:index/widget:Widget", RenameID: true}
+	renamed, name = RenameIDProperty(resourceWith(&schema.Schema{Type: shim.TypeString, Computed: true}),
+		info.Tok, info)
+	assert.Equal(t, "", name)
+	assert.Equal(t, info, renamed)
+
+	// Settable IDs are renamed, without modifying the original info.
+	renamed, name = RenameIDProperty(resourceWith(settable), info.Tok, info)
+	assert.Equal(t, "resourceId", name)
+	assert.Equal(t, "resourceId", renamed.Fields["id"].Name)
+	assert.Equal(t, info.Tok, renamed.Tok)
+	assert.Nil(t, info.Fields)
+
+	// A resource that already has a resourceId property gets a name derived from its type instead.
+	_, name = RenameIDProperty(resourceWith(settable, "resource_id"), info.Tok, info)
+	assert.Equal(t, "widgetId", name)
+
+	// Explicit names win.
+	info = &ResourceInfo{
+		Tok:      "test:index/widget:Widget",
+		Fields:   map[string]*SchemaInfo{"id": {Name: "widgetName"}},
+		RenameID: true,
+	}
+	renamed, name = RenameIDProperty(resourceWith(settable), info.Tok, info)
+	assert.Equal(t, "", name)
+	assert.Equal(t, info, renamed)
+}
+
+func TestRenamedIDRoundTrip(t *testing.T) {
+	res := (&schema.Resource{Schema: schemaMap(map[string]*schema.Schema{
+		"id":   {Type: shim.TypeString, Optional: true, Computed: true},
+		"name": {Type: shim.TypeString, Optional: true},
+	})}).Shim()
+	info, _ := RenameIDProperty(res, "test:index/widget:Widget", &ResourceInfo{RenameID: true})
+
+	inputs, _, err := MakeTerraformInputs(nil, nil, nil, resource.PropertyMap{
+		"resourceId": resource.NewStringProperty("my-widget"),
+		"name":       resource.NewStringProperty("widget"),
+	}, res.Schema(), info.Fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-widget", inputs["id"])
+	assert.Equal(t, "widget", inputs["name"])
+
+	outputs := MakeTerraformOutputs(shimv2.NewProvider(testTFProviderV2),
+		map[string]interface{}{"id": "my-widget", "name": "widget"}, res.Schema(), info.Fields, nil, false, true)
+	assert.Equal(t, resource.PropertyMap{
+		"resourceId": resource.NewStringProperty("my-widget"),
+		"name":       resource.NewStringProperty("widget"),
+	}, outputs)
+}
+
+func TestProviderRenamesIDs(t *testing.T) {
+	settable := map[string]*schemav2.Schema{"id": {Type: schemav2.TypeString, Optional: true, Computed: true}}
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {Schema: settable},
+			"test_gizmo":  {Schema: settable},
+			"test_gadget": {Schema: settable},
+		},
+	})
+	info := ProviderInfo{
+		Name: "test",
+		Resources: map[string]*ResourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget", RenameID: true},
+			"test_gizmo":  {Tok: "test:index/gizmo:Gizmo"},
+		},
+	}
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, nil)
+
+	// Only the resource that opts in is renamed, as it is in the schema; unmapped resources are never renamed.
+	assert.Equal(t, RenamedIDPropertyName, p.resources["test:index/widget:Widget"].Schema.Fields["id"].Name)
+	assert.Nil(t, p.resources["test:index/gizmo:Gizmo"].Schema.Fields["id"])
+	for tok, res := range p.resources {
+		if res.TFName == "test_gadget" && res.Schema != nil {
+			assert.Nil(t, res.Schema.Fields["id"], tok)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// sortedImportInputs returns the names of the given resource's import inputs in sorted order.
+func sortedImportInputs(info *ResourceInfo) []string {
+	if info == nil {
+		return nil
+	}
+	names := make([]string, 0, len(info.ImportInputs))
+	for name, input := range info.ImportInputs {
+		if input != nil && input.Placeholder != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fillImportPlaceholders sets each of the given inputs that the resource declares cannot be read on import, and that
+// the read did not recover, to its placeholder.
+func fillImportPlaceholders(inputs resource.PropertyMap, info *ResourceInfo) {
+	for _, name := range sortedImportInputs(info) {
+		key := resource.PropertyKey(name)
+		if v, ok := inputs[key]; ok && !v.IsNull() {
+			continue
+		}
+		inputs[key] = resource.NewPropertyValue(info.ImportInputs[name].Placeholder)
+	}
+}
+
+// checkImportPlaceholders returns a failure for each required import input that still holds its placeholder.
+func checkImportPlaceholders(urn resource.URN, news resource.PropertyMap, info *ResourceInfo) []*pulumirpc.CheckFailure {
+	var failures []*pulumirpc.CheckFailure
+	for _, name := range sortedImportInputs(info) {
+		input := info.ImportInputs[name]
+		if !input.Required {
+			continue
+		}
+
+		v, ok := news[resource.PropertyKey(name)]
+		if !ok {
+			continue
+		}
+		if v.IsSecret() {
+			v = v.SecretValue().Element
+		}
+		if !v.DeepEquals(resource.NewPropertyValue(input.Placeholder)) {
+			continue
+		}
+
+		failures = append(failures, &pulumirpc.CheckFailure{
+			Property: name,
+			Reason: fmt.Sprintf("%s.%s cannot be read from the cloud when the resource is imported and still holds "+
+				"its placeholder value %#v: set it to the real value", urn.Name(), name, input.Placeholder),
+		})
+	}
+	return failures
+}
@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestImportInputs(t *testing.T) {
+	// The upstream API never returns the password, and force_destroy only affects how the resource is deleted.
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name":          {Type: schemav2.TypeString, Required: true, ForceNew: true},
+			"password":      {Type: schemav2.TypeString, Required: true, Sensitive: true},
+			"force_destroy": {Type: schemav2.TypeBool, Optional: true},
+		},
+		ReadContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return diag.FromErr(d.Set("name", d.Id()))
+		},
+		DeleteContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return nil
+		},
+		Importer: &schemav2.ResourceImporter{StateContext: schemav2.ImportStatePassthroughContext},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_db": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/db:Db": {
+				TF:     shimv2.NewResource(tfRes),
+				TFName: "example_db",
+				Schema: &ResourceInfo{
+					Tok: "example:index/db:Db",
+					ImportInputs: map[string]*ImportInputInfo{
+						"password":     {Placeholder: "<password>", Required: true},
+						"forceDestroy": {Placeholder: false},
+					},
+				},
+			},
+		},
+	}
+	urn := resource.NewURN("stack", "project", "", "example:index/db:Db", "db")
+
+	// Importing reports the inputs that cannot be read with their placeholders.
+	read, err := p.Read(context.Background(), &pulumirpc.ReadRequest{Urn: string(urn), Id: "main"})
+	assert.NoError(t, err)
+	inputs, err := plugin.UnmarshalProperties(read.GetInputs(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, resource.PropertyMap{
+		"__defaults":   resource.NewArrayProperty([]resource.PropertyValue{}),
+		"name":         resource.NewStringProperty("main"),
+		"password":     resource.NewStringProperty("<password>"),
+		"forceDestroy": resource.NewBoolProperty(false),
+	}, inputs)
+
+	check := func(inputs resource.PropertyMap) []*pulumirpc.CheckFailure {
+		news, err := plugin.MarshalProperties(inputs, plugin.MarshalOptions{KeepSecrets: true})
+		assert.NoError(t, err)
+		resp, err := p.Check(context.Background(), &pulumirpc.CheckRequest{Urn: string(urn), News: news})
+		assert.NoError(t, err)
+		return resp.GetFailures()
+	}
+
+	// The required password fails to check until its placeholder is replaced, even if it is marked secret.
+	failures := check(inputs)
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, "password", failures[0].GetProperty())
+		assert.Contains(t, failures[0].GetReason(), `still holds its placeholder value "<password>"`)
+	}
+	inputs["password"] = resource.MakeSecret(resource.NewStringProperty("<password>"))
+	assert.Len(t, check(inputs), 1)
+	inputs["password"] = resource.NewStringProperty("hunter2")
+	assert.Empty(t, check(inputs))
+}
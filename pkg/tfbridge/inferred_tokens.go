@@ -0,0 +1,183 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// InferredTokensKey is the key under the Pulumi schema's language section that records the tokens that tfgen inferred
+// from ProviderInfo.ModulePrefixes, so that the provider serves the same tokens as the schema describes.
+const InferredTokensKey = "tfbridgeInferredTokens"
+
+// InferredTokens maps the Terraform names of resources and data sources without an entry in the ProviderInfo to the
+// tokens inferred for them from ProviderInfo.ModulePrefixes.
+type InferredTokens struct {
+	Resources   map[string]string `json:"resources,omitempty"`
+	DataSources map[string]string `json:"dataSources,omitempty"`
+}
+
+// Len returns the number of inferred tokens.
+func (t InferredTokens) Len() int {
+	return len(t.Resources) + len(t.DataSources)
+}
+
+// InferModule returns the module that ProviderInfo.ModulePrefixes assigns the given Terraform name to, along with the
+// prefix that matched. A name matches a prefix that it is equal to or starts with; the longest matching prefix wins.
+func (info ProviderInfo) InferModule(tfName string) (string, string, bool) {
+	var module, match string
+	for mod, prefixes := range info.ModulePrefixes {
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(tfName, prefix) || len(prefix) < len(match) {
+				continue
+			}
+			// Break ties between modules deterministically.
+			if len(prefix) == len(match) && mod > module {
+				continue
+			}
+			module, match = mod, prefix
+		}
+	}
+	return module, match, match != ""
+}
+
+// inferredName returns the name that the token inferred for the given Terraform name is based on. The prefix that
+// matched is removed if it ends in an underscore, e.g. `aws_ec2_fleet` becomes `fleet` under `aws_ec2_`; otherwise
+// only the provider's resource prefix is, e.g. `aws_instance` becomes `instance` under `aws_instance`.
+func (info ProviderInfo) inferredName(tfName, prefix string) string {
+	if strings.HasSuffix(prefix, "_") && len(tfName) > len(prefix) {
+		return tfName[len(prefix):]
+	}
+	return strings.TrimPrefix(tfName, info.GetResourcePrefix()+"_")
+}
+
+// InferResourceToken returns the token inferred for the given Terraform resource from ProviderInfo.ModulePrefixes,
+// e.g. `aws:ec2/fleet:Fleet` for `aws_ec2_fleet`, or false if the resource does not match any prefix.
+func (info ProviderInfo) InferResourceToken(pkg, tfName string) (tokens.Type, bool) {
+	module, prefix, ok := info.InferModule(tfName)
+	if !ok {
+		return "", false
+	}
+	name := info.inferredName(tfName, prefix)
+	camelName, pascalName := TerraformToPulumiName(name, nil, nil, false), TerraformToPulumiName(name, nil, nil, true)
+	return tokens.Type(pkg + ":" + module + "/" + camelName + ":" + pascalName), true
+}
+
+// InferDataSourceToken returns the token inferred for the given Terraform data source from
+// ProviderInfo.ModulePrefixes, e.g. `aws:ec2/getFleet:getFleet` for `aws_ec2_fleet`, or false if the data source does
+// not match any prefix.
+func (info ProviderInfo) InferDataSourceToken(pkg, tfName string) (tokens.ModuleMember, bool) {
+	module, prefix, ok := info.InferModule(tfName)
+	if !ok {
+		return "", false
+	}
+	name := "get" + TerraformToPulumiName(info.inferredName(tfName, prefix), nil, nil, true)
+	return tokens.ModuleMember(pkg + ":" + module + "/" + name + ":" + name), true
+}
+
+// InferTokens infers tokens for every upstream resource and data source that has no entry in the ProviderInfo and
+// matches one of ProviderInfo.ModulePrefixes.
+func (info ProviderInfo) InferTokens(pkg string) InferredTokens {
+	inferred := InferredTokens{Resources: map[string]string{}, DataSources: map[string]string{}}
+	if len(info.ModulePrefixes) == 0 || info.P == nil {
+		return inferred
+	}
+	info.P.ResourcesMap().Range(func(name string, _ shim.Resource) bool {
+		if _, has := info.Resources[name]; !has {
+			if tok, ok := info.InferResourceToken(pkg, name); ok {
+				inferred.Resources[name] = string(tok)
+			}
+		}
+		return true
+	})
+	info.P.DataSourcesMap().Range(func(name string, _ shim.Resource) bool {
+		if _, has := info.DataSources[name]; !has {
+			if tok, ok := info.InferDataSourceToken(pkg, name); ok {
+				inferred.DataSources[name] = string(tok)
+			}
+		}
+		return true
+	})
+	return inferred
+}
+
+// AddInferredTokens maps each of the given resources and data sources that has no entry in the ProviderInfo to its
+// inferred token. The ProviderInfo's maps are copied rather than modified in place.
+func (info *ProviderInfo) AddInferredTokens(inferred InferredTokens) {
+	if inferred.Len() == 0 {
+		return
+	}
+
+	resources := make(map[string]*ResourceInfo, len(info.Resources)+len(inferred.Resources))
+	for name, res := range info.Resources {
+		resources[name] = res
+	}
+	for name, tok := range inferred.Resources {
+		if _, has := resources[name]; !has {
+			resources[name] = &ResourceInfo{Tok: tokens.Type(tok)}
+		}
+	}
+	info.Resources = resources
+
+	dataSources := make(map[string]*DataSourceInfo, len(info.DataSources)+len(inferred.DataSources))
+	for name, ds := range info.DataSources {
+		dataSources[name] = ds
+	}
+	for name, tok := range inferred.DataSources {
+		if _, has := dataSources[name]; !has {
+			dataSources[name] = &DataSourceInfo{Tok: tokens.ModuleMember(tok)}
+		}
+	}
+	info.DataSources = dataSources
+}
+
+// readInferredTokens extracts the inferred tokens recorded in the given JSON-encoded Pulumi schema, if any.
+func readInferredTokens(pulumiSchema []byte) (InferredTokens, error) {
+	if len(pulumiSchema) == 0 {
+		return InferredTokens{}, nil
+	}
+	var spec struct {
+		Language map[string]json.RawMessage `json:"language"`
+	}
+	if err := json.Unmarshal(pulumiSchema, &spec); err != nil {
+		return InferredTokens{}, err
+	}
+	raw, ok := spec.Language[InferredTokensKey]
+	if !ok {
+		return InferredTokens{}, nil
+	}
+	var inferred InferredTokens
+	if err := json.Unmarshal(raw, &inferred); err != nil {
+		return InferredTokens{}, err
+	}
+	return inferred, nil
+}
+
+// initInferredTokens maps the resources and data sources that the provider's schema records inferred tokens for.
+// Only the recorded tokens are used, so that the provider never serves a token that its schema does not describe.
+func (p *Provider) initInferredTokens() {
+	inferred, err := readInferredTokens(p.pulumiSchema)
+	if err != nil {
+		glog.V(5).Infof("failed to read inferred tokens from schema: %v", err)
+		return
+	}
+	p.info.AddInferredTokens(inferred)
+}
@@ -0,0 +1,112 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestInferTokens(t *testing.T) {
+	info := ProviderInfo{
+		Name: "aws",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"aws_ec2_fleet":          {},
+				"aws_ec2_transit_router": {},
+				"aws_instance":           {},
+				"aws_s3_bucket":          {},
+				"aws_vpc":                {},
+			},
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"aws_ec2_fleet": {},
+				"aws_vpc":       {},
+			},
+		}),
+		Resources: map[string]*ResourceInfo{
+			"aws_vpc": {Tok: "aws:ec2/vpc:Vpc"},
+		},
+		ModulePrefixes: map[string][]string{
+			"ec2":               {"aws_ec2_", "aws_instance", "aws_vpc"},
+			"ec2transitgateway": {"aws_ec2_transit_"},
+		},
+	}
+
+	module, prefix, ok := info.InferModule("aws_ec2_transit_router")
+	assert.True(t, ok)
+	assert.Equal(t, "ec2transitgateway", module)
+	assert.Equal(t, "aws_ec2_transit_", prefix)
+	_, _, ok = info.InferModule("aws_s3_bucket")
+	assert.False(t, ok)
+
+	assert.Equal(t, InferredTokens{
+		Resources: map[string]string{
+			"aws_ec2_fleet":          "aws:ec2/fleet:Fleet",
+			"aws_ec2_transit_router": "aws:ec2transitgateway/router:Router",
+			"aws_instance":           "aws:ec2/instance:Instance",
+		},
+		DataSources: map[string]string{
+			"aws_ec2_fleet": "aws:ec2/getFleet:getFleet",
+			"aws_vpc":       "aws:ec2/getVpc:getVpc",
+		},
+	}, info.InferTokens("aws"))
+}
+
+func TestAddInferredTokens(t *testing.T) {
+	resources := map[string]*ResourceInfo{"test_widget": {Tok: "test:index/widget:Widget"}}
+	info := ProviderInfo{Resources: resources}
+	info.AddInferredTokens(InferredTokens{
+		Resources: map[string]string{
+			"test_widget": "test:widgets/widget:Widget",
+			"test_gadget": "test:gadgets/gadget:Gadget",
+		},
+		DataSources: map[string]string{"test_widget": "test:widgets/getWidget:getWidget"},
+	})
+
+	// Explicit mappings win, and the original maps are left alone.
+	assert.Equal(t, tokens.Type("test:index/widget:Widget"), info.Resources["test_widget"].Tok)
+	assert.Equal(t, tokens.Type("test:gadgets/gadget:Gadget"), info.Resources["test_gadget"].Tok)
+	assert.Equal(t, tokens.ModuleMember("test:widgets/getWidget:getWidget"), info.DataSources["test_widget"].Tok)
+	assert.Len(t, resources, 1)
+}
+
+func TestProviderServesInferredTokens(t *testing.T) {
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_widget": {Schema: map[string]*schemav2.Schema{}},
+			"test_gadget": {Schema: map[string]*schemav2.Schema{}},
+		},
+	})
+	info := ProviderInfo{
+		P:              tf,
+		Name:           "test",
+		ModulePrefixes: map[string][]string{"widgets": {"test_widget"}, "gadgets": {"test_gadget"}},
+	}
+	pulumiSchema := []byte(`{"language": {"tfbridgeInferredTokens": {
+		"resources": {"test_widget": "test:widgets/widget:Widget"}
+	}}}`)
+
+	// Only the recorded token is served; the gadget keeps its default token until its inferred token is accepted.
+	p := NewProvider(context.Background(), nil, "test", "0.0.1", tf, info, pulumiSchema)
+	assert.Contains(t, p.resources, tokens.Type("test:widgets/widget:Widget"))
+	assert.Contains(t, p.resources, tokens.Type("test:gadget:Gadget"))
+	assert.NotContains(t, p.resources, tokens.Type("test:gadgets/gadget:Gadget"))
+}
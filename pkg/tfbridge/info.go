@@ -15,7 +15,10 @@
 package tfbridge
 
 import (
+	"context"
 	"fmt"
+	"time"
+
 	"github.com/blang/semver"
 	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
@@ -52,9 +55,17 @@ type ProviderInfo struct {
 	Version                 string                             // the version of the provider package.
 	Config                  map[string]*SchemaInfo             // a map of TF name to config schema overrides.
 	ExtraConfig             map[string]*ConfigInfo             // a list of Pulumi-only configuration variables.
+	RenamedConfig           map[string]string                  // a map of deprecated config names to the names that replace them.
 	Resources               map[string]*ResourceInfo           // a map of TF name to Pulumi name; standard mangling occurs if no entry.
 	DataSources             map[string]*DataSourceInfo         // a map of TF name to Pulumi resource info.
+	EphemeralResources      map[string]*EphemeralResourceInfo  // a map of TF name to Pulumi function info.
+	ModulePrefixes          map[string][]string                // a map of module to the TF name prefixes whose tokens tfgen may infer.
 	ExtraTypes              map[string]pschema.ComplexTypeSpec // a map of Pulumi token to schema type for overlaid types.
+	SchemaFragments         []string                           // paths to hand-authored JSON/YAML schema fragments to merge.
+	IncludePrecomputedValue bool                               // true to add the built-in <pkg>:index:PrecomputedValue resource.
+	UpstreamDefaults        bool                               // true to carry static defaults of primitive TF attributes into the schema.
+	DetectExperimental      bool                               // true to label resources that look experimental upstream from their names and docs.
+	PreciseUnknowns         bool                               // true to keep the parts of preview outputs that inputs determine known.
 	PluginDownloadURL       string                             // an optional URL to download the provider binary from.
 	JavaScript              *JavaScriptInfo                    // optional overlay information for augmented JavaScript code-generation.
 	Python                  *PythonInfo                        // optional overlay information for augmented Python code-generation.
@@ -65,6 +76,42 @@ type ProviderInfo struct {
 	TFProviderModuleVersion string                             // the Go module version of the provider. Default is unversioned e.g. v1
 
 	PreConfigureCallback PreConfigureCallback // a provider-specific callback to invoke prior to TF Configure
+	TransportCallback    TransportCallback    // a provider-specific hook to inject bridge-level proxy/TLS settings
+	ExternalTools        []ExternalTool       // external programs that the upstream provider runs, checked at Configure
+	ConfigProfiles       *ConfigProfilesInfo  // lets each resource select a named set of provider configuration
+	AuditLog             bool                 // true to accept the bridge-level auditLogPath configuration
+	RateLimit            bool                 // true to accept the bridge-level rateLimit and rateLimitBurst configuration
+	AutoTags             bool                 // true to accept the bridge-level autoTags and autoTagProperties configuration
+	DefaultOptions       bool                 // true to accept the bridge-level defaultResourceOptions configuration
+
+	DocSnippetsDir   string        // a directory of shared markdown snippets (<name>.md) that docs may include.
+	ExamplesDir      string        // a directory of curated examples (<token with : as />/<name>.tf|.ts|...).
+	DocLocales       []string      // additional locales to write a translated `<locale>/schema.json` for (e.g. "ja-JP").
+	DocTranslator    DocTranslator // translates the schema's descriptions into each of DocLocales.
+	ExtractDocValues bool          // true to derive input defaults/examples from "Defaults to `x`" and similar docs.
+	ExtractDocEnums  bool          // with ExtractDocValues, true to turn allowed values in input docs into enums.
+	DocLinkRules     []DocLinkRule // rules for rewriting links in upstream docs, applied before the built-in rules.
+	DocAssetsURL     string        // the URL that images copied from upstream docs are served from, if not the registry.
+	MaxDocAssetBytes int           // the size above which images in upstream docs are not copied (default 1MB).
+
+	// post-processors for converted example code, keyed by language ("typescript", "python", "csharp" or "go").
+	ExampleTransformers map[string]ExampleTransformer
+	// languages that examples are not converted to, e.g. to skip "csharp" for a provider with few .NET users.
+	ExampleExcludedLanguages []string
+	// languages that the examples of a module are not converted to, keyed by module name (e.g. "ec2").
+	ExampleExcludedModuleLanguages map[string][]string
+	// placeholder values in converted examples to replace with consistent, obviously fake values, applied in order.
+	ExamplePlaceholders []ExamplePlaceholder
+
+	PruneUnknownStateProperties bool // true to drop state properties that are no longer in the schema, with a warning.
+	MaxStateSize                int  // the maximum size in bytes of resource state and invoke results (0 for the 400MB gRPC limit).
+
+	ProviderFactory *ProviderFactoryInfo // optional per-key provider factories (e.g. per-region) to add to the SDKs.
+
+	// behaviors that are pinned to their legacy versions for every resource. See Behavior.
+	LegacyBehaviors []Behavior
+	// how the name property of every resource that has one is autonamed, unless the resource sets its own AutoNaming.
+	AutoNaming *AutoNamingInfo
 }
 
 // TFProviderLicense is a way to be able to pass a license type for the upstream Terraform provider.
@@ -144,6 +191,116 @@ type ResourceInfo struct {
 	Aliases             []AliasInfo            // aliases for this resources, if any.
 	DeprecationMessage  string                 // message to use in deprecation warning
 	CSharpName          string                 // .NET-specific name
+	Checkpoint          *CheckpointInfo        // enables checkpointing of long-running creates, if non-nil.
+	// inputs, keyed by Pulumi name, that cannot be read from the cloud when the resource is imported.
+	ImportInputs map[string]*ImportInputInfo
+	// expressions that predict unknown outputs, keyed by Pulumi name, from the resource's inputs during previews. Each
+	// expression is an HCL template that refers to inputs by Pulumi name, e.g. `arn:aws:s3:::${bucket}` or
+	// `${lower(name)}`. Outputs are only predicted if the inputs that their expressions refer to are known. Invalid
+	// expressions are reported as check failures.
+	PreviewOutputs map[string]string
+	// inputs, keyed by Pulumi name, whose values must be unique in the cloud. A replacement that keeps any of them
+	// unchanged deletes the old resource first, since the replacement would otherwise conflict with it.
+	DeleteBeforeReplaceDependencies []string
+	// verifies that deletes have completed, for upstream providers whose deletes return before the resource is gone.
+	DeleteVerification *DeleteVerificationInfo
+	// if true, the resource is read immediately before it is updated or deleted, for APIs that reject writes that do
+	// not carry the current value of a field such as an etag or version.
+	RefreshBeforeUpdate bool
+	// reads the resource with Go code in place of the upstream provider's Read, e.g. for resources whose upstream Read
+	// is broken or too slow. It is used wherever the bridge reads the resource, including refreshes and imports.
+	CustomRead CustomReadFunc
+	// the Terraform name of the resource that upstream deprecated this resource in favor of, e.g. `aws_foo_v2` for
+	// `aws_foo`. Both resources keep their own tokens: this one is marked deprecated in favor of the replacement, the
+	// replacement's docs refer back to it, and using it warns at runtime. The replacement must be mapped as well.
+	DeprecatedInFavorOf string
+	// behaviors that are pinned to their legacy versions for this resource while the provider's default moves forward.
+	LegacyBehaviors []Behavior
+	// whether the known parts of partially unknown inputs are kept in the outputs of previews, overriding
+	// ProviderInfo.PreciseUnknowns.
+	PreciseUnknowns *bool
+	// outputs that the cloud populates asynchronously after the resource is created. Create reads the resource until
+	// all of them satisfy their conditions, so that users get complete outputs.
+	AwaitOutputs []AwaitOutput
+	// how the resource's name property is populated when programs leave it unset, overriding ProviderInfo.AutoNaming.
+	AutoNaming *AutoNamingInfo
+	// whether the resource is experimental or in beta upstream, overriding ProviderInfo.DetectExperimental.
+	Experimental *bool
+	// if true, a user-settable `id` argument, which would collide with the ID that Pulumi reserves for every resource,
+	// is renamed. See RenameIDProperty.
+	RenameID bool
+}
+
+// DeleteVerificationInfo makes Delete poll until the deleted resource is actually gone. Some upstream providers return
+// from deletes while the cloud is still removing the resource, which causes an immediate re-create to fail with a
+// conflict.
+type DeleteVerificationInfo struct {
+	// Exists reports whether the resource with the given ID and state still exists. If nil, the resource is read from
+	// the upstream provider, and is gone once the read finds nothing.
+	Exists func(ctx context.Context, id string, state resource.PropertyMap) (bool, error)
+	// Timeout bounds the time spent waiting for the resource to disappear. Defaults to 5 minutes, or to the delete
+	// timeout of the request if one was given.
+	Timeout time.Duration
+	// Interval is the time between polls. Defaults to 5 seconds.
+	Interval time.Duration
+}
+
+// AwaitOutput declares an output of a resource that is populated after the upstream provider has created the
+// resource, e.g. an endpoint that is assigned once the resource is provisioned, and the condition that it must satisfy
+// before the resource is ready. Reads are retried with exponential backoff until every declared output is ready.
+type AwaitOutput struct {
+	// Property is the Pulumi name of the output to wait for.
+	Property string
+	// Condition returns true once the output's value is ready. If nil, the output is ready once it is set to a
+	// non-empty value. AwaitValues makes conditions that wait for one of a set of values.
+	Condition func(v resource.PropertyValue) bool
+	// Timeout bounds the time spent waiting for the output. Defaults to 10 minutes.
+	Timeout time.Duration
+	// Interval is the time before the first read, which doubles after each read up to 30 seconds. Defaults to 2
+	// seconds.
+	Interval time.Duration
+}
+
+// AwaitValues returns an AwaitOutput.Condition that is satisfied once the output is set to one of the given values,
+// e.g. AwaitValues("READY", "ACTIVE") for a status output.
+func AwaitValues(values ...string) func(v resource.PropertyValue) bool {
+	return func(v resource.PropertyValue) bool {
+		if !v.IsString() {
+			return false
+		}
+		for _, value := range values {
+			if v.StringValue() == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// CheckpointInfo enables checkpointing of a resource's creation. If a create is interrupted, because the request was
+// cancelled or the connection to the upstream provider or its API failed, the resource is recorded in the stack with
+// the ID that the upstream provider would have assigned it, in provider-private state. The next update of the
+// resource reads the resource with that ID and adopts it if it exists rather than creating a duplicate, and creates it
+// otherwise. Creates that fail for any other reason are not checkpointed.
+//
+// Creates are only checkpointed if the provider is able to respond to the engine, so a provider that is killed
+// mid-create leaves no checkpoint behind.
+type CheckpointInfo struct {
+	// ID computes the ID that the upstream provider will assign to the resource from the resource's inputs. An empty
+	// ID disables checkpointing for the given inputs.
+	ID func(inputs resource.PropertyMap) (string, error)
+}
+
+// ImportInputInfo describes a top-level input property whose value cannot be read from the cloud when the resource is
+// imported, such as a password or a flag that only affects how the resource is deleted. Imports report the property
+// with its placeholder value, so that the code generated by `pulumi import` shows where the real value belongs, and the
+// placeholder is documented in the generated schema.
+type ImportInputInfo struct {
+	// Placeholder is the value that imports report for the property, e.g. "<password>" or false.
+	Placeholder interface{}
+	// Required is true if the placeholder is not a usable value and must be replaced with the real value before the
+	// resource can be created or updated. Check fails while a required input still holds its placeholder.
+	Required bool
 }
 
 func (info *ResourceInfo) GetTok() tokens.Token              { return tokens.Token(info.Tok) }
@@ -156,6 +313,17 @@ type DataSourceInfo struct {
 	Fields             map[string]*SchemaInfo
 	Docs               *DocInfo // overrides for finding and mapping TF docs.
 	DeprecationMessage string   // message to use in deprecation warning
+	// the Terraform name of the data source that upstream deprecated this data source in favor of. See
+	// ResourceInfo.DeprecatedInFavorOf.
+	DeprecatedInFavorOf string
+	// if set, the data source is also exposed as a read-only resource with this token, for users who want the result
+	// of the data source tracked in state. Creating and refreshing the resource read the data source, changing its
+	// arguments reads it again, and deleting it only removes it from state.
+	ResourceTok tokens.Type
+	// if true, a user-settable `id` argument of the resource named by ResourceTok is renamed. See RenameIDProperty.
+	RenameID bool
+	// whether the data source is experimental or in beta upstream, overriding ProviderInfo.DetectExperimental.
+	Experimental *bool
 }
 
 func (info *DataSourceInfo) GetTok() tokens.Token              { return tokens.Token(info.Tok) }
@@ -182,6 +350,10 @@ type SchemaInfo struct {
 	// an optional idemponent transformation, applied before passing to TF.
 	Transform Transformer
 
+	// optional canonicalizations applied, in order, to string inputs during Check, so that inputs that differ only
+	// in formatting do not produce diffs.
+	Normalize []Normalization
+
 	// a schema override for elements for arrays, maps, and sets.
 	Elem *SchemaInfo
 
@@ -218,8 +390,24 @@ type SchemaInfo struct {
 	// whether or not this property has been removed from the Terraform schema
 	Removed bool
 
-	// whether or not to treat this property as secret
+	// whether or not to omit this computed-only property from the Pulumi schema and state entirely, e.g. because it
+	// is enormous and of no use to Pulumi programs
+	Omit bool
+
+	// whether or not to treat this property as secret; for provider configuration, this overrides Terraform's
+	// Sensitive flag in either direction
 	Secret *bool
+
+	// an optional comparator used during refresh; if the value read from the provider is semantically equal to the
+	// prior state according to this comparator, the prior state is kept in order to avoid spurious diffs
+	ReadComparator ValueComparator
+
+	// how changes to the property are presented in previews; by default, the old and new values are shown in full
+	DiffPresentation DiffPresentation
+	// whether or not refresh keeps the prior value of this property rather than the value read from the provider, e.g.
+	// for properties whose reads are noisy or lossy; changes made to the property outside of Pulumi are not detected.
+	// The upstream provider still reads the whole resource, so this does not make refresh any cheaper.
+	SkipRefresh bool
 }
 
 // ConfigInfo represents a synthetic configuration variable that is Pulumi-only, and not passed to Terraform.
@@ -245,6 +433,27 @@ type DocInfo struct {
 	IncludeAttributesFrom          string // optionally include attributes from another raw resource for docs.
 	IncludeArgumentsFrom           string // optionally include arguments from another raw resource for docs.
 	IncludeAttributesFromArguments string // optionally include attributes from another raw resource's arguments.
+
+	// IncludeArguments maps argument names to shared doc snippets (see ProviderInfo.DocSnippetsDir) that replace
+	// the upstream descriptions of those arguments.
+	IncludeArguments map[string]string
+
+	// PrependMarkdown and AppendMarkdown are Pulumi-specific notes, e.g. import caveats or naming differences, that
+	// are added to the start and end of the generated description. They may use `{{% include "name" %}}` directives,
+	// and references to resources, data sources and properties in them are rewritten for each language.
+	PrependMarkdown string
+	AppendMarkdown  string
+}
+
+// DocLinkRule rewrites the targets of links in upstream docs that match a pattern. Links to the upstream docs of
+// resources and data sources mapped by the provider are rewritten to Pulumi registry paths without any rules; rules
+// cover the remaining cases, e.g. guides that have been ported to Pulumi.
+type DocLinkRule struct {
+	// Pattern is a regular expression matched against the whole link target.
+	Pattern string
+	// Replacement is the new link target, which may refer to submatches of Pattern as in regexp.Regexp.Expand. An
+	// empty replacement removes the link, keeping its text.
+	Replacement string
 }
 
 // HasDefault returns true if there is a default value for this property.
@@ -271,6 +480,7 @@ type DefaultInfo struct {
 type PulumiResource struct {
 	URN        resource.URN
 	Properties resource.PropertyMap
+	Autonaming AutonamingMode // how the program's configuration asks autonamed properties to be populated
 }
 
 // OverlayInfo contains optional overlay information.  Each info has a 1:1 correspondence with a module and
@@ -281,6 +491,13 @@ type OverlayInfo struct {
 	Modules   map[string]*OverlayInfo // extra modules to inject into the structure.
 }
 
+// ProviderFactoryInfo describes a family of explicit provider instances that differ only in the value of a single
+// configuration key, such as a region or partition. When set, each SDK gains a helper that returns a cached provider
+// instance for a given key value, e.g. `providerForRegion("us-west-2")` in NodeJS.
+type ProviderFactoryInfo struct {
+	Key string // the Terraform name of the string config property that distinguishes providers (e.g. "region").
+}
+
 // JavaScriptInfo contains optional overlay information for Python code-generation.
 type JavaScriptInfo struct {
 	PackageName       string            // Custom name for the NPM package.
@@ -313,6 +530,31 @@ type CSharpInfo struct {
 	Namespaces        map[string]string // Known .NET namespaces with proper capitalization.
 }
 
+// DocTranslator translates a generated documentation string into the given locale. The token identifies the schema
+// member being documented (e.g. `pkg:index/widget:Widget` or `pkg:index/widget:Widget.name`). It returns false if no
+// translation is available, in which case the original documentation is kept. Only the descriptions in the localized
+// copies of schema.json are translated; the doc comments of the generated SDKs are always in the original language.
+type DocTranslator func(locale, token, doc string) (string, bool, error)
+
+// ExampleTransformer post-processes the code of an example converted from HCL before it is embedded in the generated
+// docs, e.g. to format it, to add imports of helper libraries or to replace placeholder values. The path identifies
+// the schema member whose docs contain the example (e.g. `#/resources/pkg:index/widget:Widget`). Returning an error
+// drops the example for the language.
+type ExampleTransformer func(path, code string) (string, error)
+
+// ExamplePlaceholder replaces a placeholder value that upstream examples use, e.g. the AWS account ID `123456789012`
+// or the domain `example.com`, in the examples converted from HCL. Placeholders are only replaced in the text of the
+// string literals of the converted code, not in comments or interpolated expressions, and each replacement is escaped
+// as the literal that it ends up in requires.
+type ExamplePlaceholder struct {
+	// Pattern is a regular expression matched against the contents of string literals.
+	Pattern string
+	// Replacement is the literal text that matches of Pattern are replaced with.
+	Replacement string
+	// Languages overrides Replacement for some languages ("typescript", "python", "csharp" or "go").
+	Languages map[string]string
+}
+
 // PreConfigureCallback is a function to invoke prior to calling the TF provider Configure
 type PreConfigureCallback func(vars resource.PropertyMap, config shim.ResourceConfig) error
 
@@ -0,0 +1,132 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Behavior names a change to the bridge's runtime behavior that providers can roll out gradually. The new behavior is
+// the default; providers pin the legacy behavior with ProviderInfo.LegacyBehaviors, or for individual resources with
+// ResourceInfo.LegacyBehaviors, until those resources are ready to move forward.
+type Behavior string
+
+const (
+	// BehaviorPreciseUnknowns keeps the known elements of partially unknown inputs in the outputs of previews, for
+	// resources that opt in with PreciseUnknowns. The legacy behavior marks the whole output unknown.
+	BehaviorPreciseUnknowns Behavior = "preciseUnknowns"
+)
+
+// behaviors lists the behaviors that can be pinned to their legacy versions.
+var behaviors = map[Behavior]bool{
+	BehaviorPreciseUnknowns: true,
+}
+
+// legacyBehaviorsEnvVar may list behaviors, separated by commas, to pin to their legacy versions for every resource,
+// so that users can roll a behavior back without waiting for a new release of the provider.
+const legacyBehaviorsEnvVar = "PULUMI_TFBRIDGE_LEGACY_BEHAVIORS"
+
+// LegacyBehaviorsKey is the key under the Pulumi schema's language section that records the legacy behaviors that the
+// provider and its resources pin, so that staged migrations can be tracked from the schema.
+const LegacyBehaviorsKey = "tfbridgeLegacyBehaviors"
+
+// LegacyBehaviors records the legacy behaviors that a provider pins for all of its resources and for individual ones.
+type LegacyBehaviors struct {
+	Provider  []Behavior            `json:"provider,omitempty"`
+	Resources map[string][]Behavior `json:"resources,omitempty"` // by resource token
+}
+
+// Len returns the number of pins.
+func (b LegacyBehaviors) Len() int {
+	n := len(b.Provider)
+	for _, pinned := range b.Resources {
+		n += len(pinned)
+	}
+	return n
+}
+
+// PinnedLegacyBehaviors returns the legacy behaviors that the provider pins, and those that each of its resources pin.
+func (info ProviderInfo) PinnedLegacyBehaviors() LegacyBehaviors {
+	result := LegacyBehaviors{Provider: sortedBehaviors(info.LegacyBehaviors)}
+	for _, res := range info.Resources {
+		if res == nil || len(res.LegacyBehaviors) == 0 {
+			continue
+		}
+		if result.Resources == nil {
+			result.Resources = map[string][]Behavior{}
+		}
+		result.Resources[string(res.Tok)] = sortedBehaviors(res.LegacyBehaviors)
+	}
+	return result
+}
+
+// validateLegacyBehaviors returns an error for each pinned behavior that the bridge does not know.
+func (info ProviderInfo) validateLegacyBehaviors() []error {
+	var errs []error
+	for _, b := range info.LegacyBehaviors {
+		if !behaviors[b] {
+			errs = append(errs, errors.Errorf("LegacyBehaviors: unknown behavior %q", b))
+		}
+	}
+	for _, name := range sortedResourceInfoNames(info.Resources) {
+		if res := info.Resources[name]; res != nil {
+			for _, b := range res.LegacyBehaviors {
+				if !behaviors[b] {
+					errs = append(errs, errors.Errorf("resource %s: LegacyBehaviors: unknown behavior %q", name, b))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// usesLegacyBehavior returns true if the given behavior is pinned to its legacy version for the given resource, by
+// the resource, by the provider or by the user.
+func (p *Provider) usesLegacyBehavior(res Resource, b Behavior) bool {
+	if containsBehavior(p.info.LegacyBehaviors, b) {
+		return true
+	}
+	if res.Schema != nil && containsBehavior(res.Schema.LegacyBehaviors, b) {
+		return true
+	}
+	for _, name := range strings.Split(os.Getenv(legacyBehaviorsEnvVar), ",") {
+		if Behavior(strings.TrimSpace(name)) == b {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBehavior(behaviors []Behavior, b Behavior) bool {
+	for _, behavior := range behaviors {
+		if behavior == b {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedBehaviors(behaviors []Behavior) []Behavior {
+	if len(behaviors) == 0 {
+		return nil
+	}
+	sorted := append([]Behavior{}, behaviors...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
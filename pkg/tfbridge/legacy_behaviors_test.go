@@ -0,0 +1,64 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinnedLegacyBehaviors(t *testing.T) {
+	info := ProviderInfo{
+		LegacyBehaviors: []Behavior{BehaviorPreciseUnknowns},
+		Resources: map[string]*ResourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget", LegacyBehaviors: []Behavior{BehaviorPreciseUnknowns}},
+			"test_gadget": {Tok: "test:index/gadget:Gadget", LegacyBehaviors: []Behavior{"newSets"}},
+			"test_gizmo":  {Tok: "test:index/gizmo:Gizmo"},
+		},
+	}
+
+	pinned := info.PinnedLegacyBehaviors()
+	assert.Equal(t, LegacyBehaviors{
+		Provider: []Behavior{BehaviorPreciseUnknowns},
+		Resources: map[string][]Behavior{
+			"test:index/widget:Widget": {BehaviorPreciseUnknowns},
+			"test:index/gadget:Gadget": {"newSets"},
+		},
+	}, pinned)
+	assert.Equal(t, 3, pinned.Len())
+
+	errs := info.validateLegacyBehaviors()
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `resource test_gadget: LegacyBehaviors: unknown behavior "newSets"`)
+}
+
+func TestUsesLegacyBehavior(t *testing.T) {
+	pinned := Resource{Schema: &ResourceInfo{LegacyBehaviors: []Behavior{BehaviorPreciseUnknowns}}}
+	unpinned := Resource{Schema: &ResourceInfo{}}
+
+	p := &Provider{}
+	assert.True(t, p.usesLegacyBehavior(pinned, BehaviorPreciseUnknowns))
+	assert.False(t, p.usesLegacyBehavior(unpinned, BehaviorPreciseUnknowns))
+
+	// Providers pin behaviors for all of their resources.
+	p.info.LegacyBehaviors = []Behavior{BehaviorPreciseUnknowns}
+	assert.True(t, p.usesLegacyBehavior(unpinned, BehaviorPreciseUnknowns))
+
+	// Users may pin behaviors with an environment variable.
+	p.info.LegacyBehaviors = nil
+	defer setEnv(t, legacyBehaviorsEnvVar, "other, preciseUnknowns")()
+	assert.True(t, p.usesLegacyBehavior(unpinned, BehaviorPreciseUnknowns))
+}
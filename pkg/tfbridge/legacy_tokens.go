@@ -0,0 +1,90 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// LegacyTokensKey is the key under the Pulumi schema's language section that maps the deprecated tokens of a schema
+// that is migrating from the classic flat token layout to the tokens that replace them.
+const LegacyTokensKey = "tfbridgeLegacyTokens"
+
+// LegacyFlatToken returns the token that the given resource or function token has in the classic flat layout, in
+// which every member lives in the package's index module, and true, if that differs from the token. For example,
+// `aws:s3/bucket:Bucket` is `aws:index/bucket:Bucket` in the flat layout.
+func LegacyFlatToken(tok string) (string, bool) {
+	parts := strings.Split(tok, ":")
+	if len(parts) != 3 || parts[2] == "" || strings.SplitN(parts[1], "/", 2)[0] == "index" {
+		return "", false
+	}
+	r, size := utf8.DecodeRuneInString(parts[2])
+	file := string(unicode.ToLower(r)) + parts[2][size:]
+	return parts[0] + ":index/" + file + ":" + parts[2], true
+}
+
+// readLegacyTokens extracts the legacy token mapping recorded in the given JSON-encoded Pulumi schema, if any.
+func readLegacyTokens(pulumiSchema []byte) (map[string]string, error) {
+	if len(pulumiSchema) == 0 {
+		return nil, nil
+	}
+	var spec struct {
+		Language map[string]json.RawMessage `json:"language"`
+	}
+	if err := json.Unmarshal(pulumiSchema, &spec); err != nil {
+		return nil, err
+	}
+	raw, ok := spec.Language[LegacyTokensKey]
+	if !ok {
+		return nil, nil
+	}
+	var legacyTokens map[string]string
+	if err := json.Unmarshal(raw, &legacyTokens); err != nil {
+		return nil, err
+	}
+	return legacyTokens, nil
+}
+
+// initLegacyTokens registers the deprecated tokens that the provider's schema declares as aliases of the resources
+// and data sources that replace them, so that programs using either token layout are served.
+func (p *Provider) initLegacyTokens() {
+	p.legacyTokens = map[string]bool{}
+	legacyTokens, err := readLegacyTokens(p.pulumiSchema)
+	if err != nil {
+		glog.V(5).Infof("failed to read legacy tokens from schema: %v", err)
+		return
+	}
+
+	for legacy, tok := range legacyTokens {
+		if res, ok := p.resources[tokens.Type(tok)]; ok {
+			if _, exists := p.resources[tokens.Type(legacy)]; !exists {
+				p.resources[tokens.Type(legacy)] = res
+				p.legacyTokens[legacy] = true
+			}
+		}
+		if ds, ok := p.dataSources[tokens.ModuleMember(tok)]; ok {
+			if _, exists := p.dataSources[tokens.ModuleMember(legacy)]; !exists {
+				p.dataSources[tokens.ModuleMember(legacy)] = ds
+				p.legacyTokens[legacy] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestLegacyFlatToken(t *testing.T) {
+	tok, ok := LegacyFlatToken("aws:s3/bucket:Bucket")
+	assert.True(t, ok)
+	assert.Equal(t, "aws:index/bucket:Bucket", tok)
+
+	tok, ok = LegacyFlatToken("aws:s3/getBucket:getBucket")
+	assert.True(t, ok)
+	assert.Equal(t, "aws:index/getBucket:getBucket", tok)
+
+	_, ok = LegacyFlatToken("aws:index/provider:Provider")
+	assert.False(t, ok)
+	_, ok = LegacyFlatToken("aws:bucket")
+	assert.False(t, ok)
+}
+
+func TestLegacyTokens(t *testing.T) {
+	tf := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap:   map[string]*schemav2.Resource{"test_bucket": {}},
+		DataSourcesMap: map[string]*schemav2.Resource{"test_bucket": {}},
+	})
+	info := ProviderInfo{
+		Name:        "test",
+		Resources:   map[string]*ResourceInfo{"test_bucket": {Tok: "test:s3/bucket:Bucket"}},
+		DataSources: map[string]*DataSourceInfo{"test_bucket": {Tok: "test:s3/getBucket:getBucket"}},
+	}
+	schema := []byte(`{"language": {"tfbridgeLegacyTokens": {
+		"test:index/bucket:Bucket": "test:s3/bucket:Bucket",
+		"test:index/getBucket:getBucket": "test:s3/getBucket:getBucket",
+		"test:index/missing:Missing": "test:s3/missing:Missing"
+	}}}`)
+
+	p := NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, schema)
+	assert.Equal(t, "test_bucket", p.resources["test:index/bucket:Bucket"].TFName)
+	assert.Equal(t, "test_bucket", p.resources["test:s3/bucket:Bucket"].TFName)
+	assert.Equal(t, "test_bucket", p.dataSources["test:index/getBucket:getBucket"].TFName)
+	assert.Len(t, p.resources, 2)
+	assert.Equal(t, map[string]bool{
+		"test:index/bucket:Bucket":       true,
+		"test:index/getBucket:getBucket": true,
+	}, p.legacyTokens)
+
+	// Providers whose schemas have no legacy tokens only serve their tokens.
+	p = NewProvider(context.Background(), nil, "test", "1.0.0", tf, info, []byte(`{}`))
+	assert.Len(t, p.resources, 1)
+	assert.Len(t, p.dataSources, 1)
+}
@@ -190,12 +190,21 @@ func AutoNameTransform(name string, maxlen int, transform func(string) string) *
 // FromName automatically propagates a resource's URN onto the resulting default info.
 func FromName(options AutoNameOptions) func(res *PulumiResource) (interface{}, error) {
 	return func(res *PulumiResource) (interface{}, error) {
+		if res.Autonaming == AutonamingDisabled {
+			return nil, nil
+		}
+
 		// Take the URN name part, transform it if required, and then append some unique characters if requested.
 		vs := string(res.URN.Name())
 		if options.Transform != nil {
 			vs = options.Transform(vs)
 		}
-		if options.Randlen > 0 {
+		if res.Autonaming == AutonamingVerbatim {
+			if options.Maxlen > 0 && len(vs) > options.Maxlen {
+				return nil, errors.Errorf("could not make instance of '%v': name '%s' is longer than %d characters",
+					res.URN.Type(), vs, options.Maxlen)
+			}
+		} else if options.Randlen > 0 {
 			uniqueHex, err := resource.NewUniqueHex(vs+options.Separator, options.Randlen, options.Maxlen)
 			if err != nil {
 				return uniqueHex, errors.Wrapf(err, "could not make instance of '%v'", res.URN.Type())
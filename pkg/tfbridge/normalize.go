@@ -0,0 +1,148 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// Normalization is a canonicalization of a string input. Upstream providers often suppress diffs between values that
+// differ only in formatting using a DiffSuppressFunc, which the bridge cannot see; declaring the equivalent
+// normalization on the property lets the bridge store the canonical value instead.
+type Normalization string
+
+const (
+	// NormalizeTrimSpace removes leading and trailing whitespace.
+	NormalizeTrimSpace Normalization = "trimSpace"
+	// NormalizeLowercase converts the value to lower case.
+	NormalizeLowercase Normalization = "lowercase"
+	// NormalizeCIDR rewrites a CIDR block in its canonical notation, e.g. `2001:DB8:0:0::/32` becomes
+	// `2001:db8::/32`. The address is kept as written, including any host bits. Values that are not CIDR blocks are
+	// left alone so that the provider's own validation can report them.
+	NormalizeCIDR Normalization = "cidr"
+)
+
+// apply applies the normalization to the given string.
+func (n Normalization) apply(s string) (string, error) {
+	switch n {
+	case NormalizeTrimSpace:
+		return strings.TrimSpace(s), nil
+	case NormalizeLowercase:
+		return strings.ToLower(s), nil
+	case NormalizeCIDR:
+		ip, ipnet, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return s, nil
+		}
+		ones, _ := ipnet.Mask.Size()
+		return fmt.Sprintf("%s/%d", ip, ones), nil
+	default:
+		return "", fmt.Errorf("unknown normalization %q", n)
+	}
+}
+
+// normalizeInputs applies the normalizations declared by the given schema infos to a resource's inputs, recursing
+// into nested blocks, lists and maps. Unknown values are left alone.
+func normalizeInputs(inputs resource.PropertyMap, tfs shim.SchemaMap,
+	ps map[string]*SchemaInfo) (resource.PropertyMap, error) {
+
+	result := resource.PropertyMap{}
+	for key, value := range inputs {
+		_, sch, info := getInfoFromPulumiName(key, tfs, ps, false)
+		v, err := normalizeValue(value, sch, info)
+		if err != nil {
+			return nil, fmt.Errorf("normalizing %s: %w", key, err)
+		}
+		result[key] = v
+	}
+	return result, nil
+}
+
+func normalizeValue(v resource.PropertyValue, sch shim.Schema, info *SchemaInfo) (resource.PropertyValue, error) {
+	// Blocks with MaxItems==1 are projected as a single value; normalize them as the single-element list they are.
+	if IsMaxItemsOne(sch, info) && !v.IsArray() && !v.IsNull() && !v.IsComputed() && !v.IsOutput() {
+		arr, err := normalizeValue(resource.NewArrayProperty([]resource.PropertyValue{v}), sch, info)
+		if err != nil {
+			return v, err
+		}
+		return arr.ArrayValue()[0], nil
+	}
+
+	switch {
+	case v.IsString():
+		if info == nil || len(info.Normalize) == 0 {
+			return v, nil
+		}
+		s := v.StringValue()
+		for _, n := range info.Normalize {
+			var err error
+			if s, err = n.apply(s); err != nil {
+				return v, err
+			}
+		}
+		return resource.NewStringProperty(s), nil
+	case v.IsSecret():
+		elem, err := normalizeValue(v.SecretValue().Element, sch, info)
+		if err != nil {
+			return v, err
+		}
+		return resource.MakeSecret(elem), nil
+	case v.IsArray():
+		esch, einfo := elemSchemas(sch, info)
+		elems := make([]resource.PropertyValue, len(v.ArrayValue()))
+		for i, e := range v.ArrayValue() {
+			ne, err := normalizeValue(e, esch, einfo)
+			if err != nil {
+				return v, err
+			}
+			elems[i] = ne
+		}
+		return resource.NewArrayProperty(elems), nil
+	case v.IsObject():
+		if sch == nil {
+			return v, nil
+		}
+		// Nested blocks have named fields; maps of primitives have raw keys.
+		if res, ok := sch.Elem().(shim.Resource); ok {
+			var fields map[string]*SchemaInfo
+			if info != nil {
+				fields = info.Fields
+			}
+			obj, err := normalizeInputs(v.ObjectValue(), res.Schema(), fields)
+			if err != nil {
+				return v, err
+			}
+			return resource.NewObjectProperty(obj), nil
+		}
+		esch, einfo := elemSchemas(sch, info)
+		obj := resource.PropertyMap{}
+		for k, e := range v.ObjectValue() {
+			ne, err := normalizeValue(e, esch, einfo)
+			if err != nil {
+				return v, err
+			}
+			obj[k] = ne
+		}
+		return resource.NewObjectProperty(obj), nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestNormalization(t *testing.T) {
+	cases := []struct {
+		n        Normalization
+		in, want string
+	}{
+		{NormalizeTrimSpace, "  name\n", "name"},
+		{NormalizeLowercase, "MyBucket", "mybucket"},
+		{NormalizeCIDR, "10.0.0.1/24", "10.0.0.1/24"},
+		{NormalizeCIDR, "2001:DB8:0:0:0::/32", "2001:db8::/32"},
+		{NormalizeCIDR, "not-a-cidr", "not-a-cidr"},
+	}
+	for _, c := range cases {
+		got, err := c.n.apply(c.in)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, got)
+	}
+
+	_, err := Normalization("reverse").apply("abc")
+	assert.EqualError(t, err, `unknown normalization "reverse"`)
+}
+
+func TestNormalizeInputs(t *testing.T) {
+	tfs := schemaMap(map[string]*schema.Schema{
+		"name":  {Type: shim.TypeString},
+		"other": {Type: shim.TypeString},
+		"tags":  {Type: shim.TypeMap, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()},
+		"cidrs": {Type: shim.TypeList, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()},
+		"rule": {
+			Type: shim.TypeList,
+			Elem: (&schema.Resource{
+				Schema: schemaMap(map[string]*schema.Schema{
+					"action": {Type: shim.TypeString},
+				}),
+			}).Shim(),
+		},
+		"config": {
+			Type:     shim.TypeList,
+			MaxItems: 1,
+			Elem: (&schema.Resource{
+				Schema: schemaMap(map[string]*schema.Schema{
+					"mode": {Type: shim.TypeString},
+				}),
+			}).Shim(),
+		},
+	})
+	lower := []Normalization{NormalizeTrimSpace, NormalizeLowercase}
+	ps := map[string]*SchemaInfo{
+		"name":  {Normalize: lower},
+		"tags":  {Elem: &SchemaInfo{Normalize: lower}},
+		"cidrs": {Elem: &SchemaInfo{Normalize: []Normalization{NormalizeCIDR}}},
+		"rule": {Elem: &SchemaInfo{Fields: map[string]*SchemaInfo{
+			"action": {Normalize: lower},
+		}}},
+		"config": {Elem: &SchemaInfo{Fields: map[string]*SchemaInfo{
+			"mode": {Normalize: lower},
+		}}},
+	}
+
+	inputs := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name":   resource.MakeSecret(resource.NewStringProperty(" MyName ")),
+		"other":  " Untouched ",
+		"tags":   map[string]interface{}{"Env": " PROD"},
+		"cidrs":  []interface{}{"2001:DB8::/32", resource.MakeComputed(resource.NewStringProperty(""))},
+		"rules":  []interface{}{map[string]interface{}{"action": "ALLOW "}},
+		"config": map[string]interface{}{"mode": "Strict"},
+	})
+	normalized, err := normalizeInputs(inputs, tfs, ps)
+	assert.NoError(t, err)
+	assert.Equal(t, resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name":   resource.MakeSecret(resource.NewStringProperty("myname")),
+		"other":  " Untouched ",
+		"tags":   map[string]interface{}{"Env": "prod"},
+		"cidrs":  []interface{}{"2001:db8::/32", resource.MakeComputed(resource.NewStringProperty(""))},
+		"rules":  []interface{}{map[string]interface{}{"action": "allow"}},
+		"config": map[string]interface{}{"mode": "strict"},
+	}), normalized)
+}
@@ -0,0 +1,260 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/go-uuid"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// The PrecomputedValue resource is a bridge-provided analogue of Terraform's terraform_data and null_resource. It
+// stores an arbitrary input value in state, echoing it back as its output, and is replaced whenever the value of its
+// triggersReplace property changes. It lets users migrating Terraform configurations keep the null_resource patterns
+// they rely on without needing a separate provider.
+const (
+	precomputedValueInput           = "input"
+	precomputedValueOutput          = "output"
+	precomputedValueTriggersReplace = "triggersReplace"
+)
+
+// PrecomputedValueToken returns the token of the built-in PrecomputedValue resource for the given package.
+func PrecomputedValueToken(pkg string) tokens.Type {
+	return tokens.Type(pkg + ":index:PrecomputedValue")
+}
+
+// PrecomputedValueResourceSpec returns the Pulumi schema for the built-in PrecomputedValue resource.
+func PrecomputedValueResourceSpec() pschema.ResourceSpec {
+	anyType := pschema.TypeSpec{Ref: "pulumi.json#/Any"}
+	input := pschema.PropertySpec{
+		TypeSpec:    anyType,
+		Description: "A value to store in the resource state. Changes to it update `output` without replacement.",
+	}
+	triggersReplace := pschema.PropertySpec{
+		TypeSpec:    anyType,
+		Description: "A value which, when changed, causes the resource to be replaced.",
+	}
+	output := pschema.PropertySpec{
+		TypeSpec:    anyType,
+		Description: "The value of `input` as of the last create or update.",
+	}
+
+	return pschema.ResourceSpec{
+		ObjectTypeSpec: pschema.ObjectTypeSpec{
+			Description: "Stores a value in state and replaces itself when `triggersReplace` changes. This " +
+				"resource is the equivalent of Terraform's `terraform_data` and `null_resource`, and is useful " +
+				"for sequencing and triggering replacement of other resources.",
+			Type: "object",
+			Properties: map[string]pschema.PropertySpec{
+				precomputedValueInput:           input,
+				precomputedValueOutput:          output,
+				precomputedValueTriggersReplace: triggersReplace,
+			},
+		},
+		InputProperties: map[string]pschema.PropertySpec{
+			precomputedValueInput:           input,
+			precomputedValueTriggersReplace: triggersReplace,
+		},
+		StateInputs: &pschema.ObjectTypeSpec{
+			Type: "object",
+			Properties: map[string]pschema.PropertySpec{
+				precomputedValueInput:           input,
+				precomputedValueOutput:          output,
+				precomputedValueTriggersReplace: triggersReplace,
+			},
+		},
+	}
+}
+
+// isPrecomputedValue returns true if the given type is the provider's built-in PrecomputedValue resource.
+func (p *Provider) isPrecomputedValue(t tokens.Type) bool {
+	return p.info.IncludePrecomputedValue && t == PrecomputedValueToken(p.module)
+}
+
+// precomputedValueInputs returns only the input properties of a PrecomputedValue.
+func precomputedValueInputs(props resource.PropertyMap) resource.PropertyMap {
+	inputs := resource.PropertyMap{}
+	for _, k := range []resource.PropertyKey{precomputedValueInput, precomputedValueTriggersReplace} {
+		if v, ok := props[k]; ok {
+			inputs[k] = v
+		}
+	}
+	return inputs
+}
+
+// precomputedValueOutputs computes the state of a PrecomputedValue from its inputs.
+func precomputedValueOutputs(news resource.PropertyMap) resource.PropertyMap {
+	outs := precomputedValueInputs(news)
+	if v, ok := news[precomputedValueInput]; ok {
+		outs[precomputedValueOutput] = v
+	} else {
+		outs[precomputedValueOutput] = resource.NewNullProperty()
+	}
+	return outs
+}
+
+func (p *Provider) checkPrecomputedValue(req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, error) {
+	label := fmt.Sprintf("%s.Check(%s)", p.label(), req.GetUrn())
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, KeepSecrets: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := plugin.MarshalProperties(precomputedValueInputs(news), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.inputs", label), KeepUnknowns: true, KeepSecrets: true})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.CheckResponse{Inputs: inputs}, nil
+}
+
+func (p *Provider) diffPrecomputedValue(req *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+	label := fmt.Sprintf("%s.Diff(%s)", p.label(), req.GetUrn())
+	olds, err := plugin.UnmarshalProperties(req.GetOlds(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.olds", label), SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+
+	detailedDiff := map[string]*pulumirpc.PropertyDiff{}
+	var replaces, properties []string
+	for _, k := range []resource.PropertyKey{precomputedValueInput, precomputedValueTriggersReplace} {
+		oldValue, hasOld := olds[k]
+		newValue, hasNew := news[k]
+		if hasOld == hasNew && (!hasOld || oldValue.DeepEquals(newValue)) {
+			continue
+		}
+
+		replace := k == precomputedValueTriggersReplace
+		var kind pulumirpc.PropertyDiff_Kind
+		switch {
+		case !hasOld && replace:
+			kind = pulumirpc.PropertyDiff_ADD_REPLACE
+		case !hasOld:
+			kind = pulumirpc.PropertyDiff_ADD
+		case !hasNew && replace:
+			kind = pulumirpc.PropertyDiff_DELETE_REPLACE
+		case !hasNew:
+			kind = pulumirpc.PropertyDiff_DELETE
+		case replace:
+			kind = pulumirpc.PropertyDiff_UPDATE_REPLACE
+		default:
+			kind = pulumirpc.PropertyDiff_UPDATE
+		}
+		if replace {
+			replaces = append(replaces, string(k))
+		}
+		detailedDiff[string(k)] = &pulumirpc.PropertyDiff{Kind: kind}
+		properties = append(properties, string(k))
+	}
+
+	changes := pulumirpc.DiffResponse_DIFF_NONE
+	if len(properties) > 0 {
+		changes = pulumirpc.DiffResponse_DIFF_SOME
+	}
+	return &pulumirpc.DiffResponse{
+		Changes:         changes,
+		Replaces:        replaces,
+		Diffs:           properties,
+		DetailedDiff:    detailedDiff,
+		HasDetailedDiff: true,
+	}, nil
+}
+
+func (p *Provider) createPrecomputedValue(req *pulumirpc.CreateRequest) (resp *pulumirpc.CreateResponse, err error) {
+	urn := resource.URN(req.GetUrn())
+	label := fmt.Sprintf("%s.Create(%s)", p.label(), urn)
+	glog.V(9).Infof("%s executing", label)
+	defer p.auditOperation("Create", urn, req.GetProperties(), time.Now(), &err)
+
+	news, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, KeepSecrets: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+
+	id := ""
+	if !req.GetPreview() {
+		if id, err = uuid.GenerateUUID(); err != nil {
+			return nil, err
+		}
+	}
+
+	outs, err := plugin.MarshalProperties(precomputedValueOutputs(news), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.outs", label), KeepUnknowns: req.GetPreview(), KeepSecrets: p.supportsSecrets})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.CreateResponse{Id: id, Properties: outs}, nil
+}
+
+func (p *Provider) readPrecomputedValue(req *pulumirpc.ReadRequest) (*pulumirpc.ReadResponse, error) {
+	label := fmt.Sprintf("%s.Read(%s)", p.label(), req.GetUrn())
+	glog.V(9).Infof("%s executing", label)
+
+	// The resource has no remote state, so the last recorded state is always current.
+	props, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.state", label), KeepSecrets: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := plugin.MarshalProperties(precomputedValueInputs(props), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.inputs", label), KeepSecrets: p.supportsSecrets})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.ReadResponse{Id: req.GetId(), Properties: req.GetProperties(), Inputs: inputs}, nil
+}
+
+func (p *Provider) updatePrecomputedValue(req *pulumirpc.UpdateRequest) (resp *pulumirpc.UpdateResponse, err error) {
+	urn := resource.URN(req.GetUrn())
+	label := fmt.Sprintf("%s.Update(%s)", p.label(), urn)
+	glog.V(9).Infof("%s executing", label)
+	defer p.auditOperation("Update", urn, req.GetNews(), time.Now(), &err)
+
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, KeepSecrets: true, SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	outs, err := plugin.MarshalProperties(precomputedValueOutputs(news), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.outs", label), KeepUnknowns: req.GetPreview(), KeepSecrets: p.supportsSecrets})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.UpdateResponse{Properties: outs}, nil
+}
+
+func (p *Provider) deletePrecomputedValue(req *pulumirpc.DeleteRequest) (resp *pbempty.Empty, err error) {
+	urn := resource.URN(req.GetUrn())
+	glog.V(9).Infof("%s.Delete(%s) executing", p.label(), urn)
+	defer p.auditOperation("Delete", urn, req.GetProperties(), time.Now(), &err)
+
+	// There is nothing to tear down: the resource only exists in state.
+	return &pbempty.Empty{}, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrecomputedValue(t *testing.T) {
+	const urn = "urn:pulumi:stack::project::test:index:PrecomputedValue::v"
+	marshal := func(m map[string]interface{}) *pbstruct.Struct {
+		s, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(m), plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		return s
+	}
+	unmarshal := func(s *pbstruct.Struct) resource.PropertyMap {
+		m, err := plugin.UnmarshalProperties(s, plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		return m
+	}
+
+	// Without the opt-in the resource is not recognized.
+	p := &Provider{module: "test"}
+	_, err := p.Create(context.Background(), &pulumirpc.CreateRequest{Urn: urn})
+	assert.Error(t, err)
+
+	p.info.IncludePrecomputedValue = true
+
+	// Create echoes the input as the output and assigns an ID.
+	created, err := p.Create(context.Background(), &pulumirpc.CreateRequest{
+		Urn:        urn,
+		Properties: marshal(map[string]interface{}{"input": "a", "triggersReplace": "x"}),
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.GetId())
+	outs := unmarshal(created.GetProperties())
+	assert.Equal(t, "a", outs["output"].StringValue())
+
+	// Changing the input is an in-place update.
+	diff, err := p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"input": "b", "triggersReplace": "x"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_SOME, diff.GetChanges())
+	assert.Empty(t, diff.GetReplaces())
+	assert.Equal(t, pulumirpc.PropertyDiff_UPDATE, diff.GetDetailedDiff()["input"].GetKind())
+
+	updated, err := p.Update(context.Background(), &pulumirpc.UpdateRequest{
+		Urn:  urn,
+		Olds: created.GetProperties(),
+		News: marshal(map[string]interface{}{"input": "b", "triggersReplace": "x"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", unmarshal(updated.GetProperties())["output"].StringValue())
+
+	// Changing the triggers requires replacement.
+	diff, err = p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Urn:  urn,
+		Olds: updated.GetProperties(),
+		News: marshal(map[string]interface{}{"input": "b", "triggersReplace": "y"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"triggersReplace"}, diff.GetReplaces())
+
+	// No changes means no diff.
+	diff, err = p.Diff(context.Background(), &pulumirpc.DiffRequest{
+		Urn:  urn,
+		Olds: updated.GetProperties(),
+		News: marshal(map[string]interface{}{"input": "b", "triggersReplace": "x"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pulumirpc.DiffResponse_DIFF_NONE, diff.GetChanges())
+
+	// Read returns the recorded state, and Delete has nothing to do.
+	read, err := p.Read(context.Background(), &pulumirpc.ReadRequest{
+		Urn: urn, Id: created.GetId(), Properties: updated.GetProperties()})
+	assert.NoError(t, err)
+	assert.Equal(t, created.GetId(), read.GetId())
+	assert.Equal(t, "b", unmarshal(read.GetInputs())["input"].StringValue())
+
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{
+		Urn: urn, Id: created.GetId(), Properties: updated.GetProperties()})
+	assert.NoError(t, err)
+}
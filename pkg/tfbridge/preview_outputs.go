@@ -0,0 +1,201 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// previewOutputFunctions are the functions that preview output expressions may call.
+var previewOutputFunctions = map[string]function.Function{
+	"coalesce":      stdlib.CoalesceFunc,
+	"format":        stdlib.FormatFunc,
+	"join":          stdlib.JoinFunc,
+	"lower":         stdlib.LowerFunc,
+	"regex_replace": stdlib.RegexReplaceFunc,
+	"replace":       stdlib.ReplaceFunc,
+	"substr":        stdlib.SubstrFunc,
+	"trimspace":     stdlib.TrimSpaceFunc,
+	"upper":         stdlib.UpperFunc,
+}
+
+// sortedPreviewOutputs returns the names of the resource's preview outputs in a stable order.
+func sortedPreviewOutputs(info *ResourceInfo) []string {
+	if info == nil {
+		return nil
+	}
+	names := make([]string, 0, len(info.PreviewOutputs))
+	for name := range info.PreviewOutputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkPreviewOutputs reports a failure for each preview output expression that does not parse, calls a function
+// that preview outputs may not use, or cannot be evaluated against the resource's inputs. Check reports these so that
+// Create and Update never fail a preview because of a bad expression.
+func checkPreviewOutputs(urn resource.URN, news resource.PropertyMap, info *ResourceInfo) []*pulumirpc.CheckFailure {
+	var failures []*pulumirpc.CheckFailure
+	for _, name := range sortedPreviewOutputs(info) {
+		if _, _, err := evalPreviewOutput(info.PreviewOutputs[name], news); err != nil {
+			failures = append(failures, &pulumirpc.CheckFailure{
+				Property: name,
+				Reason:   fmt.Sprintf("%s.%s has an invalid preview expression: %v", urn.Name(), name, err),
+			})
+		}
+	}
+	return failures
+}
+
+// predictPreviewOutputs replaces each unknown output that the resource declares a preview expression for with the
+// value of the expression, if the inputs that the expression refers to are known. Outputs whose expression fails to
+// evaluate stay unknown; Check has already reported the failure.
+func predictPreviewOutputs(info *ResourceInfo, inputs, outputs resource.PropertyMap) {
+	for _, name := range sortedPreviewOutputs(info) {
+		key := resource.PropertyKey(name)
+		if v, ok := outputs[key]; ok && !v.IsNull() && !v.ContainsUnknowns() {
+			continue
+		}
+		v, ok, err := evalPreviewOutput(info.PreviewOutputs[name], inputs)
+		if err != nil {
+			glog.V(9).Infof("predicting %s: %v", name, err)
+			continue
+		}
+		if ok {
+			outputs[key] = v
+		}
+	}
+}
+
+// evalPreviewOutput evaluates the given preview output expression. It returns false if the expression refers to an
+// input that is missing or unknown.
+func evalPreviewOutput(source string, inputs resource.PropertyMap) (resource.PropertyValue, bool, error) {
+	expr, diags := hclsyntax.ParseTemplate([]byte(source), "preview", hcl.InitialPos)
+	if diags.HasErrors() {
+		return resource.PropertyValue{}, false, diags
+	}
+	// Check the functions up front so that an unsupported call is caught even when the inputs are unknown.
+	diags = hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+		if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			if _, ok := previewOutputFunctions[call.Name]; !ok {
+				return hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("Call to unknown function %q", call.Name),
+					Subject:  call.NameRange.Ptr(),
+				}}
+			}
+		}
+		return nil
+	})
+	if diags.HasErrors() {
+		return resource.PropertyValue{}, false, diags
+	}
+
+	vars, secret := map[string]cty.Value{}, false
+	for _, traversal := range expr.Variables() {
+		name := traversal.RootName()
+		v, ok := inputs[resource.PropertyKey(name)]
+		if !ok || v.IsNull() || v.ContainsUnknowns() {
+			return resource.PropertyValue{}, false, nil
+		}
+		secret = secret || v.ContainsSecrets()
+
+		cv, err := propertyValueToCty(v)
+		if err != nil {
+			return resource.PropertyValue{}, false, errors.Wrapf(err, "converting %s", name)
+		}
+		vars[name] = cv
+	}
+
+	result, diags := expr.Value(&hcl.EvalContext{Variables: vars, Functions: previewOutputFunctions})
+	if diags.HasErrors() {
+		return resource.PropertyValue{}, false, diags
+	}
+	v, err := ctyToPropertyValue(result)
+	if err != nil {
+		return resource.PropertyValue{}, false, err
+	}
+	if secret {
+		v = resource.MakeSecret(v)
+	}
+	return v, true, nil
+}
+
+// propertyValueToCty converts a known property value to the equivalent cty value, dropping any secretness.
+func propertyValueToCty(v resource.PropertyValue) (cty.Value, error) {
+	switch {
+	case v.IsNull():
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case v.IsBool():
+		return cty.BoolVal(v.BoolValue()), nil
+	case v.IsNumber():
+		return cty.NumberFloatVal(v.NumberValue()), nil
+	case v.IsString():
+		return cty.StringVal(v.StringValue()), nil
+	case v.IsSecret():
+		return propertyValueToCty(v.SecretValue().Element)
+	case v.IsArray():
+		elems := make([]cty.Value, len(v.ArrayValue()))
+		for i, e := range v.ArrayValue() {
+			ce, err := propertyValueToCty(e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems[i] = ce
+		}
+		return cty.TupleVal(elems), nil
+	case v.IsObject():
+		attrs := map[string]cty.Value{}
+		for k, e := range v.ObjectValue() {
+			ce, err := propertyValueToCty(e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[string(k)] = ce
+		}
+		return cty.ObjectVal(attrs), nil
+	default:
+		return cty.NilVal, errors.Errorf("unsupported value %v", v)
+	}
+}
+
+// ctyToPropertyValue converts a cty value to the equivalent property value.
+func ctyToPropertyValue(v cty.Value) (resource.PropertyValue, error) {
+	contents, err := ctyjson.Marshal(v, cty.DynamicPseudoType)
+	if err != nil {
+		return resource.PropertyValue{}, err
+	}
+	var wrapped struct {
+		Value interface{} `json:"value"`
+	}
+	if err = json.Unmarshal(contents, &wrapped); err != nil {
+		return resource.PropertyValue{}, err
+	}
+	return resource.NewPropertyValue(wrapped.Value), nil
+}
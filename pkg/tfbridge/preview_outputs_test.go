@@ -0,0 +1,141 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestPredictPreviewOutputs(t *testing.T) {
+	info := &ResourceInfo{PreviewOutputs: map[string]string{
+		"arn":      "arn:example:${region}:widget/${lower(name)}",
+		"size":     "${size}",
+		"tags":     "${tags}",
+		"endpoint": "${name}.${domain}",
+		"label":    "${label}",
+	}}
+	inputs := resource.PropertyMap{
+		"name":   resource.NewStringProperty("Main"),
+		"region": resource.MakeSecret(resource.NewStringProperty("north")),
+		"size":   resource.NewNumberProperty(3),
+		"tags":   resource.NewObjectProperty(resource.PropertyMap{"env": resource.NewStringProperty("dev")}),
+		"domain": resource.MakeComputed(resource.NewStringProperty("")),
+	}
+	outputs := resource.PropertyMap{
+		"arn":      resource.MakeComputed(resource.NewStringProperty("")),
+		"size":     resource.MakeComputed(resource.NewStringProperty("")),
+		"tags":     resource.MakeComputed(resource.NewStringProperty("")),
+		"endpoint": resource.MakeComputed(resource.NewStringProperty("")),
+		"label":    resource.NewStringProperty("known"),
+	}
+
+	predictPreviewOutputs(info, inputs, outputs)
+	assert.Equal(t, resource.PropertyMap{
+		// Outputs that depend on secret inputs are secret.
+		"arn":  resource.MakeSecret(resource.NewStringProperty("arn:example:north:widget/main")),
+		"size": resource.NewNumberProperty(3),
+		"tags": resource.NewObjectProperty(resource.PropertyMap{"env": resource.NewStringProperty("dev")}),
+		// Outputs that depend on unknown inputs stay unknown, and known outputs are left alone.
+		"endpoint": resource.MakeComputed(resource.NewStringProperty("")),
+		"label":    resource.NewStringProperty("known"),
+	}, outputs)
+
+	// Outputs whose expression fails to evaluate stay unknown.
+	info.PreviewOutputs = map[string]string{"arn": "${unknown_function(name)}"}
+	outputs["arn"] = resource.MakeComputed(resource.NewStringProperty(""))
+	predictPreviewOutputs(info, inputs, outputs)
+	assert.True(t, outputs["arn"].IsComputed())
+}
+
+func TestCheckPreviewOutputs(t *testing.T) {
+	info := &ResourceInfo{PreviewOutputs: map[string]string{
+		"arn":      "arn:example:widget/${name}",
+		"endpoint": "${lookup(domain)}",
+		"label":    "${name",
+		"size":     "${upper(size)}",
+	}}
+	news := resource.PropertyMap{
+		"name":   resource.NewStringProperty("main"),
+		"domain": resource.MakeComputed(resource.NewStringProperty("")),
+		"size":   resource.NewObjectProperty(resource.PropertyMap{}),
+	}
+
+	urn := resource.NewURN("stack", "project", "", "example:index/widget:Widget", "w")
+	failures := checkPreviewOutputs(urn, news, info)
+
+	// Unsupported functions are reported even when the inputs they are applied to are unknown.
+	var properties []string
+	for _, failure := range failures {
+		properties = append(properties, failure.GetProperty())
+	}
+	assert.Equal(t, []string{"endpoint", "label", "size"}, properties)
+	assert.Contains(t, failures[0].GetReason(), `w.endpoint has an invalid preview expression`)
+	assert.Contains(t, failures[0].GetReason(), `unknown function "lookup"`)
+
+	assert.Empty(t, checkPreviewOutputs(urn, news, &ResourceInfo{}))
+	assert.Empty(t, checkPreviewOutputs(urn, news, nil))
+}
+
+func TestPreviewOutputsCreate(t *testing.T) {
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Required: true, ForceNew: true},
+			"arn":  {Type: schemav2.TypeString, Computed: true},
+		},
+		CreateContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			d.SetId(d.Get("name").(string))
+			return nil
+		},
+		ReadContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return nil
+		},
+		DeleteContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return nil
+		},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_widget": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/widget:Widget": {
+				TF:     shimv2.NewResource(tfRes),
+				TFName: "example_widget",
+				Schema: &ResourceInfo{
+					Tok:            "example:index/widget:Widget",
+					PreviewOutputs: map[string]string{"arn": "arn:example:widget/${name}"},
+				},
+			},
+		},
+	}
+
+	urn := resource.NewURN("stack", "project", "", "example:index/widget:Widget", "w")
+	props, err := plugin.MarshalProperties(resource.PropertyMap{"name": resource.NewStringProperty("a")},
+		plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	resp, err := p.Create(context.Background(), &pulumirpc.CreateRequest{
+		Urn: string(urn), Properties: props, Preview: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:example:widget/a", resp.GetProperties().GetFields()["arn"].GetStringValue())
+}
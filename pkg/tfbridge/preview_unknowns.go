@@ -0,0 +1,200 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// usesPreciseUnknowns returns true if the unknown outputs of the given resource's previews are refined by
+// refinePreviewUnknowns, i.e. if the resource or the provider opts in and the behavior is not pinned to its legacy
+// version.
+func (p *Provider) usesPreciseUnknowns(res Resource) bool {
+	enabled := p.info.PreciseUnknowns
+	if res.Schema != nil && res.Schema.PreciseUnknowns != nil {
+		enabled = *res.Schema.PreciseUnknowns
+	}
+	return enabled && !p.usesLegacyBehavior(res, BehaviorPreciseUnknowns)
+}
+
+// refinePreviewUnknowns narrows the unknown outputs of a preview down to those that are unknown in the upstream plan
+// and computed by the upstream provider. The upstream plan marks a whole list, map or block unknown if any of its
+// elements is unknown, and leaves attributes whose inputs are unknown out of the planned state altogether. An
+// attribute that the provider does not compute always takes the value of its input, though, so where the plan is
+// unknown its output is the input, with unknowns in the same places.
+func refinePreviewUnknowns(p shim.Provider, res Resource, inputs, outputs resource.PropertyMap,
+	supportsSecrets bool) {
+
+	tfs := res.TF.Schema()
+	var fields map[string]*SchemaInfo
+	if res.Schema != nil {
+		fields = res.Schema.Fields
+	}
+
+	for key, input := range inputs {
+		if input.IsComputed() || !input.ContainsUnknowns() {
+			// Wholly unknown inputs make for wholly unknown outputs, and known inputs are already in the outputs.
+			continue
+		}
+		planned, has := outputs[key]
+		if !has {
+			planned = resource.MakeComputed(resource.NewStringProperty(""))
+		} else if !planned.ContainsUnknowns() {
+			continue
+		}
+
+		name, sch, info := getInfoFromPulumiName(key, tfs, fields, false)
+		if sch == nil {
+			continue
+		}
+
+		// Round trip the input through its Terraform representation so that it has the same shape as other outputs.
+		ctx := &conversionContext{}
+		tfInputs, err := ctx.MakeTerraformInputs(nil, resource.PropertyMap{key: input}, tfs, fields, false)
+		if err != nil {
+			glog.V(9).Infof("failed to refine the preview of %s: %v", name, err)
+			continue
+		}
+		shaped, has := MakeTerraformOutputs(p, tfInputs, tfs, fields, nil, false, supportsSecrets)[key]
+		if !has {
+			continue
+		}
+		if refined := refinePreviewValue(planned, shaped, sch, info); !refined.IsComputed() {
+			outputs[key] = refined
+		}
+	}
+}
+
+// refinePreviewValue returns the planned value of an attribute with the unknowns that its input determines replaced by
+// the input, recursing into nested blocks, lists and maps.
+func refinePreviewValue(planned, input resource.PropertyValue, sch shim.Schema,
+	info *SchemaInfo) resource.PropertyValue {
+
+	if !planned.ContainsUnknowns() || input.IsComputed() || input.IsNull() || !takesInput(sch, info) {
+		return planned
+	}
+
+	// Blocks with MaxItems==1 are projected as a single value; refine them as the single-element list they are.
+	if IsMaxItemsOne(sch, info) && !input.IsArray() && !planned.IsArray() {
+		arr := refinePreviewValue(resource.NewArrayProperty([]resource.PropertyValue{planned}),
+			resource.NewArrayProperty([]resource.PropertyValue{input}), sch, info)
+		return arr.ArrayValue()[0]
+	}
+
+	switch {
+	case planned.IsSecret():
+		return resource.MakeSecret(refinePreviewValue(planned.SecretValue().Element, input, sch, info))
+	case input.IsSecret():
+		return resource.MakeSecret(refinePreviewValue(planned, input.SecretValue().Element, sch, info))
+	case planned.IsComputed():
+		// The plan leaves the whole value unknown, so refine an unknown value of the input's shape.
+		planned = unknownPreviewValue(input, sch, info)
+		if !planned.ContainsUnknowns() {
+			return planned
+		}
+	}
+
+	switch {
+	case planned.IsArray() && input.IsArray():
+		planneds, inputs := planned.ArrayValue(), input.ArrayValue()
+		if len(planneds) != len(inputs) {
+			return planned
+		}
+		esch, einfo := elemSchemas(sch, info)
+		elems := make([]resource.PropertyValue, len(planneds))
+		for i, e := range planneds {
+			elems[i] = refinePreviewValue(e, inputs[i], esch, einfo)
+		}
+		return resource.NewArrayProperty(elems)
+	case planned.IsObject() && input.IsObject():
+		inputs := input.ObjectValue()
+		obj := resource.PropertyMap{}
+		// Nested blocks have named fields; maps of primitives have raw keys.
+		if res, ok := sch.Elem().(shim.Resource); ok {
+			var fields map[string]*SchemaInfo
+			if info != nil {
+				fields = info.Fields
+			}
+			for k, e := range planned.ObjectValue() {
+				obj[k] = e
+				if _, fsch, finfo := getInfoFromPulumiName(k, res.Schema(), fields, false); fsch != nil {
+					if in, has := inputs[k]; has {
+						obj[k] = refinePreviewValue(e, in, fsch, finfo)
+					}
+				}
+			}
+			return resource.NewObjectProperty(obj)
+		}
+		esch, einfo := elemSchemas(sch, info)
+		for k, e := range planned.ObjectValue() {
+			obj[k] = e
+			if in, has := inputs[k]; has {
+				obj[k] = refinePreviewValue(e, in, esch, einfo)
+			}
+		}
+		return resource.NewObjectProperty(obj)
+	default:
+		return planned
+	}
+}
+
+// unknownPreviewValue returns a value of the same shape as the given input whose elements are all unknown, standing in
+// for a value that the plan leaves unknown as a whole. The fields of nested blocks that the provider computes are
+// unknown too, whether or not they are set. A primitive input is its own value, as the provider does not compute it.
+func unknownPreviewValue(input resource.PropertyValue, sch shim.Schema, info *SchemaInfo) resource.PropertyValue {
+	unknown := resource.MakeComputed(resource.NewStringProperty(""))
+	switch {
+	case input.IsArray():
+		elems := make([]resource.PropertyValue, len(input.ArrayValue()))
+		for i := range elems {
+			elems[i] = unknown
+		}
+		return resource.NewArrayProperty(elems)
+	case input.IsObject():
+		obj := resource.PropertyMap{}
+		for k := range input.ObjectValue() {
+			obj[k] = unknown
+		}
+		if res, ok := sch.Elem().(shim.Resource); ok {
+			var fields map[string]*SchemaInfo
+			if info != nil {
+				fields = info.Fields
+			}
+			tfs := res.Schema()
+			tfs.Range(func(name string, fsch shim.Schema) bool {
+				if fsch.Computed() {
+					key, _, _ := getInfoFromTerraformName(name, tfs, fields, false)
+					obj[key] = unknown
+				}
+				return true
+			})
+		}
+		return resource.NewObjectProperty(obj)
+	default:
+		return input
+	}
+}
+
+// takesInput returns true if the provider stores the input of the given attribute as is, rather than computing a
+// different value for it.
+func takesInput(sch shim.Schema, info *SchemaInfo) bool {
+	if sch == nil || sch.Computed() || sch.StateFunc() != nil {
+		return false
+	}
+	return info == nil || (!info.Omit && info.Asset == nil)
+}
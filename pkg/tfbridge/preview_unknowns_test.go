@@ -0,0 +1,137 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestRefinePreviewUnknowns(t *testing.T) {
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Optional: true},
+			"arn":  {Type: schemav2.TypeString, Computed: true},
+			"tags": {Type: schemav2.TypeMap, Optional: true, Elem: &schemav2.Schema{Type: schemav2.TypeString}},
+			"ips":  {Type: schemav2.TypeList, Optional: true, Elem: &schemav2.Schema{Type: schemav2.TypeString}},
+			"zones": {
+				Type: schemav2.TypeList, Optional: true, Computed: true, Elem: &schemav2.Schema{Type: schemav2.TypeString},
+			},
+			"disks": {Type: schemav2.TypeList, Optional: true, Elem: &schemav2.Resource{
+				Schema: map[string]*schemav2.Schema{
+					"size":   {Type: schemav2.TypeInt, Optional: true},
+					"labels": {Type: schemav2.TypeList, Optional: true, Elem: &schemav2.Schema{Type: schemav2.TypeString}},
+					"serial": {Type: schemav2.TypeString, Computed: true},
+				},
+			}},
+			"network": {Type: schemav2.TypeList, Optional: true, MaxItems: 1, Elem: &schemav2.Resource{
+				Schema: map[string]*schemav2.Schema{
+					"subnet": {Type: schemav2.TypeString, Optional: true},
+					"mac":    {Type: schemav2.TypeString, Computed: true},
+				},
+			}},
+		},
+	}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_vm": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/vm:Vm": {TF: shimv2.NewResource(tfRes), TFName: "example_vm", Schema: &ResourceInfo{}},
+		},
+		info: ProviderInfo{PreciseUnknowns: true},
+	}
+	urn := resource.NewURN("stack", "project", "", "example:index/vm:Vm", "vm")
+
+	unknown := resource.MakeComputed(resource.NewStringProperty(""))
+	news, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name": unknown,
+		"tags": resource.NewObjectProperty(resource.PropertyMap{
+			"env":  resource.NewStringProperty("prod"),
+			"team": unknown,
+		}),
+		"ips":   resource.NewArrayProperty([]resource.PropertyValue{resource.NewStringProperty("10.0.0.1"), unknown}),
+		"zones": resource.NewArrayProperty([]resource.PropertyValue{resource.NewStringProperty("a"), unknown}),
+		"disks": resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewObjectProperty(resource.PropertyMap{
+				"size":   resource.NewNumberProperty(10),
+				"labels": resource.NewArrayProperty([]resource.PropertyValue{resource.NewStringProperty("boot"), unknown}),
+			}),
+		}),
+		"network": resource.NewObjectProperty(resource.PropertyMap{"subnet": unknown}),
+	}, plugin.MarshalOptions{KeepUnknowns: true})
+	assert.NoError(t, err)
+
+	resp, err := p.Create(context.Background(),
+		&pulumirpc.CreateRequest{Urn: string(urn), Properties: news, Preview: true})
+	assert.NoError(t, err)
+	outs, err := plugin.UnmarshalProperties(resp.GetProperties(), plugin.MarshalOptions{KeepUnknowns: true})
+	assert.NoError(t, err)
+
+	// Only the unknown elements of inputs that the provider does not compute are unknown.
+	assert.Equal(t, resource.NewObjectProperty(resource.PropertyMap{
+		"env":  resource.NewStringProperty("prod"),
+		"team": unknown,
+	}), outs["tags"])
+	assert.Equal(t, resource.NewArrayProperty([]resource.PropertyValue{resource.NewStringProperty("10.0.0.1"), unknown}),
+		outs["ips"])
+
+	// Nested blocks and lists are refined element by element, and their computed fields stay unknown.
+	assert.Equal(t, resource.NewArrayProperty([]resource.PropertyValue{
+		resource.NewObjectProperty(resource.PropertyMap{
+			"size":   resource.NewNumberProperty(10),
+			"labels": resource.NewArrayProperty([]resource.PropertyValue{resource.NewStringProperty("boot"), unknown}),
+			"serial": unknown,
+		}),
+	}), outs["disks"])
+	assert.Equal(t, resource.NewObjectProperty(resource.PropertyMap{
+		"subnet": unknown,
+		"mac":    unknown,
+	}), outs["network"])
+
+	// Wholly unknown inputs and the outputs that the provider computes are left unknown.
+	assert.NotContains(t, outs, "name")
+	assert.NotContains(t, outs, "arn")
+	assert.NotContains(t, outs, "zones")
+
+	// Resources that pin the legacy behavior leave partially unknown outputs wholly unknown.
+	p.resources["example:index/vm:Vm"] = Resource{TF: shimv2.NewResource(tfRes), TFName: "example_vm",
+		Schema: &ResourceInfo{LegacyBehaviors: []Behavior{BehaviorPreciseUnknowns}}}
+	resp, err = p.Create(context.Background(),
+		&pulumirpc.CreateRequest{Urn: string(urn), Properties: news, Preview: true})
+	assert.NoError(t, err)
+	outs, err = plugin.UnmarshalProperties(resp.GetProperties(), plugin.MarshalOptions{KeepUnknowns: true})
+	assert.NoError(t, err)
+	assert.NotContains(t, outs, "tags")
+	assert.NotContains(t, outs, "ips")
+
+	// So do resources that do not opt in.
+	p.resources["example:index/vm:Vm"] = Resource{TF: shimv2.NewResource(tfRes), TFName: "example_vm",
+		Schema: &ResourceInfo{PreciseUnknowns: new(bool)}}
+	resp, err = p.Create(context.Background(),
+		&pulumirpc.CreateRequest{Urn: string(urn), Properties: news, Preview: true})
+	assert.NoError(t, err)
+	outs, err = plugin.UnmarshalProperties(resp.GetProperties(), plugin.MarshalOptions{KeepUnknowns: true})
+	assert.NoError(t, err)
+	assert.NotContains(t, outs, "tags")
+	assert.NotContains(t, outs, "disks")
+}
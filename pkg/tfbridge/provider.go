@@ -20,6 +20,8 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/diagnostics"
@@ -57,8 +59,20 @@ type Provider struct {
 	configValues    resource.PropertyMap               // this package's config values.
 	resources       map[tokens.Type]Resource           // a map of Pulumi type tokens to resource info.
 	dataSources     map[tokens.ModuleMember]DataSource // a map of Pulumi module tokens to data sources.
+	dsResources     map[tokens.Type]Resource           // a map of Pulumi type tokens to data sources exposed as resources.
+	ephemerals      map[tokens.ModuleMember]DataSource // a map of Pulumi function tokens to ephemeral resources.
+	legacyTokens    map[string]bool                    // the deprecated tokens of the classic flat layout, if any.
 	supportsSecrets bool                               // true if the engine supports secret property values
 	pulumiSchema    []byte                             // the JSON-encoded Pulumi schema.
+	operations      int64                              // the number of in-flight operations, for debugging.
+	auditLog        *auditLog                          // the operation audit log, if one is configured.
+	rateLimiter     *rateLimiter                       // the limit on the rate of upstream operations, if any.
+	autoTags        *autoTags                          // the tags added to every tags-like property, if any.
+	defaultOptions  *defaultResourceOptions            // the resource options enforced for matching types, if any.
+	usageMetrics    *usageMetrics                      // anonymous usage counters, if enabled.
+	profiles        map[string]*Provider               // the providers of each configuration profile, if any.
+	autonaming      AutonamingMode                     // how autonamed properties are populated.
+	warned          sync.Map                           // the warnings logged by warnOnce, keyed by URN and message.
 }
 
 // Resource wraps both the Terraform resource type info plus the overlay resource info.
@@ -156,9 +170,12 @@ func NewProvider(ctx context.Context, host *provider.HostClient, module string,
 		info:         info,
 		config:       tf.Schema(),
 		pulumiSchema: pulumiSchema,
+		usageMetrics: newUsageMetricsFromEnv(module, version),
 	}
 	p.setLoggingContext(ctx)
+	p.initInferredTokens()
 	p.initResourceMaps()
+	p.initLegacyTokens()
 	return p
 }
 
@@ -187,6 +204,18 @@ func (p *Provider) label() string {
 	return fmt.Sprintf("tf.Provider[%s]", p.module)
 }
 
+// warnOnce logs the given warning about the resource with the given URN, unless this provider process has already
+// logged it, so that warnings about a resource's state are not repeated for every operation on the resource.
+func (p *Provider) warnOnce(ctx context.Context, urn resource.URN, msg string) {
+	glog.V(5).Infof("%s: %s", urn, msg)
+	if _, warned := p.warned.LoadOrStore(string(urn)+"\x00"+msg, true); warned || p.host == nil {
+		return
+	}
+	if err := p.host.Log(ctx, diag.Warning, urn, msg); err != nil {
+		glog.V(5).Infof("failed to log warning: %v", err)
+	}
+}
+
 // initResourceMaps creates maps from Pulumi types and tokens to Terraform resource type.
 func (p *Provider) initResourceMaps() {
 	// Fetch a list of all resource types handled by this provider and make a map.
@@ -210,6 +239,8 @@ func (p *Provider) initResourceMaps() {
 			tok = tokens.Type(string(p.pkg()) + ":" + camelName + ":" + pascalName)
 		}
 
+		schema, _ = ResolveResourceInfo(res, tok, schema, p.info.AutoNaming)
+
 		p.resources[tok] = Resource{
 			TF:     res,
 			TFName: name,
@@ -221,6 +252,7 @@ func (p *Provider) initResourceMaps() {
 
 	// Fetch a list of all data source types handled by this provider and make a similar map.
 	p.dataSources = make(map[tokens.ModuleMember]DataSource)
+	p.dsResources = make(map[tokens.Type]Resource)
 	p.tf.DataSourcesMap().Range(func(name string, ds shim.Resource) bool {
 		var tok tokens.ModuleMember
 
@@ -246,8 +278,19 @@ func (p *Provider) initResourceMaps() {
 			Schema: schema,
 		}
 
+		// Data sources may also be exposed as read-only resources.
+		if resInfo := DataSourceResourceInfo(ds, schema); resInfo != nil {
+			p.dsResources[resInfo.Tok] = Resource{
+				TF:     ds,
+				TFName: name,
+				Schema: resInfo,
+			}
+		}
+
 		return true
 	})
+
+	p.initEphemeralResources()
 }
 
 // camelPascalPulumiName returns the camel and pascal cased name for a given terraform name.
@@ -288,6 +331,56 @@ func convertStringToPropertyValue(s string, typ shim.ValueType) (resource.Proper
 	return resource.NewPropertyValue(jsonValue), nil
 }
 
+// markSensitiveConfigValues walks a nested configuration value (e.g. an `assume_role` or `endpoints` block) and marks
+// any fields whose Terraform schema is sensitive, or whose overlay is marked secret, as Pulumi secrets. Top-level
+// scalar values are returned unchanged.
+func markSensitiveConfigValues(v resource.PropertyValue, tfs shim.Schema, ps *SchemaInfo) resource.PropertyValue {
+	switch {
+	case v.IsArray():
+		etfs, eps := elemSchemas(tfs, ps)
+		arr := make([]resource.PropertyValue, len(v.ArrayValue()))
+		for i, e := range v.ArrayValue() {
+			arr[i] = markSensitiveConfigValues(e, etfs, eps)
+		}
+		return resource.NewArrayProperty(arr)
+	case v.IsObject():
+		var tfflds shim.SchemaMap
+		if tfs != nil {
+			if res, isres := tfs.Elem().(shim.Resource); isres {
+				tfflds = res.Schema()
+			}
+		}
+		if tfflds == nil {
+			return v
+		}
+		var psflds map[string]*SchemaInfo
+		if ps != nil {
+			psflds = ps.Fields
+		}
+
+		obj := make(resource.PropertyMap)
+		for k, e := range v.ObjectValue() {
+			_, etfs, eps := getInfoFromPulumiName(k, tfflds, psflds, false)
+			e = markSensitiveConfigValues(e, etfs, eps)
+			if !e.IsSecret() && isSensitiveConfigField(etfs, eps) {
+				e = resource.MakeSecret(e)
+			}
+			obj[k] = e
+		}
+		return resource.NewObjectProperty(obj)
+	default:
+		return v
+	}
+}
+
+// isSensitiveConfigField returns true if a nested configuration field should be treated as a secret.
+func isSensitiveConfigField(tfs shim.Schema, ps *SchemaInfo) bool {
+	if ps != nil && ps.Secret != nil {
+		return *ps.Secret
+	}
+	return tfs != nil && tfs.Sensitive()
+}
+
 // GetSchema returns the JSON-encoded schema for this provider's package.
 func (p *Provider) GetSchema(ctx context.Context,
 	req *pulumirpc.GetSchemaRequest) (*pulumirpc.GetSchemaResponse, error) {
@@ -497,6 +590,7 @@ func (p *Provider) Configure(ctx context.Context,
 	// Fetch the map of tokens to values.  It will be in the form of fully qualified tokens, so
 	// we will need to translate into simply the configuration variable names.
 	vars := make(resource.PropertyMap)
+	renamed := make(resource.PropertyMap)
 	for k, v := range req.GetVariables() {
 		mm, err := tokens.ParseModuleMember(k)
 		if err != nil {
@@ -506,16 +600,58 @@ func (p *Provider) Configure(ctx context.Context,
 			continue
 		}
 
+		// Deprecated keys are read as the keys that replace them.
+		key, isRenamed := p.renamedConfigKey(ctx, resource.PropertyKey(mm.Name()))
+
 		typ := shim.TypeString
-		_, sch, _ := getInfoFromPulumiName(resource.PropertyKey(mm.Name()), p.config, p.info.Config, false)
+		_, sch, info := getInfoFromPulumiName(key, p.config, p.info.Config, false)
 		if sch != nil {
 			typ = sch.Type()
 		}
 		pv, err := convertStringToPropertyValue(v, typ)
 		if err != nil {
+			// Nested configuration blocks may carry sensitive fields, so avoid echoing their raw values.
+			if typ == shim.TypeList || typ == shim.TypeSet || typ == shim.TypeMap {
+				return nil, errors.Wrapf(err, "malformed configuration value for '%v'", k)
+			}
 			return nil, errors.Wrapf(err, "malformed configuration value '%v'", v)
 		}
-		vars[resource.PropertyKey(mm.Name())] = pv
+		if isRenamed {
+			renamed[key] = markSensitiveConfigValues(pv, sch, info)
+		} else {
+			vars[key] = markSensitiveConfigValues(pv, sch, info)
+		}
+	}
+	mergeRenamedConfig(vars, renamed)
+
+	// Pull out any bridge-level proxy, TLS and audit log settings before handing the rest of the config to the provider.
+	transport, err := p.extractTransportSettings(ctx, vars)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.configureAuditLog(vars); err != nil {
+		return nil, err
+	}
+	if err = p.configureRateLimit(vars); err != nil {
+		return nil, err
+	}
+	if err = p.configureAutoTags(vars); err != nil {
+		return nil, err
+	}
+	if err = p.configureDefaultResourceOptions(vars); err != nil {
+		return nil, err
+	}
+	if err = p.configureAutonaming(vars); err != nil {
+		return nil, err
+	}
+	profiles, err := p.takeConfigProfiles(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail early if the upstream provider needs external programs that are not installed.
+	if err = p.checkExternalTools(ctx); err != nil {
+		return nil, err
 	}
 
 	// Store the config values with their Pulumi names and values, before translation. This lets us fetch
@@ -544,11 +680,22 @@ func (p *Provider) Configure(ctx context.Context,
 		return nil, validationErrors
 	}
 
+	if transport != nil {
+		if err = p.applyTransportSettings(transport); err != nil {
+			return nil, err
+		}
+	}
+
 	// Now actually attempt to do the configuring and return its resulting error (if any).
 	if err = p.tf.Configure(config); err != nil {
 		return nil, err
 	}
 
+	// Configure a provider for each configuration profile that resources may select.
+	if p.profiles, err = p.configureProfiles(ctx, req, profiles); err != nil {
+		return nil, err
+	}
+
 	return &pulumirpc.ConfigureResponse{
 		SupportsPreview: true,
 	}, nil
@@ -642,10 +789,19 @@ func pathToAttributePath(p cty.Path, tokenType tokens.Type, res Resource) []stri
 }
 
 // Check validates that the given property bag is valid for a resource of the given type.
-func (p *Provider) Check(ctx context.Context, req *pulumirpc.CheckRequest) (*pulumirpc.CheckResponse, error) {
+func (p *Provider) Check(ctx context.Context, req *pulumirpc.CheckRequest) (resp *pulumirpc.CheckResponse, err error) {
 	p.setLoggingContext(ctx)
 	urn := resource.URN(req.GetUrn())
 	t := urn.Type()
+	if p.isPrecomputedValue(t) {
+		return p.checkPrecomputedValue(req)
+	}
+	if res, has := p.dsResources[t]; has {
+		return p.checkDataSourceResource(ctx, res, req)
+	}
+	if resp, ok, err := p.checkWithProfile(ctx, req); ok {
+		return resp, err
+	}
 	res, has := p.resources[t]
 	if !has {
 		return nil, errors.Errorf("unrecognized resource type (Check): %s", t)
@@ -653,10 +809,12 @@ func (p *Provider) Check(ctx context.Context, req *pulumirpc.CheckRequest) (*pul
 
 	label := fmt.Sprintf("%s.Check(%s/%s)", p.label(), urn, res.TFName)
 	glog.V(9).Infof("%s executing", label)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Check", string(t))()
+	p.warnDeprecatedRedirect(ctx, urn, p.info.ResourceRedirectMessage(res.Schema))
 
 	// Unmarshal the old and new properties.
 	var olds resource.PropertyMap
-	var err error
 	if req.GetOlds() != nil {
 		olds, err = plugin.UnmarshalProperties(req.GetOlds(), plugin.MarshalOptions{
 			Label: fmt.Sprintf("%s.olds", label), KeepUnknowns: true})
@@ -671,11 +829,21 @@ func (p *Provider) Check(ctx context.Context, req *pulumirpc.CheckRequest) (*pul
 		return nil, err
 	}
 
+	// Canonicalize any inputs that declare a normalization, so that benign formatting differences do not produce
+	// diffs later on.
+	if news, err = normalizeInputs(news, res.TF.Schema(), res.Schema.Fields); err != nil {
+		return nil, err
+	}
+
+	// Add any automatic tags here, rather than when the resource is created or updated, so that previews show them.
+	news = p.applyAutoTags(res, news)
+
 	// Now fetch the default values so that (a) we can return them to the caller and (b) so that validation
 	// includes the default values.  Otherwise, the provider wouldn't be presented with its own defaults.
 	tfname := res.TFName
 	inputs, assets, err := MakeTerraformInputs(
-		&PulumiResource{URN: urn, Properties: news}, p.configValues, olds, news, res.TF.Schema(), res.Schema.Fields)
+		&PulumiResource{URN: urn, Properties: news, Autonaming: p.autonaming}, p.configValues, olds, news,
+		res.TF.Schema(), res.Schema.Fields)
 	if err != nil {
 		return nil, err
 	}
@@ -696,6 +864,8 @@ func (p *Provider) Check(ctx context.Context, req *pulumirpc.CheckRequest) (*pul
 			Reason: p.formatFailureReason(t, res, err),
 		})
 	}
+	failures = append(failures, checkImportPlaceholders(urn, news, res.Schema)...)
+	failures = append(failures, checkPreviewOutputs(urn, news, res.Schema)...)
 
 	// After all is said and done, we need to go back and return only what got populated as a diff from the origin.
 	pinputs := MakeTerraformOutputs(p.tf, inputs, res.TF.Schema(), res.Schema.Fields, assets, false, p.supportsSecrets)
@@ -709,10 +879,19 @@ func (p *Provider) Check(ctx context.Context, req *pulumirpc.CheckRequest) (*pul
 }
 
 // Diff checks what impacts a hypothetical update will have on the resource's properties.
-func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (*pulumirpc.DiffResponse, error) {
+func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (resp *pulumirpc.DiffResponse, err error) {
 	p.setLoggingContext(ctx)
 	urn := resource.URN(req.GetUrn())
 	t := urn.Type()
+	if p.isPrecomputedValue(t) {
+		return p.diffPrecomputedValue(req)
+	}
+	if res, has := p.dsResources[t]; has {
+		return p.diffDataSourceResource(res, req)
+	}
+	if resp, ok, err := p.diffWithProfile(ctx, req); ok {
+		return resp, err
+	}
 	res, has := p.resources[t]
 	if !has {
 		return nil, errors.Errorf("unrecognized resource type (Diff): %s", urn)
@@ -720,6 +899,8 @@ func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (*pulum
 
 	label := fmt.Sprintf("%s.Diff(%s/%s)", p.label(), urn, res.TFName)
 	glog.V(9).Infof("%s executing", label)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Diff", string(t))()
 
 	// To figure out if we have a replacement, perform the diff and then look for RequiresNew flags.
 	olds, err := plugin.UnmarshalProperties(req.GetOlds(),
@@ -727,6 +908,7 @@ func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (*pulum
 	if err != nil {
 		return nil, err
 	}
+	olds = p.coerceStateTypes(ctx, urn, res, p.pruneStateDrift(ctx, urn, res, olds, false))
 	state, err := MakeTerraformState(res, req.GetId(), olds)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unmarshaling %s's instance state", urn)
@@ -750,6 +932,11 @@ func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (*pulum
 	doIgnoreChanges(res.TF.Schema(), res.Schema.Fields, olds, news, req.GetIgnoreChanges(), diff)
 	detailedDiff := makeDetailedDiff(res.TF.Schema(), res.Schema.Fields, olds, news, diff)
 
+	// If requested, surface the upstream diff that this result was computed from.
+	if debugDiffEnabled() {
+		p.logDebugDiff(ctx, urn, label, res, req, diff)
+	}
+
 	// If there were changes in this diff, check to see if we have a replacement.
 	var replaces []string
 	var replaced map[string]bool
@@ -793,8 +980,17 @@ func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (*pulum
 		return true
 	})
 
+	if err = p.checkProtectedReplace(urn, replaces); err != nil {
+		return nil, err
+	}
+
 	deleteBeforeReplace := len(replaces) > 0 &&
-		(res.Schema.DeleteBeforeReplace || nameRequiresDeleteBeforeReplace(news, res.TF.Schema(), res.Schema.Fields))
+		(res.Schema.DeleteBeforeReplace || nameRequiresDeleteBeforeReplace(news, res.TF.Schema(), res.Schema.Fields) ||
+			p.verbatimNameRequiresDeleteBeforeReplace(news, res.TF.Schema(), res.Schema.Fields) ||
+			dependenciesRequireDeleteBeforeReplace(res.Schema, olds, news))
+
+	// Now that the changes are known, present the properties that opt in to it as summaries.
+	p.presentJSONDiffSummaries(ctx, urn, label, res.TF.Schema(), res.Schema.Fields, olds, news, detailedDiff)
 
 	return &pulumirpc.DiffResponse{
 		Changes:             changes,
@@ -807,12 +1003,55 @@ func (p *Provider) Diff(ctx context.Context, req *pulumirpc.DiffRequest) (*pulum
 	}, nil
 }
 
+// logDebugDiff logs the sanitized upstream diff for a resource to the engine and to glog.
+func (p *Provider) logDebugDiff(ctx context.Context, urn resource.URN, label string, res Resource,
+	req *pulumirpc.DiffRequest, diff shim.InstanceDiff) {
+
+	// The properties are read again so that the values that are secret in them are known.
+	olds, err := plugin.UnmarshalProperties(req.GetOlds(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.olds", label), KeepUnknowns: true, KeepSecrets: true, SkipNulls: true})
+	if err != nil {
+		glog.V(9).Infof("%s failed to render upstream diff: %v", label, err)
+		return
+	}
+	news, err := plugin.UnmarshalProperties(req.GetNews(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, KeepSecrets: true, SkipNulls: true})
+	if err != nil {
+		glog.V(9).Infof("%s failed to render upstream diff: %v", label, err)
+		return
+	}
+
+	bytes, err := makeDebugDiff(res.TFName, res.TF.Schema(), res.Schema.Fields, olds, news, diff)
+	if err != nil {
+		glog.V(9).Infof("%s failed to render upstream diff: %v", label, err)
+		return
+	}
+	msg := fmt.Sprintf("upstream diff: %s", bytes)
+	glog.V(9).Infof("%s %s", label, msg)
+	if p.host != nil {
+		if err = p.host.Log(ctx, diag.Debug, urn, msg); err != nil {
+			glog.V(9).Infof("%s failed to log upstream diff: %v", label, err)
+		}
+	}
+}
+
 // Create allocates a new instance of the provided resource and returns its unique ID afterwards.  (The input ID
 // must be blank.)  If this call fails, the resource must not have been created (i.e., it is "transactional").
-func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*pulumirpc.CreateResponse, error) {
+func (p *Provider) Create(ctx context.Context,
+	req *pulumirpc.CreateRequest) (resp *pulumirpc.CreateResponse, err error) {
+
 	p.setLoggingContext(ctx)
 	urn := resource.URN(req.GetUrn())
 	t := urn.Type()
+	if p.isPrecomputedValue(t) {
+		return p.createPrecomputedValue(req)
+	}
+	if res, has := p.dsResources[t]; has {
+		return p.createDataSourceResource(ctx, res, req)
+	}
+	if resp, ok, err := p.createWithProfile(ctx, req); ok {
+		return resp, err
+	}
 	res, has := p.resources[t]
 	if !has {
 		return nil, errors.Errorf("unrecognized resource type (Create): %s", t)
@@ -820,6 +1059,9 @@ func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*p
 
 	label := fmt.Sprintf("%s.Create(%s/%s)", p.label(), urn, res.TFName)
 	glog.V(9).Infof("%s executing", label)
+	defer p.auditOperation("Create", urn, req.GetProperties(), time.Now(), &err)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Create", string(t))()
 
 	// To get Terraform to create a new resource, the ID must be blank and existing state must be empty (since the
 	// resource does not exist yet), and the diff object should have no old state and all of the new state.
@@ -852,7 +1094,12 @@ func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*p
 	var newstate shim.InstanceState
 	var reasons []string
 	if !req.GetPreview() {
-		newstate, err = p.tf.Apply(res.TFName, nil, diff)
+		if err = p.waitForRateLimit(ctx); err != nil {
+			return nil, err
+		}
+		newstate, err = p.checkpointedApply(ctx, urn, res, req.GetProperties(), func() (shim.InstanceState, error) {
+			return p.tf.Apply(res.TFName, nil, diff)
+		})
 		if newstate == nil {
 			if err == nil {
 				return nil, fmt.Errorf("expected non-nil error with nil state during Create of %s", urn)
@@ -865,6 +1112,9 @@ func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*p
 
 		if err != nil {
 			reasons = append(reasons, errors.Wrapf(err, "creating %s", urn).Error())
+		} else if newstate, err = p.awaitOutputs(ctx, urn, res, newstate); err != nil {
+			// The resource exists, so its outputs are recorded along with the error.
+			reasons = append(reasons, err.Error())
 		}
 	} else {
 		newstate, err = diff.ProposedState(res.TF, nil)
@@ -878,6 +1128,18 @@ func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*p
 	if err != nil {
 		reasons = append(reasons, errors.Wrapf(err, "converting result for %s", urn).Error())
 	}
+	if req.GetPreview() && props != nil {
+		news, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+			Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true, SkipNulls: true})
+		if err != nil {
+			return nil, err
+		}
+		if p.usesPreciseUnknowns(res) {
+			refinePreviewUnknowns(p.tf, res, news, props, p.supportsSecrets)
+		}
+		predictPreviewOutputs(res.Schema, news, props)
+	}
+	p.auditSecrets(ctx, urn, label, props)
 
 	mprops, err := plugin.MarshalProperties(props, plugin.MarshalOptions{
 		Label:        fmt.Sprintf("%s.outs", label),
@@ -886,6 +1148,15 @@ func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*p
 	})
 	if err != nil {
 		reasons = append(reasons, errors.Wrapf(err, "marshalling %s", urn).Error())
+	} else if err = p.checkStateSize(string(urn), mprops); err != nil {
+		// The resource exists, so its state is recorded along with the error. Only the properties that would make the
+		// response too large for gRPC are left out.
+		reasons = append(reasons, err.Error())
+		var dropped []string
+		if mprops, dropped = fitStateSize(mprops, maxRPCMessageSize); len(dropped) > 0 {
+			reasons = append(reasons, fmt.Sprintf("%s were left out of the state of %s because they do not fit in "+
+				"a gRPC message", strings.Join(dropped, ", "), urn))
+		}
 	}
 
 	if len(reasons) != 0 {
@@ -896,10 +1167,19 @@ func (p *Provider) Create(ctx context.Context, req *pulumirpc.CreateRequest) (*p
 
 // Read the current live state associated with a resource.  Enough state must be include in the inputs to uniquely
 // identify the resource; this is typically just the resource ID, but may also include some properties.
-func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (*pulumirpc.ReadResponse, error) {
+func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (resp *pulumirpc.ReadResponse, err error) {
 	p.setLoggingContext(ctx)
 	urn := resource.URN(req.GetUrn())
 	t := urn.Type()
+	if p.isPrecomputedValue(t) {
+		return p.readPrecomputedValue(req)
+	}
+	if res, has := p.dsResources[t]; has {
+		return p.refreshDataSourceResource(ctx, res, req)
+	}
+	if resp, ok, err := p.readWithProfile(ctx, req); ok {
+		return resp, err
+	}
 	res, has := p.resources[t]
 	if !has {
 		return nil, errors.Errorf("unrecognized resource type (Read): %s", t)
@@ -908,6 +1188,9 @@ func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (*pulum
 	id := req.GetId()
 	label := fmt.Sprintf("%s.Read(%s, %s/%s)", p.label(), id, urn, res.TFName)
 	glog.V(9).Infof("%s executing", label)
+	defer p.auditOperation("Read", urn, req.GetInputs(), time.Now(), &err)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Read", string(t))()
 
 	// Manufacture Terraform attributes and state with the provided properties, in preparation for reading.
 	oldInputs, err := plugin.UnmarshalProperties(req.GetInputs(), plugin.MarshalOptions{
@@ -915,7 +1198,13 @@ func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (*pulum
 	if err != nil {
 		return nil, err
 	}
-	state, err := UnmarshalTerraformState(res, id, req.GetProperties(), fmt.Sprintf("%s.state", label))
+	stateProps, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.state", label), SkipNulls: true})
+	if err != nil {
+		return nil, err
+	}
+	stateProps = p.coerceStateTypes(ctx, urn, res, p.pruneStateDrift(ctx, urn, res, stateProps, len(stateProps) > 0))
+	state, err := MakeTerraformState(res, id, stateProps)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unmarshaling %s's instance state", urn)
 	}
@@ -937,7 +1226,10 @@ func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (*pulum
 		}
 	}
 
-	newstate, err := p.tf.Refresh(res.TFName, state)
+	if err = p.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	newstate, err := p.readResource(ctx, res, state)
 	if err != nil {
 		return nil, errors.Wrapf(err, "refreshing %s", urn)
 	}
@@ -950,15 +1242,33 @@ func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (*pulum
 			return nil, err
 		}
 
+		// If we are refreshing, keep prior values that the provider reports in a semantically equivalent form.
+		if isRefresh {
+			olds, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+				Label: fmt.Sprintf("%s.olds", label), KeepUnknowns: true, SkipNulls: true})
+			if err != nil {
+				return nil, err
+			}
+			props = reconcileReadResult(olds, props, res.TF.Schema(), res.Schema.Fields)
+		}
+		p.auditSecrets(ctx, urn, label, props)
+
 		mprops, err := plugin.MarshalProperties(props, plugin.MarshalOptions{Label: label + ".state"})
 		if err != nil {
 			return nil, err
 		}
+		if err = p.checkStateSize(string(urn), mprops); err != nil {
+			return nil, err
+		}
 
 		inputs, err := extractInputsFromOutputs(oldInputs, props, res.TF.Schema(), res.Schema.Fields, isRefresh)
 		if err != nil {
 			return nil, err
 		}
+		if !isRefresh {
+			// Report any inputs that cannot be read from the cloud with their placeholders.
+			fillImportPlaceholders(inputs, res.Schema)
+		}
 		minputs, err := plugin.MarshalProperties(inputs, plugin.MarshalOptions{
 			Label:       label + ".inputs",
 			KeepSecrets: p.supportsSecrets,
@@ -976,10 +1286,21 @@ func (p *Provider) Read(ctx context.Context, req *pulumirpc.ReadRequest) (*pulum
 
 // Update updates an existing resource with new values.  Only those values in the provided property bag are updated
 // to new values.  The resource ID is returned and may be different if the resource had to be recreated.
-func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*pulumirpc.UpdateResponse, error) {
+func (p *Provider) Update(ctx context.Context,
+	req *pulumirpc.UpdateRequest) (resp *pulumirpc.UpdateResponse, err error) {
+
 	p.setLoggingContext(ctx)
 	urn := resource.URN(req.GetUrn())
 	t := urn.Type()
+	if p.isPrecomputedValue(t) {
+		return p.updatePrecomputedValue(req)
+	}
+	if res, has := p.dsResources[t]; has {
+		return p.updateDataSourceResource(ctx, res, req)
+	}
+	if resp, ok, err := p.updateWithProfile(ctx, req); ok {
+		return resp, err
+	}
 	res, has := p.resources[t]
 	if !has {
 		return nil, errors.Errorf("unrecognized resource type (Update): %s", t)
@@ -987,6 +1308,9 @@ func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*p
 
 	label := fmt.Sprintf("%s.Update(%s/%s)", p.label(), urn, res.TFName)
 	glog.V(9).Infof("%s executing", label)
+	defer p.auditOperation("Update", urn, req.GetNews(), time.Now(), &err)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Update", string(t))()
 
 	// In order to perform the update, we first need to calculate the Terraform view of the diff.
 	olds, err := plugin.UnmarshalProperties(req.GetOlds(),
@@ -994,10 +1318,22 @@ func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*p
 	if err != nil {
 		return nil, err
 	}
+	olds = p.coerceStateTypes(ctx, urn, res, p.pruneStateDrift(ctx, urn, res, olds, false))
 	state, err := MakeTerraformState(res, req.GetId(), olds)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unmarshaling %s's instance state", urn)
 	}
+	if _, interrupted := olds[checkpointKey]; interrupted && !req.GetPreview() {
+		return p.resumeCreate(ctx, urn, res, req, state)
+	}
+	if !req.GetPreview() {
+		if state, err = p.refreshBeforeUpdate(ctx, urn, res, state); err != nil {
+			return nil, err
+		}
+		if state == nil {
+			return nil, missingOnUpdateError(urn)
+		}
+	}
 
 	news, err := plugin.UnmarshalProperties(req.GetNews(),
 		plugin.MarshalOptions{Label: fmt.Sprintf("%s.news", label), KeepUnknowns: true})
@@ -1035,15 +1371,16 @@ func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*p
 	var newstate shim.InstanceState
 	var reasons []string
 	if !req.GetPreview() {
+		if err = p.waitForRateLimit(ctx); err != nil {
+			return nil, err
+		}
 		newstate, err = p.tf.Apply(res.TFName, state, diff)
 		if newstate == nil {
 			if err != nil {
 				return nil, err
 			}
 
-			return nil, fmt.Errorf("Resource provider reported that the resource did not exist while updating %s.\n\n"+
-				"This is usually a result of the resource having been deleted outside of Pulumi, and can often be "+
-				"fixed by running `pulumi refresh` before updating.", urn)
+			return nil, missingOnUpdateError(urn)
 		}
 		if newstate.ID() == "" {
 			return nil, fmt.Errorf("expected non-empty ID for new state during Update of %s", urn)
@@ -1062,6 +1399,13 @@ func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*p
 	if err != nil {
 		reasons = append(reasons, errors.Wrapf(err, "converting result for %s", urn).Error())
 	}
+	if req.GetPreview() && props != nil {
+		if p.usesPreciseUnknowns(res) {
+			refinePreviewUnknowns(p.tf, res, news, props, p.supportsSecrets)
+		}
+		predictPreviewOutputs(res.Schema, news, props)
+	}
+	p.auditSecrets(ctx, urn, label, props)
 	mprops, err := plugin.MarshalProperties(props, plugin.MarshalOptions{
 		Label:        fmt.Sprintf("%s.outs", label),
 		KeepUnknowns: req.GetPreview(),
@@ -1069,6 +1413,15 @@ func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*p
 	})
 	if err != nil {
 		reasons = append(reasons, errors.Wrapf(err, "marshalling %s", urn).Error())
+	} else if err = p.checkStateSize(string(urn), mprops); err != nil {
+		// The resource exists, so its state is recorded along with the error. Only the properties that would make the
+		// response too large for gRPC are left out.
+		reasons = append(reasons, err.Error())
+		var dropped []string
+		if mprops, dropped = fitStateSize(mprops, maxRPCMessageSize); len(dropped) > 0 {
+			reasons = append(reasons, fmt.Sprintf("%s were left out of the state of %s because they do not fit in "+
+				"a gRPC message", strings.Join(dropped, ", "), urn))
+		}
 	}
 
 	if len(reasons) != 0 {
@@ -1077,11 +1430,27 @@ func (p *Provider) Update(ctx context.Context, req *pulumirpc.UpdateRequest) (*p
 	return &pulumirpc.UpdateResponse{Properties: mprops}, nil
 }
 
+// missingOnUpdateError returns the error reported when the resource to update no longer exists.
+func missingOnUpdateError(urn resource.URN) error {
+	return fmt.Errorf("Resource provider reported that the resource did not exist while updating %s.\n\n"+
+		"This is usually a result of the resource having been deleted outside of Pulumi, and can often be "+
+		"fixed by running `pulumi refresh` before updating.", urn)
+}
+
 // Delete tears down an existing resource with the given ID.  If it fails, the resource is assumed to still exist.
-func (p *Provider) Delete(ctx context.Context, req *pulumirpc.DeleteRequest) (*pbempty.Empty, error) {
+func (p *Provider) Delete(ctx context.Context, req *pulumirpc.DeleteRequest) (resp *pbempty.Empty, err error) {
 	p.setLoggingContext(ctx)
 	urn := resource.URN(req.GetUrn())
 	t := urn.Type()
+	if p.isPrecomputedValue(t) {
+		return p.deletePrecomputedValue(req)
+	}
+	if _, has := p.dsResources[t]; has {
+		return p.deleteDataSourceResource(req)
+	}
+	if resp, ok, err := p.deleteWithProfile(ctx, req); ok {
+		return resp, err
+	}
 	res, has := p.resources[t]
 	if !has {
 		return nil, errors.Errorf("unrecognized resource type (Delete): %s", t)
@@ -1089,12 +1458,36 @@ func (p *Provider) Delete(ctx context.Context, req *pulumirpc.DeleteRequest) (*p
 
 	label := fmt.Sprintf("%s.Delete(%s/%s)", p.label(), urn, res.TFName)
 	glog.V(9).Infof("%s executing", label)
+	defer p.auditOperation("Delete", urn, req.GetProperties(), time.Now(), &err)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Delete", string(t))()
+
+	// Enforce the default resource options that the provider configuration sets for this type, if any.
+	if err = p.checkProtectedDelete(urn); err != nil {
+		return nil, err
+	}
+	if p.retainOnDelete(ctx, urn, req.GetId()) {
+		return &pbempty.Empty{}, nil
+	}
 
 	// Fetch the resource attributes since many providers need more than just the ID to perform the delete.
-	state, err := UnmarshalTerraformState(res, req.GetId(), req.GetProperties(), label)
+	props, err := plugin.UnmarshalProperties(req.GetProperties(), plugin.MarshalOptions{
+		Label: fmt.Sprintf("%s.state", label), SkipNulls: true})
 	if err != nil {
 		return nil, err
 	}
+	props = p.coerceStateTypes(ctx, urn, res, p.pruneStateDrift(ctx, urn, res, props, false))
+	state, err := MakeTerraformState(res, req.GetId(), props)
+	if err != nil {
+		return nil, err
+	}
+	if state, err = p.refreshBeforeUpdate(ctx, urn, res, state); err != nil {
+		return nil, err
+	}
+	if state == nil {
+		// The resource is already gone, so there is nothing to delete.
+		return &pbempty.Empty{}, nil
+	}
 
 	// Create a new destroy diff.
 	diff := p.tf.NewDestroyDiff()
@@ -1102,9 +1495,15 @@ func (p *Provider) Delete(ctx context.Context, req *pulumirpc.DeleteRequest) (*p
 		diff.SetTimeout(req.Timeout, shim.TimeoutDelete)
 	}
 
+	if err := p.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
 	if _, err := p.tf.Apply(res.TFName, state, diff); err != nil {
 		return nil, errors.Wrapf(err, "deleting %s", urn)
 	}
+	if err := p.verifyDeleted(ctx, urn, res, req.GetId(), props, req.Timeout); err != nil {
+		return nil, err
+	}
 	return &pbempty.Empty{}, nil
 }
 
@@ -1119,9 +1518,23 @@ func (p *Provider) Call(ctx context.Context, req *pulumirpc.CallRequest) (*pulum
 }
 
 // Invoke dynamically executes a built-in function in the provider.
-func (p *Provider) Invoke(ctx context.Context, req *pulumirpc.InvokeRequest) (*pulumirpc.InvokeResponse, error) {
+func (p *Provider) Invoke(ctx context.Context,
+	req *pulumirpc.InvokeRequest) (resp *pulumirpc.InvokeResponse, err error) {
+
 	p.setLoggingContext(ctx)
 	tok := tokens.ModuleMember(req.GetTok())
+	if tok == p.runtimeStatsToken() && debugInvokesEnabled() {
+		return p.invokeRuntimeStats()
+	}
+	if tok == p.convertStateToken() {
+		return p.invokeConvertState(req)
+	}
+	if tok == p.terraformCompatToken() {
+		return p.invokeTerraformCompat(req)
+	}
+	if er, has := p.ephemerals[tok]; has {
+		return p.invokeEphemeralResource(ctx, er, req)
+	}
 	ds, has := p.dataSources[tok]
 	if !has {
 		return nil, errors.Errorf("unrecognized data function (Invoke): %s", tok)
@@ -1129,6 +1542,9 @@ func (p *Provider) Invoke(ctx context.Context, req *pulumirpc.InvokeRequest) (*p
 
 	label := fmt.Sprintf("%s.Invoke(%s)", p.label(), tok)
 	glog.V(9).Infof("%s executing", label)
+	defer p.recoverProviderPanic(label, &err)
+	defer p.trackOperation("Invoke", string(tok))()
+	p.warnDeprecatedRedirect(ctx, "", p.info.DataSourceRedirectMessage(ds.Schema))
 
 	// Unmarshal the arguments.
 	args, err := plugin.UnmarshalProperties(req.GetArgs(), plugin.MarshalOptions{
@@ -1165,14 +1581,9 @@ func (p *Provider) Invoke(ctx context.Context, req *pulumirpc.InvokeRequest) (*p
 	// If there are no failures in verification, go ahead and perform the invocation.
 	var ret *pbstruct.Struct
 	if len(failures) == 0 {
-		diff, err := p.tf.ReadDataDiff(tfname, rescfg)
+		invoke, err := p.readDataSource(ctx, tfname, string(tok), rescfg)
 		if err != nil {
-			return nil, errors.Wrapf(err, "reading data source diff for %s", tok)
-		}
-
-		invoke, err := p.tf.ReadDataApply(tfname, diff)
-		if err != nil {
-			return nil, errors.Wrapf(err, "invoking %s", tok)
+			return nil, err
 		}
 
 		// Add the special "id" attribute if it wasn't listed in the schema
@@ -1190,6 +1601,9 @@ func (p *Provider) Invoke(ctx context.Context, req *pulumirpc.InvokeRequest) (*p
 		if err != nil {
 			return nil, err
 		}
+		if err = p.checkStateSize(string(tok), ret); err != nil {
+			return nil, err
+		}
 	}
 
 	return &pulumirpc.InvokeResponse{
@@ -1316,7 +1730,8 @@ func generateResourceName(packageName tokens.Package, moduleName string, moduleM
 
 // SetAutonaming will loop all resources with a name property, and will add an auto-name property.  It will skip
 // those that already have a name mapping entry, since those may have custom overrides set in the resource
-// declaration (e.g., for length).
+// declaration (e.g., for length). New providers should set ProviderInfo.AutoNaming instead, which also documents the
+// autonamed properties and honors the `autonaming` configuration.
 func (p *ProviderInfo) SetAutonaming(maxLength int, separator string) {
 	const nameProperty = "name"
 	for resname, res := range p.Resources {
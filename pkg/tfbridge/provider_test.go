@@ -3,6 +3,7 @@ package tfbridge
 import (
 	"context"
 	"errors"
+	"os"
 	"sort"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
 	shimv1 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v1"
 	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
 )
@@ -199,6 +201,51 @@ func TestBuildConfig(t *testing.T) {
 	assert.Equal(t, expected, configOut)
 }
 
+func TestMarkSensitiveConfigValues(t *testing.T) {
+	assumeRole := (&schema.Schema{
+		Type:     shim.TypeList,
+		MaxItems: 1,
+		Elem: (&schema.Resource{
+			Schema: schemaMap(map[string]*schema.Schema{
+				"role_arn":    {Type: shim.TypeString},
+				"external_id": {Type: shim.TypeString, Sensitive: true},
+			}),
+		}).Shim(),
+	}).Shim()
+
+	pv, err := convertStringToPropertyValue(`{"roleArn":"arn","externalId":"shh"}`, assumeRole.Type())
+	assert.NoError(t, err)
+
+	actual := markSensitiveConfigValues(pv, assumeRole, nil)
+	expected := resource.NewObjectProperty(resource.PropertyMap{
+		"roleArn":    resource.NewStringProperty("arn"),
+		"externalId": resource.MakeSecret(resource.NewStringProperty("shh")),
+	})
+	assert.True(t, expected.DeepEquals(actual))
+
+	// Scalar values are left alone.
+	str := resource.NewStringProperty("foo")
+	assert.Equal(t, str, markSensitiveConfigValues(str, (&schema.Schema{Type: shim.TypeString}).Shim(), nil))
+}
+
+func TestBuildConfigSecrets(t *testing.T) {
+	provider := &Provider{
+		tf:     shimv1.NewProvider(testTFProvider),
+		config: shimv1.NewSchemaMap(testTFProvider.Schema),
+	}
+
+	configIn := resource.PropertyMap{
+		"configValue": resource.MakeSecret(resource.NewStringProperty("foo")),
+	}
+	configOut, err := buildTerraformConfig(provider, configIn)
+	assert.NoError(t, err)
+
+	expected := provider.tf.NewResourceConfig(map[string]interface{}{
+		"config_value": "foo",
+	})
+	assert.Equal(t, expected, configOut)
+}
+
 func testIgnoreChanges(t *testing.T, provider *Provider) {
 	urn := resource.NewURN("stack", "project", "", "ExampleResource", "name")
 
@@ -554,3 +601,16 @@ func TestProviderPreConfigureCallbackV2(t *testing.T) {
 	}
 	testProviderPreConfigureCallback(t, provider)
 }
+
+// setEnv sets the given environment variable and returns a function that restores its previous value, to be deferred.
+func setEnv(t *testing.T, key, value string) func() {
+	prev, had := os.LookupEnv(key)
+	assert.NoError(t, os.Setenv(key, value))
+	return func() {
+		if had {
+			assert.NoError(t, os.Setenv(key, prev))
+		} else {
+			assert.NoError(t, os.Unsetenv(key))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// The bridge-level configuration keys that limit the rate of the operations that the bridge sends to the upstream
+// provider, for APIs that cannot cope with the parallelism of a Pulumi deployment. These keys are only interpreted by
+// the bridge if the upstream provider does not define configuration with the same name.
+const (
+	rateLimitConfigKey      = "rateLimit"      // the sustained number of operations per second.
+	rateLimitBurstConfigKey = "rateLimitBurst" // the number of operations that may be sent at once.
+)
+
+// rateLimiter is a token bucket that holds up to burst tokens and gains rate tokens per second. Each operation takes
+// a token, waiting for one if none are available.
+type rateLimiter struct {
+	m      sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	now := time.Now
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: now(), now: now}
+}
+
+// reserve takes a token and returns how long the caller must wait before the token may be used.
+func (l *rateLimiter) reserve() time.Duration {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// The bucket may go into debt, which callers that arrive later have to wait out in turn.
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks until the caller may send an operation or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay == 0 {
+		return nil
+	}
+	glog.V(9).Infof("rate limit reached; delaying operation by %v", delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// configureRateLimit removes the rate limit settings from vars and, if a rate limit was set, limits the rate of the
+// operations sent to the upstream provider accordingly. The burst defaults to one second's worth of operations.
+// Providers that do not set ProviderInfo.RateLimit leave the keys to the upstream provider.
+func (p *Provider) configureRateLimit(vars resource.PropertyMap) error {
+	if !p.info.RateLimit {
+		return nil
+	}
+	limit, hasLimit := p.takeBridgeConfig(vars, rateLimitConfigKey)
+	burstValue, hasBurst := p.takeBridgeConfig(vars, rateLimitBurstConfigKey)
+	if !hasLimit || limit == "" {
+		return nil
+	}
+
+	rate, err := strconv.ParseFloat(limit, 64)
+	if err != nil || rate <= 0 {
+		return errors.Errorf("malformed configuration value for '%v': must be a positive number of operations "+
+			"per second", rateLimitConfigKey)
+	}
+	burst := int(math.Max(1, math.Ceil(rate)))
+	if hasBurst && burstValue != "" {
+		if burst, err = strconv.Atoi(burstValue); err != nil || burst < 1 {
+			return errors.Errorf("malformed configuration value for '%v': must be a positive number of operations",
+				rateLimitBurstConfigKey)
+		}
+	}
+
+	p.rateLimiter = newRateLimiter(rate, burst)
+	return nil
+}
+
+// waitForRateLimit blocks until the next operation may be sent to the upstream provider, if the provider is rate
+// limited.
+func (p *Provider) waitForRateLimit(ctx context.Context) error {
+	if p.rateLimiter == nil {
+		return nil
+	}
+	return p.rateLimiter.wait(ctx)
+}
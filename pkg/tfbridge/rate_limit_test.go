@@ -0,0 +1,110 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	start := time.Now()
+	now := start
+	l := newRateLimiter(2, 2)
+	l.last, l.now = start, func() time.Time { return now }
+
+	// The burst is available immediately, after which each operation waits for the bucket to refill.
+	assert.Equal(t, time.Duration(0), l.reserve())
+	assert.Equal(t, time.Duration(0), l.reserve())
+	assert.Equal(t, 500*time.Millisecond, l.reserve())
+	assert.Equal(t, time.Second, l.reserve())
+
+	// The bucket refills over time, but never beyond the burst.
+	now = start.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), l.reserve())
+	assert.Equal(t, time.Duration(0), l.reserve())
+	assert.Equal(t, 500*time.Millisecond, l.reserve())
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := newRateLimiter(0.001, 1)
+	assert.NoError(t, l.wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, l.wait(ctx))
+}
+
+func TestConfigureRateLimit(t *testing.T) {
+	optedIn := ProviderInfo{RateLimit: true}
+	p := &Provider{info: optedIn}
+	vars := resource.PropertyMap{
+		"rateLimit": resource.NewStringProperty("2.5"),
+		"region":    resource.NewStringProperty("us-west-2"),
+	}
+	assert.NoError(t, p.configureRateLimit(vars))
+	if assert.NotNil(t, p.rateLimiter) {
+		assert.Equal(t, 2.5, p.rateLimiter.rate)
+		assert.Equal(t, 3.0, p.rateLimiter.burst)
+	}
+	assert.Equal(t, resource.PropertyMap{"region": resource.NewStringProperty("us-west-2")}, vars)
+
+	p = &Provider{info: optedIn}
+	assert.NoError(t, p.configureRateLimit(resource.PropertyMap{
+		"rateLimit":      resource.NewNumberProperty(0.5),
+		"rateLimitBurst": resource.NewStringProperty("10"),
+	}))
+	if assert.NotNil(t, p.rateLimiter) {
+		assert.Equal(t, 0.5, p.rateLimiter.rate)
+		assert.Equal(t, 10.0, p.rateLimiter.burst)
+	}
+
+	p = &Provider{info: optedIn}
+	assert.NoError(t, p.configureRateLimit(resource.PropertyMap{}))
+	assert.Nil(t, p.rateLimiter)
+	assert.NoError(t, p.waitForRateLimit(context.Background()))
+
+	// Keys that the provider defines itself are left alone.
+	p = &Provider{
+		info: optedIn,
+		config: schemaMap(map[string]*schema.Schema{
+			"rate_limit": {Type: shim.TypeInt, Optional: true},
+		}),
+	}
+	assert.NoError(t, p.configureRateLimit(resource.PropertyMap{"rateLimit": resource.NewStringProperty("5")}))
+	assert.Nil(t, p.rateLimiter)
+
+	for _, vars := range []resource.PropertyMap{
+		{"rateLimit": resource.NewStringProperty("fast")},
+		{"rateLimit": resource.NewStringProperty("-1")},
+		{"rateLimit": resource.NewStringProperty("1"), "rateLimitBurst": resource.NewStringProperty("0")},
+	} {
+		assert.Error(t, (&Provider{info: optedIn}).configureRateLimit(vars))
+	}
+
+	// Providers that do not opt in leave the keys alone.
+	p = &Provider{}
+	vars = resource.PropertyMap{"rateLimit": resource.NewStringProperty("5")}
+	assert.NoError(t, p.configureRateLimit(vars))
+	assert.Nil(t, p.rateLimiter)
+	assert.Contains(t, vars, resource.PropertyKey("rateLimit"))
+}
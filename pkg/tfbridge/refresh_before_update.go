@@ -0,0 +1,50 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// refreshBeforeUpdate reads the resource with the given state from the upstream provider if the resource requests it,
+// so that fields such as etags are current when the resource is updated or deleted. The upstream provider reads the
+// resource on top of the given state, so the refreshed state keeps any attributes that cannot be read back. It returns
+// nil if the resource no longer exists, and the given state if the resource does not request a refresh.
+func (p *Provider) refreshBeforeUpdate(ctx context.Context, urn resource.URN, res Resource,
+	state shim.InstanceState) (shim.InstanceState, error) {
+
+	if res.Schema == nil || !res.Schema.RefreshBeforeUpdate {
+		return state, nil
+	}
+	glog.V(9).Infof("%s: refreshing before writing", urn)
+
+	if err := p.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	refreshed, err := p.readResource(ctx, res, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "refreshing %s", urn)
+	}
+	if refreshed == nil || refreshed.ID() == "" {
+		return nil, nil
+	}
+	return refreshed, nil
+}
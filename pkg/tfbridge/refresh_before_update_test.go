@@ -0,0 +1,106 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestRefreshBeforeUpdate(t *testing.T) {
+	// The cloud has moved on to a new etag since the state was last read, and rejects writes that carry an old one.
+	etag, exists, deletes := "v2", true, 0
+	checkEtag := func(d *schemav2.ResourceData) diag.Diagnostics {
+		if d.Get("etag").(string) != etag {
+			return diag.Errorf("precondition failed")
+		}
+		return nil
+	}
+	tfRes := &schemav2.Resource{
+		Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Optional: true},
+			"etag": {Type: schemav2.TypeString, Computed: true},
+		},
+		ReadContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			if !exists {
+				d.SetId("")
+				return nil
+			}
+			return diag.FromErr(d.Set("etag", etag))
+		},
+		UpdateContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			return checkEtag(d)
+		},
+		DeleteContext: func(_ context.Context, d *schemav2.ResourceData, _ interface{}) diag.Diagnostics {
+			deletes++
+			return checkEtag(d)
+		},
+	}
+	info := &ResourceInfo{Tok: "example:index/bucket:Bucket", RefreshBeforeUpdate: true}
+	p := &Provider{
+		tf: shimv2.NewProvider(&schemav2.Provider{ResourcesMap: map[string]*schemav2.Resource{"example_bucket": tfRes}}),
+		resources: map[tokens.Type]Resource{
+			"example:index/bucket:Bucket": {TF: shimv2.NewResource(tfRes), TFName: "example_bucket", Schema: info},
+		},
+	}
+	urn := resource.NewURN("stack", "project", "", "example:index/bucket:Bucket", "bucket")
+
+	olds, err := plugin.MarshalProperties(resource.PropertyMap{
+		"name": resource.NewStringProperty("old"),
+		"etag": resource.NewStringProperty("v1"),
+	}, plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	news, err := plugin.MarshalProperties(resource.PropertyMap{"name": resource.NewStringProperty("new")},
+		plugin.MarshalOptions{})
+	assert.NoError(t, err)
+
+	resp, err := p.Update(context.Background(), &pulumirpc.UpdateRequest{
+		Id: "bucket", Urn: string(urn), Olds: olds, News: news})
+	assert.NoError(t, err)
+	outs, err := plugin.UnmarshalProperties(resp.GetProperties(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", outs["name"].StringValue())
+	assert.Equal(t, "v2", outs["etag"].StringValue())
+
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{Id: "bucket", Urn: string(urn), Properties: olds})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deletes)
+
+	// Resources that are already gone are not deleted again, and cannot be updated.
+	exists = false
+	_, err = p.Delete(context.Background(), &pulumirpc.DeleteRequest{Id: "bucket", Urn: string(urn), Properties: olds})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deletes)
+	_, err = p.Update(context.Background(), &pulumirpc.UpdateRequest{
+		Id: "bucket", Urn: string(urn), Olds: olds, News: news})
+	assert.Error(t, err)
+
+	// Without the flag, the stale etag is sent as-is.
+	exists, info.RefreshBeforeUpdate = true, false
+	_, err = p.Update(context.Background(), &pulumirpc.UpdateRequest{
+		Id: "bucket", Urn: string(urn), Olds: olds, News: news})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "precondition failed")
+}
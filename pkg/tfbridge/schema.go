@@ -261,12 +261,13 @@ func (ctx *conversionContext) MakeTerraformInput(name string, old, v resource.Pr
 
 		// If we have schema information that indicates that this value is being presented to a map-typed field whose
 		// Elem is a shim.Resource, wrap the value in an array in order to work around a bug in Terraform.
-		if tfs != nil && tfs.Type() == shim.TypeMap {
-			if _, hasResourceElem := tfs.Elem().(shim.Resource); hasResourceElem {
-				return []interface{}{input}, nil
-			}
+		if isObjectMap(tfs) {
+			return []interface{}{input}, nil
 		}
 		return input, nil
+	case v.IsSecret():
+		// Secrets are only tracked on the Pulumi side; Terraform sees the underlying value.
+		return ctx.MakeTerraformInput(name, old, v.SecretValue().Element, tfs, ps, rawNames)
 	case v.IsComputed() || v.IsOutput():
 		// If any variables are unknown, we need to mark them in the inputs so the config map treats it right.  This
 		// requires the use of the special UnknownVariableValue sentinel in Terraform, which is how it internally stores
@@ -292,7 +293,7 @@ func (ctx *conversionContext) MakeTerraformInputs(olds, news resource.PropertyMa
 	for key, value := range news {
 		// If this is a reserved property, ignore it.
 		switch key {
-		case defaultsKey, metaKey:
+		case defaultsKey, metaKey, checkpointKey:
 			continue
 		}
 
@@ -586,6 +587,13 @@ func makeTerraformUnknown(tfs shim.Schema) interface{} {
 			arr[i] = makeTerraformUnknownElement(tfs.Elem())
 		}
 		return arr
+	case shim.TypeMap:
+		// Maps whose Elem is a shim.Resource are presented to Terraform as single-element lists of objects, so their
+		// unknowns must take the same shape.
+		if isObjectMap(tfs) {
+			return []interface{}{makeTerraformUnknownElement(tfs.Elem())}
+		}
+		return TerraformUnknownVariableValue
 	default:
 		return TerraformUnknownVariableValue
 	}
@@ -632,6 +640,9 @@ func MakeTerraformOutputs(p shim.Provider, outs map[string]interface{},
 		// First do a lookup of the name/info.
 		name, tfi, psi := getInfoFromTerraformName(key, tfs, ps, rawNames)
 		contract.Assert(name != "")
+		if psi != nil && psi.Omit {
+			continue
+		}
 
 		// Next perform a translation of the value accordingly.
 		out := MakeTerraformOutput(p, value, tfi, psi, assets, rawNames, supportsSecrets)
@@ -724,9 +735,14 @@ func MakeTerraformOutput(p shim.Provider, v interface{},
 			for _, elem := range elems {
 				arr = append(arr, MakeTerraformOutput(p, elem, tfes, pes, assets, rawNames, supportsSecrets))
 			}
+			// Terraform does not guarantee the order of the elements of a set, so sort them to keep the output stable.
+			if tfs != nil && tfs.Type() == shim.TypeSet {
+				sortSetElements(arr)
+			}
 			// For TypeList or TypeSet with MaxItems==1, we will have projected as a scalar nested value, so need to extract
-			// out the single element (or null).
-			if IsMaxItemsOne(tfs, ps) {
+			// out the single element (or null). The same is true of maps whose Elem is a shim.Resource, which we project
+			// as a single object but which Terraform may store as a single-element list.
+			if IsMaxItemsOne(tfs, ps) || isObjectMap(tfs) {
 				switch len(arr) {
 				case 0:
 					return resource.NewNullProperty()
@@ -770,6 +786,40 @@ func MakeTerraformOutput(p shim.Provider, v interface{},
 	return output
 }
 
+// sortSetElements sorts the elements of a set-typed value into a canonical order, so that a set is marshaled the same
+// way no matter the order in which Terraform returns its elements.
+func sortSetElements(elems []resource.PropertyValue) {
+	if len(elems) < 2 {
+		return
+	}
+	keys := make([]string, len(elems))
+	for i, e := range elems {
+		keys[i] = setElementKey(e)
+	}
+	sort.Stable(setElements{elems: elems, keys: keys})
+}
+
+// setElementKey returns the key by which a set element is sorted, which is its JSON representation.
+func setElementKey(v resource.PropertyValue) string {
+	bytes, err := json.Marshal(v.Mappable())
+	if err != nil {
+		return v.String()
+	}
+	return string(bytes)
+}
+
+type setElements struct {
+	elems []resource.PropertyValue
+	keys  []string
+}
+
+func (s setElements) Len() int           { return len(s.elems) }
+func (s setElements) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s setElements) Swap(i, j int) {
+	s.elems[i], s.elems[j] = s.elems[j], s.elems[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
 // MakeTerraformConfig creates a Terraform config map, used in state and diff calculations, from a Pulumi property map.
 func MakeTerraformConfig(p *Provider, m resource.PropertyMap,
 	tfs shim.SchemaMap, ps map[string]*SchemaInfo) (shim.ResourceConfig, AssetTable, error) {
@@ -814,7 +864,7 @@ func makeConfig(v interface{}) interface{} {
 		for k, e := range v {
 			// If this is a reserved property, ignore it.
 			switch k {
-			case defaultsKey, metaKey:
+			case defaultsKey, metaKey, checkpointKey:
 				continue
 			}
 			r[k] = makeConfig(e)
@@ -885,14 +935,23 @@ func IsMaxItemsOne(tfs shim.Schema, info *SchemaInfo) bool {
 	return tfs.MaxItems() == 1
 }
 
+// isObjectMap returns true if the given schema is a Terraform map whose Elem is a shim.Resource. Such maps are
+// projected as a single object in the Pulumi schema.
+func isObjectMap(tfs shim.Schema) bool {
+	if tfs == nil || tfs.Type() != shim.TypeMap {
+		return false
+	}
+	_, hasResourceElem := tfs.Elem().(shim.Resource)
+	return hasResourceElem
+}
+
 // useRawNames returns true if raw, unmangled names should be preserved.  This is only true for Terraform maps with
 // an Elem that is not a shim.Resource.
 func useRawNames(tfs shim.Schema) bool {
 	if tfs == nil || tfs.Type() != shim.TypeMap {
 		return false
 	}
-	_, hasResourceElem := tfs.Elem().(shim.Resource)
-	return !hasResourceElem
+	return !isObjectMap(tfs)
 }
 
 // getInfoFromTerraformName does a map lookup to find the Pulumi name and schema info, if any.
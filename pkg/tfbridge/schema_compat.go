@@ -0,0 +1,332 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// SchemaChangeSeverity classifies a change to a package schema by the version bump that it requires.
+type SchemaChangeSeverity int
+
+const (
+	// SchemaChangePatch is a change that does not affect programs, such as a change to docs.
+	SchemaChangePatch SchemaChangeSeverity = iota
+	// SchemaChangeMinor is a backwards-compatible addition, such as a new resource or optional input.
+	SchemaChangeMinor
+	// SchemaChangeMajor is a change that can break existing programs, such as a removed or retyped property.
+	SchemaChangeMajor
+)
+
+func (s SchemaChangeSeverity) String() string {
+	switch s {
+	case SchemaChangePatch:
+		return "patch"
+	case SchemaChangeMinor:
+		return "minor"
+	case SchemaChangeMajor:
+		return "major"
+	}
+	return fmt.Sprintf("SchemaChangeSeverity(%d)", int(s))
+}
+
+// SchemaChange is a change to one member of a package schema.
+type SchemaChange struct {
+	// Member names the changed member, e.g. `pkg:index/widget:Widget`, `pkg:index/widget:Widget.inputs.name` or
+	// `config.region`.
+	Member   string
+	Severity SchemaChangeSeverity
+	Message  string
+}
+
+func (c SchemaChange) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Severity, c.Member, c.Message)
+}
+
+// SchemaCompatibilityReport lists the changes between two versions of a package schema.
+type SchemaCompatibilityReport struct {
+	Changes []SchemaChange
+}
+
+// Severity returns the severity of the most severe change, which is the version bump that the new schema requires.
+func (r *SchemaCompatibilityReport) Severity() SchemaChangeSeverity {
+	severity := SchemaChangePatch
+	for _, c := range r.Changes {
+		if c.Severity > severity {
+			severity = c.Severity
+		}
+	}
+	return severity
+}
+
+// Check returns an error that lists the changes that are more severe than allowed, if there are any. CI can use it
+// to block accidental breaking changes with `report.Check(tfbridge.SchemaChangeMinor)`.
+func (r *SchemaCompatibilityReport) Check(allowed SchemaChangeSeverity) error {
+	var lines []string
+	for _, c := range r.Changes {
+		if c.Severity > allowed {
+			lines = append(lines, "  "+c.String())
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return errors.Errorf("%d schema changes need a %s version bump:\n%s", len(lines), r.Severity(),
+		strings.Join(lines, "\n"))
+}
+
+// CompareSchemas compares two versions of a package schema, e.g. the schema of the last release and a newly generated
+// one, and classifies each change by severity. The rules follow how the bridge maps upstream providers: for example,
+// a property whose MaxItemsOne setting flipped changes between a list and a single value, which breaks programs,
+// while changes to docs, which are regenerated from upstream on every build, are patches.
+func CompareSchemas(prev, next pschema.PackageSpec) *SchemaCompatibilityReport {
+	c := &schemaComparer{}
+
+	for _, tok := range sortedStringKeys(prev.Resources, next.Resources) {
+		old, hadOld := prev.Resources[tok]
+		res, hasNew := next.Resources[tok]
+		switch {
+		case !hasNew:
+			c.add(tok, SchemaChangeMajor, "resource was removed")
+		case !hadOld:
+			c.add(tok, SchemaChangeMinor, "resource was added")
+		default:
+			c.compareDocs(tok, old.Description, res.Description, old.DeprecationMessage, res.DeprecationMessage)
+			c.compareProperties(tok+".inputs", old.InputProperties, res.InputProperties,
+				old.RequiredInputs, res.RequiredInputs, true)
+			c.compareProperties(tok+".outputs", old.Properties, res.Properties, old.Required, res.Required, false)
+		}
+	}
+
+	for _, tok := range sortedStringKeys(prev.Functions, next.Functions) {
+		old, hadOld := prev.Functions[tok]
+		fun, hasNew := next.Functions[tok]
+		switch {
+		case !hasNew:
+			c.add(tok, SchemaChangeMajor, "function was removed")
+		case !hadOld:
+			c.add(tok, SchemaChangeMinor, "function was added")
+		default:
+			c.compareDocs(tok, old.Description, fun.Description, old.DeprecationMessage, fun.DeprecationMessage)
+			c.compareObjects(tok+".inputs", old.Inputs, fun.Inputs, true)
+			c.compareObjects(tok+".outputs", old.Outputs, fun.Outputs, false)
+		}
+	}
+
+	for _, tok := range sortedStringKeys(prev.Types, next.Types) {
+		old, hadOld := prev.Types[tok]
+		typ, hasNew := next.Types[tok]
+		switch {
+		case !hasNew:
+			c.add(tok, SchemaChangeMajor, "type was removed")
+		case !hadOld:
+			c.add(tok, SchemaChangeMinor, "type was added")
+		case len(old.Enum) > 0 || len(typ.Enum) > 0:
+			c.compareEnums(tok, old.Enum, typ.Enum)
+		default:
+			if old.Description != typ.Description {
+				c.add(tok, SchemaChangePatch, "description changed")
+			}
+			// Object types are used by both inputs and outputs, so new required properties break programs.
+			c.compareProperties(tok, old.Properties, typ.Properties, old.Required, typ.Required, true)
+		}
+	}
+
+	c.compareProperties("config", prev.Config.Variables, next.Config.Variables, prev.Config.Required,
+		next.Config.Required, true)
+	c.compareProperties("provider.inputs", prev.Provider.InputProperties, next.Provider.InputProperties,
+		prev.Provider.RequiredInputs, next.Provider.RequiredInputs, true)
+
+	return &SchemaCompatibilityReport{Changes: c.changes}
+}
+
+type schemaComparer struct {
+	changes []SchemaChange
+}
+
+func (c *schemaComparer) add(member string, severity SchemaChangeSeverity, format string, args ...interface{}) {
+	c.changes = append(c.changes, SchemaChange{
+		Member:   member,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *schemaComparer) compareDocs(member, oldDescription, description, oldDeprecation, deprecation string) {
+	if oldDescription != description {
+		c.add(member, SchemaChangePatch, "description changed")
+	}
+	switch {
+	case oldDeprecation == "" && deprecation != "":
+		c.add(member, SchemaChangeMinor, "was deprecated")
+	case oldDeprecation != "" && deprecation == "":
+		c.add(member, SchemaChangeMinor, "is no longer deprecated")
+	}
+}
+
+func (c *schemaComparer) compareObjects(member string, old, obj *pschema.ObjectTypeSpec, inputs bool) {
+	var oldProps, props map[string]pschema.PropertySpec
+	var oldRequired, required []string
+	if old != nil {
+		oldProps, oldRequired = old.Properties, old.Required
+	}
+	if obj != nil {
+		props, required = obj.Properties, obj.Required
+	}
+	c.compareProperties(member, oldProps, props, oldRequired, required, inputs)
+}
+
+// compareProperties compares the properties of an object. New required properties only break programs if the object
+// is an input; removed properties and changed types always do.
+func (c *schemaComparer) compareProperties(member string, old, props map[string]pschema.PropertySpec,
+	oldRequired, required []string, inputs bool) {
+
+	wasRequired, isRequired := stringSet(oldRequired), stringSet(required)
+	for _, name := range sortedStringKeys(old, props) {
+		path := member + "." + name
+		oldProp, hadOld := old[name]
+		prop, hasNew := props[name]
+		switch {
+		case !hasNew:
+			c.add(path, SchemaChangeMajor, "property was removed")
+			continue
+		case !hadOld && inputs && isRequired[name]:
+			c.add(path, SchemaChangeMajor, "required property was added")
+			continue
+		case !hadOld:
+			c.add(path, SchemaChangeMinor, "property was added")
+			continue
+		}
+
+		c.compareTypes(path, oldProp.TypeSpec, prop.TypeSpec)
+		switch {
+		case inputs && !wasRequired[name] && isRequired[name]:
+			c.add(path, SchemaChangeMajor, "property became required")
+		case inputs && wasRequired[name] && !isRequired[name]:
+			c.add(path, SchemaChangeMinor, "property became optional")
+		case !inputs && wasRequired[name] && !isRequired[name]:
+			c.add(path, SchemaChangeMajor, "output may no longer be set")
+		}
+		if oldProp.Secret != prop.Secret {
+			c.add(path, SchemaChangeMinor, "secret changed from %v to %v", oldProp.Secret, prop.Secret)
+		}
+		c.compareDocs(path, oldProp.Description, prop.Description, oldProp.DeprecationMessage,
+			prop.DeprecationMessage)
+	}
+}
+
+func (c *schemaComparer) compareTypes(member string, old, typ pschema.TypeSpec) {
+	oldType, newType := schemaTypeString(&old), schemaTypeString(&typ)
+	switch {
+	case oldType == newType:
+		return
+	case old.Items != nil && schemaTypeString(old.Items) == newType:
+		c.add(member, SchemaChangeMajor, "changed from a list to a single value (MaxItemsOne was set)")
+	case typ.Items != nil && schemaTypeString(typ.Items) == oldType:
+		c.add(member, SchemaChangeMajor, "changed from a single value to a list (MaxItemsOne was unset)")
+	default:
+		c.add(member, SchemaChangeMajor, "type changed from %s to %s", oldType, newType)
+	}
+}
+
+func (c *schemaComparer) compareEnums(member string, old, values []pschema.EnumValueSpec) {
+	key := func(v pschema.EnumValueSpec) string { return fmt.Sprintf("%v", v.Value) }
+	oldValues, newValues := map[string]bool{}, map[string]bool{}
+	for _, v := range old {
+		oldValues[key(v)] = true
+	}
+	for _, v := range values {
+		newValues[key(v)] = true
+	}
+	for _, v := range old {
+		if !newValues[key(v)] {
+			c.add(member, SchemaChangeMajor, "enum value %s was removed", key(v))
+		}
+	}
+	for _, v := range values {
+		if !oldValues[key(v)] {
+			c.add(member, SchemaChangeMinor, "enum value %s was added", key(v))
+		}
+	}
+}
+
+// schemaTypeString returns a canonical description of the given type, e.g. `array<string>` or
+// `map<#/types/pkg:index/widget:Widget>`.
+func schemaTypeString(t *pschema.TypeSpec) string {
+	switch {
+	case t == nil:
+		return "any"
+	case t.Ref != "":
+		return t.Ref
+	case t.Type == "array":
+		return "array<" + schemaTypeString(t.Items) + ">"
+	case t.Type == "object" && t.AdditionalProperties != nil:
+		return "map<" + schemaTypeString(t.AdditionalProperties) + ">"
+	case len(t.OneOf) > 0:
+		var types []string
+		for i := range t.OneOf {
+			types = append(types, schemaTypeString(&t.OneOf[i]))
+		}
+		sort.Strings(types)
+		return "union<" + strings.Join(types, ", ") + ">"
+	}
+	return t.Type
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// sortedStringKeys returns the union of the keys of the given maps, which must have the same type, in sorted order.
+func sortedStringKeys(maps ...interface{}) []string {
+	keys := map[string]bool{}
+	for _, m := range maps {
+		switch m := m.(type) {
+		case map[string]pschema.ResourceSpec:
+			for k := range m {
+				keys[k] = true
+			}
+		case map[string]pschema.FunctionSpec:
+			for k := range m {
+				keys[k] = true
+			}
+		case map[string]pschema.ComplexTypeSpec:
+			for k := range m {
+				keys[k] = true
+			}
+		case map[string]pschema.PropertySpec:
+			for k := range m {
+				keys[k] = true
+			}
+		default:
+			panic(fmt.Sprintf("unexpected map type %T", m))
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,115 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSchemas(t *testing.T) {
+	str := pschema.TypeSpec{Type: "string"}
+	ref := pschema.TypeSpec{Ref: "#/types/test:index/WidgetSpec:WidgetSpec"}
+	prev := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "A widget.",
+					Properties: map[string]pschema.PropertySpec{
+						"name": {TypeSpec: str},
+						"spec": {TypeSpec: ref},
+					},
+					Required: []string{"name"},
+				},
+				InputProperties: map[string]pschema.PropertySpec{
+					"name":  {TypeSpec: str, Description: "The name."},
+					"spec":  {TypeSpec: pschema.TypeSpec{Type: "array", Items: &ref}},
+					"color": {TypeSpec: str},
+				},
+			},
+			"test:index/gadget:Gadget": {},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:index/WidgetSpec:WidgetSpec": {ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "object"}},
+		},
+	}
+	next := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "A widget!",
+					Properties: map[string]pschema.PropertySpec{
+						"name": {TypeSpec: str},
+						"spec": {TypeSpec: pschema.TypeSpec{Type: "array", Items: &ref}},
+					},
+					Required: []string{"name"},
+				},
+				InputProperties: map[string]pschema.PropertySpec{
+					"name":  {TypeSpec: str, Description: "The widget's name."},
+					"spec":  {TypeSpec: ref},
+					"color": {TypeSpec: str},
+					"size":  {TypeSpec: pschema.TypeSpec{Type: "integer"}},
+				},
+				RequiredInputs: []string{"color"},
+			},
+			"test:index/sprocket:Sprocket": {},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:index/WidgetSpec:WidgetSpec": {ObjectTypeSpec: pschema.ObjectTypeSpec{
+				Type:       "object",
+				Properties: map[string]pschema.PropertySpec{"size": {TypeSpec: str}},
+			}},
+		},
+	}
+
+	report := CompareSchemas(prev, next)
+	assert.Equal(t, []SchemaChange{
+		{Member: "test:index/gadget:Gadget", Severity: SchemaChangeMajor, Message: "resource was removed"},
+		{Member: "test:index/sprocket:Sprocket", Severity: SchemaChangeMinor, Message: "resource was added"},
+		{Member: "test:index/widget:Widget", Severity: SchemaChangePatch, Message: "description changed"},
+		{Member: "test:index/widget:Widget.inputs.color", Severity: SchemaChangeMajor,
+			Message: "property became required"},
+		{Member: "test:index/widget:Widget.inputs.name", Severity: SchemaChangePatch, Message: "description changed"},
+		{Member: "test:index/widget:Widget.inputs.size", Severity: SchemaChangeMinor, Message: "property was added"},
+		{Member: "test:index/widget:Widget.inputs.spec", Severity: SchemaChangeMajor,
+			Message: "changed from a list to a single value (MaxItemsOne was set)"},
+		{Member: "test:index/widget:Widget.outputs.spec", Severity: SchemaChangeMajor,
+			Message: "changed from a single value to a list (MaxItemsOne was unset)"},
+		{Member: "test:index/WidgetSpec:WidgetSpec.size", Severity: SchemaChangeMinor, Message: "property was added"},
+	}, report.Changes)
+	assert.Equal(t, SchemaChangeMajor, report.Severity())
+
+	err := report.Check(SchemaChangeMinor)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "4 schema changes need a major version bump")
+	assert.Contains(t, err.Error(), "major: test:index/gadget:Gadget: resource was removed")
+}
+
+func TestCompareSchemasDocsOnly(t *testing.T) {
+	prev := pschema.PackageSpec{Functions: map[string]pschema.FunctionSpec{
+		"test:index/getWidget:getWidget": {Description: "Gets a widget."},
+	}}
+	next := pschema.PackageSpec{Functions: map[string]pschema.FunctionSpec{
+		"test:index/getWidget:getWidget": {Description: "Looks up a widget."},
+	}}
+
+	report := CompareSchemas(prev, next)
+	assert.Len(t, report.Changes, 1)
+	assert.Equal(t, SchemaChangePatch, report.Severity())
+	assert.NoError(t, report.Check(SchemaChangePatch))
+	assert.Equal(t, SchemaChangePatch, CompareSchemas(next, next).Severity())
+}
@@ -472,6 +472,52 @@ func TestTerraformOutputsWithSecretsUnsupported(t *testing.T) {
 	}
 }
 
+// TestTerraformOutputsSetOrder verifies that set-typed outputs are marshaled in the same order regardless of the order
+// in which Terraform returns their elements.
+func TestTerraformOutputsSetOrder(t *testing.T) {
+	for _, f := range factories {
+		t.Run(f.SDKVersion(), func(t *testing.T) {
+			tfs := f.NewSchemaMap(map[string]*schema.Schema{
+				"names": {
+					Type:     shim.TypeSet,
+					Computed: true,
+					Elem:     (&schema.Schema{Type: shim.TypeString}).Shim(),
+				},
+				"rules": {
+					Type:     shim.TypeSet,
+					Computed: true,
+					Elem: (&schema.Resource{
+						Schema: schemaMap(map[string]*schema.Schema{
+							"port": {Type: shim.TypeInt, Optional: true},
+						}),
+					}).Shim(),
+				},
+			})
+			outputs := func(names, rules []interface{}) resource.PropertyMap {
+				return MakeTerraformOutputs(f.NewTestProvider(), map[string]interface{}{"names": names, "rules": rules},
+					tfs, map[string]*SchemaInfo{}, nil /*assets*/, false /*useRawNames*/, true)
+			}
+
+			expected := resource.NewPropertyMapFromMap(map[string]interface{}{
+				"names": []interface{}{"a", "b", "c"},
+				"rules": []interface{}{
+					map[string]interface{}{"port": 22},
+					map[string]interface{}{"port": 443},
+					map[string]interface{}{"port": 80},
+				},
+			})
+			assert.Equal(t, expected, outputs(
+				[]interface{}{"c", "a", "b"},
+				[]interface{}{map[string]interface{}{"port": 80}, map[string]interface{}{"port": 22},
+					map[string]interface{}{"port": 443}}))
+			assert.Equal(t, expected, outputs(
+				[]interface{}{"b", "c", "a"},
+				[]interface{}{map[string]interface{}{"port": 443}, map[string]interface{}{"port": 80},
+					map[string]interface{}{"port": 22}}))
+		})
+	}
+}
+
 func clearMeta(state shim.InstanceState) bool {
 	if tf, ok := shimv1.IsInstanceState(state); ok {
 		tf.Meta = map[string]interface{}{}
@@ -1347,6 +1393,104 @@ func TestStringOutputsWithSchema(t *testing.T) {
 	}), result)
 }
 
+func TestOmittedOutputs(t *testing.T) {
+	result := MakeTerraformOutputs(
+		shimv1.NewProvider(testTFProvider),
+		map[string]interface{}{
+			"name":   "widget",
+			"policy": "{\"Statement\": []}",
+			"block": []interface{}{
+				map[string]interface{}{"rendered": "{}", "size": 1},
+			},
+		},
+		shimv1.NewSchemaMap(map[string]*schemav1.Schema{
+			"name":   {Type: schemav1.TypeString},
+			"policy": {Type: schemav1.TypeString, Computed: true},
+			"block": {
+				Type: schemav1.TypeList,
+				Elem: &schemav1.Resource{Schema: map[string]*schemav1.Schema{
+					"rendered": {Type: schemav1.TypeString, Computed: true},
+					"size":     {Type: schemav1.TypeInt},
+				}},
+			},
+		}),
+		map[string]*SchemaInfo{
+			"policy": {Omit: true},
+			"block": {Elem: &SchemaInfo{Fields: map[string]*SchemaInfo{
+				"rendered": {Omit: true},
+			}}},
+		},
+		nil,   /* assets */
+		false, /* useRawNames */
+		true,
+	)
+
+	assert.Equal(t, resource.NewPropertyMapFromMap(map[string]interface{}{
+		"name": "widget",
+		"blocks": []interface{}{
+			map[string]interface{}{"size": 1},
+		},
+	}), result)
+}
+
+func TestObjectMapRoundTrip(t *testing.T) {
+	tfs := shimv2.NewSchemaMap(map[string]*schemav2.Schema{
+		"object_map": {
+			Type:     schemav2.TypeMap,
+			Optional: true,
+			Elem: &schemav2.Resource{
+				Schema: map[string]*schemav2.Schema{
+					"required_value": {Type: schemav2.TypeString, Required: true},
+					"optional_value": {Type: schemav2.TypeInt, Optional: true},
+				},
+			},
+		},
+	})
+
+	// Objects are presented to Terraform as single-element lists.
+	inputs, _, err := makeTerraformInputs(nil, resource.NewPropertyMapFromMap(map[string]interface{}{
+		"objectMap": map[string]interface{}{
+			"requiredValue": "foo",
+			"optionalValue": 42,
+		},
+	}), tfs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"object_map": []interface{}{map[string]interface{}{
+			"required_value": "foo",
+			"optional_value": 42,
+		}},
+	}, inputs)
+
+	// Unknowns take the same shape.
+	inputs, _, err = makeTerraformInputs(nil, resource.PropertyMap{
+		"objectMap": resource.MakeComputed(resource.NewStringProperty("")),
+	}, tfs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"object_map": []interface{}{map[string]interface{}{
+			"required_value": TerraformUnknownVariableValue,
+		}},
+	}, inputs)
+
+	// Both wrapped and unwrapped outputs are projected as a single object with typed fields.
+	expected := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"objectMap": map[string]interface{}{
+			"requiredValue": "foo",
+			"optionalValue": 42,
+		},
+	})
+	for _, output := range []interface{}{
+		[]interface{}{map[string]interface{}{"required_value": "foo", "optional_value": "42"}},
+		map[string]interface{}{"required_value": "foo", "optional_value": "42"},
+	} {
+		result := MakeTerraformOutputs(shimv2.NewProvider(testTFProviderV2), map[string]interface{}{
+			"object_map": output,
+		}, tfs, nil, nil, false, true)
+		assert.Equal(t, expected, result)
+	}
+}
+
 func TestExtractInputsFromOutputs(t *testing.T) {
 	tfProvider := makeTestTFProvider(
 		map[string]*schemav1.Schema{
@@ -0,0 +1,107 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+// auditSecretsEnvVar enables scanning the outputs of every resource written to state for values that look like
+// secrets but are not marked secret. This is intended to help maintainers find upstream attributes that are missing
+// Sensitive flags.
+const auditSecretsEnvVar = "PULUMI_TFBRIDGE_AUDIT_SECRETS"
+
+func auditSecretsEnabled() bool {
+	return cmdutil.IsTruthy(os.Getenv(auditSecretsEnvVar))
+}
+
+// secretPattern matches values of a well-known kind of secret.
+type secretPattern struct {
+	Kind   string
+	Regexp *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"an AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"a PEM private key", regexp.MustCompile(`-----BEGIN ([A-Z]+ )*PRIVATE KEY-----`)},
+	{"a JSON Web Token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+}
+
+// unmarkedSecret is an output property whose value looks like a secret but is not marked secret.
+type unmarkedSecret struct {
+	Path string // the property's path, e.g. tokens[0].value
+	Kind string // the kind of secret the value looks like
+}
+
+// findUnmarkedSecrets returns the properties in the given map whose values match a secret pattern but are not marked
+// secret, ordered by path.
+func findUnmarkedSecrets(props resource.PropertyMap) []unmarkedSecret {
+	var found []unmarkedSecret
+	var visit func(path string, v resource.PropertyValue)
+	visit = func(path string, v resource.PropertyValue) {
+		switch {
+		case v.IsSecret():
+			return
+		case v.IsString():
+			for _, p := range secretPatterns {
+				if p.Regexp.MatchString(v.StringValue()) {
+					found = append(found, unmarkedSecret{Path: path, Kind: p.Kind})
+					return
+				}
+			}
+		case v.IsArray():
+			for i, e := range v.ArrayValue() {
+				visit(fmt.Sprintf("%s[%d]", path, i), e)
+			}
+		case v.IsObject():
+			for k, e := range v.ObjectValue() {
+				visit(path+"."+string(k), e)
+			}
+		}
+	}
+	for k, v := range props {
+		visit(string(k), v)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found
+}
+
+// auditSecrets warns about each output property of the given resource that looks like a secret but is not marked
+// secret, if secret auditing is enabled. Sensitive outputs are only marked secret if the engine supports secrets, so
+// nothing is reported otherwise.
+func (p *Provider) auditSecrets(ctx context.Context, urn resource.URN, label string, props resource.PropertyMap) {
+	if !auditSecretsEnabled() || !p.supportsSecrets {
+		return
+	}
+	for _, s := range findUnmarkedSecrets(props) {
+		msg := fmt.Sprintf("output property %s looks like %s but is not marked secret; "+
+			"the upstream schema may be missing a Sensitive flag", s.Path, s.Kind)
+		glog.V(9).Infof("%s %s", label, msg)
+		if p.host != nil {
+			if err := p.host.Log(ctx, diag.Warning, urn, msg); err != nil {
+				glog.V(9).Infof("%s failed to log secret audit: %v", label, err)
+			}
+		}
+	}
+}
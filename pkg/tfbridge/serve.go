@@ -17,16 +17,88 @@ package tfbridge
 import (
 	"context"
 
+	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/pkg/v3/resource/provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
 	lumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"google.golang.org/grpc"
 )
 
 // Serve fires up a Pulumi resource provider listening to inbound gRPC traffic,
 // and translates calls from Pulumi into actions against the provided Terraform Provider.
 func Serve(module string, version string, info ProviderInfo, pulumiSchema []byte) error {
 	// Create a new resource provider server and listen for and serve incoming connections.
-	return provider.Main(module, func(host *provider.HostClient) (lumirpc.ResourceProviderServer, error) {
-		// Create a new bridge provider.
-		return NewProvider(context.TODO(), host, module, version, info.P, info, pulumiSchema), nil
+	var prov lumirpc.ResourceProviderServer
+	err := provider.Main(module, func(host *provider.HostClient) (lumirpc.ResourceProviderServer, error) {
+		p, err := NewProviderServer(context.TODO(), host, module, version, info, pulumiSchema)
+		prov = p
+		return p, err
 	})
+	shutdown(prov)
+	return err
+}
+
+// shutdown releases the resources held by a provider once it has stopped serving.
+func shutdown(prov lumirpc.ResourceProviderServer) {
+	if p, ok := prov.(*Provider); ok {
+		p.usageMetrics.close()
+	}
+}
+
+// NewProviderServer returns the bridged provider as an in-process implementation of the Pulumi provider interface,
+// for embedding it in test harnesses and other programs that do not run it as a plugin. The host may be nil if there
+// is no engine to log to.
+func NewProviderServer(ctx context.Context, host *provider.HostClient, module string, version string,
+	info ProviderInfo, pulumiSchema []byte) (lumirpc.ResourceProviderServer, error) {
+
+	// Refuse to start if the upstream provider differs from the one the schema was generated from.
+	if err := checkUpstreamVersion(pulumiSchema, LinkedModuleVersion); err != nil {
+		return nil, err
+	}
+	return NewProvider(ctx, host, module, version, info.P, info, pulumiSchema), nil
+}
+
+// ServeOptions configures a provider served in-process by ServeInProcess.
+type ServeOptions struct {
+	EngineAddress string    // the address of the engine's host RPC server, or "" to run without an engine.
+	Port          int       // the port to listen on, or 0 to let the kernel choose a free port.
+	Cancel        chan bool // closing or sending true on this channel stops the server; may be nil.
+}
+
+// ServeInProcess serves the bridged provider over gRPC from the current process rather than from a plugin
+// subprocess. It returns the port the provider is listening on and a channel that receives the result of serving
+// once the server stops.
+func ServeInProcess(module string, version string, info ProviderInfo, pulumiSchema []byte,
+	opts ServeOptions) (int, <-chan error, error) {
+
+	var host *provider.HostClient
+	if opts.EngineAddress != "" {
+		h, err := provider.NewHostClient(opts.EngineAddress)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "connecting to the engine")
+		}
+		host = h
+	}
+
+	prov, err := NewProviderServer(context.Background(), host, module, version, info, pulumiSchema)
+	if err != nil {
+		return 0, nil, err
+	}
+	port, done, err := rpcutil.Serve(opts.Port, opts.Cancel, []func(*grpc.Server) error{
+		func(srv *grpc.Server) error {
+			lumirpc.RegisterResourceProviderServer(srv, prov)
+			return nil
+		},
+	}, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		err := <-done
+		shutdown(prov)
+		stopped <- err
+	}()
+	return port, stopped, nil
 }
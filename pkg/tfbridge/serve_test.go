@@ -0,0 +1,57 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pbempty "github.com/golang/protobuf/ptypes/empty"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestServeInProcess(t *testing.T) {
+	info := ProviderInfo{Name: "example", P: shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"example_widget": {Schema: map[string]*schemav2.Schema{
+				"name": {Type: schemav2.TypeString, Optional: true},
+			}},
+		},
+	})}
+
+	cancel := make(chan bool)
+	port, done, err := ServeInProcess("example", "1.2.3", info, nil, ServeOptions{Cancel: cancel})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithInsecure())
+	if assert.NoError(t, err) {
+		client := pulumirpc.NewResourceProviderClient(conn)
+		pluginInfo, err := client.GetPluginInfo(context.Background(), &pbempty.Empty{})
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.3", pluginInfo.GetVersion())
+		assert.NoError(t, conn.Close())
+	}
+
+	close(cancel)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,151 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"golang.org/x/net/context"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// coerceStateStrings returns a copy of the given state in which booleans and numbers that were stored as strings,
+// e.g. "true" or "1" in states written from upstream flatmap state before its types were tightened, are converted to
+// the types that the resource's schema declares, along with the paths of the properties that were converted. Strings
+// that do not parse as the declared type are left as they are. Empty strings are treated as unset.
+func coerceStateStrings(state resource.PropertyMap, tfs shim.SchemaMap,
+	ps map[string]*SchemaInfo) (resource.PropertyMap, []string) {
+
+	var coerced []string
+	result := coerceStateProperties("", state, tfs, ps, &coerced)
+	sort.Strings(coerced)
+	return result, coerced
+}
+
+func coerceStateProperties(prefix string, m resource.PropertyMap, tfs shim.SchemaMap, ps map[string]*SchemaInfo,
+	coerced *[]string) resource.PropertyMap {
+
+	result := resource.PropertyMap{}
+	for key, value := range m {
+		_, sch, info := getInfoFromPulumiName(key, tfs, ps, false)
+		if sch == nil {
+			result[key] = value
+			continue
+		}
+		if v := coerceStateValue(prefix+string(key), value, sch, info, coerced); !v.IsNull() {
+			result[key] = v
+		}
+	}
+	return result
+}
+
+func coerceStateValue(path string, v resource.PropertyValue, sch shim.Schema, info *SchemaInfo,
+	coerced *[]string) resource.PropertyValue {
+
+	esch, einfo := elemSchemas(sch, info)
+	switch {
+	case v.IsSecret():
+		return resource.MakeSecret(coerceStateValue(path, v.SecretValue().Element, sch, info, coerced))
+	case v.IsString():
+		// Lists and sets with MaxItems == 1 are projected as their only element.
+		if (sch.Type() == shim.TypeList || sch.Type() == shim.TypeSet) && esch != nil {
+			sch = esch
+		}
+		nv, ok := coerceStateString(v.StringValue(), sch.Type())
+		if !ok {
+			return v
+		}
+		*coerced = append(*coerced, path)
+		return nv
+	case v.IsArray():
+		elems := make([]resource.PropertyValue, 0, len(v.ArrayValue()))
+		for i, e := range v.ArrayValue() {
+			if esch != nil {
+				e = coerceStateValue(fmt.Sprintf("%s[%d]", path, i), e, esch, einfo, coerced)
+			}
+			elems = append(elems, e)
+		}
+		return resource.NewArrayProperty(elems)
+	case v.IsObject():
+		if res, ok := sch.Elem().(shim.Resource); ok && sch.Type() != shim.TypeMap {
+			var fields map[string]*SchemaInfo
+			if einfo != nil {
+				fields = einfo.Fields
+			}
+			return resource.NewObjectProperty(coerceStateProperties(path+".", v.ObjectValue(), res.Schema(), fields,
+				coerced))
+		}
+		if sch.Type() != shim.TypeMap || esch == nil || esch.Type() == shim.TypeMap {
+			return v
+		}
+		result := resource.PropertyMap{}
+		for k, e := range v.ObjectValue() {
+			if e = coerceStateValue(fmt.Sprintf("%s.%s", path, k), e, esch, einfo, coerced); !e.IsNull() {
+				result[k] = e
+			}
+		}
+		return resource.NewObjectProperty(result)
+	default:
+		return v
+	}
+}
+
+// coerceStateString converts the given string to a value of the given boolean or number type, if it parses as one.
+func coerceStateString(s string, typ shim.ValueType) (resource.PropertyValue, bool) {
+	switch typ {
+	case shim.TypeBool, shim.TypeInt, shim.TypeFloat:
+		if s == "" {
+			return resource.NewNullProperty(), true
+		}
+	default:
+		return resource.PropertyValue{}, false
+	}
+
+	if typ == shim.TypeBool {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return resource.PropertyValue{}, false
+		}
+		return resource.NewBoolProperty(b), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || (typ == shim.TypeInt && f != math.Trunc(f)) {
+		return resource.PropertyValue{}, false
+	}
+	return resource.NewNumberProperty(f), true
+}
+
+// coerceStateTypes converts booleans and numbers that the given state stores as strings to the types that the
+// resource's schema declares, so that states written before upstream tightened its types can still be read. Converted
+// properties are reported as a warning, once per resource.
+func (p *Provider) coerceStateTypes(ctx context.Context, urn resource.URN, res Resource,
+	state resource.PropertyMap) resource.PropertyMap {
+
+	coerced, paths := coerceStateStrings(state, res.TF.Schema(), res.Schema.Fields)
+	if len(paths) == 0 {
+		return state
+	}
+
+	msg := fmt.Sprintf("converting properties that are stored in state as strings to the boolean or number types "+
+		"that the provider declares: %s", strings.Join(paths, ", "))
+	p.warnOnce(ctx, urn, msg)
+	return coerced
+}
@@ -0,0 +1,119 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestCoerceStateStrings(t *testing.T) {
+	tfs := schemaMap(map[string]*schema.Schema{
+		"name":    {Type: shim.TypeString},
+		"enabled": {Type: shim.TypeBool},
+		"port":    {Type: shim.TypeInt},
+		"weight":  {Type: shim.TypeFloat},
+		"count":   {Type: shim.TypeInt},
+		"limits":  {Type: shim.TypeMap, Elem: (&schema.Schema{Type: shim.TypeInt}).Shim()},
+		"flags":   {Type: shim.TypeList, Elem: (&schema.Schema{Type: shim.TypeBool}).Shim()},
+		"primary": {Type: shim.TypeList, MaxItems: 1, Elem: (&schema.Schema{Type: shim.TypeBool}).Shim()},
+		"rule": {
+			Type: shim.TypeList,
+			Elem: (&schema.Resource{
+				Schema: schemaMap(map[string]*schema.Schema{
+					"priority": {Type: shim.TypeInt},
+				}),
+			}).Shim(),
+		},
+	})
+
+	state := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"id":      "abc",
+		"__meta":  `{"schema_version": "1"}`,
+		"name":    "42",
+		"enabled": "true",
+		"port":    "8080",
+		"weight":  "0.5",
+		"count":   "",
+		"limits":  map[string]interface{}{"cpu": "2", "memory": 512},
+		"flags":   []interface{}{"1", false},
+		"primary": "false",
+		"rules":   []interface{}{map[string]interface{}{"priority": "not-a-number"}},
+	})
+	state["port"] = resource.MakeSecret(state["port"])
+
+	coerced, paths := coerceStateStrings(state, tfs, nil)
+	assert.Equal(t, []string{"count", "enabled", "flags[0]", "limits.cpu", "port", "primary", "weight"}, paths)
+	expected := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"id":      "abc",
+		"__meta":  `{"schema_version": "1"}`,
+		"name":    "42",
+		"enabled": true,
+		"port":    8080,
+		"weight":  0.5,
+		"limits":  map[string]interface{}{"cpu": 2, "memory": 512},
+		"flags":   []interface{}{true, false},
+		"primary": false,
+		"rules":   []interface{}{map[string]interface{}{"priority": "not-a-number"}},
+	})
+	expected["port"] = resource.MakeSecret(expected["port"])
+	assert.Equal(t, expected, coerced)
+
+	// States without strings to convert are returned as they are.
+	_, paths = coerceStateStrings(expected, tfs, nil)
+	assert.Empty(t, paths)
+}
+
+func TestCoerceStateString(t *testing.T) {
+	_, ok := coerceStateString("1.5", shim.TypeInt)
+	assert.False(t, ok)
+	v, ok := coerceStateString("2.0", shim.TypeInt)
+	assert.True(t, ok)
+	assert.Equal(t, resource.NewNumberProperty(2), v)
+	_, ok = coerceStateString("yes", shim.TypeBool)
+	assert.False(t, ok)
+	_, ok = coerceStateString("true", shim.TypeString)
+	assert.False(t, ok)
+}
+
+func TestCoerceStateTypesWarnsOnce(t *testing.T) {
+	p := &Provider{}
+	res := Resource{
+		TF: (&schema.Resource{Schema: schemaMap(map[string]*schema.Schema{
+			"enabled": {Type: shim.TypeBool},
+		})}).Shim(),
+		Schema: &ResourceInfo{},
+	}
+	state := resource.PropertyMap{"enabled": resource.NewStringProperty("true")}
+
+	// Every operation on the resource converts its state, but the conversion is only reported once.
+	for i := 0; i < 3; i++ {
+		coerced := p.coerceStateTypes(context.Background(), "urn:a", res, state)
+		assert.Equal(t, resource.NewBoolProperty(true), coerced["enabled"])
+	}
+	p.coerceStateTypes(context.Background(), "urn:b", res, state)
+	warned := 0
+	p.warned.Range(func(_, _ interface{}) bool {
+		warned++
+		return true
+	})
+	assert.Equal(t, 2, warned)
+}
@@ -0,0 +1,180 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"golang.org/x/net/context"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// stateDriftFileEnvVar may be set to the path of a file that records, as JSON lines, every property that was dropped
+// from a resource's state because the provider's schema no longer defines it.
+const stateDriftFileEnvVar = "PULUMI_TFBRIDGE_STATE_DRIFT_FILE"
+
+// stateDriftEntry is a single line in the state drift diagnostics file.
+type stateDriftEntry struct {
+	Time       string   `json:"time"`
+	URN        string   `json:"urn"`
+	Token      string   `json:"token"`
+	Properties []string `json:"properties"`
+}
+
+// stateDriftFileLock serializes writes to the state drift diagnostics file.
+var stateDriftFileLock sync.Mutex
+
+// pruneUnknownStateProperties returns a copy of the given state with any properties that are not defined by the
+// resource's schema removed, along with the paths of the properties that were removed. Properties are checked
+// recursively through nested blocks. Reserved properties and IDs are always kept.
+func pruneUnknownStateProperties(state resource.PropertyMap, tfs shim.SchemaMap,
+	ps map[string]*SchemaInfo) (resource.PropertyMap, []string) {
+
+	var dropped []string
+	pruned := pruneUnknownProperties("", state, tfs, ps, &dropped)
+	sort.Strings(dropped)
+	return pruned, dropped
+}
+
+func pruneUnknownProperties(prefix string, m resource.PropertyMap, tfs shim.SchemaMap, ps map[string]*SchemaInfo,
+	dropped *[]string) resource.PropertyMap {
+
+	result := resource.PropertyMap{}
+	for key, value := range m {
+		path := prefix + string(key)
+		_, sch, info := getInfoFromPulumiName(key, tfs, ps, false)
+		switch {
+		case sch != nil:
+			result[key] = pruneUnknownValue(path, value, sch, info, dropped)
+		case key == "id" || key == defaultsKey || key == metaKey || key == checkpointKey:
+			result[key] = value
+		default:
+			*dropped = append(*dropped, path)
+		}
+	}
+	return result
+}
+
+func pruneUnknownValue(path string, v resource.PropertyValue, sch shim.Schema, info *SchemaInfo,
+	dropped *[]string) resource.PropertyValue {
+
+	// Only nested blocks have a schema to check their properties against; maps use raw, unchecked keys.
+	res, ok := sch.Elem().(shim.Resource)
+	if !ok || sch.Type() == shim.TypeMap {
+		return v
+	}
+	var fields map[string]*SchemaInfo
+	if info != nil && info.Elem != nil {
+		fields = info.Elem.Fields
+	}
+
+	switch {
+	case v.IsObject():
+		return resource.NewObjectProperty(pruneUnknownProperties(path+".", v.ObjectValue(), res.Schema(), fields,
+			dropped))
+	case v.IsArray():
+		elems := make([]resource.PropertyValue, len(v.ArrayValue()))
+		for i, e := range v.ArrayValue() {
+			if e.IsObject() {
+				e = resource.NewObjectProperty(pruneUnknownProperties(fmt.Sprintf("%s[%d].", path, i),
+					e.ObjectValue(), res.Schema(), fields, dropped))
+			}
+			elems[i] = e
+		}
+		return resource.NewArrayProperty(elems)
+	case v.IsSecret():
+		return resource.MakeSecret(pruneUnknownValue(path, v.SecretValue().Element, sch, info, dropped))
+	default:
+		return v
+	}
+}
+
+// pruneStateDrift removes properties that the resource's schema no longer defines from the given state, if the
+// provider has opted in to doing so. If report is set, dropped properties are reported as a warning and recorded in
+// the state drift diagnostics file, if one is configured. Only reads of existing state report them, as every other
+// operation on the resource would report the same properties again.
+func (p *Provider) pruneStateDrift(ctx context.Context, urn resource.URN, res Resource,
+	state resource.PropertyMap, report bool) resource.PropertyMap {
+
+	if !p.info.PruneUnknownStateProperties {
+		return state
+	}
+	pruned, dropped := pruneUnknownStateProperties(state, res.TF.Schema(), res.Schema.Fields)
+	if len(dropped) == 0 {
+		return state
+	}
+	if !report {
+		return pruned
+	}
+
+	msg := fmt.Sprintf("dropping properties from state that are no longer defined by the provider: %s",
+		strings.Join(dropped, ", "))
+	glog.V(5).Infof("%s: %s", urn, msg)
+	if p.host != nil {
+		if err := p.host.Log(ctx, diag.Warning, urn, msg); err != nil {
+			glog.V(5).Infof("failed to log state drift: %v", err)
+		}
+	}
+	if err := recordStateDrift(urn, dropped); err != nil {
+		glog.V(5).Infof("failed to record state drift: %v", err)
+	}
+	return pruned
+}
+
+// recordStateDrift appends the properties dropped from a resource's state to the state drift diagnostics file.
+func recordStateDrift(urn resource.URN, dropped []string) error {
+	path := os.Getenv(stateDriftFileEnvVar)
+	if path == "" {
+		return nil
+	}
+
+	bytes, err := json.Marshal(stateDriftEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		URN:        string(urn),
+		Token:      string(urn.Type()),
+		Properties: dropped,
+	})
+	if err != nil {
+		return err
+	}
+	bytes = append(bytes, '\n')
+
+	stateDriftFileLock.Lock()
+	defer stateDriftFileLock.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(bytes); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
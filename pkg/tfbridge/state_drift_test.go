@@ -0,0 +1,100 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestPruneUnknownStateProperties(t *testing.T) {
+	tfs := schemaMap(map[string]*schema.Schema{
+		"name": {Type: shim.TypeString},
+		"tags": {Type: shim.TypeMap, Elem: (&schema.Schema{Type: shim.TypeString}).Shim()},
+		"rule": {
+			Type: shim.TypeList,
+			Elem: (&schema.Resource{
+				Schema: schemaMap(map[string]*schema.Schema{
+					"action": {Type: shim.TypeString},
+				}),
+			}).Shim(),
+		},
+	})
+
+	state := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"id":        "abc",
+		"__meta":    `{"schema_version": "1"}`,
+		"name":      "foo",
+		"legacy":    "gone",
+		"tags":      map[string]interface{}{"anyKey": "kept"},
+		"rules":     []interface{}{map[string]interface{}{"action": "allow", "priority": 1}},
+		"oldBlocks": []interface{}{},
+	})
+
+	pruned, dropped := pruneUnknownStateProperties(state, tfs, nil)
+	assert.Equal(t, []string{"legacy", "oldBlocks", "rules[0].priority"}, dropped)
+	assert.Equal(t, resource.NewPropertyMapFromMap(map[string]interface{}{
+		"id":     "abc",
+		"__meta": `{"schema_version": "1"}`,
+		"name":   "foo",
+		"tags":   map[string]interface{}{"anyKey": "kept"},
+		"rules":  []interface{}{map[string]interface{}{"action": "allow"}},
+	}), pruned)
+}
+
+func TestRecordStateDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drift")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "drift.jsonl")
+	os.Setenv(stateDriftFileEnvVar, path)
+	defer os.Unsetenv(stateDriftFileEnvVar)
+
+	urn := resource.URN("urn:pulumi:stack::project::test:index:Res::r")
+	assert.NoError(t, recordStateDrift(urn, []string{"legacy"}))
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	var entry stateDriftEntry
+	assert.NoError(t, json.Unmarshal(contents, &entry))
+	assert.Equal(t, string(urn), entry.URN)
+	assert.Equal(t, "test:index:Res", entry.Token)
+	assert.Equal(t, []string{"legacy"}, entry.Properties)
+
+	// Dropped properties are only recorded when the resource is read.
+	assert.NoError(t, os.Remove(path))
+	p := &Provider{info: ProviderInfo{PruneUnknownStateProperties: true}}
+	res := Resource{
+		TF:     (&schema.Resource{Schema: schemaMap(map[string]*schema.Schema{"name": {Type: shim.TypeString}})}).Shim(),
+		Schema: &ResourceInfo{},
+	}
+	state := resource.NewPropertyMapFromMap(map[string]interface{}{"name": "foo", "legacy": "bar"})
+	pruned := p.pruneStateDrift(context.Background(), urn, res, state, false)
+	assert.Equal(t, resource.NewPropertyMapFromMap(map[string]interface{}{"name": "foo"}), pruned)
+	assert.NoFileExists(t, path)
+	p.pruneStateDrift(context.Background(), urn, res, state, true)
+	assert.FileExists(t, path)
+}
@@ -0,0 +1,159 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	pbstruct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pkg/errors"
+)
+
+// maxStateSizeEnvVar overrides ProviderInfo.MaxStateSize, e.g. to let a single stack with unusually large resources
+// through, or to catch large resources before they bloat a checkpoint.
+const maxStateSizeEnvVar = "PULUMI_TFBRIDGE_MAX_STATE_SIZE"
+
+// maxRPCMessageSize is the largest message the engine and the provider accept from one another. Anything larger is
+// rejected by gRPC with an opaque ResourceExhausted error, so it also bounds any configured limit.
+const maxRPCMessageSize = 400 * 1024 * 1024
+
+// stateTooLargeError reports outputs that are larger than the configured limit, along with the properties that
+// contribute the most to their size.
+type stateTooLargeError struct {
+	Subject string           // the resource or data source whose outputs are too large.
+	Size    int              // the size of the outputs in bytes.
+	Limit   int              // the limit in bytes.
+	Largest []propertyWeight // the largest top-level properties, in descending order of size.
+}
+
+// propertyWeight is the size of a single top-level output property.
+type propertyWeight struct {
+	Name string
+	Size int
+}
+
+func (e *stateTooLargeError) Error() string {
+	largest := make([]string, len(e.Largest))
+	for i, w := range e.Largest {
+		largest[i] = fmt.Sprintf("%s (%s)", w.Name, formatByteSize(w.Size))
+	}
+	return fmt.Sprintf("the outputs of %s are %s, which exceeds the limit of %s; the largest properties are %s. "+
+		"Set %s to change the limit, or ignore the large properties if they are not needed",
+		e.Subject, formatByteSize(e.Size), formatByteSize(e.Limit), strings.Join(largest, ", "), maxStateSizeEnvVar)
+}
+
+// formatByteSize formats a size in bytes for use in error messages.
+func formatByteSize(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// maxStateSize returns the limit on the size of a resource's state or an invoke's result in bytes.
+func (p *Provider) maxStateSize() (int, error) {
+	limit := p.info.MaxStateSize
+	if v := os.Getenv(maxStateSizeEnvVar); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, errors.Errorf("malformed value for %s: expected a size in bytes, got %q", maxStateSizeEnvVar, v)
+		}
+		limit = n
+	}
+	if limit == 0 || limit > maxRPCMessageSize {
+		limit = maxRPCMessageSize
+	}
+	return limit, nil
+}
+
+// checkStateSize returns an error describing the largest properties of the given marshaled outputs if they exceed
+// the configured limit.
+func (p *Provider) checkStateSize(subject string, props *pbstruct.Struct) error {
+	if props == nil {
+		return nil
+	}
+	limit, err := p.maxStateSize()
+	if err != nil {
+		return err
+	}
+	size := proto.Size(props)
+	if size <= limit {
+		return nil
+	}
+
+	weights := make([]propertyWeight, 0, len(props.Fields))
+	for name, v := range props.Fields {
+		weights = append(weights, propertyWeight{Name: name, Size: proto.Size(v)})
+	}
+	sort.Slice(weights, func(i, j int) bool {
+		if weights[i].Size != weights[j].Size {
+			return weights[i].Size > weights[j].Size
+		}
+		return weights[i].Name < weights[j].Name
+	})
+	if len(weights) > 3 {
+		weights = weights[:3]
+	}
+	return &stateTooLargeError{Subject: subject, Size: size, Limit: limit, Largest: weights}
+}
+
+// fitStateSize returns the given marshaled state of a resource that exists, with as few of its largest properties left
+// out as are needed for it to fit in an RPC message of the given size, along with the names of the properties that
+// were left out. The ID and the bridge's own keys are always kept, so the engine can still record the resource.
+func fitStateSize(props *pbstruct.Struct, limit int) (*pbstruct.Struct, []string) {
+	size := proto.Size(props)
+	if props == nil || size <= limit {
+		return props, nil
+	}
+
+	weights := make([]propertyWeight, 0, len(props.Fields))
+	for name, v := range props.Fields {
+		switch name {
+		case "id", metaKey, checkpointKey:
+			continue
+		}
+		weights = append(weights, propertyWeight{Name: name, Size: proto.Size(v)})
+	}
+	sort.Slice(weights, func(i, j int) bool {
+		if weights[i].Size != weights[j].Size {
+			return weights[i].Size > weights[j].Size
+		}
+		return weights[i].Name < weights[j].Name
+	})
+
+	fields := make(map[string]*pbstruct.Value, len(props.Fields))
+	for name, v := range props.Fields {
+		fields[name] = v
+	}
+	var dropped []string
+	for _, w := range weights {
+		if size <= limit {
+			break
+		}
+		delete(fields, w.Name)
+		dropped = append(dropped, w.Name)
+		size = proto.Size(&pbstruct.Struct{Fields: fields})
+	}
+	return &pbstruct.Struct{Fields: fields}, dropped
+}
@@ -0,0 +1,85 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStateSize(t *testing.T) {
+	props, err := plugin.MarshalProperties(resource.PropertyMap{
+		"rendered": resource.NewStringProperty(strings.Repeat("x", 4096)),
+		"template": resource.NewStringProperty(strings.Repeat("y", 2048)),
+		"name":     resource.NewStringProperty("small"),
+		"id":       resource.NewStringProperty("id"),
+	}, plugin.MarshalOptions{})
+	assert.NoError(t, err)
+
+	// The default limit is the gRPC message size limit.
+	p := &Provider{}
+	assert.NoError(t, p.checkStateSize("urn", props))
+	assert.NoError(t, p.checkStateSize("urn", nil))
+
+	p.info.MaxStateSize = 1024
+	err = p.checkStateSize("urn", props)
+	if assert.IsType(t, &stateTooLargeError{}, err) {
+		tooLarge := err.(*stateTooLargeError)
+		assert.Equal(t, 1024, tooLarge.Limit)
+		assert.Greater(t, tooLarge.Size, 6144)
+		assert.Equal(t, []string{"rendered", "template", "name"},
+			[]string{tooLarge.Largest[0].Name, tooLarge.Largest[1].Name, tooLarge.Largest[2].Name})
+		assert.Contains(t, err.Error(), "exceeds the limit of 1.0KB; the largest properties are rendered (4.0KB)")
+	}
+
+	// The environment overrides the provider's limit.
+	defer setEnv(t, maxStateSizeEnvVar, "1048576")()
+	assert.NoError(t, p.checkStateSize("urn", props))
+	defer setEnv(t, maxStateSizeEnvVar, "lots")()
+	assert.Error(t, p.checkStateSize("urn", props))
+}
+
+func TestFitStateSize(t *testing.T) {
+	props, err := plugin.MarshalProperties(resource.PropertyMap{
+		"rendered": resource.NewStringProperty(strings.Repeat("x", 4096)),
+		"template": resource.NewStringProperty(strings.Repeat("y", 2048)),
+		"name":     resource.NewStringProperty("small"),
+		"id":       resource.NewStringProperty(strings.Repeat("i", 512)),
+	}, plugin.MarshalOptions{})
+	assert.NoError(t, err)
+
+	fit, dropped := fitStateSize(props, maxRPCMessageSize)
+	assert.Equal(t, props, fit)
+	assert.Empty(t, dropped)
+
+	// Only the largest properties are left out, and never the ID.
+	fit, dropped = fitStateSize(props, 3072)
+	assert.Equal(t, []string{"rendered"}, dropped)
+	assert.Contains(t, fit.Fields, "template")
+	assert.Len(t, props.Fields, 4)
+
+	fit, dropped = fitStateSize(props, 1024)
+	assert.Equal(t, []string{"rendered", "template"}, dropped)
+	assert.Contains(t, fit.Fields, "id")
+	assert.Contains(t, fit.Fields, "name")
+
+	fit, dropped = fitStateSize(props, 16)
+	assert.Equal(t, []string{"rendered", "template", "name"}, dropped)
+	assert.Contains(t, fit.Fields, "id")
+}
@@ -0,0 +1,278 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// The terraformCompat invoke maps a Terraform resource type or address to the Pulumi token that the provider uses for
+// it, along with the Pulumi names of its properties, so that migration tooling and support scripts can look up the
+// provider's mappings at runtime instead of parsing its source.
+
+// TerraformCompatToken returns the token of the built-in invoke that maps Terraform addresses to Pulumi tokens for the
+// given package.
+func TerraformCompatToken(pkg string) tokens.ModuleMember {
+	return tokens.ModuleMember(pkg + ":tfbridge:terraformCompat")
+}
+
+// TerraformCompatFunctionSpec returns the Pulumi schema for the built-in invoke that maps Terraform addresses to Pulumi
+// tokens.
+func TerraformCompatFunctionSpec() pschema.FunctionSpec {
+	stringType := pschema.TypeSpec{Type: "string"}
+	return pschema.FunctionSpec{
+		Description: "Maps a Terraform resource type, e.g. `aws_s3_bucket`, or a full Terraform address, e.g. " +
+			"`module.storage.data.aws_s3_bucket.logs`, to the Pulumi token that this provider uses for it, along " +
+			"with the Pulumi names of its properties.",
+		Inputs: &pschema.ObjectTypeSpec{
+			Type: "object",
+			Properties: map[string]pschema.PropertySpec{
+				"address": {
+					TypeSpec:    stringType,
+					Description: "The Terraform resource type or address to map.",
+				},
+			},
+			Required: []string{"address"},
+		},
+		Outputs: &pschema.ObjectTypeSpec{
+			Type: "object",
+			Properties: map[string]pschema.PropertySpec{
+				"tfName": {TypeSpec: stringType, Description: "The Terraform resource type."},
+				"kind": {
+					TypeSpec:    stringType,
+					Description: "Either `resource` or `function`, for Terraform data sources.",
+				},
+				"token":  {TypeSpec: stringType, Description: "The Pulumi token of the resource or function."},
+				"module": {TypeSpec: stringType, Description: "The module of the Pulumi token."},
+				"properties": {
+					TypeSpec: pschema.TypeSpec{Type: "object", AdditionalProperties: &stringType},
+					Description: "The path of each Pulumi property, e.g. `lifecycleRules.expiration`, keyed by the " +
+						"path of the Terraform property, e.g. `lifecycle_rule.expiration`.",
+				},
+			},
+			Required: []string{"kind", "module", "properties", "tfName", "token"},
+		},
+	}
+}
+
+// terraformCompatToken returns the token of the invoke that maps Terraform addresses to Pulumi tokens.
+func (p *Provider) terraformCompatToken() tokens.ModuleMember {
+	return TerraformCompatToken(string(p.pkg()))
+}
+
+// terraformMapping describes how a Terraform resource or data source maps to this provider.
+type terraformMapping struct {
+	TFName string `json:"tfName"`
+	Kind   string `json:"kind"` // either "resource" or "function"
+	Token  string `json:"token"`
+	Module string `json:"module"`
+	// Properties maps the path of each property of the Terraform resource, e.g. `lifecycle_rule.expiration`, to
+	// the path of the corresponding Pulumi property, e.g. `lifecycleRules.expiration`.
+	Properties map[string]string `json:"properties"`
+}
+
+// parseTerraformAddress returns the resource type that the given Terraform address refers to, along with its mode:
+// "managed", "data", or "" if the address is a bare resource type. The address may be a bare resource type, e.g.
+// `aws_s3_bucket`, or a full address, e.g. `module.storage.data.aws_s3_bucket.logs["a"]`.
+func parseTerraformAddress(address string) (string, string, error) {
+	// Instance keys may contain dots, so they are removed before the address is split.
+	trimmed := strings.TrimSpace(address)
+	if idx := strings.Index(trimmed, "["); idx != -1 {
+		trimmed = trimmed[:idx]
+	}
+	parts := strings.Split(trimmed, ".")
+	for len(parts) > 2 && parts[0] == "module" {
+		parts = parts[2:]
+	}
+
+	mode := "managed"
+	if parts[0] == "data" {
+		mode, parts = "data", parts[1:]
+	}
+	switch {
+	case len(parts) == 1 && mode == "managed":
+		mode = ""
+	case len(parts) != 2:
+		return "", "", errors.Errorf("malformed Terraform address %q", address)
+	}
+	if parts[0] == "" {
+		return "", "", errors.Errorf("malformed Terraform address %q", address)
+	}
+	return parts[0], mode, nil
+}
+
+// mapTerraformAddress looks up the mapping of the resource or data source that the given Terraform address refers to.
+// Bare resource types are looked up among the resources first. It returns nil if the provider does not map it.
+func (p *Provider) mapTerraformAddress(address string) (*terraformMapping, error) {
+	tfName, mode, err := parseTerraformAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Several tokens may map the same Terraform name, e.g. aliases, so the first in sorted order is used.
+	if mode != "data" {
+		toks := make([]string, 0, len(p.resources))
+		for tok := range p.resources {
+			toks = append(toks, string(tok))
+		}
+		sort.Strings(toks)
+		for _, tok := range toks {
+			res := p.resources[tokens.Type(tok)]
+			if res.TFName != tfName || p.legacyTokens[tok] {
+				continue
+			}
+			var fields map[string]*SchemaInfo
+			if res.Schema != nil {
+				fields = res.Schema.Fields
+			}
+			return newTerraformMapping(tfName, "resource", tok, res.TF, fields), nil
+		}
+		if mode == "managed" {
+			return nil, nil
+		}
+	}
+
+	toks := make([]string, 0, len(p.dataSources))
+	for tok := range p.dataSources {
+		toks = append(toks, string(tok))
+	}
+	sort.Strings(toks)
+	for _, tok := range toks {
+		ds := p.dataSources[tokens.ModuleMember(tok)]
+		if ds.TFName != tfName || p.legacyTokens[tok] {
+			continue
+		}
+		var fields map[string]*SchemaInfo
+		if ds.Schema != nil {
+			fields = ds.Schema.Fields
+		}
+		return newTerraformMapping(tfName, "function", tok, ds.TF, fields), nil
+	}
+	return nil, nil
+}
+
+func newTerraformMapping(tfName, kind, tok string, tf shim.Resource,
+	fields map[string]*SchemaInfo) *terraformMapping {
+
+	mapping := &terraformMapping{
+		TFName:     tfName,
+		Kind:       kind,
+		Token:      tok,
+		Module:     tokenModule(tok),
+		Properties: map[string]string{},
+	}
+	if tf != nil {
+		addPropertyMappings(mapping.Properties, "", "", tf.Schema(), fields)
+	}
+	return mapping
+}
+
+// addPropertyMappings adds the Terraform and Pulumi paths of the given properties, and of the properties nested in
+// them, to the given table.
+func addPropertyMappings(table map[string]string, tfPrefix, pulumiPrefix string, tfs shim.SchemaMap,
+	infos map[string]*SchemaInfo) {
+
+	tfs.Range(func(key string, sch shim.Schema) bool {
+		info := infos[key]
+		if sch.Removed() != "" || (info != nil && info.Omit) {
+			return true
+		}
+		tfPath := tfPrefix + key
+		pulumiPath := pulumiPrefix + string(TerraformToPulumiName(key, sch, info, false))
+		if info != nil && info.Name != "" {
+			pulumiPath = pulumiPrefix + info.Name
+		}
+		table[tfPath] = pulumiPath
+
+		if res, ok := sch.Elem().(shim.Resource); ok {
+			var fields map[string]*SchemaInfo
+			if info != nil && info.Elem != nil {
+				fields = info.Elem.Fields
+			}
+			addPropertyMappings(table, tfPath+".", pulumiPath+".", res.Schema(), fields)
+		}
+		return true
+	})
+}
+
+// tokenModule returns the module portion of a token of the form `pkg:module/name:Name` or `pkg:module:Name`.
+func tokenModule(token string) string {
+	components := strings.Split(token, ":")
+	if len(components) != 3 {
+		return ""
+	}
+	mod := components[1]
+	if idx := strings.Index(mod, "/"); idx != -1 {
+		mod = mod[:idx]
+	}
+	return mod
+}
+
+// invokeTerraformCompat implements the terraformCompat invoke, which takes a Terraform resource type or address in
+// its `address` argument.
+func (p *Provider) invokeTerraformCompat(req *pulumirpc.InvokeRequest) (*pulumirpc.InvokeResponse, error) {
+	args, err := plugin.UnmarshalProperties(req.GetArgs(), plugin.MarshalOptions{Label: "terraformCompat.args"})
+	if err != nil {
+		return nil, err
+	}
+	address, ok := args["address"]
+	if !ok || !address.IsString() {
+		return &pulumirpc.InvokeResponse{Failures: []*pulumirpc.CheckFailure{{
+			Property: "address",
+			Reason:   "missing required string argument 'address'",
+		}}}, nil
+	}
+
+	mapping, err := p.mapTerraformAddress(address.StringValue())
+	if err != nil {
+		return &pulumirpc.InvokeResponse{Failures: []*pulumirpc.CheckFailure{{
+			Property: "address",
+			Reason:   err.Error(),
+		}}}, nil
+	}
+	if mapping == nil {
+		return &pulumirpc.InvokeResponse{Failures: []*pulumirpc.CheckFailure{{
+			Property: "address",
+			Reason:   "no resource or data source in this provider maps " + address.StringValue(),
+		}}}, nil
+	}
+
+	// Round-trip through JSON to build the property map, which keeps the result's shape in one place.
+	bytes, err := json.Marshal(mapping)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(bytes, &m); err != nil {
+		return nil, err
+	}
+	ret, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(m),
+		plugin.MarshalOptions{Label: "terraformCompat.ret"})
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.InvokeResponse{Return: ret}, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestParseTerraformAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		tfName  string
+		mode    string
+	}{
+		{"aws_s3_bucket", "aws_s3_bucket", ""},
+		{"aws_s3_bucket.logs", "aws_s3_bucket", "managed"},
+		{"data.aws_ami.ubuntu", "aws_ami", "data"},
+		{`module.storage.module.logs.aws_s3_bucket.logs["a.b"]`, "aws_s3_bucket", "managed"},
+		{"module.storage.data.aws_ami.ubuntu[0]", "aws_ami", "data"},
+	}
+	for _, tt := range tests {
+		tfName, mode, err := parseTerraformAddress(tt.address)
+		assert.NoError(t, err, tt.address)
+		assert.Equal(t, tt.tfName, tfName, tt.address)
+		assert.Equal(t, tt.mode, mode, tt.address)
+	}
+
+	for _, address := range []string{"", "data.aws_ami", "aws_s3_bucket.logs.extra"} {
+		_, _, err := parseTerraformAddress(address)
+		assert.Error(t, err, address)
+	}
+}
+
+func TestTerraformCompat(t *testing.T) {
+	bucket := (&schema.Resource{Schema: schemaMap(map[string]*schema.Schema{
+		"bucket_prefix": {Type: shim.TypeString, Optional: true},
+		"acl":           {Type: shim.TypeString, Optional: true},
+		"lifecycle_rule": {Type: shim.TypeList, Optional: true, Elem: (&schema.Resource{
+			Schema: schemaMap(map[string]*schema.Schema{
+				"expiration_days": {Type: shim.TypeInt, Optional: true},
+			}),
+		}).Shim()},
+	})}).Shim()
+	p := &Provider{
+		module: "test",
+		resources: map[tokens.Type]Resource{
+			"test:s3/bucket:Bucket": {TF: bucket, TFName: "test_bucket", Schema: &ResourceInfo{
+				Fields: map[string]*SchemaInfo{"acl": {Name: "cannedAcl"}},
+			}},
+		},
+		dataSources: map[tokens.ModuleMember]DataSource{
+			"test:s3/getBucket:getBucket": {TF: bucket, TFName: "test_bucket"},
+		},
+	}
+
+	mapping, err := p.mapTerraformAddress("module.logs.test_bucket.logs")
+	assert.NoError(t, err)
+	assert.Equal(t, &terraformMapping{
+		TFName: "test_bucket",
+		Kind:   "resource",
+		Token:  "test:s3/bucket:Bucket",
+		Module: "s3",
+		Properties: map[string]string{
+			"bucket_prefix":                  "bucketPrefix",
+			"acl":                            "cannedAcl",
+			"lifecycle_rule":                 "lifecycleRules",
+			"lifecycle_rule.expiration_days": "lifecycleRules.expirationDays",
+		},
+	}, mapping)
+
+	mapping, err = p.mapTerraformAddress("data.test_bucket.logs")
+	assert.NoError(t, err)
+	if assert.NotNil(t, mapping) {
+		assert.Equal(t, "function", mapping.Kind)
+		assert.Equal(t, "test:s3/getBucket:getBucket", mapping.Token)
+		assert.Equal(t, "acl", mapping.Properties["acl"])
+	}
+
+	mapping, err = p.mapTerraformAddress("test_widget")
+	assert.NoError(t, err)
+	assert.Nil(t, mapping)
+
+	invoke := func(address string) *pulumirpc.InvokeResponse {
+		args, err := plugin.MarshalProperties(resource.NewPropertyMapFromMap(map[string]interface{}{
+			"address": address,
+		}), plugin.MarshalOptions{})
+		assert.NoError(t, err)
+		resp, err := p.Invoke(context.Background(), &pulumirpc.InvokeRequest{
+			Tok: "test:tfbridge:terraformCompat", Args: args})
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := invoke("test_bucket")
+	assert.Empty(t, resp.GetFailures())
+	ret, err := plugin.UnmarshalProperties(resp.GetReturn(), plugin.MarshalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "test:s3/bucket:Bucket", ret["token"].StringValue())
+	assert.Equal(t, "lifecycleRules.expirationDays",
+		ret["properties"].ObjectValue()["lifecycle_rule.expiration_days"].StringValue())
+
+	resp = invoke("test_widget.main")
+	if assert.Len(t, resp.GetFailures(), 1) {
+		assert.Equal(t, "no resource or data source in this provider maps test_widget.main",
+			resp.GetFailures()[0].GetReason())
+	}
+
+	// Terraform names that several tokens map are resolved to the first token in sorted order.
+	p.resources["test:legacy/bucket:Bucket"] = p.resources["test:s3/bucket:Bucket"]
+	for i := 0; i < 10; i++ {
+		mapping, err = p.mapTerraformAddress("test_bucket")
+		assert.NoError(t, err)
+		assert.Equal(t, "test:legacy/bucket:Bucket", mapping.Token)
+	}
+}
+
+func TestTerraformCompatFunctionSpec(t *testing.T) {
+	spec := TerraformCompatFunctionSpec()
+	assert.Equal(t, []string{"address"}, spec.Inputs.Required)
+
+	// The schema describes exactly the properties that the invoke returns.
+	bytes, err := json.Marshal(terraformMapping{})
+	assert.NoError(t, err)
+	var ret map[string]interface{}
+	assert.NoError(t, json.Unmarshal(bytes, &ret))
+	var names []string
+	for name := range ret {
+		names = append(names, name)
+		assert.Contains(t, spec.Outputs.Properties, name)
+	}
+	assert.ElementsMatch(t, names, spec.Outputs.Required)
+	assert.Len(t, spec.Outputs.Properties, len(names))
+}
@@ -0,0 +1,161 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"golang.org/x/net/context"
+)
+
+// The bridge-level configuration keys that control the HTTP transports used by upstream providers. These keys are
+// only interpreted by the bridge if the upstream provider does not define configuration with the same name.
+const (
+	httpProxyConfigKey             = "httpProxy"
+	caBundleConfigKey              = "caBundle"
+	insecureSkipTLSVerifyConfigKey = "insecureSkipTlsVerify"
+)
+
+// TransportSettings holds the bridge-level proxy and TLS settings that are injected into the HTTP transports used by
+// the upstream provider.
+type TransportSettings struct {
+	ProxyURL  *url.URL    // the proxy to send requests through, if any.
+	TLSConfig *tls.Config // the TLS configuration to use, if any.
+}
+
+// Apply configures the given transport with these settings.
+func (s *TransportSettings) Apply(t *http.Transport) {
+	if s.ProxyURL != nil {
+		t.Proxy = http.ProxyURL(s.ProxyURL)
+	}
+	if s.TLSConfig != nil {
+		t.TLSClientConfig = s.TLSConfig
+	}
+}
+
+// Transport returns a copy of http.DefaultTransport with these settings applied, for use by the provider's own HTTP
+// clients. The default transport itself is left alone, as other clients in the process share it.
+func (s *TransportSettings) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	s.Apply(t)
+	return t
+}
+
+// TransportCallback is a function that injects bridge-level transport settings into an upstream provider's HTTP
+// clients, e.g. by building them with settings.Transport(). The bridge only accepts its proxy and TLS configuration
+// for providers that set a TransportCallback.
+type TransportCallback func(settings *TransportSettings) error
+
+// takeBridgeConfig removes the bridge-level configuration key from vars and returns its value as a string. Keys
+// that are also defined by the upstream provider or by ExtraConfig are left untouched.
+func (p *Provider) takeBridgeConfig(vars resource.PropertyMap, key string) (string, bool) {
+	if _, has := p.info.ExtraConfig[key]; has {
+		return "", false
+	}
+	if _, sch, _ := getInfoFromPulumiName(resource.PropertyKey(key), p.config, p.info.Config, false); sch != nil {
+		return "", false
+	}
+	v, has := vars[resource.PropertyKey(key)]
+	if !has {
+		return "", false
+	}
+	delete(vars, resource.PropertyKey(key))
+	if v.IsSecret() {
+		v = v.SecretValue().Element
+	}
+	if !v.IsString() {
+		return fmt.Sprintf("%v", v.V), true
+	}
+	return v.StringValue(), true
+}
+
+// extractTransportSettings removes the bridge-level transport configuration keys from vars and returns the settings
+// they describe, or nil if none were set or the provider has no TransportCallback to apply them with.
+func (p *Provider) extractTransportSettings(ctx context.Context,
+	vars resource.PropertyMap) (*TransportSettings, error) {
+
+	if p.info.TransportCallback == nil {
+		return nil, nil
+	}
+
+	var settings TransportSettings
+	var found bool
+
+	if proxy, ok := p.takeBridgeConfig(vars, httpProxyConfigKey); ok && proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed configuration value for '%v'", httpProxyConfigKey)
+		}
+		settings.ProxyURL, found = u, true
+	}
+
+	var tlsConfig tls.Config
+	var customTLS bool
+	if bundle, ok := p.takeBridgeConfig(vars, caBundleConfigKey); ok && bundle != "" {
+		pem, err := ioutil.ReadFile(bundle)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading CA bundle for '%v'", caBundleConfigKey)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in CA bundle %v", bundle)
+		}
+		tlsConfig.RootCAs, customTLS = pool, true
+	}
+	if skip, ok := p.takeBridgeConfig(vars, insecureSkipTLSVerifyConfigKey); ok && skip != "" {
+		insecure, err := strconv.ParseBool(skip)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed configuration value for '%v'", insecureSkipTLSVerifyConfigKey)
+		}
+		if insecure {
+			if p.host != nil {
+				msg := "TLS certificate verification is disabled; connections to the provider's API are insecure"
+				if err = p.host.Log(ctx, diag.Warning, "", msg); err != nil {
+					return nil, err
+				}
+			}
+			tlsConfig.InsecureSkipVerify, customTLS = true, true // nolint: gosec
+		}
+	}
+	if customTLS {
+		settings.TLSConfig, found = &tlsConfig, true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return &settings, nil
+}
+
+// applyTransportSettings injects the given settings into the provider's HTTP clients through its transport hook.
+// Nothing process-wide is changed, so other providers and clients in the process are unaffected.
+func (p *Provider) applyTransportSettings(settings *TransportSettings) error {
+	if p.info.TransportCallback == nil {
+		return nil
+	}
+	return p.info.TransportCallback(settings)
+}
@@ -0,0 +1,144 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+func TestExtractTransportSettings(t *testing.T) {
+	var applied *TransportSettings
+	p := &Provider{
+		config: schemaMap(map[string]*schema.Schema{
+			"ca_bundle": {Type: shim.TypeString, Optional: true},
+		}),
+		info: ProviderInfo{TransportCallback: func(settings *TransportSettings) error {
+			applied = settings
+			return nil
+		}},
+	}
+
+	vars := resource.PropertyMap{
+		"httpProxy":             resource.NewStringProperty("http://proxy.example.com:3128"),
+		"insecureSkipTlsVerify": resource.NewStringProperty("true"),
+		"caBundle":              resource.NewStringProperty("/path/owned/by/the/provider"),
+		"region":                resource.NewStringProperty("us-west-2"),
+	}
+	settings, err := p.extractTransportSettings(context.Background(), vars)
+	assert.NoError(t, err)
+	if assert.NotNil(t, settings) {
+		assert.Equal(t, "http://proxy.example.com:3128", settings.ProxyURL.String())
+		assert.True(t, settings.TLSConfig.InsecureSkipVerify)
+		assert.Nil(t, settings.TLSConfig.RootCAs)
+
+		// The settings are applied to a copy of the default transport, which is left alone.
+		transport := settings.Transport()
+		assert.NotSame(t, http.DefaultTransport, transport)
+		proxy, err := transport.Proxy(&http.Request{})
+		assert.NoError(t, err)
+		assert.Equal(t, settings.ProxyURL, proxy)
+		assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+		if defaultTLS := http.DefaultTransport.(*http.Transport).TLSClientConfig; defaultTLS != nil {
+			assert.False(t, defaultTLS.InsecureSkipVerify)
+		}
+
+		assert.NoError(t, p.applyTransportSettings(settings))
+		assert.Same(t, settings, applied)
+	}
+
+	// The bridge-level keys are removed, but keys that the provider defines itself are left alone.
+	assert.Equal(t, resource.PropertyMap{
+		"caBundle": resource.NewStringProperty("/path/owned/by/the/provider"),
+		"region":   resource.NewStringProperty("us-west-2"),
+	}, vars)
+
+	settings, err = p.extractTransportSettings(context.Background(), resource.PropertyMap{})
+	assert.NoError(t, err)
+	assert.Nil(t, settings)
+
+	_, err = p.extractTransportSettings(context.Background(), resource.PropertyMap{
+		"insecureSkipTlsVerify": resource.NewStringProperty("maybe"),
+	})
+	assert.Error(t, err)
+}
+
+func TestTransportSettingsRequireCallback(t *testing.T) {
+	vars := resource.PropertyMap{"httpProxy": resource.NewStringProperty("http://proxy.example.com:3128")}
+	settings, err := (&Provider{}).extractTransportSettings(context.Background(), vars)
+	assert.NoError(t, err)
+	assert.Nil(t, settings)
+}
+
+func TestBridgeConfig(t *testing.T) {
+	info := ProviderInfo{
+		P: (&schema.Provider{Schema: schemaMap(map[string]*schema.Schema{
+			"ca_bundle": {Type: shim.TypeString, Optional: true},
+		})}).Shim(),
+		ExtraConfig: map[string]*ConfigInfo{
+			"insecureSkipTlsVerify": {Schema: (&schema.Schema{Type: shim.TypeBool}).Shim()},
+		},
+	}
+	keys := func() []string {
+		var keys []string
+		for key := range info.BridgeConfig() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	// Keys of features that the provider has not opted in to are left out.
+	assert.Empty(t, keys())
+
+	// Keys that the provider defines itself are left out too.
+	info.TransportCallback = func(*TransportSettings) error { return nil }
+	assert.Equal(t, []string{"httpProxy"}, keys())
+	config := info.BridgeConfig()
+	assert.Equal(t, shim.TypeString, config["httpProxy"].Schema.Type())
+	assert.True(t, config["httpProxy"].Schema.Optional())
+	assert.Equal(t, "httpProxy", config["httpProxy"].Info.Name)
+
+	info.AuditLog = true
+	assert.Contains(t, keys(), "auditLogPath")
+
+	info.RateLimit = true
+	assert.Contains(t, keys(), "rateLimit")
+	assert.Contains(t, keys(), "rateLimitBurst")
+
+	info.AutoTags = true
+	assert.Contains(t, keys(), "autoTags")
+	assert.Equal(t, shim.TypeMap, info.BridgeConfig()["autoTags"].Schema.Type())
+	assert.Contains(t, keys(), "autoTagProperties")
+
+	info.DefaultOptions = true
+	assert.Contains(t, keys(), "defaultResourceOptions")
+
+	info.ConfigProfiles = &ConfigProfilesInfo{NewProvider: func() shim.Provider { return info.P }}
+	assert.Contains(t, keys(), "configProfiles")
+
+	info.Resources = map[string]*ResourceInfo{
+		"widget": {Fields: map[string]*SchemaInfo{"name": AutoName("name", 20, "-")}},
+	}
+	assert.Contains(t, keys(), "autonaming")
+}
@@ -0,0 +1,313 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/internal/sharedfile"
+)
+
+// usageMetricsEnvVar opts in to anonymous usage metrics. Its value is either the path of a local JSON file that
+// counts are accumulated in, or the URL of an OTLP/HTTP metrics endpoint (e.g. http://localhost:4318/v1/metrics).
+const usageMetricsEnvVar = "PULUMI_TFBRIDGE_USAGE_METRICS"
+
+// usageMetricsTimeout bounds the time spent exporting usage metrics to an OTLP endpoint.
+const usageMetricsTimeout = 5 * time.Second
+
+// usageKey identifies a usage counter. Only the operation and the resource or function token are recorded: never
+// URNs, IDs or property values.
+type usageKey struct {
+	Operation string `json:"operation"`
+	Token     string `json:"token"`
+}
+
+// usageCount is a usage counter as recorded in a usage metrics file.
+type usageCount struct {
+	usageKey
+	Count int64 `json:"count"`
+}
+
+// usageFile is the contents of a usage metrics file.
+type usageFile struct {
+	Provider string       `json:"provider"`
+	Version  string       `json:"version"`
+	Counts   []usageCount `json:"counts"`
+}
+
+// usageMetrics counts the operations performed on each token and periodically exports the counts accumulated since
+// the last export. Exports run in the background, so that the RPCs being counted never wait on the file system or
+// the network.
+type usageMetrics struct {
+	m      sync.Mutex // protects counts and start
+	counts map[usageKey]int64
+	start  time.Time
+
+	exporting sync.Mutex     // serializes exports, so that each one carries the counts since the previous one
+	pending   sync.WaitGroup // tracks background exports
+	export    func(counts map[usageKey]int64, start, end time.Time) error
+}
+
+// newUsageMetricsFromEnv returns the usage metrics configured by the environment, or nil if they are not enabled.
+func newUsageMetricsFromEnv(module, version string) *usageMetrics {
+	target := os.Getenv(usageMetricsEnvVar)
+	if target == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return newUsageMetrics(func(counts map[usageKey]int64, start, end time.Time) error {
+			return exportUsageOTLP(target, module, version, counts, start, end)
+		})
+	}
+	return newUsageMetrics(func(counts map[usageKey]int64, _, _ time.Time) error {
+		return exportUsageFile(target, module, version, counts)
+	})
+}
+
+func newUsageMetrics(export func(counts map[usageKey]int64, start, end time.Time) error) *usageMetrics {
+	return &usageMetrics{counts: map[usageKey]int64{}, start: time.Now(), export: export}
+}
+
+// count records an operation on the given token. It is a no-op if usage metrics are not enabled.
+func (u *usageMetrics) count(operation, token string) {
+	if u == nil {
+		return
+	}
+	u.m.Lock()
+	defer u.m.Unlock()
+	u.counts[usageKey{Operation: operation, Token: token}]++
+}
+
+// flush starts exporting the counts accumulated since the last export in the background. It is a no-op if usage
+// metrics are not enabled.
+func (u *usageMetrics) flush() {
+	if u == nil {
+		return
+	}
+	u.pending.Add(1)
+	go func() {
+		defer u.pending.Done()
+		u.exportCounts()
+	}()
+}
+
+// close waits for any background exports to finish and exports the remaining counts. It is called when the provider
+// shuts down, and is a no-op if usage metrics are not enabled.
+func (u *usageMetrics) close() {
+	if u == nil {
+		return
+	}
+	u.pending.Wait()
+	u.exportCounts()
+}
+
+// exportCounts exports the counts accumulated since the last export. Counting continues while the export is in
+// progress. Failures are logged rather than reported, and the counts are kept for the next export.
+func (u *usageMetrics) exportCounts() {
+	u.exporting.Lock()
+	defer u.exporting.Unlock()
+
+	u.m.Lock()
+	counts, start, end := u.counts, u.start, time.Now()
+	u.counts, u.start = map[usageKey]int64{}, end
+	u.m.Unlock()
+	if len(counts) == 0 {
+		return
+	}
+
+	if err := u.export(counts, start, end); err != nil {
+		glog.V(5).Infof("failed to export usage metrics: %v", err)
+
+		u.m.Lock()
+		for k, n := range u.counts {
+			counts[k] += n
+		}
+		u.counts, u.start = counts, start
+		u.m.Unlock()
+	}
+}
+
+// exportUsageFile adds the given counts to those in the usage metrics file at the given path. The file may be shared
+// by several provider processes, so it is locked while it is updated.
+func exportUsageFile(path, module, version string, counts map[usageKey]int64) error {
+	unlock, err := sharedfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file := usageFile{}
+	contents, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(contents, &file); err != nil {
+			return errors.Wrapf(err, "reading usage metrics from %s", path)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	totals := map[usageKey]int64{}
+	for _, c := range file.Counts {
+		totals[c.usageKey] += c.Count
+	}
+	for k, n := range counts {
+		totals[k] += n
+	}
+
+	file.Provider, file.Version, file.Counts = module, version, make([]usageCount, 0, len(totals))
+	for k, n := range totals {
+		file.Counts = append(file.Counts, usageCount{usageKey: k, Count: n})
+	}
+	sort.Slice(file.Counts, func(i, j int) bool {
+		if file.Counts[i].Token != file.Counts[j].Token {
+			return file.Counts[i].Token < file.Counts[j].Token
+		}
+		return file.Counts[i].Operation < file.Counts[j].Operation
+	})
+
+	contents, err = json.MarshalIndent(file, "", "    ")
+	if err != nil {
+		return err
+	}
+	return sharedfile.Write(path, contents)
+}
+
+// otlpAttribute and friends are the subset of the OTLP/JSON metrics encoding used to export usage counts.
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Unit        string  `json:"unit"`
+	Sum         otlpSum `json:"sum"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   map[string]string `json:"scope"`
+	Metrics []otlpMetric      `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     map[string][]otlpAttribute `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics         `json:"scopeMetrics"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// otlpDeltaTemporality is AGGREGATION_TEMPORALITY_DELTA: each export carries the counts since the previous one.
+const otlpDeltaTemporality = 1
+
+func otlpString(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]string{"stringValue": value}}
+}
+
+// newOTLPMetricsRequest encodes the given counts as an OTLP metrics export request.
+func newOTLPMetricsRequest(module, version string, counts map[usageKey]int64,
+	start, end time.Time) otlpMetricsRequest {
+
+	keys := make([]usageKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Token != keys[j].Token {
+			return keys[i].Token < keys[j].Token
+		}
+		return keys[i].Operation < keys[j].Operation
+	})
+
+	points := make([]otlpDataPoint, len(keys))
+	for i, k := range keys {
+		points[i] = otlpDataPoint{
+			Attributes:        []otlpAttribute{otlpString("operation", k.Operation), otlpString("token", k.Token)},
+			StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+			TimeUnixNano:      strconv.FormatInt(end.UnixNano(), 10),
+			AsInt:             strconv.FormatInt(counts[k], 10),
+		}
+	}
+
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: map[string][]otlpAttribute{"attributes": {
+			otlpString("service.name", "pulumi-resource-"+module),
+			otlpString("service.version", version),
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope: map[string]string{"name": "github.com/pulumi/pulumi-terraform-bridge"},
+			Metrics: []otlpMetric{{
+				Name:        "tfbridge.operations",
+				Description: "The number of operations performed on each resource or function token.",
+				Unit:        "{operation}",
+				Sum: otlpSum{
+					AggregationTemporality: otlpDeltaTemporality,
+					IsMonotonic:            true,
+					DataPoints:             points,
+				},
+			}},
+		}},
+	}}}
+}
+
+// exportUsageOTLP sends the given counts to the OTLP/HTTP metrics endpoint at the given URL.
+func exportUsageOTLP(url, module, version string, counts map[usageKey]int64, start, end time.Time) error {
+	body, err := json.Marshal(newOTLPMetricsRequest(module, version, counts, start, end))
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: usageMetricsTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer contract.IgnoreClose(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("exporting usage metrics to %s: %s", url, resp.Status)
+	}
+	return nil
+}
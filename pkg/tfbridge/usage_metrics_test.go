@@ -0,0 +1,113 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	defer setEnv(t, usageMetricsEnvVar, path)()
+
+	p := &Provider{usageMetrics: newUsageMetricsFromEnv("test", "1.2.3")}
+
+	// Counts are only exported once the provider is idle.
+	doneCreate := p.trackOperation("Create", "test:index:Widget")
+	p.trackOperation("Read", "test:index:Widget")()
+	p.usageMetrics.pending.Wait()
+	assert.NoFileExists(t, path)
+	doneCreate()
+	p.usageMetrics.pending.Wait()
+	assert.FileExists(t, path)
+
+	// Later exports add to the counts already in the file, and remaining counts are exported at shutdown.
+	p.trackOperation("Create", "test:index:Widget")()
+	p.usageMetrics.pending.Wait()
+	doneInvoke := p.trackOperation("Invoke", "test:index:getWidget")
+	p.usageMetrics.close()
+	doneInvoke()
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	var file usageFile
+	assert.NoError(t, json.Unmarshal(contents, &file))
+	assert.Equal(t, usageFile{
+		Provider: "test",
+		Version:  "1.2.3",
+		Counts: []usageCount{
+			{usageKey{Operation: "Create", Token: "test:index:Widget"}, 2},
+			{usageKey{Operation: "Read", Token: "test:index:Widget"}, 1},
+			{usageKey{Operation: "Invoke", Token: "test:index:getWidget"}, 1},
+		},
+	}, file)
+
+	// Usage metrics are disabled by default.
+	defer setEnv(t, usageMetricsEnvVar, "")()
+	assert.Nil(t, newUsageMetricsFromEnv("test", "1.2.3"))
+	p = &Provider{}
+	p.trackOperation("Create", "test:index:Widget")()
+}
+
+func TestUsageMetricsOTLP(t *testing.T) {
+	var requests []otlpMetricsRequest
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpMetricsRequest
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	defer setEnv(t, usageMetricsEnvVar, server.URL+"/v1/metrics")()
+
+	p := &Provider{usageMetrics: newUsageMetricsFromEnv("test", "1.2.3")}
+
+	// Counts that fail to export are kept for the next export.
+	status = http.StatusServiceUnavailable
+	p.trackOperation("Create", "test:index:Widget")()
+	p.usageMetrics.pending.Wait()
+	status = http.StatusOK
+	p.trackOperation("Create", "test:index:Widget")()
+	p.usageMetrics.pending.Wait()
+	p.trackOperation("Delete", "test:index:Widget")()
+	p.usageMetrics.pending.Wait()
+
+	if assert.Len(t, requests, 3) {
+		points := func(req otlpMetricsRequest) map[string]string {
+			result := map[string]string{}
+			for _, point := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Sum.DataPoints {
+				result[point.Attributes[0].Value["stringValue"]+" "+point.Attributes[1].Value["stringValue"]] =
+					point.AsInt
+			}
+			return result
+		}
+		assert.Equal(t, map[string]string{"Create test:index:Widget": "2"}, points(requests[1]))
+		assert.Equal(t, map[string]string{"Delete test:index:Widget": "1"}, points(requests[2]))
+
+		resource := requests[1].ResourceMetrics[0].Resource["attributes"]
+		assert.Equal(t, otlpString("service.name", "pulumi-resource-test"), resource[0])
+		assert.Equal(t, otlpDeltaTemporality, requests[1].ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Sum.
+			AggregationTemporality)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+// UpstreamMetadataKey is the key under the Pulumi schema's language section that records the upstream provider
+// module the schema was generated from.
+const UpstreamMetadataKey = "tfbridge"
+
+// skipVersionCheckEnvVar disables the check that the linked upstream provider matches the one the schema was
+// generated from.
+const skipVersionCheckEnvVar = "PULUMI_TFBRIDGE_SKIP_UPSTREAM_VERSION_CHECK"
+
+// UpstreamMetadata records the upstream provider module that a Pulumi schema was generated from, and the Terraform
+// plugin protocol and optional SDK features (e.g. "importers") that the module uses.
+type UpstreamMetadata struct {
+	Module          string   `json:"upstreamModule"`
+	Version         string   `json:"upstreamVersion"`
+	ProtocolVersion int      `json:"protocolVersion,omitempty"`
+	Features        []string `json:"features,omitempty"`
+}
+
+// GetUpstreamModulePath returns the Go module path of the upstream Terraform provider, derived from the provider's
+// GitHub host, org, name and module version (e.g. github.com/hashicorp/terraform-provider-aws/v3).
+func (info ProviderInfo) GetUpstreamModulePath() string {
+	path := fmt.Sprintf("%s/%s/terraform-provider-%s", info.GetGitHubHost(), info.GetGitHubOrg(), info.Name)
+	if v := info.GetProviderModuleVersion(); v != "" {
+		path += "/" + v
+	}
+	return path
+}
+
+// LinkedModuleVersion returns the version of the given Go module that is linked into the running binary, if the
+// binary was built with module support and depends on that module. Replaced modules report the version of their
+// replacement, which is empty for local replacements.
+func LinkedModuleVersion(modulePath string) (string, bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", false
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version, dep.Replace.Version != ""
+		}
+		return dep.Version, dep.Version != ""
+	}
+	return "", false
+}
+
+// readUpstreamMetadata extracts the upstream metadata recorded in the given JSON-encoded Pulumi schema, if any.
+func readUpstreamMetadata(pulumiSchema []byte) (*UpstreamMetadata, error) {
+	if len(pulumiSchema) == 0 {
+		return nil, nil
+	}
+	var spec struct {
+		Language map[string]json.RawMessage `json:"language"`
+	}
+	if err := json.Unmarshal(pulumiSchema, &spec); err != nil {
+		return nil, err
+	}
+	raw, ok := spec.Language[UpstreamMetadataKey]
+	if !ok {
+		return nil, nil
+	}
+	var metadata UpstreamMetadata
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// checkUpstreamVersion verifies that the upstream provider linked into this binary is the same version that the
+// embedded schema was generated from. A mismatch means that the bridge's view of the provider's schema may be stale,
+// which produces subtly incorrect diffs, so it is better to refuse to start.
+func checkUpstreamVersion(pulumiSchema []byte, linkedVersion func(string) (string, bool)) error {
+	if cmdutil.IsTruthy(os.Getenv(skipVersionCheckEnvVar)) {
+		return nil
+	}
+
+	metadata, err := readUpstreamMetadata(pulumiSchema)
+	if err != nil {
+		return errors.Wrap(err, "reading upstream metadata from schema")
+	}
+	if metadata == nil || metadata.Module == "" || metadata.Version == "" {
+		// Schemas generated by older versions of tfgen carry no metadata.
+		return nil
+	}
+
+	version, ok := linkedVersion(metadata.Module)
+	if !ok {
+		glog.V(5).Infof("could not determine the linked version of %s; skipping the upstream version check",
+			metadata.Module)
+		return nil
+	}
+	if version != metadata.Version {
+		return errors.Errorf("upstream provider version skew: this provider links %s %s, but its schema was "+
+			"generated against %s; re-run tfgen and rebuild the provider (set %s=true to skip this check)",
+			metadata.Module, version, metadata.Version, skipVersionCheckEnvVar)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfbridge
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUpstreamModulePath(t *testing.T) {
+	assert.Equal(t, "github.com/terraform-providers/terraform-provider-foo",
+		ProviderInfo{Name: "foo"}.GetUpstreamModulePath())
+	assert.Equal(t, "github.com/hashicorp/terraform-provider-aws/v3",
+		ProviderInfo{Name: "aws", GitHubOrg: "hashicorp", TFProviderModuleVersion: "v3"}.GetUpstreamModulePath())
+}
+
+func TestCheckUpstreamVersion(t *testing.T) {
+	schema := []byte(`{"name": "foo", "language": {"tfbridge": {` +
+		`"upstreamModule": "github.com/hashicorp/terraform-provider-foo", "upstreamVersion": "v1.2.0"}}}`)
+	linked := func(version string) func(string) (string, bool) {
+		return func(string) (string, bool) { return version, version != "" }
+	}
+
+	// Matching versions, unknown linked versions and schemas without metadata all pass.
+	assert.NoError(t, checkUpstreamVersion(schema, linked("v1.2.0")))
+	assert.NoError(t, checkUpstreamVersion(schema, linked("")))
+	assert.NoError(t, checkUpstreamVersion([]byte(`{"name": "foo"}`), linked("v1.3.0")))
+	assert.NoError(t, checkUpstreamVersion(nil, linked("v1.3.0")))
+
+	err := checkUpstreamVersion(schema, linked("v1.3.0"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "links github.com/hashicorp/terraform-provider-foo v1.3.0")
+	assert.Contains(t, err.Error(), "generated against v1.2.0")
+
+	os.Setenv(skipVersionCheckEnvVar, "true")
+	defer os.Unsetenv(skipVersionCheckEnvVar)
+	assert.NoError(t, checkUpstreamVersion(schema, linked("v1.3.0")))
+}
@@ -0,0 +1,29 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"strings"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// appendAutoNamingDoc appends a note that explains how the autonamed property is populated to the given description.
+func appendAutoNamingDoc(description string, autoNaming *tfbridge.AutoNamingInfo) string {
+	if description != "" {
+		description = strings.TrimRight(description, "\n") + "\n\n"
+	}
+	return description + autoNaming.Describe() + "\n"
+}
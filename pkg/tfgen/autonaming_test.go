@@ -0,0 +1,46 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestAutoNamingDocs(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Optional: true, Description: "The widget's name."},
+				}},
+			},
+		}),
+		Resources:  map[string]*tfbridge.ResourceInfo{"test_widget": {Tok: "test:index/widget:Widget"}},
+		AutoNaming: &tfbridge.AutoNamingInfo{MaxLength: 63},
+	}
+
+	spec := genTestSchema(t, info)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	assert.Equal(t, "The widget's name.\n\n"+info.AutoNaming.Describe()+"\n", widget.InputProperties["name"].Description)
+	assert.NotContains(t, widget.Properties["name"].Description, "generated")
+}
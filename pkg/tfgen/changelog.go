@@ -0,0 +1,303 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// changelogEntries collects the differences between two versions of a package schema that are worth calling out in
+// a CHANGELOG. Properties are those of resources (inputs and outputs), of data sources (arguments and results) and of
+// types.
+type changelogEntries struct {
+	NewResources          []string
+	NewDataSources        []string
+	NewConfig             []string
+	NewProperties         []string
+	NewDeprecations       []string
+	RemovedResources      []string
+	RemovedDataSources    []string
+	RemovedConfigurations []string
+	RemovedProperties     []string
+}
+
+func (c *changelogEntries) empty() bool {
+	return len(c.NewResources) == 0 && len(c.NewDataSources) == 0 && len(c.NewConfig) == 0 &&
+		len(c.NewProperties) == 0 && len(c.NewDeprecations) == 0 && len(c.RemovedResources) == 0 &&
+		len(c.RemovedDataSources) == 0 && len(c.RemovedConfigurations) == 0 && len(c.RemovedProperties) == 0
+}
+
+// diffSchemasForChangelog computes the changelog entries between the previous and the next schema.
+func diffSchemasForChangelog(prev, next pschema.PackageSpec) *changelogEntries {
+	entries := &changelogEntries{}
+
+	for _, tok := range sortedKeys(next.Resources) {
+		res := next.Resources[tok]
+		old, existed := prev.Resources[tok]
+		if !existed {
+			entries.NewResources = append(entries.NewResources, tok)
+			continue
+		}
+		if res.DeprecationMessage != "" && old.DeprecationMessage == "" {
+			entries.NewDeprecations = append(entries.NewDeprecations, tok)
+		}
+		diffPropertiesForChangelog(entries, tok, mergeProperties(old.Properties, old.InputProperties),
+			mergeProperties(res.Properties, res.InputProperties))
+	}
+	for _, tok := range sortedKeys(prev.Resources) {
+		if _, has := next.Resources[tok]; !has {
+			entries.RemovedResources = append(entries.RemovedResources, tok)
+		}
+	}
+
+	for _, tok := range sortedKeys(next.Functions) {
+		fun := next.Functions[tok]
+		old, existed := prev.Functions[tok]
+		if !existed {
+			entries.NewDataSources = append(entries.NewDataSources, tok)
+			continue
+		}
+		if fun.DeprecationMessage != "" && old.DeprecationMessage == "" {
+			entries.NewDeprecations = append(entries.NewDeprecations, tok)
+		}
+		diffPropertiesForChangelog(entries, tok, functionProperties(old), functionProperties(fun))
+	}
+	for _, tok := range sortedKeys(prev.Functions) {
+		if _, has := next.Functions[tok]; !has {
+			entries.RemovedDataSources = append(entries.RemovedDataSources, tok)
+		}
+	}
+
+	// New and removed types are not listed, as they come and go with the properties that use them.
+	for _, tok := range sortedKeys(next.Types) {
+		if old, existed := prev.Types[tok]; existed {
+			diffPropertiesForChangelog(entries, tok, old.Properties, next.Types[tok].Properties)
+		}
+	}
+
+	for _, name := range sortedKeys(next.Config.Variables) {
+		v := next.Config.Variables[name]
+		old, existed := prev.Config.Variables[name]
+		if !existed {
+			entries.NewConfig = append(entries.NewConfig, name)
+			continue
+		}
+		if v.DeprecationMessage != "" && old.DeprecationMessage == "" {
+			entries.NewDeprecations = append(entries.NewDeprecations, "config."+name)
+		}
+	}
+	for _, name := range sortedKeys(prev.Config.Variables) {
+		if _, has := next.Config.Variables[name]; !has {
+			entries.RemovedConfigurations = append(entries.RemovedConfigurations, name)
+		}
+	}
+
+	return entries
+}
+
+func diffPropertiesForChangelog(entries *changelogEntries, tok string, prev, next map[string]pschema.PropertySpec) {
+	for _, name := range sortedKeys(next) {
+		prop := next[name]
+		old, existed := prev[name]
+		if !existed {
+			entries.NewProperties = append(entries.NewProperties, tok+"."+name)
+			continue
+		}
+		if prop.DeprecationMessage != "" && old.DeprecationMessage == "" {
+			entries.NewDeprecations = append(entries.NewDeprecations, tok+"."+name)
+		}
+	}
+	for _, name := range sortedKeys(prev) {
+		if _, has := next[name]; !has {
+			entries.RemovedProperties = append(entries.RemovedProperties, tok+"."+name)
+		}
+	}
+}
+
+// mergeProperties returns the union of the given property maps. Properties in later maps take precedence.
+func mergeProperties(maps ...map[string]pschema.PropertySpec) map[string]pschema.PropertySpec {
+	merged := map[string]pschema.PropertySpec{}
+	for _, m := range maps {
+		for name, prop := range m {
+			merged[name] = prop
+		}
+	}
+	return merged
+}
+
+// functionProperties returns the arguments and results of the given function.
+func functionProperties(fun pschema.FunctionSpec) map[string]pschema.PropertySpec {
+	var inputs, outputs map[string]pschema.PropertySpec
+	if fun.Inputs != nil {
+		inputs = fun.Inputs.Properties
+	}
+	if fun.Outputs != nil {
+		outputs = fun.Outputs.Properties
+	}
+	return mergeProperties(outputs, inputs)
+}
+
+// sortedKeys returns the keys of the given map of schema specs in sorted order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]pschema.ResourceSpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]pschema.FunctionSpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]pschema.PropertySpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]pschema.ComplexTypeSpec:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	default:
+		contract.Failf("unexpected map type %T", m)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeChangelog renders a draft CHANGELOG section for the given entries.
+func writeChangelog(w io.Writer, prevVersion, nextVersion string, entries *changelogEntries) error {
+	header := "## HEAD (Unreleased)"
+	if nextVersion != "" {
+		header = "## " + nextVersion
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", header); err != nil {
+		return err
+	}
+	if prevVersion != "" && nextVersion != "" {
+		if _, err := fmt.Fprintf(w, "* Upgrade to v%s of the upstream provider from v%s\n", nextVersion,
+			prevVersion); err != nil {
+			return err
+		}
+	}
+	if entries.empty() {
+		_, err := fmt.Fprintf(w, "* No schema changes\n")
+		return err
+	}
+
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"New resources", entries.NewResources},
+		{"New data sources", entries.NewDataSources},
+		{"New configuration", entries.NewConfig},
+		{"New properties", entries.NewProperties},
+		{"Deprecations", entries.NewDeprecations},
+		{"Removed resources", entries.RemovedResources},
+		{"Removed data sources", entries.RemovedDataSources},
+		{"Removed configuration", entries.RemovedConfigurations},
+		{"Removed properties", entries.RemovedProperties},
+	}
+	for _, section := range sections {
+		if len(section.items) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n**%s:**\n", section.title); err != nil {
+			return err
+		}
+		for _, item := range section.items {
+			if _, err := fmt.Fprintf(w, "* `%s`\n", item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readPackageSpec(path string) (pschema.PackageSpec, error) {
+	var spec pschema.PackageSpec
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return spec, err
+	}
+	if err = json.Unmarshal(bytes, &spec); err != nil {
+		return spec, errors.Wrapf(err, "failed to parse schema %s", path)
+	}
+	return spec, nil
+}
+
+// newChangelogCmd creates the `changelog` subcommand, which renders a draft CHANGELOG section from the differences
+// between the schema of a previous release and the schema of the provider being built.
+func newChangelogCmd(prov tfbridge.ProviderInfo) *cobra.Command {
+	var prevSchemaPath string
+	var nextSchemaPath string
+	var prevVersion string
+	var nextVersion string
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Args:  cmdutil.NoArgs,
+		Short: "Generate a draft CHANGELOG section from the schema changes between upstream versions",
+		Long: "Generate a draft CHANGELOG section from the schema changes between upstream versions.\n" +
+			"\n" +
+			"The previous schema is read from --previous-schema. The new schema is read from --schema if\n" +
+			"provided; otherwise it is generated from the provider that this tool was built with.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			prev, err := readPackageSpec(prevSchemaPath)
+			if err != nil {
+				return err
+			}
+
+			var next pschema.PackageSpec
+			if nextSchemaPath != "" {
+				if next, err = readPackageSpec(nextSchemaPath); err != nil {
+					return err
+				}
+			} else if next, err = GenerateSchema(prov, nil); err != nil {
+				return err
+			}
+
+			if nextVersion == "" {
+				nextVersion = prov.TFProviderVersion
+			}
+
+			return writeChangelog(os.Stdout, prevVersion, nextVersion, diffSchemasForChangelog(prev, next))
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&prevSchemaPath, "previous-schema", "", "The schema.json of the previous release")
+	cmd.PersistentFlags().StringVar(
+		&nextSchemaPath, "schema", "", "The schema.json of the new release (defaults to generating it)")
+	cmd.PersistentFlags().StringVar(
+		&prevVersion, "previous-version", "", "The previous upstream provider version")
+	cmd.PersistentFlags().StringVar(
+		&nextVersion, "new-version", "", "The new upstream provider version (defaults to TFProviderVersion)")
+	contract.AssertNoError(cmd.MarkPersistentFlagRequired("previous-schema"))
+
+	return cmd
+}
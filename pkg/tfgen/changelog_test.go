@@ -0,0 +1,117 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSchemasForChangelog(t *testing.T) {
+	prev := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				InputProperties: map[string]pschema.PropertySpec{
+					"name": {},
+					"size": {},
+				},
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Properties: map[string]pschema.PropertySpec{
+						"name":   {},
+						"size":   {},
+						"status": {},
+					},
+				},
+			},
+			"test:index/gadget:Gadget": {},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getWidget:getWidget": {
+				Outputs: &pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{"id": {}}},
+			},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:index/WidgetPart:WidgetPart": {ObjectTypeSpec: pschema.ObjectTypeSpec{
+				Properties: map[string]pschema.PropertySpec{"kind": {}},
+			}},
+		},
+	}
+	next := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				InputProperties: map[string]pschema.PropertySpec{
+					"name":  {},
+					"size":  {DeprecationMessage: "use dimensions"},
+					"color": {},
+				},
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Properties: map[string]pschema.PropertySpec{
+						"name":  {},
+						"size":  {DeprecationMessage: "use dimensions"},
+						"color": {},
+						"arn":   {},
+					},
+				},
+			},
+			"test:index/sprocket:Sprocket": {},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getWidget:getWidget": {
+				DeprecationMessage: "gone soon",
+				Outputs: &pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{
+					"id":    {},
+					"color": {},
+				}},
+			},
+			"test:index/getSprocket:getSprocket": {},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:index/WidgetPart:WidgetPart": {ObjectTypeSpec: pschema.ObjectTypeSpec{
+				Properties: map[string]pschema.PropertySpec{"kind": {DeprecationMessage: "use type"}, "type": {}},
+			}},
+		},
+		Config: pschema.ConfigSpec{
+			Variables: map[string]pschema.PropertySpec{
+				"region": {},
+			},
+		},
+	}
+
+	entries := diffSchemasForChangelog(prev, next)
+	assert.Equal(t, []string{"test:index/sprocket:Sprocket"}, entries.NewResources)
+	assert.Equal(t, []string{"test:index/getSprocket:getSprocket"}, entries.NewDataSources)
+	assert.Equal(t, []string{"region"}, entries.NewConfig)
+	assert.Equal(t, []string{
+		"test:index/widget:Widget.arn",
+		"test:index/widget:Widget.color",
+		"test:index/getWidget:getWidget.color",
+		"test:index/WidgetPart:WidgetPart.type",
+	}, entries.NewProperties)
+	assert.Equal(t, []string{
+		"test:index/widget:Widget.size",
+		"test:index/getWidget:getWidget",
+		"test:index/WidgetPart:WidgetPart.kind",
+	}, entries.NewDeprecations)
+	assert.Equal(t, []string{"test:index/gadget:Gadget"}, entries.RemovedResources)
+	assert.Equal(t, []string{"test:index/widget:Widget.status"}, entries.RemovedProperties)
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeChangelog(&buf, "1.0.0", "1.1.0", entries))
+	assert.Contains(t, buf.String(), "## 1.1.0\n* Upgrade to v1.1.0 of the upstream provider from v1.0.0\n")
+	assert.Contains(t, buf.String(), "**New resources:**\n* `test:index/sprocket:Sprocket`\n")
+}
@@ -0,0 +1,117 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// The versions of the Terraform plugin protocol that the bridge can drive.
+const (
+	minSupportedProtocolVersion = 4
+	maxSupportedProtocolVersion = 5
+)
+
+// Names of the upstream features recorded in the compatibility report and the schema's metadata.
+const (
+	featureTimeouts       = "timeouts"
+	featureStateUpgraders = "stateUpgraders"
+	featureImporters      = "importers"
+)
+
+// compatibilityReport records which Terraform plugin protocol and SDK features the upstream provider uses, and which
+// of them the bridge does not support.
+type compatibilityReport struct {
+	ProtocolVersion int      `json:"protocolVersion,omitempty"` // 0 if the provider does not report its version.
+	Resources       int      `json:"resources"`
+	DataSources     int      `json:"dataSources"`
+	Timeouts        []string `json:"timeouts,omitempty"`       // resources that declare operation timeouts.
+	StateUpgraders  []string `json:"stateUpgraders,omitempty"` // resources whose state has a schema version above 0.
+	Importers       []string `json:"importers,omitempty"`      // resources that can be imported.
+	Unsupported     []string `json:"unsupported,omitempty"`    // features that the bridge lacks, with how to proceed.
+}
+
+// computeCompatibilityReport inspects the given upstream provider.
+func computeCompatibilityReport(p shim.Provider) *compatibilityReport {
+	report := &compatibilityReport{}
+	if p == nil {
+		return report
+	}
+
+	if versioned, ok := p.(shim.ProviderWithProtocolVersion); ok {
+		report.ProtocolVersion = versioned.ProtocolVersion()
+	}
+	if v := report.ProtocolVersion; v != 0 && (v < minSupportedProtocolVersion || v > maxSupportedProtocolVersion) {
+		report.Unsupported = append(report.Unsupported, fmt.Sprintf("the upstream provider implements Terraform "+
+			"plugin protocol version %d, but the bridge supports versions %d through %d; use a release of the "+
+			"upstream provider that implements a supported version", v, minSupportedProtocolVersion,
+			maxSupportedProtocolVersion))
+	}
+
+	report.Resources = p.ResourcesMap().Len()
+	report.DataSources = p.DataSourcesMap().Len()
+	p.ResourcesMap().Range(func(name string, res shim.Resource) bool {
+		if hasTimeouts(res.Timeouts()) {
+			report.Timeouts = append(report.Timeouts, name)
+		}
+		if res.SchemaVersion() > 0 {
+			report.StateUpgraders = append(report.StateUpgraders, name)
+		}
+		if res.Importer() != nil {
+			report.Importers = append(report.Importers, name)
+		}
+		return true
+	})
+	sort.Strings(report.Timeouts)
+	sort.Strings(report.StateUpgraders)
+	sort.Strings(report.Importers)
+
+	return report
+}
+
+// hasTimeouts returns true if the given timeouts declare a timeout for any operation.
+func hasTimeouts(t *shim.ResourceTimeout) bool {
+	return t != nil && (t.Create != nil || t.Read != nil || t.Update != nil || t.Delete != nil || t.Default != nil)
+}
+
+// features returns the names of the optional upstream features that the provider uses.
+func (r *compatibilityReport) features() []string {
+	var features []string
+	if len(r.Importers) > 0 {
+		features = append(features, featureImporters)
+	}
+	if len(r.StateUpgraders) > 0 {
+		features = append(features, featureStateUpgraders)
+	}
+	if len(r.Timeouts) > 0 {
+		features = append(features, featureTimeouts)
+	}
+	return features
+}
+
+// check returns an error describing each feature that the provider requires and the bridge does not support.
+func (r *compatibilityReport) check() error {
+	if len(r.Unsupported) == 0 {
+		return nil
+	}
+	return errors.Errorf("the upstream provider is not compatible with the bridge:\n\t%s",
+		strings.Join(r.Unsupported, "\n\t"))
+}
@@ -0,0 +1,86 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+	"time"
+
+	schemav1 "github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/muxer"
+	shimv1 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v1"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+// protocolProvider overrides the protocol version reported by a provider.
+type protocolProvider struct {
+	shim.Provider
+	version int
+}
+
+func (p protocolProvider) ProtocolVersion() int {
+	return p.version
+}
+
+func TestComputeCompatibilityReport(t *testing.T) {
+	timeout := time.Minute
+	p := shimv2.NewProvider(&schemav2.Provider{
+		ResourcesMap: map[string]*schemav2.Resource{
+			"test_plain": {},
+			"test_timeouts": {
+				Timeouts: &schemav2.ResourceTimeout{Create: &timeout},
+			},
+			"test_upgraded": {
+				SchemaVersion: 1,
+				Importer:      &schemav2.ResourceImporter{StateContext: schemav2.ImportStatePassthroughContext},
+			},
+		},
+		DataSourcesMap: map[string]*schemav2.Resource{
+			"test_plain": {},
+		},
+	})
+
+	report := computeCompatibilityReport(p)
+	assert.Equal(t, &compatibilityReport{
+		ProtocolVersion: 5,
+		Resources:       3,
+		DataSources:     1,
+		Timeouts:        []string{"test_timeouts"},
+		StateUpgraders:  []string{"test_upgraded"},
+		Importers:       []string{"test_upgraded"},
+	}, report)
+	assert.Equal(t, []string{featureImporters, featureStateUpgraders, featureTimeouts}, report.features())
+	assert.NoError(t, report.check())
+
+	// SDKv1 providers implement the same protocol as SDKv2 providers.
+	v1 := computeCompatibilityReport(shimv1.NewProvider(&schemav1.Provider{}))
+	assert.Equal(t, 5, v1.ProtocolVersion)
+	assert.NoError(t, v1.check())
+
+	// Muxed providers report the oldest protocol among them.
+	muxed, err := muxer.NewProvider(shimv1.NewProvider(&schemav1.Provider{}), protocolProvider{Provider: p, version: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, computeCompatibilityReport(muxed).ProtocolVersion)
+
+	// Unsupported protocols fail with an actionable message.
+	report = computeCompatibilityReport(protocolProvider{Provider: p, version: 6})
+	assert.EqualError(t, report.check(), "the upstream provider is not compatible with the bridge:\n\t"+
+		"the upstream provider implements Terraform plugin protocol version 6, but the bridge supports versions 4 "+
+		"through 5; use a release of the upstream provider that implements a supported version")
+}
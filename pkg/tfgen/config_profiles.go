@@ -0,0 +1,53 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+// addConfigProfileProperty adds the property that selects the resource's configuration profile to its inputs, outputs
+// and state, if the provider has configuration profiles.
+func (g *Generator) addConfigProfileProperty(rawname string, res *resourceType) error {
+	if g.info.ConfigProfiles == nil {
+		return nil
+	}
+	name := g.info.ConfigProfiles.GetProperty()
+	for _, v := range append(append([]*variable{}, res.inprops...), res.outprops...) {
+		if v.name == name {
+			return errors.Errorf("resource %s: property %s conflicts with the configuration profile property; set "+
+				"ConfigProfiles.Property to another name", rawname, name)
+		}
+	}
+
+	sch := (&schema.Schema{Type: shim.TypeString, Optional: true, ForceNew: true}).Shim()
+	info := &tfbridge.SchemaInfo{Name: name}
+	doc := fmt.Sprintf("The name of the configuration profile that manages this resource, as defined by the "+
+		"`%s:configProfiles` configuration. The provider's own configuration is used if this is not set.", g.pkg)
+
+	res.inprops = append(res.inprops, propertyVariable(name, sch, info, doc, "", false /*out*/, entityDocs{}))
+	res.argst.properties = res.inprops
+	res.outprops = append(res.outprops, propertyVariable(name, sch, info, doc, "", true /*out*/, entityDocs{}))
+	stateVar := propertyVariable(name, sch, info, doc, "", false /*out*/, entityDocs{})
+	stateVar.opt = true
+	res.statet.properties = append(res.statet.properties, stateVar)
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestConfigProfileProperty(t *testing.T) {
+	newProvider := func() shim.Provider {
+		return shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Optional: true},
+				}},
+			},
+		})
+	}
+	info := tfbridge.ProviderInfo{
+		Name:           "test",
+		P:              newProvider(),
+		Resources:      map[string]*tfbridge.ResourceInfo{"test_widget": {Tok: "test:index/widget:Widget"}},
+		ConfigProfiles: &tfbridge.ConfigProfilesInfo{NewProvider: newProvider},
+	}
+
+	spec := genTestSchema(t, info)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	for _, props := range []map[string]pschema.PropertySpec{
+		widget.InputProperties, widget.Properties, widget.StateInputs.Properties,
+	} {
+		assert.Equal(t, "string", props["configProfile"].Type)
+	}
+	assert.NotContains(t, widget.RequiredInputs, "configProfile")
+	assert.NotContains(t, spec.Provider.InputProperties, "configProfile")
+
+	// Upstream properties with the same name are errors.
+	info.ConfigProfiles.Property = "name"
+	_, err := newTestGenerator(t, info).gatherPackage()
+	assert.Error(t, err)
+}
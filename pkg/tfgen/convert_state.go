@@ -0,0 +1,65 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+func newConvertStateCmd(prov tfbridge.ProviderInfo) *cobra.Command {
+	var outPath string
+	cmd := &cobra.Command{
+		Use:   "convert-state <STATE_FILE>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Convert a Terraform state file into a file for `pulumi import --file`",
+		Long: "Convert a Terraform state file into a file for `pulumi import --file`.\n" +
+			"\n" +
+			"Each managed resource in the Terraform state (format version 4) that the provider maps is\n" +
+			"listed with its Pulumi token, a name derived from its Terraform address, and its ID. The\n" +
+			"addresses of managed resources that the provider cannot import are reported as warnings.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			state, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			imports, unmapped, err := tfbridge.ConvertTerraformState(prov, state)
+			if err != nil {
+				return errors.Wrapf(err, "converting %s", args[0])
+			}
+			for _, address := range unmapped {
+				fmt.Fprintf(os.Stderr, "warning: %s cannot be imported by this provider\n", address)
+			}
+
+			if outPath == "" {
+				_, err = fmt.Println(string(imports))
+				return err
+			}
+			return ioutil.WriteFile(outPath, append(imports, '\n'), 0600)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&outPath, "out", "o", "", "The file to write the import specs to (defaults to stdout)")
+
+	return cmd
+}
@@ -0,0 +1,133 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coverage defines the files that tfgen exports with the results of converting a provider's examples, so
+// that tools such as CI dashboards can read them with the same types that wrote them.
+//
+// The types are stable: fields may be added, but are not renamed or removed without bumping SummaryVersion.
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SummaryVersion is the version of the format of summary.json written by this version of tfgen. Summaries written
+// before the format was versioned have a version of 0.
+const SummaryVersion = 1
+
+// Failure severity values of a ConversionResult.
+const (
+	Success = 0
+	Warning = 1
+	Failure = 2
+	Fatal   = 3
+)
+
+// ConversionResult describes how successfully an example was converted to one language.
+type ConversionResult struct {
+	TargetLanguage  string
+	FailureSeverity int    // [Success, Warning, Failure, Fatal]
+	FailureInfo     string // Additional in-depth information
+
+	// !! Current example name has already been converted for this specific language before.
+	// Either the example is duplicated, or a bug is present !!
+	MultipleTranslations bool
+
+	// Time spent converting before giving up, only recorded for conversions that timed out
+	ElapsedTime time.Duration `json:",omitempty"`
+	// The fallback converter that converted the example after the bridge's converter failed to, if any
+	Converter string `json:",omitempty"`
+}
+
+// ExampleResult is an entry of byExample.json, which holds one for each example of the provider.
+type ExampleResult struct {
+	ProviderName    string
+	ProviderVersion string
+	ExampleName     string
+	OriginalHCL     string `json:"OriginalHCL,omitempty"`
+	IsDuplicated    bool
+	IsNormalized    bool
+	FailedLanguages []ConversionResult `json:"FailedLanguages,omitempty"`
+
+	// Languages that the provider intentionally excludes from the example's conversion
+	ExcludedLanguages []string `json:"ExcludedLanguages,omitempty"`
+}
+
+// NumPct is a number of conversions, and the percentage of all conversions that it makes up.
+type NumPct struct {
+	Number int
+	Pct    float64
+}
+
+// ErrorMessage is a conversion error, and the number of conversions that failed with it.
+type ErrorMessage struct {
+	Reason string
+	Count  int
+}
+
+// LanguageStatistic summarizes the conversions to one language. byLanguage.json maps each language to one.
+type LanguageStatistic struct {
+	Total          int
+	Successes      NumPct
+	Warnings       NumPct
+	Failures       NumPct
+	Fatals         NumPct
+	FrequentErrors []ErrorMessage
+
+	// Examples that the provider intentionally excludes from conversion to the language, which are not counted in
+	// Total
+	Excluded int
+	// Successes that were converted by fallback converters after the bridge's converter failed, by converter name
+	FallbackSuccesses map[string]int `json:",omitempty"`
+}
+
+// Summary summarizes the conversions of all of the provider's examples, and is written to summary.json.
+type Summary struct {
+	// The version of the summary's format, which is SummaryVersion for summaries written by this version of tfgen.
+	Version int `json:"SchemaVersion"`
+
+	Name             string
+	ProviderVersion  string `json:"Version"`
+	Examples         int
+	TotalConversions int
+	Successes        NumPct
+	Warnings         NumPct
+	Failures         NumPct
+	Fatals           NumPct
+	ConversionErrors []ErrorMessage
+
+	// Examples whose HCL had HTML entities or unicode characters normalized before conversion
+	NormalizedExamples int
+
+	// Conversions that the provider intentionally excludes, which are not counted in TotalConversions
+	ExcludedConversions int
+	// Successes that were converted by fallback converters after the bridge's converter failed, by converter name
+	FallbackSuccesses map[string]int `json:",omitempty"`
+}
+
+// ParseSummary parses the contents of a summary.json file. It fails if the summary was written in a newer format than
+// this package understands.
+func ParseSummary(data []byte) (*Summary, error) {
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+	if summary.Version > SummaryVersion {
+		return nil, fmt.Errorf("unsupported summary version %d; the latest supported version is %d",
+			summary.Version, SummaryVersion)
+	}
+	return &summary, nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSummary(t *testing.T) {
+	summary, err := ParseSummary([]byte(`{"SchemaVersion": 1, "Name": "aws", "Version": "4.0.0", "Examples": 2}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Summary{Version: 1, Name: "aws", ProviderVersion: "4.0.0", Examples: 2}, summary)
+
+	// Summaries written before the format was versioned are still understood.
+	summary, err = ParseSummary([]byte(`{"Name": "aws", "Version": "4.0.0"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.Version)
+
+	_, err = ParseSummary([]byte(`{"SchemaVersion": 2, "Name": "aws"}`))
+	assert.Error(t, err)
+}
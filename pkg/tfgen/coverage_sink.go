@@ -0,0 +1,223 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// coverageOutputDirEnvVar names the directory that coverage data is written to.
+	coverageOutputDirEnvVar = "COVERAGE_OUTPUT_DIR"
+	// coverageSinkEnvVar lists further sinks that coverage data is sent to, separated by commas. Each sink is either
+	// `stdout`, or the http or https URL of a collector that the data is posted to.
+	coverageSinkEnvVar = "COVERAGE_SINK"
+	// coverageSinkTokenEnvVar is sent as a bearer token to the collectors listed in COVERAGE_SINK, if set.
+	coverageSinkTokenEnvVar = "COVERAGE_SINK_TOKEN"
+	// coverageSinkTimeoutEnvVar overrides how long posting to each collector listed in COVERAGE_SINK may take, as a
+	// Go duration such as `1m`.
+	coverageSinkTimeoutEnvVar = "COVERAGE_SINK_TIMEOUT"
+
+	// defaultCoverageSinkTimeout is how long posting coverage data to a collector may take by default.
+	defaultCoverageSinkTimeout = 30 * time.Second
+)
+
+// CoverageReport is one of the reports that the coverage exporter produces, e.g. `summary.json`.
+type CoverageReport struct {
+	Name     string // the report's file name
+	Contents []byte
+}
+
+// CoverageResults holds the reports that the coverage exporter produced for a provider.
+type CoverageResults struct {
+	ProviderName    string
+	ProviderVersion string
+	Reports         []CoverageReport
+}
+
+// CoverageSink receives the coverage data collected while generating a provider, e.g. to store it in a directory or
+// to stream it to an external system.
+type CoverageSink interface {
+	Write(results CoverageResults) error
+}
+
+// NewFileCoverageSink returns a sink that writes each report into its own file in the given directory. This is where
+// COVERAGE_OUTPUT_DIR sends coverage data.
+func NewFileCoverageSink(outputDirectory string) CoverageSink {
+	return &fileCoverageSink{dir: outputDirectory}
+}
+
+type fileCoverageSink struct {
+	dir string
+}
+
+func (s *fileCoverageSink) Write(results CoverageResults) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	for _, report := range results.Reports {
+		if err := ioutil.WriteFile(filepath.Join(s.dir, report.Name), report.Contents, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewStdoutCoverageSink returns a sink that prints the results as a single JSON document to stdout.
+func NewStdoutCoverageSink() CoverageSink {
+	return &writerCoverageSink{w: os.Stdout}
+}
+
+type writerCoverageSink struct {
+	w io.Writer
+}
+
+func (s *writerCoverageSink) Write(results CoverageResults) error {
+	body, err := marshalCoverageResults(results)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(body, '\n'))
+	return err
+}
+
+// NewHTTPCoverageSink returns a sink that posts the results as a single JSON document to the given collector endpoint.
+// The given headers, e.g. for authorization, are sent along with the results. The post fails if it takes longer than
+// the given timeout, or 30 seconds if the timeout is not positive.
+func NewHTTPCoverageSink(endpoint string, header http.Header, timeout time.Duration) CoverageSink {
+	if timeout <= 0 {
+		timeout = defaultCoverageSinkTimeout
+	}
+	return &httpCoverageSink{endpoint: endpoint, header: header, client: &http.Client{Timeout: timeout}}
+}
+
+type httpCoverageSink struct {
+	endpoint string
+	header   http.Header
+	client   *http.Client
+}
+
+func (s *httpCoverageSink) Write(results CoverageResults) error {
+	body, err := marshalCoverageResults(results)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "posting coverage data to %s", s.endpoint)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("posting coverage data to %s: %s", s.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// coverageResultsDocument is the JSON document that the stdout and HTTP sinks send. Reports that are valid JSON are
+// embedded as is, and all others, e.g. `shortSummary.txt`, as strings.
+type coverageResultsDocument struct {
+	ProviderName    string                     `json:"providerName"`
+	ProviderVersion string                     `json:"providerVersion,omitempty"`
+	Reports         map[string]json.RawMessage `json:"reports"`
+}
+
+func marshalCoverageResults(results CoverageResults) ([]byte, error) {
+	doc := coverageResultsDocument{
+		ProviderName:    results.ProviderName,
+		ProviderVersion: results.ProviderVersion,
+		Reports:         make(map[string]json.RawMessage, len(results.Reports)),
+	}
+	for _, report := range results.Reports {
+		contents := json.RawMessage(report.Contents)
+		if !json.Valid(report.Contents) {
+			text, err := json.Marshal(string(report.Contents))
+			if err != nil {
+				return nil, err
+			}
+			contents = text
+		}
+		doc.Reports[report.Name] = contents
+	}
+	return json.Marshal(doc)
+}
+
+// multiCoverageSink sends the results to each of several sinks in turn.
+type multiCoverageSink []CoverageSink
+
+func (sinks multiCoverageSink) Write(results CoverageResults) error {
+	for _, sink := range sinks {
+		if err := sink.Write(results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coverageSinksFromEnv returns the sinks that the COVERAGE_OUTPUT_DIR and COVERAGE_SINK environment variables ask for,
+// if any.
+func coverageSinksFromEnv() ([]CoverageSink, error) {
+	var timeout time.Duration
+	if value := os.Getenv(coverageSinkTimeoutEnvVar); value != "" {
+		t, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", coverageSinkTimeoutEnvVar)
+		}
+		timeout = t
+	}
+
+	var sinks []CoverageSink
+	if dir, ok := os.LookupEnv(coverageOutputDirEnvVar); ok {
+		sinks = append(sinks, NewFileCoverageSink(dir))
+	}
+	for _, spec := range strings.Split(os.Getenv(coverageSinkEnvVar), ",") {
+		spec = strings.TrimSpace(spec)
+		switch {
+		case spec == "":
+			continue
+		case spec == "stdout":
+			sinks = append(sinks, NewStdoutCoverageSink())
+		case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+			header := http.Header{}
+			if token := os.Getenv(coverageSinkTokenEnvVar); token != "" {
+				header.Set("Authorization", "Bearer "+token)
+			}
+			sinks = append(sinks, NewHTTPCoverageSink(spec, header, timeout))
+		default:
+			return nil, fmt.Errorf("unknown coverage sink %q in %s: expected stdout or an http(s) URL",
+				spec, coverageSinkEnvVar)
+		}
+	}
+	return sinks, nil
+}
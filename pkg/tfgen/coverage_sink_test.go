@@ -0,0 +1,144 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCoverageResults() CoverageResults {
+	return CoverageResults{
+		ProviderName:    "aws",
+		ProviderVersion: "1.0.0",
+		Reports: []CoverageReport{
+			{Name: "summary.json", Contents: []byte(`{"examples": 1}`)},
+			{Name: "shortSummary.txt", Contents: []byte("Provider: aws\n")},
+		},
+	}
+}
+
+func TestFileCoverageSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "coverage")
+	assert.NoError(t, NewFileCoverageSink(dir).Write(testCoverageResults()))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "shortSummary.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Provider: aws\n", string(data))
+}
+
+func TestWriterCoverageSink(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, (&writerCoverageSink{w: &buf}).Write(testCoverageResults()))
+
+	// JSON reports are embedded as is, and all others as strings.
+	assert.JSONEq(t, `{
+		"providerName": "aws",
+		"providerVersion": "1.0.0",
+		"reports": {
+			"summary.json": {"examples": 1},
+			"shortSummary.txt": "Provider: aws\n"
+		}
+	}`, buf.String())
+}
+
+func TestHTTPCoverageSink(t *testing.T) {
+	var received coverageResultsDocument
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		authorization = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	assert.NoError(t, NewHTTPCoverageSink(server.URL, header, 0).Write(testCoverageResults()))
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, "aws", received.ProviderName)
+	assert.JSONEq(t, `{"examples": 1}`, string(received.Reports["summary.json"]))
+
+	// Collectors that reject the data fail the export.
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer failing.Close()
+	err := NewHTTPCoverageSink(failing.URL, nil, 0).Write(testCoverageResults())
+	assert.EqualError(t, err, "posting coverage data to "+failing.URL+": 401 Unauthorized")
+
+	// Collectors that do not respond in time fail the export instead of hanging it.
+	done := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer hanging.Close()
+	defer close(done)
+	err = NewHTTPCoverageSink(hanging.URL, nil, 10*time.Millisecond).Write(testCoverageResults())
+	assert.Error(t, err)
+}
+
+// setEnv sets the given environment variable and returns a function that restores its previous value, to be deferred.
+func setEnv(t *testing.T, key, value string) func() {
+	prev, had := os.LookupEnv(key)
+	assert.NoError(t, os.Setenv(key, value))
+	return func() {
+		if had {
+			assert.NoError(t, os.Setenv(key, prev))
+		} else {
+			assert.NoError(t, os.Unsetenv(key))
+		}
+	}
+}
+
+func TestCoverageSinksFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	defer setEnv(t, coverageOutputDirEnvVar, dir)()
+	defer setEnv(t, coverageSinkEnvVar, "stdout, https://coverage.example.com/upload")()
+	defer setEnv(t, coverageSinkTokenEnvVar, "secret")()
+
+	sinks, err := coverageSinksFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, []CoverageSink{
+		&fileCoverageSink{dir: dir},
+		NewStdoutCoverageSink(),
+		NewHTTPCoverageSink("https://coverage.example.com/upload", http.Header{
+			"Authorization": []string{"Bearer secret"},
+		}, 0),
+	}, sinks)
+
+	defer setEnv(t, coverageSinkTimeoutEnvVar, "1m")()
+	sinks, err = coverageSinksFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, sinks[2].(*httpCoverageSink).client.Timeout)
+
+	defer setEnv(t, coverageSinkTimeoutEnvVar, "soon")()
+	_, err = coverageSinksFromEnv()
+	assert.Error(t, err)
+
+	defer setEnv(t, coverageSinkEnvVar, "s3://bucket")()
+	_, err = coverageSinksFromEnv()
+	assert.Error(t, err)
+}
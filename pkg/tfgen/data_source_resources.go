@@ -0,0 +1,48 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// gatherDataSourceResource returns the module name and resource type of the read-only resource that the given data
+// source is exposed as. The resource is documented with the data source's docs, and a note that explains its
+// lifecycle.
+func (g *Generator) gatherDataSourceResource(rawname string, ds shim.Resource,
+	info *tfbridge.ResourceInfo) (string, *resourceType, error) {
+
+	module, res, err := g.gatherResourceWithDocs(rawname, ds, info, false, DataSourceDocs)
+	if err != nil || res == nil {
+		return module, res, err
+	}
+	res.doc = appendDataSourceResourceDoc(res.doc, rawname)
+	return module, res, nil
+}
+
+// appendDataSourceResourceDoc appends a note that explains the lifecycle of a data source resource to the given
+// description.
+func appendDataSourceResourceDoc(description, rawname string) string {
+	if description != "" {
+		description = strings.TrimRight(description, "\n") + "\n\n"
+	}
+	return description + fmt.Sprintf("This resource reads the `%s` data source and keeps the result in state. It does "+
+		"not manage anything upstream: creating or refreshing it reads the data source, changing its arguments reads "+
+		"the data source again, and deleting it only removes it from state.\n", rawname)
+}
@@ -0,0 +1,52 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestDataSourceResources(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Required: true},
+					"arn":  {Type: schemav2.TypeString, Computed: true},
+				}},
+			},
+		}),
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {Tok: "test:index/getWidget:getWidget", ResourceTok: "test:index/widgetReader:WidgetReader"},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	assert.Contains(t, spec.Functions, "test:index/getWidget:getWidget")
+	res, ok := spec.Resources["test:index/widgetReader:WidgetReader"]
+	assert.True(t, ok)
+	assert.Equal(t, []string{"name"}, res.RequiredInputs)
+	assert.Contains(t, res.Properties, "arn")
+	assert.NotContains(t, res.InputProperties, "arn")
+	assert.Contains(t, res.Description, "reads the `test_widget` data source")
+}
@@ -0,0 +1,71 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// labelDeprecatedRedirects notes in the description of the given resource or data source which of its peers upstream
+// deprecated in favor of it, per ResourceInfo.DeprecatedInFavorOf and DataSourceInfo.DeprecatedInFavorOf. It warns if
+// the member itself is deprecated in favor of one that is not mapped, as it then gets no deprecation message.
+func (g *Generator) labelDeprecatedRedirects(kind, rawname string, docs *entityDocs) {
+	redirects := map[string]string{} // the deprecated members' tokens, by Terraform name
+	var target string
+	if kind == "resource" {
+		for name, info := range g.info.Resources {
+			if info != nil && info.DeprecatedInFavorOf == rawname {
+				redirects[name] = string(info.Tok)
+			}
+		}
+		if info := g.info.Resources[rawname]; info != nil && g.info.ResourceRedirectMessage(info) == "" {
+			target = info.DeprecatedInFavorOf
+		}
+	} else {
+		for name, info := range g.info.DataSources {
+			if info != nil && info.DeprecatedInFavorOf == rawname {
+				redirects[name] = string(info.Tok)
+			}
+		}
+		if info := g.info.DataSources[rawname]; info != nil && g.info.DataSourceRedirectMessage(info) == "" {
+			target = info.DeprecatedInFavorOf
+		}
+	}
+	if target != "" {
+		g.warn("%s %s is deprecated in favor of %s, which is not mapped", kind, rawname, target)
+	}
+	if len(redirects) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(redirects))
+	for name := range redirects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	deprecated := make([]string, len(names))
+	for i, name := range names {
+		deprecated[i] = fmt.Sprintf("`%s` (`%s`)", name, redirects[name])
+	}
+
+	entity := "resource"
+	if kind == "function" {
+		entity = "data source"
+	}
+	docs.Description = fmt.Sprintf("> **Note:** upstream deprecated %s in favor of this %s.\n\n%s",
+		strings.Join(deprecated, ", "), entity, docs.Description)
+}
@@ -0,0 +1,69 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestDeprecatedRedirects(t *testing.T) {
+	resource := func() *schemav2.Resource {
+		return &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+			"name": {Type: schemav2.TypeString, Optional: true},
+		}}
+	}
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget":    resource(),
+				"test_widget_v2": resource(),
+			},
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"test_widget":    resource(),
+				"test_widget_v2": resource(),
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget":    {Tok: "test:index/widget:Widget", DeprecatedInFavorOf: "test_widget_v2"},
+			"test_widget_v2": {Tok: "test:index/widgetV2:WidgetV2"},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget":    {Tok: "test:index/getWidget:getWidget", DeprecatedInFavorOf: "test_widget_v2"},
+			"test_widget_v2": {Tok: "test:index/getWidgetV2:getWidgetV2"},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	// The deprecated members refer to their replacements, and the replacements to them.
+	assert.Equal(t, "test.Widget has been deprecated in favor of test.WidgetV2",
+		spec.Resources["test:index/widget:Widget"].DeprecationMessage)
+	assert.Empty(t, spec.Resources["test:index/widgetV2:WidgetV2"].DeprecationMessage)
+	assert.Contains(t, spec.Resources["test:index/widgetV2:WidgetV2"].Description,
+		"> **Note:** upstream deprecated `test_widget` (`test:index/widget:Widget`) in favor of this resource.")
+
+	assert.Equal(t, "test.getWidget has been deprecated in favor of test.getWidgetV2",
+		spec.Functions["test:index/getWidget:getWidget"].DeprecationMessage)
+	assert.Contains(t, spec.Functions["test:index/getWidgetV2:getWidgetV2"].Description,
+		"> **Note:** upstream deprecated `test_widget` (`test:index/getWidget:getWidget`) in favor of this "+
+			"data source.")
+}
@@ -0,0 +1,305 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// Upstream docs commonly describe a property's default value and example values in prose, e.g. "Defaults to `true`."
+// or "Example: `us-east-1`". These patterns recognize the most common phrasings.
+var (
+	docDefaultRegexps = []*regexp.Regexp{
+		regexp.MustCompile("(?i)\\bdefaults? (?:value )?(?:to|is):? `([^`]+)`"),
+		regexp.MustCompile("(?i)\\bdefault(?: value)?: `([^`]+)`"),
+	}
+	docExampleRegexp     = regexp.MustCompile("(?i)\\b(?:examples?|e\\.g\\.),?:? ((?:`[^`]+`(?:,? (?:or |and )?)?)+)")
+	docExampleItemRegexp = regexp.MustCompile("`([^`]+)`")
+
+	// docAllowedValuesRegexps match lists of allowed values, e.g. "Valid values are `A`, `B` and `C`." or "Must be
+	// one of `A` or `B`.", capturing the list up to the end of its sentence.
+	docAllowedValuesRegexps = []*regexp.Regexp{
+		regexp.MustCompile("(?i)\\b(?:valid|allowed|possible|accepted|supported) values (?:are|include|is)?:?\\s*" +
+			"(.+?)(?:\\.(?:\\s|$)|$)"),
+		regexp.MustCompile("(?i)\\b(?:must|can|may|should) be one of:? ((?:`[^`]+`(?:,? (?:or |and )?)?)+)"),
+	}
+	docAllowedValueWordRegexp = regexp.MustCompile(`^[A-Za-z0-9_.:/-]+$`)
+)
+
+// The confidence with which allowed values were extracted from docs. Values that are quoted as code are extracted
+// with high confidence, while values that are listed as plain words may have picked up some of the surrounding prose.
+const (
+	docValuesHighConfidence = "high"
+	docValuesLowConfidence  = "low"
+)
+
+// docValues are the default and example values described by a property's documentation.
+type docValues struct {
+	Default  string
+	Examples []string
+
+	AllowedValues []string
+	Confidence    string // the confidence with which AllowedValues were extracted, if any were.
+}
+
+// extractDocValues extracts the default and example values described by the given property documentation.
+func extractDocValues(doc string) docValues {
+	var values docValues
+	for _, re := range docDefaultRegexps {
+		if m := re.FindStringSubmatch(doc); m != nil {
+			values.Default = m[1]
+			break
+		}
+	}
+	for _, m := range docExampleRegexp.FindAllStringSubmatch(doc, -1) {
+		for _, item := range docExampleItemRegexp.FindAllStringSubmatch(m[1], -1) {
+			values.Examples = append(values.Examples, item[1])
+		}
+	}
+	for _, re := range docAllowedValuesRegexps {
+		if m := re.FindStringSubmatch(doc); m != nil {
+			values.AllowedValues, values.Confidence = parseAllowedValues(m[1])
+			if len(values.AllowedValues) > 0 {
+				break
+			}
+		}
+	}
+	return values
+}
+
+// parseAllowedValues parses a list of allowed values described in docs, returning the values and the confidence with
+// which they were extracted, or nil if the list cannot be parsed.
+func parseAllowedValues(list string) ([]string, string) {
+	if items := docExampleItemRegexp.FindAllStringSubmatch(list, -1); len(items) > 0 {
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = item[1]
+		}
+		return values, docValuesHighConfidence
+	}
+
+	// Without quoting, only accept lists of at least two single words, e.g. "A, B or C".
+	var values []string
+	for _, item := range strings.FieldsFunc(strings.NewReplacer(" or ", ",", " and ", ",").Replace(list),
+		func(r rune) bool { return r == ',' }) {
+
+		item = strings.Trim(strings.TrimSpace(item), `"'`)
+		if !docAllowedValueWordRegexp.MatchString(item) {
+			return nil, ""
+		}
+		values = append(values, item)
+	}
+	if len(values) < 2 {
+		return nil, ""
+	}
+	return values, docValuesLowConfidence
+}
+
+// parseDocValue parses a value extracted from documentation as a value of the given schema type. Only primitive
+// types are supported; the second result is false if the value cannot be represented by the type.
+func parseDocValue(raw string, typ string) (interface{}, bool) {
+	raw = strings.TrimSpace(raw)
+	switch typ {
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	case "integer":
+		i, err := strconv.ParseInt(raw, 10, 64)
+		return float64(i), err == nil
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		return f, err == nil
+	case "string":
+		if unquoted, err := strconv.Unquote(raw); err == nil {
+			raw = unquoted
+		}
+		// Docs that say "Defaults to `null`" or "Defaults to `""`" describe the absence of a value.
+		if raw == "" || raw == "null" {
+			return nil, false
+		}
+		return raw, true
+	default:
+		return nil, false
+	}
+}
+
+// docValueEntry is a single row in the report of values extracted from documentation, which is exported alongside
+// the coverage data so that the extracted values can be verified by a human.
+type docValueEntry struct {
+	Property string   // the schema path of the property, e.g. #/resources/pkg:index:Res/inputProperties/prop
+	Type     string   // the schema type of the property
+	Default  string   `json:"Default,omitempty"`
+	Examples []string `json:"Examples,omitempty"`
+	Applied  bool     // true if the extracted default was written into the schema
+
+	AllowedValues []string `json:"AllowedValues,omitempty"`
+	Confidence    string   `json:"Confidence,omitempty"` // "high" or "low", if there are allowed values
+	Enum          bool     `json:"Enum,omitempty"`       // true if the allowed values were turned into an enum
+}
+
+// computeDocValueReport reports the values that can be extracted from the docs of every input property in the given
+// schema, and whether the extracted default was applied to the property.
+func computeDocValueReport(spec pschema.PackageSpec) []docValueEntry {
+	var report []docValueEntry
+	visit := func(path string, props map[string]pschema.PropertySpec) {
+		for name, prop := range props {
+			values := extractDocValues(prop.Description)
+			if values.Default == "" && len(values.Examples) == 0 && len(values.AllowedValues) == 0 {
+				continue
+			}
+			entry := docValueEntry{
+				Property:      path + "/" + name,
+				Type:          prop.Type,
+				Default:       values.Default,
+				Examples:      values.Examples,
+				AllowedValues: values.AllowedValues,
+				Confidence:    values.Confidence,
+				Enum:          docEnumRef(spec, prop) != "",
+			}
+			if v, ok := parseDocValue(values.Default, prop.Type); ok && prop.Default == v {
+				entry.Applied = true
+			}
+			report = append(report, entry)
+		}
+	}
+
+	if spec.Config.Variables != nil {
+		visit("#/config/variables", spec.Config.Variables)
+	}
+	for token, res := range spec.Resources {
+		visit("#/resources/"+token+"/inputProperties", res.InputProperties)
+	}
+	for token, fun := range spec.Functions {
+		if fun.Inputs != nil {
+			visit("#/functions/"+token+"/inputs/properties", fun.Inputs.Properties)
+		}
+	}
+	for token, typ := range spec.Types {
+		visit("#/types/"+token+"/properties", typ.Properties)
+	}
+
+	sort.Slice(report, func(i, j int) bool { return report[i].Property < report[j].Property })
+	return report
+}
+
+// applyDocValues sets the default of an input property to the default described by its documentation, if it has no
+// explicit default and the documented value can be represented by the property's type. Any documented examples and
+// allowed values are recorded under the property's "tfbridge" language section.
+func applyDocValues(doc string, prop *pschema.PropertySpec) {
+	values := extractDocValues(doc)
+	if prop.Default == nil && values.Default != "" {
+		if v, ok := parseDocValue(values.Default, prop.Type); ok {
+			prop.Default = v
+		}
+	}
+
+	data := map[string]interface{}{}
+	if len(values.Examples) != 0 {
+		data["examples"] = values.Examples
+	}
+	if len(values.AllowedValues) != 0 {
+		data["allowedValues"] = values.AllowedValues
+	}
+	if len(data) != 0 {
+		setPropertyBridgeData(prop, data)
+	}
+}
+
+// docEnumValueRegexp matches the allowed values that can be turned into enum values, whose names the SDK generators
+// derive from the values.
+var docEnumValueRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.:/ -]*$`)
+
+// applyDocEnums turns the allowed values that the docs of string inputs of resources and functions describe with
+// high confidence into enums. Each enum is defined next to the resource or function as a type named after it and the
+// property, and the property accepts either the enum or any string, so that values the docs do not list, e.g. those
+// added by a newer version of the upstream API, remain usable.
+//
+// The allowed values are the ones that ExtractDocValues extracts, so enums are only added when it is enabled as well.
+// Inputs in validated, as "<token>.<name>", are skipped: upstream checks their values itself, and the docs of such
+// inputs often list only some of the values that the validation accepts.
+func applyDocEnums(spec *pschema.PackageSpec, validated codegen.StringSet) {
+	visit := func(owner string, props map[string]pschema.PropertySpec) {
+		for _, name := range codegen.SortedKeys(props) {
+			prop := props[name]
+			if prop.Type != "string" || prop.Ref != "" || len(prop.OneOf) != 0 {
+				continue
+			}
+			if validated.Has(owner + "." + name) {
+				continue
+			}
+			values := extractDocValues(prop.Description)
+			if values.Confidence != docValuesHighConfidence {
+				continue
+			}
+			var enum []pschema.EnumValueSpec
+			for _, v := range values.AllowedValues {
+				if !docEnumValueRegexp.MatchString(v) {
+					enum = nil
+					break
+				}
+				enum = append(enum, pschema.EnumValueSpec{Value: v})
+			}
+			if len(enum) < 2 {
+				continue
+			}
+
+			components := strings.Split(owner, ":")
+			if len(components) != 3 {
+				continue
+			}
+			typeName := strings.Title(components[2]) + strings.Title(name)
+			token := fmt.Sprintf("%s:%s/%s:%s", components[0], tokenModule(owner), typeName, typeName)
+			if _, exists := spec.Types[token]; exists {
+				continue
+			}
+			spec.Types[token] = pschema.ComplexTypeSpec{
+				ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "string"},
+				Enum:           enum,
+			}
+			prop.TypeSpec = pschema.TypeSpec{
+				Type:  "string",
+				OneOf: []pschema.TypeSpec{{Type: "string"}, {Ref: "#/types/" + token}},
+			}
+			props[name] = prop
+		}
+	}
+
+	for _, token := range codegen.SortedKeys(spec.Resources) {
+		visit(token, spec.Resources[token].InputProperties)
+	}
+	for _, token := range codegen.SortedKeys(spec.Functions) {
+		if inputs := spec.Functions[token].Inputs; inputs != nil {
+			visit(token, inputs.Properties)
+		}
+	}
+}
+
+// docEnumRef returns the token of the enum that the given property accepts, or "" if it accepts none.
+func docEnumRef(spec pschema.PackageSpec, prop pschema.PropertySpec) string {
+	for _, t := range prop.OneOf {
+		token := strings.TrimPrefix(t.Ref, "#/types/")
+		if typ, ok := spec.Types[token]; ok && len(typ.Enum) != 0 {
+			return token
+		}
+	}
+	return ""
+}
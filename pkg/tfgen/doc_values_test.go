@@ -0,0 +1,157 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/codegen"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractDocValues(t *testing.T) {
+	tests := []struct {
+		doc      string
+		expected docValues
+	}{
+		{"Whether to enable the thing. Defaults to `true`.", docValues{Default: "true"}},
+		{"The region. Default value is `us-east-1`.", docValues{Default: "us-east-1"}},
+		{"The size. Default: `10`", docValues{Default: "10"}},
+		{"The region, e.g. `us-east-1` or `eu-west-2`.", docValues{Examples: []string{"us-east-1", "eu-west-2"}}},
+		{"The zone. Example: `us-east-1a`. Defaults to `\"us-east-1b\"`.",
+			docValues{Default: "\"us-east-1b\"", Examples: []string{"us-east-1a"}}},
+		{"Some text with `code` but no values.", docValues{}},
+		{"The ACL. Valid values are `private`, `public-read` and `public-read-write`.", docValues{
+			AllowedValues: []string{"private", "public-read", "public-read-write"}, Confidence: "high"}},
+		{"The tier. Valid values are: Basic, Standard or Premium", docValues{
+			AllowedValues: []string{"Basic", "Standard", "Premium"}, Confidence: "low"}},
+		{"The mode. Must be one of `SYNC` or `ASYNC`. Defaults to `SYNC`.", docValues{Default: "SYNC",
+			AllowedValues: []string{"SYNC", "ASYNC"}, Confidence: "high"}},
+		{"The port. Possible values are between 1 and 65535.", docValues{}},
+		{"Exactly one of `name` or `prefix` must be set.", docValues{}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, extractDocValues(test.doc), test.doc)
+	}
+}
+
+func TestParseDocValue(t *testing.T) {
+	v, ok := parseDocValue("true", "boolean")
+	assert.True(t, ok)
+	assert.Equal(t, true, v)
+
+	v, ok = parseDocValue("10", "integer")
+	assert.True(t, ok)
+	assert.Equal(t, float64(10), v)
+
+	v, ok = parseDocValue("\"us-east-1\"", "string")
+	assert.True(t, ok)
+	assert.Equal(t, "us-east-1", v)
+
+	_, ok = parseDocValue("yes please", "boolean")
+	assert.False(t, ok)
+	_, ok = parseDocValue("null", "string")
+	assert.False(t, ok)
+	_, ok = parseDocValue("[]", "array")
+	assert.False(t, ok)
+}
+
+func TestDocValueReport(t *testing.T) {
+	enabled := pschema.PropertySpec{
+		TypeSpec:    pschema.TypeSpec{Type: "boolean"},
+		Description: "Whether to enable the thing. Defaults to `true`.",
+	}
+	applyDocValues(enabled.Description, &enabled)
+	assert.Equal(t, true, enabled.Default)
+
+	region := pschema.PropertySpec{
+		TypeSpec:    pschema.TypeSpec{Type: "string"},
+		Description: "The region, e.g. `us-east-1`.",
+	}
+	applyDocValues(region.Description, &region)
+	assert.Nil(t, region.Default)
+	assert.Equal(t, `{"examples":["us-east-1"]}`, string(region.Language["tfbridge"]))
+
+	report := computeDocValueReport(pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index:Res": {
+				InputProperties: map[string]pschema.PropertySpec{"enabled": enabled, "region": region},
+			},
+		},
+	})
+	assert.Equal(t, []docValueEntry{
+		{Property: "#/resources/test:index:Res/inputProperties/enabled", Type: "boolean", Default: "true",
+			Applied: true},
+		{Property: "#/resources/test:index:Res/inputProperties/region", Type: "string",
+			Examples: []string{"us-east-1"}},
+	}, report)
+}
+
+func TestApplyDocEnums(t *testing.T) {
+	acl := pschema.PropertySpec{
+		TypeSpec:    pschema.TypeSpec{Type: "string"},
+		Description: "The ACL. Valid values are `private` and `public-read`.",
+	}
+	tier := pschema.PropertySpec{
+		TypeSpec:    pschema.TypeSpec{Type: "string"},
+		Description: "The tier. Valid values are Basic, Standard or Premium.",
+	}
+	storageClass := pschema.PropertySpec{
+		TypeSpec:    pschema.TypeSpec{Type: "string"},
+		Description: "The storage class. Valid values are `STANDARD` and `GLACIER`.",
+	}
+	applyDocValues(tier.Description, &tier)
+	assert.Equal(t, `{"allowedValues":["Basic","Standard","Premium"]}`, string(tier.Language["tfbridge"]))
+
+	spec := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:s3/bucket:Bucket": {
+				InputProperties: map[string]pschema.PropertySpec{
+					"acl":          acl,
+					"tier":         tier,
+					"storageClass": storageClass,
+				},
+			},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{},
+	}
+	applyDocEnums(&spec, codegen.NewStringSet("test:s3/bucket:Bucket.storageClass"))
+
+	// Values quoted as code become an enum, but the property still accepts any string.
+	assert.Equal(t, map[string]pschema.ComplexTypeSpec{
+		"test:s3/BucketAcl:BucketAcl": {
+			ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "string"},
+			Enum:           []pschema.EnumValueSpec{{Value: "private"}, {Value: "public-read"}},
+		},
+	}, spec.Types)
+	inputs := spec.Resources["test:s3/bucket:Bucket"].InputProperties
+	assert.Equal(t, []pschema.TypeSpec{{Type: "string"}, {Ref: "#/types/test:s3/BucketAcl:BucketAcl"}},
+		inputs["acl"].OneOf)
+
+	// Values extracted with low confidence are only documented, and inputs that upstream validates are left alone.
+	assert.Empty(t, inputs["tier"].OneOf)
+	assert.Empty(t, inputs["storageClass"].OneOf)
+
+	report := computeDocValueReport(spec)
+	assert.Equal(t, []docValueEntry{
+		{Property: "#/resources/test:s3/bucket:Bucket/inputProperties/acl", Type: "string",
+			AllowedValues: []string{"private", "public-read"}, Confidence: "high", Enum: true},
+		{Property: "#/resources/test:s3/bucket:Bucket/inputProperties/storageClass", Type: "string",
+			AllowedValues: []string{"STANDARD", "GLACIER"}, Confidence: "high"},
+		{Property: "#/resources/test:s3/bucket:Bucket/inputProperties/tier", Type: "string",
+			AllowedValues: []string{"Basic", "Standard", "Premium"}, Confidence: "low"},
+	}, report)
+}
@@ -16,7 +16,9 @@ package tfgen
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -28,6 +30,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tf2pulumi/gen/python"
@@ -102,6 +105,9 @@ type entityDocs struct {
 
 	// Import is the import details for the resource
 	Import string
+
+	// FrontMatter is the YAML front matter of the docs, e.g. `subcategory: "Compute"`, without its delimiters.
+	FrontMatter string
 }
 
 func (ed *entityDocs) getOrCreateArgumentDocs(argumentName string) (*argumentDocs, bool) {
@@ -126,6 +132,8 @@ const (
 	ResourceDocs DocKind = "resources"
 	// DataSourceDocs indicates documentation pertaining to data source entities.
 	DataSourceDocs DocKind = "data-sources"
+	// EphemeralResourceDocs indicates documentation pertaining to ephemeral resource entities.
+	EphemeralResourceDocs DocKind = "ephemeral-resources"
 )
 
 var repoPaths sync.Map
@@ -233,7 +241,27 @@ func getDocsForProvider(g *Generator, org string, provider string, resourcePrefi
 		return entityDocs{}, nil
 	}
 
-	doc, err := parseTFMarkdown(g, info, kind, string(markdownBytes), markdownFileName, resourcePrefix, rawname)
+	if g.docTrace.tracing(kind, rawname) {
+		g.docTrace.source, g.docTrace.sourceFile = string(markdownBytes), markdownFileName
+	}
+
+	markdown, err := g.expandDocIncludes(string(markdownBytes))
+	if err != nil {
+		return entityDocs{}, fmt.Errorf("expanding doc includes for %v: %w", rawname, err)
+	}
+	g.traceDoc(kind, rawname, "expand doc includes", string(markdownBytes), markdown)
+
+	// Copy the images that the docs refer to, unless the docs were provided inline.
+	if markdownFileName != "" {
+		if repo, err := getRepoPath(githost, org, provider, providerModuleVersion); err == nil {
+			dir := filepath.Dir(filepath.Join(getDocsPath(repo, kind), markdownFileName))
+			before := markdown
+			markdown = g.rewriteDocAssets(rawname, markdown, repo, dir)
+			g.traceDoc(kind, rawname, "rewrite doc assets", before, markdown)
+		}
+	}
+
+	doc, err := parseTFMarkdown(g, info, kind, markdown, markdownFileName, resourcePrefix, rawname)
 	if err != nil {
 		return entityDocs{}, err
 	}
@@ -263,6 +291,11 @@ func getDocsForProvider(g *Generator, org string, provider string, resourcePrefi
 			providerModuleVersion, githost); err != nil {
 			return doc, err
 		}
+
+		// Replace argument descriptions with shared snippets
+		if err := g.applyArgumentIncludes(doc, docinfo); err != nil {
+			return doc, err
+		}
 	}
 
 	return doc, nil
@@ -463,6 +496,13 @@ func (p *tfMarkdownParser) parse() (entityDocs, error) {
 	// Get links.
 	footerLinks := getFooterLinks(markdown)
 
+	// Trace the cleanup of each part of the docs, if these are the docs being debugged.
+	if p.g.docTrace.tracing(p.kind, p.rawname) {
+		p.g.traceDoc(p.kind, p.rawname, "split sections", markdown, p.ret.Description)
+		p.g.docTrace.active = true
+		defer func() { p.g.docTrace.active = false }()
+	}
+
 	doc, elided := cleanupDoc(p.rawname, p.g, p.info, p.ret, footerLinks)
 	if elided {
 		p.g.warn("Resource %v contains an <elided> doc reference that needs updated", p.rawname)
@@ -838,9 +878,11 @@ func (p *tfMarkdownParser) parseFrontMatter(subsection []string) {
 			foundEndHeader = true
 			break
 		}
+		p.ret.FrontMatter += curr + "\n"
 	}
 	if !foundEndHeader {
 		p.g.warn("", "Expected to pair --- begin/end for resource %v's Markdown header", p.rawname)
+		p.ret.FrontMatter = ""
 	}
 
 	// Now extract the description section. We assume here that the first H1 (line starting with #) is the name
@@ -1001,13 +1043,34 @@ func (g *Generator) convertExamples(docs, name string, stripSubsectionsWithError
 						continue
 					}
 
+					// Curated examples may already be written in a Pulumi language, in which case they need no
+					// conversion; they are kept if they are written in a language we are generating docs for.
+					if lang := pulumiExampleLanguage(subsection[codeBlockStart][3:]); lang != "" {
+						if g.language.includesExampleLanguage(lang) {
+							fprintf(subsectionOutput, "\n%s", strings.Join(subsection[codeBlockStart:i+1], "\n"))
+							hasExamples = true
+						}
+						inCodeBlock = false
+						continue
+					}
+
+					// Code blocks in other languages, e.g. shell commands or JSON documents, apply to every language
+					// and are kept, tagged with their language, rather than converted as if they were HCL.
+					if otherCodeBlockLanguage(subsection[codeBlockStart][3:]) != "" {
+						fprintf(subsectionOutput, "\n%s", strings.Join(subsection[codeBlockStart:i+1], "\n"))
+						inCodeBlock = false
+						continue
+					}
+
 					if g.language.shouldConvertExamples() {
 						hcl := strings.Join(subsection[codeBlockStart+1:i], "\n")
 
 						// We've got some code -- assume it's HCL and try to convert it.
 						g.coverageTracker.foundExample(name, hcl)
 						codeBlock, stderr, err := g.convertHCL(hcl, name)
-						if err != nil {
+						if errors.Is(err, errExampleExcluded) {
+							skippedExamples = true
+						} else if err != nil {
 							skippedExamples = true
 							hclFailures[stderr] = true
 							hclBlocksFailed++
@@ -1083,8 +1146,13 @@ func (g *Generator) convertExamples(docs, name string, stripSubsectionsWithError
 // of failure, the error returned will be non-nil, and the second string contains the stderr stream of details.
 func (g *Generator) convertHCL(hcl, path string) (string, string, error) {
 	g.debug(fmt.Sprintf("converting HCL for %s", path))
+	original := hcl
 
-	// Fixup the HCL as necessary.
+	// Normalize and fixup the HCL as necessary.
+	if normalized, ok := normalizeHcl(hcl); ok {
+		hcl = normalized
+		g.coverageTracker.exampleNormalized()
+	}
 	if fixed, ok := fixHcl(hcl); ok {
 		hcl = fixed
 	}
@@ -1098,7 +1166,34 @@ func (g *Generator) convertHCL(hcl, path string) (string, string, error) {
 
 	var result strings.Builder
 	var stderr bytes.Buffer
-	convertHCL := func(languageName string) (err error) {
+	converted := map[string]string{}
+
+	// emit adds a fenced code-block with the given snippet to the result, after replacing the provider's placeholders
+	// and applying any provider-specific transformer.
+	emit := func(languageName, code string) error {
+		code = g.replaceExamplePlaceholders(languageName, code)
+		if transform := g.info.ExampleTransformers[languageName]; transform != nil {
+			var err error
+			if code, err = transform(path, code); err != nil {
+				g.coverageTracker.languageConversionTransformFailure(languageName, err)
+				return fmt.Errorf("failed to transform %v example for %s: %w", languageName, path, err)
+			}
+		}
+		if result.Len() > 0 {
+			result.WriteByte('\n')
+		}
+		converted[languageName] = strings.TrimSpace(code)
+		_, err := fmt.Fprintf(&result, "```%s\n%s\n```", languageName, converted[languageName])
+		contract.IgnoreError(err)
+		return nil
+	}
+
+	convertWithBridge := func(languageName string) (err error) {
+		if g.exampleLanguageExcluded(path, languageName) {
+			g.coverageTracker.languageConversionExcluded(languageName)
+			return errExampleExcluded
+		}
+
 		defer func() {
 			v := recover()
 			if v != nil {
@@ -1108,63 +1203,102 @@ func (g *Generator) convertHCL(hcl, path string) (string, string, error) {
 			}
 		}()
 
-		var logger *log.Logger
-		if g.printStats {
-			logger = log.New(&stderr, "", log.Lshortfile)
-		}
-
-		files, diags, err := convert.Convert(convert.Options{
-			Root:                     input,
-			TargetLanguage:           languageName,
-			AllowMissingProperties:   true,
-			AllowMissingVariables:    true,
-			FilterResourceNames:      true,
-			Logger:                   logger,
-			PackageCache:             g.packageCache,
-			PluginHost:               g.pluginHost,
-			ProviderInfoSource:       g.infoSource,
-			SkipResourceTypechecking: true,
-			TerraformVersion:         g.terraformVersion,
-		})
-		if err != nil {
-			g.coverageTracker.languageConversionPanic(languageName, err.Error())
-			return fmt.Errorf("failed to convert HCL for %s to %v: %w", path, languageName, err)
-		}
-		if diags.All.HasErrors() {
-			if stderr.Len() != 0 {
-				_, err := fmt.Fprintf(&stderr, "\n")
-				contract.IgnoreError(err)
+		// Examples converted by earlier runs, or by other providers sharing the cache, are reused as-is.
+		code, cached := g.exampleCache.get(g, languageName, hcl)
+		if !cached {
+			// The converter logs into its own buffer so that a timed out conversion that is still running cannot race
+			// with our use of stderr.
+			var logs bytes.Buffer
+			var logger *log.Logger
+			if g.printStats {
+				logger = log.New(&logs, "", log.Lshortfile)
 			}
-			_, err := fmt.Fprintf(&stderr, "# %s: %s\n", path, languageName)
-			contract.IgnoreError(err)
 
-			_, err = fmt.Fprintf(&stderr, "%s\n\n", hcl)
-			contract.IgnoreError(err)
+			// Each conversion gets its own host so that one that times out can be detached from the shared host.
+			host := newConversionHost(g.pluginHost, g.infoSource)
+
+			var files map[string][]byte
+			var diags convert.Diagnostics
+			elapsed, err := runWithTimeout(g.exampleTimeout, func() error {
+				var convertErr error
+				files, diags, convertErr = convert.Convert(convert.Options{
+					Root:                     input,
+					TargetLanguage:           languageName,
+					AllowMissingProperties:   true,
+					AllowMissingVariables:    true,
+					FilterResourceNames:      true,
+					Logger:                   logger,
+					PackageCache:             g.packageCache,
+					PluginHost:               host,
+					ProviderInfoSource:       host,
+					SkipResourceTypechecking: true,
+					TerraformVersion:         g.terraformVersion,
+				})
+				return convertErr
+			})
+			if errors.Is(err, errConversionTimeout) {
+				host.detach()
+				g.warn("timed out converting HCL for %s to %v after %v", path, languageName, elapsed)
+				g.coverageTracker.languageConversionTimeout(languageName, elapsed)
+				return fmt.Errorf("failed to convert HCL for %s to %v: %w", path, languageName, err)
+			}
+			_, logErr := stderr.Write(logs.Bytes())
+			contract.IgnoreError(logErr)
+			if err != nil {
+				g.coverageTracker.languageConversionPanic(languageName, err.Error())
+				return fmt.Errorf("failed to convert HCL for %s to %v: %w", path, languageName, err)
+			}
+			if diags.All.HasErrors() {
+				if stderr.Len() != 0 {
+					_, err := fmt.Fprintf(&stderr, "\n")
+					contract.IgnoreError(err)
+				}
+				_, err := fmt.Fprintf(&stderr, "# %s: %s\n", path, languageName)
+				contract.IgnoreError(err)
 
-			err = diags.NewDiagnosticWriter(&stderr, 0, false).WriteDiagnostics(diags.All)
-			contract.IgnoreError(err)
+				_, err = fmt.Fprintf(&stderr, "%s\n\n", hcl)
+				contract.IgnoreError(err)
 
-			g.coverageTracker.languageConversionFailure(languageName, diags.All)
-			// Note that we intentionally avoid returning an error here. The caller will check for an empty code block
-			// before returning and translate that into an error.
-			return nil
-		}
+				err = diags.NewDiagnosticWriter(&stderr, 0, false).WriteDiagnostics(diags.All)
+				contract.IgnoreError(err)
 
-		contract.Assert(len(files) == 1)
+				g.coverageTracker.languageConversionFailure(languageName, diags.All)
+				// Note that we intentionally avoid returning an error here. The caller will check for an empty code block
+				// before returning and translate that into an error.
+				return nil
+			}
 
-		// Add a fenced code-block with the resulting code snippet.
-		for _, output := range files {
-			if result.Len() > 0 {
-				result.WriteByte('\n')
+			contract.Assert(len(files) == 1)
+			for _, output := range files {
+				code = string(output)
 			}
-			_, err := fmt.Fprintf(&result, "```%s\n%s\n```", languageName, strings.TrimSpace(string(output)))
-			contract.IgnoreError(err)
+			g.exampleCache.put(g, languageName, hcl, code)
 		}
 
+		if err := emit(languageName, code); err != nil {
+			return err
+		}
 		g.coverageTracker.languageConversionSuccess(languageName)
 		return nil
 	}
 
+	// Examples that the bridge's converter fails to convert are retried with the fallback converters, if any.
+	convertHCL := func(languageName string) error {
+		err := convertWithBridge(languageName)
+		if _, ok := converted[languageName]; ok || errors.Is(err, errExampleExcluded) {
+			return err
+		}
+		code, converter, ok := g.convertWithFallbacks(path, hcl, languageName, &stderr)
+		if !ok {
+			return err
+		}
+		if err := emit(languageName, code); err != nil {
+			return err
+		}
+		g.coverageTracker.languageConversionFallbackSuccess(languageName, converter)
+		return nil
+	}
+
 	switch g.language {
 	case NodeJS:
 		err = convertHCL("typescript")
@@ -1177,16 +1311,22 @@ func (g *Generator) convertHCL(hcl, path string) (string, string, error) {
 	case Schema:
 		langs := []string{"typescript", "python", "csharp", "go"}
 		var anySucceeded bool = false
+		var excluded int
 		for _, lang := range langs {
-			if langErr := convertHCL(lang); langErr != nil {
+			if langErr := convertHCL(lang); errors.Is(langErr, errExampleExcluded) {
+				excluded++
+			} else if langErr != nil {
 				err = multierror.Append(err, langErr)
 			} else {
 				anySucceeded = true
 			}
 		}
-		if anySucceeded {
+		switch {
+		case anySucceeded:
 			// At least one language out of the given set has been generated, which is considered a success
 			err = nil
+		case excluded == len(langs):
+			err = errExampleExcluded
 		}
 	}
 
@@ -1196,16 +1336,53 @@ func (g *Generator) convertHCL(hcl, path string) (string, string, error) {
 	if result.Len() == 0 {
 		return "", stderr.String(), fmt.Errorf("failed to convert HCL for %s to %v: empty output produced", path, g.language)
 	}
+	g.collectExampleBundle(path, original, converted)
 	return result.String(), stderr.String(), nil
 }
 
+// errConversionTimeout is returned by runWithTimeout when the conversion did not finish in time.
+var errConversionTimeout = errors.New("example conversion timed out")
+
+// runWithTimeout runs f, giving up after the given timeout and returning errConversionTimeout along with the
+// elapsed time. A zero or negative timeout runs f to completion. The converter does not accept a context, so on
+// timeout f keeps running in the background; its results must not be read by the caller in that case, and any
+// conversionHost it uses must be detached.
+func runWithTimeout(timeout time.Duration, f func() error) (time.Duration, error) {
+	start := time.Now()
+	if timeout <= 0 {
+		err := f()
+		return time.Since(start), err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("panic: %v", v)
+			}
+		}()
+		done <- f()
+	}()
+
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-ctx.Done():
+		return time.Since(start), errConversionTimeout
+	}
+}
+
 func cleanupDoc(name string, g *Generator, info tfbridge.ResourceOrDataSourceInfo, doc entityDocs,
 	footerLinks map[string]string) (entityDocs, bool) {
 	elidedDoc := false
 	newargs := make(map[string]*argumentDocs, len(doc.Arguments))
 	for k, v := range doc.Arguments {
 		g.debug("Cleaning up text for argument [%v] in [%v]", k, name)
-		cleanedText, elided := cleanupText(g, info, v.description, footerLinks)
+		g.docTrace.setScope("argument " + k)
+		cleanedText, elided := cleanupText(g, info, g.linkDocReferences(name, k, v.description), footerLinks)
 		if elided {
 			g.warn("Documentation <elided> for argument [%v] in [%v]", k, name)
 			elidedDoc = true
@@ -1220,7 +1397,8 @@ func cleanupDoc(name string, g *Generator, info tfbridge.ResourceOrDataSourceInf
 		// Clean nested arguments (if any)
 		for kk, vv := range v.arguments {
 			g.debug("Cleaning up text for nested argument [%v] in [%v]", kk, name)
-			cleanedText, elided := cleanupText(g, info, vv, footerLinks)
+			g.docTrace.setScope("argument " + k + "." + kk)
+			cleanedText, elided := cleanupText(g, info, g.linkDocReferences(name, k+"."+kk, vv), footerLinks)
 			if elided {
 				g.warn("Documentation <elided> for nested argument [%v] in [%v]", kk, name)
 				elidedDoc = true
@@ -1231,7 +1409,8 @@ func cleanupDoc(name string, g *Generator, info tfbridge.ResourceOrDataSourceInf
 	newattrs := make(map[string]string, len(doc.Attributes))
 	for k, v := range doc.Attributes {
 		g.debug("Cleaning up text for attribute [%v] in [%v]", k, name)
-		cleanupText, elided := cleanupText(g, info, v, footerLinks)
+		g.docTrace.setScope("attribute " + k)
+		cleanupText, elided := cleanupText(g, info, g.linkDocReferences(name, k, v), footerLinks)
 		if elided {
 			g.warn("Documentation <elided> for attribute [%v] in [%v]", k, name)
 			elidedDoc = true
@@ -1239,6 +1418,7 @@ func cleanupDoc(name string, g *Generator, info tfbridge.ResourceOrDataSourceInf
 		newattrs[k] = cleanupText
 	}
 	g.debug("Cleaning up description text for [%v]", name)
+	g.docTrace.setScope("description")
 	cleanupText, elided := cleanupText(g, info, doc.Description, footerLinks)
 	if elided {
 		g.warn("Description text <elided> in [%v]", name)
@@ -1337,53 +1517,71 @@ func fixupPropertyReferences(language Language, pkg string, info tfbridge.Provid
 func cleanupText(g *Generator, info tfbridge.ResourceOrDataSourceInfo, text string,
 	footerLinks map[string]string) (string, bool) {
 
+	trace := g.docTrace
 	cleanupText := func(text string) (string, bool) {
+		// Rewrite links to upstream docs first, since text that still refers to Terraform is removed.
+		text = trace.step("rewrite doc links", text, g.rewriteDocLinks)
+
 		// Remove incorrect documentation that should have been cleaned up in our forks.
 		// TODO: fail the build in the face of such text, once we have a processes in place.
 		if strings.Contains(text, "Terraform") || strings.Contains(text, "terraform") {
+			trace.record("elide text that mentions Terraform", text, "")
 			return "", true
 		}
 
 		// Replace occurrences of "->" or "~>" with just ">", to get a proper MarkDown note.
-		text = strings.Replace(text, "-> ", "> ", -1)
-		text = strings.Replace(text, "~> ", "> ", -1)
+		text = trace.step("convert notes", text, func(text string) string {
+			text = strings.Replace(text, "-> ", "> ", -1)
+			return strings.Replace(text, "~> ", "> ", -1)
+		})
 
 		// Trim Prefixes we see when the description is spread across multiple lines.
 		text = strings.TrimPrefix(text, "-\n(Required)\n")
 		text = strings.TrimPrefix(text, "-\n(Optional)\n")
 
 		// Find markdown Terraform docs site reference links.
-		text = markdownPageReferenceLink.ReplaceAllStringFunc(text, func(referenceLink string) string {
-			parts := strings.Split(referenceLink, " ")
-			// Add Terraform domain to avoid broken links.
-			return fmt.Sprintf("%s https://www.terraform.io%s", parts[0], parts[1])
+		text = trace.step("rewrite reference links", text, func(text string) string {
+			return markdownPageReferenceLink.ReplaceAllStringFunc(text, func(referenceLink string) string {
+				parts := strings.Split(referenceLink, " ")
+				// Add Terraform domain to avoid broken links.
+				return fmt.Sprintf("%s https://www.terraform.io%s", parts[0], parts[1])
+			})
 		})
 
 		// Find links from the footer links.
-		text = replaceFooterLinks(text, footerLinks)
+		text = trace.step("replace footer links", text, func(text string) string {
+			return replaceFooterLinks(text, footerLinks)
+		})
 
 		// Find URLs and re-write local links
-		text = markdownLink.ReplaceAllStringFunc(text, func(link string) string {
-			parts := markdownLink.FindStringSubmatch(link)
-			url := parts[2]
-			if strings.HasPrefix(url, "http") {
-				// Absolute URL, return as-is
-				return link
-			} else if strings.HasPrefix(url, "/") {
-				// Relative URL to the root of the Terraform docs site, rewrite to absolute
-				return fmt.Sprintf("[%s](https://www.terraform.io%s)", parts[1], url)
-			} else if strings.HasPrefix(url, "#") {
-				// Anchor in current page,  can't be resolved currently so remove the link.
+		text = trace.step("rewrite local links", text, func(text string) string {
+			return markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+				parts := markdownLink.FindStringSubmatch(link)
+				url := parts[2]
+				if strings.HasPrefix(url, "http") {
+					// Absolute URL, return as-is
+					return link
+				} else if strings.HasPrefix(url, "/registry/") {
+					// Pulumi registry path, e.g. from a rewritten link to upstream docs, return as-is
+					return link
+				} else if strings.HasPrefix(url, "/") {
+					// Relative URL to the root of the Terraform docs site, rewrite to absolute
+					return fmt.Sprintf("[%s](https://www.terraform.io%s)", parts[1], url)
+				} else if strings.HasPrefix(url, "#") {
+					// Anchor in current page,  can't be resolved currently so remove the link.
+					// Note: This throws away potentially valuable information in the name of not having broken links.
+					return parts[1]
+				}
+				// Relative URL to the current page, can't be resolved currently so remove the link.
 				// Note: This throws away potentially valuable information in the name of not having broken links.
 				return parts[1]
-			}
-			// Relative URL to the current page, can't be resolved currently so remove the link.
-			// Note: This throws away potentially valuable information in the name of not having broken links.
-			return parts[1]
+			})
 		})
 
 		// Fixup resource and property name references
-		text = fixupPropertyReferences(g.language, g.pkg, g.info, text)
+		text = trace.step("fix up property references", text, func(text string) string {
+			return fixupPropertyReferences(g.language, g.pkg, g.info, text)
+		})
 
 		return text, false
 	}
@@ -0,0 +1,194 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultMaxDocAssetBytes is the size above which docs assets are not copied, unless the provider sets
+// ProviderInfo.MaxDocAssetBytes.
+const defaultMaxDocAssetBytes = 1 << 20
+
+// docAssetsDir is the directory of the docs output tree that docs assets are copied to.
+const docAssetsDir = "docs/assets"
+
+var (
+	// markdownImageRegexp matches markdown images, e.g. `![diagram](../images/diagram.png "Title")`.
+	markdownImageRegexp = regexp.MustCompile(`(!\[[^\]]*\]\()([^)\s]+)((?:\s+"[^"]*")?\))`)
+	// htmlImageRegexp matches the source of HTML images, e.g. `<img src="../images/diagram.png">`.
+	htmlImageRegexp = regexp.MustCompile(`(<img\s[^>]*\bsrc=["'])([^"']+)(["'])`)
+)
+
+// docAsset is an upstream image or other static asset that was copied into the docs output tree.
+type docAsset struct {
+	Source string `json:"source"` // the asset's path in the upstream repository
+	Path   string `json:"path"`   // the asset's path in the docs output tree
+	Size   int    `json:"size"`
+}
+
+// missingDocAsset is an asset that upstream docs refer to, but that could not be copied.
+type missingDocAsset struct {
+	Location string `json:"location"` // the resource, data source or guide whose docs refer to the asset
+	Target   string `json:"target"`   // the asset's URL in the upstream docs
+	Reason   string `json:"reason"`
+}
+
+// docAssetReport lists the assets that were copied into the docs output tree, and those that could not be.
+type docAssetReport struct {
+	Copied  []docAsset        `json:"copied,omitempty"`
+	Missing []missingDocAsset `json:"missing,omitempty"`
+}
+
+// docAssets collects the assets that the docs of a package refer to.
+type docAssets struct {
+	files   map[string][]byte // the contents of each copied asset, by path in the docs output tree
+	sources map[string]string // the path of each copied asset in the upstream repository
+	missing []missingDocAsset
+}
+
+// report returns the report of the collected assets, in a stable order.
+func (a *docAssets) report() docAssetReport {
+	var report docAssetReport
+	for _, p := range sortedDocAssetPaths(a.files) {
+		report.Copied = append(report.Copied, docAsset{
+			Source: a.sources[p],
+			Path:   p,
+			Size:   len(a.files[p]),
+		})
+	}
+	report.Missing = append(report.Missing, a.missing...)
+	sort.SliceStable(report.Missing, func(i, j int) bool {
+		return report.Missing[i].Location < report.Missing[j].Location
+	})
+	return report
+}
+
+func sortedDocAssetPaths(files map[string][]byte) []string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// isRemoteDocAsset returns true if the given URL does not refer to a file in the upstream repository.
+func isRemoteDocAsset(url string) bool {
+	return strings.Contains(url, "://") || strings.HasPrefix(url, "//") || strings.HasPrefix(url, "data:") ||
+		strings.HasPrefix(url, "#") || strings.HasPrefix(url, "mailto:")
+}
+
+// docAssetURL returns the URL that the asset at the given path relative to the docs assets directory is served from.
+func (g *Generator) docAssetURL(rel string) string {
+	base := g.info.DocAssetsURL
+	if base == "" {
+		base = fmt.Sprintf("/registry/packages/%s/assets", g.pkg)
+	}
+	return strings.TrimSuffix(base, "/") + "/" + rel
+}
+
+// rewriteDocAssets copies the local images that the given markdown refers to into the docs output tree, and rewrites
+// their URLs to the URLs that they are served from. Relative URLs are resolved against dir, the directory of the
+// markdown file, and absolute paths against the root of the upstream repository, repo. Images that cannot be copied
+// are left as they are and reported.
+func (g *Generator) rewriteDocAssets(location, markdown, repo, dir string) string {
+	rewrite := func(re *regexp.Regexp) func(string) string {
+		return func(match string) string {
+			parts := re.FindStringSubmatch(match)
+			url, ok := g.copyDocAsset(location, parts[2], repo, dir)
+			if !ok {
+				return match
+			}
+			return parts[1] + url + parts[3]
+		}
+	}
+	markdown = markdownImageRegexp.ReplaceAllStringFunc(markdown, rewrite(markdownImageRegexp))
+	return htmlImageRegexp.ReplaceAllStringFunc(markdown, rewrite(htmlImageRegexp))
+}
+
+// copyDocAsset copies the asset with the given URL into the docs output tree, and returns the URL that it is served
+// from and true, if the asset is a file in the upstream repository.
+func (g *Generator) copyDocAsset(location, target, repo, dir string) (string, bool) {
+	if isRemoteDocAsset(target) {
+		return "", false
+	}
+	missing := func(reason string) (string, bool) {
+		g.warn("docs for %s refer to %s, which %s", location, target, reason)
+		g.docAssets.missing = append(g.docAssets.missing, missingDocAsset{
+			Location: location,
+			Target:   target,
+			Reason:   reason,
+		})
+		return "", false
+	}
+
+	file := target
+	if i := strings.IndexAny(file, "?#"); i != -1 {
+		file = file[:i]
+	}
+	var source string
+	if strings.HasPrefix(file, "/") {
+		source = filepath.Join(repo, filepath.FromSlash(file))
+		if _, err := os.Stat(source); err != nil {
+			source = filepath.Join(repo, "website", filepath.FromSlash(file))
+		}
+	} else {
+		source = filepath.Join(dir, filepath.FromSlash(file))
+	}
+	rel, err := filepath.Rel(repo, source)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return missing("is outside of the upstream repository")
+	}
+
+	stat, err := os.Stat(source)
+	switch {
+	case err != nil || stat.IsDir():
+		return missing("does not exist")
+	case stat.Size() > int64(g.maxDocAssetBytes()):
+		return missing(fmt.Sprintf("is larger than the %d byte limit", g.maxDocAssetBytes()))
+	}
+	contents, err := ioutil.ReadFile(source)
+	if err != nil {
+		return missing(fmt.Sprintf("could not be read: %v", err))
+	}
+
+	// Upstream docs live under docs/ or website/docs/, which is left out of the asset's path.
+	rel = filepath.ToSlash(rel)
+	assetPath := rel
+	for _, prefix := range []string{"website/", "docs/"} {
+		assetPath = strings.TrimPrefix(assetPath, prefix)
+	}
+	if g.docAssets.files == nil {
+		g.docAssets.files, g.docAssets.sources = map[string][]byte{}, map[string]string{}
+	}
+	g.docAssets.files[path.Join(docAssetsDir, assetPath)] = contents
+	g.docAssets.sources[path.Join(docAssetsDir, assetPath)] = rel
+	return g.docAssetURL(assetPath), true
+}
+
+func (g *Generator) maxDocAssetBytes() int {
+	if g.info.MaxDocAssetBytes > 0 {
+		return g.info.MaxDocAssetBytes
+	}
+	return defaultMaxDocAssetBytes
+}
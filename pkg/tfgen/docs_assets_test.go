@@ -0,0 +1,81 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+func TestRewriteDocAssets(t *testing.T) {
+	repo := t.TempDir()
+	dir := filepath.Join(repo, "website", "docs", "r")
+	images := filepath.Join(repo, "website", "docs", "images")
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	assert.NoError(t, os.MkdirAll(images, 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(images, "diagram.png"), []byte("png"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(images, "large.png"), []byte("too large"), 0600))
+
+	newGenerator := func(info tfbridge.ProviderInfo) *Generator {
+		g, err := NewGenerator(GeneratorOptions{
+			Package:      "test",
+			Version:      "0.0.1",
+			Language:     Schema,
+			ProviderInfo: info,
+			Sink:         diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		})
+		assert.NoError(t, err)
+		return g
+	}
+
+	g := newGenerator(tfbridge.ProviderInfo{Name: "test", MaxDocAssetBytes: 4})
+	markdown := g.rewriteDocAssets("test_widget", "![Diagram](../images/diagram.png \"A diagram\")\n"+
+		"<img alt=\"diagram\" src=\"/docs/images/diagram.png\">\n"+
+		"![Remote](https://example.com/remote.png)\n"+
+		"![Missing](../images/missing.png)\n"+
+		"![Large](../images/large.png)\n"+
+		"![Outside](../../../../secret.png)\n", repo, dir)
+	assert.Equal(t, "![Diagram](/registry/packages/test/assets/images/diagram.png \"A diagram\")\n"+
+		"<img alt=\"diagram\" src=\"/registry/packages/test/assets/images/diagram.png\">\n"+
+		"![Remote](https://example.com/remote.png)\n"+
+		"![Missing](../images/missing.png)\n"+
+		"![Large](../images/large.png)\n"+
+		"![Outside](../../../../secret.png)\n", markdown)
+
+	assert.Equal(t, map[string][]byte{"docs/assets/images/diagram.png": []byte("png")}, g.docAssets.files)
+	report := g.docAssets.report()
+	assert.Equal(t, []docAsset{
+		{Source: "website/docs/images/diagram.png", Path: "docs/assets/images/diagram.png", Size: 3},
+	}, report.Copied)
+	assert.Equal(t, []missingDocAsset{
+		{Location: "test_widget", Target: "../images/missing.png", Reason: "does not exist"},
+		{Location: "test_widget", Target: "../images/large.png", Reason: "is larger than the 4 byte limit"},
+		{Location: "test_widget", Target: "../../../../secret.png", Reason: "is outside of the upstream repository"},
+	}, report.Missing)
+
+	// Providers can serve assets from elsewhere.
+	g = newGenerator(tfbridge.ProviderInfo{Name: "test", DocAssetsURL: "https://cdn.example.com/test/"})
+	markdown = g.rewriteDocAssets("guide intro", "![Diagram](../images/diagram.png)", repo, dir)
+	assert.Equal(t, "![Diagram](https://cdn.example.com/test/images/diagram.png)", markdown)
+	assert.Empty(t, g.docAssets.missing)
+}
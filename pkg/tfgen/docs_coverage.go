@@ -0,0 +1,82 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// docsCoverage describes how many of the properties of a schema's resources and functions received a non-empty
+// description, and is exported alongside the example coverage data in order to catch regressions in the docs.
+type docsCoverage struct {
+	TotalProperties      int
+	DocumentedProperties int
+	Members              map[string]*memberDocsCoverage // coverage for each resource and function, keyed by token
+}
+
+// memberDocsCoverage describes how many of the properties of a single resource or function received a description.
+type memberDocsCoverage struct {
+	TotalProperties      int
+	DocumentedProperties int
+	Undocumented         []string `json:",omitempty"` // the names of the properties that have no description
+}
+
+// computeDocsCoverage computes the docs coverage of the resources and functions in the given schema. A property that
+// appears as both an input and an output counts once, and is documented if either has a description.
+func computeDocsCoverage(spec pschema.PackageSpec) *docsCoverage {
+	coverage := &docsCoverage{Members: map[string]*memberDocsCoverage{}}
+
+	add := func(token string, propertyMaps ...map[string]pschema.PropertySpec) {
+		documented := map[string]bool{}
+		for _, props := range propertyMaps {
+			for name, prop := range props {
+				documented[name] = documented[name] || strings.TrimSpace(prop.Description) != ""
+			}
+		}
+
+		member := &memberDocsCoverage{TotalProperties: len(documented)}
+		for name, ok := range documented {
+			if ok {
+				member.DocumentedProperties++
+			} else {
+				member.Undocumented = append(member.Undocumented, name)
+			}
+		}
+		sort.Strings(member.Undocumented)
+
+		coverage.Members[token] = member
+		coverage.TotalProperties += member.TotalProperties
+		coverage.DocumentedProperties += member.DocumentedProperties
+	}
+
+	for token, res := range spec.Resources {
+		add(token, res.InputProperties, res.Properties)
+	}
+	for token, fun := range spec.Functions {
+		var inputs, outputs map[string]pschema.PropertySpec
+		if fun.Inputs != nil {
+			inputs = fun.Inputs.Properties
+		}
+		if fun.Outputs != nil {
+			outputs = fun.Outputs.Properties
+		}
+		add(token, inputs, outputs)
+	}
+
+	return coverage
+}
@@ -0,0 +1,76 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeDocsCoverage(t *testing.T) {
+	spec := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				InputProperties: map[string]pschema.PropertySpec{
+					"name": {Description: "The name."},
+					"size": {},
+				},
+				ObjectTypeSpec: pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{
+					"name": {},
+					"size": {Description: "The size."},
+					"arn":  {Description: "  \n"},
+				}},
+			},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getWidget:getWidget": {
+				Inputs: &pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{
+					"name": {Description: "The name."},
+				}},
+			},
+		},
+	}
+
+	coverage := computeDocsCoverage(spec)
+	assert.Equal(t, &docsCoverage{
+		TotalProperties:      4,
+		DocumentedProperties: 3,
+		Members: map[string]*memberDocsCoverage{
+			"test:index/widget:Widget": {
+				TotalProperties:      3,
+				DocumentedProperties: 2,
+				Undocumented:         []string{"arn"},
+			},
+			"test:index/getWidget:getWidget": {
+				TotalProperties:      1,
+				DocumentedProperties: 1,
+			},
+		},
+	}, coverage)
+
+	// The coverage is exported alongside the example coverage.
+	ct := newCoverageTracker("test", "0.0.1")
+	ct.foundSchema(spec)
+	ce := newCoverageExportUtil(ct)
+	assert.NoError(t, ce.exportDocsCoverage("byDocsCoverage.json"))
+	assert.Len(t, ce.reports, 1)
+	bytes := ce.reports[0].Contents
+	var exported docsCoverage
+	assert.NoError(t, json.Unmarshal(bytes, &exported))
+	assert.Equal(t, coverage, &exported)
+}
@@ -0,0 +1,217 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// docTrace records the edits that the docs pipeline makes to the docs of one resource or data source, so that
+// `tfgen debug-docs` can explain where each part of the final docs came from.
+type docTrace struct {
+	kind       DocKind
+	target     string // the Terraform name of the resource or data source whose docs are traced
+	source     string // the upstream markdown
+	sourceFile string
+	active     bool   // true while the target's docs are being cleaned up
+	scope      string // the part of the docs that is being cleaned up, e.g. "argument name"
+	steps      []docTraceStep
+}
+
+// docTraceStep is an edit that changed the traced docs.
+type docTraceStep struct {
+	Rule   string
+	Before string
+	After  string
+}
+
+// tracing returns true if the docs of the given resource or data source are traced.
+func (t *docTrace) tracing(kind DocKind, rawname string) bool {
+	return t != nil && t.kind == kind && t.target == rawname
+}
+
+// setScope sets the part of the docs that the edits that follow apply to.
+func (t *docTrace) setScope(scope string) {
+	if t != nil {
+		t.scope = scope
+	}
+}
+
+// record records the edit made by the given rule while the target's docs are being cleaned up, if it changed them.
+func (t *docTrace) record(rule, before, after string) {
+	if t == nil || !t.active || before == after {
+		return
+	}
+	if t.scope != "" {
+		rule = t.scope + ": " + rule
+	}
+	t.steps = append(t.steps, docTraceStep{Rule: rule, Before: before, After: after})
+}
+
+// step applies the given edit to text, records it, and returns the edited text.
+func (t *docTrace) step(rule, text string, edit func(string) string) string {
+	after := edit(text)
+	t.record(rule, text, after)
+	return after
+}
+
+// traceDoc records the edit made by the given rule to the docs of the given resource or data source, if they are
+// traced and the edit changed them.
+func (g *Generator) traceDoc(kind DocKind, rawname, rule, before, after string) {
+	if !g.docTrace.tracing(kind, rawname) || before == after {
+		return
+	}
+	g.docTrace.steps = append(g.docTrace.steps, docTraceStep{Rule: rule, Before: before, After: after})
+}
+
+// docTraceStage returns a function that records the edit made to the given description by the rule that last ran,
+// if the docs of the given resource or data source are traced.
+func (g *Generator) docTraceStage(kind DocKind, rawname string, description *string) func(rule string) {
+	if !g.docTrace.tracing(kind, rawname) {
+		return func(string) {}
+	}
+	before := *description
+	return func(rule string) {
+		g.traceDoc(kind, rawname, rule, before, *description)
+		before = *description
+	}
+}
+
+// DebugDocs generates the docs of the resource or data source with the given Terraform name, and writes the upstream
+// markdown, each edit that the docs pipeline made to it as a diff, and the final docs to w.
+func (g *Generator) DebugDocs(rawname string, dataSource bool, w io.Writer) error {
+	kind, tok := ResourceDocs, ""
+	if dataSource {
+		kind = DataSourceDocs
+		if info := g.info.DataSources[rawname]; info != nil {
+			tok = string(info.Tok)
+		}
+	} else if info := g.info.Resources[rawname]; info != nil {
+		tok = string(info.Tok)
+	}
+	if tok == "" {
+		return errors.Errorf("no %s named %s is mapped", kindNoun(kind), rawname)
+	}
+
+	g.docTrace = &docTrace{kind: kind, target: rawname}
+	defer func() { g.docTrace = nil }()
+
+	pack, err := g.gatherPackage()
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather package metadata")
+	}
+	spec, err := genPulumiSchema(pack, g.pkg, g.version, g.info)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate schema")
+	}
+	description := func(spec pschema.PackageSpec) string {
+		if dataSource {
+			return spec.Functions[tok].Description
+		}
+		return spec.Resources[tok].Description
+	}
+
+	// The schema may add to the docs, and examples are converted last.
+	last := g.docTrace.source
+	if n := len(g.docTrace.steps); n > 0 {
+		last = g.docTrace.steps[n-1].After
+	}
+	final := description(spec)
+	g.traceDoc(kind, rawname, "generate schema", last, final)
+	if !g.skipExamples {
+		if g.providerShim.schema, err = json.Marshal(spec); err != nil {
+			return errors.Wrapf(err, "failed to marshal intermediate schema")
+		}
+		converted := description(g.convertExamplesInSchema(spec))
+		g.traceDoc(kind, rawname, "convert examples", final, converted)
+		final = converted
+	}
+
+	return writeDocTrace(w, g.docTrace, final)
+}
+
+// writeDocTrace writes the upstream markdown, the edits and the final docs of the given trace to w.
+func writeDocTrace(w io.Writer, trace *docTrace, final string) error {
+	source := trace.sourceFile
+	switch {
+	case trace.source == "":
+		source = "no upstream docs were found"
+	case source == "":
+		source = "DocInfo.Markdown"
+	}
+	if _, err := fmt.Fprintf(w, "=== Upstream source (%s)\n\n%s\n\n", source, trace.source); err != nil {
+		return err
+	}
+	for i, step := range trace.steps {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(step.Before),
+			B:        difflib.SplitLines(step.After),
+			FromFile: "before",
+			ToFile:   "after",
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "=== %d. %s\n\n%s\n", i+1, step.Rule, diff); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "=== Final output\n\n%s\n", final)
+	return err
+}
+
+func kindNoun(kind DocKind) string {
+	if kind == DataSourceDocs {
+		return "data source"
+	}
+	return "resource"
+}
+
+// newDebugDocsCmd creates the `debug-docs` subcommand, which explains how the docs of one resource or data source
+// were generated, using the given function to run a generator with the settings of the root command.
+func newDebugDocsCmd(run func(lang Language, generate func(g *Generator) error) error) *cobra.Command {
+	var dataSource bool
+	cmd := &cobra.Command{
+		Use:   "debug-docs <resource>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Print each edit that the docs pipeline makes to the docs of a resource or data source",
+		Long: "Print each edit that the docs pipeline makes to the docs of a resource or data source.\n" +
+			"\n" +
+			"The docs of the resource with the given Terraform name, or of the data source with --data-source,\n" +
+			"are generated as they would be for the schema. The upstream markdown is printed, followed by a\n" +
+			"diff for every edit rule that changed the docs, in the order that the rules were applied, and the\n" +
+			"final docs with converted examples. Nothing is written to the --out directory.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			return run(Schema, func(g *Generator) error {
+				return g.DebugDocs(args[0], dataSource, os.Stdout)
+			})
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVar(
+		&dataSource, "data-source", false, "Debug the docs of the data source with the given name")
+
+	return cmd
+}
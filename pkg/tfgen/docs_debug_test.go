@@ -0,0 +1,95 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestDebugDocs(t *testing.T) {
+	markdown := "---\nsubcategory: \"Storage\"\n---\n\n# test_bucket\n\n" +
+		"Manages a bucket. See [the guide](#guide).\n\n" +
+		"~> **NOTE:** Buckets are private by default.\n\n" +
+		"## Argument Reference\n\n* `name` - (Optional) The name of the bucket.\n"
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_bucket": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Optional: true},
+				}},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_bucket": {
+				Tok: "test:index/bucket:Bucket",
+				Docs: &tfbridge.DocInfo{
+					Markdown:       []byte(markdown),
+					AppendMarkdown: "Buckets are billed by the hour.",
+				},
+			},
+		},
+	}
+	g, err := NewGenerator(GeneratorOptions{
+		Package:      "test",
+		Version:      "0.0.1",
+		Language:     Schema,
+		ProviderInfo: info,
+		Root:         afero.NewMemMapFs(),
+		Sink:         diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		SkipExamples: true,
+	})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, g.DebugDocs("test_bucket", false, &out))
+	assert.Nil(t, g.docTrace)
+
+	// The source, each edit that changed the docs and the final docs are printed in order.
+	text := out.String()
+	assert.Contains(t, text, "=== Upstream source (DocInfo.Markdown)\n\n---\nsubcategory")
+	var last int
+	for _, section := range []string{
+		"=== 1. split sections\n",
+		"-## Argument Reference\n",
+		"=== 2. description: convert notes\n",
+		"-~> **NOTE:** Buckets are private by default.\n+> **NOTE:** Buckets are private by default.\n",
+		"=== 3. description: rewrite local links\n",
+		"-Manages a bucket. See [the guide](#guide).\n+Manages a bucket. See the guide.\n",
+		"=== 4. add doc notes\n",
+		"+Buckets are billed by the hour.\n",
+		"=== Final output\n\nManages a bucket. See the guide.\n",
+	} {
+		i := bytes.Index(out.Bytes()[last:], []byte(section))
+		if assert.GreaterOrEqual(t, i, 0, "missing %q", section) {
+			last += i + len(section)
+		}
+	}
+
+	// Only mapped resources and data sources can be debugged.
+	assert.Error(t, g.DebugDocs("test_bucket", true, &out))
+	assert.Error(t, g.DebugDocs("test_missing", false, &out))
+}
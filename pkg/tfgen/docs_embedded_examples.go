@@ -0,0 +1,115 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// isTerraformFence returns true if the given line opens a fenced code block of Terraform configuration.
+func isTerraformFence(line string) bool {
+	if !strings.HasPrefix(line, "```") {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(line[3:])) {
+	case "hcl", "terraform", "tf":
+		return true
+	}
+	return false
+}
+
+// splitEmbeddedExamples removes the fenced code blocks of Terraform configuration from a description taken from the
+// upstream schema, as providers that have no docs files often embed their examples there. It returns the rest of the
+// description along with the removed code blocks, fences included.
+func splitEmbeddedExamples(description string) (string, []string) {
+	if !strings.Contains(description, "```") {
+		return description, nil
+	}
+
+	var text, examples []string
+	lines := strings.Split(description, "\n")
+	for i := 0; i < len(lines); i++ {
+		if !isTerraformFence(strings.TrimSpace(lines[i])) {
+			text = append(text, lines[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), "```") {
+			end++
+		}
+		if end == len(lines) {
+			// An unterminated code block is left as-is.
+			text = append(text, lines[i:]...)
+			break
+		}
+
+		examples = append(examples, "```hcl\n"+strings.Join(lines[i+1:end], "\n")+"\n```")
+		i = end
+	}
+	if len(examples) == 0 {
+		return description, nil
+	}
+	return strings.TrimSpace(strings.Join(text, "\n")), examples
+}
+
+// embeddedExampleSubsection renders the given code blocks as an example subsection with the given title.
+func embeddedExampleSubsection(title string, blocks []string) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("\n\n### %s\n", title))
+	for _, block := range blocks {
+		result.WriteString(fmt.Sprintf("\n%s\n", block))
+	}
+	return result.String()
+}
+
+// embeddedPropertyExamples renders the code blocks embedded in the upstream descriptions of the given properties as
+// example subsections, one per property.
+func embeddedPropertyExamples(schemas shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) string {
+	var result strings.Builder
+	for _, key := range stableSchemas(schemas) {
+		sch, info := schemas.Get(key), infos[key]
+		if sch.Removed() != "" || (info != nil && info.Omit) {
+			continue
+		}
+		if _, blocks := splitEmbeddedExamples(sch.Description()); len(blocks) > 0 {
+			title := fmt.Sprintf("Example for `%s`", propertyName(key, sch, info))
+			result.WriteString(embeddedExampleSubsection(title, blocks))
+		}
+	}
+	return result.String()
+}
+
+// addEmbeddedExamples moves the examples embedded in the upstream descriptions of an entity and its properties into
+// the Example Usage section of the entity's description, so that they are converted like any other example. The
+// entity's upstream description is only consulted if it is being used as the description and does not already lay out
+// its examples in an Example Usage section.
+func addEmbeddedExamples(description string, fromUpstream bool, schemas shim.SchemaMap,
+	infos map[string]*tfbridge.SchemaInfo) string {
+
+	if fromUpstream && !strings.Contains(description, "## Example Usage") {
+		var blocks []string
+		description, blocks = splitEmbeddedExamples(description)
+		description = addExampleSubsections(description, embeddedExampleSubsection("Example", blocks))
+	}
+	return addExampleSubsections(description, embeddedPropertyExamples(schemas, infos))
+}
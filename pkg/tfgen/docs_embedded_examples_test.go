@@ -0,0 +1,65 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestSplitEmbeddedExamples(t *testing.T) {
+	text, examples := splitEmbeddedExamples("Manages a widget.\n\n```terraform\nresource \"test_widget\" \"w\" {}\n" +
+		"```\n\nSee also:\n\n```json\n{}\n```\n")
+	assert.Equal(t, "Manages a widget.\n\n\nSee also:\n\n```json\n{}\n```", text)
+	assert.Equal(t, []string{"```hcl\nresource \"test_widget\" \"w\" {}\n```"}, examples)
+
+	text, examples = splitEmbeddedExamples("No examples.\n\n```json\n{}\n```\n")
+	assert.Equal(t, "No examples.\n\n```json\n{}\n```\n", text)
+	assert.Empty(t, examples)
+
+	text, examples = splitEmbeddedExamples("Unterminated.\n```hcl\nresource \"test_widget\" \"w\" {}")
+	assert.Equal(t, "Unterminated.\n```hcl\nresource \"test_widget\" \"w\" {}", text)
+	assert.Empty(t, examples)
+}
+
+func TestAddEmbeddedExamples(t *testing.T) {
+	schemas := shimv2.NewSchemaMap(map[string]*schemav2.Schema{
+		"rule_set": {
+			Type:     schemav2.TypeString,
+			Optional: true,
+			Description: "The rules.\n```hcl\nresource \"test_widget\" \"w\" {\n  rule_set = \"strict\"\n}\n" +
+				"```",
+		},
+		"name": {Type: schemav2.TypeString, Optional: true, Description: "The name."},
+	})
+
+	description := addEmbeddedExamples("Manages a widget.\n```tf\nresource \"test_widget\" \"w\" {}\n```",
+		true, schemas, nil)
+	assert.Equal(t, "Manages a widget.\n\n## Example Usage\n\n"+
+		"### Example\n\n```hcl\nresource \"test_widget\" \"w\" {}\n```\n\n"+
+		"### Example for `ruleSet`\n\n```hcl\nresource \"test_widget\" \"w\" {\n  rule_set = \"strict\"\n}\n```\n",
+		description)
+
+	// Descriptions from docs files are left alone, but still get the examples embedded in their properties.
+	description = addEmbeddedExamples("Manages a widget.\n\n## Example Usage\n\n```hcl\n```\n\n## Import\n\nNo.",
+		false, schemas, nil)
+	assert.Equal(t, "Manages a widget.\n\n## Example Usage\n\n```hcl\n```\n\n"+
+		"### Example for `ruleSet`\n\n```hcl\nresource \"test_widget\" \"w\" {\n  rule_set = \"strict\"\n}\n```\n"+
+		"\n## Import\n\nNo.", description)
+}
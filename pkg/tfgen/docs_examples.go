@@ -0,0 +1,171 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// curatedExampleFences maps the extensions of curated example files to the language of their fenced code blocks.
+// HCL examples are converted like upstream examples; examples written in a Pulumi language are used as-is.
+var curatedExampleFences = map[string]string{
+	".tf": "hcl",
+	".ts": "typescript",
+	".py": "python",
+	".go": "go",
+	".cs": "csharp",
+}
+
+// pulumiExampleLanguage returns the Pulumi language of a fenced code block's info string, or "" if the code block is
+// not written in a Pulumi language.
+func pulumiExampleLanguage(fence string) string {
+	switch fence := strings.TrimSpace(fence); fence {
+	case "typescript", "python", "go", "csharp":
+		return fence
+	}
+	return ""
+}
+
+// otherCodeBlockLanguage returns the language of a fenced code block's info string if the code block is written in
+// neither Terraform nor a Pulumi language, e.g. "sh" or "json", or "" otherwise. Code blocks without a language are
+// taken to be Terraform configuration, as upstream docs often leave their examples untagged.
+func otherCodeBlockLanguage(fence string) string {
+	lang := strings.TrimSpace(fence)
+	if lang == "" || isTerraformFence("```"+lang) || pulumiExampleLanguage(lang) != "" {
+		return ""
+	}
+	return lang
+}
+
+// includesExampleLanguage returns true if examples written in the given Pulumi language belong in the docs generated
+// for the target language.
+func (l Language) includesExampleLanguage(lang string) bool {
+	switch l {
+	case NodeJS:
+		return lang == "typescript"
+	case Python:
+		return lang == "python"
+	case Golang:
+		return lang == "go"
+	case CSharp:
+		return lang == "csharp"
+	case Schema:
+		return true
+	}
+	return false
+}
+
+// curatedExamplesDir returns the directory that holds the curated examples for the given token, e.g.
+// examples/aws/s3/bucket/Bucket for aws:s3/bucket:Bucket.
+func curatedExamplesDir(root, token string) string {
+	return filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(token, ":", "/")))
+}
+
+// curatedExampleTitle derives an example title from a file name, e.g. basic-usage.tf becomes "Basic Usage".
+func curatedExampleTitle(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// curatedExamples renders the hand-written examples for the given token from the provider's ExamplesDir as markdown
+// subsections, one per file name, each containing a code block for every file with that name. It returns "" if the
+// token has no curated examples.
+func (g *Generator) curatedExamples(token string) (string, error) {
+	if g.info.ExamplesDir == "" || token == "" {
+		return "", nil
+	}
+
+	dir := curatedExamplesDir(g.info.ExamplesDir, token)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "reading curated examples for %s", token)
+	}
+
+	// Group the files by name so that the same example written in several languages forms a single subsection.
+	examples := map[string][]string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if _, ok := curatedExampleFences[ext]; entry.IsDir() || !ok {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ext)
+		examples[name] = append(examples[name], entry.Name())
+	}
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result strings.Builder
+	for _, name := range names {
+		result.WriteString(fmt.Sprintf("\n\n### %s\n", curatedExampleTitle(name)))
+		files := examples[name]
+		sort.Strings(files)
+		for _, file := range files {
+			bytes, err := ioutil.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				return "", errors.Wrapf(err, "reading curated example %s for %s", file, token)
+			}
+			fence := curatedExampleFences[filepath.Ext(file)]
+			result.WriteString(fmt.Sprintf("\n```%s\n%s\n```\n", fence, strings.TrimSpace(string(bytes))))
+		}
+	}
+	return result.String(), nil
+}
+
+// addCuratedExamples merges the hand-written examples for the given token into its description, appending them to the
+// description's Example Usage section, which is created if necessary.
+func (g *Generator) addCuratedExamples(description, token string) (string, error) {
+	examples, err := g.curatedExamples(token)
+	if err != nil || examples == "" {
+		return description, err
+	}
+	return addExampleSubsections(description, examples), nil
+}
+
+// addExampleSubsections appends the given markdown example subsections to the description's Example Usage section,
+// which is created if necessary.
+func addExampleSubsections(description, examples string) string {
+	if examples == "" {
+		return description
+	}
+
+	const header = "## Example Usage"
+	start := strings.Index(description, header)
+	if start == -1 {
+		return strings.TrimRight(description, "\n") + "\n\n" + header + examples
+	}
+
+	// Insert the examples at the end of the existing section, i.e. before the next top-level section, if any.
+	end := len(description)
+	if next := strings.Index(description[start+len(header):], "\n## "); next != -1 {
+		end = start + len(header) + next
+	}
+	return strings.TrimRight(description[:end], "\n") + examples + description[end:]
+}
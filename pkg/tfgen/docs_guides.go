@@ -0,0 +1,227 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// guideExtensions are the extensions of upstream guide files, longest first so that the whole extension is removed
+// from a guide's name.
+var guideExtensions = []string{".html.markdown", ".html.md", ".markdown", ".md"}
+
+// getGuidesPath returns the directory that holds the upstream provider's guides, or "" if it has none.
+func getGuidesPath(repo string) string {
+	for _, dir := range []string{
+		filepath.Join(repo, "docs", "guides"),
+		filepath.Join(repo, "website", "docs", "guides"),
+	} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// guideName returns the name of the guide in the given file, and true, if the file is a guide.
+func guideName(fileName string) (string, bool) {
+	for _, ext := range guideExtensions {
+		if strings.HasSuffix(fileName, ext) {
+			return strings.TrimSuffix(fileName, ext), true
+		}
+	}
+	return "", false
+}
+
+// genGuides converts the upstream provider's guides, returning the converted guides keyed by their output path. A
+// provider whose upstream repository cannot be found or has no guides produces no guides.
+func (g *Generator) genGuides() (map[string][]byte, error) {
+	if g.skipDocs {
+		return nil, nil
+	}
+
+	repo, err := getRepoPath(g.info.GetGitHubHost(), g.info.GetGitHubOrg(), g.info.Name,
+		g.info.GetProviderModuleVersion())
+	if err != nil {
+		g.debug(fmt.Sprintf("not generating guides: %v", err))
+		return nil, nil
+	}
+	dir := getGuidesPath(repo)
+	if dir == "" {
+		return nil, nil
+	}
+	return g.convertGuides(repo, dir)
+}
+
+// convertGuides converts each guide in the given directory of the upstream repository, repo. The examples embedded in
+// the guides are converted to Pulumi and counted by the coverage tracker; examples that cannot be converted are kept
+// as HCL. The images that the guides refer to are copied along with them.
+func (g *Generator) convertGuides(repo, dir string) (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading guides: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		name, ok := guideName(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		markdown, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading guide %v: %w", name, err)
+		}
+		guide, err := g.convertGuide(name, g.rewriteDocAssets("guide "+name, string(markdown), repo, dir))
+		if err != nil {
+			return nil, fmt.Errorf("converting guide %v: %w", name, err)
+		}
+		files[path.Join("docs", "guides", name+".md")] = guide
+	}
+	return files, nil
+}
+
+// convertGuide converts a single upstream guide. The guide's front matter is replaced with the front matter that
+// Pulumi docs expect, its links are rewritten, and its HCL examples are converted.
+func (g *Generator) convertGuide(name, markdown string) ([]byte, error) {
+	markdown, err := g.expandDocIncludes(strings.ReplaceAll(markdown, "\r\n", "\n"))
+	if err != nil {
+		return nil, err
+	}
+	frontMatter, body := splitGuideFrontMatter(markdown)
+
+	title := frontMatter["page_title"]
+	if title == "" {
+		title = guideTitle(body, name)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(fmt.Sprintf("---\ntitle: %q\n", title))
+	if desc := frontMatter["description"]; desc != "" {
+		out.WriteString(fmt.Sprintf("meta_desc: %q\n", desc))
+	}
+	out.WriteString("---\n\n")
+	out.WriteString(g.convertGuideExamples(name, g.rewriteDocLinks(body)))
+	if b := out.Bytes(); b[len(b)-1] != '\n' {
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// splitGuideFrontMatter splits the YAML front matter from the given guide, returning its top-level fields and the
+// rest of the guide. Upstream front matter only uses simple fields, so only plain, quoted, and block scalars are
+// understood; the lines of a block scalar are joined with spaces.
+func splitGuideFrontMatter(markdown string) (map[string]string, string) {
+	fields := map[string]string{}
+	if !strings.HasPrefix(markdown, "---\n") {
+		return fields, markdown
+	}
+	end := strings.Index(markdown[4:], "\n---")
+	if end == -1 {
+		return fields, markdown
+	}
+	header, body := markdown[4:4+end], markdown[4+end+4:]
+
+	lines := strings.Split(header, "\n")
+	for i := 0; i < len(lines); i++ {
+		parts := strings.SplitN(lines[i], ":", 2)
+		if len(parts) != 2 || strings.HasPrefix(lines[i], " ") || strings.HasPrefix(lines[i], "\t") {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch value {
+		case "|", "|-", ">", ">-":
+			var block []string
+			for i+1 < len(lines) && (strings.HasPrefix(lines[i+1], " ") || strings.HasPrefix(lines[i+1], "\t")) {
+				i++
+				block = append(block, strings.TrimSpace(lines[i]))
+			}
+			value = strings.Join(block, " ")
+		default:
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+		}
+		fields[strings.TrimSpace(parts[0])] = value
+	}
+	return fields, strings.TrimLeft(body, "\n")
+}
+
+// guideTitle returns the text of the guide's first H1, or its name if it has none.
+func guideTitle(body, name string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+	}
+	return name
+}
+
+// convertGuideExamples converts the HCL code blocks in the given guide. Unlike resource docs, a guide is kept whole
+// when one of its examples fails to convert: the example is left in HCL.
+func (g *Generator) convertGuideExamples(name, body string) string {
+	examplePath := "#/guides/" + name
+
+	var out []string
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		lang := strings.TrimSpace(strings.TrimPrefix(line, "```"))
+		if !strings.HasPrefix(line, "```") || (lang != "hcl" && lang != "terraform" && lang != "tf") {
+			out = append(out, line)
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(lines[end], "```") {
+			end++
+		}
+		if end == len(lines) {
+			// An unterminated code block is left as-is.
+			out = append(out, lines[i:]...)
+			break
+		}
+
+		if !g.skipExamples && g.language.shouldConvertExamples() {
+			hcl := strings.Join(lines[i+1:end], "\n")
+			g.coverageTracker.foundExample(examplePath, hcl)
+			codeBlock, stderr, err := g.convertHCL(hcl, examplePath)
+			if err == nil {
+				out = append(out, "{{% example %}}", codeBlock, "{{% /example %}}")
+				hclBlocksSucceeded++
+				i = end
+				continue
+			}
+			if !errors.Is(err, errExampleExcluded) {
+				hclFailures[stderr] = true
+				hclBlocksFailed++
+			}
+		}
+		out = append(out, lines[i:end+1]...)
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
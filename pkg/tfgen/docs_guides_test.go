@@ -0,0 +1,107 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+func TestConvertGuides(t *testing.T) {
+	repo := t.TempDir()
+	dir := filepath.Join(repo, "website", "docs", "guides")
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	assert.Equal(t, dir, getGuidesPath(repo))
+
+	writeGuide := func(name, contents string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600))
+	}
+	writeGuide("getting-started.html.markdown", "---\n"+
+		"subcategory: \"\"\n"+
+		"layout: \"test\"\n"+
+		"page_title: \"Getting Started with the Test Provider\"\n"+
+		"description: |-\n"+
+		"  Getting started.\n"+
+		"---\n"+
+		"\n"+
+		"# Getting Started\n"+
+		"\n"+
+		"See the [upgrade guide](https://example.com/upgrade).\n"+
+		"\n"+
+		"```hcl\n"+
+		"output \"greeting\" {\n"+
+		"  value = \"hello\"\n"+
+		"}\n"+
+		"```\n"+
+		"\n"+
+		"```shell\n"+
+		"$ terraform init\n"+
+		"```\n")
+	writeGuide("broken.md", "# Broken Example\n\n```hcl\noutput {\n```\n")
+	writeGuide("notes.txt", "not a guide")
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "images"), 0700))
+
+	coverage := newCoverageTracker("test", "0.0.1")
+	g, err := NewGenerator(GeneratorOptions{
+		Package:         "test",
+		Version:         "0.0.1",
+		Language:        NodeJS,
+		Sink:            diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		CoverageTracker: coverage,
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name:         "test",
+			DocLinkRules: []tfbridge.DocLinkRule{{Pattern: `https://example\.com/upgrade`, Replacement: "/upgrade/"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	files, err := g.convertGuides(repo, dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	guide := string(files["docs/guides/getting-started.md"])
+	assert.Contains(t, guide, "---\ntitle: \"Getting Started with the Test Provider\"\n"+
+		"meta_desc: \"Getting started.\"\n---\n\n# Getting Started\n")
+	assert.Contains(t, guide, "See the [upgrade guide](/upgrade/).")
+	assert.Contains(t, guide, "{{% example %}}\n```typescript\n")
+	assert.NotContains(t, guide, "```hcl")
+	assert.Contains(t, guide, "```shell\n$ terraform init\n```\n")
+
+	// Examples that fail to convert are kept in HCL.
+	assert.Equal(t, "---\ntitle: \"Broken Example\"\n---\n\n# Broken Example\n\n```hcl\noutput {\n```\n",
+		string(files["docs/guides/broken.md"]))
+
+	assert.Contains(t, coverage.EncounteredExamples, "#/guides/getting-started")
+	assert.Contains(t, coverage.EncounteredExamples, "#/guides/broken")
+}
+
+func TestSplitGuideFrontMatter(t *testing.T) {
+	fields, body := splitGuideFrontMatter("---\npage_title: 'Title: Sub'\ndescription: |-\n  Two\n  lines\n" +
+		"layout: plain\n---\n\nBody\n")
+	assert.Equal(t, map[string]string{"page_title": "Title: Sub", "description": "Two lines", "layout": "plain"}, fields)
+	assert.Equal(t, "Body\n", body)
+
+	fields, body = splitGuideFrontMatter("# No front matter\n")
+	assert.Empty(t, fields)
+	assert.Equal(t, "# No front matter\n", body)
+}
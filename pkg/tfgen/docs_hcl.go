@@ -15,12 +15,35 @@
 package tfgen
 
 import (
+	"html"
+	"strings"
+
 	"github.com/hashicorp/hcl/hcl/scanner"
 	"github.com/hashicorp/hcl/hcl/token"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
+// hclNormalizer replaces characters that commonly leak into upstream examples from rendered documentation, such as
+// smart quotes and non-breaking spaces, with their plain equivalents, and removes invisible zero-width characters.
+var hclNormalizer = strings.NewReplacer(
+	"\u201c", `"`, "\u201d", `"`, "\u201e", `"`, "\u2033", `"`,
+	"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u2032", "'",
+	"\u00a0", " ", "\u2002", " ", "\u2003", " ", "\u2009", " ",
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "",
+)
+
+// normalizeHcl unescapes HTML entities and replaces typographic and invisible characters in the given HCL source
+// text, all of which confuse the HCL parser. The second result is true if the text was changed.
+func normalizeHcl(s string) (string, bool) {
+	normalized := s
+	if strings.Contains(normalized, "&") {
+		normalized = html.UnescapeString(normalized)
+	}
+	normalized = hclNormalizer.Replace(normalized)
+	return normalized, normalized != s
+}
+
 // fixHcl attempts to fix certain simple syntactical errors in a particular piece of HCL source text.
 //
 // For reference, here is the HCL grammar in ~EBNF:
@@ -0,0 +1,217 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// upstreamDocLinkRegexp matches links to the upstream docs of a resource or data source, on the Terraform registry,
+// on the old Terraform docs site, or relative to the docs of another resource or data source.
+var upstreamDocLinkRegexp = regexp.MustCompile(`^(?:` +
+	`https://registry\.terraform\.io/providers/[^/]+/(?P<provider>[^/]+)/[^/]+/docs|` +
+	`(?:https://www\.terraform\.io)?/docs/providers/(?P<provider>[^/]+)|` +
+	`\.\.)` +
+	`/(?P<kind>r|d|resources|data-sources)/(?P<name>[a-z0-9_]+)(?:\.html(?:\.markdown)?|\.md)?(?:#.*)?$`)
+
+// docLinkRule is a compiled tfbridge.DocLinkRule.
+type docLinkRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// compileDocLinkRules compiles the given rules, anchoring their patterns so that they match whole link targets.
+func compileDocLinkRules(rules []tfbridge.DocLinkRule) ([]docLinkRule, error) {
+	compiled := make([]docLinkRule, len(rules))
+	for i, rule := range rules {
+		pattern, err := regexp.Compile("^(?:" + rule.Pattern + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid doc link rule %q", rule.Pattern)
+		}
+		compiled[i] = docLinkRule{pattern: pattern, replacement: rule.Replacement}
+	}
+	return compiled, nil
+}
+
+// rewriteDocLinks rewrites the targets of the markdown links in the given text to which a rule applies.
+func (g *Generator) rewriteDocLinks(text string) string {
+	return markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+		parts := markdownLink.FindStringSubmatch(link)
+		target, ok := g.rewriteDocLink(parts[2])
+		switch {
+		case !ok:
+			return link
+		case target == "":
+			return parts[1]
+		default:
+			return fmt.Sprintf("[%s](%s)", parts[1], target)
+		}
+	})
+}
+
+// rewriteDocLink returns the target that a link in upstream docs should be rewritten to, and true, if a rule applies
+// to the link. An empty target means the link should be removed.
+func (g *Generator) rewriteDocLink(url string) (string, bool) {
+	for _, rule := range g.docLinkRules {
+		if match := rule.pattern.FindStringSubmatchIndex(url); match != nil {
+			return string(rule.pattern.ExpandString(nil, rule.replacement, url, match)), true
+		}
+	}
+
+	match := upstreamDocLinkRegexp.FindStringSubmatch(url)
+	if match == nil {
+		return "", false
+	}
+	var provider, kind, name string
+	for i, group := range upstreamDocLinkRegexp.SubexpNames() {
+		switch {
+		case group == "provider" && match[i] != "":
+			provider = match[i]
+		case group == "kind":
+			kind = match[i]
+		case group == "name":
+			name = match[i]
+		}
+	}
+	if provider != "" && provider != g.info.Name {
+		return "", false
+	}
+
+	tfName := name
+	if prefix := g.info.GetResourcePrefix() + "_"; !strings.HasPrefix(tfName, prefix) {
+		tfName = prefix + tfName
+	}
+	var token string
+	switch kind {
+	case "r", "resources":
+		if info, ok := g.info.Resources[tfName]; ok && info.Tok != "" {
+			token = string(info.Tok)
+		}
+	default:
+		if info, ok := g.info.DataSources[tfName]; ok && info.Tok != "" {
+			token = string(info.Tok)
+		}
+	}
+	if token == "" {
+		return "", false
+	}
+	return registryDocsPath(token), true
+}
+
+// registryDocsPath returns the path of the Pulumi registry docs for the resource or function with the given token,
+// e.g. /registry/packages/aws/api-docs/s3/bucket/ for aws:s3/bucket:Bucket.
+func registryDocsPath(token string) string {
+	pkg, mod, name := splitToken(token)
+	path := fmt.Sprintf("/registry/packages/%s/api-docs/", pkg)
+	if mod != "index" {
+		path += strings.ToLower(mod) + "/"
+	}
+	return path + strings.ToLower(name) + "/"
+}
+
+// brokenDocLink is a link in the generated docs to a Pulumi registry path of the package that the package does not
+// generate.
+type brokenDocLink struct {
+	Location string // the token, and property if any, whose docs contain the link
+	Target   string // the link target
+}
+
+// findBrokenDocLinks checks that links in the given schema's docs to the package's Pulumi registry docs refer to
+// resources, functions or modules that the schema defines.
+func findBrokenDocLinks(spec pschema.PackageSpec) []brokenDocLink {
+	prefix := fmt.Sprintf("/registry/packages/%s/api-docs/", spec.Name)
+	valid := map[string]bool{prefix: true}
+	addToken := func(token string) {
+		path := registryDocsPath(token)
+		valid[path] = true
+		valid[path[:strings.LastIndex(strings.TrimSuffix(path, "/"), "/")+1]] = true
+	}
+	for token := range spec.Resources {
+		addToken(token)
+	}
+	for token := range spec.Functions {
+		addToken(token)
+	}
+
+	var broken []brokenDocLink
+	check := func(location, description string) {
+		for _, match := range markdownLink.FindAllStringSubmatch(description, -1) {
+			target := match[2]
+			if i := strings.IndexAny(target, "#?"); i != -1 {
+				target = target[:i]
+			}
+			if !strings.HasPrefix(target, prefix) {
+				continue
+			}
+			if !strings.HasSuffix(target, "/") {
+				target += "/"
+			}
+			if !valid[target] {
+				broken = append(broken, brokenDocLink{Location: location, Target: match[2]})
+			}
+		}
+	}
+	checkProperties := func(token string, properties map[string]pschema.PropertySpec) {
+		for name, prop := range properties {
+			check(token+"."+name, prop.Description)
+		}
+	}
+
+	check("description", spec.Description)
+	checkProperties("config", spec.Config.Variables)
+	check(spec.Name+":index:Provider", spec.Provider.Description)
+	checkProperties(spec.Name+":index:Provider", spec.Provider.InputProperties)
+	for token, res := range spec.Resources {
+		check(token, res.Description)
+		checkProperties(token, res.InputProperties)
+		checkProperties(token, res.Properties)
+	}
+	for token, fun := range spec.Functions {
+		check(token, fun.Description)
+		if fun.Inputs != nil {
+			checkProperties(token, fun.Inputs.Properties)
+		}
+		if fun.Outputs != nil {
+			checkProperties(token, fun.Outputs.Properties)
+		}
+	}
+	for token, typ := range spec.Types {
+		check(token, typ.Description)
+		checkProperties(token, typ.Properties)
+	}
+
+	// Inputs and outputs share docs, so the same link may be found more than once.
+	sort.Slice(broken, func(i, j int) bool {
+		if broken[i].Location != broken[j].Location {
+			return broken[i].Location < broken[j].Location
+		}
+		return broken[i].Target < broken[j].Target
+	})
+	deduped := broken[:0]
+	for i, link := range broken {
+		if i == 0 || link != broken[i-1] {
+			deduped = append(deduped, link)
+		}
+	}
+	return deduped
+}
@@ -0,0 +1,196 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestRewriteDocLinks(t *testing.T) {
+	g, err := NewGenerator(GeneratorOptions{
+		Package:  "google",
+		Version:  "0.1.2",
+		Language: "nodejs",
+		Sink:     diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name: "google",
+			Resources: map[string]*tfbridge.ResourceInfo{
+				"google_container_node_pool": {Tok: "google:container/nodePool:NodePool"},
+			},
+			DataSources: map[string]*tfbridge.DataSourceInfo{
+				"google_client_config": {Tok: "google:organizations/getClientConfig:getClientConfig"},
+			},
+			DocLinkRules: []tfbridge.DocLinkRule{
+				{Pattern: `https://registry\.terraform\.io/providers/hashicorp/google/latest/docs/guides/(.*)`,
+					Replacement: "/registry/packages/google/installation-configuration/"},
+				{Pattern: `https://example\.com/.*`},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{
+			"See [node pools](https://registry.terraform.io/providers/hashicorp/google/latest/docs/resources/container_node_pool).",
+			"See [node pools](/registry/packages/google/api-docs/container/nodepool/).",
+		},
+		{
+			"See [node pools](/docs/providers/google/r/container_node_pool.html#name).",
+			"See [node pools](/registry/packages/google/api-docs/container/nodepool/).",
+		},
+		{
+			"See [node pools](../r/container_node_pool.html.markdown).",
+			"See [node pools](/registry/packages/google/api-docs/container/nodepool/).",
+		},
+		{
+			"See [client config](https://registry.terraform.io/providers/hashicorp/google/latest/docs/data-sources/client_config).",
+			"See [client config](/registry/packages/google/api-docs/organizations/getclientconfig/).",
+		},
+		{
+			"See [provider versions](https://registry.terraform.io/providers/hashicorp/google/latest/docs/guides/provider_versions).",
+			"See [provider versions](/registry/packages/google/installation-configuration/).",
+		},
+		{
+			"See [the example](https://example.com/foo).",
+			"See the example.",
+		},
+		{
+			// Links to the docs of other providers are left alone, so the text is elided.
+			"See [buckets](https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/s3_bucket).",
+			"",
+		},
+		{
+			"See [clusters](/docs/providers/google/r/container_cluster.html).",
+			"See [clusters](https://www.terraform.io/docs/providers/google/r/container_cluster.html).",
+		},
+	}
+	for _, test := range tests {
+		text, _ := cleanupText(g, nil, test.Input, nil)
+		assert.Equal(t, test.Expected, text)
+	}
+
+	_, err = compileDocLinkRules([]tfbridge.DocLinkRule{{Pattern: "("}})
+	assert.Error(t, err)
+}
+
+func TestLinkDocReferences(t *testing.T) {
+	g, err := NewGenerator(GeneratorOptions{
+		Package:  "google",
+		Version:  "0.1.2",
+		Language: "nodejs",
+		Sink:     diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name: "google",
+			P: shimv2.NewProvider(&schemav2.Provider{
+				ResourcesMap: map[string]*schemav2.Resource{
+					"google_container_node_pool": {},
+					"google_compute_network":     {},
+				},
+			}),
+			Resources: map[string]*tfbridge.ResourceInfo{
+				"google_container_node_pool": {Tok: "google:container/nodePool:NodePool"},
+			},
+			DataSources: map[string]*tfbridge.DataSourceInfo{
+				"google_client_config": {Tok: "google:organizations/getClientConfig:getClientConfig"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{
+			"The name of a `google_container_node_pool`.",
+			"The name of a [`google.container.NodePool`](/registry/packages/google/api-docs/container/nodepool/).",
+		},
+		{
+			"Defaults to google_client_config's project.",
+			"Defaults to [`google.organizations.getClientConfig`](/registry/packages/google/api-docs/organizations/" +
+				"getclientconfig/)'s project.",
+		},
+		{
+			// Only the first reference is linked.
+			"A google_container_node_pool, or the google_container_node_pool's parent.",
+			"A [`google.container.NodePool`](/registry/packages/google/api-docs/container/nodepool/), or the " +
+				"google_container_node_pool's parent.",
+		},
+		{
+			// References in links, parts of longer names and unmapped entities are not linked.
+			"See [google_container_node_pool](https://example.com), `google_container_node_pool.pool.id`, " +
+				"google_compute_network and google_project_id.",
+			"See [google.container.NodePool](https://example.com), `google_container_node_pool.pool.id`, " +
+				"googleComputeNetwork and google_project_id.",
+		},
+	}
+	for _, test := range tests {
+		text, _ := cleanupText(g, nil, g.linkDocReferences("google_widget", "name", test.Input), nil)
+		assert.Equal(t, test.Expected, text)
+	}
+
+	// References to upstream entities that are not mapped are reported.
+	assert.Equal(t, []unresolvedDocReference{
+		{Location: "google_widget.name", Name: "google_compute_network"},
+	}, g.unresolvedDocReferences())
+
+	// The docs of an entity do not link to itself.
+	text := g.linkDocReferences("google_container_node_pool", "name", "The google_container_node_pool's name.")
+	assert.Equal(t, "The google_container_node_pool's name.", text)
+}
+
+func TestFindBrokenDocLinks(t *testing.T) {
+	spec := pschema.PackageSpec{
+		Name: "test",
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "Use with a [gadget](/registry/packages/test/api-docs/gadgets/gadget/).",
+					Properties: map[string]pschema.PropertySpec{
+						"size": {Description: "See [sizes](/registry/packages/test/api-docs/getsizes/#size)."},
+					},
+				},
+				InputProperties: map[string]pschema.PropertySpec{
+					"size": {Description: "See [sizes](/registry/packages/test/api-docs/getsizes/#size)."},
+				},
+			},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getSizes:getSizes": {
+				Description: "Sizes of [widgets](/registry/packages/test/api-docs/widget/) and " +
+					"[other things](/registry/packages/other/api-docs/thing/).",
+			},
+		},
+	}
+	assert.Equal(t, []brokenDocLink{
+		{Location: "test:index/widget:Widget", Target: "/registry/packages/test/api-docs/gadgets/gadget/"},
+	}, findBrokenDocLinks(spec))
+
+	spec.Resources["test:gadgets/gadget:Gadget"] = pschema.ResourceSpec{}
+	assert.Empty(t, findBrokenDocLinks(spec))
+}
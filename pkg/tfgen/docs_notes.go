@@ -0,0 +1,70 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// addDocNotes adds the Pulumi-specific notes of the given DocInfo to the start and end of the given description.
+func (g *Generator) addDocNotes(rawname, description string, docinfo *tfbridge.DocInfo) (string, error) {
+	if docinfo == nil || (docinfo.PrependMarkdown == "" && docinfo.AppendMarkdown == "") {
+		return description, nil
+	}
+	prepend, err := g.formatDocNote(docinfo.PrependMarkdown)
+	if err != nil {
+		return "", errors.Wrapf(err, "formatting the docs to prepend to %s", rawname)
+	}
+	appendix, err := g.formatDocNote(docinfo.AppendMarkdown)
+	if err != nil {
+		return "", errors.Wrapf(err, "formatting the docs to append to %s", rawname)
+	}
+
+	var parts []string
+	for _, part := range []string{prepend, strings.TrimSpace(description), appendix} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// formatDocNote expands the includes in the given note, and rewrites its links and its references to resources, data
+// sources and properties as cleanupText does for upstream docs. Unlike upstream docs, notes that mention Terraform
+// are kept, and code blocks are left as they are.
+func (g *Generator) formatDocNote(note string) (string, error) {
+	note, err := g.expandDocIncludes(strings.TrimSpace(note))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	start := 0
+	for _, block := range codeBlocks.FindAllStringIndex(note, -1) {
+		b.WriteString(g.formatDocNoteText(note[start:block[0]]))
+		b.WriteString(note[block[0]:block[1]])
+		start = block[1]
+	}
+	b.WriteString(g.formatDocNoteText(note[start:]))
+	return b.String(), nil
+}
+
+func (g *Generator) formatDocNoteText(text string) string {
+	return fixupPropertyReferences(g.language, g.pkg, g.info, g.rewriteDocLinks(text))
+}
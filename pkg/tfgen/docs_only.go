@@ -0,0 +1,177 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/cobra"
+)
+
+// GenerateDocs regenerates only the documentation and examples of the existing schema at the given path. The docs are
+// rendered from the upstream provider's documentation with the current doc edit rules and copied onto the members of
+// the existing schema, whose structure is left untouched. The updated schema.json, along with the guides and localized
+// schemas, is written to the generator's root.
+func (g *Generator) GenerateDocs(schemaPath string) error {
+	if g.language != Schema {
+		return errors.Errorf("docs can only be regenerated for the %s, not %s", Schema, g.language)
+	}
+
+	spec, err := readPackageSpec(schemaPath)
+	if err != nil {
+		return err
+	}
+
+	// Render the docs as they would be generated for a full schema.
+	pack, err := g.gatherPackage()
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather package metadata")
+	}
+	docs, err := genPulumiSchema(pack, g.pkg, g.version, g.info)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render docs")
+	}
+	overlayDocs(&spec, docs)
+	g.coverageTracker.foundSchema(spec)
+
+	// Serialize the schema and attach it to the provider shim for example conversion.
+	g.providerShim.schema, err = json.Marshal(spec)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal intermediate schema")
+	}
+	if !g.skipExamples {
+		spec = g.convertExamplesInSchema(spec)
+	}
+
+	bytes, err := json.MarshalIndent(spec, "", "    ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal schema")
+	}
+	files := map[string][]byte{"schema.json": bytes}
+
+	localized, err := g.genLocalizedSchemas(spec)
+	if err != nil {
+		return errors.Wrapf(err, "failed to translate schema docs")
+	}
+	for f, contents := range localized {
+		files[f] = contents
+	}
+	guides, err := g.genGuides()
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate guides")
+	}
+	for f, contents := range guides {
+		files[f] = contents
+	}
+
+	for f, contents := range files {
+		if err := emitFile(g.root, f, contents); err != nil {
+			return errors.Wrapf(err, "emitting file %v", f)
+		}
+	}
+
+	printDocStats(g, g.printStats, g.printStats)
+	g.pluginHost.Close()
+
+	return nil
+}
+
+// overlayDocs copies the descriptions of the members of docs onto the members of spec that they correspond to. Members
+// that only exist in one of the schemas are left alone.
+func overlayDocs(spec *pschema.PackageSpec, docs pschema.PackageSpec) {
+	overlayPropertyDocs(spec.Config.Variables, docs.Config.Variables)
+	overlayResourceDocs(&spec.Provider, docs.Provider)
+
+	for tok, res := range spec.Resources {
+		if doc, ok := docs.Resources[tok]; ok {
+			overlayResourceDocs(&res, doc)
+			spec.Resources[tok] = res
+		}
+	}
+	for tok, fun := range spec.Functions {
+		doc, ok := docs.Functions[tok]
+		if !ok {
+			continue
+		}
+		fun.Description = doc.Description
+		if fun.Inputs != nil && doc.Inputs != nil {
+			overlayObjectDocs(fun.Inputs, *doc.Inputs)
+		}
+		if fun.Outputs != nil && doc.Outputs != nil {
+			overlayObjectDocs(fun.Outputs, *doc.Outputs)
+		}
+		spec.Functions[tok] = fun
+	}
+	for tok, typ := range spec.Types {
+		if doc, ok := docs.Types[tok]; ok {
+			overlayObjectDocs(&typ.ObjectTypeSpec, doc.ObjectTypeSpec)
+			spec.Types[tok] = typ
+		}
+	}
+}
+
+func overlayResourceDocs(spec *pschema.ResourceSpec, docs pschema.ResourceSpec) {
+	overlayObjectDocs(&spec.ObjectTypeSpec, docs.ObjectTypeSpec)
+	overlayPropertyDocs(spec.InputProperties, docs.InputProperties)
+	if spec.StateInputs != nil && docs.StateInputs != nil {
+		overlayObjectDocs(spec.StateInputs, *docs.StateInputs)
+	}
+}
+
+func overlayObjectDocs(spec *pschema.ObjectTypeSpec, docs pschema.ObjectTypeSpec) {
+	spec.Description = docs.Description
+	overlayPropertyDocs(spec.Properties, docs.Properties)
+}
+
+func overlayPropertyDocs(spec, docs map[string]pschema.PropertySpec) {
+	for name, prop := range spec {
+		if doc, ok := docs[name]; ok {
+			prop.Description = doc.Description
+			spec[name] = prop
+		}
+	}
+}
+
+// newDocsCmd creates the `docs` subcommand, which regenerates only the docs and examples of an existing schema using
+// the given function to run a generator with the settings of the root command.
+func newDocsCmd(run func(lang Language, generate func(g *Generator) error) error) *cobra.Command {
+	var schemaPath string
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Args:  cmdutil.NoArgs,
+		Short: "Regenerate only the docs and examples of an existing schema",
+		Long: "Regenerate only the docs and examples of an existing schema.\n" +
+			"\n" +
+			"The docs of the schema read from --schema are rendered again from the upstream provider's\n" +
+			"documentation, and their examples are converted again, without regenerating the rest of the\n" +
+			"schema. This is useful for quickly iterating on doc edit rules. The result is written to the\n" +
+			"--out directory, along with any guides.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			return run(Schema, func(g *Generator) error {
+				return g.GenerateDocs(schemaPath)
+			})
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&schemaPath, "schema", "", "The schema.json whose docs to regenerate")
+	contract.AssertNoError(cmd.MarkPersistentFlagRequired("schema"))
+
+	return cmd
+}
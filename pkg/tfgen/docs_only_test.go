@@ -0,0 +1,81 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlayDocs(t *testing.T) {
+	prop := func(description string) pschema.PropertySpec {
+		return pschema.PropertySpec{TypeSpec: pschema.TypeSpec{Type: "string"}, Description: description}
+	}
+
+	spec := pschema.PackageSpec{
+		Config: pschema.ConfigSpec{Variables: map[string]pschema.PropertySpec{"region": prop("old")}},
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/bucket:Bucket": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "old",
+					Properties:  map[string]pschema.PropertySpec{"name": prop("old")},
+				},
+				InputProperties: map[string]pschema.PropertySpec{"name": prop("old"), "extra": prop("kept")},
+			},
+			"test:index/legacy:Legacy": {ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "kept"}},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getBucket:getBucket": {
+				Description: "old",
+				Inputs:      &pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{"name": prop("old")}},
+			},
+		},
+	}
+	docs := pschema.PackageSpec{
+		Config: pschema.ConfigSpec{Variables: map[string]pschema.PropertySpec{"region": prop("new")}},
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/bucket:Bucket": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "new",
+					Properties:  map[string]pschema.PropertySpec{"name": prop("new")},
+				},
+				InputProperties: map[string]pschema.PropertySpec{"name": prop("new")},
+			},
+			"test:index/other:Other": {ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "ignored"}},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getBucket:getBucket": {
+				Description: "new",
+				Inputs:      &pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{"name": prop("new")}},
+			},
+		},
+	}
+
+	overlayDocs(&spec, docs)
+
+	assert.Equal(t, "new", spec.Config.Variables["region"].Description)
+	bucket := spec.Resources["test:index/bucket:Bucket"]
+	assert.Equal(t, "new", bucket.Description)
+	assert.Equal(t, "new", bucket.Properties["name"].Description)
+	assert.Equal(t, "new", bucket.InputProperties["name"].Description)
+	assert.Equal(t, "kept", bucket.InputProperties["extra"].Description)
+	assert.Equal(t, "string", bucket.InputProperties["name"].Type)
+	assert.Equal(t, "kept", spec.Resources["test:index/legacy:Legacy"].Description)
+	assert.NotContains(t, spec.Resources, "test:index/other:Other")
+	assert.Equal(t, "new", spec.Functions["test:index/getBucket:getBucket"].Description)
+	assert.Equal(t, "new", spec.Functions["test:index/getBucket:getBucket"].Inputs.Properties["name"].Description)
+}
@@ -0,0 +1,146 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// unresolvedDocReference is a reference in a property's docs to an upstream resource or data source that the provider
+// does not map, so that it could not be linked to the Pulumi registry docs.
+type unresolvedDocReference struct {
+	Location string // the upstream resource or data source, and property, whose docs contain the reference
+	Name     string // the Terraform name of the referenced resource or data source
+}
+
+// docReferenceRegexp returns the regular expression that matches references to the resources and data sources of
+// the provider with the given resource prefix, e.g. `aws_vpc` or aws_vpc, or nil if the provider has no prefix.
+func docReferenceRegexp(prefix string) *regexp.Regexp {
+	if prefix == "" {
+		return nil
+	}
+	return regexp.MustCompile("\x60?\\b" + regexp.QuoteMeta(prefix) + "_[a-z0-9_]*[a-z0-9]\\b\x60?")
+}
+
+// linkDocReferences links the first reference in the given property docs to each resource or data source that the
+// provider maps to the Pulumi registry docs of its resource or function. The link text is rewritten for each language
+// by fixupPropertyReferences. References in existing links, to the entity whose docs these are, and that are part of
+// longer names such as `aws_vpc.main.id` are left alone. References to upstream resources and data sources that the
+// provider does not map are reported.
+func (g *Generator) linkDocReferences(rawname, property, text string) string {
+	if g == nil {
+		return text
+	}
+	if g.docRefRegexp == nil {
+		g.docRefRegexp = docReferenceRegexp(g.info.GetResourcePrefix())
+	}
+	re := g.docRefRegexp
+	if re == nil {
+		return text
+	}
+
+	links := markdownLink.FindAllStringIndex(text, -1)
+	inLink := func(start int) bool {
+		for _, link := range links {
+			if link[0] <= start && start < link[1] {
+				return true
+			}
+		}
+		return false
+	}
+	linked := map[string]bool{}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		start, end := match[0], match[1]
+		ref := text[start:end]
+		name := strings.Trim(ref, "\x60")
+		if strings.HasPrefix(ref, "\x60") != strings.HasSuffix(ref, "\x60") {
+			continue
+		}
+		if start > 0 && strings.ContainsAny(text[start-1:start], "./$") ||
+			end+1 < len(text) && text[end] == '.' && isDocWordChar(text[end+1]) ||
+			name == rawname || linked[name] || inLink(start) {
+			continue
+		}
+
+		target, ok := g.docReferenceTarget(name)
+		if !ok {
+			if g.isUpstreamEntity(name) {
+				g.recordUnresolvedDocReference(rawname+"."+property, name)
+			}
+			continue
+		}
+		linked[name] = true
+		b.WriteString(text[last:start])
+		fmt.Fprintf(&b, "[\x60%s\x60](%s)", name, target)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// docReferenceTarget returns the registry docs path of the resource or data source with the given Terraform name,
+// and true, if the provider maps it.
+func (g *Generator) docReferenceTarget(name string) (string, bool) {
+	if info, ok := g.info.Resources[name]; ok && info.Tok != "" {
+		return registryDocsPath(string(info.Tok)), true
+	}
+	if info, ok := g.info.DataSources[name]; ok && info.Tok != "" {
+		return registryDocsPath(string(info.Tok)), true
+	}
+	return "", false
+}
+
+// isUpstreamEntity returns true if the upstream provider defines a resource or data source with the given name.
+func (g *Generator) isUpstreamEntity(name string) bool {
+	if g.info.P == nil {
+		return false
+	}
+	if _, ok := g.info.P.ResourcesMap().GetOk(name); ok {
+		return true
+	}
+	_, ok := g.info.P.DataSourcesMap().GetOk(name)
+	return ok
+}
+
+func (g *Generator) recordUnresolvedDocReference(location, name string) {
+	for _, ref := range g.unresolvedDocRefs {
+		if ref.Location == location && ref.Name == name {
+			return
+		}
+	}
+	g.unresolvedDocRefs = append(g.unresolvedDocRefs, unresolvedDocReference{Location: location, Name: name})
+}
+
+// unresolvedDocReferences returns the unresolved references that were found, in a stable order.
+func (g *Generator) unresolvedDocReferences() []unresolvedDocReference {
+	refs := append([]unresolvedDocReference(nil), g.unresolvedDocRefs...)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Location != refs[j].Location {
+			return refs[i].Location < refs[j].Location
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+func isDocWordChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
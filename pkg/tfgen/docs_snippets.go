@@ -0,0 +1,91 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// includeDirectiveRegexp matches `{{% include "name" %}}` directives in markdown.
+var includeDirectiveRegexp = regexp.MustCompile(`{{%\s*include\s+"([^"]+)"\s*%}}`)
+
+// docSnippet returns the shared markdown snippet with the given name from the provider's DocSnippetsDir.
+func (g *Generator) docSnippet(name string) (string, error) {
+	if g.docSnippets == nil {
+		g.docSnippets = map[string]string{}
+	}
+	if snippet, ok := g.docSnippets[name]; ok {
+		return snippet, nil
+	}
+	if g.info.DocSnippetsDir == "" {
+		return "", errors.Errorf("doc snippet %q was referenced, but no DocSnippetsDir is configured", name)
+	}
+
+	bytes, err := ioutil.ReadFile(filepath.Join(g.info.DocSnippetsDir, name+".md"))
+	if err != nil {
+		return "", errors.Wrapf(err, "reading doc snippet %q", name)
+	}
+	snippet := strings.TrimSpace(string(bytes))
+	g.docSnippets[name] = snippet
+	return snippet, nil
+}
+
+// expandDocIncludes replaces each `{{% include "name" %}}` directive in the given markdown with the named snippet.
+func (g *Generator) expandDocIncludes(markdown string) (string, error) {
+	var err error
+	expanded := includeDirectiveRegexp.ReplaceAllStringFunc(markdown, func(directive string) string {
+		if err != nil {
+			return directive
+		}
+		name := includeDirectiveRegexp.FindStringSubmatch(directive)[1]
+		snippet, serr := g.docSnippet(name)
+		if serr != nil {
+			err = serr
+			return directive
+		}
+		return snippet
+	})
+	return expanded, err
+}
+
+// applyArgumentIncludes replaces the descriptions of arguments listed in the DocInfo's IncludeArguments with their
+// shared snippets.
+func (g *Generator) applyArgumentIncludes(docs entityDocs, docinfo *tfbridge.DocInfo) error {
+	args := make([]string, 0, len(docinfo.IncludeArguments))
+	for arg := range docinfo.IncludeArguments {
+		args = append(args, arg)
+	}
+	sort.Strings(args)
+	for _, arg := range args {
+		snippet, err := g.docSnippet(docinfo.IncludeArguments[arg])
+		if err != nil {
+			return errors.Wrapf(err, "including docs for argument %s", arg)
+		}
+		if existing, ok := docs.Arguments[arg]; ok {
+			existing.description = snippet
+		} else {
+			docs.Arguments[arg] = &argumentDocs{description: snippet, arguments: map[string]string{}}
+		}
+	}
+	return nil
+}
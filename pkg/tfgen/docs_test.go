@@ -17,9 +17,15 @@ package tfgen
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -332,3 +338,322 @@ Misleading example title without any actual code fences. We should not modify th
 		assert.Contains(t, processedMarkdown, "#### Basic Example")
 	})
 }
+
+func TestDocIncludes(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "region.md"), []byte("The region to operate in.\n"), 0600)
+	assert.NoError(t, err)
+
+	g := &Generator{info: tfbridge.ProviderInfo{DocSnippetsDir: dir}}
+
+	expanded, err := g.expandDocIncludes("Before.\n{{% include \"region\" %}}\nAfter.")
+	assert.NoError(t, err)
+	assert.Equal(t, "Before.\nThe region to operate in.\nAfter.", expanded)
+
+	_, err = g.expandDocIncludes(`{{% include "missing" %}}`)
+	assert.Error(t, err)
+
+	docs := entityDocs{Arguments: map[string]*argumentDocs{
+		"location": {description: "The upstream description."},
+	}}
+	err = g.applyArgumentIncludes(docs, &tfbridge.DocInfo{IncludeArguments: map[string]string{
+		"location": "region",
+		"zone":     "region",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "The region to operate in.", docs.Arguments["location"].description)
+	assert.Equal(t, "The region to operate in.", docs.Arguments["zone"].description)
+}
+
+func TestDocNotes(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "import.md"), []byte("Import by `bucket_name`.\n"), 0600)
+	assert.NoError(t, err)
+
+	g := &Generator{
+		pkg:      "test",
+		language: NodeJS,
+		info: tfbridge.ProviderInfo{
+			DocSnippetsDir: dir,
+			Resources:      map[string]*tfbridge.ResourceInfo{"test_bucket": {Tok: "test:s3/bucket:Bucket"}},
+		},
+	}
+
+	// Descriptions without notes are left as they are.
+	description, err := g.addDocNotes("test_bucket", "Manages a bucket.\n", &tfbridge.DocInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Manages a bucket.\n", description)
+
+	// Notes are formatted for the target language, even if they mention Terraform, except in code blocks.
+	description, err = g.addDocNotes("test_bucket", "Manages a bucket.\n", &tfbridge.DocInfo{
+		PrependMarkdown: "> Unlike Terraform's `test_bucket`, `force_destroy` defaults to true.\n",
+		AppendMarkdown:  "{{% include \"import\" %}}\n\n```sh\n$ echo bucket_name\n```\n",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "> Unlike Terraform's `test.s3.Bucket`, `forceDestroy` defaults to true.\n\n"+
+		"Manages a bucket.\n\nImport by `bucketName`.\n\n```sh\n$ echo bucket_name\n```", description)
+
+	_, err = g.addDocNotes("test_bucket", "", &tfbridge.DocInfo{AppendMarkdown: `{{% include "missing" %}}`})
+	assert.Error(t, err)
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	// No timeout runs the function to completion.
+	_, err := runWithTimeout(0, func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Errors from the function are passed through.
+	_, err = runWithTimeout(time.Second, func() error { return fmt.Errorf("boom") })
+	assert.EqualError(t, err, "boom")
+
+	// A slow function is abandoned once the timeout elapses.
+	release := make(chan struct{})
+	defer close(release)
+	elapsed, err := runWithTimeout(10*time.Millisecond, func() error {
+		<-release
+		return nil
+	})
+	assert.ErrorIs(t, err, errConversionTimeout)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(10*time.Millisecond))
+
+	// Timeouts are recorded as fatal results along with the elapsed time.
+	ct := newCoverageTracker("test", "0.0.1")
+	ct.foundExample("example", "resource \"foo\" \"bar\" {}")
+	ct.languageConversionTimeout("python", elapsed)
+	result := ct.EncounteredExamples["example"].LanguagesConvertedTo["python"]
+	assert.Equal(t, Fatal, result.FailureSeverity)
+	assert.Equal(t, elapsed, result.ElapsedTime)
+}
+
+func TestConversionHostDetach(t *testing.T) {
+	provider := &inmemoryProvider{name: "test", info: tfbridge.ProviderInfo{Name: "test"}}
+	shared := &inmemoryProviderHost{provider: provider}
+	host := newConversionHost(shared, shared)
+
+	p, err := host.Provider("test", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, provider, p)
+	info, err := host.GetProviderInfo("", "", "test", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", info.Name)
+
+	// A detached host no longer reaches the shared host.
+	host.detach()
+	_, err = host.Provider("test", nil)
+	assert.ErrorIs(t, err, errConversionHostDetached)
+	_, err = host.GetProviderInfo("", "", "test", "")
+	assert.ErrorIs(t, err, errConversionHostDetached)
+	assert.NoError(t, host.Close())
+}
+
+func TestNormalizeHcl(t *testing.T) {
+	input := "resource \u201caws_s3_bucket\u201d \"b\" {\n" +
+		"\u00a0\u00a0bucket = \"my-bucket\"\u200b\n" +
+		"  policy = &quot;{}&quot;\n" +
+		"  count  = var.enabled &amp;&amp; true ? 1 : 0\n" +
+		"}"
+	normalized, ok := normalizeHcl(input)
+	assert.True(t, ok)
+	assert.Equal(t, "resource \"aws_s3_bucket\" \"b\" {\n"+
+		"  bucket = \"my-bucket\"\n"+
+		"  policy = \"{}\"\n"+
+		"  count  = var.enabled && true ? 1 : 0\n"+
+		"}", normalized)
+
+	// Clean HCL, including operators that look like entities, is left alone.
+	clean := "locals {\n  enabled = var.a && var.b\n}"
+	normalized, ok = normalizeHcl(clean)
+	assert.False(t, ok)
+	assert.Equal(t, clean, normalized)
+
+	// Normalized examples are recorded by the coverage tracker.
+	ct := newCoverageTracker("test", "0.0.1")
+	ct.foundExample("example", input)
+	ct.exampleNormalized()
+	assert.True(t, ct.EncounteredExamples["example"].Normalized)
+}
+
+func TestCuratedExamples(t *testing.T) {
+	root := t.TempDir()
+	dir := curatedExamplesDir(root, "test:index/widget:Widget")
+	assert.Equal(t, filepath.Join(root, "test", "index", "widget", "Widget"), dir)
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "with-tags.tf"), []byte("resource \"test_widget\" \"w\" {}\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "with-tags.ts"), []byte("new test.Widget(\"w\");\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0600))
+
+	g := &Generator{info: tfbridge.ProviderInfo{ExamplesDir: root}, language: Python}
+	examples := "\n\n### With Tags\n\n```hcl\nresource \"test_widget\" \"w\" {}\n```\n" +
+		"\n```typescript\nnew test.Widget(\"w\");\n```\n"
+
+	// Examples are appended to an existing Example Usage section...
+	description, err := g.addCuratedExamples("Widgets.\n\n## Example Usage\n\n### Basic\n\nBody.\n\n## Import\n\nImports.",
+		"test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Equal(t, "Widgets.\n\n## Example Usage\n\n### Basic\n\nBody."+examples+"\n## Import\n\nImports.", description)
+
+	// ...or in a new one if there is none.
+	description, err = g.addCuratedExamples("Widgets.\n", "test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Equal(t, "Widgets.\n\n## Example Usage"+examples, description)
+
+	// Tokens without curated examples are left alone.
+	description, err = g.addCuratedExamples("Gadgets.", "test:index/gadget:Gadget")
+	assert.NoError(t, err)
+	assert.Equal(t, "Gadgets.", description)
+
+	// Examples written in a Pulumi language are kept as-is, but only for the language being generated.
+	converted := g.convertExamples("## Example Usage\n\n### Basic\n\n```typescript\nts();\n```\n\n"+
+		"```python\npy()\n```\n", "test_widget", true)
+	assert.Equal(t, "{{% examples %}}\n## Example Usage\n{{% example %}}\n### Basic\n\n\n```python\npy()\n```\n"+
+		"{{% /example %}}\n{{% /examples %}}", converted)
+
+	// Code blocks in other languages are kept for every language rather than converted as HCL.
+	converted = g.convertExamples("## Example Usage\n\n### Basic\n\n```python\npy()\n```\n\n"+
+		"```sh\n$ widget --help\n```\n", "test_widget", true)
+	assert.Equal(t, "{{% examples %}}\n## Example Usage\n{{% example %}}\n### Basic\n\n```python\npy()\n```\n\n"+
+		"```sh\n$ widget --help\n```\n{{% /example %}}\n{{% /examples %}}", converted)
+}
+
+func TestExampleTransformers(t *testing.T) {
+	var paths []string
+	tracker := newCoverageTracker("test", "0.0.1")
+	g, err := NewGenerator(GeneratorOptions{
+		Package:         "test",
+		Version:         "0.0.1",
+		Language:        NodeJS,
+		Sink:            diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		CoverageTracker: tracker,
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name: "test",
+			ExampleTransformers: map[string]tfbridge.ExampleTransformer{
+				"typescript": func(path, code string) (string, error) {
+					paths = append(paths, path)
+					return "// Transformed.\n" + code, nil
+				},
+				"python": func(path, code string) (string, error) {
+					return "", fmt.Errorf("cannot transform %s", path)
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	hcl := "output \"greeting\" {\n  value = \"hello\"\n}"
+	tracker.foundExample("widget", hcl)
+
+	code, _, err := g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#/resources/test:index/widget:Widget"}, paths)
+	assert.Contains(t, code, "```typescript\n// Transformed.\n")
+
+	// Languages without a transformer are left alone, and transformer errors fail the conversion.
+	g.language = Golang
+	code, _, err = g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.NotContains(t, code, "Transformed")
+	g.language = Python
+	_, _, err = g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.EqualError(t, err, "failed to transform python example for #/resources/test:index/widget:Widget: "+
+		"cannot transform #/resources/test:index/widget:Widget")
+
+	// Transformer errors are recorded as failures of their own rather than as converter panics.
+	result := tracker.EncounteredExamples["widget"].LanguagesConvertedTo["python"]
+	if assert.NotNil(t, result) {
+		assert.Equal(t, Failure, result.FailureSeverity)
+		assert.Equal(t, "Example transformer failed: cannot transform #/resources/test:index/widget:Widget",
+			result.FailureInfo)
+	}
+}
+
+func TestExampleCache(t *testing.T) {
+	dir := t.TempDir()
+	defer setEnv(t, "npm_config_cache", "")()
+	defer setEnv(t, "YARN_CACHE_FOLDER", "")()
+	defer setEnv(t, "NUGET_PACKAGES", "")()
+	defer setEnv(t, "PIP_CACHE_DIR", "/shared/pip")()
+	newGenerator := func(version string, resources map[string]*tfbridge.ResourceInfo) *Generator {
+		g, err := NewGenerator(GeneratorOptions{
+			Package:         "test",
+			Version:         version,
+			Language:        NodeJS,
+			Sink:            diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+			ProviderInfo:    tfbridge.ProviderInfo{Name: "test", Version: version, Resources: resources},
+			ExampleCacheDir: dir,
+		})
+		assert.NoError(t, err)
+		return g
+	}
+	hcl := "output \"greeting\" {\n  value = \"hello\"\n}"
+
+	// Converted examples are cached per language.
+	g := newGenerator("0.0.1", nil)
+	code, _, err := g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	entries, err := filepath.Glob(filepath.Join(dir, "typescript", "*.txt"))
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	assert.Contains(t, code, "hello")
+	partials, err := filepath.Glob(entries[0] + ".*.partial")
+	assert.NoError(t, err)
+	assert.Empty(t, partials)
+
+	// The languages' package caches are shared through the cache with the tools that converters start, unless they
+	// are already configured, without changing the generator's own environment.
+	env := g.exampleCache.toolEnvironment()
+	assert.Contains(t, env, "npm_config_cache="+filepath.Join(dir, "env", "npm"))
+	assert.NotContains(t, env, "PIP_CACHE_DIR="+filepath.Join(dir, "env", "pip"))
+	assert.Empty(t, os.Getenv("npm_config_cache"))
+
+	// Later conversions of the same example by the same provider are served from the cache, even after a release.
+	assert.NoError(t, ioutil.WriteFile(entries[0], []byte("cached();"), 0600))
+	code, _, err = newGenerator("0.0.2", nil).convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Equal(t, "```typescript\ncached();\n```", code)
+
+	// Changes to the provider's mappings invalidate the cache.
+	resources := map[string]*tfbridge.ResourceInfo{"test_widget": {Tok: "test:index/widget:Widget"}}
+	code, _, err = newGenerator("0.0.2", resources).convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.NotContains(t, code, "cached();")
+}
+
+func TestExampleExclusions(t *testing.T) {
+	coverage := newCoverageTracker("test", "0.0.1")
+	g, err := NewGenerator(GeneratorOptions{
+		Package:         "test",
+		Version:         "0.0.1",
+		Language:        Schema,
+		Sink:            diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		CoverageTracker: coverage,
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name:                           "test",
+			ExampleExcludedLanguages:       []string{"csharp"},
+			ExampleExcludedModuleLanguages: map[string][]string{"ec2": {"go", "python", "typescript"}},
+		},
+	})
+	assert.NoError(t, err)
+	hcl := "output \"greeting\" {\n  value = \"hello\"\n}"
+
+	// Languages excluded for all examples are skipped, and reported as exclusions rather than failures.
+	coverage.foundExample("#/resources/test:index/widget:Widget", hcl)
+	code, _, err := g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Contains(t, code, "```go\n")
+	assert.NotContains(t, code, "```csharp\n")
+	widget := coverage.EncounteredExamples["#/resources/test:index/widget:Widget"]
+	assert.Equal(t, []string{"csharp"}, widget.ExcludedLanguages)
+	assert.NotContains(t, widget.LanguagesConvertedTo, "csharp")
+
+	// Examples whose every language is excluded are not converted at all.
+	coverage.foundExample("#/resources/test:ec2/instance:Instance", hcl)
+	_, _, err = g.convertHCL(hcl, "#/resources/test:ec2/instance:Instance")
+	assert.ErrorIs(t, err, errExampleExcluded)
+	instance := coverage.EncounteredExamples["#/resources/test:ec2/instance:Instance"]
+	assert.Len(t, instance.ExcludedLanguages, 4)
+	assert.Empty(t, instance.LanguagesConvertedTo)
+}
@@ -0,0 +1,190 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// docTranslation records which documentation pages (resources and functions) were translated for a single locale.
+type docTranslation struct {
+	Locale              string
+	TranslatedPages     int
+	UntranslatedPages   []string `json:"UntranslatedPages,omitempty"`
+	TranslatedStrings   int
+	UntranslatedStrings int
+}
+
+// docTranslator applies a provider's DocTranslator to every description in a schema.
+type docTranslator struct {
+	locale     string
+	translate  tfbridge.DocTranslator
+	translated bool // whether any string on the current page has been translated
+	result     *docTranslation
+}
+
+func (t *docTranslator) translateString(token, doc string) (string, error) {
+	if doc == "" {
+		return doc, nil
+	}
+	translated, ok, err := t.translate(t.locale, token, doc)
+	if err != nil {
+		return "", errors.Wrapf(err, "translating docs for %s into %s", token, t.locale)
+	}
+	if !ok {
+		t.result.UntranslatedStrings++
+		return doc, nil
+	}
+	t.result.TranslatedStrings++
+	t.translated = true
+	return translated, nil
+}
+
+func (t *docTranslator) translateProperties(token string, props map[string]pschema.PropertySpec) error {
+	for name, prop := range props {
+		doc, err := t.translateString(token+"."+name, prop.Description)
+		if err != nil {
+			return err
+		}
+		prop.Description = doc
+		props[name] = prop
+	}
+	return nil
+}
+
+// translatePage translates the description and properties of a single documentation page, and records whether
+// anything on the page was translated.
+func (t *docTranslator) translatePage(token string, description *string,
+	propertyMaps ...map[string]pschema.PropertySpec) error {
+
+	t.translated = false
+	doc, err := t.translateString(token, *description)
+	if err != nil {
+		return err
+	}
+	*description = doc
+	for _, props := range propertyMaps {
+		if err = t.translateProperties(token, props); err != nil {
+			return err
+		}
+	}
+
+	if t.translated {
+		t.result.TranslatedPages++
+	} else {
+		t.result.UntranslatedPages = append(t.result.UntranslatedPages, token)
+	}
+	return nil
+}
+
+// translateSchemaDocs returns a copy of the given schema with its documentation translated into the given locale,
+// along with a record of which pages were left untranslated.
+func translateSchemaDocs(spec pschema.PackageSpec, locale string,
+	translate tfbridge.DocTranslator) (pschema.PackageSpec, *docTranslation, error) {
+
+	// Make a deep copy of the spec so that the original is left untouched.
+	var localized pschema.PackageSpec
+	bytes, err := json.Marshal(spec)
+	if err != nil {
+		return localized, nil, err
+	}
+	if err = json.Unmarshal(bytes, &localized); err != nil {
+		return localized, nil, err
+	}
+
+	t := &docTranslator{locale: locale, translate: translate, result: &docTranslation{Locale: locale}}
+
+	if localized.Description, err = t.translateString(localized.Name, localized.Description); err != nil {
+		return localized, nil, err
+	}
+	if err = t.translateProperties("config", localized.Config.Variables); err != nil {
+		return localized, nil, err
+	}
+
+	for _, tok := range sortedKeys(localized.Resources) {
+		res := localized.Resources[tok]
+		propertyMaps := []map[string]pschema.PropertySpec{res.Properties, res.InputProperties}
+		if res.StateInputs != nil {
+			propertyMaps = append(propertyMaps, res.StateInputs.Properties)
+		}
+		if err = t.translatePage(tok, &res.Description, propertyMaps...); err != nil {
+			return localized, nil, err
+		}
+		localized.Resources[tok] = res
+	}
+
+	for _, tok := range sortedKeys(localized.Functions) {
+		fun := localized.Functions[tok]
+		var propertyMaps []map[string]pschema.PropertySpec
+		if fun.Inputs != nil {
+			propertyMaps = append(propertyMaps, fun.Inputs.Properties)
+		}
+		if fun.Outputs != nil {
+			propertyMaps = append(propertyMaps, fun.Outputs.Properties)
+		}
+		if err = t.translatePage(tok, &fun.Description, propertyMaps...); err != nil {
+			return localized, nil, err
+		}
+		localized.Functions[tok] = fun
+	}
+
+	typeTokens := make([]string, 0, len(localized.Types))
+	for tok := range localized.Types {
+		typeTokens = append(typeTokens, tok)
+	}
+	sort.Strings(typeTokens)
+	for _, tok := range typeTokens {
+		typ := localized.Types[tok]
+		if typ.Description, err = t.translateString(tok, typ.Description); err != nil {
+			return localized, nil, err
+		}
+		if err = t.translateProperties(tok, typ.Properties); err != nil {
+			return localized, nil, err
+		}
+		localized.Types[tok] = typ
+	}
+
+	return localized, t.result, nil
+}
+
+// genLocalizedSchemas returns a translated copy of the schema for each of the provider's DocLocales, keyed by the
+// `<locale>/schema.json` path it should be written to side-by-side with the primary schema.
+func (g *Generator) genLocalizedSchemas(spec pschema.PackageSpec) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if g.info.DocTranslator == nil {
+		return files, nil
+	}
+	for _, locale := range g.info.DocLocales {
+		localized, result, err := translateSchemaDocs(spec, locale, g.info.DocTranslator)
+		if err != nil {
+			return nil, err
+		}
+		g.coverageTracker.foundDocTranslation(result)
+
+		bytes, err := json.MarshalIndent(localized, "", "    ")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal %s schema", locale)
+		}
+		files[path.Join(locale, "schema.json")] = bytes
+	}
+	return files, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateSchemaDocs(t *testing.T) {
+	spec := pschema.PackageSpec{
+		Name: "test",
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "A widget."},
+				InputProperties: map[string]pschema.PropertySpec{
+					"name": {Description: "The name."},
+				},
+			},
+			"test:index/gadget:Gadget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "A gadget."},
+			},
+		},
+	}
+
+	translations := map[string]string{
+		"A widget.": "Un widget.",
+		"The name.": "Le nom.",
+	}
+	localized, result, err := translateSchemaDocs(spec, "fr-FR", func(locale, token, doc string) (string, bool, error) {
+		assert.Equal(t, "fr-FR", locale)
+		translated, ok := translations[doc]
+		return translated, ok, nil
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Un widget.", localized.Resources["test:index/widget:Widget"].Description)
+	assert.Equal(t, "Le nom.", localized.Resources["test:index/widget:Widget"].InputProperties["name"].Description)
+	assert.Equal(t, "A gadget.", localized.Resources["test:index/gadget:Gadget"].Description)
+
+	// The original spec is untouched.
+	assert.Equal(t, "A widget.", spec.Resources["test:index/widget:Widget"].Description)
+
+	assert.Equal(t, &docTranslation{
+		Locale:              "fr-FR",
+		TranslatedPages:     1,
+		UntranslatedPages:   []string{"test:index/gadget:Gadget"},
+		TranslatedStrings:   2,
+		UntranslatedStrings: 1,
+	}, result)
+}
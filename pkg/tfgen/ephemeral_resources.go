@@ -0,0 +1,89 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// gatherEphemeralResources exposes the mapped ephemeral resources of the upstream provider as functions, if they are
+// included. Ephemeral resources that are not mapped are skipped with a warning, as they usually share their names
+// with data sources.
+func (g *Generator) gatherEphemeralResources() (moduleMap, error) {
+	ep, ok := g.provider().(shim.EphemeralResourceProvider)
+	if !g.includeEphemeral || !ok {
+		return nil, nil
+	}
+	resources := ep.EphemeralResourcesMap()
+	modules := make(moduleMap)
+
+	var ererr error
+	for _, name := range stableResources(resources) {
+		info := g.info.EphemeralResources[name]
+		if info == nil || info.Tok == "" {
+			g.warn("ephemeral resource %s not found in provider map; skipping", name)
+			continue
+		}
+
+		dsinfo := &tfbridge.DataSourceInfo{Tok: info.Tok, Fields: info.Fields, Docs: info.Docs}
+		module, fun, err := g.gatherDataSourceWithDocs(name, resources.Get(name), dsinfo, EphemeralResourceDocs)
+		if err != nil {
+			ererr = multierror.Append(ererr, err)
+			continue
+		} else if fun == nil {
+			continue
+		}
+		fun.doc = ephemeralResourceNote(name, info.TTL) + fun.doc
+		fun.entityDocs.Description = fun.doc
+		modules.ensureModule(module).addMember(fun)
+	}
+	if ererr != nil {
+		return nil, ererr
+	}
+	return modules, nil
+}
+
+// ephemeralResourceNote returns the note that warns users of the function that exposes the given ephemeral resource
+// that its values are short-lived and are not persisted by the upstream provider.
+func ephemeralResourceNote(rawname string, ttl time.Duration) string {
+	note := fmt.Sprintf("> **Note:** this function exposes the upstream ephemeral resource %s. Terraform never "+
+		"persists the values of ephemeral resources, and they are opened again every time this function is invoked. "+
+		"Pulumi stores the results of functions in the stack's state when they are used as resource inputs, so "+
+		"consider marking them as secrets.", rawname)
+	if ttl > 0 {
+		note += fmt.Sprintf(" The values are valid for %s after they are opened, so do not rely on values from a "+
+			"previous deployment.", formatTTL(ttl))
+	}
+	return note + "\n\n"
+}
+
+// formatTTL formats the given duration without zero minutes and seconds, e.g. "12h" rather than "12h0m0s".
+func formatTTL(ttl time.Duration) string {
+	s := ttl.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+	"time"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+// ephemeralProvider exposes its ephemeral resources alongside the provider it wraps.
+type ephemeralProvider struct {
+	shim.Provider
+	ephemeral shim.ResourceMap
+}
+
+func (p ephemeralProvider) EphemeralResourcesMap() shim.ResourceMap { return p.ephemeral }
+
+func (p ephemeralProvider) ValidateEphemeralResource(string, shim.ResourceConfig) ([]string, []error) {
+	return nil, nil
+}
+
+func (p ephemeralProvider) OpenEphemeralResource(string, shim.ResourceConfig) (shim.InstanceState, error) {
+	return nil, nil
+}
+
+func TestEphemeralResources(t *testing.T) {
+	ephemeral := shimv2.NewProvider(&schemav2.Provider{
+		DataSourcesMap: map[string]*schemav2.Resource{
+			"test_token": {Schema: map[string]*schemav2.Schema{
+				"registry": {Type: schemav2.TypeString, Required: true},
+				"password": {Type: schemav2.TypeString, Computed: true, Sensitive: true},
+			}},
+			"test_unmapped": {Schema: map[string]*schemav2.Schema{
+				"name": {Type: schemav2.TypeString, Optional: true},
+			}},
+		},
+	}).DataSourcesMap()
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P:    ephemeralProvider{Provider: shimv2.NewProvider(&schemav2.Provider{}), ephemeral: ephemeral},
+		EphemeralResources: map[string]*tfbridge.EphemeralResourceInfo{
+			"test_token": {Tok: "test:index/getToken:getToken", TTL: 12 * time.Hour},
+		},
+	}
+
+	gatherSchema := func(include bool) map[string]bool {
+		spec := genTestSchema(t, info, func(opts *GeneratorOptions) { opts.IncludeEphemeralResources = include })
+
+		functions := map[string]bool{}
+		for tok, fun := range spec.Functions {
+			functions[tok] = true
+			if tok == "test:index/getToken:getToken" {
+				assert.Contains(t, fun.Description, "ephemeral resource test_token")
+				assert.Contains(t, fun.Description, "valid for 12h after they are opened")
+				assert.Contains(t, fun.Inputs.Properties, "registry")
+				assert.Contains(t, fun.Outputs.Properties, "password")
+			}
+		}
+		return functions
+	}
+
+	// Ephemeral resources are left out unless they are included, and unmapped ones are always left out.
+	assert.Equal(t, map[string]bool{"test:tfbridge:terraformCompat": true}, gatherSchema(false))
+	assert.Equal(t, map[string]bool{
+		"test:index/getToken:getToken":  true,
+		"test:tfbridge:terraformCompat": true,
+	}, gatherSchema(true))
+}
+
+func TestFormatTTL(t *testing.T) {
+	assert.Equal(t, "12h", formatTTL(12*time.Hour))
+	assert.Equal(t, "1h30m", formatTTL(90*time.Minute))
+	assert.Equal(t, "15m", formatTTL(15*time.Minute))
+	assert.Equal(t, "45s", formatTTL(45*time.Second))
+}
@@ -0,0 +1,272 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// bundledExample is one of a member's examples, in its original HCL and in each language it was converted to.
+type bundledExample struct {
+	HCL       string            `json:"hcl"`
+	Languages map[string]string `json:"languages"` // the converted code, by language name
+}
+
+// exampleBundle is the manifest of the examples exported for a resource or function.
+type exampleBundle struct {
+	Token    string           `json:"token"`
+	Kind     string           `json:"kind"` // "resource" or "function"
+	Source   string           `json:"source"`
+	License  string           `json:"license"`
+	Examples []bundledExample `json:"examples"`
+}
+
+// exampleBundleFile describes how the code of a language is written out: the name of its file, the tag of its fenced
+// code blocks, and the syntax of its comments.
+type exampleBundleFile struct {
+	Name, Fence          string
+	CommentStart, Prefix string
+	CommentEnd           string
+}
+
+var exampleBundleFiles = map[string]exampleBundleFile{
+	"hcl":        {Name: "main.tf", Fence: "hcl", Prefix: "# "},
+	"typescript": {Name: "index.ts", Fence: "typescript", Prefix: "// "},
+	"python":     {Name: "__main__.py", Fence: "python", Prefix: "# "},
+	"csharp":     {Name: "Program.cs", Fence: "csharp", Prefix: "// "},
+	"go":         {Name: "main.go", Fence: "go", Prefix: "// "},
+	"markdown":   {Name: "README.md", CommentStart: "<!--\n", CommentEnd: "-->\n"},
+}
+
+// exampleBundleLanguages is the order in which the languages of an example are listed.
+var exampleBundleLanguages = []string{"typescript", "python", "csharp", "go"}
+
+// collectExampleBundle records an example of the given path that was converted to the given languages, if example
+// bundles are exported.
+func (g *Generator) collectExampleBundle(path, hcl string, converted map[string]string) {
+	if g.exampleBundlesDir == "" || len(converted) == 0 {
+		return
+	}
+	if g.exampleBundles == nil {
+		g.exampleBundles = map[string][]bundledExample{}
+	}
+	languages := make(map[string]string, len(converted))
+	for lang, code := range converted {
+		languages[lang] = code
+	}
+	g.exampleBundles[path] = append(g.exampleBundles[path], bundledExample{
+		HCL:       strings.TrimSpace(hcl),
+		Languages: languages,
+	})
+}
+
+// exampleBundleSource returns the upstream repository that the examples are derived from.
+func (g *Generator) exampleBundleSource() string {
+	return fmt.Sprintf("https://%s/%s/terraform-provider-%s", g.info.GetGitHubHost(), g.info.GetGitHubOrg(),
+		g.info.Name)
+}
+
+// exampleBundleNotice returns the notice that is added to each exported file, as upstream examples are distributed
+// under the upstream provider's license.
+func (g *Generator) exampleBundleNotice() string {
+	return fmt.Sprintf("This example is derived from the docs of the Terraform Provider (%s),\n"+
+		"distributed under %s (%s).\n", g.exampleBundleSource(), g.info.GetTFProviderLicense(),
+		getLicenseTypeURL(g.info.GetTFProviderLicense()))
+}
+
+// withExampleBundleNotice prepends the license notice to the given contents, as a comment in the syntax of the file.
+func (g *Generator) withExampleBundleNotice(file exampleBundleFile, contents string) []byte {
+	var b strings.Builder
+	b.WriteString(file.CommentStart)
+	for _, line := range strings.SplitAfter(g.exampleBundleNotice(), "\n") {
+		if line != "" {
+			b.WriteString(file.Prefix + line)
+		}
+	}
+	b.WriteString(file.CommentEnd)
+	b.WriteString("\n")
+	b.WriteString(contents)
+	if !strings.HasSuffix(contents, "\n") {
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// exampleBundleDir returns the directory of the bundle of the given resource or function, relative to the export
+// directory.
+func exampleBundleDir(kind, token string) string {
+	return filepath.Join(kind+"s", strings.NewReplacer(":", "-", "/", "-").Replace(token))
+}
+
+// exportExampleBundles writes the examples of each resource and function in the given schema to the example bundles
+// directory. Each bundle consists of a bundle.json manifest, a README.md that lists every example in all of its
+// languages, and a directory per example with the original HCL and the code of each language.
+func (g *Generator) exportExampleBundles(spec pschema.PackageSpec) error {
+	if g.exampleBundlesDir == "" {
+		return nil
+	}
+	if g.info.GetTFProviderLicense() == tfbridge.UnlicensedLicenseType {
+		g.warn("not exporting example bundles, as the upstream provider is unlicensed")
+		return nil
+	}
+
+	var bundles []exampleBundle
+	for _, kind := range []string{"resource", "function"} {
+		var tokens []string
+		if kind == "resource" {
+			for token := range spec.Resources {
+				tokens = append(tokens, token)
+			}
+		} else {
+			for token := range spec.Functions {
+				tokens = append(tokens, token)
+			}
+		}
+		sort.Strings(tokens)
+		for _, token := range tokens {
+			examples := g.exampleBundles[fmt.Sprintf("#/%ss/%s", kind, token)]
+			if len(examples) == 0 {
+				continue
+			}
+			bundles = append(bundles, exampleBundle{
+				Token:    token,
+				Kind:     kind,
+				Source:   g.exampleBundleSource(),
+				License:  string(g.info.GetTFProviderLicense()),
+				Examples: examples,
+			})
+		}
+	}
+
+	for _, bundle := range bundles {
+		if err := g.writeExampleBundle(bundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notebook is a Jupyter notebook that only has markdown cells.
+type notebook struct {
+	Cells         []notebookCell         `json:"cells"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	NBFormat      int                    `json:"nbformat"`
+	NBFormatMinor int                    `json:"nbformat_minor"`
+}
+
+type notebookCell struct {
+	CellType string                 `json:"cell_type"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Source   []string               `json:"source"`
+}
+
+func newMarkdownCell(markdown string) notebookCell {
+	return notebookCell{
+		CellType: "markdown",
+		Metadata: map[string]interface{}{},
+		Source:   strings.SplitAfter(strings.TrimSuffix(markdown, "\n"), "\n"),
+	}
+}
+
+// writeExampleBundle writes the files of the given bundle. The README.md and examples.ipynb files hold the same
+// sections, so that the examples can be read in either form.
+func (g *Generator) writeExampleBundle(bundle exampleBundle) error {
+	dir := filepath.Join(g.exampleBundlesDir, exampleBundleDir(bundle.Kind, bundle.Token))
+	write := func(name string, contents []byte) error {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(p, contents, 0600)
+	}
+
+	quotedNotice := "> " + strings.ReplaceAll(strings.TrimSuffix(g.exampleBundleNotice(), "\n"), "\n", "\n> ")
+	sections := []string{fmt.Sprintf("# %s\n", bundle.Token)}
+	for i, example := range bundle.Examples {
+		exampleDir := fmt.Sprintf("example-%d", i+1)
+		sections = append(sections, fmt.Sprintf("## Example %d\n", i+1))
+
+		hcl := exampleBundleFiles["hcl"]
+		sections = append(sections, fmt.Sprintf("### Terraform\n\n```%s\n%s\n```\n", hcl.Fence, example.HCL))
+		if err := write(filepath.Join(exampleDir, hcl.Name), g.withExampleBundleNotice(hcl, example.HCL)); err != nil {
+			return err
+		}
+		for _, lang := range exampleBundleLanguages {
+			code, ok := example.Languages[lang]
+			if !ok {
+				continue
+			}
+			file := exampleBundleFiles[lang]
+			sections = append(sections,
+				fmt.Sprintf("### %s\n\n```%s\n%s\n```\n", exampleLanguageTitle(lang), file.Fence, code))
+			if err := write(filepath.Join(exampleDir, file.Name), g.withExampleBundleNotice(file, code)); err != nil {
+				return err
+			}
+		}
+	}
+
+	readme := g.withExampleBundleNotice(exampleBundleFiles["markdown"], strings.Join(sections, "\n"))
+	if err := write("README.md", readme); err != nil {
+		return err
+	}
+	nb := notebook{
+		Cells:         []notebookCell{newMarkdownCell(quotedNotice)},
+		Metadata:      map[string]interface{}{},
+		NBFormat:      4,
+		NBFormatMinor: 2,
+	}
+	for _, section := range sections {
+		nb.Cells = append(nb.Cells, newMarkdownCell(section))
+	}
+	contents, err := json.MarshalIndent(nb, "", " ")
+	if err != nil {
+		return err
+	}
+	if err = write("examples.ipynb", contents); err != nil {
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(bundle, "", "    ")
+	if err != nil {
+		return err
+	}
+	return write("bundle.json", manifest)
+}
+
+// exampleLanguageTitle returns the display name of the given example language.
+func exampleLanguageTitle(lang string) string {
+	switch lang {
+	case "typescript":
+		return "TypeScript"
+	case "python":
+		return "Python"
+	case "csharp":
+		return "C#"
+	case "go":
+		return "Go"
+	default:
+		return lang
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+func TestExportExampleBundles(t *testing.T) {
+	newGenerator := func(dir string, license tfbridge.TFProviderLicense) *Generator {
+		g, err := NewGenerator(GeneratorOptions{
+			Package:           "test",
+			Version:           "0.0.1",
+			Language:          Schema,
+			Sink:              diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+			ProviderInfo:      tfbridge.ProviderInfo{Name: "test", TFProviderLicense: &license},
+			ExampleBundlesDir: dir,
+		})
+		assert.NoError(t, err)
+		return g
+	}
+	hcl := "output \"greeting\" {\n  value = \"hello\"\n}"
+	spec := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {},
+			"test:index/gadget:Gadget": {},
+		},
+	}
+
+	dir := t.TempDir()
+	g := newGenerator(dir, tfbridge.MPL20LicenseType)
+	_, _, err := g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.NoError(t, g.exportExampleBundles(spec))
+
+	// Each converted language is written next to the original HCL, with the upstream license notice.
+	bundleDir := filepath.Join(dir, "resources", "test-index-widget-Widget")
+	for _, name := range []string{"main.tf", "index.ts", "__main__.py", "Program.cs", "main.go"} {
+		contents, err := ioutil.ReadFile(filepath.Join(bundleDir, "example-1", name))
+		assert.NoError(t, err)
+		assert.Contains(t, string(contents), "distributed under MPL 2.0", name)
+	}
+	mainTF, err := ioutil.ReadFile(filepath.Join(bundleDir, "example-1", "main.tf"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(mainTF), "# This example is derived from the docs of the Terraform Provider "+
+		"(https://github.com/terraform-providers/terraform-provider-test),\n")
+	assert.Contains(t, string(mainTF), "\n"+hcl+"\n")
+
+	readme, err := ioutil.ReadFile(filepath.Join(bundleDir, "README.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(readme), "<!--\nThis example is derived")
+	assert.Contains(t, string(readme), "### Terraform\n\n```hcl\n"+hcl+"\n```\n")
+	assert.Contains(t, string(readme), "### TypeScript\n\n```typescript\n")
+
+	var nb notebook
+	contents, err := ioutil.ReadFile(filepath.Join(bundleDir, "examples.ipynb"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(contents, &nb))
+	assert.Equal(t, 4, nb.NBFormat)
+	assert.Len(t, nb.Cells, 8)
+
+	var bundle exampleBundle
+	contents, err = ioutil.ReadFile(filepath.Join(bundleDir, "bundle.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(contents, &bundle))
+	assert.Equal(t, "test:index/widget:Widget", bundle.Token)
+	assert.Equal(t, "MPL 2.0", bundle.License)
+	assert.Len(t, bundle.Examples, 1)
+	assert.Equal(t, hcl, bundle.Examples[0].HCL)
+	assert.Len(t, bundle.Examples[0].Languages, 4)
+
+	// Members without examples get no bundle.
+	_, err = ioutil.ReadDir(filepath.Join(dir, "resources", "test-index-gadget-Gadget"))
+	assert.Error(t, err)
+
+	// Examples of unlicensed providers are not exported.
+	dir = t.TempDir()
+	g = newGenerator(dir, tfbridge.UnlicensedLicenseType)
+	_, _, err = g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.NoError(t, g.exportExampleBundles(spec))
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
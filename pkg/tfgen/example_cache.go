@@ -0,0 +1,192 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/internal/sharedfile"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// exampleCacheDirEnvVar may be set to enable the example cache when the --example-cache-dir flag is not given.
+const exampleCacheDirEnvVar = "PULUMI_TFBRIDGE_EXAMPLE_CACHE_DIR"
+
+// exampleCacheFormat is bumped whenever the layout or the meaning of the cached examples changes.
+const exampleCacheFormat = 1
+
+// exampleCache stores examples converted from HCL on disk, one directory per language, so that they can be reused by
+// later runs and by other providers that share the directory. Entries are keyed by everything that the conversion of
+// an example depends on: the HCL, the target language, the provider's mappings, and the versions of the converter.
+// The directory also holds the package caches of the languages' tools, so that the npm, pip, yarn and NuGet packages
+// restored for one provider are reused by the next.
+type exampleCache struct {
+	dir         string
+	providerKey string // a digest of the provider's mappings and the converter versions, computed on first use.
+}
+
+func newExampleCache(dir string) *exampleCache {
+	if dir == "" {
+		return nil
+	}
+	return &exampleCache{dir: dir}
+}
+
+// languageEnvironment maps the environment variables that locate the package caches of the languages' tools to the
+// directories under the example cache that they are shared in.
+var languageEnvironment = map[string]string{
+	"npm_config_cache":  "npm",
+	"YARN_CACHE_FOLDER": "yarn",
+	"PIP_CACHE_DIR":     "pip",
+	"NUGET_PACKAGES":    "nuget",
+}
+
+// toolEnvironment returns the environment to start the languages' tools with while examples are converted, e.g. by
+// fallback converters. It points their package caches at the example cache, if there is one, unless they are already
+// configured. The environment of the generator itself is left alone.
+func (c *exampleCache) toolEnvironment() []string {
+	env := os.Environ()
+	if c == nil {
+		return env
+	}
+	keys := make([]string, 0, len(languageEnvironment))
+	for key := range languageEnvironment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if os.Getenv(key) == "" {
+			env = append(env, key+"="+filepath.Join(c.dir, "env", languageEnvironment[key]))
+		}
+	}
+	return env
+}
+
+// converterVersions returns the versions of the modules that implement example conversion. If any of them is not a
+// released version, e.g. because it is built from a local checkout and reports "(devel)" without a checksum, the
+// digest of the running executable stands in for the versions, so that changes to the converter are never masked by
+// stale entries.
+func converterVersions() map[string]string {
+	versions := map[string]string{}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return executableVersion()
+	}
+	released := 0
+	for _, dep := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		switch dep.Path {
+		case "github.com/pulumi/pulumi-terraform-bridge/v3", "github.com/pulumi/pulumi/pkg/v3":
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			if dep.Version == "" || dep.Version == "(devel)" || dep.Sum == "" {
+				return executableVersion()
+			}
+			versions[dep.Path] = dep.Version + dep.Sum
+			released++
+		}
+	}
+	if released != 2 {
+		return executableVersion()
+	}
+	return versions
+}
+
+// executableVersion returns the digest of the running executable, or nothing if it cannot be read.
+func executableVersion() map[string]string {
+	path, err := os.Executable()
+	if err != nil {
+		glog.V(5).Infof("failed to locate the executable for the example cache: %v", err)
+		return map[string]string{}
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		glog.V(5).Infof("failed to read the executable for the example cache: %v", err)
+		return map[string]string{}
+	}
+	sum := sha256.Sum256(contents)
+	return map[string]string{"executable": hex.EncodeToString(sum[:])}
+}
+
+// path returns the path of the cache entry for the given example.
+func (c *exampleCache) path(g *Generator, languageName, hcl string) string {
+	if c.providerKey == "" {
+		// Only the inputs of the converter are hashed, so that e.g. releasing a new version of the provider does not
+		// invalidate its examples.
+		info := tfbridge.MarshalProviderInfo(&g.info)
+		info.Version, info.TFProviderVersion = "", ""
+		contents, err := json.Marshal(struct {
+			Format    int                                `json:"format"`
+			Terraform string                             `json:"terraform"`
+			Converter map[string]string                  `json:"converter"`
+			Provider  *tfbridge.MarshallableProviderInfo `json:"provider"`
+		}{exampleCacheFormat, g.terraformVersion, converterVersions(), info})
+		contract.AssertNoError(err)
+		sum := sha256.Sum256(contents)
+		c.providerKey = hex.EncodeToString(sum[:])
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s", c.providerKey, hcl)))
+	return filepath.Join(c.dir, languageName, hex.EncodeToString(sum[:])+".txt")
+}
+
+// get returns the cached conversion of the given example, and true, if there is one.
+func (c *exampleCache) get(g *Generator, languageName, hcl string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	contents, err := ioutil.ReadFile(c.path(g, languageName, hcl))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.V(5).Infof("failed to read example cache: %v", err)
+		}
+		return "", false
+	}
+	return string(contents), true
+}
+
+// put records the conversion of the given example. Failures are logged rather than reported, since the cache is only
+// an optimization.
+func (c *exampleCache) put(g *Generator, languageName, hcl, code string) {
+	if c == nil {
+		return
+	}
+	if err := c.write(c.path(g, languageName, hcl), code); err != nil {
+		glog.V(5).Infof("failed to write example cache: %v", err)
+	}
+}
+
+// write records the given entry. Several generators may share the cache, so the entry is locked while it is written,
+// and is written beside its final path and renamed into place, like the other files shared between processes.
+func (c *exampleCache) write(path, code string) error {
+	unlock, err := sharedfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return sharedfile.Write(path, []byte(code))
+}
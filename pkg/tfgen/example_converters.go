@@ -0,0 +1,66 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// ExampleConverter is an alternative implementation of HCL example conversion, e.g. an older release of the converter
+// or an experimental one, that examples are retried with when the bridge's converter fails. Fallbacks raise the
+// number of examples that are converted while fixes to the bridge's converter are in progress.
+type ExampleConverter struct {
+	// Name identifies the converter in the coverage data.
+	Name string
+	// Convert converts the given HCL program to the given language, e.g. "typescript", and returns the converted code.
+	// Any tools it starts should be started with the given environment, which shares their package caches through
+	// the example cache.
+	Convert func(hcl, language string, env []string) (string, error)
+}
+
+// convertWithFallbacks tries each fallback converter in turn on an example that the bridge's converter failed to
+// convert to the given language, and returns the code and the name of the first converter that succeeds. Failures
+// are written to stderr.
+func (g *Generator) convertWithFallbacks(path, hcl, languageName string, stderr *bytes.Buffer) (string, string, bool) {
+	env := g.exampleCache.toolEnvironment()
+	for _, converter := range g.fallbackConverters {
+		var code string
+		elapsed, err := runWithTimeout(g.exampleTimeout, func() error {
+			var convertErr error
+			code, convertErr = converter.Convert(hcl, languageName, env)
+			return convertErr
+		})
+		switch {
+		case errors.Is(err, errConversionTimeout):
+			err = fmt.Errorf("timed out after %v", elapsed)
+		case err == nil && code == "":
+			err = errors.New("empty output produced")
+		}
+		if err != nil {
+			g.debug("fallback converter %s failed to convert HCL for %s to %v: %v", converter.Name, path,
+				languageName, err)
+			_, writeErr := fmt.Fprintf(stderr, "# %s: %s (%s): %v\n", path, languageName, converter.Name, err)
+			contract.IgnoreError(writeErr)
+			continue
+		}
+		g.debug("converted HCL for %s to %v with fallback converter %s", path, languageName, converter.Name)
+		return code, converter.Name, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,81 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfgen/coverage"
+)
+
+func TestFallbackExampleConverters(t *testing.T) {
+	var attempts []string
+	tracker := newCoverageTracker("test", "0.0.1")
+	g, err := NewGenerator(GeneratorOptions{
+		Package:         "test",
+		Version:         "0.0.1",
+		Language:        NodeJS,
+		Sink:            diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		CoverageTracker: tracker,
+		ProviderInfo:    tfbridge.ProviderInfo{Name: "test"},
+		FallbackExampleConverters: []ExampleConverter{
+			{Name: "broken", Convert: func(hcl, language string, env []string) (string, error) {
+				attempts = append(attempts, "broken")
+				return "", errors.New("unsupported")
+			}},
+			{Name: "legacy", Convert: func(hcl, language string, env []string) (string, error) {
+				attempts = append(attempts, "legacy")
+				return "legacy(" + language + ");", nil
+			}},
+		},
+	})
+	assert.NoError(t, err)
+
+	// Examples that the bridge's converter handles never reach the fallbacks.
+	hcl := "output \"greeting\" {\n  value = \"hello\"\n}"
+	tracker.foundExample("#/resources/test:index/widget:Widget", hcl)
+	code, _, err := g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Contains(t, code, "hello")
+	assert.Empty(t, attempts)
+
+	// Failures are retried with each fallback in turn, and the one that succeeded is recorded.
+	hcl = "output \"greeting\" {\n  value = [\n}"
+	tracker.foundExample("#/resources/test:index/gadget:Gadget", hcl)
+	code, stderr, err := g.convertHCL(hcl, "#/resources/test:index/gadget:Gadget")
+	assert.NoError(t, err)
+	assert.Equal(t, "```typescript\nlegacy(typescript);\n```", code)
+	assert.Equal(t, []string{"broken", "legacy"}, attempts)
+	assert.Contains(t, stderr, "(broken): unsupported")
+	assert.Equal(t, &LanguageConversionResult{TargetLanguage: "typescript", Converter: "legacy"},
+		tracker.EncounteredExamples["#/resources/test:index/gadget:Gadget"].LanguagesConvertedTo["typescript"])
+
+	dir := t.TempDir()
+	assert.NoError(t, tracker.exportResults(NewFileCoverageSink(dir)))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "summary.json"))
+	assert.NoError(t, err)
+	summary, err := coverage.ParseSummary(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, summary.Successes.Number)
+	assert.Equal(t, map[string]int{"legacy": 1}, summary.FallbackSuccesses)
+}
@@ -0,0 +1,44 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"errors"
+	"strings"
+)
+
+// errExampleExcluded is returned by convertHCL when the provider excludes every language that the example would be
+// converted to.
+var errExampleExcluded = errors.New("example conversion excluded")
+
+// exampleLanguageExcluded returns true if the provider excludes the given language from the conversion of the example
+// at the given path, e.g. `#/resources/pkg:ec2/instance:Instance`, either for all examples or for the examples of the
+// path's module.
+func (g *Generator) exampleLanguageExcluded(path, languageName string) bool {
+	for _, excluded := range g.info.ExampleExcludedLanguages {
+		if excluded == languageName {
+			return true
+		}
+	}
+
+	if components := strings.SplitN(path, "/", 3); len(components) == 3 {
+		for _, excluded := range g.info.ExampleExcludedModuleLanguages[tokenModule(components[2])] {
+			if excluded == languageName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// examplePlaceholder is a compiled tfbridge.ExamplePlaceholder.
+type examplePlaceholder struct {
+	pattern     *regexp.Regexp
+	replacement string
+	languages   map[string]string
+}
+
+// compileExamplePlaceholders compiles the patterns of the given placeholders.
+func compileExamplePlaceholders(placeholders []tfbridge.ExamplePlaceholder) ([]examplePlaceholder, error) {
+	compiled := make([]examplePlaceholder, len(placeholders))
+	for i, p := range placeholders {
+		if p.Pattern == "" {
+			return nil, errors.Errorf("example placeholder %d has an empty pattern", i)
+		}
+		pattern, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid example placeholder %q", p.Pattern)
+		}
+		compiled[i] = examplePlaceholder{pattern: pattern, replacement: p.Replacement, languages: p.Languages}
+	}
+	return compiled, nil
+}
+
+// stringLiteral is one of the forms of string literal of a language. The pattern's only group matches the contents
+// of the literal, and escape returns the given text escaped for use in the literal, or false if it cannot be used.
+// Comments are listed as literals without an escape, so that the quotes in them are not taken for string literals.
+type stringLiteral struct {
+	pattern string
+	escape  func(text string) (string, bool)
+	// interpolation matches the parts of the contents that are code rather than text, e.g. `${...}`.
+	interpolation *regexp.Regexp
+}
+
+// escapeWith returns an escape function that prefixes each of the given strings with a backslash and applies the
+// given further replacements.
+func escapeWith(special []string, oldnew ...string) func(string) (string, bool) {
+	oldnew = append([]string{`\`, `\\`}, oldnew...)
+	for _, s := range special {
+		oldnew = append(oldnew, s, `\`+s)
+	}
+	replacer := strings.NewReplacer(oldnew...)
+	return func(text string) (string, bool) {
+		return replacer.Replace(text), true
+	}
+}
+
+var (
+	doubleQuoted = stringLiteral{pattern: `"((?:[^"\\\n]|\\.)*)"`, escape: escapeWith([]string{`"`})}
+	singleQuoted = stringLiteral{pattern: `'((?:[^'\\\n]|\\.)*)'`, escape: escapeWith([]string{`'`})}
+	lineComment  = stringLiteral{pattern: `(//[^\n]*)`}
+	blockComment = stringLiteral{pattern: `(/\*(?s:.*?)\*/)`}
+
+	// braceInterpolation matches the expressions of Python f-strings and C# interpolated strings, along with the
+	// doubled braces that stand for literal ones.
+	braceInterpolation = regexp.MustCompile(`\{\{|\}\}|\{[^{}]*\}`)
+	// doubleBraces escapes the braces of Python f-strings and C# interpolated strings.
+	doubleBraces = []string{"{", "{{", "}", "}}"}
+)
+
+// exampleStringLiterals are the forms of string literal of each language that examples are converted to.
+var exampleStringLiterals = map[string][]stringLiteral{
+	"typescript": {
+		doubleQuoted,
+		singleQuoted,
+		{
+			pattern:       "`((?:[^`\\\\]|\\\\.)*)`",
+			escape:        escapeWith([]string{"`", "${"}),
+			interpolation: regexp.MustCompile(`\\.|\$\{[^}]*\}`),
+		},
+		lineComment,
+		blockComment,
+	},
+	"python": {
+		{
+			pattern:       `\b[fF]"((?:[^"\\\n]|\\.)*)"`,
+			escape:        escapeWith([]string{`"`}, doubleBraces...),
+			interpolation: braceInterpolation,
+		},
+		{
+			pattern:       `\b[fF]'((?:[^'\\\n]|\\.)*)'`,
+			escape:        escapeWith([]string{`'`}, doubleBraces...),
+			interpolation: braceInterpolation,
+		},
+		doubleQuoted,
+		singleQuoted,
+		{pattern: `(#[^\n]*)`},
+	},
+	"csharp": {
+		{
+			pattern: `(?:\$@|@\$)"((?:[^"]|"")*)"`,
+			escape: func(text string) (string, bool) {
+				return strings.NewReplacer(`"`, `""`, "{", "{{", "}", "}}").Replace(text), true
+			},
+			interpolation: braceInterpolation,
+		},
+		{pattern: `@"((?:[^"]|"")*)"`, escape: func(text string) (string, bool) {
+			return strings.ReplaceAll(text, `"`, `""`), true
+		}},
+		{
+			pattern:       `\$"((?:[^"\\\n]|\\.)*)"`,
+			escape:        escapeWith([]string{`"`}, doubleBraces...),
+			interpolation: braceInterpolation,
+		},
+		doubleQuoted,
+		lineComment,
+		blockComment,
+	},
+	"go": {
+		doubleQuoted,
+		{pattern: "`([^`]*)`", escape: func(text string) (string, bool) {
+			return text, !strings.Contains(text, "`")
+		}},
+		lineComment,
+		blockComment,
+	},
+}
+
+// exampleStringLiteralRegexps caches the regular expression that matches any string literal of each language.
+var exampleStringLiteralRegexps = map[string]*regexp.Regexp{}
+
+func init() {
+	for language, literals := range exampleStringLiterals {
+		patterns := make([]string, len(literals))
+		for i, literal := range literals {
+			patterns[i] = literal.pattern
+		}
+		exampleStringLiteralRegexps[language] = regexp.MustCompile(strings.Join(patterns, "|"))
+	}
+}
+
+// replaceExamplePlaceholders replaces the provider's placeholders in the string literals of the given example code.
+// Comments and the expressions interpolated into literals are left alone, as is code in languages whose string
+// literals are not known.
+func (g *Generator) replaceExamplePlaceholders(language, code string) string {
+	re, literals := exampleStringLiteralRegexps[language], exampleStringLiterals[language]
+	if len(g.examplePlaceholders) == 0 || re == nil {
+		return code
+	}
+	return re.ReplaceAllStringFunc(code, func(match string) string {
+		groups := re.FindStringSubmatchIndex(match)
+		for i, literal := range literals {
+			start, end := groups[2*i+2], groups[2*i+3]
+			if start < 0 {
+				continue
+			}
+			if literal.escape == nil {
+				return match
+			}
+			contents := replaceLiteralText(literal.interpolation, match[start:end], func(text string) string {
+				for _, p := range g.examplePlaceholders {
+					replacement, ok := p.languages[language]
+					if !ok {
+						replacement = p.replacement
+					}
+					if escaped, ok := literal.escape(replacement); ok {
+						text = p.pattern.ReplaceAllLiteralString(text, escaped)
+					}
+				}
+				return text
+			})
+			return match[:start] + contents + match[end:]
+		}
+		return match
+	})
+}
+
+// replaceLiteralText applies replace to the text of the given literal contents, leaving alone the parts that the
+// literal's interpolation matches.
+func replaceLiteralText(interpolation *regexp.Regexp, contents string, replace func(string) string) string {
+	if interpolation == nil {
+		return replace(contents)
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range interpolation.FindAllStringIndex(contents, -1) {
+		b.WriteString(replace(contents[last:loc[0]]))
+		b.WriteString(contents[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(replace(contents[last:]))
+	return b.String()
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+func TestReplaceExamplePlaceholders(t *testing.T) {
+	placeholders, err := compileExamplePlaceholders([]tfbridge.ExamplePlaceholder{
+		{Pattern: `\b\d{12}\b`, Replacement: "000000000000"},
+		{Pattern: `example\.(com|org)`, Replacement: `"fake".test`, Languages: map[string]string{"go": "go.test"}},
+		{Pattern: `PLACEHOLDER`, Replacement: `{id}`},
+	})
+	assert.NoError(t, err)
+	g := &Generator{examplePlaceholders: placeholders}
+
+	tests := []struct {
+		language, code, expected string
+	}{
+		// Only string literals are affected.
+		{"typescript", `const id = 123456789012; const arn = "arn:aws:iam::123456789012:root";`,
+			`const id = 123456789012; const arn = "arn:aws:iam::000000000000:root";`},
+		// Replacements are escaped as each form of literal requires.
+		{"typescript", "const a = \"www.example.com\", b = 'example.org', c = `${x}.example.com`;",
+			"const a = \"www.\\\"fake\\\".test\", b = '\"fake\".test', c = `${x}.\"fake\".test`;"},
+		{"python", `url = "https://example.com/path"`, `url = "https://\"fake\".test/path"`},
+		{"csharp", `var a = "example.com"; var b = @"C:\example.com";`,
+			`var a = "\"fake\".test"; var b = @"C:\""fake"".test";`},
+		// Replacements can differ by language.
+		{"go", "a := \"example.com\"\nb := `example.org`", "a := \"go.test\"\nb := `go.test`"},
+		// Comments are left alone, even when they contain quotes.
+		{"typescript", "// Don't use example.com\nconst a = 'example.com'; /* it's example.org */",
+			"// Don't use example.com\nconst a = '\"fake\".test'; /* it's example.org */"},
+		{"go", "// Don't use example.com\na := \"example.com\"", "// Don't use example.com\na := \"go.test\""},
+		{"python", "# Don't use example.com\na = 'example.com'", "# Don't use example.com\na = '\"fake\".test'"},
+		// Interpolated expressions are left alone, and braces are escaped in the literals that interpolate them.
+		{"typescript", "const a = `${PLACEHOLDER}-PLACEHOLDER`;", "const a = `${PLACEHOLDER}-{id}`;"},
+		{"python", `a = f"{PLACEHOLDER}-PLACEHOLDER"`, `a = f"{PLACEHOLDER}-{{id}}"`},
+		{"python", `a = "PLACEHOLDER"`, `a = "{id}"`},
+		{"csharp", `var a = $"{PLACEHOLDER}-PLACEHOLDER"; var b = $@"{x}\PLACEHOLDER";`,
+			`var a = $"{PLACEHOLDER}-{{id}}"; var b = $@"{x}\{{id}}";`},
+		// Languages whose literals are not known are left alone.
+		{"yaml", `name: "example.com"`, `name: "example.com"`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, g.replaceExamplePlaceholders(tt.language, tt.code), tt.language)
+	}
+
+	_, err = compileExamplePlaceholders([]tfbridge.ExamplePlaceholder{{Pattern: "("}})
+	assert.Error(t, err)
+}
+
+func TestExamplePlaceholdersInConvertedExamples(t *testing.T) {
+	g, err := NewGenerator(GeneratorOptions{
+		Package:  "test",
+		Version:  "0.0.1",
+		Language: NodeJS,
+		Sink:     diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name: "test",
+			ExamplePlaceholders: []tfbridge.ExamplePlaceholder{
+				{Pattern: `\b\d{12}\b`, Replacement: "000000000000"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	hcl := "output \"arn\" {\n  value = \"arn:aws:iam::123456789012:root\"\n}"
+	code, _, err := g.convertHCL(hcl, "#/resources/test:index/widget:Widget")
+	assert.NoError(t, err)
+	assert.Contains(t, code, "arn:aws:iam::000000000000:root")
+	assert.NotContains(t, code, "123456789012")
+
+	_, err = NewGenerator(GeneratorOptions{
+		Package:  "test",
+		Version:  "0.0.1",
+		Language: NodeJS,
+		Sink:     diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name:                "test",
+			ExamplePlaceholders: []tfbridge.ExamplePlaceholder{{Pattern: "["}},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -13,39 +13,67 @@
 // limitations under the License.
 
 // This file implements the methods used by the Coverage Tracker in order
-// to export the data it collected into various JSON formats.
+// to export the data it collected into various JSON and CSV formats, which are handed to a CoverageSink.
 
 package tfgen
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"sort"
+	"strconv"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfgen/coverage"
 )
 
-// The export utility's main structure, where it stores the desired output directory
-// and a reference to the CoverageTracker that created it
+// The export utility's main structure, where it stores a reference to the CoverageTracker that created it
+// and the reports produced so far
 type coverageExportUtil struct {
-	Tracker *CoverageTracker // Reference to the Coverage Tracker that wants to turn its data into a file
+	Tracker *CoverageTracker // Reference to the Coverage Tracker that wants to turn its data into reports
+	reports []CoverageReport
 }
 
 func newCoverageExportUtil(coverageTracker *CoverageTracker) coverageExportUtil {
-	return coverageExportUtil{coverageTracker}
+	return coverageExportUtil{Tracker: coverageTracker}
+}
+
+// The entire export utility interface. Will attempt to turn the Coverage Tracker's data into reports
+// and send them to the given sink, returning the first error encountered along the way
+func (ce *coverageExportUtil) tryExport(sink CoverageSink) error {
+	results, err := ce.collectResults()
+	if err != nil {
+		return err
+	}
+	return sink.Write(results)
 }
 
-// The entire export utility interface. Will attempt to export the Coverage Tracker's data into the
-// specified output directory, and will panic if an error is encountered along the way
-func (ce *coverageExportUtil) tryExport(outputDirectory string) error {
+// Produces every report from the Coverage Tracker's data
+func (ce *coverageExportUtil) collectResults() (CoverageResults, error) {
+	ce.reports = nil
+	if err := ce.collectReports(); err != nil {
+		return CoverageResults{}, err
+	}
+	return CoverageResults{
+		ProviderName:    ce.Tracker.ProviderName,
+		ProviderVersion: ce.Tracker.ProviderVersion,
+		Reports:         ce.reports,
+	}, nil
+}
+
+func (ce *coverageExportUtil) collectReports() error {
 
 	// "summary.json" is the file name that other Pulumi coverage trackers use
-	var err = ce.exportByExample(outputDirectory, "byExample.json")
+	var err = ce.exportByExample("byExample.json")
+	if err != nil {
+		return err
+	}
+	err = ce.exportCSV("byExample.csv")
 	if err != nil {
 		return err
 	}
-	err = ce.exportByLanguage(outputDirectory, "byLanguage.json")
+	err = ce.exportByLanguage("byLanguage.json")
 	if err != nil {
 		return err
 	}
@@ -53,49 +81,78 @@ func (ce *coverageExportUtil) tryExport(outputDirectory string) error {
 	// `summary.json` & `shortSummary.txt` are magic filenames used by pulumi/ci-mgmt/provider-ci.
 	// If it finds these files, `summary.json` gets uploaded to S3 for cloudwatch analysis, and
 	// `shortSummary.txt` is read by the terminal to be visible in Github Actions for inspection
-	err = ce.exportOverall(outputDirectory, "summary.json")
+	err = ce.exportOverall("summary.json")
+	if err != nil {
+		return err
+	}
+	err = ce.exportHumanReadable("shortSummary.txt")
+	if err != nil {
+		return err
+	}
+	err = ce.exportSchemaStats("schemaStats.json")
+	if err != nil {
+		return err
+	}
+	err = ce.exportDocsCoverage("byDocsCoverage.json")
+	if err != nil {
+		return err
+	}
+	err = ce.exportDocTranslations("byLocale.json")
+	if err != nil {
+		return err
+	}
+	err = ce.exportDocValues("docValues.json")
+	if err != nil {
+		return err
+	}
+	err = ce.exportBrokenDocLinks("docLinksLint.json")
 	if err != nil {
 		return err
 	}
-	return ce.exportHumanReadable(outputDirectory, "shortSummary.txt")
+	err = ce.exportUnresolvedDocReferences("docReferencesLint.json")
+	if err != nil {
+		return err
+	}
+	return ce.exportCompatibility("compatibility.json")
 }
 
 // Four different ways to export coverage data:
 // The first mode, which lists each example individually in one big file. This is the most detailed.
-func (ce *coverageExportUtil) exportByExample(outputDirectory string, fileName string) error {
+func (ce *coverageExportUtil) exportByExample(fileName string) error {
 
 	// The Coverage Tracker data structure is flattened down to the example level, and they all
-	// get individually written to the file in order to not have the "{ }" brackets at the start and end
-	type SingleExampleResult struct {
-		ProviderName    string
-		ProviderVersion string
-		ExampleName     string
-		OriginalHCL     string `json:"OriginalHCL,omitempty"`
-		IsDuplicated    bool
-		FailedLanguages []LanguageConversionResult `json:"FailedLanguages,omitempty"`
-	}
-
-	jsonOutputLocation, err := createEmptyFile(outputDirectory, fileName)
-	if err != nil {
-		return err
+	// get individually written to the report in order to not have the "{ }" brackets at the start and end
+	// All the examples in the map are iterated in order of their names and marshalled into one large byte
+	// array separated by \n, making the end result look like a bunch of Json files that got concatenated
+	exampleNames := make([]string, 0, len(ce.Tracker.EncounteredExamples))
+	for name := range ce.Tracker.EncounteredExamples {
+		exampleNames = append(exampleNames, name)
 	}
+	sort.Strings(exampleNames)
 
-	// All the examples in the map are iterated by key and marshalled into one large byte array
-	// separated by \n, making the end result look like a bunch of Json files that got concatenated
 	var result []byte
-	for _, exampleInMap := range ce.Tracker.EncounteredExamples {
-		singleExample := SingleExampleResult{
-			ProviderName:    ce.Tracker.ProviderName,
-			ProviderVersion: ce.Tracker.ProviderVersion,
-			ExampleName:     exampleInMap.Name,
-			OriginalHCL:     "",
-			FailedLanguages: []LanguageConversionResult{},
+	for _, exampleName := range exampleNames {
+		exampleInMap := ce.Tracker.EncounteredExamples[exampleName]
+		singleExample := coverage.ExampleResult{
+			ProviderName:      ce.Tracker.ProviderName,
+			ProviderVersion:   ce.Tracker.ProviderVersion,
+			ExampleName:       exampleInMap.Name,
+			OriginalHCL:       "",
+			IsNormalized:      exampleInMap.Normalized,
+			FailedLanguages:   []coverage.ConversionResult{},
+			ExcludedLanguages: exampleInMap.ExcludedLanguages,
 		}
 
-		// The current example's language conversion results are iterated over. If the severity is
-		// anything but zero, then it means some sort of error occurred during conversion and
-		// should be logged for future analysis.
-		for _, conversionResult := range exampleInMap.LanguagesConvertedTo {
+		// The current example's language conversion results are iterated over in order of language. If
+		// the severity is anything but zero, then it means some sort of error occurred during conversion
+		// and should be logged for future analysis.
+		languages := make([]string, 0, len(exampleInMap.LanguagesConvertedTo))
+		for language := range exampleInMap.LanguagesConvertedTo {
+			languages = append(languages, language)
+		}
+		sort.Strings(languages)
+		for _, language := range languages {
+			conversionResult := exampleInMap.LanguagesConvertedTo[language]
 			if conversionResult.FailureSeverity != 0 {
 				singleExample.OriginalHCL = exampleInMap.OriginalHCL
 				singleExample.FailedLanguages = append(singleExample.FailedLanguages, *conversionResult)
@@ -108,66 +165,133 @@ func (ce *coverageExportUtil) exportByExample(outputDirectory string, fileName s
 		}
 		result = append(append(result, marshalledExample...), uint8('\n'))
 	}
-	return ioutil.WriteFile(jsonOutputLocation, result, 0600)
+	ce.addReport(fileName, result)
+	return nil
 }
 
-// The second mode, which exports information about each language such as total number of
-// examples, common failure messages, and failure severity percentages.
-func (ce *coverageExportUtil) exportByLanguage(outputDirectory string, fileName string) error {
+// The CSV mode, which lists the result of converting each example to each language as one row, for ingestion into
+// tools that work with tabular data. Languages that the provider excludes are listed with the result "excluded".
+func (ce *coverageExportUtil) exportCSV(fileName string) error {
+	exampleNames := make([]string, 0, len(ce.Tracker.EncounteredExamples))
+	for name := range ce.Tracker.EncounteredExamples {
+		exampleNames = append(exampleNames, name)
+	}
+	sort.Strings(exampleNames)
 
-	// The Coverage Tracker data structure is flattened to gather statistics about each language
-	type NumPct struct {
-		Number int
-		Pct    float64
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	err := w.Write([]string{"ProviderName", "ProviderVersion", "ExampleName", "Language", "Result",
+		"FailureInfo", "IsDuplicated", "IsNormalized", "Converter", "ElapsedSeconds"})
+	if err != nil {
+		return err
 	}
+	for _, exampleName := range exampleNames {
+		exampleInMap := ce.Tracker.EncounteredExamples[exampleName]
+		// row writes the result of converting the example to one language, which is nil for excluded languages.
+		row := func(language string, r *LanguageConversionResult) error {
+			result, failureInfo, duplicated, converter, elapsed := "excluded", "", false, "", ""
+			if r != nil {
+				result, failureInfo = conversionResultName(r.FailureSeverity), r.FailureInfo
+				duplicated, converter = r.MultipleTranslations, r.Converter
+				if r.ElapsedTime > 0 {
+					elapsed = strconv.FormatFloat(r.ElapsedTime.Seconds(), 'f', -1, 64)
+				}
+			}
+			return w.Write([]string{ce.Tracker.ProviderName, ce.Tracker.ProviderVersion, exampleInMap.Name,
+				language, result, failureInfo, strconv.FormatBool(duplicated),
+				strconv.FormatBool(exampleInMap.Normalized), converter, elapsed})
+		}
 
-	type ErrorMessage struct {
-		Reason string
-		Count  int
+		languages := make([]string, 0, len(exampleInMap.LanguagesConvertedTo))
+		for language := range exampleInMap.LanguagesConvertedTo {
+			languages = append(languages, language)
+		}
+		sort.Strings(languages)
+		for _, language := range languages {
+			if err := row(language, exampleInMap.LanguagesConvertedTo[language]); err != nil {
+				return err
+			}
+		}
+		excluded := append([]string{}, exampleInMap.ExcludedLanguages...)
+		sort.Strings(excluded)
+		for _, language := range excluded {
+			if err := row(language, nil); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
 	}
+	ce.addReport(fileName, buf.Bytes())
+	return nil
+}
 
-	type LanguageStatistic struct {
-		Total           int
-		Successes       NumPct
-		Warnings        NumPct
-		Failures        NumPct
-		Fatals          NumPct
-		_errorHistogram map[string]int
-		FrequentErrors  []ErrorMessage
+// conversionResultName returns the name of the given failure severity, as listed in the CSV report.
+func conversionResultName(severity int) string {
+	switch severity {
+	case Success:
+		return "success"
+	case Warning:
+		return "warning"
+	case Failure:
+		return "failure"
+	case Fatal:
+		return "fatal"
+	default:
+		return strconv.Itoa(severity)
 	}
+}
 
-	// Main map for holding all the language conversion statistics
-	var allLanguageStatistics = make(map[string]*LanguageStatistic)
+// The second mode, which exports information about each language such as total number of
+// examples, common failure messages, and failure severity percentages.
+func (ce *coverageExportUtil) exportByLanguage(fileName string) error {
+
+	// The Coverage Tracker data structure is flattened to gather statistics about each language.
+	// Main map for holding all the language conversion statistics, and the histogram of each language's errors
+	var allLanguageStatistics = make(map[string]*coverage.LanguageStatistic)
+	var errorHistograms = make(map[string]map[string]int)
+	languageStatistic := func(languageName string) *coverage.LanguageStatistic {
+		if val, ok := allLanguageStatistics[languageName]; ok {
+
+			// The main map already contains the language entry
+			return val
+		}
+
+		// The main map doesn't yet contain this language, and it needs to be added
+		allLanguageStatistics[languageName] = &coverage.LanguageStatistic{
+			FrequentErrors: []coverage.ErrorMessage{},
+		}
+		errorHistograms[languageName] = make(map[string]int)
+		return allLanguageStatistics[languageName]
+	}
 
 	// All the conversion attempts for each example are iterated by language name and
 	// their results are added to the main map
 	for _, exampleInMap := range ce.Tracker.EncounteredExamples {
+		for _, languageName := range exampleInMap.ExcludedLanguages {
+			languageStatistic(languageName).Excluded++
+		}
 		for _, conversionResult := range exampleInMap.LanguagesConvertedTo {
-			var language *LanguageStatistic
-			if val, ok := allLanguageStatistics[conversionResult.TargetLanguage]; ok {
-
-				// The main map already contains the language entry
-				language = val
-			} else {
-
-				// The main map doesn't yet contain this language, and it needs to be added
-				allLanguageStatistics[conversionResult.TargetLanguage] = &LanguageStatistic{0,
-					NumPct{0, 0.0}, NumPct{0, 0.0},
-					NumPct{0, 0.0}, NumPct{0, 0.0},
-					make(map[string]int), []ErrorMessage{}}
-				language = allLanguageStatistics[conversionResult.TargetLanguage]
-			}
+			language := languageStatistic(conversionResult.TargetLanguage)
 
 			// The language's entry in the summarized results is updated and any
 			// error messages are saved
 			language.Total++
 			if conversionResult.FailureSeverity == Success {
 				language.Successes.Number++
+				if conversionResult.Converter != "" {
+					if language.FallbackSuccesses == nil {
+						language.FallbackSuccesses = map[string]int{}
+					}
+					language.FallbackSuccesses[conversionResult.Converter]++
+				}
 			} else {
 
 				// A failure occurred during conversion so we take the failure info
 				// and add it to the histogram
-				language._errorHistogram[conversionResult.FailureInfo]++
+				errorHistograms[conversionResult.TargetLanguage][conversionResult.FailureInfo]++
 
 				switch conversionResult.FailureSeverity {
 				case Warning:
@@ -181,7 +305,10 @@ func (ce *coverageExportUtil) exportByLanguage(outputDirectory string, fileName
 		}
 	}
 
-	for _, language := range allLanguageStatistics {
+	for languageName, language := range allLanguageStatistics {
+		if language.Total == 0 {
+			continue
+		}
 
 		// Calculating error percentages for all languages that were found
 		language.Successes.Pct = float64(language.Successes.Number) / float64(language.Total) * 100.0
@@ -190,8 +317,8 @@ func (ce *coverageExportUtil) exportByLanguage(outputDirectory string, fileName
 		language.Fatals.Pct = float64(language.Fatals.Number) / float64(language.Total) * 100.0
 
 		// Appending and sorting conversion errors by their frequency
-		for reason, count := range language._errorHistogram {
-			language.FrequentErrors = append(language.FrequentErrors, ErrorMessage{reason, count})
+		for reason, count := range errorHistograms[languageName] {
+			language.FrequentErrors = append(language.FrequentErrors, coverage.ErrorMessage{Reason: reason, Count: count})
 		}
 		sort.Slice(language.FrequentErrors, func(index1, index2 int) bool {
 			if language.FrequentErrors[index1].Count != language.FrequentErrors[index2].Count {
@@ -202,59 +329,45 @@ func (ce *coverageExportUtil) exportByLanguage(outputDirectory string, fileName
 		})
 	}
 
-	jsonOutputLocation, err := createEmptyFile(outputDirectory, fileName)
-	if err != nil {
-		return err
-	}
-	return marshalAndWriteJSON(allLanguageStatistics, jsonOutputLocation)
+	return ce.addJSONReport(fileName, allLanguageStatistics)
 }
 
 // The third mode, which lists failure reaons, quantities and percentages for the provider as a whole.
-func (ce *coverageExportUtil) exportOverall(outputDirectory string, fileName string) error {
-
-	// The Coverage Tracker data structure is flattened to gather statistics about the provider
-	type NumPct struct {
-		Number int
-		Pct    float64
-	}
-
-	type ErrorMessage struct {
-		Reason string
-		Count  int
+func (ce *coverageExportUtil) exportOverall(fileName string) error {
+
+	// The Coverage Tracker data structure is flattened to gather statistics about the provider.
+	// Main variable for holding the overall provider conversion results, and the histogram of its errors
+	var providerStatistic = coverage.Summary{
+		Version:          coverage.SummaryVersion,
+		Name:             ce.Tracker.ProviderName,
+		ProviderVersion:  ce.Tracker.ProviderVersion,
+		ConversionErrors: []coverage.ErrorMessage{},
 	}
-
-	type ProviderStatistic struct {
-		Name             string
-		Version          string
-		Examples         int
-		TotalConversions int
-		Successes        NumPct
-		Warnings         NumPct
-		Failures         NumPct
-		Fatals           NumPct
-		_errorHistogram  map[string]int
-		ConversionErrors []ErrorMessage
-	}
-
-	// Main variable for holding the overall provider conversion results
-	var providerStatistic = ProviderStatistic{ce.Tracker.ProviderName,
-		ce.Tracker.ProviderVersion, 0, 0, NumPct{0, 0.0},
-		NumPct{0, 0.0}, NumPct{0, 0.0},
-		NumPct{0, 0.0}, make(map[string]int), []ErrorMessage{}}
+	var errorHistogram = make(map[string]int)
 
 	// All the conversion attempts for each example are iterated by language name and
 	// their results are added to the overall statistic
 	for _, exampleInMap := range ce.Tracker.EncounteredExamples {
 		providerStatistic.Examples++
+		if exampleInMap.Normalized {
+			providerStatistic.NormalizedExamples++
+		}
+		providerStatistic.ExcludedConversions += len(exampleInMap.ExcludedLanguages)
 		for _, conversionResult := range exampleInMap.LanguagesConvertedTo {
 			providerStatistic.TotalConversions++
 			if conversionResult.FailureSeverity == Success {
 				providerStatistic.Successes.Number++
+				if conversionResult.Converter != "" {
+					if providerStatistic.FallbackSuccesses == nil {
+						providerStatistic.FallbackSuccesses = map[string]int{}
+					}
+					providerStatistic.FallbackSuccesses[conversionResult.Converter]++
+				}
 			} else {
 
 				// A failure occurred during conversion so we take the failure info
 				// and add it to the histogram
-				providerStatistic._errorHistogram[conversionResult.FailureInfo]++
+				errorHistogram[conversionResult.FailureInfo]++
 
 				switch conversionResult.FailureSeverity {
 				case Warning:
@@ -279,9 +392,9 @@ func (ce *coverageExportUtil) exportOverall(outputDirectory string, fileName str
 		float64(providerStatistic.TotalConversions) * 100.0
 
 	// Appending and sorting conversion errors by their frequency
-	for reason, count := range providerStatistic._errorHistogram {
+	for reason, count := range errorHistogram {
 		providerStatistic.ConversionErrors = append(providerStatistic.ConversionErrors,
-			ErrorMessage{reason, count})
+			coverage.ErrorMessage{Reason: reason, Count: count})
 	}
 	sort.Slice(providerStatistic.ConversionErrors, func(index1, index2 int) bool {
 		if providerStatistic.ConversionErrors[index1].Count != providerStatistic.ConversionErrors[index2].Count {
@@ -291,15 +404,11 @@ func (ce *coverageExportUtil) exportOverall(outputDirectory string, fileName str
 		return providerStatistic.ConversionErrors[index1].Reason > providerStatistic.ConversionErrors[index2].Reason
 	})
 
-	jsonOutputLocation, err := createEmptyFile(outputDirectory, fileName)
-	if err != nil {
-		return err
-	}
-	return marshalAndWriteJSON(providerStatistic, jsonOutputLocation)
+	return ce.addJSONReport(fileName, providerStatistic)
 }
 
 // The fourth mode, which simply gives the provider name, and success percentage.
-func (ce *coverageExportUtil) exportHumanReadable(outputDirectory string, fileName string) error {
+func (ce *coverageExportUtil) exportHumanReadable(fileName string) error {
 
 	// The Coverage Tracker data structure is flattened to gather statistics about each language
 	type LanguageStatistic struct {
@@ -308,20 +417,22 @@ func (ce *coverageExportUtil) exportHumanReadable(outputDirectory string, fileNa
 	}
 
 	type ProviderStatistic struct {
-		Name             string
-		Examples         int
-		TotalConversions int
-		Successes        int
+		Name                string
+		Examples            int
+		TotalConversions    int
+		Successes           int
+		ExcludedConversions int
 	}
 
 	// Main maps for holding the overall provider summary, and each language conversion statistic
 	var allLanguageStatistics = make(map[string]*LanguageStatistic)
-	var providerStatistic = ProviderStatistic{ce.Tracker.ProviderName, 0, 0, 0}
+	var providerStatistic = ProviderStatistic{ce.Tracker.ProviderName, 0, 0, 0, 0}
 
 	// All the conversion attempts for each example are iterated by language name and
 	// their results are added to the main map
 	for _, exampleInMap := range ce.Tracker.EncounteredExamples {
 		providerStatistic.Examples++
+		providerStatistic.ExcludedConversions += len(exampleInMap.ExcludedLanguages)
 		for _, conversionResult := range exampleInMap.LanguagesConvertedTo {
 			providerStatistic.TotalConversions++
 			var language *LanguageStatistic
@@ -345,18 +456,16 @@ func (ce *coverageExportUtil) exportHumanReadable(outputDirectory string, fileNa
 		}
 	}
 
-	targetFile, err := createEmptyFile(outputDirectory, fileName)
-	if err != nil {
-		return err
-	}
-
-	// Forming a string which will eventually be written to the target file
+	// Forming a string which will eventually be written to the report
 	fileString := fmt.Sprintf("Provider:     %s\nSuccess rate: %.2f%% (%d/%d)\n\n",
 		providerStatistic.Name,
 		float64(providerStatistic.Successes)/float64(providerStatistic.TotalConversions)*100.0,
 		providerStatistic.Successes,
 		providerStatistic.TotalConversions,
 	)
+	if providerStatistic.ExcludedConversions > 0 {
+		fileString += fmt.Sprintf("Excluded:     %d conversions\n\n", providerStatistic.ExcludedConversions)
+	}
 
 	// Adding language results to the string in alphabetical order
 	keys := make([]string, 0, len(allLanguageStatistics))
@@ -376,20 +485,76 @@ func (ce *coverageExportUtil) exportHumanReadable(outputDirectory string, fileNa
 		)
 	}
 
-	return ioutil.WriteFile(targetFile, []byte(fileString), 0600)
+	ce.addReport(fileName, []byte(fileString))
+	return nil
 }
 
 // Minor helper functions to assist with exporting results
-func createEmptyFile(outputDirectory string, fileName string) (string, error) {
-	outputLocation := filepath.Join(outputDirectory, fileName)
-	err := os.MkdirAll(outputDirectory, 0700)
-	return outputLocation, err
+// Alongside the coverage data, statistics on the shape of the generated schema are exported, if any were collected.
+func (ce *coverageExportUtil) exportSchemaStats(fileName string) error {
+	if ce.Tracker.schemaStats == nil {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.schemaStats)
+}
+
+// Documentation coverage is exported per resource and function, listing the properties that have no description.
+func (ce *coverageExportUtil) exportDocsCoverage(fileName string) error {
+	if ce.Tracker.docsCoverage == nil {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.docsCoverage)
+}
+
+// Documentation translation coverage is exported per locale, listing the pages that were left untranslated.
+func (ce *coverageExportUtil) exportDocTranslations(fileName string) error {
+	if len(ce.Tracker.docTranslations) == 0 {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.docTranslations)
+}
+
+// Default and example values extracted from property docs are exported for human verification.
+func (ce *coverageExportUtil) exportDocValues(fileName string) error {
+	if len(ce.Tracker.docValues) == 0 {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.docValues)
+}
+
+// Broken links in the generated docs are exported as a lint report.
+func (ce *coverageExportUtil) exportBrokenDocLinks(fileName string) error {
+	if len(ce.Tracker.brokenDocLinks) == 0 {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.brokenDocLinks)
+}
+
+// References in property docs to upstream resources and data sources that are not mapped are exported as a lint report.
+func (ce *coverageExportUtil) exportUnresolvedDocReferences(fileName string) error {
+	if len(ce.Tracker.unresolvedDocRefs) == 0 {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.unresolvedDocRefs)
+}
+
+// Exports the Terraform protocol and SDK features that the upstream provider uses, and any that the bridge lacks.
+func (ce *coverageExportUtil) exportCompatibility(fileName string) error {
+	if ce.Tracker.compatibility == nil {
+		return nil
+	}
+	return ce.addJSONReport(fileName, ce.Tracker.compatibility)
+}
+
+func (ce *coverageExportUtil) addReport(fileName string, contents []byte) {
+	ce.reports = append(ce.reports, CoverageReport{Name: fileName, Contents: contents})
 }
 
-func marshalAndWriteJSON(unmarshalledData interface{}, finalDestination string) error {
+func (ce *coverageExportUtil) addJSONReport(fileName string, unmarshalledData interface{}) error {
 	jsonBytes, err := json.MarshalIndent(unmarshalledData, "", "\t")
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(finalDestination, jsonBytes, 0600)
+	ce.addReport(fileName, jsonBytes)
+	return nil
 }
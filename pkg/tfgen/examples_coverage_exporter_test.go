@@ -0,0 +1,73 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfgen/coverage"
+)
+
+func TestExportSummary(t *testing.T) {
+	tracker := newCoverageTracker("aws", "1.0.0")
+	tracker.foundExample("#/resources/example", "")
+	tracker.languageConversionSuccess("python")
+	tracker.languageConversionFailure("nodejs", nil)
+	tracker.languageConversionExcluded("csharp")
+
+	dir := t.TempDir()
+	assert.NoError(t, tracker.exportResults(NewFileCoverageSink(dir)))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "summary.json"))
+	assert.NoError(t, err)
+	summary, err := coverage.ParseSummary(data)
+	assert.NoError(t, err)
+	assert.Equal(t, coverage.SummaryVersion, summary.Version)
+	assert.Equal(t, "1.0.0", summary.ProviderVersion)
+	assert.Equal(t, 2, summary.TotalConversions)
+	assert.Equal(t, coverage.NumPct{Number: 1, Pct: 50}, summary.Failures)
+	assert.Equal(t, 1, summary.ExcludedConversions)
+}
+
+func TestExportCSV(t *testing.T) {
+	tracker := newCoverageTracker("aws", "1.0.0")
+	tracker.foundExample("#/resources/widget", "")
+	tracker.languageConversionSuccess("python")
+	tracker.languageConversionPanic("nodejs", "boom, \"quoted\"")
+	tracker.languageConversionExcluded("csharp")
+	tracker.foundExample("#/functions/getWidget", "")
+	tracker.languageConversionSuccess("go")
+
+	dir := t.TempDir()
+	assert.NoError(t, tracker.exportResults(NewFileCoverageSink(dir)))
+	f, err := os.Open(filepath.Join(dir, "byExample.csv"))
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"ProviderName", "ProviderVersion", "ExampleName", "Language", "Result", "FailureInfo", "IsDuplicated",
+			"IsNormalized", "Converter", "ElapsedSeconds"},
+		{"aws", "1.0.0", "#/functions/getWidget", "go", "success", "", "false", "false", "", ""},
+		{"aws", "1.0.0", "#/resources/widget", "nodejs", "fatal", "boom, \"quoted\"", "false", "false", "", ""},
+		{"aws", "1.0.0", "#/resources/widget", "python", "success", "", "false", "false", "", ""},
+		{"aws", "1.0.0", "#/resources/widget", "csharp", "excluded", "", "false", "false", "", ""},
+	}, rows)
+}
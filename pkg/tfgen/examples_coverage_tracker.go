@@ -30,20 +30,32 @@ package tfgen
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfgen/coverage"
 )
 
 // Main overarching structure for storing coverage data on how many examples were processed,
 // how many failed, and for what reason. At different stages, the code translator notifies
 // the tracker of what is going on. Notifications are treated as an ordered stream of events.
 // INTERFACE:
-// foundExample(), languageConversionSuccess(), languageConversionFailure(), languageConversionPanic().
+// foundExample(), exampleNormalized(), languageConversionSuccess(), languageConversionFailure(),
+// languageConversionPanic(), languageConversionTimeout(), languageConversionTransformFailure().
 type CoverageTracker struct {
 	ProviderName        string                         // Name of the provider
 	ProviderVersion     string                         // Version of the provider
 	currentExampleName  string                         // Name of current example that is being processed
 	EncounteredExamples map[string]*GeneralExampleInfo // Mapping example names to their general information
+	schemaStats         *schemaStats                   // Statistics on the shape of the generated schema
+	docsCoverage        *docsCoverage                  // How many schema properties received a description
+	docTranslations     []*docTranslation              // Translation coverage for each documentation locale
+	docValues           []docValueEntry                // Default and example values extracted from property docs
+	brokenDocLinks      []brokenDocLink                // Links to registry docs that the package does not generate
+	unresolvedDocRefs   []unresolvedDocReference       // References in property docs to unmapped upstream entities
+	compatibility       *compatibilityReport           // Terraform protocol and SDK features used by the provider
 }
 
 // General information about an example, and how successful it was at being converted to different languages
@@ -52,30 +64,73 @@ type GeneralExampleInfo struct {
 	OriginalHCL            string
 	LanguagesConvertedTo   map[string]*LanguageConversionResult // Mapping language names to their conversion diagnostics
 	NameFoundMultipleTimes bool                                 // Current name has already been encountered before
+	Normalized             bool                                 // HTML entities or unicode had to be normalized
+	ExcludedLanguages      []string                             // Languages the provider excludes from conversion
 }
 
 // Individual language information concerning how successfully an example was converted to Pulumi
-type LanguageConversionResult struct {
-	TargetLanguage  string
-	FailureSeverity int    // [Success, Warning, Failure, Fatal]
-	FailureInfo     string // Additional in-depth information
-
-	// !! Current example name has already been converted for this specific language before.
-	// Either the example is duplicated, or a bug is present !!
-	MultipleTranslations bool
-}
+type LanguageConversionResult = coverage.ConversionResult
 
 // Failure severity values
 const (
-	Success = 0
-	Warning = 1
-	Failure = 2
-	Fatal   = 3
+	Success = coverage.Success
+	Warning = coverage.Warning
+	Failure = coverage.Failure
+	Fatal   = coverage.Fatal
 )
 
 func newCoverageTracker(ProviderName string, ProviderVersion string) *CoverageTracker {
 	return &CoverageTracker{ProviderName, ProviderVersion, "",
-		make(map[string]*GeneralExampleInfo)}
+		make(map[string]*GeneralExampleInfo), nil, nil, nil, nil, nil, nil, nil}
+}
+
+// Used when: generator has produced the Pulumi schema for the provider
+func (ct *CoverageTracker) foundSchema(spec pschema.PackageSpec) {
+	if ct == nil {
+		return
+	}
+	ct.schemaStats = computeSchemaStats(spec)
+	ct.docsCoverage = computeDocsCoverage(spec)
+}
+
+// Used when: generator has translated the schema's documentation into another locale
+func (ct *CoverageTracker) foundDocTranslation(translation *docTranslation) {
+	if ct == nil {
+		return
+	}
+	ct.docTranslations = append(ct.docTranslations, translation)
+}
+
+// Used when: generator has checked the links in the schema's docs
+func (ct *CoverageTracker) foundBrokenDocLinks(links []brokenDocLink) {
+	if ct == nil {
+		return
+	}
+	ct.brokenDocLinks = links
+}
+
+// Used when: generator has linked references to other resources and data sources in property docs
+func (ct *CoverageTracker) foundUnresolvedDocReferences(refs []unresolvedDocReference) {
+	if ct == nil {
+		return
+	}
+	ct.unresolvedDocRefs = refs
+}
+
+// Used when: generator has inspected the upstream provider's protocol and SDK features
+func (ct *CoverageTracker) foundCompatibility(report *compatibilityReport) {
+	if ct == nil {
+		return
+	}
+	ct.compatibility = report
+}
+
+// Used when: generator has extracted default and example values from the schema's property docs
+func (ct *CoverageTracker) foundDocValues(report []docValueEntry) {
+	if ct == nil {
+		return
+	}
+	ct.docValues = report
 }
 
 // Used when: generator has found a new example with a convertible block of HCL
@@ -88,7 +143,33 @@ func (ct *CoverageTracker) foundExample(exampleName string, hcl string) {
 		val.NameFoundMultipleTimes = true
 	} else {
 		ct.EncounteredExamples[exampleName] = &GeneralExampleInfo{exampleName, hcl,
-			make(map[string]*LanguageConversionResult), false}
+			make(map[string]*LanguageConversionResult), false, false, nil}
+	}
+}
+
+// Used when: current example's HCL contained HTML entities or unicode characters that had to be normalized
+func (ct *CoverageTracker) exampleNormalized() {
+	if ct == nil {
+		return
+	}
+	if example, ok := ct.EncounteredExamples[ct.currentExampleName]; ok {
+		example.Normalized = true
+	}
+}
+
+// Used when: the provider excludes a certain language from the conversion of the current example. Exclusions are
+// intentional, so they are reported separately rather than as conversion results.
+func (ct *CoverageTracker) languageConversionExcluded(targetLanguage string) {
+	if ct == nil {
+		return
+	}
+	if example, ok := ct.EncounteredExamples[ct.currentExampleName]; ok {
+		for _, language := range example.ExcludedLanguages {
+			if language == targetLanguage {
+				return
+			}
+		}
+		example.ExcludedLanguages = append(example.ExcludedLanguages, targetLanguage)
 	}
 }
 
@@ -105,8 +186,30 @@ func (ct *CoverageTracker) languageConversionSuccess(targetLanguage string) {
 	})
 }
 
-//nolint
+// Used when: a fallback converter has converted the current example to a certain language after the bridge's
+// converter failed to. The fallback's success replaces the failure that was recorded for the language.
+func (ct *CoverageTracker) languageConversionFallbackSuccess(targetLanguage, converter string) {
+	if ct == nil {
+		return
+	}
+	if example, ok := ct.EncounteredExamples[ct.currentExampleName]; ok {
+		var multiple bool
+		if existing, ok := example.LanguagesConvertedTo[targetLanguage]; ok {
+			multiple = existing.MultipleTranslations
+		}
+		example.LanguagesConvertedTo[targetLanguage] = &LanguageConversionResult{
+			TargetLanguage:       targetLanguage,
+			FailureSeverity:      0,
+			FailureInfo:          "",
+			MultipleTranslations: multiple,
+			Converter:            converter,
+		}
+	}
+}
+
 // Used when: generator has successfully converted current example, but threw out some warnings
+//
+//nolint:deadcode,unused
 func (ct *CoverageTracker) languageConversionWarning(targetLanguage string, warningDiagnostics hcl.Diagnostics) {
 	if ct == nil {
 		return
@@ -146,6 +249,35 @@ func (ct *CoverageTracker) languageConversionPanic(targetLanguage string, panicI
 	})
 }
 
+// Used when: generator gave up converting the current example to a certain language because
+// the conversion exceeded its timeout
+func (ct *CoverageTracker) languageConversionTimeout(targetLanguage string, elapsed time.Duration) {
+	if ct == nil {
+		return
+	}
+	ct.insertLanguageConversionResult(LanguageConversionResult{
+		TargetLanguage:       targetLanguage,
+		FailureSeverity:      3,
+		FailureInfo:          "Conversion timed out",
+		MultipleTranslations: false,
+		ElapsedTime:          elapsed,
+	})
+}
+
+// Used when: generator converted the current example to a certain language, but the provider's
+// example transformer for that language failed
+func (ct *CoverageTracker) languageConversionTransformFailure(targetLanguage string, err error) {
+	if ct == nil {
+		return
+	}
+	ct.insertLanguageConversionResult(LanguageConversionResult{
+		TargetLanguage:       targetLanguage,
+		FailureSeverity:      2,
+		FailureInfo:          fmt.Sprintf("Example transformer failed: %v", err),
+		MultipleTranslations: false,
+	})
+}
+
 // Adding a language conversion result to the current example. If a conversion result with the same
 // target language already exists, keep the lowest severity one and mark the example as possibly duplicated
 func (ct *CoverageTracker) insertLanguageConversionResult(conversionResult LanguageConversionResult) {
@@ -202,8 +334,8 @@ func formatDiagnostics(diagnostics hcl.Diagnostics) string {
 	return strings.Join(results[:], "; ")
 }
 
-// Exporting the coverage results
-func (ct *CoverageTracker) exportResults(outputDirectory string) error {
+// Exporting the coverage results to the given sink
+func (ct *CoverageTracker) exportResults(sink CoverageSink) error {
 	coverageExportUtil := newCoverageExportUtil(ct)
-	return (coverageExportUtil.tryExport(outputDirectory))
+	return (coverageExportUtil.tryExport(sink))
 }
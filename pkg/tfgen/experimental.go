@@ -0,0 +1,100 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// experimentalNameRegexp matches Terraform names that mark a resource or data source as unstable, e.g.
+// `google_beta_widget` or `example_widget_preview`.
+var experimentalNameRegexp = regexp.MustCompile(`(^|_)(alpha|beta|experimental|preview)(_|$)`)
+
+// experimentalFrontMatterRegexp matches the subcategory line of doc front matter that marks a resource or data source
+// as unstable, e.g. `subcategory: "Compute (Beta)"`. The other lines, e.g. the page title or description, often
+// mention beta features in passing.
+var experimentalFrontMatterRegexp = regexp.MustCompile(`(?im)^\s*subcategory:.*\b(alpha|beta|experimental|preview)\b`)
+
+// experimentalDescriptionRegexp matches descriptions that call a resource or data source unstable. It is stricter
+// than experimentalFrontMatterRegexp, as descriptions often mention beta features of the resource in passing.
+var experimentalDescriptionRegexp = regexp.MustCompile(`(?i)\b(this (resource|data source) is (currently )?` +
+	`(in (public )?(alpha|beta|preview)|experimental)|(alpha|beta|experimental) (resource|data source))\b`)
+
+// experimentalMember records an upstream resource or data source that is experimental, and how it was detected.
+type experimentalMember struct {
+	Kind     string   `json:"kind"` // either "resource" or "function"
+	TFName   string   `json:"tfName"`
+	Token    string   `json:"token"`
+	Signals  []string `json:"signals"`            // "explicit", or any of "name", "frontMatter", "docs" and "schema"
+	Excluded bool     `json:"excluded,omitempty"` // true if the member was left out of the schema
+}
+
+// experimentalSignals returns the signals that mark the upstream resource or data source with the given name, docs
+// and schema description as experimental, or nil if it is stable.
+func experimentalSignals(rawname string, docs entityDocs, upstreamDescription string) []string {
+	var signals []string
+	if experimentalNameRegexp.MatchString(rawname) {
+		signals = append(signals, "name")
+	}
+	if experimentalFrontMatterRegexp.MatchString(docs.FrontMatter) {
+		signals = append(signals, "frontMatter")
+	}
+	if docs.Description != upstreamDescription && experimentalDescriptionRegexp.MatchString(docs.Description) {
+		signals = append(signals, "docs")
+	}
+	if experimentalDescriptionRegexp.MatchString(upstreamDescription) {
+		signals = append(signals, "schema")
+	}
+	return signals
+}
+
+// labelExperimental labels the given resource or data source as experimental in its description if its upstream
+// counterpart is, and records it in the list of experimental members. It returns true if the member should be left
+// out of the schema. Members are experimental if their info says so, or if ProviderInfo.DetectExperimental is set and
+// their info does not say otherwise and experimentalSignals detects them.
+func (g *Generator) labelExperimental(kind, rawname, token string, explicit *bool, docs *entityDocs,
+	upstreamDescription string) bool {
+
+	var signals []string
+	switch {
+	case explicit != nil && *explicit:
+		signals = []string{"explicit"}
+	case explicit == nil && g.info.DetectExperimental:
+		signals = experimentalSignals(rawname, *docs, upstreamDescription)
+	}
+	if len(signals) == 0 {
+		return false
+	}
+	g.experimental = append(g.experimental, experimentalMember{
+		Kind:     kind,
+		TFName:   rawname,
+		Token:    token,
+		Signals:  signals,
+		Excluded: g.excludeExperimental,
+	})
+	if g.excludeExperimental {
+		g.debug("excluding experimental %s %s", kind, rawname)
+		return true
+	}
+
+	entity := "resource"
+	if kind == "function" {
+		entity = "data source"
+	}
+	docs.Description = fmt.Sprintf("> **Experimental:** the upstream %s %s is experimental or in beta, and may change "+
+		"in backwards-incompatible ways.\n\n%s", entity, rawname, docs.Description)
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestExperimentalSignals(t *testing.T) {
+	assert.Nil(t, experimentalSignals("google_compute_instance", entityDocs{
+		FrontMatter: "subcategory: \"Compute Engine\"\ndescription: |-\n  Manages an instance, including beta features.\n",
+		Description: "Manages an instance. Beta features of instances are supported.",
+	}, ""))
+
+	assert.Equal(t, []string{"name"}, experimentalSignals("google_beta_widget", entityDocs{}, ""))
+	assert.Equal(t, []string{"frontMatter"}, experimentalSignals("example_widget", entityDocs{
+		FrontMatter: "subcategory: \"Widgets (Beta)\"\n",
+	}, ""))
+	assert.Equal(t, []string{"docs"}, experimentalSignals("example_widget", entityDocs{
+		Description: "Manages a widget.\n\n~> **Note:** This resource is currently in public preview.",
+	}, "Manages a widget."))
+	assert.Equal(t, []string{"name", "schema"}, experimentalSignals("example_widget_preview", entityDocs{
+		Description: "An experimental resource for widgets.",
+	}, "An experimental resource for widgets."))
+}
+
+func TestLabelExperimental(t *testing.T) {
+	upstream := func(description string) *schemav2.Resource {
+		return &schemav2.Resource{
+			Description: description,
+			Schema: map[string]*schemav2.Schema{
+				"name": {Type: schemav2.TypeString, Optional: true},
+			},
+		}
+	}
+	info := tfbridge.ProviderInfo{
+		Name:               "test",
+		DetectExperimental: true,
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget":      upstream("Manages a widget."),
+				"test_beta_gadget": upstream("Manages a gadget."),
+			},
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"test_widget": upstream("Gets a widget. This data source is experimental."),
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget":      {Tok: "test:index/widget:Widget"},
+			"test_beta_gadget": {Tok: "test:index/gadget:Gadget"},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {Tok: "test:index/getWidget:getWidget"},
+		},
+	}
+
+	gather := func(info tfbridge.ProviderInfo, exclude bool) (*Generator, map[string]string) {
+		g := newTestGenerator(t, info, func(opts *GeneratorOptions) { opts.ExcludeExperimental = exclude })
+		spec := genGeneratorSchema(t, g)
+
+		descriptions := map[string]string{}
+		for tok, res := range spec.Resources {
+			descriptions[tok] = res.Description
+		}
+		for tok, fun := range spec.Functions {
+			if tok != string(tfbridge.TerraformCompatToken("test")) {
+				descriptions[tok] = fun.Description
+			}
+		}
+		return g, descriptions
+	}
+
+	g, descriptions := gather(info, false)
+	assert.Equal(t, "Manages a widget.\n", descriptions["test:index/widget:Widget"])
+	assert.Equal(t, "> **Experimental:** the upstream resource test_beta_gadget is experimental or in beta, and may "+
+		"change in backwards-incompatible ways.\n\nManages a gadget.\n", descriptions["test:index/gadget:Gadget"])
+	assert.Contains(t, descriptions["test:index/getWidget:getWidget"], "the upstream data source test_widget is")
+	assert.Equal(t, []experimentalMember{
+		{Kind: "resource", TFName: "test_beta_gadget", Token: "test:index/gadget:Gadget", Signals: []string{"name"}},
+		{Kind: "function", TFName: "test_widget", Token: "test:index/getWidget:getWidget", Signals: []string{"schema"}},
+	}, g.experimental)
+
+	g, descriptions = gather(info, true)
+	assert.Len(t, descriptions, 1)
+	assert.Contains(t, descriptions, "test:index/widget:Widget")
+	if assert.Len(t, g.experimental, 2) {
+		assert.True(t, g.experimental[0].Excluded)
+		assert.True(t, g.experimental[1].Excluded)
+	}
+
+	// Explicit flags override the detection.
+	yes, no := true, false
+	info.Resources["test_widget"].Experimental = &yes
+	info.DataSources["test_widget"].Experimental = &no
+	g, descriptions = gather(info, false)
+	assert.Equal(t, "Gets a widget. This data source is experimental.\n", descriptions["test:index/getWidget:getWidget"])
+	assert.Equal(t, []experimentalMember{
+		{Kind: "resource", TFName: "test_beta_gadget", Token: "test:index/gadget:Gadget", Signals: []string{"name"}},
+		{Kind: "resource", TFName: "test_widget", Token: "test:index/widget:Widget", Signals: []string{"explicit"}},
+	}, g.experimental)
+
+	// Without the opt-in, only the explicitly flagged members are experimental.
+	info.DetectExperimental = false
+	g, descriptions = gather(info, false)
+	assert.Equal(t, "Manages a gadget.\n", descriptions["test:index/gadget:Gadget"])
+	assert.Equal(t, []experimentalMember{
+		{Kind: "resource", TFName: "test_widget", Token: "test:index/widget:Widget", Signals: []string{"explicit"}},
+	}, g.experimental)
+}
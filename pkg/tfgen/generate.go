@@ -17,11 +17,14 @@ package tfgen
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -54,21 +57,51 @@ const (
 )
 
 type Generator struct {
-	pkg              string                // the Pulum package name (e.g. `gcp`)
-	version          string                // the package version.
-	language         Language              // the language runtime to generate.
-	info             tfbridge.ProviderInfo // the provider info for customizing code generation
-	root             afero.Fs              // the output virtual filesystem.
-	providerShim     *inmemoryProvider     // a provider shim to hold the provider schema during example conversion.
-	pluginHost       plugin.Host           // the plugin host for tf2pulumi.
-	packageCache     *hcl2.PackageCache    // the package cache for tf2pulumi.
-	infoSource       il.ProviderInfoSource // the provider info source for tf2pulumi.
-	terraformVersion string                // the Terraform version to target for example codegen, if any
-	sink             diag.Sink
-	printStats       bool
-	skipDocs         bool
-	skipExamples     bool
-	coverageTracker  *CoverageTracker
+	pkg                string                // the Pulum package name (e.g. `gcp`)
+	version            string                // the package version.
+	language           Language              // the language runtime to generate.
+	info               tfbridge.ProviderInfo // the provider info for customizing code generation
+	root               afero.Fs              // the output virtual filesystem.
+	providerShim       *inmemoryProvider     // a provider shim to hold the provider schema during example conversion.
+	pluginHost         plugin.Host           // the plugin host for tf2pulumi.
+	packageCache       *hcl2.PackageCache    // the package cache for tf2pulumi.
+	infoSource         il.ProviderInfoSource // the provider info source for tf2pulumi.
+	terraformVersion   string                // the Terraform version to target for example codegen, if any
+	sink               diag.Sink
+	printStats         bool
+	skipDocs           bool
+	skipExamples       bool
+	coverageTracker    *CoverageTracker
+	docSnippets        map[string]string // cache of shared doc snippets, keyed by name
+	docLinkRules       []docLinkRule     // compiled ProviderInfo.DocLinkRules
+	docRefRegexp       *regexp.Regexp    // matches references to resources and data sources in property docs
+	unresolvedDocRefs  []unresolvedDocReference
+	docTrace           *docTrace         // the trace of the edits to one entity's docs, for `tfgen debug-docs`
+	exampleTimeout     time.Duration     // the maximum time to spend converting one example to one language, if any
+	exampleCache       *exampleCache     // the on-disk cache of converted examples, if any
+	legacyTokens       bool              // true to also emit the classic flat token layout, deprecated.
+	pruneSchema        bool              // true to remove fields that are equivalent to leaving them out.
+	includeEphemeral   bool              // true to expose mapped ephemeral resources as functions.
+	schemaBudget       schemaBudget      // the limits on the size of the emitted schema.
+	previousSchemaPath string            // the schema of the previous release, to compare sizes against, if any.
+	// true to leave the resources and data sources that are experimental upstream out of the schema.
+	excludeExperimental bool
+	experimental        []experimentalMember // the resources and data sources that are experimental upstream.
+	// the tokens inferred from ProviderInfo.ModulePrefixes that are in use, and those that have yet to be accepted.
+	inferredTokens, pendingInferredTokens tfbridge.InferredTokens
+	inferredTokensPath                    string      // the file that records the inferred tokens in use, if any.
+	acceptInferredTokens                  bool        // true to record newly inferred tokens in inferredTokensPath.
+	renamedIDs                            []renamedID // the resources whose `id` property was renamed.
+	docAssets                             docAssets   // the upstream docs assets that the package's docs refer to.
+	// the directory to export per-member example bundles to, if any, and the examples collected for them.
+	exampleBundlesDir string
+	exampleBundles    map[string][]bundledExample
+	// the converters to retry examples with when the bridge's converter fails, in order.
+	fallbackConverters []ExampleConverter
+	compatibility      *compatibilityReport // the protocol and features of the upstream provider, once computed.
+	progress           *progressReporter // reports the progress of Generate, if requested.
+	// the compiled ProviderInfo.ExamplePlaceholders.
+	examplePlaceholders []examplePlaceholder
 }
 
 type Language string
@@ -158,6 +191,8 @@ type pkg struct {
 	root     afero.Fs      // the root of the package.
 	modules  moduleMap     // the modules inside of this package.
 	provider *resourceType // the provider type for this package.
+
+	compatibility *compatibilityReport // the protocol and features of the upstream provider.
 }
 
 func newPkg(name, version string, language Language, fs afero.Fs) *pkg {
@@ -288,6 +323,7 @@ const (
 	kindMap
 	kindSet
 	kindObject
+	kindAny
 )
 
 // Avoid an unused warning from varcheck.
@@ -341,6 +377,8 @@ func makePropertyType(objectName string, sch shim.Schema, info *tfbridge.SchemaI
 		t.kind = kindMap
 	case shim.TypeSet:
 		t.kind = kindSet
+	case shim.TypeDynamic:
+		t.kind = kindAny
 	}
 
 	// We should carry across any of the deprecation messages, to Pulumi, as per Terraform schema
@@ -397,6 +435,9 @@ func makeObjectPropertyType(objectName string, res shim.Resource, info *tfbridge
 		if propertyInfos != nil {
 			propertyInfo = propertyInfos[key]
 		}
+		if propertyInfo != nil && propertyInfo.Omit {
+			continue
+		}
 
 		doc := getNestedDescriptionFromParsedDocs(entityDocs, objectName, key)
 		if v := propertyVariable(key, propertySchema, propertyInfo, doc, "", out, entityDocs); v != nil {
@@ -492,6 +533,50 @@ func (v *variable) deprecationMessage() string {
 	return ""
 }
 
+// forceNew returns true if changing the property forces the replacement of its resource, either due to Terraform or
+// an overlay.
+func (v *variable) forceNew() bool {
+	if v.info != nil && v.info.ForceNew != nil {
+		return *v.info.ForceNew
+	}
+	return v.schema != nil && v.schema.ForceNew()
+}
+
+// configSecret returns true if the given provider configuration property should be treated as secret, either due to
+// an overlay, which may also force a sensitive property to be plain, or because Terraform marks it sensitive.
+func (v *variable) configSecret() bool {
+	if v.info != nil && v.info.Secret != nil {
+		return *v.info.Secret
+	}
+	return v.schema != nil && v.schema.Sensitive()
+}
+
+// upstreamDefault returns the static default value Terraform declares for the given property, if that value can be
+// represented in a Pulumi schema. Only primitive properties may carry defaults in a Pulumi schema, so collections,
+// objects and dynamic attributes are skipped, and defaults that are computed by a function are not known until the
+// provider runs.
+func (v *variable) upstreamDefault() interface{} {
+	if v.schema == nil || v.typ == nil {
+		return nil
+	}
+	switch v.schema.Type() {
+	case shim.TypeBool, shim.TypeInt, shim.TypeFloat, shim.TypeString:
+	default:
+		return nil
+	}
+
+	switch v.typ.kind {
+	case kindBool, kindInt, kindFloat, kindString:
+		switch d := v.schema.Default().(type) {
+		case bool, float64, string:
+			return d
+		case int:
+			return float64(d)
+		}
+	}
+	return nil
+}
+
 // optional checks whether the given property is optional, either due to Terraform or an overlay.
 func (v *variable) optional() bool {
 	if v.opt {
@@ -616,6 +701,46 @@ type GeneratorOptions struct {
 	SkipDocs           bool
 	SkipExamples       bool
 	CoverageTracker    *CoverageTracker
+	// ExampleTimeout bounds the time spent converting a single example to a single language. Zero means no limit.
+	ExampleTimeout time.Duration
+	// ExampleCacheDir is a directory in which converted examples, and the package caches of the languages' tools, are
+	// kept across runs. It may be shared by several providers. Empty disables the cache.
+	ExampleCacheDir string
+	// LegacyTokens adds deprecated copies of the resources and functions that are not in the index module under their
+	// tokens in the classic flat layout, for providers migrating to module-namespaced tokens.
+	LegacyTokens bool
+	// MaxSchemaBytes fails generation if the emitted schema.json is larger than this many bytes. Zero means no limit.
+	MaxSchemaBytes int
+	// ModuleSchemaBudgets fails generation if any of the listed modules takes up more than its number of bytes of the
+	// emitted schema.
+	ModuleSchemaBudgets map[string]int
+	// PreviousSchemaPath is the schema.json of the previous release. If the schema exceeds its budget, the modules and
+	// members that grew the most since that release are reported.
+	PreviousSchemaPath string
+	// ExcludeExperimental leaves the resources and data sources that are experimental or in beta upstream out of the
+	// schema. They are labeled as experimental otherwise.
+	ExcludeExperimental bool
+	// InferredTokensPath is a JSON file that records the tokens inferred from ProviderInfo.ModulePrefixes, so that
+	// they stay the same from one run to the next. It should be checked in alongside the provider.
+	InferredTokensPath string
+	// AcceptInferredTokens uses, and records, the tokens inferred for resources and data sources for the first time.
+	// Otherwise those resources and data sources are skipped with a warning.
+	AcceptInferredTokens bool
+	// ExampleBundlesDir is a directory to export each resource's and function's examples to, with the original HCL
+	// and every converted language side by side. Empty disables the export.
+	ExampleBundlesDir string
+	// PruneSchema removes fields from the emitted schema.json that are equivalent to leaving them out, e.g. empty
+	// language-specific settings and descriptions that are only whitespace, to make the schema smaller.
+	PruneSchema bool
+	// IncludeEphemeralResources exposes the upstream ephemeral resources that ProviderInfo.EphemeralResources maps as
+	// functions. Experimental: it only applies to providers whose shim implements shim.EphemeralResourceProvider.
+	IncludeEphemeralResources bool
+	// FallbackExampleConverters are tried in order for each example that the bridge's converter fails to convert to
+	// a language. The coverage data records which converter succeeded.
+	FallbackExampleConverters []ExampleConverter
+	// Progress receives an event for the start, progress and end of each stage of Generate, as a JSON object per
+	// line, e.g. for build dashboards to display.
+	Progress io.Writer
 }
 
 // NewGenerator returns a code-generator for the given language runtime and package info.
@@ -670,6 +795,27 @@ func NewGenerator(opts GeneratorOptions) (*Generator, error) {
 	infoSources := append([]il.ProviderInfoSource{}, opts.ProviderInfoSource, il.PluginProviderInfoSource)
 	infoSource := il.NewCachingProviderInfoSource(il.NewMultiProviderInfoSource(infoSources...))
 
+	docLinkRules, err := compileDocLinkRules(info.DocLinkRules)
+	if err != nil {
+		return nil, err
+	}
+	examplePlaceholders, err := compileExamplePlaceholders(info.ExamplePlaceholders)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the unmapped resources and data sources whose tokens are inferred from the provider's module prefixes.
+	recordedTokens, err := readInferredTokensFile(opts.InferredTokensPath)
+	if err != nil {
+		return nil, err
+	}
+	inferredTokens, pendingInferredTokens := resolveInferredTokens(info, pkg, recordedTokens,
+		opts.AcceptInferredTokens)
+	info.AddInferredTokens(inferredTokens)
+	renamedIDs := resolveResourceInfos(&info)
+
+	exampleCache := newExampleCache(opts.ExampleCacheDir)
+
 	providerShim := newInMemoryProvider(pkg, nil, info)
 	host := &inmemoryProviderHost{
 		Host:               pluginHost,
@@ -696,6 +842,27 @@ func NewGenerator(opts GeneratorOptions) (*Generator, error) {
 		skipDocs:         opts.SkipDocs,
 		skipExamples:     opts.SkipExamples,
 		coverageTracker:  opts.CoverageTracker,
+		docLinkRules:     docLinkRules,
+		exampleTimeout:   opts.ExampleTimeout,
+		exampleCache:     exampleCache,
+		legacyTokens:     opts.LegacyTokens,
+		pruneSchema:      opts.PruneSchema,
+		includeEphemeral: opts.IncludeEphemeralResources,
+		schemaBudget: schemaBudget{
+			MaxBytes: opts.MaxSchemaBytes,
+			Modules:  opts.ModuleSchemaBudgets,
+		},
+		previousSchemaPath:    opts.PreviousSchemaPath,
+		excludeExperimental:   opts.ExcludeExperimental,
+		inferredTokens:        inferredTokens,
+		pendingInferredTokens: pendingInferredTokens,
+		inferredTokensPath:    opts.InferredTokensPath,
+		acceptInferredTokens:  opts.AcceptInferredTokens,
+		renamedIDs:            renamedIDs,
+		exampleBundlesDir:     opts.ExampleBundlesDir,
+		fallbackConverters:    opts.FallbackExampleConverters,
+		progress:              newProgressReporter(opts.Progress),
+		examplePlaceholders:   examplePlaceholders,
 	}, nil
 }
 
@@ -721,18 +888,52 @@ type GenerateOptions struct {
 
 // Generate creates Pulumi packages from the information it was initialized with.
 func (g *Generator) Generate() error {
+	// Fail before doing any work if the upstream provider relies on features that the bridge does not support.
+	compatibility := g.upstreamCompatibility()
+	g.coverageTracker.foundCompatibility(compatibility)
+	if err := compatibility.check(); err != nil {
+		return err
+	}
+
 	// First gather up the entire package contents.  This structure is complete and sufficient to hand off
 	// to the language-specific generators to create the full output.
+	g.progress.begin(progressGather, g.provider().ResourcesMap().Len()+g.provider().DataSourcesMap().Len())
 	pack, err := g.gatherPackage()
 	if err != nil {
 		return errors.Wrapf(err, "failed to gather package metadata")
 	}
+	g.progress.end()
 
 	// Convert the package to a Pulumi schema.
+	g.progress.begin(progressSchema, 0)
 	pulumiPackageSpec, err := genPulumiSchema(pack, g.pkg, g.version, g.info)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create Pulumi schema")
 	}
+	if err = checkReferences(pulumiPackageSpec, g.info); err != nil {
+		return err
+	}
+	if g.inferredTokens.Len() > 0 {
+		pulumiPackageSpec.Language[tfbridge.InferredTokensKey] = rawMessage(g.inferredTokens)
+	}
+	if pinned := g.info.PinnedLegacyBehaviors(); pinned.Len() > 0 {
+		pulumiPackageSpec.Language[tfbridge.LegacyBehaviorsKey] = rawMessage(pinned)
+	}
+	g.coverageTracker.foundSchema(pulumiPackageSpec)
+	g.coverageTracker.foundDocValues(computeDocValueReport(pulumiPackageSpec))
+	if !g.skipDocs {
+		brokenLinks := findBrokenDocLinks(pulumiPackageSpec)
+		for _, link := range brokenLinks {
+			g.warn("docs for %s link to %s, which is not generated", link.Location, link.Target)
+		}
+		g.coverageTracker.foundBrokenDocLinks(brokenLinks)
+
+		unresolvedRefs := g.unresolvedDocReferences()
+		for _, ref := range unresolvedRefs {
+			g.warn("docs for %s refer to %s, which is not mapped", ref.Location, ref.Name)
+		}
+		g.coverageTracker.foundUnresolvedDocReferences(unresolvedRefs)
+	}
 
 	// Serialize the schema and attach it to the provider shim.
 	g.providerShim.schema, err = json.Marshal(pulumiPackageSpec)
@@ -740,23 +941,95 @@ func (g *Generator) Generate() error {
 		return errors.Wrapf(err, "failed to marshal intermediate schema")
 	}
 
+	g.progress.end()
+
 	// Convert examples.
 	if !g.skipExamples {
+		g.progress.begin(progressExamples, len(pulumiPackageSpec.Resources)+len(pulumiPackageSpec.Functions))
 		pulumiPackageSpec = g.convertExamplesInSchema(pulumiPackageSpec)
+		g.progress.end()
+		if err = g.exportExampleBundles(pulumiPackageSpec); err != nil {
+			return errors.Wrapf(err, "failed to export example bundles")
+		}
+	}
+
+	// Add the deprecated flat token layout. This happens after the examples are converted, as the legacy members share
+	// their canonical members' properties.
+	var legacyTokens []legacyTokenMapping
+	if g.legacyTokens {
+		var warnings []string
+		legacyTokens, warnings = addLegacyTokenLayout(&pulumiPackageSpec)
+		for _, warning := range warnings {
+			g.warn("%s", warning)
+		}
 	}
 
 	// Go ahead and let the language generator do its thing. If we're emitting the schema, just go ahead and serialize
 	// it out.
+	g.progress.begin(progressEmit, 0)
 	var files map[string][]byte
 	if g.language == Schema {
 		// Omit the version so that the spec is stable if the version is e.g. derived from the current Git commit hash.
 		pulumiPackageSpec.Version = ""
 
+		if g.pruneSchema {
+			removed, err := pruneSchema(&pulumiPackageSpec)
+			if err != nil {
+				return err
+			}
+			g.debug("pruned %d empty fields from the schema", removed)
+		}
+
 		bytes, err := json.MarshalIndent(pulumiPackageSpec, "", "    ")
 		if err != nil {
 			return errors.Wrapf(err, "failed to marshal schema")
 		}
+		if err = g.checkSchemaSize(pulumiPackageSpec, bytes); err != nil {
+			return err
+		}
 		files = map[string][]byte{"schema.json": bytes}
+
+		if len(legacyTokens) > 0 {
+			if files["legacyTokens.json"], err = json.MarshalIndent(legacyTokens, "", "    "); err != nil {
+				return errors.Wrapf(err, "failed to marshal legacy tokens")
+			}
+		}
+		if len(g.experimental) > 0 {
+			if files["experimental.json"], err = json.MarshalIndent(g.experimental, "", "    "); err != nil {
+				return errors.Wrapf(err, "failed to marshal the list of experimental members")
+			}
+		}
+		if len(g.renamedIDs) > 0 {
+			if files["renamedIds.json"], err = json.MarshalIndent(g.renamedIDs, "", "    "); err != nil {
+				return errors.Wrapf(err, "failed to marshal the list of renamed id properties")
+			}
+		}
+
+		localized, err := g.genLocalizedSchemas(pulumiPackageSpec)
+		if err != nil {
+			return errors.Wrapf(err, "failed to translate schema docs")
+		}
+		for f, contents := range localized {
+			files[f] = contents
+		}
+
+		guides, err := g.genGuides()
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate guides")
+		}
+		for f, contents := range guides {
+			files[f] = contents
+		}
+
+		// Copy the assets that the docs refer to, and report those that could not be copied.
+		for f, contents := range g.docAssets.files {
+			files[f] = contents
+		}
+		if report := g.docAssets.report(); len(report.Copied) > 0 || len(report.Missing) > 0 {
+			if files["docAssets.json"], err = json.MarshalIndent(report, "", "    "); err != nil {
+				return errors.Wrapf(err, "failed to marshal the docs asset report")
+			}
+		}
 	} else {
 		pulumiPackage, err := pschema.ImportSpec(pulumiPackageSpec, nil)
 		if err != nil {
@@ -765,6 +1038,11 @@ func (g *Generator) Generate() error {
 		if files, err = g.language.emitSDK(pulumiPackage, g.info, g.root); err != nil {
 			return errors.Wrapf(err, "failed to generate package")
 		}
+		if g.info.ProviderFactory != nil {
+			if err = addProviderFactory(g.language, g.info, pulumiPackage, files); err != nil {
+				return errors.Wrapf(err, "failed to generate provider factory")
+			}
+		}
 	}
 
 	// Write the result to disk. Do not overwrite the root-level README.md if any exists.
@@ -779,11 +1057,20 @@ func (g *Generator) Generate() error {
 		}
 	}
 
+	// Record the inferred tokens in use, so that later runs keep them.
+	if g.acceptInferredTokens && g.inferredTokensPath != "" {
+		if err = writeInferredTokensFile(g.inferredTokensPath, g.inferredTokens); err != nil {
+			return errors.Wrapf(err, "failed to record inferred tokens")
+		}
+	}
+
 	// Emit the Pulumi project information.
 	if err = g.emitProjectMetadata(pack); err != nil {
 		return errors.Wrapf(err, "failed to create project file")
 	}
 
+	g.progress.end()
+
 	// Print out some documentation stats as a summary afterwards.
 	printDocStats(g, g.printStats, g.printStats)
 
@@ -794,10 +1081,22 @@ func (g *Generator) Generate() error {
 }
 
 // gatherPackage creates a package plus module structure for the entire set of members of this package.
+// upstreamCompatibility returns the compatibility report of the upstream provider, which is computed on first use.
+func (g *Generator) upstreamCompatibility() *compatibilityReport {
+	if g.compatibility == nil {
+		g.compatibility = computeCompatibilityReport(g.info.P)
+	}
+	return g.compatibility
+}
+
 func (g *Generator) gatherPackage() (*pkg, error) {
 	// First, gather up the entire package/module structure.  This includes gathering config entries, resources,
 	// data sources, and any supporting type information, and placing them into modules.
 	pack := newPkg(g.pkg, g.version, g.language, g.root)
+	pack.compatibility = g.upstreamCompatibility()
+	g.experimental = nil
+	g.docAssets = docAssets{}
+	g.exampleBundles = nil
 
 	// Place all configuration variables into a single config module.
 	if cfg := g.gatherConfig(); cfg != nil {
@@ -827,6 +1126,14 @@ func (g *Generator) gatherPackage() (*pkg, error) {
 		pack.addModuleMap(dsmods)
 	}
 
+	// Expose ephemeral resources as functions, if they are included.
+	ermods, err := g.gatherEphemeralResources()
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem gathering ephemeral resources")
+	} else if ermods != nil {
+		pack.addModuleMap(ermods)
+	}
+
 	// Now go ahead and merge in any overlays into the modules if there are any.
 	olaymods, err := g.gatherOverlays()
 	if err != nil {
@@ -868,20 +1175,63 @@ func (g *Generator) gatherConfig() *module {
 	}
 
 	// Ensure there weren't any keys that were unrecognized.
+	customKeys := make([]string, 0, len(custom))
 	for key := range custom {
+		customKeys = append(customKeys, key)
+	}
+	sort.Strings(customKeys)
+	for _, key := range customKeys {
 		if _, has := cfg.GetOk(key); !has {
 			g.warn("custom config schema %s was not present in the Terraform metadata", key)
 		}
 	}
 
 	// Now, if there are any extra config variables, that are Pulumi-only, add them.
-	for key, val := range g.info.ExtraConfig {
+	extraKeys := make([]string, 0, len(g.info.ExtraConfig))
+	for key := range g.info.ExtraConfig {
+		extraKeys = append(extraKeys, key)
+	}
+	sort.Strings(extraKeys)
+	for _, key := range extraKeys {
+		val := g.info.ExtraConfig[key]
 		if prop := propertyVariable(key, val.Schema, val.Info, "", "", true /*out*/, entityDocs{}); prop != nil {
 			prop.config = true
 			config.addMember(prop)
 		}
 	}
 
+	// Declare the configuration that the bridge interprets itself, so that it is typed and validated like the rest.
+	bridgeConfig := g.info.BridgeConfig()
+	bridgeKeys := make([]string, 0, len(bridgeConfig))
+	for key := range bridgeConfig {
+		bridgeKeys = append(bridgeKeys, key)
+	}
+	sort.Strings(bridgeKeys)
+	for _, key := range bridgeKeys {
+		val := bridgeConfig[key]
+		prop := propertyVariable(key, val.Schema, val.Info, "", val.Schema.Description(), true /*out*/, entityDocs{})
+		if prop != nil {
+			prop.config = true
+			config.addMember(prop)
+		}
+	}
+
+	// Ensure that every renamed config variable was renamed to a variable that exists.
+	names := map[string]bool{}
+	for _, member := range config.members {
+		names[member.Name()] = true
+	}
+	oldNames := make([]string, 0, len(g.info.RenamedConfig))
+	for oldName := range g.info.RenamedConfig {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+	for _, oldName := range oldNames {
+		if newName := g.info.RenamedConfig[oldName]; !names[newName] {
+			g.warn("renamed config %s refers to %s, which is not a config variable", oldName, newName)
+		}
+	}
+
 	return config
 }
 
@@ -914,10 +1264,14 @@ func (g *Generator) gatherResources() (moduleMap, error) {
 	var reserr error
 	seen := make(map[string]bool)
 	for _, r := range stableResources(resources) {
+		g.progress.advance()
 		info := g.info.Resources[r]
 		if info == nil {
 			if failBuildOnProviderMapError {
 				g.error("resource %s not found in provider map; exiting", r)
+			} else if tok, ok := g.pendingInferredTokens.Resources[r]; ok {
+				g.warn("resource %s would be mapped to %s by its module prefix; skipping until the token is "+
+					"accepted with --accept-inferred-tokens", r, tok)
 			} else {
 				g.warn("resource %s not found in provider map; skipping", r)
 			}
@@ -926,10 +1280,13 @@ func (g *Generator) gatherResources() (moduleMap, error) {
 		seen[r] = true
 
 		module, res, err := g.gatherResource(r, resources.Get(r), info, false)
+		if err == nil && res != nil {
+			err = g.addConfigProfileProperty(r, res)
+		}
 		if err != nil {
 			// Keep track of the error, but keep going, so we can expose more at once.
 			reserr = multierror.Append(reserr, err)
-		} else {
+		} else if res != nil {
 			// Add any members returned to the specified module.
 			modules.ensureModule(module).addMember(res)
 		}
@@ -954,9 +1311,25 @@ func (g *Generator) gatherResources() (moduleMap, error) {
 	return modules, nil
 }
 
+// upstreamDescription returns the description of the given upstream resource or data source, or "" if its shim does not
+// know it.
+func upstreamDescription(res shim.Resource) string {
+	if described, ok := res.(shim.ResourceWithDescription); ok {
+		return described.Description()
+	}
+	return ""
+}
+
 // gatherResource returns the module name and one or more module members to represent the given resource.
 func (g *Generator) gatherResource(rawname string,
 	schema shim.Resource, info *tfbridge.ResourceInfo, isProvider bool) (string, *resourceType, error) {
+	return g.gatherResourceWithDocs(rawname, schema, info, isProvider, ResourceDocs)
+}
+
+// gatherResourceWithDocs returns the module name and resource type for the given resource, reading its docs from the
+// upstream docs of the given kind.
+func (g *Generator) gatherResourceWithDocs(rawname string, schema shim.Resource, info *tfbridge.ResourceInfo,
+	isProvider bool, docsKind DocKind) (string, *resourceType, error) {
 	// Get the resource's module and name.
 	name, module := resourceName(g.info.Name, rawname, info, isProvider)
 
@@ -964,12 +1337,44 @@ func (g *Generator) gatherResource(rawname string,
 	var entityDocs entityDocs
 	if !isProvider {
 		pd, err := getDocsForProvider(g, g.info.GetGitHubOrg(), g.info.Name,
-			g.info.GetResourcePrefix(), ResourceDocs, rawname, info, g.info.GetProviderModuleVersion(),
+			g.info.GetResourcePrefix(), docsKind, rawname, info, g.info.GetProviderModuleVersion(),
 			g.info.GetGitHubHost())
 		if err != nil {
 			return "", nil, err
 		}
 		entityDocs = pd
+		trace := g.docTraceStage(docsKind, rawname, &entityDocs.Description)
+
+		// Fall back to the upstream resource's own description if there are no docs for it.
+		fromUpstream := entityDocs.Description == ""
+		if fromUpstream {
+			entityDocs.Description = upstreamDescription(schema)
+		}
+		trace("use upstream schema description")
+		entityDocs.Description = addEmbeddedExamples(entityDocs.Description, fromUpstream, schema.Schema(), info.Fields)
+		trace("add embedded examples")
+
+		// Merge in any hand-written examples for this resource.
+		description, err := g.addCuratedExamples(entityDocs.Description, string(info.Tok))
+		if err != nil {
+			return "", nil, err
+		}
+		entityDocs.Description = description
+		trace("add curated examples")
+
+		// Add any Pulumi-specific notes for this resource.
+		if entityDocs.Description, err = g.addDocNotes(rawname, entityDocs.Description, info.GetDocs()); err != nil {
+			return "", nil, err
+		}
+		trace("add doc notes")
+
+		if g.labelExperimental("resource", rawname, string(info.Tok), info.Experimental, &entityDocs,
+			upstreamDescription(schema)) {
+			return "", nil, nil
+		}
+		trace("label experimental")
+		g.labelDeprecatedRedirects("resource", rawname, &entityDocs)
+		trace("label deprecated redirects")
 	} else {
 		entityDocs.Description = fmt.Sprintf(
 			"The provider type for the %s package. By default, resources use package-wide configuration\n"+
@@ -983,18 +1388,26 @@ func (g *Generator) gatherResource(rawname string,
 	res := newResourceType(name, entityDocs, schema, info, isProvider)
 
 	// Next, gather up all properties.
+	if err := validateOmittedFields(schema.Schema(), info.Fields); err != nil {
+		return "", nil, errors.Wrapf(err, "resource %s", rawname)
+	}
+	if err := validateMemberNames(schema.Schema(), info.Fields); err != nil {
+		return "", nil, errors.Wrapf(err, "resource %s", rawname)
+	}
 	var stateVars []*variable
 	for _, key := range stableSchemas(schema.Schema()) {
 		propschema := schema.Schema().Get(key)
 		if propschema.Removed() != "" {
 			continue
 		}
+		propinfo := info.Fields[key]
+		if propinfo != nil && propinfo.Omit {
+			continue
+		}
 
 		// TODO[pulumi/pulumi#397]: represent sensitive types using a Secret<T> type.
 		doc := getDescriptionFromParsedDocs(entityDocs, key)
-		rawdoc := propschema.Description()
-
-		propinfo := info.Fields[key]
+		rawdoc, _ := splitEmbeddedExamples(propschema.Description())
 
 		// If we are generating a provider, we do not emit output property definitions as provider outputs are not
 		// yet implemented.
@@ -1043,7 +1456,12 @@ func (g *Generator) gatherResource(rawname string,
 	}
 
 	// Ensure there weren't any custom fields that were unrecognized.
+	fieldKeys := make([]string, 0, len(info.Fields))
 	for key := range info.Fields {
+		fieldKeys = append(fieldKeys, key)
+	}
+	sort.Strings(fieldKeys)
+	for _, key := range fieldKeys {
 		if _, has := schema.Schema().GetOk(key); !has {
 			g.warn("custom resource schema %s.%s was not present in the Terraform metadata", name, key)
 		}
@@ -1066,10 +1484,14 @@ func (g *Generator) gatherDataSources() (moduleMap, error) {
 	var dserr error
 	seen := make(map[string]bool)
 	for _, ds := range stableResources(sources) {
+		g.progress.advance()
 		dsinfo := g.info.DataSources[ds]
 		if dsinfo == nil {
 			if failBuildOnProviderMapError {
 				g.error("data source %s not found in provider map; exiting", ds)
+			} else if tok, ok := g.pendingInferredTokens.DataSources[ds]; ok {
+				g.warn("data source %s would be mapped to %s by its module prefix; skipping until the token is "+
+					"accepted with --accept-inferred-tokens", ds, tok)
 			} else {
 				g.warn("data source %s not found in provider map; skipping", ds)
 			}
@@ -1081,10 +1503,20 @@ func (g *Generator) gatherDataSources() (moduleMap, error) {
 		if err != nil {
 			// Keep track of the error, but keep going, so we can expose more at once.
 			dserr = multierror.Append(dserr, err)
-		} else {
+		} else if fun != nil {
 			// Add any members returned to the specified module.
 			modules.ensureModule(module).addMember(fun)
 		}
+
+		// Data sources may also be exposed as read-only resources.
+		if resInfo := tfbridge.DataSourceResourceInfo(sources.Get(ds), dsinfo); resInfo != nil {
+			module, res, err := g.gatherDataSourceResource(ds, sources.Get(ds), resInfo)
+			if err != nil {
+				dserr = multierror.Append(dserr, err)
+			} else if res != nil {
+				modules.ensureModule(module).addMember(res)
+			}
+		}
 	}
 	if dserr != nil {
 		return nil, dserr
@@ -1109,17 +1541,54 @@ func (g *Generator) gatherDataSources() (moduleMap, error) {
 // gatherDataSource returns the module name and members for the given data source function.
 func (g *Generator) gatherDataSource(rawname string,
 	ds shim.Resource, info *tfbridge.DataSourceInfo) (string, *resourceFunc, error) {
+	return g.gatherDataSourceWithDocs(rawname, ds, info, DataSourceDocs)
+}
+
+// gatherDataSourceWithDocs returns the module name and function for the given data source, reading its docs from
+// the upstream docs of the given kind.
+func (g *Generator) gatherDataSourceWithDocs(rawname string, ds shim.Resource, info *tfbridge.DataSourceInfo,
+	kind DocKind) (string, *resourceFunc, error) {
 	// Generate the name and module for this data source.
 	name, module := dataSourceName(g.info.Name, rawname, info)
 
 	// Collect documentation information for this data source.
 	entityDocs, err := getDocsForProvider(g, g.info.GetGitHubOrg(), g.info.Name,
-		g.info.GetResourcePrefix(), DataSourceDocs, rawname, info, g.info.GetProviderModuleVersion(),
+		g.info.GetResourcePrefix(), kind, rawname, info, g.info.GetProviderModuleVersion(),
 		g.info.GetGitHubHost())
 	if err != nil {
 		return "", nil, err
 	}
 
+	trace := g.docTraceStage(kind, rawname, &entityDocs.Description)
+
+	// Fall back to the upstream data source's own description if there are no docs for it.
+	fromUpstream := entityDocs.Description == ""
+	if fromUpstream {
+		entityDocs.Description = upstreamDescription(ds)
+	}
+	trace("use upstream schema description")
+	entityDocs.Description = addEmbeddedExamples(entityDocs.Description, fromUpstream, ds.Schema(), info.Fields)
+	trace("add embedded examples")
+
+	// Merge in any hand-written examples for this data source.
+	if entityDocs.Description, err = g.addCuratedExamples(entityDocs.Description, string(info.Tok)); err != nil {
+		return "", nil, err
+	}
+	trace("add curated examples")
+
+	// Add any Pulumi-specific notes for this data source.
+	if entityDocs.Description, err = g.addDocNotes(rawname, entityDocs.Description, info.GetDocs()); err != nil {
+		return "", nil, err
+	}
+	trace("add doc notes")
+	if g.labelExperimental("function", rawname, string(info.Tok), info.Experimental, &entityDocs,
+		upstreamDescription(ds)) {
+		return "", nil, nil
+	}
+	trace("label experimental")
+	g.labelDeprecatedRedirects("function", rawname, &entityDocs)
+	trace("label deprecated redirects")
+
 	// Build up the function information.
 	fun := &resourceFunc{
 		name:       name,
@@ -1131,12 +1600,21 @@ func (g *Generator) gatherDataSource(rawname string,
 	}
 
 	// See if arguments for this function are optional, and generate detailed metadata.
+	if err := validateOmittedFields(ds.Schema(), info.Fields); err != nil {
+		return "", nil, errors.Wrapf(err, "data source %s", rawname)
+	}
+	if err := validateMemberNames(ds.Schema(), info.Fields); err != nil {
+		return "", nil, errors.Wrapf(err, "data source %s", rawname)
+	}
 	for _, arg := range stableSchemas(ds.Schema()) {
 		sch := ds.Schema().Get(arg)
 		if sch.Removed() != "" {
 			continue
 		}
 		cust := info.Fields[arg]
+		if cust != nil && cust.Omit {
+			continue
+		}
 
 		// Remember detailed information for every input arg (we will use it below).
 		if input(sch, cust) {
@@ -1277,6 +1755,59 @@ func propertyName(key string, sch shim.Schema, custom *tfbridge.SchemaInfo) stri
 	return tfbridge.TerraformToPulumiName(key, sch, custom, false /*no to PascalCase; we want camelCase*/)
 }
 
+// validateOmittedFields ensures that every property marked as omitted is computed-only and that no remaining property
+// refers to it, since omitted properties never reach Pulumi programs or state.
+func validateOmittedFields(tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) error {
+	var err error
+	omitted, references := map[string]bool{}, map[string][]string{}
+
+	// Terraform refers to nested properties using paths of the form `block.0.property`.
+	var visit func(prefix string, tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo)
+	visit = func(prefix string, tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) {
+		for _, key := range stableSchemas(tfs) {
+			sch, path := tfs.Get(key), prefix+key
+			for _, conflict := range sch.ConflictsWith() {
+				references[conflict] = append(references[conflict], path)
+			}
+
+			info := infos[key]
+			if info != nil && info.Omit {
+				omitted[path] = true
+				switch {
+				case path == "id":
+					err = multierror.Append(err, errors.New("id cannot be omitted"))
+				case sch.Required() || sch.Optional():
+					err = multierror.Append(err, errors.Errorf("%s cannot be omitted because it is an input", path))
+				}
+				continue
+			}
+
+			if res, ok := sch.Elem().(shim.Resource); ok {
+				var fields map[string]*tfbridge.SchemaInfo
+				if info != nil && info.Elem != nil {
+					fields = info.Elem.Fields
+				}
+				visit(path+".0.", res.Schema(), fields)
+			}
+		}
+	}
+	visit("", tfs, infos)
+
+	paths := make([]string, 0, len(omitted))
+	for path := range omitted {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, ref := range references[path] {
+			if !omitted[ref] {
+				err = multierror.Append(err, errors.Errorf("%s cannot be omitted because %s refers to it", path, ref))
+			}
+		}
+	}
+	return err
+}
+
 // propertyVariable creates a new property, with the Pulumi name, out of the given components.
 func propertyVariable(key string, sch shim.Schema, info *tfbridge.SchemaInfo,
 	doc string, rawdoc string, out bool, entityDocs entityDocs) *variable {
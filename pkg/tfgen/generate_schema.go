@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -39,6 +40,8 @@ type schemaGenerator struct {
 	pkg     string
 	version string
 	info    tfbridge.ProviderInfo
+
+	validatedInputs codegen.StringSet // the inputs, as "<token>.<name>", whose values upstream validates.
 }
 
 type schemaNestedType struct {
@@ -266,11 +269,47 @@ func (g *schemaGenerator) genPackageSpec(pack *pkg) (pschema.PackageSpec, error)
 		}
 	}
 
-	for token, typ := range g.info.ExtraTypes {
+	extraTypes := make([]string, 0, len(g.info.ExtraTypes))
+	for token := range g.info.ExtraTypes {
+		extraTypes = append(extraTypes, token)
+	}
+	sort.Strings(extraTypes)
+	for _, token := range extraTypes {
 		if _, defined := spec.Types[token]; defined {
 			return pschema.PackageSpec{}, fmt.Errorf("failed to define extra types: %v is already defined", token)
 		}
-		spec.Types[token] = typ
+		spec.Types[token] = g.info.ExtraTypes[token]
+	}
+
+	// Enums are added after the extra types, so that they never take a token that the provider defines itself. They
+	// are built from the allowed values that ExtractDocValues extracts, so they are only added along with those.
+	if g.info.ExtractDocValues && g.info.ExtractDocEnums {
+		applyDocEnums(&spec, g.validatedInputs)
+	}
+
+	if g.info.IncludePrecomputedValue {
+		token := string(tfbridge.PrecomputedValueToken(g.pkg))
+		if _, defined := spec.Resources[token]; defined {
+			return pschema.PackageSpec{}, fmt.Errorf("failed to define PrecomputedValue: %v is already defined", token)
+		}
+		spec.Resources[token] = tfbridge.PrecomputedValueResourceSpec()
+	}
+
+	// Every bridged provider serves the terraformCompat invoke.
+	compatToken := string(tfbridge.TerraformCompatToken(g.pkg))
+	if _, defined := spec.Functions[compatToken]; defined {
+		return pschema.PackageSpec{}, fmt.Errorf("failed to define terraformCompat: %v is already defined", compatToken)
+	}
+	spec.Functions[compatToken] = tfbridge.TerraformCompatFunctionSpec()
+
+	for _, path := range g.info.SchemaFragments {
+		fragment, err := readSchemaFragment(path)
+		if err != nil {
+			return pschema.PackageSpec{}, err
+		}
+		if err = mergeSchemaFragment(&spec, fragment); err != nil {
+			return pschema.PackageSpec{}, fmt.Errorf("failed to merge schema fragment %s: %w", path, err)
+		}
 	}
 
 	downstreamLicense := g.info.GetTFProviderLicense()
@@ -323,6 +362,19 @@ func (g *schemaGenerator) genPackageSpec(pack *pkg) (pschema.PackageSpec, error)
 		})
 	}
 
+	// Record the version of the upstream provider linked into tfgen, so that the provider can detect at startup
+	// whether it was built against a different version than this schema describes, along with the protocol and
+	// features that the upstream provider uses.
+	var upstream tfbridge.UpstreamMetadata
+	upstreamModule := g.info.GetUpstreamModulePath()
+	if upstreamVersion, ok := tfbridge.LinkedModuleVersion(upstreamModule); ok {
+		upstream.Module, upstream.Version = upstreamModule, upstreamVersion
+	}
+	upstream.ProtocolVersion, upstream.Features = pack.compatibility.ProtocolVersion, pack.compatibility.features()
+	if upstream.Version != "" || upstream.ProtocolVersion != 0 || len(upstream.Features) > 0 {
+		spec.Language[tfbridge.UpstreamMetadataKey] = rawMessage(upstream)
+	}
+
 	return spec, nil
 }
 
@@ -371,6 +423,34 @@ func (g *schemaGenerator) genRawDocComment(comment string) string {
 	return buffer.String()
 }
 
+// forceNewDocComment is appended to the docs of properties that force the replacement of their resource.
+const forceNewDocComment = "Changing this property forces a new resource to be created."
+
+// skipRefreshDocComment is appended to the docs of properties that refresh does not update.
+const skipRefreshDocComment = "This property is not refreshed: `pulumi refresh` still reads the resource, but keeps " +
+	"the last known value of this property, so changes made to it outside of Pulumi are not detected."
+
+// forceNewDocRegexp matches upstream docs that already mention that changing a property forces a replacement.
+var forceNewDocRegexp = regexp.MustCompile(`(?i)forces? (a )?(new resource|replacement)`)
+
+// appendImportPlaceholderDoc appends a note on the placeholder that imports report for an input that cannot be read
+// from the cloud to the input's docs.
+func appendImportPlaceholderDoc(description string, input *tfbridge.ImportInputInfo) string {
+	if description != "" {
+		description = strings.TrimRight(description, "\n") + "\n\n"
+	}
+	placeholder := &bytes.Buffer{}
+	enc := json.NewEncoder(placeholder)
+	enc.SetEscapeHTML(false)
+	contract.AssertNoError(enc.Encode(input.Placeholder))
+	description += fmt.Sprintf("This property cannot be read from the cloud when the resource is imported, so imports "+
+		"report the placeholder `%s`.", strings.TrimSpace(placeholder.String()))
+	if input.Required {
+		description += " The placeholder must be replaced with the real value before the resource can be created or updated."
+	}
+	return description + "\n"
+}
+
 func (g *schemaGenerator) genProperty(mod string, prop *variable, pyMapCase bool) pschema.PropertySpec {
 	description := ""
 	if prop.doc != "" && prop.doc != elidedDocComment {
@@ -403,14 +483,42 @@ func (g *schemaGenerator) genProperty(mod string, prop *variable, pyMapCase bool
 				}
 			}
 		}
+	} else if !prop.out && g.info.UpstreamDefaults {
+		// Carry over defaults declared by the upstream schema, e.g. by a plugin framework attribute.
+		defaultValue = prop.upstreamDefault()
 	}
 
 	var secret bool
-	if prop.info != nil && prop.info.Secret != nil {
+	if prop.config {
+		secret = prop.configSecret()
+		if secret {
+			// Remind users to encrypt secret configuration, since the config docs are where they look up its key.
+			if description != "" {
+				description = strings.TrimRight(description, "\n") + "\n\n"
+			}
+			description += fmt.Sprintf("This value is secret. Set it with `pulumi config set --secret %s:%s`.\n",
+				g.pkg, prop.name)
+		}
+	} else if prop.info != nil && prop.info.Secret != nil {
 		secret = *prop.info.Secret
 	}
 
-	return pschema.PropertySpec{
+	// Replacements are easy to miss in a preview, so call them out in the docs of every property that forces one.
+	if !prop.config && prop.forceNew() && !forceNewDocRegexp.MatchString(description) {
+		if description != "" {
+			description = strings.TrimRight(description, "\n") + "\n\n"
+		}
+		description += forceNewDocComment + "\n"
+	}
+
+	if !prop.config && prop.info != nil && prop.info.SkipRefresh {
+		if description != "" {
+			description = strings.TrimRight(description, "\n") + "\n\n"
+		}
+		description += skipRefreshDocComment + "\n"
+	}
+
+	spec := pschema.PropertySpec{
 		TypeSpec:           g.schemaType(mod, prop.typ, prop.out),
 		Description:        description,
 		Default:            defaultValue,
@@ -419,6 +527,11 @@ func (g *schemaGenerator) genProperty(mod string, prop *variable, pyMapCase bool
 		Language:           language,
 		Secret:             secret,
 	}
+	setNestingMode(&spec, prop)
+	if g.info.ExtractDocValues && !prop.out {
+		applyDocValues(description, &spec)
+	}
+	return spec
 }
 
 func (g *schemaGenerator) genConfig(variables []*variable) pschema.ConfigSpec {
@@ -432,6 +545,19 @@ func (g *schemaGenerator) genConfig(variables []*variable) pschema.ConfigSpec {
 			spec.Required = append(spec.Required, v.name)
 		}
 	}
+
+	// Keep each renamed variable under its old name as well, so that programs that read it continue to compile.
+	for oldName, newName := range g.info.RenamedConfig {
+		v, ok := spec.Variables[newName]
+		if !ok {
+			continue
+		}
+		if _, has := spec.Variables[oldName]; has {
+			continue
+		}
+		v.DeprecationMessage = fmt.Sprintf("%s has been deprecated in favor of %s", oldName, newName)
+		spec.Variables[oldName] = v
+	}
 	return spec
 }
 
@@ -445,13 +571,33 @@ func (g *schemaGenerator) genResourceType(mod string, res *resourceType) pschema
 	if !res.IsProvider() {
 		if res.info.DeprecationMessage != "" {
 			spec.DeprecationMessage = res.info.DeprecationMessage
+		} else if msg := g.info.ResourceRedirectMessage(res.info); msg != "" {
+			spec.DeprecationMessage = msg
+		} else if res.schema != nil {
+			spec.DeprecationMessage = res.schema.DeprecationMessage()
 		}
 	}
 	spec.Description = description
 
+	// A renamed `id` property explains why it is not called `id`.
+	var renamedID *tfbridge.SchemaInfo
+	if !res.IsProvider() && res.info.Fields["id"] != nil && res.info.Fields["id"].Name != "" {
+		renamedID = res.info.Fields["id"]
+	}
+
+	// An autonamed property explains how it is populated.
+	var autoNamed *tfbridge.SchemaInfo
+	if !res.IsProvider() && res.info.AutoNaming != nil {
+		autoNamed = res.info.Fields[res.info.AutoNaming.GetField()]
+	}
+
 	spec.Properties = map[string]pschema.PropertySpec{}
 	for _, prop := range res.outprops {
-		spec.Properties[prop.name] = g.genProperty(mod, prop, true)
+		propSpec := g.genProperty(mod, prop, true)
+		if renamedID != nil && prop.info == renamedID {
+			propSpec.Description = appendRenamedIDDoc(propSpec.Description)
+		}
+		spec.Properties[prop.name] = propSpec
 
 		if !prop.optional() {
 			spec.Required = append(spec.Required, prop.name)
@@ -460,13 +606,33 @@ func (g *schemaGenerator) genResourceType(mod string, res *resourceType) pschema
 
 	spec.InputProperties = map[string]pschema.PropertySpec{}
 	for _, prop := range res.inprops {
-		spec.InputProperties[prop.name] = g.genProperty(mod, prop, true)
+		propSpec := g.genProperty(mod, prop, true)
+		if res.IsProvider() {
+			propSpec.Secret = prop.configSecret()
+		}
+		if input := res.info.ImportInputs[prop.name]; input != nil && input.Placeholder != nil {
+			propSpec.Description = appendImportPlaceholderDoc(propSpec.Description, input)
+		}
+		if renamedID != nil && prop.info == renamedID {
+			propSpec.Description = appendRenamedIDDoc(propSpec.Description)
+		}
+		if autoNamed != nil && prop.info == autoNamed {
+			propSpec.Description = appendAutoNamingDoc(propSpec.Description, res.info.AutoNaming)
+		}
+		spec.InputProperties[prop.name] = propSpec
 
 		if !prop.optional() {
 			spec.RequiredInputs = append(spec.RequiredInputs, prop.name)
 		}
 	}
 
+	if res.schema != nil {
+		setInputConstraints(&spec.Language, gatherInputConstraints(res.schema.Schema(), res.info.Fields))
+	}
+	if !res.IsProvider() {
+		g.recordValidatedInputs(string(res.info.Tok), res.inprops)
+	}
+
 	if !res.IsProvider() {
 		_, stateInputs := g.genObjectType(mod, &schemaNestedType{typ: res.statet, pyMapCase: true})
 		spec.StateInputs = &stateInputs
@@ -492,6 +658,10 @@ func (g *schemaGenerator) genDatasourceFunc(mod string, fun *resourceFunc) psche
 	}
 	if fun.info.DeprecationMessage != "" {
 		spec.DeprecationMessage = fun.info.DeprecationMessage
+	} else if msg := g.info.DataSourceRedirectMessage(fun.info); msg != "" {
+		spec.DeprecationMessage = msg
+	} else if fun.schema != nil {
+		spec.DeprecationMessage = fun.schema.DeprecationMessage()
 	}
 	spec.Description = description
 
@@ -504,10 +674,27 @@ func (g *schemaGenerator) genDatasourceFunc(mod string, fun *resourceFunc) psche
 		_, t := g.genObjectType(mod, &schemaNestedType{typ: fun.retst, pyMapCase: true})
 		spec.Outputs = &t
 	}
+	if fun.schema != nil {
+		setInputConstraints(&spec.Language, gatherInputConstraints(fun.schema.Schema(), fun.info.Fields))
+	}
+	g.recordValidatedInputs(string(fun.info.Tok), fun.args)
 
 	return spec
 }
 
+// recordValidatedInputs records the inputs of the resource or function with the given token whose values upstream
+// validates, e.g. with a ValidateFunc.
+func (g *schemaGenerator) recordValidatedInputs(token string, props []*variable) {
+	for _, prop := range props {
+		if v, ok := prop.schema.(shim.SchemaWithValidation); ok && v.HasValidation() {
+			if g.validatedInputs == nil {
+				g.validatedInputs = codegen.NewStringSet()
+			}
+			g.validatedInputs.Add(token + "." + prop.name)
+		}
+	}
+}
+
 func setEquals(a, b codegen.StringSet) bool {
 	if len(a) != len(b) {
 		return false
@@ -543,9 +730,20 @@ func (g *schemaGenerator) genObjectType(mod string, typInfo *schemaNestedType) (
 		spec.Description = g.genDocComment(typ.doc)
 	}
 
+	// Nested provider configuration blocks (e.g. `assume_role`) are projected as first-class object types. Any
+	// sensitive fields inside of them are marked as secrets so that they are not flattened into plaintext config.
+	configType := mod == configMod
+	if res, ok := typInfo.declarer.(*resourceType); ok && res.IsProvider() {
+		configType = true
+	}
+
 	spec.Properties = map[string]pschema.PropertySpec{}
 	for _, prop := range typ.properties {
-		spec.Properties[prop.name] = g.genProperty(mod, prop, typInfo.pyMapCase)
+		propSpec := g.genProperty(mod, prop, typInfo.pyMapCase)
+		if configType {
+			propSpec.Secret = prop.configSecret()
+		}
+		spec.Properties[prop.name] = propSpec
 
 		if !prop.optional() {
 			spec.Required = append(spec.Required, prop.name)
@@ -663,6 +861,8 @@ func (g *schemaGenerator) schemaType(mod string, typ *propertyType, out bool) ps
 		return pschema.TypeSpec{Type: "object", AdditionalProperties: &additionalProperties}
 	case kindObject:
 		return pschema.TypeSpec{Ref: fmt.Sprintf("#/types/%s:%s/%s:%s", g.pkg, mod, typ.name, typ.name)}
+	case kindAny:
+		return pschema.TypeSpec{Ref: "pulumi.json#/Any"}
 	default:
 		contract.Failf("Unrecognized type kind: %v", typ.kind)
 		return pschema.TypeSpec{}
@@ -712,20 +912,49 @@ func (g *Generator) convertExamplesInFunctionSpec(path string, spec pschema.Func
 	return spec
 }
 
+// convertExamplesInSchema converts the examples in every part of the schema. Members are visited in order of their
+// tokens so that conversion diagnostics are reported in the same order on every run.
 func (g *Generator) convertExamplesInSchema(spec pschema.PackageSpec) pschema.PackageSpec {
-	for name, variable := range spec.Config.Variables {
-		spec.Config.Variables[name] = g.convertExamplesInPropertySpec(name, variable)
+	names := make([]string, 0, len(spec.Config.Variables))
+	for name := range spec.Config.Variables {
+		names = append(names, name)
 	}
-	for token, object := range spec.Types {
+	sort.Strings(names)
+	for _, name := range names {
+		spec.Config.Variables[name] = g.convertExamplesInPropertySpec(name, spec.Config.Variables[name])
+	}
+
+	typeTokens := make([]string, 0, len(spec.Types))
+	for token := range spec.Types {
+		typeTokens = append(typeTokens, token)
+	}
+	sort.Strings(typeTokens)
+	for _, token := range typeTokens {
+		object := spec.Types[token]
 		object.ObjectTypeSpec = g.convertExamplesInObjectSpec("#/types/"+token, object.ObjectTypeSpec)
 		spec.Types[token] = object
 	}
+
 	spec.Provider = g.convertExamplesInResourceSpec("#/provider", spec.Provider)
-	for token, resource := range spec.Resources {
-		spec.Resources[token] = g.convertExamplesInResourceSpec("#/resources/"+token, resource)
+
+	resourceTokens := make([]string, 0, len(spec.Resources))
+	for token := range spec.Resources {
+		resourceTokens = append(resourceTokens, token)
+	}
+	sort.Strings(resourceTokens)
+	for _, token := range resourceTokens {
+		spec.Resources[token] = g.convertExamplesInResourceSpec("#/resources/"+token, spec.Resources[token])
+		g.progress.advance()
+	}
+
+	functionTokens := make([]string, 0, len(spec.Functions))
+	for token := range spec.Functions {
+		functionTokens = append(functionTokens, token)
 	}
-	for token, function := range spec.Functions {
-		spec.Functions[token] = g.convertExamplesInFunctionSpec("#/functions/"+token, function)
+	sort.Strings(functionTokens)
+	for _, token := range functionTokens {
+		spec.Functions[token] = g.convertExamplesInFunctionSpec("#/functions/"+token, spec.Functions[token])
+		g.progress.advance()
 	}
 	return spec
 }
@@ -1,12 +1,25 @@
 package tfgen
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	shimschema "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
 	shimv1 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v1"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
 )
 
 func Test_DeprecationFromTFSchema(t *testing.T) {
@@ -18,3 +31,472 @@ func Test_DeprecationFromTFSchema(t *testing.T) {
 	deprecationMessage := v.deprecationMessage()
 	assert.Equal(t, "This is deprecated", deprecationMessage)
 }
+
+func Test_ResourceDescriptionAndDeprecationFromTFSchema(t *testing.T) {
+	upstream := func() *schemav2.Resource {
+		return &schemav2.Resource{
+			Description:        "An upstream description.",
+			DeprecationMessage: "Use something else.",
+			Schema: map[string]*schemav2.Schema{
+				"name": {Type: schemav2.TypeString, Optional: true},
+			},
+		}
+	}
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": upstream(),
+				"test_gadget": upstream(),
+			},
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"test_widget": upstream(),
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget"},
+			"test_gadget": {Tok: "test:index/gadget:Gadget", DeprecationMessage: "Overridden."},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {Tok: "test:index/getWidget:getWidget"},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	assert.Equal(t, "An upstream description.\n", widget.Description)
+	assert.Equal(t, "Use something else.", widget.DeprecationMessage)
+
+	// Explicit ResourceInfo entries take precedence over the upstream schema.
+	assert.Equal(t, "Overridden.", spec.Resources["test:index/gadget:Gadget"].DeprecationMessage)
+
+	getWidget := spec.Functions["test:index/getWidget:getWidget"]
+	assert.Equal(t, "An upstream description.\n", getWidget.Description)
+	assert.Equal(t, "Use something else.", getWidget.DeprecationMessage)
+
+	// Resources whose shims do not know their descriptions have none.
+	assert.Equal(t, "An upstream description.", upstreamDescription(shimv2.NewResource(upstream())))
+	assert.Equal(t, "", upstreamDescription(struct{ shim.Resource }{shimv2.NewResource(upstream())}))
+}
+
+func TestOmittedFields(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {
+					Schema: map[string]*schemav2.Schema{
+						"name":   {Type: schemav2.TypeString, Optional: true},
+						"policy": {Type: schemav2.TypeString, Computed: true},
+						"block": {
+							Type:     schemav2.TypeList,
+							Computed: true,
+							Elem: &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+								"rendered": {Type: schemav2.TypeString, Computed: true},
+								"size":     {Type: schemav2.TypeInt, Computed: true},
+							}},
+						},
+					},
+				},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {
+				Tok: "test:index/widget:Widget",
+				Fields: map[string]*tfbridge.SchemaInfo{
+					"policy": {Omit: true},
+					"block": {Elem: &tfbridge.SchemaInfo{Fields: map[string]*tfbridge.SchemaInfo{
+						"rendered": {Omit: true},
+					}}},
+				},
+			},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	assert.Contains(t, widget.Properties, "name")
+	assert.NotContains(t, widget.Properties, "policy")
+	assert.NotContains(t, widget.StateInputs.Properties, "policy")
+	block := spec.Types["test:index/WidgetBlock:WidgetBlock"]
+	assert.Contains(t, block.Properties, "size")
+	assert.NotContains(t, block.Properties, "rendered")
+}
+
+func TestValidateOmittedFields(t *testing.T) {
+	tfs := shimv2.NewSchemaMap(map[string]*schemav2.Schema{
+		"id":     {Type: schemav2.TypeString, Computed: true},
+		"name":   {Type: schemav2.TypeString, Optional: true, ConflictsWith: []string{"alias"}},
+		"alias":  {Type: schemav2.TypeString, Computed: true},
+		"policy": {Type: schemav2.TypeString, Computed: true},
+	})
+
+	assert.NoError(t, validateOmittedFields(tfs, map[string]*tfbridge.SchemaInfo{"policy": {Omit: true}}))
+
+	err := validateOmittedFields(tfs, map[string]*tfbridge.SchemaInfo{
+		"id":    {Omit: true},
+		"name":  {Omit: true},
+		"alias": {Omit: true},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "id cannot be omitted")
+	assert.Contains(t, err.Error(), "name cannot be omitted because it is an input")
+	assert.NotContains(t, err.Error(), "alias")
+
+	err = validateOmittedFields(tfs, map[string]*tfbridge.SchemaInfo{"alias": {Omit: true}})
+	assert.EqualError(t, err, "1 error occurred:\n\t* alias cannot be omitted because name refers to it\n\n")
+}
+
+func TestForceNewDocs(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {
+					Schema: map[string]*schemav2.Schema{
+						"name": {Type: schemav2.TypeString, Optional: true, ForceNew: true, Description: "The name."},
+						"zone": {Type: schemav2.TypeString, Optional: true, ForceNew: true,
+							Description: "The zone. Changing this forces a new resource to be created."},
+						"size": {Type: schemav2.TypeInt, Optional: true, Description: "The size."},
+						"tag":  {Type: schemav2.TypeString, Optional: true, ForceNew: true, Description: "The tag."},
+					},
+				},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {
+				Tok: "test:index/widget:Widget",
+				Fields: map[string]*tfbridge.SchemaInfo{
+					"tag": {ForceNew: tfbridge.False()},
+				},
+			},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	name := widget.InputProperties["name"]
+	assert.Equal(t, "The name.\n\nChanging this property forces a new resource to be created.\n", name.Description)
+	assert.Equal(t, name.Description, widget.Properties["name"].Description)
+
+	// Upstream docs that already mention the replacement are left alone.
+	zone := widget.InputProperties["zone"]
+	assert.Equal(t, "The zone. Changing this forces a new resource to be created.\n", zone.Description)
+
+	assert.Equal(t, "The size.\n", widget.InputProperties["size"].Description)
+
+	// Overlays take precedence over the upstream schema.
+	assert.Equal(t, "The tag.\n", widget.InputProperties["tag"].Description)
+}
+
+func TestSkipRefreshDocs(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {
+					Schema: map[string]*schemav2.Schema{
+						"name": {Type: schemav2.TypeString, Optional: true, Description: "The name."},
+						"tags": {Type: schemav2.TypeMap, Optional: true, Elem: &schemav2.Schema{Type: schemav2.TypeString},
+							Description: "The tags."},
+					},
+				},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {
+				Tok:    "test:index/widget:Widget",
+				Fields: map[string]*tfbridge.SchemaInfo{"tags": {SkipRefresh: true}},
+			},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	assert.Equal(t, "The tags.\n\n"+skipRefreshDocComment+"\n", widget.InputProperties["tags"].Description)
+	assert.Equal(t, "The tags.\n\n"+skipRefreshDocComment+"\n", widget.Properties["tags"].Description)
+	assert.Equal(t, "The name.\n", widget.InputProperties["name"].Description)
+}
+
+func TestConfigSecrets(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			Schema: map[string]*schemav2.Schema{
+				"token":    {Type: schemav2.TypeString, Optional: true, Sensitive: true, Description: "The token."},
+				"region":   {Type: schemav2.TypeString, Optional: true, Description: "The region."},
+				"username": {Type: schemav2.TypeString, Optional: true, Sensitive: true, Description: "The user."},
+				"api_key":  {Type: schemav2.TypeString, Optional: true, Description: "The API key."},
+			},
+		}),
+		Config: map[string]*tfbridge.SchemaInfo{
+			"username": {Secret: tfbridge.False()},
+			"api_key":  {Secret: tfbridge.True()},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	variables := spec.Config.Variables
+	assert.True(t, variables["token"].Secret)
+	assert.Equal(t, "The token.\n\nThis value is secret. Set it with `pulumi config set --secret test:token`.\n",
+		variables["token"].Description)
+	assert.False(t, variables["region"].Secret)
+	assert.Equal(t, "The region.\n", variables["region"].Description)
+	assert.False(t, variables["username"].Secret)
+	assert.Equal(t, "The user.\n", variables["username"].Description)
+	assert.True(t, variables["apiKey"].Secret)
+
+	// The provider resource's inputs agree with the config.
+	inputs := spec.Provider.InputProperties
+	assert.True(t, inputs["token"].Secret)
+	assert.False(t, inputs["username"].Secret)
+	assert.True(t, inputs["apiKey"].Secret)
+}
+
+func TestDynamicAttributesAndDefaults(t *testing.T) {
+	// Providers written against the plugin framework are shimmed directly rather than through an SDK.
+	settings := (&shimschema.Resource{
+		Schema: shimschema.SchemaMap{
+			"enabled": (&shimschema.Schema{Type: shim.TypeBool, Optional: true, Default: true}).Shim(),
+			"retries": (&shimschema.Schema{Type: shim.TypeInt, Optional: true, Default: 3}).Shim(),
+		},
+	}).Shim()
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: (&shimschema.Provider{
+			Schema: shimschema.SchemaMap{},
+			ResourcesMap: shimschema.ResourceMap{
+				"test_widget": (&shimschema.Resource{
+					Schema: shimschema.SchemaMap{
+						"payload": (&shimschema.Schema{Type: shim.TypeDynamic, Optional: true, Default: "{}"}).Shim(),
+						"result":  (&shimschema.Schema{Type: shim.TypeDynamic, Computed: true}).Shim(),
+						"mode":    (&shimschema.Schema{Type: shim.TypeString, Optional: true, Default: "fast"}).Shim(),
+						"size":    (&shimschema.Schema{Type: shim.TypeString, Optional: true, Default: "small"}).Shim(),
+						"settings": (&shimschema.Schema{
+							Type:     shim.TypeList,
+							Optional: true,
+							MaxItems: 1,
+							Elem:     settings,
+						}).Shim(),
+					},
+				}).Shim(),
+			},
+			DataSourcesMap: shimschema.ResourceMap{},
+		}).Shim(),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {
+				Tok: "test:index/widget:Widget",
+				Fields: map[string]*tfbridge.SchemaInfo{
+					"size": {Default: &tfbridge.DefaultInfo{Value: "large"}},
+				},
+			},
+		},
+	}
+	// Upstream defaults are only carried over when the provider opts in.
+	res := genTestSchema(t, info).Resources["test:index/widget:Widget"]
+	assert.Nil(t, res.InputProperties["mode"].Default)
+	assert.Equal(t, "large", res.InputProperties["size"].Default)
+
+	info.UpstreamDefaults = true
+	spec := genTestSchema(t, info)
+	res = spec.Resources["test:index/widget:Widget"]
+	assert.Equal(t, "pulumi.json#/Any", res.InputProperties["payload"].Ref)
+	assert.Equal(t, "pulumi.json#/Any", res.Properties["payload"].Ref)
+	assert.Equal(t, "pulumi.json#/Any", res.Properties["result"].Ref)
+
+	// Dynamic attributes never carry defaults.
+	assert.Nil(t, res.InputProperties["payload"].Default)
+
+	// Upstream defaults are carried over to inputs, but overlays take precedence.
+	assert.Equal(t, "fast", res.InputProperties["mode"].Default)
+	assert.Nil(t, res.Properties["mode"].Default)
+	assert.Equal(t, "large", res.InputProperties["size"].Default)
+
+	// Defaults of nested object attributes are carried over as well.
+	typ, ok := spec.Types["test:index/WidgetSettings:WidgetSettings"]
+	if assert.True(t, ok) {
+		assert.Equal(t, true, typ.Properties["enabled"].Default)
+		assert.Equal(t, 3.0, typ.Properties["retries"].Default)
+	}
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	newResource := func() *schemav2.Resource {
+		return &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+			"name":     {Type: schemav2.TypeString, Required: true, Description: "The name."},
+			"size":     {Type: schemav2.TypeInt, Required: true, Description: "The size. Defaults to `1`."},
+			"zone":     {Type: schemav2.TypeString, Required: true, ForceNew: true},
+			"tags":     {Type: schemav2.TypeMap, Optional: true, Elem: &schemav2.Schema{Type: schemav2.TypeString}},
+			"arn":      {Type: schemav2.TypeString, Computed: true},
+			"endpoint": {Type: schemav2.TypeString, Computed: true},
+			"rule": {Type: schemav2.TypeList, Optional: true, Elem: &schemav2.Resource{
+				Schema: map[string]*schemav2.Schema{
+					"action":   {Type: schemav2.TypeString, Required: true},
+					"priority": {Type: schemav2.TypeInt, Required: true},
+					"secret":   {Type: schemav2.TypeString, Optional: true, Sensitive: true},
+				},
+			}},
+		}}
+	}
+	requiredString := func() *tfbridge.ConfigInfo {
+		return &tfbridge.ConfigInfo{Schema: shimv2.NewSchema(&schemav2.Schema{Type: schemav2.TypeString, Required: true})}
+	}
+
+	generate := func(language Language) map[string]string {
+		info := tfbridge.ProviderInfo{
+			Name: "test",
+			P: shimv2.NewProvider(&schemav2.Provider{
+				Schema: map[string]*schemav2.Schema{
+					"region": {Type: schemav2.TypeString, Required: true},
+					"token":  {Type: schemav2.TypeString, Optional: true, Sensitive: true},
+				},
+				ResourcesMap: map[string]*schemav2.Resource{
+					"test_widget": newResource(), "test_gadget": newResource(), "test_gizmo": newResource(),
+				},
+				DataSourcesMap: map[string]*schemav2.Resource{
+					"test_widget": newResource(), "test_gadget": newResource(),
+				},
+			}),
+			Resources: map[string]*tfbridge.ResourceInfo{
+				"test_widget": {Tok: "test:index/widget:Widget", Fields: map[string]*tfbridge.SchemaInfo{
+					"missing_a": {}, "missing_b": {}, "missing_c": {},
+				}},
+				"test_gadget": {Tok: "test:gadgets/gadget:Gadget"},
+				"test_gizmo":  {Tok: "test:gadgets/gizmo:Gizmo"},
+			},
+			DataSources: map[string]*tfbridge.DataSourceInfo{
+				"test_widget": {Tok: "test:index/getWidget:getWidget"},
+				"test_gadget": {Tok: "test:gadgets/getGadget:getGadget"},
+			},
+			ExtraConfig: map[string]*tfbridge.ConfigInfo{
+				"alpha": requiredString(), "bravo": requiredString(), "charlie": requiredString(),
+			},
+			Config: map[string]*tfbridge.SchemaInfo{
+				"missing_a": {}, "missing_b": {}, "missing_c": {},
+			},
+			RenamedConfig: map[string]string{
+				"old_a": "missingA", "old_b": "missingB", "old_c": "missingC",
+			},
+			ExtractDocValues: true,
+		}
+
+		var warnings bytes.Buffer
+		root, tracker := afero.NewMemMapFs(), newCoverageTracker("test", "0.0.1")
+		g, err := NewGenerator(GeneratorOptions{
+			Package:         "test",
+			Version:         "0.0.1",
+			Language:        language,
+			ProviderInfo:    info,
+			Root:            root,
+			Sink:            diag.DefaultSink(&warnings, &warnings, diag.FormatOptions{Color: colors.Never}),
+			SkipExamples:    true,
+			CoverageTracker: tracker,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, g.Generate())
+
+		// Record example conversions as if examples had been converted, so that every exporter has data.
+		for _, example := range []string{"create", "update", "import", "delete"} {
+			tracker.foundExample(example, "resource \"test_widget\" \""+example+"\" {}")
+			tracker.languageConversionSuccess("nodejs")
+			tracker.languageConversionFailure("python", hcl.Diagnostics{{Summary: "unsupported " + example}})
+			tracker.languageConversionWarning("go", hcl.Diagnostics{{Summary: "partially supported"}})
+			tracker.languageConversionPanic("dotnet", "panicked")
+		}
+		dir := t.TempDir()
+		assert.NoError(t, tracker.exportResults(NewFileCoverageSink(dir)))
+
+		files := map[string]string{}
+		err = afero.Walk(root, "", func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			contents, err := afero.ReadFile(root, path)
+			files[path] = string(contents)
+			return err
+		})
+		assert.NoError(t, err)
+		exported, err := ioutil.ReadDir(dir)
+		assert.NoError(t, err)
+		for _, info := range exported {
+			contents, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+			assert.NoError(t, err)
+			files["coverage/"+info.Name()] = string(contents)
+		}
+		files["warnings"] = warnings.String()
+		return files
+	}
+
+	// Map iteration order is randomized, so compare several runs to catch any output that depends on it.
+	for _, language := range []Language{Schema, NodeJS} {
+		first := generate(language)
+		assert.Contains(t, first, "coverage/byExample.json")
+		assert.Contains(t, first["warnings"], "renamed config old_b refers to missingB")
+		assert.Contains(t, first["warnings"], "custom resource schema Widget.missing_c was not present")
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, first, generate(language), "%s output differs between runs", language)
+		}
+	}
+}
+
+func TestAppendImportPlaceholderDoc(t *testing.T) {
+	assert.Equal(t, "The admin password.\n\nThis property cannot be read from the cloud when the resource is imported, "+
+		"so imports report the placeholder `\"<password>\"`. The placeholder must be replaced with the real "+
+		"value before the resource can be created or updated.\n",
+		appendImportPlaceholderDoc("The admin password.\n", &tfbridge.ImportInputInfo{
+			Placeholder: "<password>",
+			Required:    true,
+		}))
+	assert.Equal(t, "This property cannot be read from the cloud when the resource is imported, so imports report "+
+		"the placeholder `false`.\n", appendImportPlaceholderDoc("", &tfbridge.ImportInputInfo{Placeholder: false}))
+}
+
+func Test_GenConfigRenamedVariables(t *testing.T) {
+	sch := shimv2.NewSchema(&schemav2.Schema{Type: schemav2.TypeString, Optional: true})
+	v := propertyVariable("region", sch, nil, "", "The region.", true /*out*/, entityDocs{})
+	v.config = true
+
+	g := &schemaGenerator{
+		pkg: "test",
+		info: tfbridge.ProviderInfo{
+			RenamedConfig: map[string]string{"location": "region", "zone": "missing"},
+		},
+	}
+	spec := g.genConfig([]*variable{v})
+
+	assert.Len(t, spec.Variables, 2)
+	assert.Equal(t, "", spec.Variables["region"].DeprecationMessage)
+	assert.Equal(t, "location has been deprecated in favor of region", spec.Variables["location"].DeprecationMessage)
+	assert.Equal(t, spec.Variables["region"].TypeSpec, spec.Variables["location"].TypeSpec)
+}
+
+func TestBridgeConfigVariables(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			Schema: map[string]*schemav2.Schema{
+				"http_proxy": {Type: schemav2.TypeString, Optional: true, Description: "The provider's own proxy."},
+			},
+		}),
+	}
+
+	// Features that the provider has not opted in to are not declared.
+	spec := genTestSchema(t, info)
+	assert.NotContains(t, spec.Config.Variables, "insecureSkipTlsVerify")
+
+	info.TransportCallback = func(*tfbridge.TransportSettings) error { return nil }
+	spec = genTestSchema(t, info)
+	insecure := spec.Config.Variables["insecureSkipTlsVerify"]
+	assert.Equal(t, "boolean", insecure.Type)
+	assert.Equal(t, "Disables TLS certificate verification for the provider's HTTP requests. This is insecure.\n",
+		insecure.Description)
+	assert.Equal(t, "string", spec.Config.Variables["caBundle"].Type)
+
+	// The provider's own configuration wins over the bridge's.
+	assert.Equal(t, "The provider's own proxy.\n", spec.Config.Variables["httpProxy"].Description)
+}
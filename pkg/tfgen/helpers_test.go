@@ -0,0 +1,71 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/spf13/afero"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// The helpers below generate the Pulumi schema of a test provider, and are shared by the tests of the features that
+// change the schema.
+
+// newTestGenerator returns a generator of the Pulumi schema of the given provider, without docs. The given options
+// adjust the generator's options.
+func newTestGenerator(t *testing.T, info tfbridge.ProviderInfo, options ...func(*GeneratorOptions)) *Generator {
+	opts := GeneratorOptions{
+		Package:      "test",
+		Version:      "0.0.1",
+		Language:     Schema,
+		ProviderInfo: info,
+		Root:         afero.NewMemMapFs(),
+		Sink:         diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		SkipDocs:     true,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	g, err := NewGenerator(opts)
+	if err != nil {
+		t.Fatalf("creating generator: %v", err)
+	}
+	return g
+}
+
+// genTestSchema generates the Pulumi schema of the given provider, without docs. The given options adjust the
+// generator's options.
+func genTestSchema(t *testing.T, info tfbridge.ProviderInfo, options ...func(*GeneratorOptions)) pschema.PackageSpec {
+	return genGeneratorSchema(t, newTestGenerator(t, info, options...))
+}
+
+// genGeneratorSchema generates the Pulumi schema with the given generator.
+func genGeneratorSchema(t *testing.T, g *Generator) pschema.PackageSpec {
+	pack, err := g.gatherPackage()
+	if err != nil {
+		t.Fatalf("gathering package: %v", err)
+	}
+	spec, err := genPulumiSchema(pack, g.pkg, g.version, g.info)
+	if err != nil {
+		t.Fatalf("generating schema: %v", err)
+	}
+	return spec
+}
@@ -0,0 +1,77 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"strings"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// renamedIDDocComment is appended to the docs of `id` properties that were renamed.
+const renamedIDDocComment = "This is the `id` argument of the upstream resource. It is renamed because Pulumi reserves " +
+	"`id` for the ID of every resource."
+
+// renamedID records a resource whose user-settable `id` property was renamed by tfbridge.RenameIDProperty.
+type renamedID struct {
+	TFName string `json:"tfName"`
+	Token  string `json:"token"`
+	Name   string `json:"name"` // the property's new name
+}
+
+// resolveResourceInfos resolves the info of every mapped resource in the given info with tfbridge.ResolveResourceInfo,
+// the same way the provider does, so that the schema, the docs and the converted examples all describe the properties
+// that the provider renames and autonames. It returns the resources whose `id` property was renamed.
+func resolveResourceInfos(info *tfbridge.ProviderInfo) []renamedID {
+	if info.P == nil {
+		return nil
+	}
+	resources := info.P.ResourcesMap()
+
+	var renamed []renamedID
+	var infos map[string]*tfbridge.ResourceInfo
+	for _, name := range stableResources(resources) {
+		resInfo := info.Resources[name]
+		if resInfo == nil {
+			continue
+		}
+		newInfo, newName := tfbridge.ResolveResourceInfo(resources.Get(name), resInfo.Tok, resInfo, info.AutoNaming)
+		if newName != "" {
+			renamed = append(renamed, renamedID{TFName: name, Token: string(resInfo.Tok), Name: newName})
+		}
+		if newInfo == resInfo {
+			continue
+		}
+		if infos == nil {
+			infos = make(map[string]*tfbridge.ResourceInfo, len(info.Resources))
+			for k, v := range info.Resources {
+				infos[k] = v
+			}
+		}
+		infos[name] = newInfo
+	}
+	if infos != nil {
+		info.Resources = infos
+	}
+	return renamed
+}
+
+// appendRenamedIDDoc appends a note that explains why the property was renamed to the given description.
+func appendRenamedIDDoc(description string) string {
+	if description != "" {
+		description = strings.TrimRight(description, "\n") + "\n\n"
+	}
+	return description + renamedIDDocComment + "\n"
+}
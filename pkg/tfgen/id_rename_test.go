@@ -0,0 +1,73 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestRenameIDProperties(t *testing.T) {
+	resources := map[string]*tfbridge.ResourceInfo{
+		"test_widget": {Tok: "test:index/widget:Widget", RenameID: true},
+		"test_gadget": {Tok: "test:index/gadget:Gadget", RenameID: true},
+		"test_gizmo":  {Tok: "test:index/gizmo:Gizmo"},
+	}
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"id": {
+						Type:        schemav2.TypeString,
+						Optional:    true,
+						Computed:    true,
+						Description: "The name of the widget.",
+					},
+				}},
+				"test_gadget": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Optional: true},
+				}},
+				"test_gizmo": {Schema: map[string]*schemav2.Schema{
+					"id": {Type: schemav2.TypeString, Optional: true, Computed: true},
+				}},
+			},
+		}),
+		Resources: resources,
+	}
+
+	g := newTestGenerator(t, info)
+	assert.Equal(t, []renamedID{
+		{TFName: "test_widget", Token: "test:index/widget:Widget", Name: "resourceId"},
+	}, g.renamedIDs)
+	assert.Nil(t, resources["test_widget"].Fields)
+
+	spec := genGeneratorSchema(t, g)
+
+	widget := spec.Resources["test:index/widget:Widget"]
+	assert.Contains(t, widget.InputProperties, "resourceId")
+	assert.NotContains(t, widget.InputProperties, "id")
+	assert.Equal(t, "The name of the widget.\n\n"+renamedIDDocComment+"\n",
+		widget.InputProperties["resourceId"].Description)
+	assert.Contains(t, widget.Properties, "resourceId")
+
+	// Resources that do not opt in keep their id argument.
+	assert.Contains(t, spec.Resources["test:index/gizmo:Gizmo"].InputProperties, "id")
+}
@@ -0,0 +1,109 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+func newInferredTokens() tfbridge.InferredTokens {
+	return tfbridge.InferredTokens{Resources: map[string]string{}, DataSources: map[string]string{}}
+}
+
+// readInferredTokensFile reads the inferred tokens that an earlier run recorded in the file at path. A missing file
+// records no tokens.
+func readInferredTokensFile(path string) (tfbridge.InferredTokens, error) {
+	recorded := newInferredTokens()
+	if path == "" {
+		return recorded, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return recorded, nil
+	} else if err != nil {
+		return recorded, err
+	}
+	if err = json.Unmarshal(contents, &recorded); err != nil {
+		return recorded, errors.Wrapf(err, "failed to parse inferred tokens in %s", path)
+	}
+	return recorded, nil
+}
+
+// writeInferredTokensFile records the given inferred tokens in the file at path, unless it already records them.
+func writeInferredTokensFile(path string, inferred tfbridge.InferredTokens) error {
+	contents, err := json.MarshalIndent(inferred, "", "    ")
+	if err != nil {
+		return err
+	}
+	contents = append(contents, '\n')
+	if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, contents) {
+		return nil
+	}
+	// The file is checked in alongside the provider's sources, so it is world-readable like them.
+	return ioutil.WriteFile(path, contents, 0644) //nolint:gosec
+}
+
+// resolveInferredTokens decides which tokens inferred from ProviderInfo.ModulePrefixes to use. Tokens recorded by an
+// earlier run are kept as they were, so that a member's token does not change when the prefixes do. Tokens inferred
+// for the first time are only used if accept is true, so that new upstream members are not mapped without review;
+// otherwise they are returned as pending. Recorded tokens for members that are now mapped explicitly, or that no
+// longer exist upstream, are dropped.
+func resolveInferredTokens(info tfbridge.ProviderInfo, pkg string, recorded tfbridge.InferredTokens,
+	accept bool) (tfbridge.InferredTokens, tfbridge.InferredTokens) {
+
+	accepted, pending := newInferredTokens(), newInferredTokens()
+	if info.P == nil {
+		return accepted, pending
+	}
+	inferred := info.InferTokens(pkg)
+
+	resolve := func(tfMap shim.ResourceMap, mapped func(string) bool, recorded, inferred, accepted,
+		pending map[string]string) {
+
+		tfMap.Range(func(name string, _ shim.Resource) bool {
+			if mapped(name) {
+				return true
+			}
+			if tok, ok := recorded[name]; ok {
+				accepted[name] = tok
+			} else if tok, ok := inferred[name]; ok {
+				if accept {
+					accepted[name] = tok
+				} else {
+					pending[name] = tok
+				}
+			}
+			return true
+		})
+	}
+	resolve(info.P.ResourcesMap(), func(name string) bool {
+		_, has := info.Resources[name]
+		return has
+	}, recorded.Resources, inferred.Resources, accepted.Resources, pending.Resources)
+	resolve(info.P.DataSourcesMap(), func(name string) bool {
+		_, has := info.DataSources[name]
+		return has
+	}, recorded.DataSources, inferred.DataSources, accepted.DataSources, pending.DataSources)
+
+	return accepted, pending
+}
@@ -0,0 +1,128 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestResolveInferredTokens(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget":       {},
+				"test_widget_part":  {},
+				"test_gadget":       {},
+				"test_unrecognized": {},
+			},
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_gadget": {Tok: "test:index/gadget:Gadget"},
+		},
+		ModulePrefixes: map[string][]string{"widgets": {"test_widget"}, "gadgets": {"test_gadget"}},
+	}
+
+	// The widget's recorded token is kept even though it no longer matches what would be inferred, and records for
+	// members that are mapped explicitly or no longer exist are dropped.
+	recorded := tfbridge.InferredTokens{
+		Resources: map[string]string{
+			"test_widget":  "test:index/widget:Widget",
+			"test_gadget":  "test:gadgets/gadget:Gadget",
+			"test_removed": "test:widgets/removed:Removed",
+		},
+	}
+	accepted, pending := resolveInferredTokens(info, "test", recorded, false)
+	assert.Equal(t, map[string]string{"test_widget": "test:index/widget:Widget"}, accepted.Resources)
+	assert.Empty(t, accepted.DataSources)
+	assert.Equal(t, map[string]string{"test_widget_part": "test:widgets/widgetPart:WidgetPart"}, pending.Resources)
+	assert.Equal(t, map[string]string{"test_widget": "test:widgets/getWidget:getWidget"}, pending.DataSources)
+
+	accepted, pending = resolveInferredTokens(info, "test", recorded, true)
+	assert.Equal(t, 3, accepted.Len())
+	assert.Equal(t, "test:widgets/widgetPart:WidgetPart", accepted.Resources["test_widget_part"])
+	assert.Equal(t, 0, pending.Len())
+}
+
+func TestGenerateInferredTokens(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Optional: true},
+				}},
+			},
+		}),
+		ModulePrefixes: map[string][]string{"widgets": {"test_widget"}},
+	}
+	path := filepath.Join(t.TempDir(), "inferred-tokens.json")
+
+	generate := func(accept bool) afero.Fs {
+		root := afero.NewMemMapFs()
+		g, err := NewGenerator(GeneratorOptions{
+			Package:              "test",
+			Version:              "0.0.1",
+			Language:             Schema,
+			ProviderInfo:         info,
+			Root:                 root,
+			Sink:                 diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+			SkipDocs:             true,
+			SkipExamples:         true,
+			InferredTokensPath:   path,
+			AcceptInferredTokens: accept,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, g.Generate())
+		return root
+	}
+
+	// Without confirmation, the widget is skipped and nothing is recorded.
+	root := generate(false)
+	schema, err := afero.ReadFile(root, "schema.json")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(schema), "test:widgets/widget:Widget")
+	assert.NoFileExists(t, path)
+
+	// Once accepted, the token is recorded both in the file and in the schema, where the provider reads it from.
+	root = generate(true)
+	schema, err = afero.ReadFile(root, "schema.json")
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"test:widgets/widget:Widget"`)
+	assert.Contains(t, string(schema), tfbridge.InferredTokensKey)
+	recorded, err := readInferredTokensFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"test_widget": "test:widgets/widget:Widget"}, recorded.Resources)
+
+	// Later runs keep the recorded token without confirmation.
+	root = generate(false)
+	schema, err = afero.ReadFile(root, "schema.json")
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"test:widgets/widget:Widget"`)
+}
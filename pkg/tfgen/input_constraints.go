@@ -0,0 +1,126 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// inputConstraints lists the upstream `ExactlyOneOf`, `AtLeastOneOf` and `RequiredWith` groups between the inputs of
+// a resource or function, using the inputs' Pulumi names. The groups are recorded under the "tfbridge" language
+// section of the resource or function as metadata only: the upstream provider already enforces them when inputs are
+// checked, and neither the bridge nor the SDK generators read them back. They are there for tools that document or
+// lint programs against the schema.
+type inputConstraints struct {
+	ExactlyOneOf [][]string          `json:"exactlyOneOf,omitempty"` // exactly one input of each group must be set
+	AtLeastOneOf [][]string          `json:"atLeastOneOf,omitempty"` // at least one input of each group must be set
+	RequiredWith map[string][]string `json:"requiredWith,omitempty"` // the inputs that each input must be set with
+}
+
+// gatherInputConstraints returns the constraint groups between the top-level inputs in the given schema, or nil if
+// there are none. Groups that refer to nested properties, or to properties that are not Pulumi inputs, cannot be
+// expressed in terms of the inputs and are left out, as are the groups of schemas that do not implement
+// shim.SchemaWithConstraints.
+func gatherInputConstraints(tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) *inputConstraints {
+	if tfs == nil {
+		return nil
+	}
+
+	names := map[string]string{}
+	for _, key := range stableSchemas(tfs) {
+		sch, info := tfs.Get(key), infos[key]
+		if (info != nil && info.Omit) || !(sch.Optional() || sch.Required()) {
+			continue
+		}
+		names[key] = propertyName(key, sch, info)
+	}
+	// translate returns the sorted Pulumi names of the given properties, and false if any of them is not an input.
+	translate := func(keys []string) ([]string, bool) {
+		result := make([]string, 0, len(keys))
+		seen := map[string]bool{}
+		for _, key := range keys {
+			name, ok := names[key]
+			if !ok {
+				return nil, false
+			}
+			if !seen[name] {
+				seen[name] = true
+				result = append(result, name)
+			}
+		}
+		sort.Strings(result)
+		return result, true
+	}
+
+	var constraints inputConstraints
+	exactlyOneOf, atLeastOneOf := map[string]bool{}, map[string]bool{}
+	addGroup := func(groups *[][]string, seen map[string]bool, key string, others []string) {
+		if len(others) == 0 {
+			return
+		}
+		group, ok := translate(append([]string{key}, others...))
+		if !ok || len(group) < 2 || seen[strings.Join(group, ",")] {
+			return
+		}
+		seen[strings.Join(group, ",")] = true
+		*groups = append(*groups, group)
+	}
+	for _, key := range stableSchemas(tfs) {
+		if _, ok := names[key]; !ok {
+			continue
+		}
+		sch, ok := tfs.Get(key).(shim.SchemaWithConstraints)
+		if !ok {
+			continue
+		}
+		addGroup(&constraints.ExactlyOneOf, exactlyOneOf, key, sch.ExactlyOneOf())
+		addGroup(&constraints.AtLeastOneOf, atLeastOneOf, key, sch.AtLeastOneOf())
+
+		var required []string
+		for _, other := range sch.RequiredWith() {
+			if other != key {
+				required = append(required, other)
+			}
+		}
+		if group, ok := translate(required); ok && len(group) > 0 {
+			if constraints.RequiredWith == nil {
+				constraints.RequiredWith = map[string][]string{}
+			}
+			constraints.RequiredWith[names[key]] = group
+		}
+	}
+
+	if len(constraints.ExactlyOneOf) == 0 && len(constraints.AtLeastOneOf) == 0 && len(constraints.RequiredWith) == 0 {
+		return nil
+	}
+	return &constraints
+}
+
+// setInputConstraints records the given constraint groups, if any, under the "tfbridge" language section.
+func setInputConstraints(language *map[string]pschema.RawMessage, constraints *inputConstraints) {
+	if constraints == nil {
+		return
+	}
+	if *language == nil {
+		*language = map[string]pschema.RawMessage{}
+	}
+	(*language)["tfbridge"] = rawMessage(map[string]interface{}{"inputConstraints": constraints})
+}
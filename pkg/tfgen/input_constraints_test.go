@@ -0,0 +1,96 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestInputConstraints(t *testing.T) {
+	widget := &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+		"source_url":  {Type: schemav2.TypeString, Optional: true, ExactlyOneOf: []string{"source_url", "source_file"}},
+		"source_file": {Type: schemav2.TypeString, Optional: true, ExactlyOneOf: []string{"source_url", "source_file"}},
+		"username":    {Type: schemav2.TypeString, Optional: true, RequiredWith: []string{"password"}},
+		"password":    {Type: schemav2.TypeString, Optional: true, Sensitive: true},
+		"name":        {Type: schemav2.TypeString, Optional: true, AtLeastOneOf: []string{"name", "name_prefix"}},
+		"name_prefix": {Type: schemav2.TypeString, Optional: true, AtLeastOneOf: []string{"name", "name_prefix"}},
+		// Groups that refer to nested properties cannot be expressed in terms of the inputs.
+		"region": {Type: schemav2.TypeString, Optional: true, AtLeastOneOf: []string{"region", "settings.0.region"}},
+		"settings": {
+			Type:     schemav2.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+				"region": {Type: schemav2.TypeString, Optional: true},
+			}},
+		},
+	}}
+
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap:   map[string]*schemav2.Resource{"test_widget": widget},
+			DataSourcesMap: map[string]*schemav2.Resource{"test_widget": widget},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget", Fields: map[string]*tfbridge.SchemaInfo{
+				"source_file": {Name: "sourcePath"},
+			}},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {Tok: "test:index/getWidget:getWidget"},
+		},
+	}
+
+	spec := genTestSchema(t, info)
+
+	// The groups use the inputs' Pulumi names.
+	assert.JSONEq(t, `{"inputConstraints": {
+		"exactlyOneOf": [["sourcePath", "sourceUrl"]],
+		"atLeastOneOf": [["name", "namePrefix"]],
+		"requiredWith": {"username": ["password"]}
+	}}`, string(spec.Resources["test:index/widget:Widget"].Language["tfbridge"]))
+	assert.JSONEq(t, `{"inputConstraints": {
+		"exactlyOneOf": [["sourceFile", "sourceUrl"]],
+		"atLeastOneOf": [["name", "namePrefix"]],
+		"requiredWith": {"username": ["password"]}
+	}}`, string(spec.Functions["test:index/getWidget:getWidget"].Language["tfbridge"]))
+
+	// Resources without constraint groups are left alone.
+	assert.Nil(t, gatherInputConstraints(shimv2.NewResource(&schemav2.Resource{Schema: map[string]*schemav2.Schema{
+		"name": {Type: schemav2.TypeString, Optional: true},
+	}}).Schema(), nil))
+
+	// So are schemas that do not know their constraint groups.
+	opaque := func(sch *schema.Schema) shim.Schema {
+		return struct{ shim.Schema }{sch.Shim()}
+	}
+	assert.Nil(t, gatherInputConstraints(schema.SchemaMap{
+		"name": opaque(&schema.Schema{
+			Type: shim.TypeString, Optional: true, AtLeastOneOf: []string{"name", "name_prefix"},
+		}),
+		"name_prefix": opaque(&schema.Schema{
+			Type: shim.TypeString, Optional: true, AtLeastOneOf: []string{"name", "name_prefix"},
+		}),
+	}, nil))
+}
@@ -0,0 +1,117 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// legacyTokenMapping maps a deprecated token of the classic flat layout to the token that replaces it. The complete
+// table is emitted for codemod tools that migrate programs to the new tokens.
+type legacyTokenMapping struct {
+	Kind   string `json:"kind"` // either "resource" or "function"
+	Legacy string `json:"legacy"`
+	Token  string `json:"token"`
+}
+
+// addLegacyTokenLayout adds a deprecated copy of each resource and function that is not in the index module to the
+// given schema, under its token in the classic flat layout, so that programs written against that layout keep working
+// while they migrate. Each resource is aliased to its legacy type, so that migrating a program does not replace any
+// resources. Legacy tokens that would collide with a member of the schema are skipped and returned as warnings.
+func addLegacyTokenLayout(spec *pschema.PackageSpec) ([]legacyTokenMapping, []string) {
+	taken := map[string]bool{}
+	for tok := range spec.Resources {
+		taken[strings.ToLower(tok)] = true
+	}
+	for tok := range spec.Functions {
+		taken[strings.ToLower(tok)] = true
+	}
+
+	var mappings []legacyTokenMapping
+	var warnings []string
+	claim := func(kind, tok string) (string, bool) {
+		legacy, ok := tfbridge.LegacyFlatToken(tok)
+		if !ok {
+			return "", false
+		}
+		if taken[strings.ToLower(legacy)] {
+			warnings = append(warnings, fmt.Sprintf("not adding legacy token %s for %s %s, which collides with "+
+				"another member of the schema", legacy, kind, tok))
+			return "", false
+		}
+		taken[strings.ToLower(legacy)] = true
+		mappings = append(mappings, legacyTokenMapping{Kind: kind, Legacy: legacy, Token: tok})
+		return legacy, true
+	}
+
+	resourceTokens := make([]string, 0, len(spec.Resources))
+	for tok := range spec.Resources {
+		resourceTokens = append(resourceTokens, tok)
+	}
+	sort.Strings(resourceTokens)
+	for _, tok := range resourceTokens {
+		legacy, ok := claim("resource", tok)
+		if !ok {
+			continue
+		}
+		res := spec.Resources[tok]
+		res.Aliases = append(res.Aliases, pschema.AliasSpec{Type: &legacy})
+		spec.Resources[tok] = res
+
+		res.Aliases = nil
+		res.Description = legacyTokenDeprecation(legacy, tok)
+		res.DeprecationMessage = legacyTokenDeprecation(legacy, tok)
+		spec.Resources[legacy] = res
+	}
+
+	functionTokens := make([]string, 0, len(spec.Functions))
+	for tok := range spec.Functions {
+		functionTokens = append(functionTokens, tok)
+	}
+	sort.Strings(functionTokens)
+	for _, tok := range functionTokens {
+		legacy, ok := claim("function", tok)
+		if !ok {
+			continue
+		}
+		fun := spec.Functions[tok]
+		fun.Description = legacyTokenDeprecation(legacy, tok)
+		fun.DeprecationMessage = legacyTokenDeprecation(legacy, tok)
+		spec.Functions[legacy] = fun
+	}
+
+	if len(mappings) > 0 {
+		legacyTokens := map[string]string{}
+		for _, m := range mappings {
+			legacyTokens[m.Legacy] = m.Token
+		}
+		if spec.Language == nil {
+			spec.Language = map[string]pschema.RawMessage{}
+		}
+		spec.Language[tfbridge.LegacyTokensKey] = rawMessage(legacyTokens)
+	}
+	return mappings, warnings
+}
+
+// legacyTokenDeprecation returns the deprecation message of a member that is only kept for the classic flat layout.
+func legacyTokenDeprecation(legacy, tok string) string {
+	return fmt.Sprintf("%s has been deprecated in favor of %s", legacy, tok)
+}
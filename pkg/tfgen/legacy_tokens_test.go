@@ -0,0 +1,76 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+func TestAddLegacyTokenLayout(t *testing.T) {
+	bucket := pschema.ResourceSpec{
+		ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "A bucket."},
+		InputProperties: map[string]pschema.PropertySpec{
+			"name": {TypeSpec: pschema.TypeSpec{Type: "string"}},
+		},
+	}
+	spec := pschema.PackageSpec{
+		Name: "test",
+		Resources: map[string]pschema.ResourceSpec{
+			"test:s3/bucket:Bucket":      bucket,
+			"test:index/widget:Widget":   {},
+			"test:ec2/instance:Instance": {},
+			"test:index/instance:Instance": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "An unrelated instance."},
+			},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:s3/getBucket:getBucket": {Description: "Gets a bucket."},
+		},
+	}
+
+	mappings, warnings := addLegacyTokenLayout(&spec)
+	assert.Equal(t, []legacyTokenMapping{
+		{Kind: "resource", Legacy: "test:index/bucket:Bucket", Token: "test:s3/bucket:Bucket"},
+		{Kind: "function", Legacy: "test:index/getBucket:getBucket", Token: "test:s3/getBucket:getBucket"},
+	}, mappings)
+	assert.Equal(t, []string{"not adding legacy token test:index/instance:Instance for resource " +
+		"test:ec2/instance:Instance, which collides with another member of the schema"}, warnings)
+
+	// The canonical resource is aliased to its legacy type, and the legacy resource is deprecated.
+	legacyType := "test:index/bucket:Bucket"
+	assert.Equal(t, []pschema.AliasSpec{{Type: &legacyType}}, spec.Resources["test:s3/bucket:Bucket"].Aliases)
+	assert.Equal(t, "A bucket.", spec.Resources["test:s3/bucket:Bucket"].Description)
+	legacy := spec.Resources["test:index/bucket:Bucket"]
+	assert.Empty(t, legacy.Aliases)
+	assert.Equal(t, "test:index/bucket:Bucket has been deprecated in favor of test:s3/bucket:Bucket",
+		legacy.DeprecationMessage)
+	assert.Equal(t, bucket.InputProperties, legacy.InputProperties)
+	assert.Equal(t, "An unrelated instance.", spec.Resources["test:index/instance:Instance"].Description)
+	assert.Equal(t, "test:index/getBucket:getBucket has been deprecated in favor of test:s3/getBucket:getBucket",
+		spec.Functions["test:index/getBucket:getBucket"].DeprecationMessage)
+
+	var legacyTokens map[string]string
+	assert.NoError(t, json.Unmarshal(spec.Language[tfbridge.LegacyTokensKey], &legacyTokens))
+	assert.Equal(t, map[string]string{
+		"test:index/bucket:Bucket":       "test:s3/bucket:Bucket",
+		"test:index/getBucket:getBucket": "test:s3/getBucket:getBucket",
+	}, legacyTokens)
+}
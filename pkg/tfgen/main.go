@@ -16,14 +16,17 @@ package tfgen
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"runtime/trace"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -33,14 +36,35 @@ import (
 
 // Main executes the TFGen process for the given package pkg and provider prov.
 func Main(pkg string, version string, prov tfbridge.ProviderInfo) {
-	if err := newTFGenCmd(pkg, version, prov).Execute(); err != nil {
+	MainWithCoverageSinks(pkg, version, prov)
+}
+
+// MainWithCoverageSinks executes the TFGen process like Main, and sends the example coverage data it collects to the
+// given sinks, in addition to any that the COVERAGE_OUTPUT_DIR and COVERAGE_SINK environment variables ask for.
+func MainWithCoverageSinks(pkg string, version string, prov tfbridge.ProviderInfo, sinks ...CoverageSink) {
+	MainWithOptions(pkg, version, prov, MainOptions{CoverageSinks: sinks})
+}
+
+// MainOptions configures the TFGen process with settings that cannot be given on the command line.
+type MainOptions struct {
+	// CoverageSinks receive the example coverage data, in addition to any that the COVERAGE_OUTPUT_DIR and
+	// COVERAGE_SINK environment variables ask for.
+	CoverageSinks []CoverageSink
+	// FallbackExampleConverters are tried in order for each example that the bridge's converter fails to convert to
+	// a language. See GeneratorOptions.FallbackExampleConverters.
+	FallbackExampleConverters []ExampleConverter
+}
+
+// MainWithOptions executes the TFGen process like Main, with the given options.
+func MainWithOptions(pkg string, version string, prov tfbridge.ProviderInfo, opts MainOptions) {
+	if err := newTFGenCmd(pkg, version, prov, opts).Execute(); err != nil {
 		_, fmterr := fmt.Fprintf(os.Stderr, "An error occurred: %v\n", err)
 		contract.IgnoreError(fmterr)
 		os.Exit(-1)
 	}
 }
 
-func newTFGenCmd(pkg string, version string, prov tfbridge.ProviderInfo) *cobra.Command {
+func newTFGenCmd(pkg string, version string, prov tfbridge.ProviderInfo, opts MainOptions) *cobra.Command {
 	var logToStderr bool
 	var outDir string
 	var overlaysDir string
@@ -52,106 +76,171 @@ func newTFGenCmd(pkg string, version string, prov tfbridge.ProviderInfo) *cobra.
 	var debug bool
 	var skipDocs bool
 	var skipExamples bool
-	cmd := &cobra.Command{
-		Use:   os.Args[0] + " <LANGUAGE>",
-		Args:  cmdutil.SpecificArgs([]string{"language"}),
-		Short: "The Pulumi TFGen compiler generates Pulumi package metadata from a Terraform provider",
-		Long: "The Pulumi TFGen compiler generates Pulumi package metadata from a Terraform provider.\n" +
-			"\n" +
-			"The tool will load the provider from your $PATH, inspect its contents dynamically,\n" +
-			"and generate all of the Pulumi metadata necessary to consume the resources.\n" +
-			"\n" +
-			"<LANGUAGE> indicates which language/runtime to target; the current supported set of\n" +
-			"languages is " + fmt.Sprintf("%v", AllLanguages) + ".\n" +
-			"\n" +
-			"Note that there is no custom Pulumi provider code required, because the generated\n" +
-			"provider plugin is metadata-driven and thus works against all Terraform providers.\n",
-		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
-			if profile != "" {
-				f, err := os.Create(profile)
-				if err != nil {
-					return err
-				}
-				if err = pprof.StartCPUProfile(f); err != nil {
-					return err
-				}
-				defer pprof.StopCPUProfile()
-			}
+	var exampleTimeout time.Duration
+	var exampleCacheDir string
+	var legacyTokens bool
+	var pruneSchemaFields bool
+	var includeEphemeral bool
+	var maxSchemaBytes int
+	var moduleSchemaBudgets map[string]int
+	var previousSchemaPath string
+	var excludeExperimental bool
+	var inferredTokensPath string
+	var acceptInferredTokens bool
+	var exampleBundlesDir string
+	var upstreamDir string
+	var patchesDir string
+	var progressFormat string
 
-			if heapProfile != "" {
-				defer func() {
-					f, err := os.Create(heapProfile)
-					if err != nil {
-						log.Printf("could not write heap profile: %v", err)
-						return
-					}
-					runtime.GC() // get up-to-date statistics
-					if err := pprof.WriteHeapProfile(f); err != nil {
-						log.Printf("could not write heap profile: %v", err)
-					}
-				}()
-			}
+	// runWith creates a generator for the given language with the command's settings, runs generate with it, and
+	// exports the collected coverage data to the given sinks and to any that are configured.
+	runWith := func(lang Language, generate func(g *Generator) error, extraSinks ...CoverageSink) error {
+		if err := validateProgressFormat(progressFormat); err != nil {
+			return err
+		}
 
-			if tracePath != "" {
-				f, err := os.Create(tracePath)
-				if err != nil {
-					return err
-				}
-				if err = trace.Start(f); err != nil {
-					return err
-				}
-				defer trace.Stop()
+		if profile != "" {
+			f, err := os.Create(profile)
+			if err != nil {
+				return err
+			}
+			if err = pprof.StartCPUProfile(f); err != nil {
+				return err
 			}
+			defer pprof.StopCPUProfile()
+		}
 
-			// Create the output directory.
-			var root afero.Fs
-			if outDir != "" {
-				absOutDir, err := filepath.Abs(outDir)
+		if heapProfile != "" {
+			defer func() {
+				f, err := os.Create(heapProfile)
 				if err != nil {
-					return err
+					log.Printf("could not write heap profile: %v", err)
+					return
 				}
-				if err = os.MkdirAll(absOutDir, 0700); err != nil {
-					return err
+				runtime.GC() // get up-to-date statistics
+				if err := pprof.WriteHeapProfile(f); err != nil {
+					log.Printf("could not write heap profile: %v", err)
 				}
-				root = afero.NewBasePathFs(afero.NewOsFs(), absOutDir)
-			}
+			}()
+		}
 
-			// Creating an item to keep track of example coverage if the
-			// COVERAGE_OUTPUT_DIR env is set
-			var coverageTracker *CoverageTracker
-			coverageOutputDir, coverageTrackingEnabled := os.LookupEnv("COVERAGE_OUTPUT_DIR")
-			if coverageTrackingEnabled {
-				coverageTracker = newCoverageTracker(prov.Name, prov.Version)
+		if tracePath != "" {
+			f, err := os.Create(tracePath)
+			if err != nil {
+				return err
 			}
+			if err = trace.Start(f); err != nil {
+				return err
+			}
+			defer trace.Stop()
+		}
+		var progress io.Writer
+		if progressFormat == ProgressFormatJSON {
+			progress = os.Stderr
+		}
 
-			// Create a generator with the specified settings.
-			g, err := NewGenerator(GeneratorOptions{
-				Package:         pkg,
-				Version:         version,
-				Language:        Language(args[0]),
-				ProviderInfo:    prov,
-				Root:            root,
-				Debug:           debug,
-				SkipDocs:        skipDocs,
-				SkipExamples:    skipExamples,
-				CoverageTracker: coverageTracker,
-			})
-			if err != nil {
+		// Refuse to generate from upstream sources that are missing any of the provider's patches.
+		if patchesDir != "" {
+			if err := requirePatchesApplied(upstreamDir, patchesDir); err != nil {
 				return err
 			}
+		}
 
-			// Let's generate some code!
-			err = g.Generate()
+		// Create the output directory.
+		var root afero.Fs
+		if outDir != "" {
+			absOutDir, err := filepath.Abs(outDir)
 			if err != nil {
 				return err
 			}
-
-			// Exporting collected coverage data to the directory specified by COVERAGE_OUTPUT_DIR
-			if coverageTrackingEnabled {
-				err = coverageTracker.exportResults(coverageOutputDir)
+			if err = os.MkdirAll(absOutDir, 0700); err != nil {
+				return err
 			}
+			root = afero.NewBasePathFs(afero.NewOsFs(), absOutDir)
+		}
+
+		// Creating an item to keep track of example coverage if any coverage sinks were
+		// given, or the COVERAGE_OUTPUT_DIR or COVERAGE_SINK envs are set
+		var coverageTracker *CoverageTracker
+		coverageSinks, err := coverageSinksFromEnv()
+		if err != nil {
+			return err
+		}
+		coverageSinks = append(append(coverageSinks, opts.CoverageSinks...), extraSinks...)
+		coverageTrackingEnabled := len(coverageSinks) > 0
+		if coverageTrackingEnabled {
+			coverageTracker = newCoverageTracker(prov.Name, prov.Version)
+		}
+
+		// Create a generator with the specified settings.
+		g, err := NewGenerator(GeneratorOptions{
+			Package:                   pkg,
+			Version:                   version,
+			Language:                  lang,
+			ProviderInfo:              prov,
+			Root:                      root,
+			Debug:                     debug,
+			SkipDocs:                  skipDocs,
+			SkipExamples:              skipExamples,
+			CoverageTracker:           coverageTracker,
+			ExampleTimeout:            exampleTimeout,
+			ExampleCacheDir:           exampleCacheDir,
+			LegacyTokens:              legacyTokens,
+			MaxSchemaBytes:            maxSchemaBytes,
+			ModuleSchemaBudgets:       moduleSchemaBudgets,
+			PreviousSchemaPath:        previousSchemaPath,
+			ExcludeExperimental:       excludeExperimental,
+			InferredTokensPath:        inferredTokensPath,
+			AcceptInferredTokens:      acceptInferredTokens,
+			ExampleBundlesDir:         exampleBundlesDir,
+			PruneSchema:               pruneSchemaFields,
+			IncludeEphemeralResources: includeEphemeral,
+			FallbackExampleConverters: opts.FallbackExampleConverters,
+			Progress:                  progress,
+		})
+		if err != nil {
+			return err
+		}
 
+		// Let's generate some code!
+		err = generate(g)
+		if err != nil {
 			return err
+		}
+
+		// Exporting collected coverage data to the coverage sinks
+		if coverageTrackingEnabled {
+			err = coverageTracker.exportResults(multiCoverageSink(coverageSinks))
+		}
+
+		return err
+	}
+
+	// run is runWith for the commands that export coverage data only to the configured sinks.
+	run := func(lang Language, generate func(g *Generator) error) error {
+		return runWith(lang, generate)
+	}
+
+	cmd := &cobra.Command{
+		Use:   os.Args[0] + " <LANGUAGE>",
+		Args:  cmdutil.SpecificArgs([]string{"language"}),
+		Short: "The Pulumi TFGen compiler generates Pulumi package metadata from a Terraform provider",
+		Long: "The Pulumi TFGen compiler generates Pulumi package metadata from a Terraform provider.\n" +
+			"\n" +
+			"The tool will load the provider from your $PATH, inspect its contents dynamically,\n" +
+			"and generate all of the Pulumi metadata necessary to consume the resources.\n" +
+			"\n" +
+			"<LANGUAGE> indicates which language/runtime to target; the current supported set of\n" +
+			"languages is " + fmt.Sprintf("%v", AllLanguages) + ".\n" +
+			"\n" +
+			"Note that there is no custom Pulumi provider code required, because the generated\n" +
+			"provider plugin is metadata-driven and thus works against all Terraform providers.\n" +
+			"\n" +
+			"The schema, sdk, docs, coverage and lint subcommands run the individual steps of a\n" +
+			"provider build. With --progress=json, each step reports its progress to stderr as\n" +
+			"a JSON object per line.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			return run(Language(args[0]), (*Generator).Generate)
 		}),
 		PersistentPostRun: func(cmd *cobra.Command, args []string) {
 			glog.Flush()
@@ -160,30 +249,152 @@ func newTFGenCmd(pkg string, version string, prov tfbridge.ProviderInfo) *cobra.
 
 	cmd.PersistentFlags().BoolVar(
 		&logToStderr, "logtostderr", false, "Log to stderr instead of to files")
-	cmd.PersistentFlags().StringVarP(
-		&outDir, "out", "o", "", "Emit the generated SDK to this directory")
 	cmd.PersistentFlags().BoolVarP(
 		&quiet, "quiet", "q", false, "Suppress non-error output progress messages")
 	cmd.PersistentFlags().IntVarP(
 		&verbose, "verbose", "v", 0, "Enable verbose logging (e.g., v=3); anything >3 is very verbose")
-	cmd.PersistentFlags().StringVar(
-		&profile, "profile", "", "Write a CPU profile to this file")
-	cmd.PersistentFlags().StringVar(
-		&heapProfile, "heap-profile", "", "Write a heap profile to this file")
-	cmd.PersistentFlags().StringVar(
-		&tracePath, "trace", "", "Write a Go runtime trace to this file")
-	cmd.PersistentFlags().BoolVarP(
-		&debug, "debug", "d", false, "Enable debug logging")
-	cmd.PersistentFlags().BoolVar(
-		&skipDocs, "skip-docs", false, "Do not convert docs from TF Markdown")
-	cmd.PersistentFlags().BoolVar(
-		&skipExamples, "skip-examples", false, "Do not convert examples from HCL")
-
 	cmd.PersistentFlags().StringVar(
 		&overlaysDir, "overlays", "",
 		"Use the target directory for overlays rather than the default of overlays/ (unsupported)")
 	err := cmd.PersistentFlags().MarkHidden("overlays")
 	contract.AssertNoError(err)
 
+	// addGenerateFlags adds the flags that configure generation to the commands that generate.
+	addGenerateFlags := func(c *cobra.Command) *cobra.Command {
+		flags := c.Flags()
+		flags.StringVarP(
+			&outDir, "out", "o", "", "Emit the generated SDK to this directory")
+		flags.StringVar(
+			&profile, "profile", "", "Write a CPU profile to this file")
+		flags.StringVar(
+			&heapProfile, "heap-profile", "", "Write a heap profile to this file")
+		flags.StringVar(
+			&tracePath, "trace", "", "Write a Go runtime trace to this file")
+		flags.BoolVarP(
+			&debug, "debug", "d", false, "Enable debug logging")
+		flags.BoolVar(
+			&skipDocs, "skip-docs", false, "Do not convert docs from TF Markdown")
+		flags.BoolVar(
+			&skipExamples, "skip-examples", false, "Do not convert examples from HCL")
+		flags.DurationVar(
+			&exampleTimeout, "example-timeout", 0,
+			"Give up converting an example to a language after this long (e.g., 30s); 0 means no limit")
+		flags.StringVar(
+			&exampleCacheDir, "example-cache-dir", os.Getenv(exampleCacheDirEnvVar),
+			"Cache converted examples in this directory, which may be shared by several providers")
+		flags.StringVar(
+			&exampleBundlesDir, "example-bundles-dir", "",
+			"Export each resource's and function's examples to this directory, with the original HCL and every "+
+				"converted language side by side")
+		flags.BoolVar(
+			&legacyTokens, "legacy-tokens", false,
+			"Also emit deprecated aliases of namespaced resources and functions under their flat index tokens, "+
+				"and a legacyTokens.json table mapping them to their new tokens")
+		flags.BoolVar(
+			&pruneSchemaFields, "prune-schema", false,
+			"Remove fields from schema.json that are equivalent to leaving them out, such as empty language settings, "+
+				"to make the schema smaller")
+		flags.BoolVar(
+			&includeEphemeral, "include-ephemeral-resources", false,
+			"Expose the upstream ephemeral resources that the provider maps as functions, documented with their TTL and "+
+				"a warning that their values are not persisted by Terraform (experimental)")
+		flags.IntVar(
+			&maxSchemaBytes, "max-schema-bytes", 0,
+			"Fail if the generated schema.json is larger than this many bytes; 0 means no limit")
+		flags.StringToIntVar(
+			&moduleSchemaBudgets, "module-schema-budget", nil,
+			"Fail if a module takes up more than the given number of bytes of the schema (e.g., ec2=5000000)")
+		flags.StringVar(
+			&previousSchemaPath, "baseline-schema", "",
+			"The schema.json of the previous release, to report the modules that grew the most since then if the "+
+				"schema exceeds its budget")
+		flags.BoolVar(
+			&excludeExperimental, "exclude-experimental", false,
+			"Leave the resources and data sources that are experimental or in beta upstream out of the schema")
+		flags.StringVar(
+			&inferredTokensPath, "inferred-tokens", "",
+			"A JSON file that records the tokens inferred from the provider's module prefixes, to keep them stable")
+		flags.BoolVar(
+			&acceptInferredTokens, "accept-inferred-tokens", false,
+			"Map the resources and data sources whose tokens are inferred for the first time, and record them in the "+
+				"--inferred-tokens file")
+		flags.StringVar(
+			&patchesDir, "patches-dir", "",
+			"Fail unless every patch in this directory is applied to the --upstream-dir checkout")
+		flags.StringVar(
+			&upstreamDir, "upstream-dir", "upstream", "The checkout of the upstream provider that --patches-dir applies to")
+		flags.StringVar(
+			&progressFormat, "progress", "",
+			"Report the progress of each stage of generation to stderr in the given format; the supported format is "+
+				"\"json\", which writes an event per line with the stage, the completed and total counts, and an ETA")
+		return c
+	}
+	addGenerateFlags(cmd)
+
+	cmd.AddCommand(addGenerateFlags(newSchemaCmd(run)))
+	cmd.AddCommand(addGenerateFlags(newSDKCmd(run)))
+	cmd.AddCommand(addGenerateFlags(newCoverageCmd(runWith)))
+	cmd.AddCommand(newChangelogCmd(prov))
+	cmd.AddCommand(newConvertStateCmd(prov))
+	cmd.AddCommand(addGenerateFlags(newDebugDocsCmd(run)))
+	cmd.AddCommand(addGenerateFlags(newDocsCmd(run)))
+	cmd.AddCommand(newDryRunMappingsCmd(prov))
+	cmd.AddCommand(newLintMappingsCmd(prov))
+	cmd.AddCommand(newPatchesCmd())
+	cmd.AddCommand(newScaffoldTestsCmd(prov))
+	cmd.AddCommand(newUpstreamChangelogCmd(prov))
+
 	return cmd
 }
+
+func newSchemaCmd(run func(lang Language, generate func(g *Generator) error) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Args:  cmdutil.NoArgs,
+		Short: "Generate the Pulumi schema, with its docs and examples",
+		Long: "Generate the Pulumi schema, with its docs and examples.\n" +
+			"\n" +
+			"The schema.json and its supporting files are written to the --out directory. This is the\n" +
+			"same as running the command with the schema language.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			return run(Schema, (*Generator).Generate)
+		}),
+	}
+}
+
+func newSDKCmd(run func(lang Language, generate func(g *Generator) error) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sdk <LANGUAGE>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Generate the SDK for a language",
+		Long: "Generate the SDK for a language.\n" +
+			"\n" +
+			"<LANGUAGE> is one of " + fmt.Sprintf("%v", AllLanguages) + ". The SDK is written to the --out\n" +
+			"directory. This is the same as running the command with the language.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			lang := Language(args[0])
+			if lang == Schema {
+				return errors.Errorf("use the schema subcommand to generate the schema")
+			}
+			return run(lang, (*Generator).Generate)
+		}),
+	}
+}
+
+func newCoverageCmd(
+	runWith func(lang Language, generate func(g *Generator) error, sinks ...CoverageSink) error) *cobra.Command {
+
+	return &cobra.Command{
+		Use:   "coverage <DIR>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Generate the schema and write the example coverage reports to a directory",
+		Long: "Generate the schema and write the example coverage reports to a directory.\n" +
+			"\n" +
+			"The examples of every resource and function are converted as they are for the schema, and\n" +
+			"the coverage reports, such as summary.json and byExample.json, are written to <DIR>. The\n" +
+			"schema is written to the --out directory as usual.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			return runWith(Schema, (*Generator).Generate, NewFileCoverageSink(args[0]))
+		}),
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// The rules checked by the mapping lint.
+const (
+	lintDuplicateToken = "duplicate-token" // several Terraform names map to tokens that are equal, or equal but for case
+	lintTokenCasing    = "token-casing"    // a token does not follow Pulumi's casing conventions
+	lintModuleCase     = "module-case"     // several modules differ only in case
+	lintUnknownModule  = "unknown-module"  // a token uses a module that the provider does not declare
+)
+
+// maxModuleTypoDistance is the largest edit distance between an undeclared module and a declared one at which the
+// declared one is suggested in its place.
+const maxModuleTypoDistance = 2
+
+// mappingLintIssue is a single problem found in the token mappings of a provider.
+type mappingLintIssue struct {
+	Rule    string
+	Kind    string   `json:"Kind,omitempty"` // either "resource" or "dataSource", if the issue is about members of one kind
+	TFNames []string `json:"TFNames,omitempty"`
+	Token   string   `json:"Token,omitempty"`
+	Message string
+}
+
+// mappingLintReport lists the problems found in the token mappings of a provider.
+type mappingLintReport struct {
+	Issues []mappingLintIssue
+}
+
+// lintMappings checks the tokens that the given provider maps its resources and data sources to for mistakes that
+// would otherwise ship, and could only be fixed by a breaking change: duplicate tokens, tokens that do not follow
+// Pulumi's casing conventions, modules that differ only in case and, if the provider declares its modules with
+// ModulePrefixes, tokens in modules that it does not declare.
+func lintMappings(prov tfbridge.ProviderInfo) *mappingLintReport {
+	report := &mappingLintReport{}
+	mappings := computeTokenMappings(prov)
+
+	for _, c := range mappings.Collisions {
+		report.Issues = append(report.Issues, mappingLintIssue{
+			Rule:    lintDuplicateToken,
+			Kind:    c.Kind,
+			TFNames: c.TFNames,
+			Token:   c.Token,
+			Message: c.Reason,
+		})
+	}
+
+	members := map[string][]string{} // the Terraform names of the members of each module
+	for _, m := range mappings.Mappings {
+		if m.Status != mappingMapped {
+			continue
+		}
+		members[m.Module] = append(members[m.Module], m.TFName)
+		if msg := tokenCasingProblem(m.Kind, m.Token); msg != "" {
+			report.Issues = append(report.Issues, mappingLintIssue{
+				Rule:    lintTokenCasing,
+				Kind:    m.Kind,
+				TFNames: []string{m.TFName},
+				Token:   m.Token,
+				Message: msg,
+			})
+		}
+	}
+
+	modules := sortedStrings(members)
+	for mod := range prov.ModulePrefixes {
+		if _, ok := members[mod]; !ok {
+			modules = append(modules, mod)
+		}
+	}
+	sort.Strings(modules)
+	byFolded := map[string][]string{}
+	for _, mod := range modules {
+		byFolded[strings.ToLower(mod)] = append(byFolded[strings.ToLower(mod)], mod)
+	}
+	for _, folded := range sortedStrings(byFolded) {
+		if variants := byFolded[folded]; len(variants) > 1 {
+			report.Issues = append(report.Issues, mappingLintIssue{
+				Rule:    lintModuleCase,
+				Message: fmt.Sprintf("modules %s differ only in case", strings.Join(variants, ", ")),
+			})
+		}
+	}
+
+	// Modules can only be checked against the modules that the provider declares, if it declares any.
+	if len(prov.ModulePrefixes) == 0 {
+		return report
+	}
+	declared := map[string][]string{"index": nil}
+	for mod, prefixes := range prov.ModulePrefixes {
+		declared[mod] = prefixes
+	}
+	for _, mod := range sortedStrings(members) {
+		if _, ok := declared[mod]; ok {
+			continue
+		}
+		tfNames := members[mod]
+		sort.Strings(tfNames)
+		msg := fmt.Sprintf("module %s is not declared in ModulePrefixes", mod)
+		if suggestion := closestModule(mod, declared); suggestion != "" {
+			msg += fmt.Sprintf("; did you mean %s?", suggestion)
+		}
+		report.Issues = append(report.Issues, mappingLintIssue{
+			Rule:    lintUnknownModule,
+			TFNames: tfNames,
+			Message: msg,
+		})
+	}
+
+	return report
+}
+
+// tokenCasingProblem describes how the given token of a resource or data source breaks Pulumi's casing conventions,
+// e.g. `pkg:module/widget:Widget` for resources and `pkg:module/getWidget:getWidget` for functions, or returns "" if it
+// follows them.
+func tokenCasingProblem(kind, token string) string {
+	components := strings.Split(token, ":")
+	if len(components) != 3 || components[0] == "" || components[1] == "" || components[2] == "" {
+		return "token is not of the form <package>:<module>:<name>"
+	}
+	module, member, name := components[1], "", components[2]
+	if i := strings.Index(module, "/"); i != -1 {
+		module, member = module[:i], module[i+1:]
+	}
+
+	if strings.ContainsAny(components[1]+components[2], "_- ") {
+		return "module or name contains underscores, dashes or spaces"
+	}
+	if r, _ := utf8.DecodeRuneInString(module); unicode.IsUpper(r) {
+		return fmt.Sprintf("module %s should start with a lowercase letter", module)
+	}
+
+	r, size := utf8.DecodeRuneInString(name)
+	expectedMember := string(unicode.ToLower(r)) + name[size:]
+	if kind == "resource" && !unicode.IsUpper(r) {
+		return fmt.Sprintf("resource name %s should start with an uppercase letter", name)
+	}
+	if kind != "resource" && !unicode.IsLower(r) {
+		return fmt.Sprintf("function name %s should start with a lowercase letter", name)
+	}
+	if member != "" && member != expectedMember {
+		return fmt.Sprintf("member %s should be %s to match the name %s", member, expectedMember, name)
+	}
+	return ""
+}
+
+// closestModule returns the declared module that the given module is most likely a typo of, or "" if none is close.
+func closestModule(mod string, declared map[string][]string) string {
+	closest, closestDistance := "", maxModuleTypoDistance+1
+	for _, other := range sortedStrings(declared) {
+		if d := editDistance(strings.ToLower(mod), strings.ToLower(other)); d < closestDistance {
+			closest, closestDistance = other, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = cur[j-1] + 1
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// writeMappingLintReport renders the given report as one line per issue.
+func writeMappingLintReport(w io.Writer, report *mappingLintReport) error {
+	for _, issue := range report.Issues {
+		subject := ""
+		if issue.Kind != "" {
+			subject = fmt.Sprintf(" %s %s", issue.Kind, strings.Join(issue.TFNames, ", "))
+		}
+		if issue.Token != "" {
+			subject += fmt.Sprintf(" (%s)", issue.Token)
+		}
+		if _, err := fmt.Fprintf(w, "%s:%s %s\n", issue.Rule, subject, issue.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLintMappingsCmd creates the `lint-mappings` subcommand, which checks the provider's token mappings for mistakes
+// that would require breaking changes to fix once released.
+func newLintMappingsCmd(prov tfbridge.ProviderInfo) *cobra.Command {
+	var jsonOutput, strict bool
+	cmd := &cobra.Command{
+		Use:   "lint-mappings",
+		Args:  cmdutil.NoArgs,
+		Short: "Check the Terraform name to Pulumi token mapping for mistakes",
+		Long: "Check the Terraform name to Pulumi token mapping for mistakes.\n" +
+			"\n" +
+			"Reports tokens that more than one Terraform name maps to, tokens that do not follow\n" +
+			"Pulumi's casing conventions, modules that differ only in case, and, if the provider\n" +
+			"declares its modules with ModulePrefixes, tokens in modules that it does not declare.\n" +
+			"These mistakes can only be fixed by breaking changes once released. With --strict,\n" +
+			"the command exits with an error if it finds any.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			report := lintMappings(prov)
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else if err := writeMappingLintReport(os.Stdout, report); err != nil {
+				return err
+			}
+
+			if strict && len(report.Issues) != 0 {
+				return errors.Errorf("found %d mapping issue(s)", len(report.Issues))
+			}
+			return nil
+		}),
+	}
+	// `lint` is the name of the step in the unified build commands.
+	cmd.Aliases = []string{"lint"}
+
+	cmd.PersistentFlags().BoolVar(
+		&jsonOutput, "json", false, "Emit the issues as JSON rather than as text")
+	cmd.PersistentFlags().BoolVar(
+		&strict, "strict", false, "Exit with an error if any issues are found")
+
+	return cmd
+}
@@ -0,0 +1,132 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestLintMappings(t *testing.T) {
+	prov := tfbridge.ProviderInfo{
+		P: shimv2.NewProvider(&schema.Provider{
+			ResourcesMap: map[string]*schema.Resource{
+				"test_widget":    {},
+				"test_widget_v2": {},
+				"test_gadget":    {},
+				"test_gizmo":     {},
+				"test_sprocket":  {},
+				"test_cog":       {},
+				"test_bolt":      {},
+				"test_nut":       {},
+			},
+			DataSourcesMap: map[string]*schema.Resource{
+				"test_widget": {},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget":    {Tok: "test:index/widget:Widget"},
+			"test_widget_v2": {Tok: "test:index/widget:Widget"},
+			"test_gadget":    {Tok: "test:devices/gadget:Gadget"},
+			"test_gizmo":     {Tok: "test:devices/gizmo:Gizmo"},
+			"test_sprocket":  {Tok: "test:Devices/sprocket:Sprocket"},
+			"test_cog":       {Tok: "test:devicse/cog:Cog"},
+			"test_bolt":      {Tok: "test:devices/bolt:bolt_thing"},
+			"test_nut":       {Tok: "test:hardware/nut:Nut"},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget"},
+		},
+		ModulePrefixes: map[string][]string{"devices": nil},
+	}
+
+	report := lintMappings(prov)
+	assert.Equal(t, []mappingLintIssue{
+		{
+			Rule:    lintDuplicateToken,
+			Kind:    "resource",
+			TFNames: []string{"test_widget", "test_widget_v2"},
+			Token:   "test:index/widget:Widget",
+			Message: "multiple Terraform names map to the same token",
+		},
+		{
+			Rule:    lintTokenCasing,
+			Kind:    "resource",
+			TFNames: []string{"test_bolt"},
+			Token:   "test:devices/bolt:bolt_thing",
+			Message: "module or name contains underscores, dashes or spaces",
+		},
+		{
+			Rule:    lintTokenCasing,
+			Kind:    "resource",
+			TFNames: []string{"test_sprocket"},
+			Token:   "test:Devices/sprocket:Sprocket",
+			Message: "module Devices should start with a lowercase letter",
+		},
+		{
+			Rule:    lintTokenCasing,
+			Kind:    "dataSource",
+			TFNames: []string{"test_widget"},
+			Token:   "test:index/widget:Widget",
+			Message: "function name Widget should start with a lowercase letter",
+		},
+		{
+			Rule:    lintModuleCase,
+			Message: "modules Devices, devices differ only in case",
+		},
+		{
+			Rule:    lintUnknownModule,
+			TFNames: []string{"test_sprocket"},
+			Message: "module Devices is not declared in ModulePrefixes; did you mean devices?",
+		},
+		{
+			Rule:    lintUnknownModule,
+			TFNames: []string{"test_cog"},
+			Message: "module devicse is not declared in ModulePrefixes; did you mean devices?",
+		},
+		{
+			Rule:    lintUnknownModule,
+			TFNames: []string{"test_nut"},
+			Message: "module hardware is not declared in ModulePrefixes",
+		},
+	}, report.Issues)
+
+	// Modules are not checked if the provider does not declare them.
+	prov.ModulePrefixes = nil
+	for _, issue := range lintMappings(prov).Issues {
+		assert.NotEqual(t, lintUnknownModule, issue.Rule)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeMappingLintReport(&buf, report))
+	assert.Contains(t, buf.String(),
+		"token-casing: resource test_sprocket (test:Devices/sprocket:Sprocket) module Devices should start with a "+
+			"lowercase letter\n")
+}
+
+func TestTokenCasingProblem(t *testing.T) {
+	assert.Equal(t, "", tokenCasingProblem("resource", "test:index/widget:Widget"))
+	assert.Equal(t, "", tokenCasingProblem("resource", "test:index:Widget"))
+	assert.Equal(t, "", tokenCasingProblem("dataSource", "test:ec2/getWidget:getWidget"))
+	assert.Equal(t, "member gadget should be widget to match the name Widget",
+		tokenCasingProblem("resource", "test:index/gadget:Widget"))
+	assert.Equal(t, "token is not of the form <package>:<module>:<name>", tokenCasingProblem("resource", "Widget"))
+}
@@ -0,0 +1,250 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// The status of a single entry in the token mapping table.
+const (
+	mappingMapped   = "mapped"   // the Terraform name is mapped to a Pulumi token
+	mappingUnmapped = "unmapped" // the Terraform name has no entry in the provider info, and will be skipped
+	mappingNoToken  = "no-token" // the Terraform name has an entry in the provider info, but no token
+	mappingMissing  = "missing"  // the provider info has an entry that does not exist in the Terraform provider
+)
+
+// tokenMapping describes the Pulumi token that a single Terraform resource or data source is mapped to.
+type tokenMapping struct {
+	Kind   string // either "resource" or "dataSource"
+	TFName string
+	Token  string `json:"Token,omitempty"`
+	Module string `json:"Module,omitempty"`
+	Status string
+}
+
+// tokenCollision describes a Pulumi token that more than one Terraform name is mapped to.
+type tokenCollision struct {
+	Kind    string
+	Token   string
+	TFNames []string
+	Reason  string
+}
+
+// tokenMappings is the complete Terraform name to Pulumi token table for a provider.
+type tokenMappings struct {
+	Mappings   []tokenMapping
+	Collisions []tokenCollision `json:"Collisions,omitempty"`
+}
+
+// computeTokenMappings resolves the Pulumi token for every Terraform resource and data source in the given provider
+// and detects any tokens that collide.
+func computeTokenMappings(prov tfbridge.ProviderInfo) *tokenMappings {
+	result := &tokenMappings{}
+
+	var resources, dataSources shim.ResourceMap
+	if prov.P != nil {
+		resources, dataSources = prov.P.ResourcesMap(), prov.P.DataSourcesMap()
+	}
+
+	resourceTokens := map[string]string{}
+	for name, info := range prov.Resources {
+		if info != nil {
+			resourceTokens[name] = string(info.Tok)
+		}
+	}
+	result.add("resource", resources, resourceTokens)
+
+	dataSourceTokens := map[string]string{}
+	for name, info := range prov.DataSources {
+		if info != nil {
+			dataSourceTokens[name] = string(info.Tok)
+		}
+	}
+	result.add("dataSource", dataSources, dataSourceTokens)
+
+	return result
+}
+
+// add appends the mappings and collisions for a single kind of Terraform entity.
+func (m *tokenMappings) add(kind string, tfMap shim.ResourceMap, tokens map[string]string) {
+	names, inTF := map[string]bool{}, map[string]bool{}
+	if tfMap != nil {
+		tfMap.Range(func(name string, _ shim.Resource) bool {
+			names[name], inTF[name] = true, true
+			return true
+		})
+	}
+	for name := range tokens {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	byToken := map[string][]string{}
+	byFoldedToken := map[string][]string{}
+	for _, name := range sorted {
+		mapping := tokenMapping{Kind: kind, TFName: name}
+
+		tok, hasInfo := tokens[name]
+		switch {
+		case !hasInfo:
+			mapping.Status = mappingUnmapped
+		case !inTF[name]:
+			mapping.Status, mapping.Token = mappingMissing, tok
+		case tok == "":
+			mapping.Status = mappingNoToken
+		default:
+			mapping.Status, mapping.Token = mappingMapped, tok
+		}
+		if mapping.Token != "" {
+			mapping.Module = tokenModule(mapping.Token)
+		}
+		m.Mappings = append(m.Mappings, mapping)
+
+		if mapping.Status == mappingMapped {
+			byToken[tok] = append(byToken[tok], name)
+			folded := strings.ToLower(tok)
+			byFoldedToken[folded] = append(byFoldedToken[folded], name)
+		}
+	}
+
+	for _, tok := range sortedStrings(byToken) {
+		if names := byToken[tok]; len(names) > 1 {
+			m.Collisions = append(m.Collisions, tokenCollision{
+				Kind:    kind,
+				Token:   tok,
+				TFNames: names,
+				Reason:  "multiple Terraform names map to the same token",
+			})
+		}
+	}
+	for _, folded := range sortedStrings(byFoldedToken) {
+		names := byFoldedToken[folded]
+		if len(names) < 2 {
+			continue
+		}
+		// Skip groups that were already reported as exact duplicates.
+		distinct := map[string]bool{}
+		for _, name := range names {
+			distinct[tokens[name]] = true
+		}
+		if len(distinct) < 2 {
+			continue
+		}
+		m.Collisions = append(m.Collisions, tokenCollision{
+			Kind:    kind,
+			Token:   folded,
+			TFNames: names,
+			Reason:  "tokens differ only in case, which collide on case-insensitive file systems",
+		})
+	}
+}
+
+// sortedStrings returns the keys of the given map in sorted order.
+func sortedStrings(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeTokenMappings renders the given mappings as a table.
+func writeTokenMappings(w io.Writer, mappings *tokenMappings) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "KIND\tTERRAFORM NAME\tPULUMI TOKEN\tMODULE\tSTATUS"); err != nil {
+		return err
+	}
+	for _, m := range mappings.Mappings {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.Kind, m.TFName, m.Token, m.Module,
+			m.Status); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, c := range mappings.Collisions {
+		if _, err := fmt.Fprintf(w, "\ncollision: %s token %s: %s (%s)", c.Kind, c.Token,
+			strings.Join(c.TFNames, ", "), c.Reason); err != nil {
+			return err
+		}
+	}
+	if len(mappings.Collisions) != 0 {
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newDryRunMappingsCmd creates the `dry-run-mappings` subcommand, which prints the complete Terraform name to Pulumi
+// token table for the provider without generating a schema or docs.
+func newDryRunMappingsCmd(prov tfbridge.ProviderInfo) *cobra.Command {
+	var jsonOutput bool
+	cmd := &cobra.Command{
+		Use:   "dry-run-mappings",
+		Args:  cmdutil.NoArgs,
+		Short: "Print the Terraform name to Pulumi token mapping without generating any code",
+		Long: "Print the Terraform name to Pulumi token mapping without generating any code.\n" +
+			"\n" +
+			"Every resource and data source in the Terraform provider is listed along with the Pulumi\n" +
+			"token it is mapped to. Tokens that more than one Terraform name map to are reported as\n" +
+			"collisions, in which case the command exits with an error.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			mappings := computeTokenMappings(prov)
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				if err := enc.Encode(mappings); err != nil {
+					return err
+				}
+			} else if err := writeTokenMappings(os.Stdout, mappings); err != nil {
+				return err
+			}
+
+			if len(mappings.Collisions) != 0 {
+				return errors.Errorf("found %d token collision(s)", len(mappings.Collisions))
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVar(
+		&jsonOutput, "json", false, "Emit the mapping as JSON rather than as a table")
+
+	return cmd
+}
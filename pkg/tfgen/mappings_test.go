@@ -0,0 +1,88 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestComputeTokenMappings(t *testing.T) {
+	prov := tfbridge.ProviderInfo{
+		P: shimv2.NewProvider(&schema.Provider{
+			ResourcesMap: map[string]*schema.Resource{
+				"test_widget":     {},
+				"test_widget_v2":  {},
+				"test_gadget":     {},
+				"test_unmapped":   {},
+				"test_other_case": {},
+			},
+			DataSourcesMap: map[string]*schema.Resource{
+				"test_widget": {},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget":     {Tok: "test:index/widget:Widget"},
+			"test_widget_v2":  {Tok: "test:index/widget:Widget"},
+			"test_gadget":     {Tok: "test:devices/gadget:Gadget"},
+			"test_other_case": {Tok: "test:devices/Gadget:gadget"},
+			"test_removed":    {Tok: "test:index/removed:Removed"},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {},
+		},
+	}
+
+	mappings := computeTokenMappings(prov)
+	assert.Equal(t, []tokenMapping{
+		{Kind: "resource", TFName: "test_gadget", Token: "test:devices/gadget:Gadget", Module: "devices",
+			Status: mappingMapped},
+		{Kind: "resource", TFName: "test_other_case", Token: "test:devices/Gadget:gadget", Module: "devices",
+			Status: mappingMapped},
+		{Kind: "resource", TFName: "test_removed", Token: "test:index/removed:Removed", Module: "index",
+			Status: mappingMissing},
+		{Kind: "resource", TFName: "test_unmapped", Status: mappingUnmapped},
+		{Kind: "resource", TFName: "test_widget", Token: "test:index/widget:Widget", Module: "index",
+			Status: mappingMapped},
+		{Kind: "resource", TFName: "test_widget_v2", Token: "test:index/widget:Widget", Module: "index",
+			Status: mappingMapped},
+		{Kind: "dataSource", TFName: "test_widget", Status: mappingNoToken},
+	}, mappings.Mappings)
+
+	assert.Equal(t, []tokenCollision{
+		{
+			Kind:    "resource",
+			Token:   "test:index/widget:Widget",
+			TFNames: []string{"test_widget", "test_widget_v2"},
+			Reason:  "multiple Terraform names map to the same token",
+		},
+		{
+			Kind:    "resource",
+			Token:   "test:devices/gadget:gadget",
+			TFNames: []string{"test_gadget", "test_other_case"},
+			Reason:  "tokens differ only in case, which collide on case-insensitive file systems",
+		},
+	}, mappings.Collisions)
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeTokenMappings(&buf, mappings))
+	assert.Contains(t, buf.String(), "collision: resource token test:index/widget:Widget: test_widget, test_widget_v2")
+}
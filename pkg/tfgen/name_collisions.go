@@ -0,0 +1,111 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	dotnetgen "github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	gogen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	pygen "github.com/pulumi/pulumi/pkg/v3/codegen/python"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// memberNameMangler derives the name of a property in one of the generated SDKs from its Pulumi name.
+type memberNameMangler struct {
+	language string
+	override string // the SchemaInfo field that overrides the name in this language.
+	mangle   func(name string, info *tfbridge.SchemaInfo) string
+}
+
+// memberNameManglers lists the name manglings applied by the SDK generators, in the order in which collisions are
+// reported. A collision between Pulumi names collides in every language, so it is only reported once.
+var memberNameManglers = []memberNameMangler{
+	{"the schema", "Name", func(name string, _ *tfbridge.SchemaInfo) string { return name }},
+	{"Python", "Name", func(name string, _ *tfbridge.SchemaInfo) string { return pygen.PyName(name) }},
+	{"Go", "Name", func(name string, _ *tfbridge.SchemaInfo) string { return gogen.Title(name) }},
+	{"C#", "CSharpName", func(name string, info *tfbridge.SchemaInfo) string {
+		if info != nil && info.CSharpName != "" {
+			return info.CSharpName
+		}
+		return dotnetgen.Title(name)
+	}},
+}
+
+// validateMemberNames ensures that no two properties of the same object end up with the same name in any of the
+// generated SDKs, which would otherwise only be discovered when the SDK fails to compile. Each collision is reported
+// with the Terraform paths of both properties and the override that would resolve it.
+func validateMemberNames(tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) error {
+	var err error
+
+	// Terraform refers to nested properties using paths of the form `block.0.property`.
+	var visit func(prefix string, tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo)
+	visit = func(prefix string, tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) {
+		var keys, names []string
+		for _, key := range stableSchemas(tfs) {
+			sch, info := tfs.Get(key), infos[key]
+			if sch.Removed() != "" || (info != nil && info.Omit) {
+				continue
+			}
+			if name := propertyName(key, sch, info); name != "" {
+				keys, names = append(keys, key), append(names, name)
+			}
+		}
+
+		reported := map[string]bool{}
+		for _, m := range memberNameManglers {
+			seen := map[string]string{}
+			for i, key := range keys {
+				mangled := m.mangle(names[i], infos[key])
+				other, has := seen[mangled]
+				if !has {
+					seen[mangled] = key
+					continue
+				}
+				if pair := other + "\x00" + key; !reported[pair] {
+					reported[pair] = true
+					err = multierror.Append(err, errors.Errorf(
+						"%s%s and %s%s both have the name %s in %s; set SchemaInfo.%s on one of them, e.g. "+
+							"Fields: map[string]*tfbridge.SchemaInfo{%q: {%s: %q}}",
+						prefix, other, prefix, key, mangled, m.language, m.override, key, m.override,
+						suggestMemberName(m, mangled)))
+				}
+			}
+		}
+
+		for _, key := range keys {
+			if res, ok := tfs.Get(key).Elem().(shim.Resource); ok {
+				var fields map[string]*tfbridge.SchemaInfo
+				if info := infos[key]; info != nil && info.Elem != nil {
+					fields = info.Elem.Fields
+				}
+				visit(prefix+key+".0.", res.Schema(), fields)
+			}
+		}
+	}
+	visit("", tfs, infos)
+
+	return err
+}
+
+// suggestMemberName returns a replacement for a name that collides in the language of the given mangler.
+func suggestMemberName(m memberNameMangler, mangled string) string {
+	if m.override == "CSharpName" {
+		return mangled + "Value"
+	}
+	return tfbridge.TerraformToPulumiName(pygen.PyName(mangled)+"_value", nil, nil, false)
+}
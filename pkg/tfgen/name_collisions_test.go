@@ -0,0 +1,56 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestValidateMemberNames(t *testing.T) {
+	tfs := shimv2.NewSchemaMap(map[string]*schemav2.Schema{
+		"a_bc":  {Type: schemav2.TypeString, Optional: true},
+		"a_b_c": {Type: schemav2.TypeString, Optional: true},
+		"block": {
+			Type:     schemav2.TypeList,
+			Optional: true,
+			Elem: &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+				"foo_bar": {Type: schemav2.TypeString, Optional: true},
+				"fooBar":  {Type: schemav2.TypeString, Optional: true},
+			}},
+		},
+		"size":  {Type: schemav2.TypeInt, Optional: true},
+		"sizes": {Type: schemav2.TypeInt, Optional: true},
+	})
+
+	assert.NoError(t, validateMemberNames(tfs, map[string]*tfbridge.SchemaInfo{
+		"a_b_c": {Name: "abc"},
+		"block": {Elem: &tfbridge.SchemaInfo{Fields: map[string]*tfbridge.SchemaInfo{"fooBar": {Omit: true}}}},
+	}))
+
+	err := validateMemberNames(tfs, map[string]*tfbridge.SchemaInfo{"size": {CSharpName: "Sizes"}})
+	assert.EqualError(t, err, "3 errors occurred:\n"+
+		"\t* a_b_c and a_bc both have the name a_bc in Python; set SchemaInfo.Name on one of them, e.g. "+
+		"Fields: map[string]*tfbridge.SchemaInfo{\"a_bc\": {Name: \"aBcValue\"}}\n"+
+		"\t* size and sizes both have the name Sizes in C#; set SchemaInfo.CSharpName on one of them, e.g. "+
+		"Fields: map[string]*tfbridge.SchemaInfo{\"sizes\": {CSharpName: \"SizesValue\"}}\n"+
+		"\t* block.0.fooBar and block.0.foo_bar both have the name fooBar in the schema; set SchemaInfo.Name on "+
+		"one of them, e.g. Fields: map[string]*tfbridge.SchemaInfo{\"foo_bar\": {Name: \"fooBarValue\"}}\n\n")
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// The nesting modes of properties whose upstream type is a collection. Pulumi represents lists and sets alike as
+// arrays, and single-element collections as their element, so the mode is recorded under the "tfbridge" language
+// section of each such property for tools that care about ordering, e.g. diff visualizations and converters.
+const (
+	nestingModeList   = "list"   // an ordered collection, represented as an array
+	nestingModeSet    = "set"    // an unordered collection, represented as an array whose order is not significant
+	nestingModeSingle = "single" // a collection of at most one element, represented as the element
+)
+
+// propertyNestingMode returns the nesting mode of the given property, and for single-element collections the kind of
+// upstream collection, or "" if the property is not a collection.
+func propertyNestingMode(sch shim.Schema, info *tfbridge.SchemaInfo) (string, string) {
+	if sch == nil {
+		return "", ""
+	}
+	var collection string
+	switch sch.Type() {
+	case shim.TypeList:
+		collection = nestingModeList
+	case shim.TypeSet:
+		collection = nestingModeSet
+	default:
+		return "", ""
+	}
+	if tfbridge.IsMaxItemsOne(sch, info) {
+		return nestingModeSingle, collection
+	}
+	return collection, ""
+}
+
+// setNestingMode records the nesting mode of the given property, if it is a collection.
+func setNestingMode(spec *pschema.PropertySpec, prop *variable) {
+	mode, collection := propertyNestingMode(prop.schema, prop.info)
+	if mode == "" {
+		return
+	}
+	data := map[string]interface{}{"nestingMode": mode}
+	if collection != "" {
+		data["collection"] = collection
+	}
+	setPropertyBridgeData(spec, data)
+}
+
+// setPropertyBridgeData merges the given data into the "tfbridge" language section of the given property.
+func setPropertyBridgeData(spec *pschema.PropertySpec, data map[string]interface{}) {
+	if spec.Language == nil {
+		spec.Language = map[string]pschema.RawMessage{}
+	}
+	merged := map[string]interface{}{}
+	if existing, ok := spec.Language["tfbridge"]; ok {
+		if err := json.Unmarshal(existing, &merged); err != nil {
+			merged = map[string]interface{}{}
+		}
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	spec.Language["tfbridge"] = rawMessage(merged)
+}
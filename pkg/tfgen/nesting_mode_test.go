@@ -0,0 +1,73 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestNestingMode(t *testing.T) {
+	str := &schemav2.Schema{Type: schemav2.TypeString}
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"name":  {Type: schemav2.TypeString, Optional: true},
+					"ports": {Type: schemav2.TypeList, Optional: true, Elem: str},
+					"tags":  {Type: schemav2.TypeSet, Optional: true, Elem: str},
+					"spec": {Type: schemav2.TypeList, Optional: true, MaxItems: 1, Elem: &schemav2.Resource{
+						Schema: map[string]*schemav2.Schema{
+							"zones": {Type: schemav2.TypeSet, Optional: true, Elem: str},
+						},
+					}},
+					"owner": {Type: schemav2.TypeSet, Optional: true, Elem: str,
+						Description: "The owner. Valid values are `alice` and `bob`."},
+				}},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{"test_widget": {
+			Tok: "test:index/widget:Widget",
+			Fields: map[string]*tfbridge.SchemaInfo{
+				"owner": {MaxItemsOne: tfbridge.True()},
+			},
+		}},
+		ExtractDocValues: true,
+	}
+
+	spec := genTestSchema(t, info)
+
+	mode := func(prop pschema.PropertySpec) string {
+		return string(prop.Language["tfbridge"])
+	}
+	widget := spec.Resources["test:index/widget:Widget"]
+	assert.Equal(t, "", mode(widget.InputProperties["name"]))
+	assert.Equal(t, `{"nestingMode":"list"}`, mode(widget.InputProperties["ports"]))
+	assert.Equal(t, `{"nestingMode":"set"}`, mode(widget.Properties["tags"]))
+	assert.Equal(t, `{"collection":"list","nestingMode":"single"}`, mode(widget.InputProperties["spec"]))
+	assert.Equal(t, `{"nestingMode":"set"}`,
+		mode(spec.Types["test:index/WidgetSpec:WidgetSpec"].Properties["zones"]))
+
+	// Modes are recorded alongside other bridge metadata.
+	assert.Equal(t, `{"allowedValues":["alice","bob"],"collection":"set","nestingMode":"single"}`,
+		mode(widget.InputProperties["owner"]))
+}
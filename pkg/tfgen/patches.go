@@ -0,0 +1,350 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/cobra"
+)
+
+// The status of a single patch of a patch series.
+const (
+	patchApplied        = "applied"         // the patch was applied to the upstream checkout
+	patchAlreadyApplied = "already-applied" // the upstream checkout already contains the patch
+	patchNotApplied     = "not-applied"     // the patch applies to the upstream checkout, but has not been applied
+	patchClean          = "clean"           // the patch applies cleanly on top of the patches before it
+	patchConflict       = "conflict"        // the patch does not apply
+	patchSkipped        = "skipped"         // the patch was not tried, as an earlier patch did not apply
+)
+
+// patchResult describes the outcome of applying or checking a single patch.
+type patchResult struct {
+	Patch       string // the name of the patch file
+	Status      string
+	Diagnostics string `json:"Diagnostics,omitempty"` // git's explanation of why the patch does not apply
+}
+
+// patchReport describes the outcome of applying or checking a patch series, in the order of the series.
+type patchReport struct {
+	Patches []patchResult
+}
+
+// count returns the number of patches with the given status.
+func (r *patchReport) count(status string) int {
+	n := 0
+	for _, p := range r.Patches {
+		if p.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// findPatches returns the absolute paths of the *.patch files in the given directory, in the order in which they are
+// applied, which is the order of their names.
+func findPatches(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.patch"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	for i, m := range matches {
+		if matches[i], err = filepath.Abs(m); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// runGit runs git with the given arguments in the upstream checkout, adding env to its environment, and returns its
+// combined output.
+func runGit(upstream string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = upstream
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// scratchIndex creates a git index that starts out as the upstream commit, in which a patch series can be applied
+// without touching the working tree or index of the upstream checkout. It returns the environment that selects the
+// index and a function that removes it.
+func scratchIndex(upstream string) ([]string, func(), error) {
+	index, err := ioutil.TempFile("", "tfgen-patches-index-")
+	if err != nil {
+		return nil, nil, err
+	}
+	contract.IgnoreClose(index)
+	cleanup := func() { contract.IgnoreError(os.Remove(index.Name())) }
+
+	env := []string{"GIT_INDEX_FILE=" + index.Name()}
+	if out, err := runGit(upstream, env, "read-tree", "HEAD"); err != nil {
+		cleanup()
+		return nil, nil, errors.Errorf("failed to read the upstream commit: %s", out)
+	}
+	return env, cleanup, nil
+}
+
+// appliedPatchCount returns the number of leading patches of the series that are applied to the working tree of the
+// upstream checkout: the working tree matches the upstream commit with those patches applied, in the files that the
+// series touches.
+func appliedPatchCount(upstream string, patches []string) (int, error) {
+	var paths []string
+	for _, patch := range patches {
+		out, err := runGit(upstream, nil, "apply", "--numstat", patch)
+		if err != nil {
+			return 0, errors.Errorf("failed to read %s: %s", filepath.Base(patch), out)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if fields := strings.SplitN(line, "\t", 3); len(fields) == 3 {
+				paths = append(paths, fields[2])
+			}
+		}
+	}
+
+	env, cleanup, err := scratchIndex(upstream)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	matches := func() bool {
+		_, err := runGit(upstream, env, append([]string{"diff", "--quiet", "--"}, paths...)...)
+		return err == nil
+	}
+
+	applied := 0
+	for i, patch := range patches {
+		if _, err := runGit(upstream, env, "apply", "--cached", patch); err != nil {
+			break
+		}
+		if matches() {
+			applied = i + 1
+		}
+	}
+	return applied, nil
+}
+
+// applyPatches applies the patch series in patchesDir to the working tree of the upstream checkout. Patches that the
+// checkout already contains are skipped, so that the series can be applied again after it was partially applied. The
+// series stops at the first patch that does not apply, leaving the patches before it applied.
+func applyPatches(upstream, patchesDir string) (*patchReport, error) {
+	patches, err := findPatches(patchesDir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedPatchCount(upstream, patches)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &patchReport{}
+	conflicted := false
+	for i, patch := range patches {
+		result := patchResult{Patch: filepath.Base(patch)}
+		switch {
+		case i < applied:
+			result.Status = patchAlreadyApplied
+		case conflicted:
+			result.Status = patchSkipped
+		default:
+			if out, err := runGit(upstream, nil, "apply", "--verbose", patch); err != nil {
+				result.Status, result.Diagnostics, conflicted = patchConflict, out, true
+			} else {
+				result.Status = patchApplied
+			}
+		}
+		report.Patches = append(report.Patches, result)
+	}
+	return report, nil
+}
+
+// verifyPatches checks that the patch series in patchesDir applies cleanly to the committed upstream sources, one
+// patch on top of the other, without touching the working tree or index of the checkout.
+func verifyPatches(upstream, patchesDir string) (*patchReport, error) {
+	patches, err := findPatches(patchesDir)
+	if err != nil {
+		return nil, err
+	}
+	env, cleanup, err := scratchIndex(upstream)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	report := &patchReport{}
+	for _, patch := range patches {
+		result := patchResult{Patch: filepath.Base(patch), Status: patchClean}
+		if out, err := runGit(upstream, env, "apply", "--cached", "--verbose", patch); err != nil {
+			result.Status, result.Diagnostics = patchConflict, out
+		}
+		report.Patches = append(report.Patches, result)
+	}
+	return report, nil
+}
+
+// checkPatchesApplied reports whether each patch of the series in patchesDir is applied to the working tree of the
+// upstream checkout. Of the patches that are not, only the first one is checked against the working tree, as the
+// others may depend on it.
+func checkPatchesApplied(upstream, patchesDir string) (*patchReport, error) {
+	patches, err := findPatches(patchesDir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedPatchCount(upstream, patches)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &patchReport{}
+	for i, patch := range patches {
+		result := patchResult{Patch: filepath.Base(patch), Status: patchNotApplied}
+		switch {
+		case i < applied:
+			result.Status = patchAlreadyApplied
+		case i == applied:
+			if out, err := runGit(upstream, nil, "apply", "--check", "--verbose", patch); err != nil {
+				result.Status, result.Diagnostics = patchConflict, out
+			}
+		}
+		report.Patches = append(report.Patches, result)
+	}
+	return report, nil
+}
+
+// requirePatchesApplied fails if any patch of the series in patchesDir is not applied to the upstream checkout, so
+// that the schema is never generated from unpatched upstream sources.
+func requirePatchesApplied(upstream, patchesDir string) error {
+	report, err := checkPatchesApplied(upstream, patchesDir)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, p := range report.Patches {
+		if p.Status != patchAlreadyApplied {
+			missing = append(missing, fmt.Sprintf("%s (%s)", p.Patch, p.Status))
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("upstream patches are not applied to %s: %s; run `patches apply` first",
+			upstream, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// writePatchReport renders the given report as a table, followed by the diagnostics of the patches that do not apply.
+func writePatchReport(w io.Writer, report *patchReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "PATCH\tSTATUS"); err != nil {
+		return err
+	}
+	for _, p := range report.Patches {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\n", p.Patch, p.Status); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range report.Patches {
+		if p.Diagnostics == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n%s does not apply:\n%s\n", p.Patch, p.Diagnostics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPatchesCmd creates the `patches` subcommand, which applies and verifies the series of patches that the provider
+// maintains against its upstream checkout.
+func newPatchesCmd() *cobra.Command {
+	var upstream, patchesDir string
+	var jsonOutput bool
+
+	report := func(apply func(upstream, patchesDir string) (*patchReport, error)) func(*cobra.Command, []string) {
+		return cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			report, err := apply(upstream, patchesDir)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else if err := writePatchReport(os.Stdout, report); err != nil {
+				return err
+			}
+
+			if n := report.count(patchConflict); n != 0 {
+				return errors.Errorf("%d patch(es) do not apply", n)
+			}
+			return nil
+		})
+	}
+
+	cmd := &cobra.Command{
+		Use:   "patches",
+		Short: "Apply or verify the patches to the upstream provider",
+		Long: "Apply or verify the patches to the upstream provider.\n" +
+			"\n" +
+			"Providers that patch their upstream provider keep the patches as a series of *.patch\n" +
+			"files, applied in the order of their names to a checkout of the upstream repository.\n",
+	}
+	cmd.PersistentFlags().StringVar(
+		&upstream, "upstream", "upstream", "The checkout of the upstream provider repository")
+	cmd.PersistentFlags().StringVar(
+		&patchesDir, "patches", "patches", "The directory that holds the patch series")
+	cmd.PersistentFlags().BoolVar(
+		&jsonOutput, "json", false, "Emit the report as JSON rather than as a table")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "apply",
+		Args:  cmdutil.NoArgs,
+		Short: "Apply the patch series to the upstream checkout, skipping patches that are already applied",
+		Run:   report(applyPatches),
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Args:  cmdutil.NoArgs,
+		Short: "Check that the patch series applies cleanly to the committed upstream sources",
+		Run:   report(verifyPatches),
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Args:  cmdutil.NoArgs,
+		Short: "Report which patches of the series are applied to the upstream checkout",
+		Run:   report(checkPatchesApplied),
+	})
+
+	return cmd
+}
@@ -0,0 +1,126 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// Create an upstream checkout with a single commit.
+	upstream, patches := t.TempDir(), t.TempDir()
+	write := func(dir, name, contents string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600))
+	}
+	write(upstream, "main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		out, err := runGit(upstream, nil, args...)
+		require.NoError(t, err, out)
+	}
+
+	// The second patch depends on the first.
+	write(patches, "0001-greeting.patch", `--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ package main
+ 
+ func main() {
+-	println("hello")
++	println("hello, world")
+ }
+`)
+	write(patches, "0002-exclaim.patch", `--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ package main
+ 
+ func main() {
+-	println("hello, world")
++	println("hello, world!")
+ }
+`)
+
+	report, err := verifyPatches(upstream, patches)
+	require.NoError(t, err)
+	assert.Equal(t, []patchResult{
+		{Patch: "0001-greeting.patch", Status: patchClean},
+		{Patch: "0002-exclaim.patch", Status: patchClean},
+	}, report.Patches)
+	assert.Error(t, requirePatchesApplied(upstream, patches))
+
+	report, err = applyPatches(upstream, patches)
+	require.NoError(t, err)
+	assert.Equal(t, []patchResult{
+		{Patch: "0001-greeting.patch", Status: patchApplied},
+		{Patch: "0002-exclaim.patch", Status: patchApplied},
+	}, report.Patches)
+	contents, err := ioutil.ReadFile(filepath.Join(upstream, "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "hello, world!")
+	assert.NoError(t, requirePatchesApplied(upstream, patches))
+
+	// Applying the series again is a no-op.
+	report, err = applyPatches(upstream, patches)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.count(patchAlreadyApplied))
+
+	// Patches that no longer apply to the upstream sources are reported with git's diagnostics, and the patches after
+	// them are skipped.
+	write(patches, "0001-greeting.patch", `--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ package main
+ 
+ func main() {
+-	println("goodbye")
++	println("hello, world")
+ }
+`)
+	report, err = verifyPatches(upstream, patches)
+	require.NoError(t, err)
+	assert.Equal(t, patchConflict, report.Patches[0].Status)
+	assert.Contains(t, report.Patches[0].Diagnostics, "patch does not apply")
+
+	_, err = runGit(upstream, nil, "checkout", "--", ".")
+	require.NoError(t, err)
+	report, err = applyPatches(upstream, patches)
+	require.NoError(t, err)
+	assert.Equal(t, patchConflict, report.Patches[0].Status)
+	assert.Equal(t, patchSkipped, report.Patches[1].Status)
+
+	report, err = checkPatchesApplied(upstream, patches)
+	require.NoError(t, err)
+	assert.Equal(t, patchConflict, report.Patches[0].Status)
+	assert.Equal(t, patchNotApplied, report.Patches[1].Status)
+
+	var buf bytes.Buffer
+	assert.NoError(t, writePatchReport(&buf, report))
+	assert.Contains(t, buf.String(), "0001-greeting.patch does not apply:\n")
+}
@@ -1,6 +1,7 @@
 package tfgen
 
 import (
+	"errors"
 	"sync"
 
 	"github.com/blang/semver"
@@ -93,3 +94,60 @@ func (host *cachingProviderHost) Provider(pkg tokens.Package, version *semver.Ve
 	host.cache[key] = provider
 	return provider, nil
 }
+
+// errConversionHostDetached is returned by a conversionHost once its conversion has been abandoned.
+var errConversionHostDetached = errors.New("example conversion was abandoned")
+
+// conversionHost is the plugin host and provider info source for a single example conversion. The converter does not
+// accept a context, so a conversion that times out keeps running in the background; detaching its host stops it from
+// loading providers or provider info through the generator's shared host, which may be in use by later conversions
+// or already closed.
+type conversionHost struct {
+	plugin.Host
+
+	info il.ProviderInfoSource
+
+	m        sync.RWMutex
+	detached bool
+}
+
+func newConversionHost(host plugin.Host, info il.ProviderInfoSource) *conversionHost {
+	return &conversionHost{Host: host, info: info}
+}
+
+// detach cuts the host off from the shared host. Subsequent calls fail with errConversionHostDetached; calls that are
+// already in progress are not waited for, as they may be what caused the conversion to time out.
+func (host *conversionHost) detach() {
+	host.m.Lock()
+	defer host.m.Unlock()
+
+	host.detached = true
+}
+
+func (host *conversionHost) isDetached() bool {
+	host.m.RLock()
+	defer host.m.RUnlock()
+
+	return host.detached
+}
+
+func (host *conversionHost) Provider(pkg tokens.Package, version *semver.Version) (plugin.Provider, error) {
+	if host.isDetached() {
+		return nil, errConversionHostDetached
+	}
+	return host.Host.Provider(pkg, version)
+}
+
+func (host *conversionHost) GetProviderInfo(
+	registryName, namespace, name, version string) (*tfbridge.ProviderInfo, error) {
+
+	if host.isDetached() {
+		return nil, errConversionHostDetached
+	}
+	return host.info.GetProviderInfo(registryName, namespace, name, version)
+}
+
+// Close is a no-op: the shared host is owned and closed by the generator.
+func (host *conversionHost) Close() error {
+	return nil
+}
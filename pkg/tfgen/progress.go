@@ -0,0 +1,126 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// The stages of Generate that progress is reported for, in order.
+const (
+	progressGather   = "gather"   // gathering the resources and data sources of the upstream provider
+	progressSchema   = "schema"   // generating the Pulumi schema
+	progressExamples = "examples" // converting the examples of each resource and function
+	progressEmit     = "emit"     // generating the SDK, if any, and writing the output files
+)
+
+// ProgressFormatJSON is the --progress format that writes a JSON object per line for each progress event.
+const ProgressFormatJSON = "json"
+
+// progressEvent is a progress event, as written by `--progress=json`. A stage starts with a "start" event, reports
+// "progress" events as its items complete, and ends with a "done" event.
+type progressEvent struct {
+	Stage     string  `json:"stage"`
+	Event     string  `json:"event"`
+	Completed int     `json:"completed"`
+	Total     int     `json:"total,omitempty"` // the number of items in the stage, if known
+	Elapsed   float64 `json:"elapsedSeconds"`  // the time since the stage started
+	ETA       float64 `json:"etaSeconds,omitempty"`
+}
+
+// progressReporter writes the progress of a Generator's stages to a writer. A nil reporter reports nothing.
+type progressReporter struct {
+	w   io.Writer
+	now func() time.Time
+
+	stage     string
+	start     time.Time
+	total     int
+	completed int
+	percent   int // the percentage of the stage that was last reported, to limit the number of events
+}
+
+func newProgressReporter(w io.Writer) *progressReporter {
+	if w == nil {
+		return nil
+	}
+	return &progressReporter{w: w, now: time.Now}
+}
+
+// validateProgressFormat returns an error if the given --progress format is not supported.
+func validateProgressFormat(format string) error {
+	if format != "" && format != ProgressFormatJSON {
+		return errors.Errorf("unsupported progress format %q; the supported format is %q", format, ProgressFormatJSON)
+	}
+	return nil
+}
+
+// begin starts the given stage, which has the given number of items, or 0 if the number is not known.
+func (p *progressReporter) begin(stage string, total int) {
+	if p == nil {
+		return
+	}
+	p.stage, p.start, p.total, p.completed, p.percent = stage, p.now(), total, 0, 0
+	p.emit("start")
+}
+
+// advance records that an item of the current stage completed. Events are only written when the completed
+// percentage of the stage changes.
+func (p *progressReporter) advance() {
+	if p == nil || p.stage == "" {
+		return
+	}
+	p.completed++
+	if p.total > 0 {
+		percent := p.completed * 100 / p.total
+		if percent == p.percent {
+			return
+		}
+		p.percent = percent
+	}
+	p.emit("progress")
+}
+
+// end finishes the current stage.
+func (p *progressReporter) end() {
+	if p == nil || p.stage == "" {
+		return
+	}
+	p.emit("done")
+	p.stage = ""
+}
+
+func (p *progressReporter) emit(event string) {
+	elapsed := p.now().Sub(p.start)
+	e := progressEvent{
+		Stage:     p.stage,
+		Event:     event,
+		Completed: p.completed,
+		Total:     p.total,
+		Elapsed:   elapsed.Seconds(),
+	}
+	if event == "progress" && p.total > 0 && p.completed > 0 && p.completed < p.total {
+		e.ETA = (elapsed * time.Duration(p.total-p.completed) / time.Duration(p.completed)).Seconds()
+	}
+	bytes, err := json.Marshal(e)
+	contract.AssertNoError(err)
+	_, err = p.w.Write(append(bytes, '\n'))
+	contract.IgnoreError(err)
+}
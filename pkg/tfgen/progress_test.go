@@ -0,0 +1,143 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func readProgressEvents(t *testing.T, buf *bytes.Buffer) []progressEvent {
+	var events []progressEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e progressEvent
+		assert.NoError(t, json.Unmarshal([]byte(line), &e))
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestProgressReporter(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Unix(0, 0)
+	p := newProgressReporter(&buf)
+	p.now = func() time.Time { return now }
+
+	p.begin(progressGather, 4)
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Second)
+		p.advance()
+	}
+	p.end()
+
+	assert.Equal(t, []progressEvent{
+		{Stage: "gather", Event: "start", Total: 4},
+		{Stage: "gather", Event: "progress", Completed: 1, Total: 4, Elapsed: 1, ETA: 3},
+		{Stage: "gather", Event: "progress", Completed: 2, Total: 4, Elapsed: 2, ETA: 2},
+		{Stage: "gather", Event: "progress", Completed: 3, Total: 4, Elapsed: 3, ETA: 1},
+		{Stage: "gather", Event: "progress", Completed: 4, Total: 4, Elapsed: 4},
+		{Stage: "gather", Event: "done", Completed: 4, Total: 4, Elapsed: 4},
+	}, readProgressEvents(t, &buf))
+
+	// Nil reporters, and items outside of a stage, report nothing.
+	var none *progressReporter
+	none.begin(progressSchema, 1)
+	none.advance()
+	none.end()
+	buf.Reset()
+	p.advance()
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, validateProgressFormat("json"))
+	assert.Error(t, validateProgressFormat("xml"))
+}
+
+func TestGenerateProgress(t *testing.T) {
+	var buf bytes.Buffer
+	g, err := NewGenerator(GeneratorOptions{
+		Package:  "test",
+		Version:  "0.0.1",
+		Language: Schema,
+		ProviderInfo: tfbridge.ProviderInfo{
+			Name: "test",
+			P: shimv2.NewProvider(&schemav2.Provider{
+				ResourcesMap: map[string]*schemav2.Resource{
+					"test_widget": {Schema: map[string]*schemav2.Schema{
+						"name": {Type: schemav2.TypeString, Optional: true},
+					}},
+				},
+				DataSourcesMap: map[string]*schemav2.Resource{
+					"test_widget": {Schema: map[string]*schemav2.Schema{
+						"name": {Type: schemav2.TypeString, Optional: true},
+					}},
+				},
+			}),
+			Resources:   map[string]*tfbridge.ResourceInfo{"test_widget": {Tok: "test:index/widget:Widget"}},
+			DataSources: map[string]*tfbridge.DataSourceInfo{"test_widget": {Tok: "test:index/getWidget:getWidget"}},
+		},
+		Root:     afero.NewMemMapFs(),
+		Sink:     diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never}),
+		SkipDocs: true,
+		Progress: &buf,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, g.Generate())
+
+	var stages []string
+	for _, e := range readProgressEvents(t, &buf) {
+		if e.Event == "done" {
+			stages = append(stages, e.Stage)
+			switch e.Stage {
+			case progressGather:
+				assert.Equal(t, 2, e.Completed, e.Stage)
+				assert.Equal(t, 2, e.Total, e.Stage)
+			case progressExamples:
+				// The examples of the built-in terraformCompat invoke are converted as well.
+				assert.Equal(t, 3, e.Completed, e.Stage)
+				assert.Equal(t, 3, e.Total, e.Stage)
+			}
+		}
+	}
+	assert.Equal(t, []string{"gather", "schema", "examples", "emit"}, stages)
+}
+
+func TestTFGenSubcommands(t *testing.T) {
+	cmd := newTFGenCmd("test", "0.0.1", tfbridge.ProviderInfo{Name: "test"}, MainOptions{})
+	for _, name := range []string{"schema", "sdk", "docs", "coverage", "lint"} {
+		sub, _, err := cmd.Find([]string{name})
+		assert.NoError(t, err)
+		assert.NotEqual(t, cmd, sub, name)
+
+		// Only the commands that generate take the flags that configure generation.
+		generates := name != "lint"
+		assert.Equal(t, generates, sub.Flags().Lookup("progress") != nil, name)
+		assert.Equal(t, generates, sub.Flags().Lookup("skip-examples") != nil, name)
+		assert.NotNil(t, sub.InheritedFlags().Lookup("verbose"), name)
+	}
+	assert.NotNil(t, cmd.Flags().Lookup("progress"))
+}
@@ -0,0 +1,261 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	pygen "github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// providerFactoryData is the data used to render a provider factory for a single language.
+type providerFactoryData struct {
+	Tool      string // the name of the generating tool, for the header comment
+	Package   string // the Pulumi package name, used to name the providers the factory creates
+	Key       string // the Pulumi name of the config property that distinguishes providers, e.g. region
+	Name      string // the language-specific name of the key, e.g. Region or region
+	FuncName  string // the language-specific name of the factory function
+	Namespace string // the Go package or C# namespace the factory is emitted into
+}
+
+var providerFactoryTemplates = map[Language]*template.Template{
+	NodeJS: template.Must(template.New("providerFactory.ts").Parse(`// *** WARNING: this file was generated by {{.Tool}}. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+import * as pulumi from "@pulumi/pulumi";
+import { Provider, ProviderArgs } from "./provider";
+
+const providers: { [{{.Name}}: string]: Provider } = {};
+
+/**
+ * Returns a provider configured for the given {{.Key}}, creating it on first use. Providers are shared between
+ * calls with the same {{.Key}}; the args and opts of later calls are ignored.
+ */
+export function {{.FuncName}}({{.Name}}: string, args?: ProviderArgs, opts?: pulumi.ResourceOptions): Provider {
+    let provider = providers[{{.Name}}];
+    if (!provider) {
+        provider = new Provider(` + "`{{.Package}}-${ {{- .Name -}} }`" + `, { ...args, {{.Name}} }, opts);
+        providers[{{.Name}}] = provider;
+    }
+    return provider;
+}
+`)),
+	Python: template.Must(template.New("provider_factory.py").Parse(`# coding=utf-8
+# *** WARNING: this file was generated by {{.Tool}}. ***
+# *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+from typing import Dict, Optional
+import pulumi
+from .provider import Provider
+
+__all__ = ['{{.FuncName}}']
+
+_providers: Dict[str, Provider] = {}
+
+
+def {{.FuncName}}({{.Name}}: str, opts: Optional[pulumi.ResourceOptions] = None, **kwargs) -> Provider:
+    """
+    Returns a provider configured for the given {{.Key}}, creating it on first use. Providers are shared between
+    calls with the same {{.Key}}; the options and arguments of later calls are ignored.
+    """
+    provider = _providers.get({{.Name}})
+    if provider is None:
+        provider = Provider(f"{{.Package}}-{ {{- .Name -}} }", opts=opts, {{.Name}}={{.Name}}, **kwargs)
+        _providers[{{.Name}}] = provider
+    return provider
+`)),
+	Golang: template.Must(template.New("providerFactory.go").Parse(`// *** WARNING: this file was generated by {{.Tool}}. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+package {{.Namespace}}
+
+import (
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var providersBy{{.Name}}Lock sync.Mutex
+var providersBy{{.Name}} = map[*pulumi.Context]map[string]*Provider{}
+
+// {{.FuncName}} returns a provider configured for the given {{.Key}}, creating it on first use. Providers are shared
+// between calls with the same context and {{.Key}}; the args and opts of later calls are ignored.
+func {{.FuncName}}(ctx *pulumi.Context, {{.Key}} string, args *ProviderArgs,
+	opts ...pulumi.ResourceOption) (*Provider, error) {
+
+	providersBy{{.Name}}Lock.Lock()
+	defer providersBy{{.Name}}Lock.Unlock()
+
+	providers, ok := providersBy{{.Name}}[ctx]
+	if !ok {
+		providers = map[string]*Provider{}
+		providersBy{{.Name}}[ctx] = providers
+	}
+	if provider, ok := providers[{{.Key}}]; ok {
+		return provider, nil
+	}
+
+	var providerArgs ProviderArgs
+	if args != nil {
+		providerArgs = *args
+	}
+	providerArgs.{{.Name}} = pulumi.String({{.Key}})
+	provider, err := NewProvider(ctx, "{{.Package}}-"+{{.Key}}, &providerArgs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	providers[{{.Key}}] = provider
+	return provider, nil
+}
+`)),
+	CSharp: template.Must(template.New("ProviderFactory.cs").Parse(`// *** WARNING: this file was generated by {{.Tool}}. ***
+// *** Do not edit by hand unless you're certain you know what you are doing! ***
+
+using System.Collections.Concurrent;
+using Pulumi;
+
+namespace {{.Namespace}}
+{
+    public static class ProviderFactory
+    {
+        private static readonly ConcurrentDictionary<string, Provider> _providers =
+            new ConcurrentDictionary<string, Provider>();
+
+        /// <summary>
+        /// Returns a provider configured for the given {{.Key}}, creating it on first use. Providers are shared
+        /// between calls with the same {{.Key}}; the args and options of later calls are ignored.
+        /// </summary>
+        public static Provider {{.FuncName}}(string {{.Key}}, ProviderArgs? args = null, CustomResourceOptions? options = null)
+        {
+            return _providers.GetOrAdd({{.Key}}, key =>
+            {
+                args ??= new ProviderArgs();
+                args.{{.Name}} = key;
+                return new Provider($"{{.Package}}-{key}", args, options);
+            });
+        }
+    }
+}
+`)),
+}
+
+var (
+	goPackageRegexp   = regexp.MustCompile(`(?m)^package (\w+)$`)
+	csNamespaceRegexp = regexp.MustCompile(`(?m)^namespace ([\w.]+)$`)
+)
+
+// providerFactoryKey returns the Pulumi name of the provider configuration property that the provider factory is
+// keyed by, validating that it is a string.
+func providerFactoryKey(info tfbridge.ProviderInfo) (string, error) {
+	key := info.ProviderFactory.Key
+	sch := info.P.Schema().Get(key)
+	if sch == nil {
+		return "", errors.Errorf("provider factory key %q is not a provider configuration property", key)
+	}
+	if sch.Type() != shim.TypeString {
+		return "", errors.Errorf("provider factory key %q must be a string property", key)
+	}
+	return tfbridge.TerraformToPulumiName(key, sch, info.Config[key], false), nil
+}
+
+// addProviderFactory adds a per-key provider factory to the generated SDK files for the given language, and exports
+// it from the package's entry point where the language requires it.
+func addProviderFactory(lang Language, info tfbridge.ProviderInfo, pkg *pschema.Package,
+	files map[string][]byte) error {
+
+	key, err := providerFactoryKey(info)
+	if err != nil {
+		return err
+	}
+	tmpl, ok := providerFactoryTemplates[lang]
+	if !ok {
+		return nil
+	}
+
+	// If there is more than one candidate, e.g. because a module is itself named "provider", use the one closest to
+	// the root of the SDK, breaking ties by name so that the choice does not depend on map order.
+	var candidates []string
+	for name := range files {
+		if base := path.Base(name); base == "provider.ts" || base == "provider.py" || base == "provider.go" ||
+			base == "Provider.cs" {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return errors.New("could not find the generated provider to add a provider factory to")
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		di, dj := strings.Count(candidates[i], "/"), strings.Count(candidates[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return candidates[i] < candidates[j]
+	})
+	providerFile := candidates[0]
+	dir := path.Dir(providerFile)
+
+	title := strings.ToUpper(key[:1]) + key[1:]
+	data := providerFactoryData{Tool: tfgen, Package: pkg.Name, Key: key}
+	var factoryFile string
+	switch lang {
+	case NodeJS:
+		data.Name, data.FuncName = key, "providerFor"+title
+		factoryFile = path.Join(dir, "providerFactory.ts")
+		indexFile := path.Join(dir, "index.ts")
+		files[indexFile] = append(files[indexFile], []byte("export * from \"./providerFactory\";\n")...)
+	case Python:
+		data.Name = pygen.PyName(key)
+		data.FuncName = "provider_for_" + data.Name
+		factoryFile = path.Join(dir, "provider_factory.py")
+		initFile := path.Join(dir, "__init__.py")
+		files[initFile] = bytes.Replace(files[initFile], []byte("from .provider import *\n"),
+			[]byte("from .provider import *\nfrom .provider_factory import *\n"), 1)
+	case Golang:
+		m := goPackageRegexp.FindSubmatch(files[providerFile])
+		if m == nil {
+			return errors.Errorf("could not find the package name of %s", providerFile)
+		}
+		data.Name, data.FuncName, data.Namespace = title, "ProviderFor"+title, string(m[1])
+		factoryFile = path.Join(dir, "providerFactory.go")
+	case CSharp:
+		m := csNamespaceRegexp.FindSubmatch(files[providerFile])
+		if m == nil {
+			return errors.Errorf("could not find the namespace of %s", providerFile)
+		}
+		data.Name, data.FuncName, data.Namespace = title, "For"+title, string(m[1])
+		if ci := info.Config[info.ProviderFactory.Key]; ci != nil && ci.CSharpName != "" {
+			data.Name = ci.CSharpName
+		}
+		factoryFile = path.Join(dir, "ProviderFactory.cs")
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("rendering provider factory: %w", err)
+	}
+	files[factoryFile] = buf.Bytes()
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestAddProviderFactory(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		P: shimv2.NewProvider(&schema.Provider{
+			Schema: map[string]*schema.Schema{
+				"default_region": {Type: schema.TypeString, Optional: true},
+				"max_retries":    {Type: schema.TypeInt, Optional: true},
+			},
+		}),
+		ProviderFactory: &tfbridge.ProviderFactoryInfo{Key: "default_region"},
+	}
+	pkg := &pschema.Package{Name: "test"}
+
+	files := map[string][]byte{"provider.ts": nil, "index.ts": []byte("export * from \"./provider\";\n")}
+	assert.NoError(t, addProviderFactory(NodeJS, info, pkg, files))
+	assert.Contains(t, string(files["providerFactory.ts"]),
+		"export function providerForDefaultRegion(defaultRegion: string, args?: ProviderArgs")
+	assert.Contains(t, string(files["providerFactory.ts"]), "new Provider(`test-${defaultRegion}`")
+	assert.Equal(t, "export * from \"./provider\";\nexport * from \"./providerFactory\";\n", string(files["index.ts"]))
+
+	files = map[string][]byte{
+		"pulumi_test/provider.py": nil,
+		"pulumi_test/__init__.py": []byte("from .provider import *\n"),
+	}
+	assert.NoError(t, addProviderFactory(Python, info, pkg, files))
+	assert.Contains(t, string(files["pulumi_test/provider_factory.py"]),
+		"def provider_for_default_region(default_region: str,")
+	assert.Contains(t, string(files["pulumi_test/__init__.py"]), "from .provider_factory import *\n")
+
+	files = map[string][]byte{"test/provider.go": []byte("package test\n")}
+	assert.NoError(t, addProviderFactory(Golang, info, pkg, files))
+	assert.Contains(t, string(files["test/providerFactory.go"]), "package test\n")
+	assert.Contains(t, string(files["test/providerFactory.go"]),
+		"func ProviderForDefaultRegion(ctx *pulumi.Context, defaultRegion string, args *ProviderArgs,")
+
+	// A module named "provider" does not shadow the SDK's own provider.
+	for i := 0; i < 10; i++ {
+		files = map[string][]byte{
+			"test/provider.go":          []byte("package test\n"),
+			"test/provider/provider.go": []byte("package provider\n"),
+		}
+		assert.NoError(t, addProviderFactory(Golang, info, pkg, files))
+		assert.Contains(t, string(files["test/providerFactory.go"]), "package test\n")
+	}
+
+	files = map[string][]byte{"Provider.cs": []byte("namespace Pulumi.Test\n{\n}\n")}
+	assert.NoError(t, addProviderFactory(CSharp, info, pkg, files))
+	assert.Contains(t, string(files["ProviderFactory.cs"]), "namespace Pulumi.Test\n")
+	assert.Contains(t, string(files["ProviderFactory.cs"]), "public static Provider ForDefaultRegion(string defaultRegion")
+
+	info.ProviderFactory.Key = "max_retries"
+	err := addProviderFactory(NodeJS, info, pkg, map[string][]byte{"provider.ts": nil})
+	assert.EqualError(t, err, `provider factory key "max_retries" must be a string property`)
+
+	info.ProviderFactory.Key = "missing"
+	err = addProviderFactory(NodeJS, info, pkg, map[string][]byte{"provider.ts": nil})
+	assert.EqualError(t, err, `provider factory key "missing" is not a provider configuration property`)
+}
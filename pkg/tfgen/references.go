@@ -0,0 +1,222 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// builtinRefs are the types defined by the Pulumi schema itself.
+var builtinRefs = map[string]bool{
+	"pulumi.json#/Any":     true,
+	"pulumi.json#/Archive": true,
+	"pulumi.json#/Asset":   true,
+	"pulumi.json#/Json":    true,
+}
+
+// hclBlockRegexp matches the header of an HCL resource or data source block, e.g. `resource "aws_vpc" "main" {`.
+var hclBlockRegexp = regexp.MustCompile(`(?m)^\s*(resource|data)\s+"([A-Za-z0-9_]+)"\s+"[^"]*"\s*\{`)
+
+// brokenReference is a single reference in the schema that does not resolve.
+type brokenReference struct {
+	Location string // the schema path of the reference, e.g. #/resources/pkg:index:Res/inputProperties/prop
+	Ref      string // the unresolved reference
+	Reason   string // why the reference does not resolve
+}
+
+func (r brokenReference) String() string {
+	return fmt.Sprintf("%s: %s (%s)", r.Location, r.Ref, r.Reason)
+}
+
+// brokenReferenceError reports every broken reference found in a schema.
+type brokenReferenceError struct {
+	References []brokenReference
+}
+
+func (e *brokenReferenceError) Error() string {
+	lines := make([]string, len(e.References))
+	for i, r := range e.References {
+		lines[i] = "\t" + r.String()
+	}
+	return fmt.Sprintf("%d broken reference(s) in the generated schema:\n%s", len(e.References),
+		strings.Join(lines, "\n"))
+}
+
+// referenceChecker accumulates the broken references in a schema.
+type referenceChecker struct {
+	spec   pschema.PackageSpec
+	info   tfbridge.ProviderInfo
+	broken []brokenReference
+}
+
+// checkReferences verifies that every local `$ref` in the given schema resolves to a type or resource it defines, and
+// that every mapped resource and data source used by the HCL examples in its docs maps to a token it defines. It must
+// run before examples are converted, while the docs still contain HCL.
+func checkReferences(spec pschema.PackageSpec, info tfbridge.ProviderInfo) error {
+	c := &referenceChecker{spec: spec, info: info}
+
+	for name, variable := range spec.Config.Variables {
+		c.checkProperty("#/config/variables/"+name, variable)
+	}
+	c.checkResource("#/provider", spec.Provider)
+	for token, res := range spec.Resources {
+		c.checkResource("#/resources/"+token, res)
+	}
+	for token, fun := range spec.Functions {
+		location := "#/functions/" + token
+		c.checkDescription(location, fun.Description)
+		if fun.Inputs != nil {
+			c.checkProperties(location+"/inputs/properties", fun.Inputs.Properties)
+		}
+		if fun.Outputs != nil {
+			c.checkProperties(location+"/outputs/properties", fun.Outputs.Properties)
+		}
+	}
+	for token, typ := range spec.Types {
+		location := "#/types/" + token
+		c.checkDescription(location, typ.Description)
+		c.checkProperties(location+"/properties", typ.Properties)
+	}
+
+	if len(c.broken) == 0 {
+		return nil
+	}
+	sort.Slice(c.broken, func(i, j int) bool {
+		if c.broken[i].Location != c.broken[j].Location {
+			return c.broken[i].Location < c.broken[j].Location
+		}
+		return c.broken[i].Ref < c.broken[j].Ref
+	})
+	return &brokenReferenceError{References: c.broken}
+}
+
+func (c *referenceChecker) checkResource(location string, res pschema.ResourceSpec) {
+	c.checkDescription(location, res.Description)
+	c.checkProperties(location+"/inputProperties", res.InputProperties)
+	c.checkProperties(location+"/properties", res.Properties)
+	if res.StateInputs != nil {
+		c.checkProperties(location+"/stateInputs/properties", res.StateInputs.Properties)
+	}
+}
+
+func (c *referenceChecker) checkProperties(location string, props map[string]pschema.PropertySpec) {
+	for name, prop := range props {
+		c.checkProperty(location+"/"+name, prop)
+	}
+}
+
+func (c *referenceChecker) checkProperty(location string, prop pschema.PropertySpec) {
+	c.checkDescription(location, prop.Description)
+	c.checkType(location, prop.TypeSpec)
+}
+
+func (c *referenceChecker) checkType(location string, typ pschema.TypeSpec) {
+	if typ.Ref != "" {
+		if reason := c.resolveRef(typ.Ref); reason != "" {
+			c.broken = append(c.broken, brokenReference{Location: location, Ref: typ.Ref, Reason: reason})
+		}
+	}
+	if typ.Items != nil {
+		c.checkType(location+"/items", *typ.Items)
+	}
+	if typ.AdditionalProperties != nil {
+		c.checkType(location+"/additionalProperties", *typ.AdditionalProperties)
+	}
+	for i, t := range typ.OneOf {
+		c.checkType(fmt.Sprintf("%s/oneOf/%d", location, i), t)
+	}
+}
+
+// resolveRef returns the reason the given reference does not resolve, or the empty string if it does. References to
+// other packages' schemas cannot be checked and are assumed to resolve.
+func (c *referenceChecker) resolveRef(ref string) string {
+	if builtinRefs[ref] {
+		return ""
+	}
+	if strings.HasPrefix(ref, "pulumi.json#") {
+		return "unknown built-in type"
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(ref, "#/types/"):
+		if _, ok := c.spec.Types[strings.TrimPrefix(ref, "#/types/")]; !ok {
+			return "no such type"
+		}
+	case strings.HasPrefix(ref, "#/resources/"):
+		if _, ok := c.spec.Resources[strings.TrimPrefix(ref, "#/resources/")]; !ok {
+			return "no such resource"
+		}
+	default:
+		return "references must refer to #/types/ or #/resources/"
+	}
+	return ""
+}
+
+// checkDescription checks that every resource and data source of this provider used by the HCL examples in the given
+// description maps to a token defined by the schema.
+func (c *referenceChecker) checkDescription(location, description string) {
+	prefix := c.info.GetResourcePrefix() + "_"
+	for _, m := range hclBlockRegexp.FindAllStringSubmatch(description, -1) {
+		kind, tfName := m[1], m[2]
+		if !strings.HasPrefix(tfName, prefix) {
+			// Examples commonly use resources from other providers, which we cannot check.
+			continue
+		}
+		ref := kind + " " + tfName
+		if reason := c.resolveExampleRef(kind, tfName); reason != "" {
+			c.broken = append(c.broken, brokenReference{Location: location, Ref: ref, Reason: reason})
+		}
+	}
+}
+
+func (c *referenceChecker) resolveExampleRef(kind, tfName string) string {
+	// Resources and data sources that are not mapped are already reported as warnings while gathering the package,
+	// so examples that use them are not treated as broken, and those with default tokens are always in the schema.
+	if kind == "resource" {
+		if c.info.P.ResourcesMap().Get(tfName) == nil {
+			return "the provider has no such resource"
+		}
+		res, ok := c.info.Resources[tfName]
+		if !ok || res == nil || res.Tok == "" {
+			return ""
+		}
+		if _, ok := c.spec.Resources[string(res.Tok)]; !ok {
+			return fmt.Sprintf("the resource's token %s is not in the schema", res.Tok)
+		}
+		return ""
+	}
+
+	if c.info.P.DataSourcesMap().Get(tfName) == nil {
+		return "the provider has no such data source"
+	}
+	ds, ok := c.info.DataSources[tfName]
+	if !ok || ds == nil || ds.Tok == "" {
+		return ""
+	}
+	if _, ok := c.spec.Functions[string(ds.Tok)]; !ok {
+		return fmt.Sprintf("the data source's token %s is not in the schema", ds.Tok)
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+func TestCheckReferences(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schema.Provider{
+			ResourcesMap: map[string]*schema.Resource{
+				"test_widget":   {},
+				"test_gadget":   {},
+				"test_unmapped": {},
+			},
+			DataSourcesMap: map[string]*schema.Resource{
+				"test_widget": {},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget"},
+			"test_gadget": {Tok: "test:index/gadget:Gadget"},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_widget": {Tok: "test:index/getWidget:getWidget"},
+		},
+	}
+
+	widgetRef := pschema.TypeSpec{Ref: "#/types/test:index/WidgetPart:WidgetPart"}
+	spec := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "```hcl\nresource \"test_widget\" \"a\" {}\nresource \"random_id\" \"b\" {}\n" +
+						"resource \"test_unmapped\" \"c\" {}\n```",
+					Properties: map[string]pschema.PropertySpec{
+						"part":  {TypeSpec: widgetRef},
+						"parts": {TypeSpec: pschema.TypeSpec{Type: "array", Items: &widgetRef}},
+						"any":   {TypeSpec: pschema.TypeSpec{Ref: "pulumi.json#/Any"}},
+						"other": {TypeSpec: pschema.TypeSpec{Ref: "/other/v1.0.0/schema.json#/types/other:index:Thing"}},
+					},
+				},
+			},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:index/WidgetPart:WidgetPart": {},
+		},
+	}
+	assert.NoError(t, checkReferences(spec, info))
+
+	spec.Resources["test:index/widget:Widget"].Properties["missing"] = pschema.PropertySpec{
+		TypeSpec: pschema.TypeSpec{
+			Type:                 "object",
+			AdditionalProperties: &pschema.TypeSpec{Ref: "#/types/test:index/Missing:Missing"},
+		},
+	}
+	spec.Resources["test:index/widget:Widget"].Properties["bad"] = pschema.PropertySpec{
+		TypeSpec: pschema.TypeSpec{Ref: "pulumi.json#/Nope"},
+	}
+	spec.Functions = map[string]pschema.FunctionSpec{
+		"test:index/getWidget:getWidget": {
+			Description: "```terraform\ndata \"test_widget\" \"a\" {}\nresource \"test_gadget\" \"b\" {}\n" +
+				"resource \"test_gizmo\" \"c\" {}\n```",
+		},
+	}
+	err := checkReferences(spec, info)
+	assert.EqualError(t, err, "4 broken reference(s) in the generated schema:\n"+
+		"\t#/functions/test:index/getWidget:getWidget: resource test_gadget "+
+		"(the resource's token test:index/gadget:Gadget is not in the schema)\n"+
+		"\t#/functions/test:index/getWidget:getWidget: resource test_gizmo (the provider has no such resource)\n"+
+		"\t#/resources/test:index/widget:Widget/properties/bad: pulumi.json#/Nope (unknown built-in type)\n"+
+		"\t#/resources/test:index/widget:Widget/properties/missing/additionalProperties: "+
+		"#/types/test:index/Missing:Missing (no such type)")
+}
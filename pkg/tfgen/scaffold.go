@@ -0,0 +1,499 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+	dotnetgen "github.com/pulumi/pulumi/pkg/v3/codegen/dotnet"
+	gogen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	pygen "github.com/pulumi/pulumi/pkg/v3/codegen/python"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// scaffoldLanguage describes how to scaffold an example program and its test in a single language.
+type scaffoldLanguage struct {
+	Dir      string // the name of the program's directory under the example's directory, e.g. ts
+	Runtime  string // the runtime named in the program's Pulumi.yaml
+	Suffix   string // appended to the example's name to form the test name, e.g. Ts
+	BuildTag string // the build tag that selects the language's tests, e.g. nodejs
+	Options  string // the provider repo's helper that returns the language's base test options
+
+	files map[string]*template.Template // the program's files, by name
+}
+
+// scaffoldInput is a required input property of a scaffolded resource.
+type scaffoldInput struct {
+	Name  string // the property's name in the language
+	Value string // a placeholder value, or "" if the value must be filled in by hand
+}
+
+// scaffoldData is the data used to render the files of a scaffolded example in a single language.
+type scaffoldData struct {
+	Tool     string
+	Package  string // the Pulumi package name
+	Token    string // the resource's token
+	Example  string // the example's directory name, e.g. s3-bucket
+	TestName string // the name of the example's test, e.g. TestAccS3BucketTs
+	Lang     scaffoldLanguage
+
+	Import   string // the module or package to import, e.g. @pulumi/aws or pulumi_aws
+	Resource string // the qualified resource type in the language, e.g. aws.s3.Bucket
+	Args     string // the qualified argument type in the language, if the language requires one
+	Inputs   []scaffoldInput
+}
+
+var scaffoldProjectTemplate = template.Must(template.New("Pulumi.yaml").Parse(`name: {{.Example}}-{{.Lang.Dir}}
+runtime: {{.Lang.Runtime}}
+description: An example of {{.Token}}, generated by {{.Tool}}.
+`))
+
+var scaffoldTestTemplate = template.Must(template.New("test.go").Parse(`// +build {{.Lang.BuildTag}} all
+
+package examples
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+)
+
+// {{.TestName}} was generated by {{.Tool}} when {{.Token}} was first mapped.
+func {{.TestName}}(t *testing.T) {
+	test := {{.Lang.Options}}(t).
+		With(integration.ProgramTestOptions{
+			Dir: filepath.Join(getCwd(t), "{{.Example}}", "{{.Lang.Dir}}"),
+		})
+
+	integration.ProgramTest(t, &test)
+}
+`))
+
+var scaffoldLanguages = map[Language]scaffoldLanguage{
+	NodeJS: {
+		Dir: "ts", Runtime: "nodejs", Suffix: "Ts", BuildTag: "nodejs", Options: "getJSBaseOptions",
+		files: map[string]*template.Template{
+			"index.ts": template.Must(template.New("index.ts").Parse(`import * as {{.Package}} from "{{.Import}}";
+
+const example = new {{.Resource}}("example", {
+{{- range .Inputs}}
+    {{if .Value}}{{.Name}}: {{.Value}},{{else}}// TODO: set {{.Name}}{{end}}
+{{- end}}
+});
+
+export const id = example.id;
+`)),
+			"package.json": template.Must(template.New("package.json").Parse(`{
+    "name": "{{.Example}}",
+    "devDependencies": {
+        "@types/node": "^10.0.0"
+    },
+    "dependencies": {
+        "@pulumi/pulumi": "^3.0.0",
+        "{{.Import}}": "latest"
+    }
+}
+`)),
+		},
+	},
+	Python: {
+		Dir: "py", Runtime: "python", Suffix: "Py", BuildTag: "python", Options: "getPythonBaseOptions",
+		files: map[string]*template.Template{
+			"__main__.py": template.Must(template.New("__main__.py").Parse(`import pulumi
+import {{.Import}} as {{.Package}}
+
+example = {{.Resource}}("example"
+{{- range .Inputs}}{{if .Value}}, {{.Name}}={{.Value}}{{end}}{{end}})
+{{- range .Inputs}}{{if not .Value}}
+# TODO: set {{.Name}}{{end}}{{end}}
+
+pulumi.export("id", example.id)
+`)),
+			"requirements.txt": template.Must(template.New("requirements.txt").Parse(`pulumi>=3.0.0,<4.0.0
+{{.Import}}
+`)),
+		},
+	},
+	Golang: {
+		Dir: "go", Runtime: "go", Suffix: "Go", BuildTag: "go", Options: "getGoBaseOptions",
+		files: map[string]*template.Template{
+			"main.go": template.Must(template.New("main.go").Parse(`package main
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"{{.Import}}"
+)
+
+func main() {
+	pulumi.Run(func(ctx *pulumi.Context) error {
+		example, err := {{.Resource}}(ctx, "example", &{{.Args}}{
+{{- range .Inputs}}
+			{{if .Value}}{{.Name}}: {{.Value}},{{else}}// TODO: set {{.Name}}{{end}}
+{{- end}}
+		})
+		if err != nil {
+			return err
+		}
+		ctx.Export("id", example.ID())
+		return nil
+	})
+}
+`)),
+		},
+	},
+	CSharp: {
+		Dir: "cs", Runtime: "dotnet", Suffix: "Cs", BuildTag: "dotnet", Options: "getCSBaseOptions",
+		files: map[string]*template.Template{
+			"Program.cs": template.Must(template.New("Program.cs").Parse(`using System.Collections.Generic;
+using System.Threading.Tasks;
+using Pulumi;
+
+class Program
+{
+    static Task<int> Main() => Deployment.RunAsync(() =>
+    {
+        var example = new {{.Resource}}("example", new {{.Args}}
+        {
+{{- range .Inputs}}
+            {{if .Value}}{{.Name}} = {{.Value}},{{else}}// TODO: set {{.Name}}{{end}}
+{{- end}}
+        });
+
+        return new Dictionary<string, object?>
+        {
+            { "id", example.Id },
+        };
+    });
+}
+`)),
+			"Example.csproj": template.Must(template.New("Example.csproj").Parse(`<Project Sdk="Microsoft.NET.Sdk">
+
+  <PropertyGroup>
+    <OutputType>Exe</OutputType>
+    <TargetFramework>netcoreapp3.1</TargetFramework>
+    <Nullable>enable</Nullable>
+  </PropertyGroup>
+
+  <ItemGroup>
+    <PackageReference Include="Pulumi" Version="3.*" />
+    <PackageReference Include="{{.Import}}" Version="*" />
+  </ItemGroup>
+
+</Project>
+`)),
+		},
+	},
+}
+
+// splitToken splits a resource token into its package, module and name, dropping the module's file component, e.g.
+// aws:s3/bucket:Bucket becomes aws, s3 and Bucket.
+func splitToken(token string) (string, string, string) {
+	parts := strings.Split(token, ":")
+	contract.Assertf(len(parts) == 3, "malformed token %q", token)
+	return parts[0], strings.Split(parts[1], "/")[0], parts[2]
+}
+
+// scaffoldExampleName returns the directory and test names for the example of the given resource token, e.g.
+// s3-bucket and S3Bucket for aws:s3/bucket:Bucket. Resources in the index module are named after the resource alone.
+func scaffoldExampleName(token string) (string, string) {
+	_, mod, name := splitToken(token)
+	var words []string
+	if mod != "index" {
+		words = append(words, mod)
+	}
+	var word []rune
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			words, word = append(words, string(word)), nil
+		}
+		word = append(word, r)
+	}
+	words = append(words, string(word))
+
+	titles := make([]string, len(words))
+	for i, w := range words {
+		words[i], titles[i] = strings.ToLower(w), strings.Title(w)
+	}
+	return strings.Join(words, "-"), strings.Join(titles, "")
+}
+
+// scaffoldPlaceholder returns a placeholder literal for a required input of the given type, or "" if the type has no
+// obvious placeholder.
+func scaffoldPlaceholder(lang Language, typ pschema.TypeSpec) string {
+	if typ.Ref != "" {
+		return ""
+	}
+	switch typ.Type {
+	case "string":
+		if lang == Golang {
+			return `pulumi.String("TODO")`
+		}
+		return `"TODO"`
+	case "integer":
+		if lang == Golang {
+			return "pulumi.Int(0)"
+		}
+		return "0"
+	case "number":
+		if lang == Golang {
+			return "pulumi.Float64(0)"
+		}
+		return "0"
+	case "boolean":
+		switch lang {
+		case Golang:
+			return "pulumi.Bool(false)"
+		case Python:
+			return "False"
+		}
+		return "false"
+	}
+	return ""
+}
+
+// scaffoldInputs returns the resource's required inputs in the given language, with placeholder values.
+func scaffoldInputs(lang Language, res pschema.ResourceSpec) []scaffoldInput {
+	required := append([]string(nil), res.RequiredInputs...)
+	sort.Strings(required)
+
+	inputs := make([]scaffoldInput, 0, len(required))
+	for _, name := range required {
+		input := scaffoldInput{Name: name, Value: scaffoldPlaceholder(lang, res.InputProperties[name].TypeSpec)}
+		switch lang {
+		case Python:
+			input.Name = pygen.PyName(name)
+		case Golang:
+			input.Name = gogen.Title(name)
+		case CSharp:
+			input.Name = dotnetgen.Title(name)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// scaffoldResource fills in the language-specific names used to construct the given resource.
+func scaffoldResource(lang Language, spec pschema.PackageSpec, token string, data *scaffoldData) error {
+	pkg, mod, name := splitToken(token)
+	switch lang {
+	case NodeJS:
+		data.Import = "@pulumi/" + pkg
+		data.Resource = pkg + "." + name
+		if mod != "index" {
+			data.Resource = pkg + "." + mod + "." + name
+		}
+	case Python:
+		var info pygen.PackageInfo
+		if err := unmarshalLanguageInfo(spec, "python", &info); err != nil {
+			return err
+		}
+		data.Import = info.PackageName
+		if data.Import == "" {
+			data.Import = "pulumi_" + strings.ReplaceAll(pkg, "-", "_")
+		}
+		data.Resource = pkg + "." + name
+		if mod != "index" {
+			data.Resource = pkg + "." + strings.ToLower(mod) + "." + name
+		}
+	case Golang:
+		var info gogen.GoPackageInfo
+		if err := unmarshalLanguageInfo(spec, "go", &info); err != nil {
+			return err
+		}
+		data.Import = info.ImportBasePath
+		if data.Import == "" {
+			data.Import = fmt.Sprintf("github.com/pulumi/pulumi-%s/sdk/go/%s", pkg, pkg)
+		}
+		goPkg := filepath.Base(data.Import)
+		if mod != "index" {
+			data.Import, goPkg = data.Import+"/"+strings.ToLower(mod), strings.ToLower(mod)
+		}
+		data.Resource, data.Args = goPkg+".New"+name, goPkg+"."+name+"Args"
+	case CSharp:
+		var info dotnetgen.CSharpPackageInfo
+		if err := unmarshalLanguageInfo(spec, "csharp", &info); err != nil {
+			return err
+		}
+		namespace := func(name string) string {
+			if ns, ok := info.Namespaces[name]; ok {
+				return ns
+			}
+			return dotnetgen.Title(name)
+		}
+		data.Import = "Pulumi." + namespace(pkg)
+		qualified := data.Import + "."
+		if mod != "index" {
+			qualified += namespace(mod) + "."
+		}
+		data.Resource, data.Args = qualified+name, qualified+name+"Args"
+	}
+	return nil
+}
+
+// unmarshalLanguageInfo reads a language's section of the schema, if any, into info.
+func unmarshalLanguageInfo(spec pschema.PackageSpec, lang string, info interface{}) error {
+	raw, ok := spec.Language[lang]
+	if !ok {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(raw, info), "reading the %s language options of the schema", lang)
+}
+
+// scaffoldExample renders the example programs and tests for the given resource, returning the generated files by
+// path relative to the examples directory.
+func scaffoldExample(spec pschema.PackageSpec, token string) (map[string][]byte, error) {
+	example, testName := scaffoldExampleName(token)
+	files := map[string][]byte{}
+
+	render := func(path string, tmpl *template.Template, data scaffoldData) error {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return errors.Wrapf(err, "rendering %s", path)
+		}
+		files[path] = buf.Bytes()
+		return nil
+	}
+
+	for _, lang := range []Language{NodeJS, Python, Golang, CSharp} {
+		l := scaffoldLanguages[lang]
+		data := scaffoldData{
+			Tool:     "the Pulumi Terraform Bridge (tfgen) Tool",
+			Package:  spec.Name,
+			Token:    token,
+			Example:  example,
+			TestName: "TestAcc" + testName + l.Suffix,
+			Lang:     l,
+			Inputs:   scaffoldInputs(lang, spec.Resources[token]),
+		}
+		if err := scaffoldResource(lang, spec, token, &data); err != nil {
+			return nil, err
+		}
+
+		dir := filepath.Join(example, l.Dir)
+		if err := render(filepath.Join(dir, "Pulumi.yaml"), scaffoldProjectTemplate, data); err != nil {
+			return nil, err
+		}
+		for name, tmpl := range l.files {
+			if err := render(filepath.Join(dir, name), tmpl, data); err != nil {
+				return nil, err
+			}
+		}
+		testFile := fmt.Sprintf("%s_%s_test.go", strings.ReplaceAll(example, "-", "_"), l.BuildTag)
+		if err := render(testFile, scaffoldTestTemplate, data); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// writeScaffolding writes the example programs and tests for every resource that is in next but not in prev to the
+// given examples directory, leaving any files that already exist untouched. It returns the paths it wrote.
+func writeScaffolding(examplesDir string, prev, next pschema.PackageSpec) ([]string, error) {
+	var written []string
+	for _, token := range diffSchemasForChangelog(prev, next).NewResources {
+		files, err := scaffoldExample(next, token)
+		if err != nil {
+			return nil, errors.Wrapf(err, "scaffolding an example for %s", token)
+		}
+
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			path, contents := filepath.Join(examplesDir, path), files[path]
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return nil, err
+			}
+			if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+				return nil, err
+			}
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+// newScaffoldTestsCmd creates the `scaffold-tests` subcommand, which generates example programs and integration
+// tests for the resources that have been mapped since a previous release.
+func newScaffoldTestsCmd(prov tfbridge.ProviderInfo) *cobra.Command {
+	var prevSchemaPath string
+	var nextSchemaPath string
+	var examplesDir string
+	cmd := &cobra.Command{
+		Use:   "scaffold-tests",
+		Args:  cmdutil.NoArgs,
+		Short: "Generate example programs and tests for newly mapped resources",
+		Long: "Generate example programs and tests for newly mapped resources.\n" +
+			"\n" +
+			"Every resource in the new schema that is not in --previous-schema gets an example program\n" +
+			"per language under --examples, with placeholders for its required inputs, and a Go test\n" +
+			"per language that runs it. The tests use the getCwd and get<Language>BaseOptions helpers\n" +
+			"that provider repositories define in their examples package. Existing files are never\n" +
+			"overwritten.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			prev, err := readPackageSpec(prevSchemaPath)
+			if err != nil {
+				return err
+			}
+
+			var next pschema.PackageSpec
+			if nextSchemaPath != "" {
+				if next, err = readPackageSpec(nextSchemaPath); err != nil {
+					return err
+				}
+			} else if next, err = GenerateSchema(prov, nil); err != nil {
+				return err
+			}
+
+			written, err := writeScaffolding(examplesDir, prev, next)
+			for _, path := range written {
+				fmt.Println(path)
+			}
+			return err
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&prevSchemaPath, "previous-schema", "", "The schema.json of the previous release")
+	cmd.PersistentFlags().StringVar(
+		&nextSchemaPath, "schema", "", "The schema.json of the new release (defaults to generating it)")
+	cmd.PersistentFlags().StringVar(
+		&examplesDir, "examples", "examples", "The directory to write the example programs and tests to")
+	contract.AssertNoError(cmd.MarkPersistentFlagRequired("previous-schema"))
+
+	return cmd
+}
@@ -0,0 +1,147 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffoldExampleName(t *testing.T) {
+	example, test := scaffoldExampleName("test:index/widget:Widget")
+	assert.Equal(t, "widget", example)
+	assert.Equal(t, "Widget", test)
+
+	example, test = scaffoldExampleName("aws:s3/bucketPolicy:BucketPolicy")
+	assert.Equal(t, "s3-bucket-policy", example)
+	assert.Equal(t, "S3BucketPolicy", test)
+}
+
+func TestWriteScaffolding(t *testing.T) {
+	prev := pschema.PackageSpec{
+		Name: "test",
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {},
+		},
+	}
+	next := pschema.PackageSpec{
+		Name: "test",
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index/widget:Widget": {},
+			"test:storage/bucketPolicy:BucketPolicy": {
+				InputProperties: map[string]pschema.PropertySpec{
+					"bucketName": {TypeSpec: pschema.TypeSpec{Type: "string"}},
+					"policy":     {TypeSpec: pschema.TypeSpec{Ref: "#/types/test:storage/Policy:Policy"}},
+					"versioned":  {TypeSpec: pschema.TypeSpec{Type: "boolean"}},
+				},
+				RequiredInputs: []string{"policy", "bucketName"},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "storage_bucket_policy_go_test.go"), []byte("custom"), 0600))
+
+	written, err := writeScaffolding(dir, prev, next)
+	assert.NoError(t, err)
+
+	var rel []string
+	for _, path := range written {
+		r, err := filepath.Rel(dir, path)
+		assert.NoError(t, err)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	assert.Equal(t, []string{
+		"storage-bucket-policy/cs/Example.csproj",
+		"storage-bucket-policy/cs/Program.cs",
+		"storage-bucket-policy/cs/Pulumi.yaml",
+		"storage-bucket-policy/go/Pulumi.yaml",
+		"storage-bucket-policy/go/main.go",
+		"storage-bucket-policy/py/Pulumi.yaml",
+		"storage-bucket-policy/py/__main__.py",
+		"storage-bucket-policy/py/requirements.txt",
+		"storage-bucket-policy/ts/Pulumi.yaml",
+		"storage-bucket-policy/ts/index.ts",
+		"storage-bucket-policy/ts/package.json",
+		"storage_bucket_policy_dotnet_test.go",
+		"storage_bucket_policy_nodejs_test.go",
+		"storage_bucket_policy_python_test.go",
+	}, rel)
+
+	read := func(path string) string {
+		bytes, err := ioutil.ReadFile(filepath.Join(dir, path))
+		assert.NoError(t, err)
+		return string(bytes)
+	}
+
+	// Existing files are left alone.
+	assert.Equal(t, "custom", read("storage_bucket_policy_go_test.go"))
+
+	assert.Equal(t, `import * as test from "@pulumi/test";
+
+const example = new test.storage.BucketPolicy("example", {
+    bucketName: "TODO",
+    // TODO: set policy
+});
+
+export const id = example.id;
+`, read("storage-bucket-policy/ts/index.ts"))
+
+	assert.Equal(t, `import pulumi
+import pulumi_test as test
+
+example = test.storage.BucketPolicy("example", bucket_name="TODO")
+# TODO: set policy
+
+pulumi.export("id", example.id)
+`, read("storage-bucket-policy/py/__main__.py"))
+
+	assert.Contains(t, read("storage-bucket-policy/go/main.go"), `
+		example, err := storage.NewBucketPolicy(ctx, "example", &storage.BucketPolicyArgs{
+			BucketName: pulumi.String("TODO"),
+			// TODO: set Policy
+		})`)
+	assert.Contains(t, read("storage-bucket-policy/go/main.go"), `"github.com/pulumi/pulumi-test/sdk/go/test/storage"`)
+
+	assert.Contains(t, read("storage-bucket-policy/cs/Program.cs"),
+		`var example = new Pulumi.Test.Storage.BucketPolicy("example", new Pulumi.Test.Storage.BucketPolicyArgs`)
+
+	assert.Equal(t, `// +build nodejs all
+
+package examples
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+)
+
+// TestAccStorageBucketPolicyTs was generated by the Pulumi Terraform Bridge (tfgen) Tool when `+
+		`test:storage/bucketPolicy:BucketPolicy was first mapped.
+func TestAccStorageBucketPolicyTs(t *testing.T) {
+	test := getJSBaseOptions(t).
+		With(integration.ProgramTestOptions{
+			Dir: filepath.Join(getCwd(t), "storage-bucket-policy", "ts"),
+		})
+
+	integration.ProgramTest(t, &test)
+}
+`, read("storage_bucket_policy_nodejs_test.go"))
+}
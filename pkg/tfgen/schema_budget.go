@@ -0,0 +1,181 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// schemaBudgetReportSize is the number of modules and members listed when the schema exceeds its budget.
+const schemaBudgetReportSize = 10
+
+// schemaBudget limits the size of the emitted schema, so that the registry and SDK artifacts built from it stay
+// within the limits of the platforms that host them.
+type schemaBudget struct {
+	MaxBytes int            // the maximum size of schema.json in bytes, or 0 for no limit.
+	Modules  map[string]int // the maximum size of each listed module in bytes.
+}
+
+func (b schemaBudget) empty() bool {
+	return b.MaxBytes == 0 && len(b.Modules) == 0
+}
+
+// schemaSizes records the size of a schema, along with the size of each of its modules and members. The size of a
+// module is the sum of the sizes of its resources, functions and types, each measured as compact JSON.
+type schemaSizes struct {
+	Total   int
+	Modules map[string]int
+	Members map[string]int // keyed by token.
+}
+
+// computeSchemaSizes measures the given schema, whose serialized form is total bytes long.
+func computeSchemaSizes(spec pschema.PackageSpec, total int) (*schemaSizes, error) {
+	sizes := &schemaSizes{Total: total, Modules: map[string]int{}, Members: map[string]int{}}
+	measure := func(token string, member interface{}) error {
+		bytes, err := json.Marshal(member)
+		if err != nil {
+			return errors.Wrapf(err, "failed to measure %s", token)
+		}
+		sizes.Members[token] = len(bytes)
+		sizes.Modules[tokenModule(token)] += len(bytes)
+		return nil
+	}
+
+	for token, res := range spec.Resources {
+		if err := measure(token, res); err != nil {
+			return nil, err
+		}
+	}
+	for token, fun := range spec.Functions {
+		if err := measure(token, fun); err != nil {
+			return nil, err
+		}
+	}
+	for token, typ := range spec.Types {
+		if err := measure(token, typ); err != nil {
+			return nil, err
+		}
+	}
+	return sizes, nil
+}
+
+// checkSchemaBudget returns an error if the schema's sizes exceed the budget. The error lists the modules and members
+// that grew the most since the previous release, or the largest ones if prev is nil.
+func checkSchemaBudget(budget schemaBudget, sizes, prev *schemaSizes) error {
+	var violations []string
+	if budget.MaxBytes > 0 && sizes.Total > budget.MaxBytes {
+		violations = append(violations, fmt.Sprintf("schema.json is %d bytes, over the budget of %d bytes",
+			sizes.Total, budget.MaxBytes))
+	}
+	modules := make([]string, 0, len(budget.Modules))
+	for module := range budget.Modules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		if max, size := budget.Modules[module], sizes.Modules[module]; size > max {
+			violations = append(violations, fmt.Sprintf("module %s is %d bytes, over its budget of %d bytes",
+				module, size, max))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	var prevModules, prevMembers map[string]int
+	heading := "largest %s:\n"
+	if prev != nil {
+		prevModules, prevMembers = prev.Modules, prev.Members
+		heading = "%s that grew the most since the previous release:\n"
+	}
+
+	var msg strings.Builder
+	msg.WriteString("the schema exceeds its size budget:\n")
+	for _, v := range violations {
+		msg.WriteString("\t" + v + "\n")
+	}
+	msg.WriteString(fmt.Sprintf(heading, "modules"))
+	writeSizeGrowth(&msg, sizes.Modules, prevModules)
+	msg.WriteString(fmt.Sprintf(heading, "resources, functions and types"))
+	writeSizeGrowth(&msg, sizes.Members, prevMembers)
+	return errors.New(strings.TrimSuffix(msg.String(), "\n"))
+}
+
+// writeSizeGrowth writes the entries of next that grew the most compared to prev, largest growth first.
+func writeSizeGrowth(w *strings.Builder, next, prev map[string]int) {
+	type growth struct {
+		name        string
+		size, delta int
+	}
+	var growths []growth
+	for name, size := range next {
+		if delta := size - prev[name]; delta > 0 {
+			growths = append(growths, growth{name, size, delta})
+		}
+	}
+	sort.Slice(growths, func(i, j int) bool {
+		if growths[i].delta != growths[j].delta {
+			return growths[i].delta > growths[j].delta
+		}
+		return growths[i].name < growths[j].name
+	})
+	if len(growths) > schemaBudgetReportSize {
+		growths = growths[:schemaBudgetReportSize]
+	}
+
+	if len(growths) == 0 {
+		w.WriteString("\t(none)\n")
+	}
+	for _, g := range growths {
+		name := g.name
+		if name == "" {
+			name = "(no module)"
+		}
+		w.WriteString(fmt.Sprintf("\t%s: +%d bytes (%d bytes)\n", name, g.delta, g.size))
+	}
+}
+
+// checkSchemaSize enforces the generator's schema budget on the given schema and its serialized form.
+func (g *Generator) checkSchemaSize(spec pschema.PackageSpec, bytes []byte) error {
+	if g.schemaBudget.empty() {
+		return nil
+	}
+	sizes, err := computeSchemaSizes(spec, len(bytes))
+	if err != nil {
+		return err
+	}
+
+	var prev *schemaSizes
+	if g.previousSchemaPath != "" {
+		prevSpec, err := readPackageSpec(g.previousSchemaPath)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the previous schema")
+		}
+		prevBytes, err := json.MarshalIndent(prevSpec, "", "    ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the previous schema")
+		}
+		if prev, err = computeSchemaSizes(prevSpec, len(prevBytes)); err != nil {
+			return err
+		}
+	}
+	return checkSchemaBudget(g.schemaBudget, sizes, prev)
+}
@@ -0,0 +1,80 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeSchemaSizes(t *testing.T) {
+	spec := pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{
+			"test:s3/bucket:Bucket": {ObjectTypeSpec: pschema.ObjectTypeSpec{Description: "A bucket."}},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:s3/getBucket:getBucket": {Description: "Gets a bucket."},
+		},
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:ec2/InstanceTag:InstanceTag": {ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "object"}},
+		},
+	}
+
+	sizes, err := computeSchemaSizes(spec, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, sizes.Total)
+	assert.Len(t, sizes.Members, 3)
+	assert.Equal(t, sizes.Members["test:s3/bucket:Bucket"]+sizes.Members["test:s3/getBucket:getBucket"],
+		sizes.Modules["s3"])
+	assert.Equal(t, sizes.Members["test:ec2/InstanceTag:InstanceTag"], sizes.Modules["ec2"])
+}
+
+func TestCheckSchemaBudget(t *testing.T) {
+	sizes := &schemaSizes{
+		Total:   1000,
+		Modules: map[string]int{"s3": 600, "ec2": 300},
+		Members: map[string]int{"test:s3/bucket:Bucket": 600, "test:ec2/instance:Instance": 300},
+	}
+	prev := &schemaSizes{
+		Total:   700,
+		Modules: map[string]int{"s3": 400, "ec2": 250},
+		Members: map[string]int{"test:s3/bucket:Bucket": 400, "test:ec2/instance:Instance": 250},
+	}
+
+	assert.NoError(t, checkSchemaBudget(schemaBudget{MaxBytes: 1000, Modules: map[string]int{"s3": 600}}, sizes, prev))
+
+	err := checkSchemaBudget(schemaBudget{MaxBytes: 900, Modules: map[string]int{"s3": 500}}, sizes, prev)
+	assert.EqualError(t, err, "the schema exceeds its size budget:\n"+
+		"\tschema.json is 1000 bytes, over the budget of 900 bytes\n"+
+		"\tmodule s3 is 600 bytes, over its budget of 500 bytes\n"+
+		"modules that grew the most since the previous release:\n"+
+		"\ts3: +200 bytes (600 bytes)\n"+
+		"\tec2: +50 bytes (300 bytes)\n"+
+		"resources, functions and types that grew the most since the previous release:\n"+
+		"\ttest:s3/bucket:Bucket: +200 bytes (600 bytes)\n"+
+		"\ttest:ec2/instance:Instance: +50 bytes (300 bytes)")
+
+	err = checkSchemaBudget(schemaBudget{MaxBytes: 900}, sizes, nil)
+	assert.EqualError(t, err, "the schema exceeds its size budget:\n"+
+		"\tschema.json is 1000 bytes, over the budget of 900 bytes\n"+
+		"largest modules:\n"+
+		"\ts3: +600 bytes (600 bytes)\n"+
+		"\tec2: +300 bytes (300 bytes)\n"+
+		"largest resources, functions and types:\n"+
+		"\ttest:s3/bucket:Bucket: +600 bytes (600 bytes)\n"+
+		"\ttest:ec2/instance:Instance: +300 bytes (300 bytes)")
+}
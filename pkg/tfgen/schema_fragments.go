@@ -0,0 +1,108 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// readSchemaFragment reads a hand-authored schema fragment from the given path. Files ending in `.yaml` or `.yml`
+// are parsed as YAML; all other files are parsed as JSON.
+func readSchemaFragment(path string) (pschema.PackageSpec, error) {
+	var fragment pschema.PackageSpec
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fragment, errors.Wrapf(err, "failed to read schema fragment %s", path)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &fragment)
+	default:
+		err = json.Unmarshal(contents, &fragment)
+	}
+	if err != nil {
+		return fragment, errors.Wrapf(err, "failed to parse schema fragment %s", path)
+	}
+	return fragment, nil
+}
+
+// mergeSchemaFragment merges the types, resources, and functions declared by a schema fragment into the generated
+// package spec. Members are merged in sorted token order, and it is an error for a fragment to declare a member
+// that already exists or whose token does not belong to the package.
+func mergeSchemaFragment(spec *pschema.PackageSpec, fragment pschema.PackageSpec) error {
+	checkToken := func(kind, token string) error {
+		if !strings.HasPrefix(token, spec.Name+":") {
+			return fmt.Errorf("%s %v does not belong to package %v", kind, token, spec.Name)
+		}
+		return nil
+	}
+
+	typeTokens := make([]string, 0, len(fragment.Types))
+	for token := range fragment.Types {
+		typeTokens = append(typeTokens, token)
+	}
+	sort.Strings(typeTokens)
+	for _, token := range typeTokens {
+		if err := checkToken("type", token); err != nil {
+			return err
+		}
+		if _, defined := spec.Types[token]; defined {
+			return fmt.Errorf("type %v is already defined", token)
+		}
+		spec.Types[token] = fragment.Types[token]
+	}
+
+	resourceTokens := make([]string, 0, len(fragment.Resources))
+	for token := range fragment.Resources {
+		resourceTokens = append(resourceTokens, token)
+	}
+	sort.Strings(resourceTokens)
+	for _, token := range resourceTokens {
+		if err := checkToken("resource", token); err != nil {
+			return err
+		}
+		if _, defined := spec.Resources[token]; defined {
+			return fmt.Errorf("resource %v is already defined", token)
+		}
+		spec.Resources[token] = fragment.Resources[token]
+	}
+
+	functionTokens := make([]string, 0, len(fragment.Functions))
+	for token := range fragment.Functions {
+		functionTokens = append(functionTokens, token)
+	}
+	sort.Strings(functionTokens)
+	for _, token := range functionTokens {
+		if err := checkToken("function", token); err != nil {
+			return err
+		}
+		if _, defined := spec.Functions[token]; defined {
+			return fmt.Errorf("function %v is already defined", token)
+		}
+		spec.Functions[token] = fragment.Functions[token]
+	}
+
+	return nil
+}
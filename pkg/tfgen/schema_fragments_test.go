@@ -0,0 +1,70 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeSchemaFragments(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "types.json")
+	assert.NoError(t, ioutil.WriteFile(jsonPath, []byte(`{
+  "types": {
+    "test:index/extra:Extra": {"type": "object", "properties": {"name": {"type": "string"}}}
+  }
+}`), 0600))
+
+	yamlPath := filepath.Join(dir, "functions.yaml")
+	assert.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+functions:
+  test:index/getExtra:getExtra:
+    description: Returns an extra.
+`), 0600))
+
+	spec := pschema.PackageSpec{
+		Name:      "test",
+		Types:     map[string]pschema.ComplexTypeSpec{},
+		Resources: map[string]pschema.ResourceSpec{"test:index/widget:Widget": {}},
+		Functions: map[string]pschema.FunctionSpec{},
+	}
+
+	for _, path := range []string{jsonPath, yamlPath} {
+		fragment, err := readSchemaFragment(path)
+		assert.NoError(t, err)
+		assert.NoError(t, mergeSchemaFragment(&spec, fragment))
+	}
+	assert.Contains(t, spec.Types, "test:index/extra:Extra")
+	assert.Equal(t, "string", spec.Types["test:index/extra:Extra"].Properties["name"].Type)
+	assert.Equal(t, "Returns an extra.", spec.Functions["test:index/getExtra:getExtra"].Description)
+
+	// Collisions with generated members are rejected.
+	err := mergeSchemaFragment(&spec, pschema.PackageSpec{
+		Resources: map[string]pschema.ResourceSpec{"test:index/widget:Widget": {}},
+	})
+	assert.EqualError(t, err, "resource test:index/widget:Widget is already defined")
+
+	// Members must belong to the package.
+	err = mergeSchemaFragment(&spec, pschema.PackageSpec{
+		Functions: map[string]pschema.FunctionSpec{"other:index/getThing:getThing": {}},
+	})
+	assert.EqualError(t, err, "function other:index/getThing:getThing does not belong to package test")
+}
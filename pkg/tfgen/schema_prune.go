@@ -0,0 +1,212 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// schemaPruner removes fields from a schema that are equivalent to leaving them out: descriptions that are only
+// whitespace, and empty strings, nulls, empty arrays and empty objects in language-specific metadata, whose decoders
+// read missing fields as the same zero values. Booleans and numbers are kept, as some languages default a missing
+// flag to true.
+type schemaPruner struct {
+	removed int // the number of fields removed
+}
+
+// pruneSchema prunes the given schema in place, and checks that the result is still a valid schema. It returns the
+// number of fields that were removed.
+func pruneSchema(spec *pschema.PackageSpec) (int, error) {
+	p := &schemaPruner{}
+	spec.Description = p.description(spec.Description)
+	spec.Language = p.language(spec.Language)
+	p.properties(spec.Config.Variables)
+	p.resource(&spec.Provider)
+	for token, res := range spec.Resources {
+		p.resource(&res)
+		spec.Resources[token] = res
+	}
+	for token, typ := range spec.Types {
+		p.objectType(&typ.ObjectTypeSpec)
+		for i, value := range typ.Enum {
+			typ.Enum[i].Description = p.description(value.Description)
+		}
+		spec.Types[token] = typ
+	}
+	for token, fun := range spec.Functions {
+		fun.Description = p.description(fun.Description)
+		fun.Language = p.language(fun.Language)
+		if fun.Inputs != nil {
+			p.objectType(fun.Inputs)
+		}
+		if fun.Outputs != nil {
+			p.objectType(fun.Outputs)
+		}
+		spec.Functions[token] = fun
+	}
+
+	if err := validatePrunedSchema(*spec); err != nil {
+		return p.removed, errors.Wrap(err, "the pruned schema is invalid")
+	}
+	return p.removed, nil
+}
+
+// validatePrunedSchema checks that the given schema is still valid. Types of other packages are not checked, as
+// loading them would start their plugins: external references are replaced by the Any type in a copy of the schema.
+func validatePrunedSchema(spec pschema.PackageSpec) error {
+	bytes, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	var local pschema.PackageSpec
+	if err = json.Unmarshal(bytes, &local); err != nil {
+		return err
+	}
+
+	var typ func(t *pschema.TypeSpec)
+	properties := func(props map[string]pschema.PropertySpec) {
+		for name, prop := range props {
+			typ(&prop.TypeSpec)
+			props[name] = prop
+		}
+	}
+	typ = func(t *pschema.TypeSpec) {
+		if t.Ref != "" && !strings.HasPrefix(t.Ref, "#/") && !strings.HasPrefix(t.Ref, "pulumi.json#/") {
+			*t = pschema.TypeSpec{Ref: "pulumi.json#/Any"}
+			return
+		}
+		if t.Items != nil {
+			typ(t.Items)
+		}
+		if t.AdditionalProperties != nil {
+			typ(t.AdditionalProperties)
+		}
+		for i := range t.OneOf {
+			typ(&t.OneOf[i])
+		}
+	}
+	resource := func(res *pschema.ResourceSpec) {
+		properties(res.Properties)
+		properties(res.InputProperties)
+		if res.StateInputs != nil {
+			properties(res.StateInputs.Properties)
+		}
+	}
+
+	properties(local.Config.Variables)
+	resource(&local.Provider)
+	for _, res := range local.Resources {
+		resource(&res)
+	}
+	for _, t := range local.Types {
+		properties(t.Properties)
+	}
+	for _, fun := range local.Functions {
+		if fun.Inputs != nil {
+			properties(fun.Inputs.Properties)
+		}
+		if fun.Outputs != nil {
+			properties(fun.Outputs.Properties)
+		}
+	}
+
+	_, err = pschema.ImportSpec(local, nil)
+	return err
+}
+
+func (p *schemaPruner) resource(res *pschema.ResourceSpec) {
+	p.objectType(&res.ObjectTypeSpec)
+	p.properties(res.InputProperties)
+	if res.StateInputs != nil {
+		p.objectType(res.StateInputs)
+	}
+}
+
+func (p *schemaPruner) objectType(typ *pschema.ObjectTypeSpec) {
+	typ.Description = p.description(typ.Description)
+	typ.Language = p.language(typ.Language)
+	p.properties(typ.Properties)
+}
+
+func (p *schemaPruner) properties(props map[string]pschema.PropertySpec) {
+	for name, prop := range props {
+		prop.Description = p.description(prop.Description)
+		prop.Language = p.language(prop.Language)
+		props[name] = prop
+	}
+}
+
+func (p *schemaPruner) description(description string) string {
+	if description != "" && strings.TrimSpace(description) == "" {
+		p.removed++
+		return ""
+	}
+	return description
+}
+
+// language prunes the empty values in the given language-specific metadata, and returns nil if none is left.
+func (p *schemaPruner) language(language map[string]pschema.RawMessage) map[string]pschema.RawMessage {
+	for lang, raw := range language {
+		// Decode numbers as json.Number, so that they are written back exactly as they were.
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			continue
+		}
+		pruned, ok := p.value(v)
+		if !ok {
+			p.removed++
+			delete(language, lang)
+			continue
+		}
+		if b, err := json.Marshal(pruned); err == nil {
+			language[lang] = b
+		}
+	}
+	if len(language) == 0 {
+		return nil
+	}
+	return language
+}
+
+// value returns the given JSON value with its empty values removed, and false if the value itself is empty.
+func (p *schemaPruner) value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return v, v != ""
+	case []interface{}:
+		return v, len(v) > 0
+	case map[string]interface{}:
+		for k, e := range v {
+			if pruned, ok := p.value(e); ok {
+				v[k] = pruned
+			} else {
+				p.removed++
+				delete(v, k)
+			}
+		}
+		return v, len(v) > 0
+	default:
+		return v, true
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneSchema(t *testing.T) {
+	spec := pschema.PackageSpec{
+		Name: "test",
+		Language: map[string]pschema.RawMessage{
+			"go":       pschema.RawMessage(`{"importBasePath": "", "generateResourceContainerTypes": false}`),
+			"csharp":   pschema.RawMessage(`{"packageReferences": {}, "namespaces": null}`),
+			"tfbridge": pschema.RawMessage(`{"upstreamModule": "", "protocolVersion": 5, "features": []}`),
+		},
+		Resources: map[string]pschema.ResourceSpec{
+			"test:index:Widget": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{
+					Description: "A widget.",
+					Properties: map[string]pschema.PropertySpec{
+						"size": {
+							TypeSpec:    pschema.TypeSpec{Type: "string"},
+							Description: "\n",
+							Language: map[string]pschema.RawMessage{
+								"python": pschema.RawMessage(`{"mapCase": false}`),
+								"csharp": pschema.RawMessage(`{"name": ""}`),
+							},
+						},
+					},
+				},
+				InputProperties: map[string]pschema.PropertySpec{
+					"size": {TypeSpec: pschema.TypeSpec{Type: "string"}, Default: ""},
+				},
+			},
+		},
+	}
+
+	removed, err := pruneSchema(&spec)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, removed)
+	assert.Equal(t, map[string]pschema.RawMessage{
+		"go":       pschema.RawMessage(`{"generateResourceContainerTypes":false}`),
+		"tfbridge": pschema.RawMessage(`{"protocolVersion":5}`),
+	}, spec.Language)
+
+	widget := spec.Resources["test:index:Widget"]
+	assert.Equal(t, "A widget.", widget.Description)
+	assert.Equal(t, "", widget.Properties["size"].Description)
+	assert.Equal(t, map[string]pschema.RawMessage{
+		"python": pschema.RawMessage(`{"mapCase":false}`),
+	}, widget.Properties["size"].Language)
+	// Defaults are values, not metadata, so empty ones are kept.
+	assert.Equal(t, "", widget.InputProperties["size"].Default)
+
+	// Types of other packages are not loaded to validate the pruned schema.
+	spec.Resources["test:index:Gizmo"] = pschema.ResourceSpec{InputProperties: map[string]pschema.PropertySpec{
+		"role": {TypeSpec: pschema.TypeSpec{Ref: "/other/v1.0.0/schema.json#/types/other:index:Role"}},
+	}}
+	_, err = pruneSchema(&spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "/other/v1.0.0/schema.json#/types/other:index:Role",
+		spec.Resources["test:index:Gizmo"].InputProperties["role"].Ref)
+
+	// Pruned schemas must still be valid.
+	spec.Resources["test:index:Gadget"] = pschema.ResourceSpec{InputProperties: map[string]pschema.PropertySpec{
+		"widget": {TypeSpec: pschema.TypeSpec{Type: "widget"}},
+	}}
+	_, err = pruneSchema(&spec)
+	assert.Error(t, err)
+}
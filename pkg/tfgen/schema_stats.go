@@ -0,0 +1,162 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"strings"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+)
+
+// schemaStats describes the shape of a generated schema, and is exported alongside the example coverage data in
+// order to track the complexity of a provider over time.
+type schemaStats struct {
+	TotalResources  int
+	TotalFunctions  int
+	TotalTypes      int
+	TotalEnums      int
+	MaxNestingDepth int                           // deepest chain of nested object types reachable from any member
+	Modules         map[string]*moduleSchemaStats // statistics for each module, keyed by module name
+}
+
+// moduleSchemaStats describes the shape of a single module within a generated schema.
+type moduleSchemaStats struct {
+	Resources  int
+	Functions  int
+	Types      int
+	Properties int // the number of input properties across all resources and functions in the module
+}
+
+const typeRefPrefix = "#/types/"
+
+// computeSchemaStats computes statistics about the shape of the given schema.
+func computeSchemaStats(spec pschema.PackageSpec) *schemaStats {
+	stats := &schemaStats{
+		TotalResources: len(spec.Resources),
+		TotalFunctions: len(spec.Functions),
+		Modules:        map[string]*moduleSchemaStats{},
+	}
+
+	module := func(token string) *moduleSchemaStats {
+		name := tokenModule(token)
+		m, ok := stats.Modules[name]
+		if !ok {
+			m = &moduleSchemaStats{}
+			stats.Modules[name] = m
+		}
+		return m
+	}
+
+	depths := map[string]int{}
+	for token, typ := range spec.Types {
+		if len(typ.Enum) != 0 {
+			stats.TotalEnums++
+		} else {
+			stats.TotalTypes++
+		}
+		module(token).Types++
+	}
+
+	maxDepth := func(props map[string]pschema.PropertySpec) {
+		if d := propertiesDepth(spec, props, depths, map[string]bool{}); d > stats.MaxNestingDepth {
+			stats.MaxNestingDepth = d
+		}
+	}
+
+	for token, res := range spec.Resources {
+		m := module(token)
+		m.Resources++
+		m.Properties += len(res.InputProperties)
+		maxDepth(res.InputProperties)
+		maxDepth(res.Properties)
+	}
+	for token, fun := range spec.Functions {
+		m := module(token)
+		m.Functions++
+		if fun.Inputs != nil {
+			m.Properties += len(fun.Inputs.Properties)
+			maxDepth(fun.Inputs.Properties)
+		}
+		if fun.Outputs != nil {
+			maxDepth(fun.Outputs.Properties)
+		}
+	}
+
+	return stats
+}
+
+// tokenModule returns the module portion of a token of the form `pkg:module/name:Name` or `pkg:module:Name`.
+func tokenModule(token string) string {
+	components := strings.Split(token, ":")
+	if len(components) != 3 {
+		return ""
+	}
+	mod := components[1]
+	if idx := strings.Index(mod, "/"); idx != -1 {
+		mod = mod[:idx]
+	}
+	return mod
+}
+
+// propertiesDepth returns the maximum depth of object types that are referenced by the given properties.
+func propertiesDepth(spec pschema.PackageSpec, props map[string]pschema.PropertySpec, depths map[string]int,
+	visiting map[string]bool) int {
+
+	depth := 0
+	for _, prop := range props {
+		if d := typeSpecDepth(spec, prop.TypeSpec, depths, visiting); d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+func typeSpecDepth(spec pschema.PackageSpec, ts pschema.TypeSpec, depths map[string]int,
+	visiting map[string]bool) int {
+
+	depth := 0
+	if ts.Items != nil {
+		depth = typeSpecDepth(spec, *ts.Items, depths, visiting)
+	}
+	if ts.AdditionalProperties != nil {
+		if d := typeSpecDepth(spec, *ts.AdditionalProperties, depths, visiting); d > depth {
+			depth = d
+		}
+	}
+	for _, t := range ts.OneOf {
+		if d := typeSpecDepth(spec, t, depths, visiting); d > depth {
+			depth = d
+		}
+	}
+
+	if !strings.HasPrefix(ts.Ref, typeRefPrefix) {
+		return depth
+	}
+	token := strings.TrimPrefix(ts.Ref, typeRefPrefix)
+	if d, ok := depths[token]; ok {
+		return d
+	}
+	typ, ok := spec.Types[token]
+	if !ok || visiting[token] || len(typ.Enum) != 0 {
+		return depth
+	}
+
+	visiting[token] = true
+	d := 1 + propertiesDepth(spec, typ.Properties, depths, visiting)
+	delete(visiting, token)
+
+	depths[token] = d
+	return d
+}
@@ -0,0 +1,69 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"testing"
+
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeSchemaStats(t *testing.T) {
+	ref := func(tok string) pschema.TypeSpec { return pschema.TypeSpec{Ref: "#/types/" + tok} }
+	object := func(props map[string]pschema.PropertySpec) pschema.ComplexTypeSpec {
+		return pschema.ComplexTypeSpec{ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "object", Properties: props}}
+	}
+
+	spec := pschema.PackageSpec{
+		Types: map[string]pschema.ComplexTypeSpec{
+			"test:compute/InstanceDisk:InstanceDisk": object(map[string]pschema.PropertySpec{
+				"encryption": {TypeSpec: ref("test:compute/InstanceDiskEncryption:InstanceDiskEncryption")},
+			}),
+			"test:compute/InstanceDiskEncryption:InstanceDiskEncryption": object(map[string]pschema.PropertySpec{
+				"key": {TypeSpec: pschema.TypeSpec{Type: "string"}},
+			}),
+			"test:compute/Size:Size": {
+				ObjectTypeSpec: pschema.ObjectTypeSpec{Type: "string"},
+				Enum:           []pschema.EnumValueSpec{{Value: "small"}},
+			},
+		},
+		Resources: map[string]pschema.ResourceSpec{
+			"test:compute/instance:Instance": {
+				InputProperties: map[string]pschema.PropertySpec{
+					"name": {TypeSpec: pschema.TypeSpec{Type: "string"}},
+					"disks": {TypeSpec: pschema.TypeSpec{
+						Type:  "array",
+						Items: &pschema.TypeSpec{Ref: "#/types/test:compute/InstanceDisk:InstanceDisk"},
+					}},
+				},
+			},
+		},
+		Functions: map[string]pschema.FunctionSpec{
+			"test:index/getZones:getZones": {
+				Inputs: &pschema.ObjectTypeSpec{Properties: map[string]pschema.PropertySpec{"region": {}}},
+			},
+		},
+	}
+
+	stats := computeSchemaStats(spec)
+	assert.Equal(t, 1, stats.TotalResources)
+	assert.Equal(t, 1, stats.TotalFunctions)
+	assert.Equal(t, 2, stats.TotalTypes)
+	assert.Equal(t, 1, stats.TotalEnums)
+	assert.Equal(t, 2, stats.MaxNestingDepth)
+	assert.Equal(t, &moduleSchemaStats{Resources: 1, Types: 3, Properties: 2}, stats.Modules["compute"])
+	assert.Equal(t, &moduleSchemaStats{Functions: 1, Properties: 1}, stats.Modules["index"])
+}
@@ -0,0 +1,433 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	pschema "github.com/pulumi/pulumi/pkg/v3/codegen/schema"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+// The kinds of features that upstream changelogs announce.
+const (
+	upstreamFeatureResource   = "resource"
+	upstreamFeatureDataSource = "dataSource"
+	upstreamFeatureAttribute  = "attribute"
+)
+
+var (
+	// upstreamChangelogHeadingRegexp matches the heading of a release, e.g. `## 4.50.0 (January 13, 2023)`.
+	upstreamChangelogHeadingRegexp = regexp.MustCompile(`^##\s+v?(\d+\.\d+\.\d+\S*)`)
+	// upstreamNewEntityRegexp matches new resources and data sources, e.g. "* **New Resource:** `aws_widget`".
+	upstreamNewEntityRegexp = regexp.MustCompile("^\\*\\s+\\*\\*New (Resource|Data Source):\\*\\*\\s*`([^`]+)`")
+	// upstreamEntityChangeRegexp matches changes to a resource or data source, e.g. "* resource/aws_widget: Add ...".
+	upstreamEntityChangeRegexp = regexp.MustCompile(`^\*\s+(resource|data[-_]source)/([A-Za-z0-9_]+):\s*(.*)$`)
+	// upstreamAddedRegexp matches the changes that add arguments or attributes.
+	upstreamAddedRegexp = regexp.MustCompile(`(?i)\badd(s|ed)?\b`)
+	// upstreamAttributeRegexp matches the names of the arguments and attributes that a change refers to.
+	upstreamAttributeRegexp = regexp.MustCompile("`([a-z][a-z0-9_]*)`")
+)
+
+// upstreamFeature is a resource, data source or attribute that an upstream release announced.
+type upstreamFeature struct {
+	Kind   string
+	TFName string // the resource or data source that was added, or that the attribute was added to
+	Name   string `json:"Name,omitempty"`   // the name of the attribute, if any
+	Owner  string `json:"Owner,omitempty"`  // the kind of TFName, if the feature is an attribute
+	Reason string `json:"Reason,omitempty"` // the reason why the feature is not exposed
+}
+
+// upstreamChangelogReport lists the features that an upstream release announced and that the bridged provider does
+// not expose.
+type upstreamChangelogReport struct {
+	Version   string
+	Announced int
+	Unexposed []upstreamFeature
+}
+
+// parseUpstreamChangelog returns the features that the given release announces in an upstream CHANGELOG that follows
+// the HashiCorp conventions, or those of the latest release if version is empty.
+func parseUpstreamChangelog(r io.Reader, version string) (string, []upstreamFeature, error) {
+	version = strings.TrimPrefix(version, "v")
+
+	var features []upstreamFeature
+	found, inRelease := "", false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := upstreamChangelogHeadingRegexp.FindStringSubmatch(line); m != nil {
+			if inRelease {
+				break
+			}
+			if version == "" || m[1] == version {
+				found, inRelease = m[1], true
+			}
+			continue
+		}
+		if !inRelease {
+			continue
+		}
+
+		if m := upstreamNewEntityRegexp.FindStringSubmatch(line); m != nil {
+			kind := upstreamFeatureResource
+			if m[1] == "Data Source" {
+				kind = upstreamFeatureDataSource
+			}
+			features = append(features, upstreamFeature{Kind: kind, TFName: m[2]})
+			continue
+		}
+		if m := upstreamEntityChangeRegexp.FindStringSubmatch(line); m != nil && upstreamAddedRegexp.MatchString(m[3]) {
+			owner := upstreamFeatureResource
+			if m[1] != "resource" {
+				owner = upstreamFeatureDataSource
+			}
+			seen := map[string]bool{}
+			for _, attr := range upstreamAttributeRegexp.FindAllStringSubmatch(m[3], -1) {
+				if attr[1] == m[2] || seen[attr[1]] {
+					continue
+				}
+				seen[attr[1]] = true
+				features = append(features, upstreamFeature{
+					Kind:   upstreamFeatureAttribute,
+					TFName: m[2],
+					Name:   attr[1],
+					Owner:  owner,
+				})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if found == "" {
+		if version == "" {
+			return "", nil, errors.New("the changelog does not list any releases")
+		}
+		return "", nil, errors.Errorf("the changelog does not list release %s", version)
+	}
+	return found, features, nil
+}
+
+// checkUpstreamFeatures cross-references the given upstream features against the schema generated for the provider,
+// and returns the number of features that were announced and those that the schema does not expose along with the
+// reason why. Changelogs quote blocks, values and other names as well as attributes, so only the names that resolve to
+// attributes of the linked upstream provider are counted as attributes.
+func checkUpstreamFeatures(prov tfbridge.ProviderInfo, spec pschema.PackageSpec,
+	features []upstreamFeature) (int, []upstreamFeature) {
+
+	var resources, dataSources shim.ResourceMap
+	if prov.P != nil {
+		resources, dataSources = prov.P.ResourcesMap(), prov.P.DataSourcesMap()
+	}
+
+	// entity returns the upstream schema, field infos and generated properties of the given resource or data source,
+	// or the reason why it is not exposed.
+	entity := func(kind, tfName string) (shim.Resource, map[string]*tfbridge.SchemaInfo,
+		[]map[string]pschema.PropertySpec, string) {
+
+		if kind == upstreamFeatureResource {
+			var res shim.Resource
+			if resources != nil {
+				res = resources.Get(tfName)
+			}
+			info := prov.Resources[tfName]
+			switch {
+			case res == nil:
+				return nil, nil, nil, "not in the linked upstream provider"
+			case info == nil || info.Tok == "":
+				return nil, nil, nil, "not mapped"
+			}
+			spec, ok := spec.Resources[string(info.Tok)]
+			if !ok {
+				return nil, nil, nil, fmt.Sprintf("%s is not in the schema", info.Tok)
+			}
+			return res, info.Fields, []map[string]pschema.PropertySpec{spec.InputProperties, spec.Properties}, ""
+		}
+
+		var ds shim.Resource
+		if dataSources != nil {
+			ds = dataSources.Get(tfName)
+		}
+		info := prov.DataSources[tfName]
+		switch {
+		case ds == nil:
+			return nil, nil, nil, "not in the linked upstream provider"
+		case info == nil || info.Tok == "":
+			return nil, nil, nil, "not mapped"
+		}
+		fun, ok := spec.Functions[string(info.Tok)]
+		if !ok {
+			return nil, nil, nil, fmt.Sprintf("%s is not in the schema", info.Tok)
+		}
+		var props []map[string]pschema.PropertySpec
+		if fun.Inputs != nil {
+			props = append(props, fun.Inputs.Properties)
+		}
+		if fun.Outputs != nil {
+			props = append(props, fun.Outputs.Properties)
+		}
+		return ds, info.Fields, props, ""
+	}
+
+	// upstream returns the upstream resource or data source of the given kind and name, if any.
+	upstream := func(kind, tfName string) shim.Resource {
+		m := resources
+		if kind != upstreamFeatureResource {
+			m = dataSources
+		}
+		if m == nil {
+			return nil
+		}
+		return m.Get(tfName)
+	}
+
+	announced := 0
+	var unexposed []upstreamFeature
+	for _, feature := range features {
+		switch feature.Kind {
+		case upstreamFeatureResource, upstreamFeatureDataSource:
+			announced++
+			if _, _, _, reason := entity(feature.Kind, feature.TFName); reason != "" {
+				feature.Reason = reason
+				unexposed = append(unexposed, feature)
+			}
+		case upstreamFeatureAttribute:
+			owner := upstream(feature.Owner, feature.TFName)
+			if owner == nil {
+				continue
+			}
+			if _, ok := upstreamAttributeNames(feature.Name, owner.Schema(), nil); !ok {
+				continue
+			}
+			announced++
+
+			// Attributes of resources and data sources that are not exposed are reported along with the reason why.
+			res, fields, props, reason := entity(feature.Owner, feature.TFName)
+			if reason == "" {
+				names, _ := upstreamAttributeNames(feature.Name, res.Schema(), fields)
+				if !schemaHasAnyProperty(spec, props, names) {
+					reason = "not in the schema"
+				}
+			} else {
+				reason = feature.TFName + " is " + reason
+			}
+			if reason != "" {
+				feature.Reason = reason
+				unexposed = append(unexposed, feature)
+			}
+		}
+	}
+	return announced, unexposed
+}
+
+// upstreamAttributeNames returns the Pulumi names that the attribute with the given name has anywhere in the given
+// schema, and false if the schema does not have the attribute at all. Omitted attributes have no Pulumi name, and
+// blocks are not attributes.
+func upstreamAttributeNames(attr string, tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) ([]string, bool) {
+	var names []string
+	found := false
+	var visit func(tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo)
+	visit = func(tfs shim.SchemaMap, infos map[string]*tfbridge.SchemaInfo) {
+		for _, key := range stableSchemas(tfs) {
+			sch, info := tfs.Get(key), infos[key]
+			if info != nil && info.Omit {
+				found = found || key == attr
+				continue
+			}
+			res, block := sch.Elem().(shim.Resource)
+			if key == attr && !block {
+				found = true
+				names = append(names, propertyName(key, sch, info))
+			}
+			if block {
+				var fields map[string]*tfbridge.SchemaInfo
+				if info != nil && info.Elem != nil {
+					fields = info.Elem.Fields
+				}
+				visit(res.Schema(), fields)
+			}
+		}
+	}
+	if tfs != nil {
+		visit(tfs, infos)
+	}
+	return names, found
+}
+
+// schemaHasAnyProperty returns true if any of the given names is a property of the given property maps, or of any
+// object type that they refer to.
+func schemaHasAnyProperty(spec pschema.PackageSpec, props []map[string]pschema.PropertySpec, names []string) bool {
+	if len(names) == 0 {
+		return false
+	}
+	visited := map[string]bool{}
+	var visitType func(typ pschema.TypeSpec) bool
+	var visitProps func(props map[string]pschema.PropertySpec) bool
+	visitType = func(typ pschema.TypeSpec) bool {
+		if typ.Ref != "" && strings.HasPrefix(typ.Ref, "#/types/") {
+			tok := strings.TrimPrefix(typ.Ref, "#/types/")
+			if visited[tok] {
+				return false
+			}
+			visited[tok] = true
+			if obj, ok := spec.Types[tok]; ok && visitProps(obj.Properties) {
+				return true
+			}
+		}
+		if typ.Items != nil && visitType(*typ.Items) {
+			return true
+		}
+		if typ.AdditionalProperties != nil && visitType(*typ.AdditionalProperties) {
+			return true
+		}
+		for _, t := range typ.OneOf {
+			if visitType(t) {
+				return true
+			}
+		}
+		return false
+	}
+	visitProps = func(props map[string]pschema.PropertySpec) bool {
+		for _, name := range names {
+			if _, ok := props[name]; ok {
+				return true
+			}
+		}
+		for _, name := range sortedKeys(props) {
+			if visitType(props[name].TypeSpec) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range props {
+		if visitProps(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeUpstreamChangelogReport renders the given report as text.
+func writeUpstreamChangelogReport(w io.Writer, report *upstreamChangelogReport) error {
+	if _, err := fmt.Fprintf(w, "Upstream %s announces %d feature(s), of which %d are not exposed\n",
+		report.Version, report.Announced, len(report.Unexposed)); err != nil {
+		return err
+	}
+	for _, feature := range report.Unexposed {
+		subject := feature.TFName
+		if feature.Name != "" {
+			subject += "." + feature.Name
+		}
+		if _, err := fmt.Fprintf(w, "%s %s: %s\n", feature.Kind, subject, feature.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newUpstreamChangelogCmd creates the `upstream-changelog` subcommand, which reports the resources, data sources and
+// attributes that an upstream release announces in its CHANGELOG but that the bridged provider does not expose.
+func newUpstreamChangelogCmd(prov tfbridge.ProviderInfo) *cobra.Command {
+	var changelogPath string
+	var schemaPath string
+	var version string
+	var jsonOutput, strict bool
+	cmd := &cobra.Command{
+		Use:   "upstream-changelog",
+		Args:  cmdutil.NoArgs,
+		Short: "Report upstream features that the provider does not expose yet",
+		Long: "Report upstream features that the provider does not expose yet.\n" +
+			"\n" +
+			"Reads the new resources, data sources and attributes that a release announces in the\n" +
+			"upstream CHANGELOG given by --changelog, and checks each of them against the schema read\n" +
+			"from --schema, or generated from the provider that this tool was built with. Features\n" +
+			"that are not mapped, or that the linked upstream provider lacks, are reported. Names\n" +
+			"that are not attributes of the linked upstream provider, e.g. blocks, are skipped. With\n" +
+			"--strict, the command exits with an error if it finds any.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(changelogPath)
+			if err != nil {
+				return err
+			}
+			defer contract.IgnoreClose(f)
+
+			if version == "" {
+				version = prov.TFProviderVersion
+			}
+			found, features, err := parseUpstreamChangelog(f, version)
+			if err != nil {
+				return errors.Wrapf(err, "reading %s", changelogPath)
+			}
+
+			var spec pschema.PackageSpec
+			if schemaPath != "" {
+				if spec, err = readPackageSpec(schemaPath); err != nil {
+					return err
+				}
+			} else if spec, err = GenerateSchema(prov, nil); err != nil {
+				return err
+			}
+
+			announced, unexposed := checkUpstreamFeatures(prov, spec, features)
+			report := &upstreamChangelogReport{Version: found, Announced: announced, Unexposed: unexposed}
+			sort.SliceStable(report.Unexposed, func(i, j int) bool {
+				return report.Unexposed[i].TFName < report.Unexposed[j].TFName
+			})
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else if err := writeUpstreamChangelogReport(os.Stdout, report); err != nil {
+				return err
+			}
+
+			if strict && len(report.Unexposed) != 0 {
+				return errors.Errorf("found %d unexposed upstream feature(s)", len(report.Unexposed))
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&changelogPath, "changelog", "", "The CHANGELOG.md of the upstream provider")
+	cmd.PersistentFlags().StringVar(
+		&schemaPath, "schema", "", "The schema.json to check (defaults to generating it)")
+	cmd.PersistentFlags().StringVar(
+		&version, "version", "", "The upstream release to check (defaults to TFProviderVersion, or the latest)")
+	cmd.PersistentFlags().BoolVar(
+		&jsonOutput, "json", false, "Emit the report as JSON rather than as text")
+	cmd.PersistentFlags().BoolVar(
+		&strict, "strict", false, "Exit with an error if any upstream features are not exposed")
+	contract.AssertNoError(cmd.MarkPersistentFlagRequired("changelog"))
+
+	return cmd
+}
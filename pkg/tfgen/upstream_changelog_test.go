@@ -0,0 +1,148 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	schemav2 "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+const testUpstreamChangelog = `## 1.3.0 (Unreleased)
+
+FEATURES:
+
+* **New Resource:** ` + "`test_unreleased`" + `
+
+## 1.2.0 (January 13, 2023)
+
+FEATURES:
+
+* **New Data Source:** ` + "`test_gadget`" + ` ([#12](https://example.com/12))
+* **New Resource:** ` + "`test_gadget`" + ` ([#12](https://example.com/12))
+* **New Resource:** ` + "`test_gizmo`" + ` ([#13](https://example.com/13))
+
+ENHANCEMENTS:
+
+* resource/test_widget: Add ` + "`color` and `size`" + ` arguments ([#14](https://example.com/14))
+* resource/test_widget: Add ` + "`port`" + ` argument to the ` + "`settings`" + ` configuration block
+* data-source/test_gadget: Added ` + "`serial`" + ` attribute
+* resource/test_widget: Add ` + "`secret`" + ` argument
+
+BUG FIXES:
+
+* resource/test_widget: Fix crash when ` + "`name`" + ` is empty
+
+## 1.1.0 (December 1, 2022)
+
+* **New Resource:** ` + "`test_widget`" + `
+`
+
+func TestParseUpstreamChangelog(t *testing.T) {
+	version, features, err := parseUpstreamChangelog(strings.NewReader(testUpstreamChangelog), "v1.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0", version)
+	assert.Equal(t, []upstreamFeature{
+		{Kind: upstreamFeatureDataSource, TFName: "test_gadget"},
+		{Kind: upstreamFeatureResource, TFName: "test_gadget"},
+		{Kind: upstreamFeatureResource, TFName: "test_gizmo"},
+		{Kind: upstreamFeatureAttribute, TFName: "test_widget", Name: "color", Owner: upstreamFeatureResource},
+		{Kind: upstreamFeatureAttribute, TFName: "test_widget", Name: "size", Owner: upstreamFeatureResource},
+		{Kind: upstreamFeatureAttribute, TFName: "test_widget", Name: "port", Owner: upstreamFeatureResource},
+		{Kind: upstreamFeatureAttribute, TFName: "test_widget", Name: "settings", Owner: upstreamFeatureResource},
+		{Kind: upstreamFeatureAttribute, TFName: "test_gadget", Name: "serial", Owner: upstreamFeatureDataSource},
+		{Kind: upstreamFeatureAttribute, TFName: "test_widget", Name: "secret", Owner: upstreamFeatureResource},
+	}, features)
+
+	// The latest release is read if no version is given.
+	version, features, err = parseUpstreamChangelog(strings.NewReader(testUpstreamChangelog), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0", version)
+	assert.Len(t, features, 1)
+
+	_, _, err = parseUpstreamChangelog(strings.NewReader(testUpstreamChangelog), "2.0.0")
+	assert.EqualError(t, err, "the changelog does not list release 2.0.0")
+}
+
+func TestCheckUpstreamFeatures(t *testing.T) {
+	info := tfbridge.ProviderInfo{
+		Name: "test",
+		P: shimv2.NewProvider(&schemav2.Provider{
+			ResourcesMap: map[string]*schemav2.Resource{
+				"test_widget": {Schema: map[string]*schemav2.Schema{
+					"color":  {Type: schemav2.TypeString, Optional: true},
+					"secret": {Type: schemav2.TypeString, Computed: true},
+					"settings": {
+						Type:     schemav2.TypeList,
+						Optional: true,
+						MaxItems: 1,
+						Elem: &schemav2.Resource{Schema: map[string]*schemav2.Schema{
+							"port": {Type: schemav2.TypeInt, Optional: true},
+						}},
+					},
+				}},
+				"test_gadget": {Schema: map[string]*schemav2.Schema{
+					"name": {Type: schemav2.TypeString, Optional: true},
+				}},
+			},
+			DataSourcesMap: map[string]*schemav2.Resource{
+				"test_gadget": {Schema: map[string]*schemav2.Schema{
+					"serial": {Type: schemav2.TypeString, Computed: true},
+				}},
+			},
+		}),
+		Resources: map[string]*tfbridge.ResourceInfo{
+			"test_widget": {Tok: "test:index/widget:Widget", Fields: map[string]*tfbridge.SchemaInfo{
+				"secret": {Omit: true},
+			}},
+		},
+		DataSources: map[string]*tfbridge.DataSourceInfo{
+			"test_gadget": {Tok: "test:index/getGadget:getGadget"},
+		},
+	}
+	spec := genTestSchema(t, info)
+
+	version, features, err := parseUpstreamChangelog(strings.NewReader(testUpstreamChangelog), "1.2.0")
+	assert.NoError(t, err)
+	announced, unexposed := checkUpstreamFeatures(info, spec, features)
+	report := &upstreamChangelogReport{Version: version, Announced: announced, Unexposed: unexposed}
+
+	// Only names that are attributes upstream are checked: the settings block and size, which the linked upstream
+	// provider lacks, are skipped.
+	assert.Equal(t, []upstreamFeature{
+		{Kind: upstreamFeatureResource, TFName: "test_gadget", Reason: "not mapped"},
+		{Kind: upstreamFeatureResource, TFName: "test_gizmo", Reason: "not in the linked upstream provider"},
+		{
+			Kind:   upstreamFeatureAttribute,
+			TFName: "test_widget",
+			Name:   "secret",
+			Owner:  upstreamFeatureResource,
+			Reason: "not in the schema",
+		},
+	}, report.Unexposed)
+
+	var buf bytes.Buffer
+	assert.NoError(t, writeUpstreamChangelogReport(&buf, report))
+	assert.Equal(t, "Upstream 1.2.0 announces 7 feature(s), of which 3 are not exposed\n"+
+		"resource test_gadget: not mapped\n"+
+		"resource test_gizmo: not in the linked upstream provider\n"+
+		"attribute test_widget.secret: not in the schema\n", buf.String())
+}
@@ -0,0 +1,303 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/codegen/hcl2"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfbridge"
+)
+
+// WorkspaceProvider is a bridged provider that can be generated as part of a tfgen workspace.
+type WorkspaceProvider struct {
+	Package string                // the Pulumi package name (e.g. `gcp`).
+	Version string                // the package version.
+	Info    tfbridge.ProviderInfo // the provider info for customizing code generation.
+}
+
+// workspaceManifest lists the providers to generate in a workspace, and where to generate them.
+type workspaceManifest struct {
+	Providers []workspaceManifestEntry `json:"providers" yaml:"providers"`
+}
+
+// workspaceManifestEntry describes the generation of a single provider in a workspace.
+type workspaceManifestEntry struct {
+	Name      string     `json:"name" yaml:"name"`           // the Pulumi package name of a WorkspaceProvider.
+	Out       string     `json:"out" yaml:"out"`             // the directory to emit <out>/<language> into.
+	Languages []Language `json:"languages" yaml:"languages"` // the languages to generate, in order.
+}
+
+// readWorkspaceManifest reads and validates a workspace manifest. The manifest is YAML, of which JSON is a subset.
+// Relative output directories are resolved against the directory that contains the manifest.
+func readWorkspaceManifest(path string, providers map[string]WorkspaceProvider) (*workspaceManifest, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest workspaceManifest
+	if err = yaml.Unmarshal(contents, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "parsing workspace manifest %s", path)
+	}
+	if len(manifest.Providers) == 0 {
+		return nil, errors.Errorf("workspace manifest %s lists no providers", path)
+	}
+
+	seen := map[string]bool{}
+	for i, entry := range manifest.Providers {
+		if _, ok := providers[entry.Name]; !ok {
+			return nil, errors.Errorf("workspace manifest %s: unknown provider %q", path, entry.Name)
+		}
+		if seen[entry.Name] {
+			return nil, errors.Errorf("workspace manifest %s: provider %q is listed more than once", path, entry.Name)
+		}
+		seen[entry.Name] = true
+
+		if len(entry.Languages) == 0 {
+			return nil, errors.Errorf("workspace manifest %s: provider %q lists no languages", path, entry.Name)
+		}
+
+		if entry.Out == "" {
+			entry.Out = filepath.Join(entry.Name, defaultOutDir)
+		}
+		if !filepath.IsAbs(entry.Out) {
+			entry.Out = filepath.Join(filepath.Dir(path), entry.Out)
+		}
+		manifest.Providers[i] = entry
+	}
+	return &manifest, nil
+}
+
+// workspace generates several providers in a single process, sharing the plugin host and package cache between them
+// so that schemas loaded for one provider's examples are reused for the next.
+type workspace struct {
+	providers    map[string]WorkspaceProvider
+	sink         diag.Sink
+	pluginHost   plugin.Host
+	packageCache *hcl2.PackageCache
+	debug        bool
+	skipDocs     bool
+	skipExamples bool
+	exampleCache string // the directory in which converted examples are cached, if any
+}
+
+// generate generates every provider in the manifest, in order. If trackCoverage is set, example coverage is
+// collected for each provider and returned keyed by package name.
+func (w *workspace) generate(manifest *workspaceManifest, trackCoverage bool) (map[string]*CoverageTracker, error) {
+	trackers := map[string]*CoverageTracker{}
+	for _, entry := range manifest.Providers {
+		prov := w.providers[entry.Name]
+
+		var tracker *CoverageTracker
+		if trackCoverage {
+			tracker = newCoverageTracker(prov.Info.Name, prov.Info.Version)
+			trackers[entry.Name] = tracker
+		}
+
+		for i, lang := range entry.Languages {
+			outDir := filepath.Join(entry.Out, string(lang))
+			if err := os.MkdirAll(outDir, 0700); err != nil {
+				return nil, err
+			}
+
+			// Examples are converted once per language, so only the first language's conversions are tracked to
+			// avoid counting each example several times.
+			langTracker := tracker
+			if i > 0 {
+				langTracker = nil
+			}
+
+			glog.V(3).Infof("workspace: generating %s for %s into %s", lang, entry.Name, outDir)
+			g, err := NewGenerator(GeneratorOptions{
+				Package:         prov.Package,
+				Version:         prov.Version,
+				Language:        lang,
+				ProviderInfo:    prov.Info,
+				Root:            afero.NewBasePathFs(afero.NewOsFs(), outDir),
+				PluginHost:      w.pluginHost,
+				Sink:            w.sink,
+				Debug:           w.debug,
+				SkipDocs:        w.skipDocs,
+				SkipExamples:    w.skipExamples,
+				CoverageTracker: langTracker,
+				ExampleCacheDir: w.exampleCache,
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "creating generator for %s (%s)", entry.Name, lang)
+			}
+			g.packageCache = w.packageCache
+
+			if err = g.Generate(); err != nil {
+				return nil, errors.Wrapf(err, "generating %s for %s", lang, entry.Name)
+			}
+		}
+	}
+	return trackers, nil
+}
+
+// mergeCoverageTrackers combines the coverage of several providers into a single tracker. Example names are prefixed
+// with the name of the provider they came from, so that examples with the same name in different providers are kept
+// apart.
+func mergeCoverageTrackers(name string, trackers map[string]*CoverageTracker) *CoverageTracker {
+	merged := newCoverageTracker(name, "")
+	for pkg, tracker := range trackers {
+		for exampleName, example := range tracker.EncounteredExamples {
+			merged.EncounteredExamples[fmt.Sprintf("%s:%s", pkg, exampleName)] = example
+		}
+	}
+	return merged
+}
+
+// exportWorkspaceCoverage exports the coverage of each provider, and the combined coverage of all of them, to each of
+// the given sinks. File sinks write the coverage of each provider into its own subdirectory, and the combined coverage
+// into their directory itself.
+func exportWorkspaceCoverage(sinks []CoverageSink, trackers map[string]*CoverageTracker) error {
+	pkgs := make([]string, 0, len(trackers))
+	for pkg := range trackers {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	for _, sink := range sinks {
+		for _, pkg := range pkgs {
+			providerSink := sink
+			if fileSink, ok := sink.(*fileCoverageSink); ok {
+				providerSink = NewFileCoverageSink(filepath.Join(fileSink.dir, pkg))
+			}
+			if err := trackers[pkg].exportResults(providerSink); err != nil {
+				return errors.Wrapf(err, "exporting coverage for %s", pkg)
+			}
+		}
+		if err := mergeCoverageTrackers("workspace", trackers).exportResults(sink); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MainWorkspace executes TFGen in workspace mode: it generates every provider listed in a manifest in one process,
+// sharing caches between them and reporting their combined example coverage.
+func MainWorkspace(providers []WorkspaceProvider) {
+	if err := newWorkspaceCmd(providers).Execute(); err != nil {
+		_, fmterr := fmt.Fprintf(os.Stderr, "An error occurred: %v\n", err)
+		contract.IgnoreError(fmterr)
+		os.Exit(-1)
+	}
+}
+
+func newWorkspaceCmd(providers []WorkspaceProvider) *cobra.Command {
+	var debug bool
+	var skipDocs bool
+	var skipExamples bool
+	var exampleCacheDir string
+	cmd := &cobra.Command{
+		Use:   os.Args[0] + " <MANIFEST>",
+		Args:  cmdutil.SpecificArgs([]string{"manifest"}),
+		Short: "Generate Pulumi package metadata for several Terraform providers at once",
+		Long: "Generate Pulumi package metadata for several Terraform providers at once.\n" +
+			"\n" +
+			"<MANIFEST> is a YAML or JSON file listing the providers to generate, e.g.:\n" +
+			"\n" +
+			"    providers:\n" +
+			"      - name: aws\n" +
+			"        out: ../pulumi-aws/sdk\n" +
+			"        languages: [schema, nodejs, python, dotnet, go]\n" +
+			"\n" +
+			"Each language is emitted into <out>/<language>. Relative paths are resolved against the\n" +
+			"directory containing the manifest. If COVERAGE_OUTPUT_DIR is set, the coverage of each\n" +
+			"provider is exported into a subdirectory named after it, and the combined coverage of all\n" +
+			"providers is exported into COVERAGE_OUTPUT_DIR itself.\n",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			byName := map[string]WorkspaceProvider{}
+			for _, prov := range providers {
+				byName[prov.Package] = prov
+			}
+
+			manifest, err := readWorkspaceManifest(args[0], byName)
+			if err != nil {
+				return err
+			}
+
+			cmdutil.InitDiag(diag.FormatOptions{
+				Color: cmdutil.GetGlobalColorization(),
+				Debug: debug,
+			})
+			sink := cmdutil.Diag()
+
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			ctx, err := plugin.NewContext(sink, sink, nil, nil, cwd, nil, false, nil)
+			if err != nil {
+				return err
+			}
+			defer contract.IgnoreClose(ctx)
+
+			w := &workspace{
+				providers:    byName,
+				sink:         sink,
+				pluginHost:   ctx.Host,
+				packageCache: hcl2.NewPackageCache(),
+				debug:        debug,
+				skipDocs:     skipDocs,
+				skipExamples: skipExamples,
+				exampleCache: exampleCacheDir,
+			}
+
+			coverageSinks, err := coverageSinksFromEnv()
+			if err != nil {
+				return err
+			}
+			trackers, err := w.generate(manifest, len(coverageSinks) > 0)
+			if err != nil {
+				return err
+			}
+			if len(coverageSinks) > 0 {
+				return exportWorkspaceCoverage(coverageSinks, trackers)
+			}
+			return nil
+		}),
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			glog.Flush()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false, "Enable debug logging")
+	cmd.PersistentFlags().BoolVar(
+		&skipDocs, "skip-docs", false, "Do not convert docs from TF Markdown")
+	cmd.PersistentFlags().BoolVar(
+		&skipExamples, "skip-examples", false, "Do not convert examples from HCL")
+	cmd.PersistentFlags().StringVar(
+		&exampleCacheDir, "example-cache-dir", os.Getenv(exampleCacheDirEnvVar),
+		"Cache converted examples in this directory, which may be shared with other runs")
+
+	return cmd
+}
@@ -0,0 +1,78 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tfgen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWorkspaceManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workspace")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	providers := map[string]WorkspaceProvider{"aws": {Package: "aws"}, "gcp": {Package: "gcp"}}
+	write := func(contents string) string {
+		path := filepath.Join(dir, "workspace.yaml")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+
+	manifest, err := readWorkspaceManifest(write(`
+providers:
+  - name: aws
+    out: aws/sdk
+    languages: [schema, nodejs]
+  - name: gcp
+    out: /tmp/gcp
+    languages: [go]
+`), providers)
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Providers, 2)
+	assert.Equal(t, filepath.Join(dir, "aws", "sdk"), manifest.Providers[0].Out)
+	assert.Equal(t, []Language{Schema, NodeJS}, manifest.Providers[0].Languages)
+	assert.Equal(t, "/tmp/gcp", manifest.Providers[1].Out)
+
+	_, err = readWorkspaceManifest(write(`{"providers": [{"name": "azure", "languages": ["go"]}]}`), providers)
+	assert.EqualError(t, err, "workspace manifest "+filepath.Join(dir, "workspace.yaml")+`: unknown provider "azure"`)
+
+	_, err = readWorkspaceManifest(write(`{"providers": [{"name": "aws"}]}`), providers)
+	assert.Error(t, err)
+
+	_, err = readWorkspaceManifest(write(`{"providers": []}`), providers)
+	assert.Error(t, err)
+}
+
+func TestMergeCoverageTrackers(t *testing.T) {
+	aws := newCoverageTracker("aws", "1.0.0")
+	aws.foundExample("#/resources/example", "")
+	aws.languageConversionSuccess("python")
+
+	gcp := newCoverageTracker("gcp", "2.0.0")
+	gcp.foundExample("#/resources/example", "")
+	gcp.languageConversionFailure("python", nil)
+
+	merged := mergeCoverageTrackers("workspace", map[string]*CoverageTracker{"aws": aws, "gcp": gcp})
+	assert.Len(t, merged.EncounteredExamples, 2)
+	assert.Equal(t, Success,
+		merged.EncounteredExamples["aws:#/resources/example"].LanguagesConvertedTo["python"].FailureSeverity)
+	assert.Equal(t, Failure,
+		merged.EncounteredExamples["gcp:#/resources/example"].LanguagesConvertedTo["python"].FailureSeverity)
+}
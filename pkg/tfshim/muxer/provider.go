@@ -0,0 +1,379 @@
+// Package muxer combines several shimmed providers into a single provider, in the same way that
+// terraform-plugin-mux combines SDKv2 and plugin-framework providers upstream. The combined provider exposes the
+// union of its providers' resources and data sources, routing each operation to the provider that defines the
+// resource or data source, and the merged configuration schema of all of them.
+package muxer
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+)
+
+var _ = shim.Provider(&provider{})
+
+type provider struct {
+	providers []shim.Provider
+
+	schema      schema.SchemaMap
+	resources   schema.ResourceMap
+	dataSources schema.ResourceMap
+
+	resourceOwners   map[string]shim.Provider
+	dataSourceOwners map[string]shim.Provider
+}
+
+// NewProvider returns a provider that combines the given providers. Every resource and data source must be defined by
+// exactly one of the providers, and configuration properties that are defined by more than one provider must have
+// the same type in each.
+func NewProvider(providers ...shim.Provider) (shim.Provider, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one provider is required")
+	}
+
+	p := &provider{
+		providers:        providers,
+		schema:           schema.SchemaMap{},
+		resources:        schema.ResourceMap{},
+		dataSources:      schema.ResourceMap{},
+		resourceOwners:   map[string]shim.Provider{},
+		dataSourceOwners: map[string]shim.Provider{},
+	}
+
+	var err error
+	for _, sub := range providers {
+		sub.Schema().Range(func(key string, value shim.Schema) bool {
+			if existing, ok := p.schema[key]; ok {
+				if existing.Type() != value.Type() {
+					err = multierror.Append(err, fmt.Errorf("configuration property %q has conflicting types", key))
+				}
+				return true
+			}
+			p.schema[key] = value
+			return true
+		})
+		sub.ResourcesMap().Range(func(key string, value shim.Resource) bool {
+			if _, ok := p.resourceOwners[key]; ok {
+				err = multierror.Append(err, fmt.Errorf("resource %q is defined by more than one provider", key))
+				return true
+			}
+			p.resources[key] = &resource{Resource: value, owner: sub}
+			p.resourceOwners[key] = sub
+			return true
+		})
+		sub.DataSourcesMap().Range(func(key string, value shim.Resource) bool {
+			if _, ok := p.dataSourceOwners[key]; ok {
+				err = multierror.Append(err, fmt.Errorf("data source %q is defined by more than one provider", key))
+				return true
+			}
+			p.dataSources[key] = &resource{Resource: value, owner: sub}
+			p.dataSourceOwners[key] = sub
+			return true
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// resource wraps a resource so that its importer receives the metadata of the provider that owns it rather than
+// that of the combined provider.
+type resource struct {
+	shim.Resource
+
+	owner shim.Provider
+}
+
+func (r *resource) Importer() shim.ImportFunc {
+	importer := r.Resource.Importer()
+	if importer == nil {
+		return nil
+	}
+	return func(t, id string, _ interface{}) ([]shim.InstanceState, error) {
+		return importer(t, id, r.owner.Meta())
+	}
+}
+
+// resourceConfig holds a raw configuration object, which is converted into the configuration type of whichever
+// provider it is eventually passed to.
+type resourceConfig struct {
+	object map[string]interface{}
+	config shim.ResourceConfig
+}
+
+func (c resourceConfig) IsSet(k string) bool {
+	return c.config.IsSet(k)
+}
+
+// destroyDiff records the timeouts set on a destroy diff until the resource it applies to, and therefore the
+// provider that must create the real diff, is known.
+type destroyDiff struct {
+	timeouts []func(shim.InstanceDiff)
+}
+
+func (d *destroyDiff) Attribute(key string) *shim.ResourceAttrDiff {
+	return nil
+}
+
+func (d *destroyDiff) Attributes() map[string]shim.ResourceAttrDiff {
+	return nil
+}
+
+func (d *destroyDiff) ProposedState(res shim.Resource, priorState shim.InstanceState) (shim.InstanceState, error) {
+	return nil, nil
+}
+
+func (d *destroyDiff) Destroy() bool {
+	return true
+}
+
+func (d *destroyDiff) RequiresNew() bool {
+	return false
+}
+
+func (d *destroyDiff) IgnoreChanges(ignored map[string]bool) {
+}
+
+func (d *destroyDiff) EncodeTimeouts(timeouts *shim.ResourceTimeout) error {
+	d.timeouts = append(d.timeouts, func(diff shim.InstanceDiff) {
+		_ = diff.EncodeTimeouts(timeouts)
+	})
+	return nil
+}
+
+func (d *destroyDiff) SetTimeout(timeout float64, timeoutKey string) {
+	d.timeouts = append(d.timeouts, func(diff shim.InstanceDiff) {
+		diff.SetTimeout(timeout, timeoutKey)
+	})
+}
+
+// ProtocolVersion returns the oldest plugin protocol version implemented by the combined providers, or 0 if any of
+// them does not report its version.
+func (p *provider) ProtocolVersion() int {
+	version := 0
+	for i, sub := range p.providers {
+		versioned, ok := sub.(shim.ProviderWithProtocolVersion)
+		if !ok {
+			return 0
+		}
+		if v := versioned.ProtocolVersion(); i == 0 || v < version {
+			version = v
+		}
+	}
+	return version
+}
+
+func (p *provider) Schema() shim.SchemaMap {
+	return p.schema
+}
+
+func (p *provider) ResourcesMap() shim.ResourceMap {
+	return p.resources
+}
+
+func (p *provider) DataSourcesMap() shim.ResourceMap {
+	return p.dataSources
+}
+
+// configObject returns the raw configuration object of a configuration created by the combined provider.
+func configObject(c shim.ResourceConfig) (map[string]interface{}, error) {
+	rc, ok := c.(resourceConfig)
+	if !ok {
+		return nil, fmt.Errorf("configuration of type %T was not created by the combined provider", c)
+	}
+	return rc.object, nil
+}
+
+// providerConfig returns the given configuration restricted to the properties that the given provider defines.
+func providerConfig(sub shim.Provider, c shim.ResourceConfig) (shim.ResourceConfig, error) {
+	object := map[string]interface{}{}
+	if c != nil {
+		all, err := configObject(c)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range all {
+			if _, ok := sub.Schema().GetOk(k); ok {
+				object[k] = v
+			}
+		}
+	}
+	return sub.NewResourceConfig(object), nil
+}
+
+// routedConfig returns the given configuration in the form expected by the given provider.
+func routedConfig(sub shim.Provider, c shim.ResourceConfig) (shim.ResourceConfig, error) {
+	if c == nil {
+		return nil, nil
+	}
+	object, err := configObject(c)
+	if err != nil {
+		return nil, err
+	}
+	return sub.NewResourceConfig(object), nil
+}
+
+func (p *provider) Validate(c shim.ResourceConfig) ([]string, []error) {
+	var warnings []string
+	var errors []error
+	for _, sub := range p.providers {
+		config, err := providerConfig(sub, c)
+		if err != nil {
+			return nil, []error{err}
+		}
+		w, e := sub.Validate(config)
+		warnings, errors = append(warnings, w...), append(errors, e...)
+	}
+	return warnings, errors
+}
+
+func (p *provider) resourceOwner(t string) (shim.Provider, error) {
+	sub, ok := p.resourceOwners[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %v", t)
+	}
+	return sub, nil
+}
+
+func (p *provider) dataSourceOwner(t string) (shim.Provider, error) {
+	sub, ok := p.dataSourceOwners[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown data source %v", t)
+	}
+	return sub, nil
+}
+
+func (p *provider) ValidateResource(t string, c shim.ResourceConfig) ([]string, []error) {
+	sub, err := p.resourceOwner(t)
+	if err != nil {
+		return nil, []error{err}
+	}
+	config, err := routedConfig(sub, c)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return sub.ValidateResource(t, config)
+}
+
+func (p *provider) ValidateDataSource(t string, c shim.ResourceConfig) ([]string, []error) {
+	sub, err := p.dataSourceOwner(t)
+	if err != nil {
+		return nil, []error{err}
+	}
+	config, err := routedConfig(sub, c)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return sub.ValidateDataSource(t, config)
+}
+
+func (p *provider) Configure(c shim.ResourceConfig) error {
+	var err error
+	for _, sub := range p.providers {
+		config, subErr := providerConfig(sub, c)
+		if subErr != nil {
+			return subErr
+		}
+		if subErr = sub.Configure(config); subErr != nil {
+			err = multierror.Append(err, subErr)
+		}
+	}
+	return err
+}
+
+func (p *provider) Diff(t string, s shim.InstanceState, c shim.ResourceConfig) (shim.InstanceDiff, error) {
+	sub, err := p.resourceOwner(t)
+	if err != nil {
+		return nil, err
+	}
+	config, err := routedConfig(sub, c)
+	if err != nil {
+		return nil, err
+	}
+	return sub.Diff(t, s, config)
+}
+
+func (p *provider) Apply(t string, s shim.InstanceState, d shim.InstanceDiff) (shim.InstanceState, error) {
+	sub, err := p.resourceOwner(t)
+	if err != nil {
+		return nil, err
+	}
+	if destroy, ok := d.(*destroyDiff); ok {
+		d = sub.NewDestroyDiff()
+		for _, setTimeout := range destroy.timeouts {
+			setTimeout(d)
+		}
+	}
+	return sub.Apply(t, s, d)
+}
+
+func (p *provider) Refresh(t string, s shim.InstanceState) (shim.InstanceState, error) {
+	sub, err := p.resourceOwner(t)
+	if err != nil {
+		return nil, err
+	}
+	return sub.Refresh(t, s)
+}
+
+func (p *provider) ReadDataDiff(t string, c shim.ResourceConfig) (shim.InstanceDiff, error) {
+	sub, err := p.dataSourceOwner(t)
+	if err != nil {
+		return nil, err
+	}
+	config, err := routedConfig(sub, c)
+	if err != nil {
+		return nil, err
+	}
+	return sub.ReadDataDiff(t, config)
+}
+
+func (p *provider) ReadDataApply(t string, d shim.InstanceDiff) (shim.InstanceState, error) {
+	sub, err := p.dataSourceOwner(t)
+	if err != nil {
+		return nil, err
+	}
+	return sub.ReadDataApply(t, d)
+}
+
+// Meta returns the metadata of the first provider. Importers are always passed the metadata of the provider that
+// owns the resource being imported.
+func (p *provider) Meta() interface{} {
+	return p.providers[0].Meta()
+}
+
+func (p *provider) Stop() error {
+	var err error
+	for _, sub := range p.providers {
+		if subErr := sub.Stop(); subErr != nil {
+			err = multierror.Append(err, subErr)
+		}
+	}
+	return err
+}
+
+func (p *provider) InitLogging() {
+	for _, sub := range p.providers {
+		sub.InitLogging()
+	}
+}
+
+func (p *provider) NewDestroyDiff() shim.InstanceDiff {
+	return &destroyDiff{}
+}
+
+func (p *provider) NewResourceConfig(object map[string]interface{}) shim.ResourceConfig {
+	return resourceConfig{object: object, config: p.providers[0].NewResourceConfig(object)}
+}
+
+func (p *provider) IsSet(v interface{}) ([]interface{}, bool) {
+	for _, sub := range p.providers {
+		if list, ok := sub.IsSet(v); ok {
+			return list, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,247 @@
+package muxer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+	shimschema "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
+	shimv2 "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/sdk-v2"
+)
+
+// testProvider returns a provider with a single resource that records the provider it was created and deleted by.
+func testProvider(name string, config map[string]*schema.Schema, events *[]string) *schema.Provider {
+	return &schema.Provider{
+		Schema: config,
+		ConfigureContextFunc: func(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+			*events = append(*events, name+" configured with region "+d.Get("region").(string))
+			return name, nil
+		},
+		ResourcesMap: map[string]*schema.Resource{
+			"example_" + name: {
+				Schema: map[string]*schema.Schema{
+					"value": {Type: schema.TypeString, Optional: true, ForceNew: true},
+				},
+				CreateContext: func(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+					*events = append(*events, "example_"+name+" created by "+meta.(string))
+					d.SetId(name)
+					return nil
+				},
+				ReadContext: func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+					return nil
+				},
+				DeleteContext: func(_ context.Context, _ *schema.ResourceData, meta interface{}) diag.Diagnostics {
+					*events = append(*events, "example_"+name+" deleted by "+meta.(string))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// frameworkProvider is a provider shaped like a plugin-framework provider: it has no SDK resource data or meta, and
+// accepts only the configurations and diffs that it created itself.
+type frameworkProvider struct {
+	shimschema.ProviderShim
+
+	events *[]string
+	region string
+}
+
+func newFrameworkProvider(events *[]string) *frameworkProvider {
+	str := (&shimschema.Schema{Type: shim.TypeString, Optional: true}).Shim()
+	return &frameworkProvider{
+		ProviderShim: shimschema.ProviderShim{V: &shimschema.Provider{
+			Schema: shimschema.SchemaMap{"region": str, "token": str},
+			ResourcesMap: shimschema.ResourceMap{
+				"example_framework": (&shimschema.Resource{Schema: shimschema.SchemaMap{"value": str}}).Shim(),
+			},
+			DataSourcesMap: shimschema.ResourceMap{},
+		}},
+		events: events,
+	}
+}
+
+type frameworkConfig map[string]interface{}
+
+func (c frameworkConfig) IsSet(k string) bool {
+	_, ok := c[k]
+	return ok
+}
+
+type frameworkDiff struct {
+	destroy  bool
+	attrs    map[string]shim.ResourceAttrDiff
+	timeouts map[string]float64
+}
+
+func (d *frameworkDiff) Attribute(key string) *shim.ResourceAttrDiff {
+	if attr, ok := d.attrs[key]; ok {
+		return &attr
+	}
+	return nil
+}
+
+func (d *frameworkDiff) Attributes() map[string]shim.ResourceAttrDiff { return d.attrs }
+
+func (d *frameworkDiff) ProposedState(shim.Resource, shim.InstanceState) (shim.InstanceState, error) {
+	return nil, nil
+}
+
+func (d *frameworkDiff) Destroy() bool                              { return d.destroy }
+func (d *frameworkDiff) RequiresNew() bool                          { return false }
+func (d *frameworkDiff) IgnoreChanges(map[string]bool)              {}
+func (d *frameworkDiff) EncodeTimeouts(*shim.ResourceTimeout) error { return nil }
+
+func (d *frameworkDiff) SetTimeout(timeout float64, timeoutKey string) {
+	d.timeouts[timeoutKey] = timeout
+}
+
+type frameworkState struct{ id string }
+
+func (s frameworkState) Type() string                                          { return "example_framework" }
+func (s frameworkState) ID() string                                            { return s.id }
+func (s frameworkState) Object(shim.SchemaMap) (map[string]interface{}, error) { return nil, nil }
+func (s frameworkState) Meta() map[string]interface{}                          { return nil }
+
+func (p *frameworkProvider) ProtocolVersion() int { return 6 }
+
+func (p *frameworkProvider) NewResourceConfig(object map[string]interface{}) shim.ResourceConfig {
+	return frameworkConfig(object)
+}
+
+func (p *frameworkProvider) NewDestroyDiff() shim.InstanceDiff {
+	return &frameworkDiff{destroy: true, timeouts: map[string]float64{}}
+}
+
+func (p *frameworkProvider) config(c shim.ResourceConfig) (frameworkConfig, error) {
+	config, ok := c.(frameworkConfig)
+	if !ok {
+		return nil, fmt.Errorf("unexpected configuration type %T", c)
+	}
+	for k := range config {
+		if _, ok := p.Schema().GetOk(k); !ok {
+			return nil, fmt.Errorf("unexpected configuration property %q", k)
+		}
+	}
+	return config, nil
+}
+
+func (p *frameworkProvider) Validate(c shim.ResourceConfig) ([]string, []error) {
+	if _, err := p.config(c); err != nil {
+		return nil, []error{err}
+	}
+	return nil, nil
+}
+
+func (p *frameworkProvider) Configure(c shim.ResourceConfig) error {
+	config, err := p.config(c)
+	if err != nil {
+		return err
+	}
+	p.region = config["region"].(string)
+	*p.events = append(*p.events, "framework configured with region "+p.region)
+	return nil
+}
+
+func (p *frameworkProvider) Diff(t string, s shim.InstanceState, c shim.ResourceConfig) (shim.InstanceDiff, error) {
+	config, ok := c.(frameworkConfig)
+	if !ok {
+		return nil, fmt.Errorf("unexpected configuration type %T", c)
+	}
+	return &frameworkDiff{
+		attrs:    map[string]shim.ResourceAttrDiff{"value": {New: config["value"].(string)}},
+		timeouts: map[string]float64{},
+	}, nil
+}
+
+func (p *frameworkProvider) Apply(t string, s shim.InstanceState, d shim.InstanceDiff) (shim.InstanceState, error) {
+	diff, ok := d.(*frameworkDiff)
+	if !ok {
+		return nil, fmt.Errorf("unexpected diff type %T", d)
+	}
+	if diff.destroy {
+		*p.events = append(*p.events, fmt.Sprintf("%s deleted in %s within %vs", t, p.region,
+			diff.timeouts[shim.TimeoutDelete]))
+		return nil, nil
+	}
+	*p.events = append(*p.events, t+" created in "+p.region)
+	return frameworkState{id: "framework"}, nil
+}
+
+func (p *frameworkProvider) Meta() interface{} { return nil }
+
+func TestMuxedProvider(t *testing.T) {
+	var events []string
+	sdk := testProvider("sdk", map[string]*schema.Schema{
+		"region": {Type: schema.TypeString, Optional: true},
+	}, &events)
+	framework := newFrameworkProvider(&events)
+
+	p, err := NewProvider(shimv2.NewProvider(sdk), framework)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, p.Schema().Len())
+	assert.Equal(t, 2, p.ResourcesMap().Len())
+	assert.Equal(t, 5, p.(shim.ProviderWithProtocolVersion).ProtocolVersion())
+
+	config := p.NewResourceConfig(map[string]interface{}{"region": "us-west-2", "token": "secret"})
+	assert.True(t, config.IsSet("token"))
+	_, errs := p.Validate(config)
+	assert.Empty(t, errs)
+	assert.NoError(t, p.Configure(config))
+	assert.Equal(t, []string{
+		"sdk configured with region us-west-2",
+		"framework configured with region us-west-2",
+	}, events)
+
+	events = nil
+	for _, name := range []string{"example_sdk", "example_framework"} {
+		diff, err := p.Diff(name, nil, p.NewResourceConfig(map[string]interface{}{"value": "v"}))
+		assert.NoError(t, err)
+		state, err := p.Apply(name, nil, diff)
+		assert.NoError(t, err)
+
+		destroy := p.NewDestroyDiff()
+		destroy.SetTimeout(60, shim.TimeoutDelete)
+		_, err = p.Apply(name, state, destroy)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{
+		"example_sdk created by sdk",
+		"example_sdk deleted by sdk",
+		"example_framework created in us-west-2",
+		"example_framework deleted in us-west-2 within 60s",
+	}, events)
+
+	_, err = p.Diff("example_unknown", nil, p.NewResourceConfig(map[string]interface{}{}))
+	assert.EqualError(t, err, "unknown resource example_unknown")
+
+	// Configurations that were not created by the combined provider are rejected rather than causing a panic.
+	foreign := framework.NewResourceConfig(map[string]interface{}{"value": "v"})
+	_, err = p.Diff("example_framework", nil, foreign)
+	assert.EqualError(t, err, "configuration of type muxer.frameworkConfig was not created by the combined provider")
+	_, errs = p.ValidateResource("example_framework", foreign)
+	assert.Len(t, errs, 1)
+	_, errs = p.Validate(foreign)
+	assert.Len(t, errs, 1)
+	assert.Error(t, p.Configure(foreign))
+}
+
+func TestMuxedProviderConflicts(t *testing.T) {
+	var events []string
+	a := testProvider("a", map[string]*schema.Schema{"region": {Type: schema.TypeString, Optional: true}}, &events)
+	b := testProvider("a", map[string]*schema.Schema{"region": {Type: schema.TypeInt, Optional: true}}, &events)
+
+	_, err := NewProvider(shimv2.NewProvider(a), shimv2.NewProvider(b))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `configuration property "region" has conflicting types`)
+	assert.Contains(t, err.Error(), `resource "example_a" is defined by more than one provider`)
+
+	_, err = NewProvider()
+	assert.EqualError(t, err, "at least one provider is required")
+}
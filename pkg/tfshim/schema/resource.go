@@ -6,6 +6,7 @@ import (
 	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
 )
 
+var _ = shim.ResourceWithDescription(ResourceShim{})
 var _ = shim.ResourceMap(ResourceMap{})
 
 type Resource struct {
@@ -13,6 +14,7 @@ type Resource struct {
 	SchemaVersion      int
 	Importer           shim.ImportFunc
 	DeprecationMessage string
+	Description        string
 	Timeouts           *shim.ResourceTimeout
 }
 
@@ -40,6 +42,10 @@ func (r ResourceShim) DeprecationMessage() string {
 	return r.V.DeprecationMessage
 }
 
+func (r ResourceShim) Description() string {
+	return r.V.Description
+}
+
 func (r ResourceShim) Timeouts() *shim.ResourceTimeout {
 	return r.V.Timeouts
 }
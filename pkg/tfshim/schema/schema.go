@@ -10,6 +10,7 @@ import (
 // rather a lot of things.
 const UnknownVariableValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
 
+var _ = shim.SchemaWithConstraints(SchemaShim{})
 var _ = shim.SchemaMap(SchemaMap{})
 
 type Schema struct {
@@ -26,6 +27,9 @@ type Schema struct {
 	MaxItems      int
 	MinItems      int
 	ConflictsWith []string
+	ExactlyOneOf  []string
+	AtLeastOneOf  []string
+	RequiredWith  []string
 	Removed       string
 	Deprecated    string
 	Sensitive     bool
@@ -108,6 +112,18 @@ func (s SchemaShim) ConflictsWith() []string {
 	return s.V.ConflictsWith
 }
 
+func (s SchemaShim) ExactlyOneOf() []string {
+	return s.V.ExactlyOneOf
+}
+
+func (s SchemaShim) AtLeastOneOf() []string {
+	return s.V.AtLeastOneOf
+}
+
+func (s SchemaShim) RequiredWith() []string {
+	return s.V.RequiredWith
+}
+
 func (s SchemaShim) Removed() string {
 	return s.V.Removed
 }
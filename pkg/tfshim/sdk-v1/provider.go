@@ -56,6 +56,12 @@ func NewProvider(p *schema.Provider) shim.Provider {
 	return v1Provider{p}
 }
 
+// ProtocolVersion returns 5, the version of the gRPC plugin protocol that providers built with SDKv1 serve to
+// Terraform 0.12 and later. SDKv1 only serves version 4 to Terraform 0.10 and 0.11, which the bridge does not drive.
+func (p v1Provider) ProtocolVersion() int {
+	return 5
+}
+
 func (p v1Provider) Schema() shim.SchemaMap {
 	return v1SchemaMap(p.tf.Schema)
 }
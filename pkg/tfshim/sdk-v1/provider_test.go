@@ -0,0 +1,18 @@
+package sdkv1
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+func TestProtocolVersion(t *testing.T) {
+	// SDKv1 serves protocol 5 over gRPC, as SDKv2 does; version 4 is only its legacy net/rpc protocol.
+	p, ok := NewProvider(&schema.Provider{}).(shim.ProviderWithProtocolVersion)
+	if assert.True(t, ok) {
+		assert.Equal(t, 5, p.ProtocolVersion())
+	}
+}
@@ -8,7 +8,7 @@ import (
 	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
 )
 
-var _ = shim.Resource(v1Resource{})
+var _ = shim.ResourceWithDescription(v1Resource{})
 var _ = shim.ResourceMap(v1ResourceMap{})
 
 type v1Resource struct {
@@ -36,9 +36,14 @@ func (r v1Resource) Importer() shim.ImportFunc {
 		data.SetId(id)
 		data.SetType(t)
 
-		v1Results, err := r.tf.Importer.State(data, meta)
-		if err != nil {
-			return nil, err
+		// An importer without a State function passes the ID straight through, as it does in Terraform.
+		v1Results := []*schema.ResourceData{data}
+		if r.tf.Importer.State != nil {
+			var err error
+			v1Results, err = r.tf.Importer.State(data, meta)
+			if err != nil {
+				return nil, err
+			}
 		}
 		results := make([]shim.InstanceState, len(v1Results))
 		for i, v := range v1Results {
@@ -60,6 +65,11 @@ func (r v1Resource) DeprecationMessage() string {
 	return r.tf.DeprecationMessage
 }
 
+func (r v1Resource) Description() string {
+	// SDKv1 resources do not carry a description.
+	return ""
+}
+
 func (r v1Resource) Timeouts() *shim.ResourceTimeout {
 	if r.tf.Timeouts == nil {
 		return nil
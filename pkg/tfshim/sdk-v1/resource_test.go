@@ -0,0 +1,105 @@
+package sdkv1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+func timeoutsTestProvider(created *time.Duration) *schema.Provider {
+	create, def := 10*time.Minute, time.Minute
+	return &schema.Provider{
+		ResourcesMap: map[string]*schema.Resource{
+			"test_widget": {
+				Schema: map[string]*schema.Schema{
+					"name": {Type: schema.TypeString, Optional: true},
+				},
+				Create: func(d *schema.ResourceData, _ interface{}) error {
+					*created = d.Timeout(schema.TimeoutCreate)
+					d.SetId("widget")
+					return nil
+				},
+				Read:     func(*schema.ResourceData, interface{}) error { return nil },
+				Delete:   func(*schema.ResourceData, interface{}) error { return nil },
+				Timeouts: &schema.ResourceTimeout{Create: &create, Default: &def},
+				Importer: &schema.ResourceImporter{},
+			},
+			"test_gadget": {
+				Schema: map[string]*schema.Schema{
+					"name": {Type: schema.TypeString, Optional: true},
+				},
+				Read:   func(*schema.ResourceData, interface{}) error { return nil },
+				Delete: func(*schema.ResourceData, interface{}) error { return nil },
+				Importer: &schema.ResourceImporter{
+					State: func(d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+						if err := d.Set("name", d.Id()); err != nil {
+							return nil, err
+						}
+						return []*schema.ResourceData{d}, nil
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	var created time.Duration
+	p := NewProvider(timeoutsTestProvider(&created))
+	res := p.ResourcesMap().Get("test_widget")
+
+	timeouts := res.Timeouts()
+	if assert.NotNil(t, timeouts) && assert.NotNil(t, timeouts.Create) {
+		assert.Equal(t, 10*time.Minute, *timeouts.Create)
+	}
+	assert.Nil(t, p.ResourcesMap().Get("test_gadget").Timeouts())
+
+	config := p.NewResourceConfig(map[string]interface{}{
+		"name":     "widget",
+		"timeouts": map[string]interface{}{"create": "5m"},
+	})
+	decoded, err := res.DecodeTimeouts(config)
+	assert.NoError(t, err)
+	if assert.NotNil(t, decoded.Create) {
+		assert.Equal(t, 5*time.Minute, *decoded.Create)
+	}
+
+	// Timeouts that are encoded into a diff reach the resource's CRUD functions, and a timeout that is set
+	// explicitly overrides the configured one.
+	diff, err := p.Diff("test_widget", nil, config)
+	assert.NoError(t, err)
+	assert.NoError(t, diff.EncodeTimeouts(decoded))
+	_, err = p.Apply("test_widget", nil, diff)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, created)
+
+	diff.SetTimeout(90, shim.TimeoutCreate)
+	_, err = p.Apply("test_widget", nil, diff)
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, created)
+}
+
+func TestImporter(t *testing.T) {
+	var created time.Duration
+	p := NewProvider(timeoutsTestProvider(&created))
+
+	// Importers without a State function pass the ID through.
+	states, err := p.ResourcesMap().Get("test_widget").Importer()("test_widget", "my-widget", nil)
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, "my-widget", states[0].ID())
+		assert.Equal(t, "test_widget", states[0].Type())
+	}
+
+	states, err = p.ResourcesMap().Get("test_gadget").Importer()("test_gadget", "my-gadget", nil)
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) {
+		obj, err := states[0].Object(p.ResourcesMap().Get("test_gadget").Schema())
+		assert.NoError(t, err)
+		assert.Equal(t, "my-gadget", obj["name"])
+	}
+}
@@ -7,6 +7,8 @@ import (
 )
 
 var _ = shim.Schema(v1Schema{})
+var _ = shim.SchemaWithValidation(v1Schema{})
+var _ = shim.SchemaWithConstraints(v1Schema{})
 var _ = shim.SchemaMap(v1SchemaMap{})
 
 // UnknownVariableValue is the sentinal defined in github.com/hashicorp/terraform/configs/hcl2shim,
@@ -103,6 +105,23 @@ func (s v1Schema) ConflictsWith() []string {
 	return s.tf.ConflictsWith
 }
 
+func (s v1Schema) HasValidation() bool {
+	return s.tf.ValidateFunc != nil
+}
+
+func (s v1Schema) ExactlyOneOf() []string {
+	return s.tf.ExactlyOneOf
+}
+
+func (s v1Schema) AtLeastOneOf() []string {
+	return s.tf.AtLeastOneOf
+}
+
+func (s v1Schema) RequiredWith() []string {
+	// SDKv1 schemas do not support RequiredWith.
+	return nil
+}
+
 func (s v1Schema) Removed() string {
 	return s.tf.Removed
 }
@@ -56,6 +56,11 @@ func NewProvider(p *schema.Provider) shim.Provider {
 	return v2Provider{p}
 }
 
+// ProtocolVersion returns 5, the version of the plugin protocol that providers built with SDKv2 implement.
+func (p v2Provider) ProtocolVersion() int {
+	return 5
+}
+
 func (p v2Provider) Schema() shim.SchemaMap {
 	return v2SchemaMap(p.tf.Schema)
 }
@@ -9,7 +9,7 @@ import (
 	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
 )
 
-var _ = shim.Resource(v2Resource{})
+var _ = shim.ResourceWithDescription(v2Resource{})
 var _ = shim.ResourceMap(v2ResourceMap{})
 
 type v2Resource struct {
@@ -38,7 +38,9 @@ func (r v2Resource) Importer() shim.ImportFunc {
 		data.SetId(id)
 		data.SetType(t)
 
-		var v2Results []*schema.ResourceData
+		// An importer without a State or StateContext function passes the ID straight through, as it does in
+		// Terraform.
+		v2Results := []*schema.ResourceData{data}
 		var err error
 		switch {
 		case r.tf.Importer.State != nil:
@@ -69,6 +71,10 @@ func (r v2Resource) DeprecationMessage() string {
 	return r.tf.DeprecationMessage
 }
 
+func (r v2Resource) Description() string {
+	return r.tf.Description
+}
+
 func (r v2Resource) Timeouts() *shim.ResourceTimeout {
 	if r.tf.Timeouts == nil {
 		return nil
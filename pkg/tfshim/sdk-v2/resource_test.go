@@ -0,0 +1,25 @@
+package sdkv2
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImporterPassthrough(t *testing.T) {
+	res := NewResource(&schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {Type: schema.TypeString, Optional: true},
+		},
+		Importer: &schema.ResourceImporter{},
+	})
+
+	// Importers without a State or StateContext function pass the ID through.
+	states, err := res.Importer()("test_widget", "my-widget", nil)
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, "my-widget", states[0].ID())
+		assert.Equal(t, "test_widget", states[0].Type())
+	}
+}
@@ -7,6 +7,8 @@ import (
 )
 
 var _ = shim.Schema(v2Schema{})
+var _ = shim.SchemaWithValidation(v2Schema{})
+var _ = shim.SchemaWithConstraints(v2Schema{})
 var _ = shim.SchemaMap(v2SchemaMap{})
 
 // UnknownVariableValue is the sentinal defined in github.com/hashicorp/terraform/configs/hcl2shim,
@@ -103,6 +105,22 @@ func (s v2Schema) ConflictsWith() []string {
 	return s.tf.ConflictsWith
 }
 
+func (s v2Schema) HasValidation() bool {
+	return s.tf.ValidateFunc != nil || s.tf.ValidateDiagFunc != nil
+}
+
+func (s v2Schema) ExactlyOneOf() []string {
+	return s.tf.ExactlyOneOf
+}
+
+func (s v2Schema) AtLeastOneOf() []string {
+	return s.tf.AtLeastOneOf
+}
+
+func (s v2Schema) RequiredWith() []string {
+	return s.tf.RequiredWith
+}
+
 func (s v2Schema) Removed() string {
 	return ""
 }
@@ -154,6 +172,12 @@ func (s v2Schema) UnknownValue() interface{} {
 			arr[i] = makeUnknownElement(s.tf.Elem)
 		}
 		return arr
+	case schema.TypeMap:
+		// Maps whose Elem is a resource schema hold a single object, which Terraform stores as a single-element list.
+		if _, isResource := s.tf.Elem.(*schema.Resource); isResource {
+			return []interface{}{makeUnknownElement(s.tf.Elem)}
+		}
+		return UnknownVariableValue
 	default:
 		return UnknownVariableValue
 	}
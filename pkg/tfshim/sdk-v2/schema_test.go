@@ -0,0 +1,81 @@
+package sdkv2
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
+)
+
+func objectMapResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"object_map": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"required_value": {Type: schema.TypeString, Required: true},
+						"optional_value": {Type: schema.TypeString, Optional: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestObjectMapUnknownValue(t *testing.T) {
+	sch := NewSchema(objectMapResource().Schema["object_map"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"required_value": UnknownVariableValue},
+	}, sch.UnknownValue())
+
+	assert.Equal(t, UnknownVariableValue, NewSchema(&schema.Schema{
+		Type: schema.TypeMap,
+		Elem: &schema.Schema{Type: schema.TypeString},
+	}).UnknownValue())
+}
+
+func TestObjectMapDiff(t *testing.T) {
+	p := NewProvider(&schema.Provider{
+		ResourcesMap: map[string]*schema.Resource{"example_resource": objectMapResource()},
+	})
+
+	// Known object values are diffed field-by-field rather than as strings.
+	diff, err := p.Diff("example_resource", nil, p.NewResourceConfig(map[string]interface{}{
+		"object_map": []interface{}{map[string]interface{}{
+			"required_value": "foo",
+			"optional_value": "bar",
+		}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", diff.Attribute("object_map.required_value").New)
+	assert.Equal(t, "bar", diff.Attribute("object_map.optional_value").New)
+
+	// Unknown object values mark the whole map as computed.
+	diff, err = p.Diff("example_resource", nil, p.NewResourceConfig(map[string]interface{}{
+		"object_map": NewSchema(objectMapResource().Schema["object_map"]).UnknownValue(),
+	}))
+	assert.NoError(t, err)
+	assert.True(t, diff.Attribute("object_map.%").NewComputed)
+}
+
+func TestHasValidation(t *testing.T) {
+	validated := NewSchema(&schema.Schema{
+		Type:         schema.TypeString,
+		ValidateFunc: validation.StringInSlice([]string{"a", "b"}, false),
+	})
+	assert.True(t, validated.(shim.SchemaWithValidation).HasValidation())
+
+	validated = NewSchema(&schema.Schema{
+		Type:             schema.TypeString,
+		ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+	})
+	assert.True(t, validated.(shim.SchemaWithValidation).HasValidation())
+
+	unvalidated := NewSchema(&schema.Schema{Type: schema.TypeString})
+	assert.False(t, unvalidated.(shim.SchemaWithValidation).HasValidation())
+}
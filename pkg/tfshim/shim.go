@@ -58,6 +58,7 @@ const (
 	TypeList
 	TypeMap
 	TypeSet
+	TypeDynamic // a value of any type, e.g. a plugin framework DynamicAttribute
 )
 
 type SchemaDefaultFunc func() (interface{}, error)
@@ -89,6 +90,24 @@ type Schema interface {
 	SetHash(v interface{}) int
 }
 
+// SchemaWithValidation is implemented by schemas that know whether the upstream provider validates their values, e.g.
+// with a ValidateFunc.
+type SchemaWithValidation interface {
+	Schema
+
+	HasValidation() bool
+}
+
+// SchemaWithConstraints is implemented by schemas that know the other properties that the upstream provider requires
+// to be set, or not, along with theirs.
+type SchemaWithConstraints interface {
+	Schema
+
+	ExactlyOneOf() []string
+	AtLeastOneOf() []string
+	RequiredWith() []string
+}
+
 type SchemaMap interface {
 	Len() int
 	Get(key string) Schema
@@ -124,6 +143,13 @@ type Resource interface {
 	DecodeTimeouts(config ResourceConfig) (*ResourceTimeout, error)
 }
 
+// ResourceWithDescription is implemented by resources that know the upstream description of the resource.
+type ResourceWithDescription interface {
+	Resource
+
+	Description() string
+}
+
 type ResourceMap interface {
 	Len() int
 	Get(key string) Resource
@@ -133,6 +159,14 @@ type ResourceMap interface {
 	Set(key string, value Resource)
 }
 
+// ProviderWithProtocolVersion is implemented by providers that know which version of the Terraform plugin protocol
+// they implement.
+type ProviderWithProtocolVersion interface {
+	Provider
+
+	ProtocolVersion() int
+}
+
 type Provider interface {
 	Schema() SchemaMap
 	ResourcesMap() ResourceMap
@@ -158,3 +192,20 @@ type Provider interface {
 	NewResourceConfig(object map[string]interface{}) ResourceConfig
 	IsSet(v interface{}) ([]interface{}, bool)
 }
+
+// EphemeralResourceProvider is implemented by providers that have ephemeral resources: values such as short-lived
+// credentials that are opened when they are needed and that Terraform never stores in state.
+//
+// Experimental: none of the shims in this repository implement it yet, so ephemeral resources are only available to
+// providers that supply their own shim.
+type EphemeralResourceProvider interface {
+	Provider
+
+	EphemeralResourcesMap() ResourceMap
+
+	// ValidateEphemeralResource validates the given config for the ephemeral resource of the given type.
+	ValidateEphemeralResource(t string, c ResourceConfig) ([]string, []error)
+
+	// OpenEphemeralResource opens the ephemeral resource of the given type with the given config and returns its values.
+	OpenEphemeralResource(t string, c ResourceConfig) (InstanceState, error)
+}
@@ -84,6 +84,10 @@ var ctyValueType = reflect.TypeOf((*cty.Value)(nil)).Elem()
 // Only a limited set of Go values are supported: bools, ints/uints/floats, strings, arrays/slices, and maps with
 // string-typed keys. Structs are not supported.
 func reflectToCty(v reflect.Value, ty cty.Type) (cty.Value, error) {
+	if !v.IsValid() {
+		return cty.NullVal(ty), nil
+	}
+
 	if v.Type() == ctyValueType {
 		if !v.CanInterface() {
 			return cty.NullVal(ty), nil
@@ -91,16 +95,20 @@ func reflectToCty(v reflect.Value, ty cty.Type) (cty.Value, error) {
 		return v.Interface().(cty.Value), nil
 	}
 
-	if !v.IsValid() {
-		return cty.NullVal(ty), nil
-	}
-
 	switch v.Type().Kind() {
 	case reflect.Ptr, reflect.Interface:
 		if v.IsNil() {
 			return cty.NullVal(ty), nil
 		}
 		return reflectToCty(v.Elem(), ty)
+	}
+
+	// Values of dynamic attributes take the type of the Go value.
+	if ty == cty.DynamicPseudoType {
+		return reflectToDynamicCty(v)
+	}
+
+	switch v.Type().Kind() {
 	case reflect.Bool:
 		if ty != cty.Bool {
 			return cty.NilVal, fmt.Errorf("can't convert Go bool to %v", ty.FriendlyName())
@@ -241,3 +249,68 @@ func reflectToCty(v reflect.Value, ty cty.Type) (cty.Value, error) {
 		return cty.NilVal, fmt.Errorf("unsupported Go value of type %v", v.Type())
 	}
 }
+
+// reflectToDynamicCty converts a reflect.Value to a cty.Value whose type is inferred from the Go value: slices become
+// tuples and maps become objects, so that their elements may have different types.
+func reflectToDynamicCty(v reflect.Value) (cty.Value, error) {
+	if !v.IsValid() {
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	}
+	if v.Type() == ctyValueType {
+		if !v.CanInterface() {
+			return cty.NullVal(cty.DynamicPseudoType), nil
+		}
+		return v.Interface().(cty.Value), nil
+	}
+
+	switch v.Type().Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return cty.NullVal(cty.DynamicPseudoType), nil
+		}
+		return reflectToDynamicCty(v.Elem())
+	case reflect.Bool:
+		return cty.BoolVal(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cty.NumberIntVal(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cty.NumberUIntVal(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return cty.NumberFloatVal(v.Float()), nil
+	case reflect.String:
+		if v.String() == UnknownVariableValue {
+			return cty.DynamicVal, nil
+		}
+		return cty.StringVal(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		values := make([]cty.Value, v.Len())
+		for i := range values {
+			val, err := reflectToDynamicCty(v.Index(i))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			values[i] = val
+		}
+		return cty.TupleVal(values), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return cty.NilVal, fmt.Errorf("can't convert Go map with keys that are not strings")
+		}
+		values := map[string]cty.Value{}
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if k == UnknownVariableValue {
+				return cty.NilVal, fmt.Errorf("can't convert Go map with unknown keys")
+			}
+			val, err := reflectToDynamicCty(iter.Value())
+			if err != nil {
+				return cty.NilVal, err
+			}
+			values[k] = val
+		}
+		return cty.ObjectVal(values), nil
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported Go value of type %v", v.Type())
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/stretchr/testify/assert"
+
+	shim "github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim"
 )
 
 func testCtyToGo(t *testing.T, expected interface{}, val cty.Value) {
@@ -140,3 +142,36 @@ func TestObject(t *testing.T) {
 		"baz": cty.ListVal([]cty.Value{cty.StringVal("qux"), cty.StringVal("zed")}),
 	}))
 }
+
+func TestDynamic(t *testing.T) {
+	testGoToCty := func(expected cty.Value, v interface{}) {
+		actual, err := goToCty(v, cty.DynamicPseudoType)
+		if assert.NoError(t, err) {
+			assert.True(t, expected.RawEquals(actual), "expected %#v, got %#v", expected, actual)
+		}
+	}
+
+	testGoToCty(cty.NullVal(cty.DynamicPseudoType), nil)
+	testGoToCty(cty.DynamicVal, UnknownVariableValue)
+	testGoToCty(cty.True, true)
+	testGoToCty(cty.NumberIntVal(42), 42)
+	testGoToCty(cty.NumberFloatVal(3.14), 3.14)
+	testGoToCty(cty.StringVal("foo"), "foo")
+	testGoToCty(cty.TupleVal([]cty.Value{cty.StringVal("foo"), cty.False}), []interface{}{"foo", false})
+	testGoToCty(cty.ObjectVal(map[string]cty.Value{
+		"foo": cty.NumberIntVal(1),
+		"bar": cty.TupleVal([]cty.Value{cty.StringVal("baz")}),
+	}), map[string]interface{}{"foo": 1, "bar": []interface{}{"baz"}})
+
+	// Dynamic values round-trip through their inferred types.
+	testCtyToGo(t, map[string]interface{}{"foo": 1.0, "bar": []interface{}{"baz"}}, cty.ObjectVal(map[string]cty.Value{
+		"foo": cty.NumberIntVal(1),
+		"bar": cty.TupleVal([]cty.Value{cty.StringVal("baz")}),
+	}))
+
+	valueType, elem, err := unmarshalType(cty.DynamicPseudoType)
+	if assert.NoError(t, err) {
+		assert.Equal(t, shim.TypeDynamic, valueType)
+		assert.Nil(t, elem)
+	}
+}
@@ -26,7 +26,7 @@ func unmarshalNestedType(elementType cty.Type) (interface{}, error) {
 	}
 
 	switch valueType {
-	case shim.TypeBool, shim.TypeInt, shim.TypeFloat, shim.TypeString:
+	case shim.TypeBool, shim.TypeInt, shim.TypeFloat, shim.TypeString, shim.TypeDynamic:
 		return &attributeSchema{
 			ctyType:   elementType,
 			valueType: valueType,
@@ -104,6 +104,8 @@ func unmarshalType(ty cty.Type) (shim.ValueType, interface{}, error) {
 		return shim.TypeBool, nil, nil
 	case cty.Number:
 		return shim.TypeFloat, nil, nil
+	case cty.DynamicPseudoType:
+		return shim.TypeDynamic, nil, nil
 	default:
 		return unmarshalCompositeType(ty)
 	}
@@ -221,6 +223,8 @@ func unmarshalResource(p *provider, typeName string, resourceSchema *proto.Schem
 		ctyType:       ctyType,
 		schema:        properties,
 		schemaVersion: int(resourceSchema.Version),
+		description:   resourceSchema.Block.Description,
+		deprecated:    deprecationMessage(typeName, resourceSchema.Block.Deprecated),
 	}, nil
 }
 
@@ -3,6 +3,7 @@ package tfplugin5
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -16,6 +17,7 @@ import (
 type provider struct {
 	client           proto.ProviderClient
 	terraformVersion string
+	protocolVersion  int // the version of the plugin protocol negotiated with the plugin.
 
 	resources   resourceMap
 	dataSources resourceMap
@@ -23,6 +25,17 @@ type provider struct {
 }
 
 func NewProvider(ctx context.Context, client proto.ProviderClient, terraformVersion string) (shim.Provider, error) {
+	p, err := newProvider(ctx, client, terraformVersion, int(Handshake.ProtocolVersion))
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newProvider creates a provider for the given client, which speaks the given version of the plugin protocol.
+func newProvider(ctx context.Context, client proto.ProviderClient, terraformVersion string,
+	protocolVersion int) (*provider, error) {
+
 	schemaResponse, err := client.GetSchema(ctx, &proto.GetProviderSchema_Request{})
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving schema: %w", err)
@@ -36,6 +49,7 @@ func NewProvider(ctx context.Context, client proto.ProviderClient, terraformVers
 	p := &provider{
 		client:           client,
 		terraformVersion: terraformVersion,
+		protocolVersion:  protocolVersion,
 	}
 
 	p.resources, err = unmarshalResourceMap(p, schemaResponse.ResourceSchemas)
@@ -167,6 +181,11 @@ func (p *provider) importResourceState(t, id string, _ interface{}) ([]shim.Inst
 	return states, nil
 }
 
+// ProtocolVersion returns the version of the plugin protocol that was negotiated with the plugin in its handshake.
+func (p *provider) ProtocolVersion() int {
+	return p.protocolVersion
+}
+
 func (p *provider) Schema() shim.SchemaMap {
 	return p.config.schema
 }
@@ -388,6 +407,11 @@ func (p *provider) Apply(t string, s shim.InstanceState, d shim.InstanceDiff) (s
 		PlannedPrivate: plannedMetaBytes,
 	})
 	if err != nil {
+		// If the plugin crashed, whatever it did to the resource is unknown, so preserve the prior state.
+		var crash *PluginCrashError
+		if errors.As(err, &crash) && state != nil {
+			return state, err
+		}
 		return nil, err
 	}
 
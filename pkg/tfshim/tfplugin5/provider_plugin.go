@@ -36,6 +36,21 @@ func (p *providerPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) e
 	return fmt.Errorf("unsupported")
 }
 
+// providerClientPlugin dispenses the raw gRPC client for a provider plugin.
+type providerClientPlugin struct {
+	plugin.Plugin
+}
+
+func (p *providerClientPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker,
+	c *grpc.ClientConn) (interface{}, error) {
+
+	return proto.NewProviderClient(c), nil
+}
+
+func (p *providerClientPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	return fmt.Errorf("unsupported")
+}
+
 func StartProvider(ctx context.Context, executablePath, terraformVersion string) (shim.Provider, error) {
 	var logger hclog.Logger
 	switch os.Getenv("TF_LOG") {
@@ -53,27 +68,44 @@ func StartProvider(ctx context.Context, executablePath, terraformVersion string)
 		logger = hclog.NewNullLogger()
 	}
 
-	pluginClient := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig:  Handshake,
-		Plugins:          plugin.PluginSet{"provider": &providerPlugin{terraformVersion: terraformVersion}},
-		Cmd:              exec.Command(executablePath),
-		Managed:          true,
-		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
-		AutoMTLS:         true,
-		Logger:           logger,
-	})
-	go func() {
-		<-ctx.Done()
-		pluginClient.Kill()
-	}()
-
-	client, err := pluginClient.Client()
+	// The plugin is supervised so that it is restarted if it crashes, up to a limit.
+	stderr := newStderrTail(stderrTailLines)
+	protocolVersion := int(Handshake.ProtocolVersion)
+	start := func() (proto.ProviderClient, func() bool, error) {
+		pluginClient := plugin.NewClient(&plugin.ClientConfig{
+			HandshakeConfig:  Handshake,
+			Plugins:          plugin.PluginSet{"provider": &providerClientPlugin{}},
+			Cmd:              exec.Command(executablePath),
+			Managed:          true,
+			AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+			AutoMTLS:         true,
+			Logger:           logger,
+			Stderr:           stderr,
+		})
+		go func() {
+			<-ctx.Done()
+			pluginClient.Kill()
+		}()
+
+		client, err := pluginClient.Client()
+		if err != nil {
+			return nil, nil, err
+		}
+		provider, err := client.Dispense("provider")
+		if err != nil {
+			return nil, nil, err
+		}
+		protocolVersion = pluginClient.NegotiatedVersion()
+		return provider.(proto.ProviderClient), pluginClient.Exited, nil
+	}
+
+	supervisor, err := newPluginSupervisor(start, stderr)
 	if err != nil {
 		return nil, err
 	}
-	provider, err := client.Dispense("provider")
+	p, err := newProvider(ctx, supervisor, terraformVersion, protocolVersion)
 	if err != nil {
 		return nil, err
 	}
-	return provider.(shim.Provider), nil
+	return p, nil
 }
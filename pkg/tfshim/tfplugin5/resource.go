@@ -9,7 +9,7 @@ import (
 	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/schema"
 )
 
-var _ = shim.Resource((*resource)(nil))
+var _ = shim.ResourceWithDescription((*resource)(nil))
 var _ = shim.ResourceMap(resourceMap{})
 
 type resource struct {
@@ -19,6 +19,8 @@ type resource struct {
 	ctyType       cty.Type
 	schema        schema.SchemaMap
 	schemaVersion int
+	description   string
+	deprecated    string
 }
 
 func (r *resource) Schema() shim.SchemaMap {
@@ -38,7 +40,11 @@ func (r *resource) Importer() shim.ImportFunc {
 }
 
 func (r *resource) DeprecationMessage() string {
-	return ""
+	return r.deprecated
+}
+
+func (r *resource) Description() string {
+	return r.description
 }
 
 func (r *resource) Timeouts() *shim.ResourceTimeout {
@@ -0,0 +1,336 @@
+package tfplugin5
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/tfplugin5/proto"
+)
+
+const (
+	// crashLoopLimit is the number of times the plugin may crash within crashLoopWindow before the supervisor stops
+	// restarting it.
+	crashLoopLimit  = 3
+	crashLoopWindow = 5 * time.Minute
+
+	// stderrTailLines is the number of lines of the plugin's stderr that are included in crash summaries.
+	stderrTailLines = 20
+
+	// exitTimeout bounds the time spent waiting for a plugin that has stopped responding to exit.
+	exitTimeout = 2 * time.Second
+)
+
+// PluginCrashError is returned by calls to a provider plugin that crashed while handling the call.
+type PluginCrashError struct {
+	Err    error  // the error returned by the call.
+	Stderr string // the last lines that the plugin wrote to stderr.
+}
+
+func (e *PluginCrashError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("the provider plugin crashed: %v", e.Err)
+	}
+	return fmt.Sprintf("the provider plugin crashed: %v\n\nLast output from the plugin:\n%s", e.Err, e.Stderr)
+}
+
+func (e *PluginCrashError) Unwrap() error {
+	return e.Err
+}
+
+// stderrTail keeps the last lines written to a plugin's stderr.
+type stderrTail struct {
+	m       sync.Mutex
+	lines   []string
+	partial string
+	max     int
+}
+
+func newStderrTail(max int) *stderrTail {
+	return &stderrTail{max: max}
+}
+
+func (t *stderrTail) Write(b []byte) (int, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	lines := strings.Split(t.partial+string(b), "\n")
+	t.partial = lines[len(lines)-1]
+	t.lines = append(t.lines, lines[:len(lines)-1]...)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+	return len(b), nil
+}
+
+func (t *stderrTail) String() string {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	lines := t.lines
+	if t.partial != "" {
+		lines = append(lines[:len(lines):len(lines)], t.partial)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pluginInstance is a running provider plugin process.
+type pluginInstance struct {
+	client  proto.ProviderClient
+	exited  func() bool // reports whether the process has exited.
+	crashed bool        // true once the instance's crash has been counted.
+}
+
+// pluginSupervisor is a proto.ProviderClient that runs a provider plugin and restarts it, replaying the provider's
+// configuration, if it crashes. If the plugin crashes crashLoopLimit times within crashLoopWindow, the supervisor's
+// circuit breaker opens and every later call fails with a summary of the crashes rather than starting the plugin again.
+type pluginSupervisor struct {
+	m         sync.Mutex
+	start     func() (proto.ProviderClient, func() bool, error)
+	stderr    *stderrTail
+	instance  *pluginInstance
+	configure *proto.Configure_Request // the last successful configuration, replayed after a restart.
+	crashes   []time.Time
+	broken    error // the error returned by every call once the circuit breaker is open.
+}
+
+var _ proto.ProviderClient = (*pluginSupervisor)(nil)
+
+func newPluginSupervisor(start func() (proto.ProviderClient, func() bool, error),
+	stderr *stderrTail) (*pluginSupervisor, error) {
+
+	client, exited, err := start()
+	if err != nil {
+		return nil, err
+	}
+	return &pluginSupervisor{
+		start:    start,
+		stderr:   stderr,
+		instance: &pluginInstance{client: client, exited: exited},
+	}, nil
+}
+
+// current returns the running plugin, restarting it if it has crashed since the last call.
+func (s *pluginSupervisor) current(ctx context.Context) (*pluginInstance, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.broken != nil {
+		return nil, s.broken
+	}
+	if !s.instance.exited() {
+		return s.instance, nil
+	}
+
+	s.recordCrash(s.instance)
+	if s.broken != nil {
+		return nil, s.broken
+	}
+
+	client, exited, err := s.start()
+	if err != nil {
+		return nil, fmt.Errorf("restarting the provider plugin: %w", err)
+	}
+	s.instance = &pluginInstance{client: client, exited: exited}
+	if s.configure != nil {
+		if _, err = client.Configure(ctx, s.configure); err != nil {
+			return nil, fmt.Errorf("reconfiguring the restarted provider plugin: %w", err)
+		}
+	}
+	return s.instance, nil
+}
+
+// recordCrash counts the given instance's crash, opening the circuit breaker if the plugin is crashing repeatedly.
+// The supervisor's lock must be held.
+func (s *pluginSupervisor) recordCrash(instance *pluginInstance) {
+	if instance.crashed {
+		return
+	}
+	instance.crashed = true
+
+	now := time.Now()
+	recent := s.crashes[:0]
+	for _, t := range s.crashes {
+		if now.Sub(t) < crashLoopWindow {
+			recent = append(recent, t)
+		}
+	}
+	s.crashes = append(recent, now)
+
+	if len(s.crashes) >= crashLoopLimit {
+		s.broken = &PluginCrashError{
+			Err: fmt.Errorf("crashed %d times in %v; not restarting it again", len(s.crashes),
+				now.Sub(s.crashes[0]).Round(time.Second)),
+			Stderr: s.stderr.String(),
+		}
+	}
+}
+
+// call invokes f with the running plugin. If the plugin crashes during the call, the resulting error is a
+// *PluginCrashError; the call is not retried, as it may not be safe to repeat, but the next call restarts the plugin.
+func (s *pluginSupervisor) call(ctx context.Context, f func(client proto.ProviderClient) error) error {
+	instance, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+	if err = f(instance.client); err == nil || !waitForExit(instance, err) {
+		return err
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.recordCrash(instance)
+	return &PluginCrashError{Err: err, Stderr: s.stderr.String()}
+}
+
+// waitForExit reports whether the given call error was caused by the plugin exiting. A plugin that has stopped
+// responding is given a moment to exit, since the connection may be closed before the process is reaped.
+func waitForExit(instance *pluginInstance, err error) bool {
+	if instance.exited() {
+		return true
+	}
+	if status.Code(err) != codes.Unavailable {
+		return false
+	}
+	for deadline := time.Now().Add(exitTimeout); time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		if instance.exited() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *pluginSupervisor) GetSchema(ctx context.Context, in *proto.GetProviderSchema_Request,
+	opts ...grpc.CallOption) (resp *proto.GetProviderSchema_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.GetSchema(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) PrepareProviderConfig(ctx context.Context, in *proto.PrepareProviderConfig_Request,
+	opts ...grpc.CallOption) (resp *proto.PrepareProviderConfig_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.PrepareProviderConfig(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) ValidateResourceTypeConfig(ctx context.Context, in *proto.ValidateResourceTypeConfig_Request,
+	opts ...grpc.CallOption) (resp *proto.ValidateResourceTypeConfig_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.ValidateResourceTypeConfig(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) ValidateDataSourceConfig(ctx context.Context, in *proto.ValidateDataSourceConfig_Request,
+	opts ...grpc.CallOption) (resp *proto.ValidateDataSourceConfig_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.ValidateDataSourceConfig(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) UpgradeResourceState(ctx context.Context, in *proto.UpgradeResourceState_Request,
+	opts ...grpc.CallOption) (resp *proto.UpgradeResourceState_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.UpgradeResourceState(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) Configure(ctx context.Context, in *proto.Configure_Request,
+	opts ...grpc.CallOption) (resp *proto.Configure_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.Configure(ctx, in, opts...)
+		return err
+	})
+	if err == nil {
+		s.m.Lock()
+		s.configure = in
+		s.m.Unlock()
+	}
+	return resp, err
+}
+
+func (s *pluginSupervisor) ReadResource(ctx context.Context, in *proto.ReadResource_Request,
+	opts ...grpc.CallOption) (resp *proto.ReadResource_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.ReadResource(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) PlanResourceChange(ctx context.Context, in *proto.PlanResourceChange_Request,
+	opts ...grpc.CallOption) (resp *proto.PlanResourceChange_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.PlanResourceChange(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) ApplyResourceChange(ctx context.Context, in *proto.ApplyResourceChange_Request,
+	opts ...grpc.CallOption) (resp *proto.ApplyResourceChange_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.ApplyResourceChange(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) ImportResourceState(ctx context.Context, in *proto.ImportResourceState_Request,
+	opts ...grpc.CallOption) (resp *proto.ImportResourceState_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.ImportResourceState(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) ReadDataSource(ctx context.Context, in *proto.ReadDataSource_Request,
+	opts ...grpc.CallOption) (resp *proto.ReadDataSource_Response, err error) {
+
+	err = s.call(ctx, func(client proto.ProviderClient) (err error) {
+		resp, err = client.ReadDataSource(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (s *pluginSupervisor) Stop(ctx context.Context, in *proto.Stop_Request,
+	opts ...grpc.CallOption) (*proto.Stop_Response, error) {
+
+	// A plugin that has exited has nothing to stop, so it is not restarted.
+	s.m.Lock()
+	instance := s.instance
+	s.m.Unlock()
+	if instance.exited() {
+		return &proto.Stop_Response{}, nil
+	}
+	return instance.client.Stop(ctx, in, opts...)
+}
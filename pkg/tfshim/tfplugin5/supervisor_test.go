@@ -0,0 +1,95 @@
+package tfplugin5
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pulumi/pulumi-terraform-bridge/v3/pkg/tfshim/tfplugin5/proto"
+)
+
+// fakePlugin is a provider plugin that crashes when asked to read a resource of type "crash".
+type fakePlugin struct {
+	proto.ProviderClient
+
+	stderr     *stderrTail
+	exited     bool
+	configured bool
+}
+
+func (p *fakePlugin) Configure(ctx context.Context, in *proto.Configure_Request,
+	opts ...grpc.CallOption) (*proto.Configure_Response, error) {
+
+	p.configured = true
+	return &proto.Configure_Response{}, nil
+}
+
+func (p *fakePlugin) ReadResource(ctx context.Context, in *proto.ReadResource_Request,
+	opts ...grpc.CallOption) (*proto.ReadResource_Response, error) {
+
+	switch {
+	case !p.configured:
+		return nil, errors.New("unconfigured")
+	case in.TypeName == "crash":
+		fmt.Fprintf(p.stderr, "panic: boom\n\ngoroutine 1 [running]:\nmain.read()\n")
+		p.exited = true
+		return nil, status.Error(codes.Unavailable, "transport is closing")
+	case in.TypeName == "fail":
+		return nil, status.Error(codes.Unknown, "failed")
+	default:
+		return &proto.ReadResource_Response{}, nil
+	}
+}
+
+func TestPluginSupervisor(t *testing.T) {
+	stderr, starts := newStderrTail(3), 0
+	s, err := newPluginSupervisor(func() (proto.ProviderClient, func() bool, error) {
+		starts++
+		p := &fakePlugin{stderr: stderr}
+		return p, func() bool { return p.exited }, nil
+	}, stderr)
+	assert.NoError(t, err)
+
+	read := func(typeName string) error {
+		_, err := s.ReadResource(context.Background(), &proto.ReadResource_Request{TypeName: typeName})
+		return err
+	}
+
+	_, err = s.Configure(context.Background(), &proto.Configure_Request{})
+	assert.NoError(t, err)
+	assert.NoError(t, read("ok"))
+
+	// Ordinary errors are passed through.
+	assert.EqualError(t, read("fail"), "rpc error: code = Unknown desc = failed")
+
+	// A crash is reported with the plugin's last output, and the next call restarts and reconfigures the plugin.
+	err = read("crash")
+	var crash *PluginCrashError
+	if assert.True(t, errors.As(err, &crash)) {
+		assert.Equal(t, "\ngoroutine 1 [running]:\nmain.read()", crash.Stderr)
+		assert.Equal(t, codes.Unavailable, status.Code(crash.Err))
+	}
+	assert.NoError(t, read("ok"))
+	assert.Equal(t, 2, starts)
+
+	// Once the plugin is crashing repeatedly, it is no longer restarted.
+	assert.Error(t, read("crash"))
+	assert.Error(t, read("crash"))
+	assert.Equal(t, 3, starts)
+	err = read("ok")
+	if assert.True(t, errors.As(err, &crash)) {
+		assert.Contains(t, crash.Error(), "crashed 3 times")
+	}
+	assert.Equal(t, 3, starts)
+
+	// Stopping a plugin that has exited does not restart it.
+	_, err = s.Stop(context.Background(), &proto.Stop_Request{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, starts)
+}